@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// actionlintPrefix namespaces the rules of actionlint diagnostics among scharf findings
+const actionlintPrefix = "actionlint/"
+
+// ActionlintConfig sets how actionlint is run by --actionlint
+type ActionlintConfig struct {
+	// Path of the actionlint binary, looked up in PATH by default
+	Path string `yaml:"path"`
+	// Args are passed to actionlint before the workflow, e.g. ["-shellcheck="] to disable shellcheck
+	Args []string `yaml:"args"`
+}
+
+// actionlintDiagnostic is an entry of the JSON output of actionlint
+type actionlintDiagnostic struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Kind    string `json:"kind"`
+}
+
+// ActionlintRule runs actionlint on every workflow file and reports its diagnostics as
+// findings, so a single report covers both tools
+type ActionlintRule struct {
+	path string
+	args []string
+}
+
+// NewActionlintRule creates the rule, failing early if actionlint cannot be found
+func NewActionlintRule(cfg ActionlintConfig) (ActionlintRule, error) {
+	path := cfg.Path
+	if path == "" {
+		path = "actionlint"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return ActionlintRule{}, fmt.Errorf("actionlint: %w", err)
+	}
+	return ActionlintRule{path: resolved, args: cfg.Args}, nil
+}
+
+func (r ActionlintRule) ID() string {
+	return "actionlint"
+}
+
+func (r ActionlintRule) Check(wf *WorkflowFile) []Finding {
+	diags, err := r.run(wf)
+	if err != nil {
+		logger.Warn("actionlint failed", "file", wf.Path, "err", err)
+		return nil
+	}
+
+	var findings []Finding
+	for _, d := range diags {
+		findings = append(findings, Finding{
+			Rule:     actionlintPrefix + d.Kind,
+			Severity: actionlintSeverity(d.Kind),
+			Message:  d.Message,
+			Line:     d.Line,
+			Column:   d.Column,
+		})
+	}
+	return findings
+}
+
+// run lints the content of a workflow read from stdin, so files of any branch or remote
+// can be linted without writing them to disk
+func (r ActionlintRule) run(wf *WorkflowFile) ([]actionlintDiagnostic, error) {
+	args := append(append([]string{}, r.args...), "-format", "{{json .}}", "-stdin-filename", wf.Path, "-")
+	cmd := exec.Command(r.path, args...)
+	cmd.Stdin = bytes.NewReader(wf.Content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	// actionlint exits with 1 when it found problems and with higher codes on failure.
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var diags []actionlintDiagnostic
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return diags, nil
+}
+
+// actionlintSeverity maps the kind of an actionlint diagnostic to a severity. Scripts with
+// untrusted input in them are injection vectors, while shell lints are mostly style.
+func actionlintSeverity(kind string) string {
+	switch kind {
+	case "expression", "credentials", "permissions":
+		return SeverityHigh
+	case "shellcheck", "pyflakes":
+		return SeverityLow
+	default:
+		return SeverityMedium
+	}
+}
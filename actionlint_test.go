@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeActionlint writes a script standing in for actionlint. It records its arguments and
+// stdin next to itself, prints output and exits with code.
+func fakeActionlint(t *testing.T, output string, code int) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake actionlint is a shell script")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "actionlint")
+	script := "#!/bin/sh\necho \"$@\" > \"$0.args\"\ncat > \"$0.stdin\"\nprintf '%s' '" + output + "'\nexit " + strconv.Itoa(code) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, path + ".args"
+}
+
+// --- Tests for ActionlintRule.Check ---
+
+func TestActionlintRule_Check(t *testing.T) {
+	output := `[{"message":"\"github.event.issue.title\" is potentially untrusted","filepath":"wf.yml","line":7,"column":14,"kind":"expression"},` +
+		`{"message":"SC2086: Double quote to prevent globbing","filepath":"wf.yml","line":8,"column":9,"kind":"shellcheck"}]`
+	path, argsFile := fakeActionlint(t, output, 1)
+
+	rule, err := NewActionlintRule(ActionlintConfig{Path: path, Args: []string{"-shellcheck=shellcheck"}})
+	CheckIfError(err)
+	content := "on: push\njobs: {}\n"
+	findings := rule.Check(newWorkflowFile(".github/workflows/wf.yml", []byte(content)))
+
+	expected := []Finding{
+		{Rule: "actionlint/expression", Severity: SeverityHigh, Message: `"github.event.issue.title" is potentially untrusted`, Line: 7, Column: 14},
+		{Rule: "actionlint/shellcheck", Severity: SeverityLow, Message: "SC2086: Double quote to prevent globbing", Line: 8, Column: 9},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %+v", len(expected), findings)
+	}
+	for i := range expected {
+		if findings[i] != expected[i] {
+			t.Errorf("finding %d = %+v; want %+v", i, findings[i], expected[i])
+		}
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if !strings.HasPrefix(string(args), "-shellcheck=shellcheck -format {{json .}} -stdin-filename .github/workflows/wf.yml -") {
+		t.Errorf("unexpected arguments: %s", args)
+	}
+	stdin, _ := os.ReadFile(path + ".stdin")
+	if string(stdin) != content {
+		t.Errorf("workflow was not passed on stdin: %q", stdin)
+	}
+}
+
+func TestActionlintRule_CheckClean(t *testing.T) {
+	for _, output := range []string{"", "[]"} {
+		path, _ := fakeActionlint(t, output, 0)
+		rule, err := NewActionlintRule(ActionlintConfig{Path: path})
+		CheckIfError(err)
+		if findings := rule.Check(newWorkflowFile("wf.yml", []byte("on: push\n"))); len(findings) != 0 {
+			t.Errorf("expected no findings for output %q, got %+v", output, findings)
+		}
+	}
+}
+
+func TestActionlintRule_CheckFailure(t *testing.T) {
+	path, _ := fakeActionlint(t, "", 3)
+	rule, err := NewActionlintRule(ActionlintConfig{Path: path})
+	CheckIfError(err)
+	if _, err := rule.run(newWorkflowFile("wf.yml", []byte("on: push\n"))); err == nil {
+		t.Error("expected error when actionlint fails, got nil")
+	}
+}
+
+func TestNewActionlintRule_Missing(t *testing.T) {
+	if _, err := NewActionlintRule(ActionlintConfig{Path: filepath.Join(t.TempDir(), "actionlint")}); err == nil {
+		t.Error("expected error for a missing actionlint, got nil")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// argoDirs hold the Argo Workflows manifests of a repository by convention
+var argoDirs = []string{".argo", "argo", "workflows"}
+
+// argoKinds are the Argo resources running templates
+var argoKinds = map[string]bool{"Workflow": true, "WorkflowTemplate": true, "ClusterWorkflowTemplate": true, "CronWorkflow": true}
+
+// ArgoChecker flags the containers of the Argo Workflows manifests of a directory whose
+// image is not pinned to a digest. Templates run them with the service account of the
+// workflow and its artifact repository credentials, so whoever pushes the tag runs there.
+type ArgoChecker struct {
+	// Dir is the directory relative to the repository root
+	Dir string
+}
+
+// File is empty: Argo has no pipeline file, only the manifests of the directory
+func (c ArgoChecker) File() string {
+	return ""
+}
+
+func (c ArgoChecker) Directory() string {
+	return c.Dir
+}
+
+func (c ArgoChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, root := range wf.roots() {
+		// Other resources may sit next to the workflows in the directory.
+		api, kind := mappingValue(root, "apiVersion"), mappingValue(root, "kind")
+		if api == nil || !strings.HasPrefix(api.Value, "argoproj.io/") || kind == nil || !argoKinds[kind.Value] {
+			continue
+		}
+		for _, img := range argoImages(root) {
+			findings = append(findings, unpinnedImageFinding(wf, img)...)
+		}
+	}
+	return findings
+}
+
+// argoImages returns the images of the container and script templates of a manifest, of
+// their init containers and sidecars, of container sets and of the template defaults.
+// Images set through workflow parameters cannot be told.
+func argoImages(root *yaml.Node) []*yaml.Node {
+	var images []*yaml.Node
+	seen := map[*yaml.Node]bool{}
+	add := func(container *yaml.Node) {
+		img := mappingValue(container, "image")
+		if img == nil || img.Kind != yaml.ScalarNode || img.Value == "" || strings.Contains(img.Value, "{{") || seen[img] {
+			return
+		}
+		seen[img] = true
+		images = append(images, img)
+	}
+
+	visited := map[*yaml.Node]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n == nil || visited[n] {
+			return
+		}
+		visited[n] = true
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				switch p.Key.Value {
+				case "container", "script":
+					add(resolveAlias(p.Value))
+				case "containers", "initContainers", "sidecars":
+					if list := resolveAlias(p.Value); list.Kind == yaml.SequenceNode {
+						for _, c := range list.Content {
+							add(resolveAlias(c))
+						}
+					}
+				}
+				walk(p.Value)
+			}
+		}
+	}
+	walk(root)
+	return images
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// --- Tests for ArgoChecker.Check ---
+
+func TestArgoChecker_Check(t *testing.T) {
+	content := `apiVersion: argoproj.io/v1alpha1
+kind: WorkflowTemplate
+metadata:
+  name: build
+spec:
+  templateDefaults:
+    container:
+      image: busybox
+  templates:
+    - name: compile
+      container:
+        image: golang:1.22
+      initContainers:
+        - name: fetch
+          image: alpine/git:latest
+      sidecars:
+        - name: docker
+          image: docker@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+    - name: report
+      script:
+        image: python:3.12
+        source: print("done")
+    - name: parallel
+      containerSet:
+        containers:
+          - name: a
+            image: ghcr.io/acme/tool:v1
+          - name: b
+            image: "{{inputs.parameters.image}}"
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: unrelated
+spec:
+  containers:
+    - name: app
+      image: nginx
+---
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: nightly
+spec:
+  workflowSpec:
+    templates:
+      - name: main
+        container:
+          image: alpine:3
+`
+	findings := ArgoChecker{Dir: "argo"}.Check(newWorkflowFile("argo/build.yaml", []byte(content)))
+
+	expected := map[int]bool{8: true, 12: true, 15: true, 21: true, 27: true, 49: true}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if !expected[f.Line] || f.Rule != ruleUnpinnedImage || f.Severity != SeverityMedium {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with Argo suppressions ---
+
+func TestScanner_ScanBranchArgoSuppressions(t *testing.T) {
+	root := filepath.Join("ws", "repo")
+	repo := fakeRepository{
+		name:  "repo",
+		files: []string{"ci.yaml"},
+		fileContents: map[string][]byte{
+			filepath.Join(root, "argo", "ci.yaml"): []byte(`apiVersion: argoproj.io/v1alpha1
+kind: Workflow
+spec:
+  templates:
+    - name: test
+      container:
+        image: golang:1.22 # scharf-ignore: unpinned-image rebuilt nightly from source
+    - name: lint
+      container:
+        image: golangci/golangci-lint:v1.59
+`),
+		},
+	}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Pipelines: []PipelineChecker{ArgoChecker{Dir: "argo"}}}
+	records := sc.ScanBranch("main", repo, regexp.MustCompile("@v"), workflowDir(root))
+
+	if len(records) != 1 || len(records[0].Findings) != 1 || len(records[0].Suppressed) != 1 {
+		t.Fatalf("expected one finding and one suppressed, got %+v", records)
+	}
+	if records[0].Findings[0].Line != 10 {
+		t.Errorf("unexpected finding %+v", records[0].Findings[0])
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ruleSensitiveArtifact flags artifacts uploading files likely to hold secrets
+	ruleSensitiveArtifact = "sensitive-artifact"
+	// ruleArtifactRetention flags artifacts kept longer than the policy allows
+	ruleArtifactRetention = "artifact-retention"
+)
+
+// defaultMaxRetentionDays is how long artifacts may be kept when the policy does not say
+const defaultMaxRetentionDays = 30
+
+// sensitiveArtifactPaths are globs of the base names of files and directories holding
+// credentials
+var sensitiveArtifactPaths = []string{
+	".env", ".env.*", "*.env", ".aws", ".ssh", ".gnupg", ".kube", "kubeconfig", ".docker",
+	".npmrc", ".pypirc", ".netrc", ".git-credentials", "credentials", "credentials.json",
+	"id_rsa", "id_ed25519", "*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.tfstate", "*.tfvars",
+	".git",
+}
+
+// workspaceArtifactPaths upload the whole checkout, including .git/config where
+// actions/checkout persists the token of the job unless persist-credentials is false
+var workspaceArtifactPaths = map[string]bool{
+	".": true, "./": true, "*": true, "**": true, "./*": true, "./**": true, "**/*": true,
+	"${{ github.workspace }}": true, "${{ github.workspace }}/": true, "$GITHUB_WORKSPACE": true,
+}
+
+// ArtifactsConfig sets the policy of --check-artifacts
+type ArtifactsConfig struct {
+	// MaxRetentionDays flags artifacts kept longer. Defaults to 30.
+	MaxRetentionDays int `yaml:"max_retention_days"`
+}
+
+// ArtifactRule flags actions/upload-artifact steps uploading paths likely to hold secrets,
+// and artifacts kept longer than the policy allows. Anyone with read access to the
+// repository can download artifacts, public repositories included.
+type ArtifactRule struct {
+	Policy ArtifactsConfig
+}
+
+func (r ArtifactRule) ID() string {
+	return ruleSensitiveArtifact
+}
+
+func (r ArtifactRule) Check(wf *WorkflowFile) []Finding {
+	maxDays := r.Policy.MaxRetentionDays
+	if maxDays <= 0 {
+		maxDays = defaultMaxRetentionDays
+	}
+
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			step = resolveAlias(step)
+			uses := mappingValue(step, "uses")
+			if uses == nil || strings.ToLower(splitRawAction(uses.Value)[0]) != "actions/upload-artifact" {
+				continue
+			}
+			with := mappingValue(step, "with")
+
+			if p := mappingValue(with, "path"); p != nil {
+				for _, line := range strings.Split(p.Value, "\n") {
+					line = strings.TrimSpace(line)
+					if line == "" || strings.HasPrefix(line, "!") {
+						continue
+					}
+					switch {
+					case workspaceArtifactPaths[line]:
+						findings = append(findings, wf.finding(ruleSensitiveArtifact, SeverityHigh, p, fmt.Sprintf(
+							"job %s uploads the whole workspace %s as an artifact, including .git/config where actions/checkout persists the job token. Upload only the build output",
+							job.ID, line)))
+					case isSensitiveArtifactPath(line):
+						findings = append(findings, wf.finding(ruleSensitiveArtifact, SeverityHigh, p, fmt.Sprintf(
+							"job %s uploads %s as an artifact, which likely holds credentials readable by anyone with read access to the repository",
+							job.ID, line)))
+					}
+				}
+			}
+
+			if days := mappingValue(with, "retention-days"); days != nil {
+				if n, err := strconv.Atoi(days.Value); err == nil && n > maxDays {
+					findings = append(findings, wf.finding(ruleArtifactRetention, SeverityLow, days, fmt.Sprintf(
+						"job %s keeps its artifact for %d days, above the %d allowed. Ex: retention-days: %d", job.ID, n, maxDays, maxDays)))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// isSensitiveArtifactPath reports whether any element of an uploaded path names a file or
+// directory holding credentials, such as ~/.aws/credentials or config/.env. Templates such
+// as .env.example hold none.
+func isSensitiveArtifactPath(p string) bool {
+	for _, elem := range strings.Split(strings.TrimSuffix(p, "/"), "/") {
+		switch path.Ext(elem) {
+		case ".example", ".sample", ".template", ".dist":
+			continue
+		}
+		if matchesAny(sensitiveArtifactPaths, elem) {
+			return true
+		}
+	}
+	return false
+}
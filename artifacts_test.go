@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for ArtifactRule.Check ---
+
+func TestArtifactRule_Check(t *testing.T) {
+	content := `jobs:
+  build:
+    steps:
+      - uses: actions/upload-artifact@v4
+        with:
+          path: dist/
+          retention-days: 7
+      - uses: actions/upload-artifact@v4
+        with:
+          path: .
+      - uses: actions/upload-artifact@v4
+        with:
+          path: |
+            build/
+            config/.env
+            !config/.env.local
+            .env.example
+          retention-days: 90
+      - uses: actions/upload-artifact@v4
+        with:
+          path: ~/.aws/credentials
+      - uses: actions/download-artifact@v4
+        with:
+          path: .
+`
+	findings := ArtifactRule{}.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	expected := map[int]string{
+		10: ruleSensitiveArtifact, // workspace
+		13: ruleSensitiveArtifact, // config/.env
+		18: ruleArtifactRetention,
+		21: ruleSensitiveArtifact, // ~/.aws
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+
+	// A longer retention is allowed by the policy.
+	if findings := (ArtifactRule{Policy: ArtifactsConfig{MaxRetentionDays: 90}}).Check(newWorkflowFile("wf.yml", []byte(content))); len(findings) != 3 {
+		t.Errorf("expected 3 findings with a retention of 90 days allowed, got %+v", findings)
+	}
+}
+
+// --- Tests for isSensitiveArtifactPath ---
+
+func TestIsSensitiveArtifactPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"~/.ssh/id_rsa", true},
+		{"deploy/prod.tfvars", true},
+		{"certs/server.pem", true},
+		{".env.production", true},
+		{"coverage/lcov.info", false},
+		{".env.example", false},
+		{"docs/environments.md", false},
+	}
+
+	for _, tc := range tests {
+		if got := isSensitiveArtifactPath(tc.path); got != tc.expected {
+			t.Errorf("isSensitiveArtifactPath(%q) = %v; want %v", tc.path, got, tc.expected)
+		}
+	}
+}
@@ -3,63 +3,53 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strings"
 )
 
-// AuditRepository collects inventory details from current Git repository.
-func AuditRepository(regex *regexp.Regexp) (*Inventory, error) {
+// AuditRepository collects inventory details from current Git repository, or from the
+// revision of s.Ref when set, read from the object store without touching the worktree.
+// The FileScanner defaults to GitHubWorkFlowScanner when unset.
+func (s *Scanner) AuditRepository(regex *regexp.Regexp) (*Inventory, error) {
 
 	if !IsGitRepo(".") {
 		return nil, fmt.Errorf("The current directory is not a Git repository")
 	}
 
-	var inventory Inventory
 	absPath, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("dir error: %w", err)
 	}
 
-	paths := strings.Split(absPath, "/")
 	repo := &GitRepository{
 		localPath: absPath,
-		name:      paths[len(paths)-1],
+		name:      filepath.Base(absPath),
 	}
-	workflowPath := fmt.Sprintf("%s/.github/workflows", absPath)
 
-	fileNames, err := repo.ListFiles(workflowPath)
-	if err != nil {
-		return nil, fmt.Errorf("file error: %w", err)
+	if s.FileScanner == nil {
+		s.FileScanner = GitHubWorkFlowScanner{}
 	}
-
-	// Process each file found in the directory.
-	for _, fileName := range fileNames {
-		fPath := fmt.Sprintf("%s/%s", workflowPath, fileName)
-		content, err := repo.ReadFile(fPath)
-		if err != nil {
-			return nil, fmt.Errorf("file error: %w", err)
-		}
-
-		found := regex.FindAll([]byte(content), -1)
-		var matches []string
-		for _, match := range found {
-			matches = append(matches, string(match))
-		}
-
-		b, err := GetCurrentBranch(absPath)
+	ref := s.Ref
+	if ref == "" && IsBareRepo(absPath) {
+		// A bare repository has no worktree to read, so HEAD is read from the object store.
+		ref = "HEAD"
+	}
+	if ref != "" {
+		at, err := repo.AtBranch(ref)
 		if err != nil {
-			return nil, fmt.Errorf("git error: %w", err)
+			return nil, err
 		}
+		return &Inventory{
+			Records: s.ScanBranch(ref, at, regex, workflowDir(absPath)),
+		}, nil
+	}
 
-		if len(matches) > 0 {
-			inventory.Records = append(inventory.Records, &InventoryRecord{
-				Repository: repo.Name(),
-				Branch:     b,
-				FilePath:   fPath,
-				Matches:    matches,
-			})
-		}
+	b, err := GetCurrentBranch(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
 	}
 
-	return &inventory, nil
+	return &Inventory{
+		Records: s.ScanBranch(b, repo, regex, workflowDir(absPath)),
+	}, nil
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// --- Tests for Scanner.AuditRepository with Ref ---
+
+func TestScanner_AuditRepositoryRef(t *testing.T) {
+	root, repo := branchedRepo(t)
+	feature, err := repo.ResolveRevision("feature")
+	CheckIfError(err)
+	_, err = repo.CreateTag("v1", *feature, nil)
+	CheckIfError(err)
+	t.Chdir(filepath.Join(root, "repo"))
+
+	cacheMatches := func(inv *Inventory) int {
+		for _, ir := range inv.Records {
+			if filepath.Base(ir.FilePath) == "ci.yml" {
+				return len(ir.Matches)
+			}
+		}
+		return 0
+	}
+
+	tests := []struct {
+		ref     string
+		matches int
+	}{
+		{"", 1}, // the worktree, on master
+		{"feature", 2},
+		{"v1", 2},
+		{feature.String(), 2},
+		{feature.String()[:10], 2},
+		{"master", 1},
+	}
+	for _, tc := range tests {
+		sc := Scanner{Ref: tc.ref}
+		inv, err := sc.AuditRepository(mutableRefRegex)
+		if err != nil {
+			t.Errorf("ref %q: unexpected error %v", tc.ref, err)
+			continue
+		}
+		if got := cacheMatches(inv); got != tc.matches {
+			t.Errorf("ref %q: expected %d mutable references in ci.yml, got %d", tc.ref, tc.matches, got)
+		}
+		if tc.ref != "" && inv.Records[0].Branch != tc.ref {
+			t.Errorf("ref %q: expected records of the ref, got branch %s", tc.ref, inv.Records[0].Branch)
+		}
+	}
+
+	if _, err := (&Scanner{Ref: "nope"}).AuditRepository(mutableRefRegex); err == nil {
+		t.Error("expected an error for a ref that does not resolve")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// fileModification tells who last changed a workflow file, when, and from where
+type fileModification struct {
+	Commit string    `json:"commit"`
+	Actor  string    `json:"actor"`
+	At     time.Time `json:"at"`
+	// PushedAt, IP and Country come from the audit log entry of the push that brought the
+	// commit in, when one is found. IP needs IP disclosure enabled for the organization.
+	PushedAt time.Time `json:"pushed_at,omitzero"`
+	IP       string    `json:"ip,omitempty"`
+	Country  string    `json:"country,omitempty"`
+}
+
+// From renders where the modification was pushed from, if known
+func (m *fileModification) From() string {
+	switch {
+	case m.IP != "" && m.Country != "":
+		return fmt.Sprintf("%s (%s)", m.IP, m.Country)
+	case m.IP != "":
+		return m.IP
+	}
+	return m.Country
+}
+
+// shortSHA abbreviates a commit SHA the way git does
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// auditLogEntry is an event of the organization or enterprise audit log
+type auditLogEntry struct {
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	Repo      string `json:"repo"`
+	Timestamp int64  `json:"@timestamp"`
+	ActorIP   string `json:"actor_ip"`
+	Location  struct {
+		CountryCode string `json:"country_code"`
+	} `json:"actor_location"`
+}
+
+// time returns when the event happened. The audit log counts milliseconds.
+func (e auditLogEntry) time() time.Time {
+	return time.UnixMilli(e.Timestamp)
+}
+
+// auditLog correlates workflow files with the commits and audit log events that last
+// modified them
+type auditLog struct {
+	// Enterprise is the slug of the enterprise whose audit log is read. The audit log of the
+	// organization owning each repository is read when it is empty.
+	Enterprise string
+}
+
+// lastCommit returns the latest commit of a branch that modified a file
+func lastCommit(owner, name, branch, path string) (*fileModification, error) {
+	query := url.Values{"path": {path}, "per_page": {"1"}}
+	if branch != "" {
+		query.Set("sha", branch)
+	}
+	var commits []struct {
+		SHA    string `json:"sha"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		Commit struct {
+			Author struct {
+				Name string    `json:"name"`
+				Date time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+	}
+	found, err := getJSON(fmt.Sprintf("%s/%s/%s/commits?%s", apiURL, owner, name, query.Encode()), &commits)
+	if err != nil || !found || len(commits) == 0 {
+		return nil, err
+	}
+	c := commits[0]
+	m := &fileModification{Commit: c.SHA, Actor: c.Commit.Author.Name, At: c.Commit.Author.Date}
+	if c.Author != nil && c.Author.Login != "" {
+		m.Actor = c.Author.Login
+	}
+	return m, nil
+}
+
+// pushOf finds the audit log entry of the first push by the author of a commit to the
+// repository after the commit was authored
+func (a auditLog) pushOf(owner, name string, m *fileModification) (*auditLogEntry, error) {
+	endpoint := fmt.Sprintf("%s/%s/audit-log", orgsAPIURL, owner)
+	if a.Enterprise != "" {
+		endpoint = fmt.Sprintf("%s/%s/audit-log", enterprisesAPIURL, a.Enterprise)
+	}
+	phrase := fmt.Sprintf("action:git.push repo:%s/%s actor:%s created:>=%s", owner, name, m.Actor, m.At.UTC().Format("2006-01-02"))
+	query := url.Values{"phrase": {phrase}, "include": {"git"}, "per_page": {"100"}}
+
+	var entries []auditLogEntry
+	found, err := getJSON(endpoint+"?"+query.Encode(), &entries)
+	if err != nil || !found {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Timestamp < entries[j].Timestamp })
+	for i, e := range entries {
+		if !e.time().Before(m.At) {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// lastModified returns who last modified a workflow file of a repository, and from where
+// when the audit log tells. An unreadable audit log, which needs an owner token, leaves the
+// commit alone.
+func (a auditLog) lastModified(owner, name, branch, path string) (*fileModification, error) {
+	m, err := lastCommit(owner, name, branch, path)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	push, err := a.pushOf(owner, name, m)
+	if err != nil {
+		logger.Warn("could not read the audit log", "repo", owner+"/"+name, "err", err)
+		return m, nil
+	}
+	if push != nil {
+		m.PushedAt, m.IP, m.Country = push.time().UTC(), push.ActorIP, push.Location.CountryCode
+	}
+	return m, nil
+}
+
+// repoOfFunc returns the GitHub repository checked out at a local root
+type repoOfFunc func(root string) (owner, name string, err error)
+
+// originRepo identifies a local clone by its origin remote
+func originRepo(root string) (string, string, error) {
+	remote, err := GetRemoteURL(root, "origin")
+	if err != nil {
+		return "", "", err
+	}
+	return parseGitHubRemote(remote)
+}
+
+// Correlate sets who last modified the file of each record with issues, in the repository
+// repoOf identifies from the root of the record
+func (a auditLog) Correlate(inv *Inventory, repoOf repoOfFunc) {
+	for _, ir := range inv.Records {
+		if len(ir.Locations) == 0 && len(ir.Findings) == 0 {
+			continue
+		}
+		root := ir.repoRoot()
+		owner, name, err := repoOf(root)
+		if err != nil {
+			logger.Debug("skipping audit log correlation of a repository not on GitHub", "repo", ir.Repository, "err", err)
+			continue
+		}
+		m, err := a.lastModified(owner, name, ir.Branch, relativePath(root, ir.FilePath))
+		if err != nil {
+			logger.Warn("could not find who last modified the workflow", "file", ir.FilePath, "err", err)
+			continue
+		}
+		ir.LastModified = m
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// auditLogTransport answers the commits of .github/workflows/ci.yml in org/app and the audit
+// log with the given status
+func auditLogTransport(t *testing.T, auditStatus int) roundTripFunc {
+	pushed := time.Date(2025, 3, 14, 10, 5, 0, 0, time.UTC).UnixMilli()
+	earlier := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC).UnixMilli()
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusNotFound, `{}`
+		switch req.URL.Path {
+		case "/repos/org/app/commits":
+			if req.URL.Query().Get("path") != ".github/workflows/ci.yml" || req.URL.Query().Get("sha") != "main" {
+				t.Errorf("unexpected commits query %s", req.URL.RawQuery)
+			}
+			status, body = http.StatusOK, `[{"sha": "0123456789abcdef", "author": {"login": "mallory"}, "commit": {"author": {"name": "Mallory", "date": "2025-03-14T10:00:00Z"}}}]`
+		case "/orgs/org/audit-log", "/enterprises/acme/audit-log":
+			if !strings.Contains(req.URL.Query().Get("phrase"), "repo:org/app actor:mallory") {
+				t.Errorf("unexpected audit log phrase %q", req.URL.Query().Get("phrase"))
+			}
+			status, body = auditStatus, fmt.Sprintf(`[
+				{"action": "git.push", "actor": "mallory", "@timestamp": %d, "actor_ip": "203.0.113.7", "actor_location": {"country_code": "NL"}},
+				{"action": "git.push", "actor": "mallory", "@timestamp": %d, "actor_ip": "198.51.100.1"}
+			]`, pushed, earlier)
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+}
+
+// --- Tests for auditLog.Correlate ---
+
+func TestAuditLogCorrelate(t *testing.T) {
+	repoOf := func(root string) (string, string, error) {
+		if root != "." {
+			t.Errorf("unexpected repository root %q", root)
+		}
+		return "org", "app", nil
+	}
+
+	tests := []struct {
+		name        string
+		enterprise  string
+		auditStatus int
+		expectedIP  string
+	}{
+		{"organization audit log", "", http.StatusOK, "203.0.113.7 (NL)"},
+		{"enterprise audit log", "acme", http.StatusOK, "203.0.113.7 (NL)"},
+		{"unreadable audit log keeps the commit", "", http.StatusForbidden, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inv := &Inventory{Records: []*InventoryRecord{
+				{Repository: "org/app", Branch: "main", FilePath: ".github/workflows/ci.yml", Locations: []Match{{Value: "actions/checkout@v4", Line: 3}}},
+				{Repository: "org/app", Branch: "main", FilePath: ".github/workflows/clean.yml"},
+			}}
+			withHTTPClientTransport(auditLogTransport(t, tc.auditStatus), func() {
+				auditLog{Enterprise: tc.enterprise}.Correlate(inv, repoOf)
+			})
+
+			m := inv.Records[0].LastModified
+			if m == nil || m.Actor != "mallory" || m.Commit != "0123456789abcdef" {
+				t.Fatalf("unexpected modification %+v", m)
+			}
+			if m.From() != tc.expectedIP {
+				t.Errorf("From() = %q; want %q", m.From(), tc.expectedIP)
+			}
+			if inv.Records[1].LastModified != nil {
+				t.Errorf("expected records without issues to be left alone")
+			}
+		})
+	}
+}
+
+// --- Tests for the Last modified section of reports ---
+
+func TestReports_LastModified(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{{
+		Repository:   "org/app",
+		FilePath:     ".github/workflows/ci.yml",
+		Locations:    []Match{{Value: "actions/checkout@v4", Line: 3}},
+		LastModified: &fileModification{Commit: "0123456789abcdef", Actor: "mallory", At: time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)},
+	}}}
+
+	var b strings.Builder
+	CheckIfError(writeMarkdownReport(&b, inv))
+	if !strings.Contains(b.String(), "| org/app | .github/workflows/ci.yml | `0123456` | mallory | 2025-03-14T10:00:00Z | unknown |") {
+		t.Errorf("expected the last modification in the report:\n%s", b.String())
+	}
+
+	b.Reset()
+	CheckIfError(writeHTMLReport(&b, inv))
+	if !strings.Contains(b.String(), "<td><code>0123456</code></td><td>mallory</td><td>2025-03-14T10:00:00Z</td><td>unknown</td>") {
+		t.Errorf("expected the last modification in the HTML report:\n%s", b.String())
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checksPollInterval is how often check runs are polled before merging a fix PR
+var checksPollInterval = 15 * time.Second
+
+// pinOnlyChange reports whether fixed differs from original only by `uses:` references
+// repinned to a commit SHA, for actions of the given owners. Such changes cannot alter
+// what a workflow does beyond the action versions, which makes them safe to merge unattended.
+func pinOnlyChange(original, fixed []byte, owners []string) bool {
+	var removed, added []string
+	for _, op := range diffLines(splitLines(string(original)), splitLines(string(fixed))) {
+		switch op.kind {
+		case '-':
+			removed = append(removed, op.line)
+		case '+':
+			added = append(added, op.line)
+		}
+	}
+	if len(removed) != len(added) {
+		return false
+	}
+
+	for i := range removed {
+		before, after := usesRef.FindStringSubmatchIndex(removed[i]), usesRef.FindStringSubmatchIndex(added[i])
+		if before == nil || after == nil {
+			return false
+		}
+		action := added[i][after[2]:after[3]]
+		ref := added[i][after[4]:after[5]]
+		if removed[i][before[2]:before[3]] != action || !pinnedRef.MatchString("@"+ref) || !ownerAllowed(action, owners) {
+			return false
+		}
+		// Everything around the reference must be unchanged.
+		if removed[i][:before[0]] != added[i][:after[0]] || removed[i][before[1]:] != added[i][after[1]:] {
+			return false
+		}
+	}
+	return len(added) > 0
+}
+
+// ownerAllowed reports whether the owner of an action is in the allowlist
+func ownerAllowed(action string, owners []string) bool {
+	owner, _, _ := strings.Cut(action, "/")
+	for _, o := range owners {
+		if strings.EqualFold(o, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// autoMergeEligible reports whether every fix only repins actions of allowlisted owners
+func autoMergeEligible(results []FixResult, owners []string) bool {
+	for _, r := range results {
+		if !pinOnlyChange(r.Original, r.Fixed, owners) {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// enableAutoMerge turns on auto-merge for a pull request, so GitHub squashes it once the
+// required checks pass. The repository must allow auto-merge.
+func enableAutoMerge(pr *PullRequest) error {
+	query := `mutation($id: ID!) { enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: SQUASH}) { clientMutationId } }`
+	resp, err := githubSend(http.MethodPost, graphqlURL, map[string]any{
+		"query":     query,
+		"variables": map[string]string{"id": pr.NodeID},
+	})
+	if err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(body.Errors) > 0 {
+		msg := resp.Status
+		if len(body.Errors) > 0 {
+			msg = body.Errors[0].Message
+		}
+		return fmt.Errorf("http: enabling auto-merge: %s", msg)
+	}
+	return nil
+}
+
+// checkRun is a check of a commit
+type checkRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+}
+
+// checksPassed reports whether all checks of a commit completed successfully: its check
+// runs, and the commit statuses set by CI systems outside of GitHub Actions. It returns an
+// error as soon as one fails, and false while some are still running or none was reported
+// yet, as checks are queued a while after the push.
+func checksPassed(owner, name, sha string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/commits/%s", apiURL, owner, name, sha)
+
+	var runs []checkRun
+	for page := 1; page <= maxPages; page++ {
+		var body struct {
+			CheckRuns []checkRun `json:"check_runs"`
+		}
+		found, err := getJSON(fmt.Sprintf("%s/check-runs?per_page=100&page=%d", endpoint, page), &body)
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			return false, fmt.Errorf("http: checks of %s were not found", sha)
+		}
+		runs = append(runs, body.CheckRuns...)
+		if len(body.CheckRuns) < 100 {
+			break
+		}
+	}
+	for _, c := range runs {
+		if c.Status != "completed" {
+			return false, nil
+		}
+		switch c.Conclusion {
+		case "success", "neutral", "skipped":
+		default:
+			return false, fmt.Errorf("check %s concluded %s", c.Name, c.Conclusion)
+		}
+	}
+
+	// The combined status is pending while no status was set, so it only counts once one was.
+	var status struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	found, err := getJSON(endpoint+"/status", &status)
+	if err != nil {
+		return false, err
+	}
+	if found && status.TotalCount > 0 {
+		switch status.State {
+		case "success":
+		case "pending":
+			return false, nil
+		default:
+			return false, fmt.Errorf("commit status of %s is %s", sha, status.State)
+		}
+	}
+	return len(runs) > 0 || (found && status.TotalCount > 0), nil
+}
+
+// mergeWhenGreen waits for the checks of a pull request to pass and squash merges it
+func mergeWhenGreen(owner, name string, pr *PullRequest, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		passed, err := checksPassed(owner, name, pr.Head.SHA)
+		if err != nil {
+			return err
+		}
+		if passed {
+			break
+		}
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for checks to pass")
+		}
+		time.Sleep(checksPollInterval)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s/pulls/%d/merge", apiURL, owner, name, pr.Number)
+	resp, err := githubSend(http.MethodPut, endpoint, map[string]string{"merge_method": "squash", "sha": pr.Head.SHA})
+	if err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http: merging pull request #%d returned %s", pr.Number, resp.Status)
+	}
+	return nil
+}
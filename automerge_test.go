@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const checkoutSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+
+// --- Tests for pinOnlyChange ---
+
+func TestPinOnlyChange(t *testing.T) {
+	original := "steps:\n  - uses: actions/checkout@v4\n  - uses: other/action@v1\n    with:\n      a: b\n"
+	owners := []string{"actions", "Other"}
+
+	tests := []struct {
+		name     string
+		fixed    string
+		expected bool
+	}{
+		{name: "pin with comment", fixed: strings.Replace(original, "checkout@v4", "checkout@"+checkoutSHA+" # v4", 1), expected: true},
+		{name: "pin of every allowlisted owner", fixed: strings.NewReplacer("checkout@v4", "checkout@"+checkoutSHA, "action@v1", "action@"+checkoutSHA).Replace(original), expected: true},
+		{name: "owner not allowlisted", fixed: strings.Replace(original, "checkout@v4", "checkout@"+checkoutSHA, 1), expected: false},
+		{name: "bump to another tag", fixed: strings.Replace(original, "checkout@v4", "checkout@v5", 1), expected: false},
+		{name: "other action", fixed: strings.Replace(original, "actions/checkout@v4", "evil/checkout@"+checkoutSHA, 1), expected: false},
+		{name: "other changes", fixed: strings.Replace(original, "a: b", "a: c", 1), expected: false},
+		{name: "unchanged", fixed: original, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			allow := owners
+			if tc.name == "owner not allowlisted" {
+				allow = []string{"github"}
+			}
+			if got := pinOnlyChange([]byte(original), []byte(tc.fixed), allow); got != tc.expected {
+				t.Errorf("pinOnlyChange = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for mergeWhenGreen ---
+
+func TestMergeWhenGreen(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "token")
+	orig := checksPollInterval
+	checksPollInterval = time.Millisecond
+	defer func() { checksPollInterval = orig }()
+
+	pr := &PullRequest{Number: 7}
+	pr.Head.SHA = checkoutSHA
+
+	polls, merged := 0, false
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		respond := func(status int, body string) (*http.Response, error) {
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+		}
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/commits/"+checkoutSHA+"/check-runs"):
+			polls++
+			if polls == 1 {
+				return respond(http.StatusOK, `{"check_runs": [{"name": "test", "status": "in_progress"}]}`)
+			}
+			return respond(http.StatusOK, `{"check_runs": [{"name": "test", "status": "completed", "conclusion": "success"}]}`)
+		case strings.HasSuffix(req.URL.Path, "/commits/"+checkoutSHA+"/status"):
+			return respond(http.StatusOK, `{"state": "pending", "total_count": 0}`)
+		case req.Method == http.MethodPut && req.URL.Path == "/repos/owner/repo/pulls/7/merge":
+			merged = true
+			return respond(http.StatusOK, `{"merged": true}`)
+		}
+		t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		return respond(http.StatusNotFound, "")
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if err := mergeWhenGreen("owner", "repo", pr, time.Minute); err != nil {
+			t.Fatalf("mergeWhenGreen returned error: %v", err)
+		}
+	})
+	if polls != 2 || !merged {
+		t.Errorf("expected 2 polls and a merge, got %d polls (merged %v)", polls, merged)
+	}
+}
+
+func TestMergeWhenGreen_FailedChecks(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			t.Errorf("must not merge when checks fail, got %s %s", req.Method, req.URL)
+		}
+		body := `{"check_runs": [{"name": "test", "status": "completed", "conclusion": "failure"}]}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if err := mergeWhenGreen("owner", "repo", &PullRequest{Number: 7}, time.Minute); err == nil {
+			t.Error("expected error when checks fail, got nil")
+		}
+	})
+}
+
+// --- Tests for checksPassed ---
+
+func TestChecksPassed(t *testing.T) {
+	completed := `{"name": "test", "status": "completed", "conclusion": "success"}`
+	fullPage := `{"check_runs": [` + strings.TrimSuffix(strings.Repeat(completed+",", 100), ",") + `]}`
+
+	tests := []struct {
+		name     string
+		runs     []string
+		status   string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "no checks yet", runs: []string{`{"check_runs": []}`}, status: `{"state": "pending", "total_count": 0}`, expected: false},
+		{name: "check runs passed", runs: []string{`{"check_runs": [` + completed + `]}`}, status: `{"state": "pending", "total_count": 0}`, expected: true},
+		{name: "statuses passed", runs: []string{`{"check_runs": []}`}, status: `{"state": "success", "total_count": 1}`, expected: true},
+		{name: "status pending", runs: []string{`{"check_runs": [` + completed + `]}`}, status: `{"state": "pending", "total_count": 1}`, expected: false},
+		{name: "status failed", runs: []string{`{"check_runs": [` + completed + `]}`}, status: `{"state": "failure", "total_count": 1}`, wantErr: true},
+		{name: "run running past the first page", runs: []string{fullPage, `{"check_runs": [{"name": "last", "status": "queued"}]}`}, status: `{"state": "success", "total_count": 1}`, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				body := tc.status
+				if strings.HasSuffix(req.URL.Path, "/check-runs") {
+					page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+					if page < 1 || page > len(tc.runs) {
+						t.Errorf("unexpected page %s", req.URL)
+						return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Header: make(http.Header)}, nil
+					}
+					body = tc.runs[page-1]
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+			})
+			withHTTPClientTransport(customTransport, func() {
+				passed, err := checksPassed("owner", "repo", checkoutSHA)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("checksPassed error = %v; wantErr %v", err, tc.wantErr)
+				}
+				if passed != tc.expected {
+					t.Errorf("checksPassed = %v; want %v", passed, tc.expected)
+				}
+			})
+		})
+	}
+}
+
+// --- Tests for enableAutoMerge ---
+
+func TestEnableAutoMerge(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	for _, tc := range []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "enabled", body: `{"data": {"enablePullRequestAutoMerge": {"clientMutationId": null}}}`},
+		{name: "not allowed", body: `{"errors": [{"message": "Auto merge is not allowed for this repository"}]}`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(req.Body)
+				if req.URL.String() != graphqlURL || !strings.Contains(string(b), `"id":"PR_node"`) {
+					t.Errorf("unexpected request %s: %s", req.URL, b)
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(tc.body))), Header: make(http.Header)}, nil
+			})
+			withHTTPClientTransport(customTransport, func() {
+				err := enableAutoMerge(&PullRequest{NodeID: "PR_node"})
+				if (err != nil) != tc.wantErr {
+					t.Errorf("enableAutoMerge error = %v; wantErr %v", err, tc.wantErr)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// azurePipelinesFile is the pipeline file of Azure Pipelines at the root of a repository
+const azurePipelinesFile = "azure-pipelines.yml"
+
+const (
+	// ruleUnpinnedResource flags repository resources following a branch or tag
+	ruleUnpinnedResource = "unpinned-repository-resource"
+	// ruleUnversionedTask flags tasks without a version
+	ruleUnversionedTask = "unversioned-task"
+)
+
+// AzurePipelinesChecker flags repository resources of Azure Pipelines not pinned to a commit,
+// whose templates and code run in the pipeline, and tasks without a version, which pick up
+// any new major version published to the marketplace.
+type AzurePipelinesChecker struct{}
+
+func (c AzurePipelinesChecker) File() string {
+	return azurePipelinesFile
+}
+
+func (c AzurePipelinesChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, root := range wf.roots() {
+		repos := resolveAlias(mappingValue(mappingValue(root, "resources"), "repositories"))
+		if repos != nil && repos.Kind == yaml.SequenceNode {
+			for _, r := range repos.Content {
+				findings = append(findings, c.checkRepository(wf, resolveAlias(r))...)
+			}
+		}
+	}
+
+	for _, task := range azureKeys(wf, "task") {
+		if strings.Contains(task.Value, "$") || strings.Contains(task.Value, "@") {
+			continue
+		}
+		findings = append(findings, wf.finding(ruleUnversionedTask, SeverityMedium, task, fmt.Sprintf(
+			"task %s has no version and runs whatever major version is published next. Ex: task: %s@2", task.Value, task.Value)))
+	}
+	return findings
+}
+
+// checkRepository flags a repository resource following its default branch, a branch or a
+// tag. Only a commit SHA keeps the templates it provides from changing.
+func (c AzurePipelinesChecker) checkRepository(wf *WorkflowFile, repo *yaml.Node) []Finding {
+	alias, name := mappingValue(repo, "repository"), mappingValue(repo, "name")
+	if alias == nil || name == nil {
+		return nil
+	}
+	ref := mappingValue(repo, "ref")
+	switch {
+	case ref == nil:
+		return []Finding{wf.finding(ruleUnpinnedResource, SeverityMedium, alias, fmt.Sprintf(
+			"repository resource %s (%s) follows its default branch. Pin it with ref: <commit-sha>", alias.Value, name.Value))}
+	case fullSHA.MatchString(ref.Value) || strings.Contains(ref.Value, "$"):
+		return nil
+	case strings.HasPrefix(ref.Value, "refs/tags/"):
+		return []Finding{wf.finding(ruleUnpinnedResource, SeverityLow, ref, fmt.Sprintf(
+			"repository resource %s (%s) follows the tag %s, which can be moved. Pin it to a commit SHA", alias.Value, name.Value, ref.Value))}
+	}
+	return []Finding{wf.finding(ruleUnpinnedResource, SeverityMedium, ref, fmt.Sprintf(
+		"repository resource %s (%s) follows the branch %s. Pin it to a commit SHA", alias.Value, name.Value, ref.Value))}
+}
+
+// Templates returns the templates of the repository referenced by template: and
+// extends: template: keys. Paths starting with / are relative to the repository root and
+// others to the referencing file. Templates of other repositories, written path@alias,
+// are checked through their resource.
+func (c AzurePipelinesChecker) Templates(wf *WorkflowFile, rel string) []string {
+	var templates []string
+	for _, t := range azureKeys(wf, "template") {
+		p, alias, _ := strings.Cut(t.Value, "@")
+		if (alias != "" && alias != "self") || p == "" || strings.Contains(p, "$") {
+			continue
+		}
+		if strings.HasPrefix(p, "/") {
+			p = path.Clean(strings.TrimPrefix(p, "/"))
+		} else {
+			p = path.Join(path.Dir(rel), p)
+		}
+		if p == ".." || strings.HasPrefix(p, "../") {
+			continue
+		}
+		templates = append(templates, p)
+	}
+	return templates
+}
+
+// azureKeys returns the scalar values of a key anywhere in the file but variables, such as
+// the task: of steps nested in stages, jobs, conditional insertions and step lists handed to
+// templates as parameters.
+func azureKeys(wf *WorkflowFile, key string) []*yaml.Node {
+	var values []*yaml.Node
+	seen := map[*yaml.Node]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				switch v := resolveAlias(p.Value); {
+				case p.Key.Value == key && v.Kind == yaml.ScalarNode:
+					values = append(values, v)
+				case p.Key.Value != "variables":
+					walk(v)
+				}
+			}
+		}
+	}
+	for _, root := range wf.roots() {
+		walk(root)
+	}
+	return values
+}
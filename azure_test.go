@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// --- Tests for AzurePipelinesChecker.Check ---
+
+func TestAzurePipelinesChecker_Check(t *testing.T) {
+	content := `resources:
+  repositories:
+    - repository: templates
+      type: git
+      name: platform/templates
+    - repository: tools
+      type: github
+      name: org/tools
+      ref: refs/heads/main
+    - repository: release
+      type: git
+      name: platform/release
+      ref: refs/tags/v1
+    - repository: pinned
+      type: git
+      name: platform/pinned
+      ref: 0123456789abcdef0123456789abcdef01234567
+extends:
+  template: pipeline.yml@templates
+  parameters:
+    steps:
+      - task: PublishBuildArtifacts
+stages:
+  - stage: build
+    jobs:
+      - job: build
+        steps:
+          - task: DotNetCoreCLI@2
+          - task: $(customTask)
+          - ${{ if eq(variables.deploy, true) }}:
+              - task: AzureCLI
+`
+	findings := AzurePipelinesChecker{}.Check(newWorkflowFile(azurePipelinesFile, []byte(content)))
+
+	expected := map[int]string{
+		3:  ruleUnpinnedResource + " " + SeverityMedium, // default branch
+		9:  ruleUnpinnedResource + " " + SeverityMedium, // branch
+		13: ruleUnpinnedResource + " " + SeverityLow,    // tag
+		22: ruleUnversionedTask + " " + SeverityMedium,
+		31: ruleUnversionedTask + " " + SeverityMedium,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule+" "+f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with Azure Pipelines templates ---
+
+func TestScanner_ScanBranchAzureTemplates(t *testing.T) {
+	root := filepath.Join("ws", "repo")
+	file := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+	repo := fakeRepository{
+		name:         "repo",
+		listFilesErr: os.ErrNotExist,
+		fileContents: map[string][]byte{
+			file(azurePipelinesFile): []byte(`extends:
+  template: ci/pipeline.yml
+steps:
+  - template: /ci/pipeline.yml@self
+  - template: build.yml@templates
+  - template: ../outside.yml
+`),
+			file("ci/pipeline.yml"): []byte(`steps:
+  - template: steps/test.yml
+  - task: Npm@1
+`),
+			file("ci/steps/test.yml"): []byte(`steps:
+  - task: VSTest
+  - template: ../pipeline.yml
+`),
+		},
+	}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Pipelines: []PipelineChecker{AzurePipelinesChecker{}}}
+	records := sc.ScanBranch("main", repo, regexp.MustCompile("@v"), workflowDir(root))
+
+	// Only the nested template has a finding; each template is read once.
+	var files []string
+	for _, r := range records {
+		files = append(files, sarifURI(r))
+	}
+	sort.Strings(files)
+	if strings.Join(files, ",") != "ci/steps/test.yml" {
+		t.Errorf("unexpected records for %v", files)
+	}
+}
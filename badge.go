@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+)
+
+// Badge colors, as named by shields.io
+const (
+	badgeGreen  = "brightgreen"
+	badgeYellow = "yellow"
+	badgeRed    = "red"
+)
+
+// badgeHex maps badge colors to the hex values used in SVG badges
+var badgeHex = map[string]string{badgeGreen: "#4c1", badgeYellow: "#dfb317", badgeRed: "#e05d44"}
+
+// Badge is the content of a status badge
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// badgeFor summarizes an inventory as a badge. A repository with no mutable references and
+// every action pinned is "passing", otherwise the badge shows its pinning score.
+func badgeFor(inv *Inventory) Badge {
+	b := Badge{Label: "sharfer", Message: "passing", Color: badgeGreen}
+
+	score, ok := pinningScore(inv.Records)
+	switch {
+	case ok && score < 100:
+		b.Message = fmt.Sprintf("%d%% pinned", score)
+		b.Color = badgeRed
+		if score >= 90 {
+			b.Color = badgeGreen
+		} else if score >= 70 {
+			b.Color = badgeYellow
+		}
+	case inv.hasMatches():
+		b.Message, b.Color = "failing", badgeRed
+	}
+	return b
+}
+
+// SVG renders the badge in the flat style of shields.io. Text widths are estimated, which
+// is close enough for the short labels used here.
+func (b Badge) SVG() string {
+	lw, mw := 6*len(b.Label)+10, 6*len(b.Message)+10
+	w := lw + mw
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<title>%s: %s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g></svg>`+"\n",
+		w, label, message, label, message, w, lw, lw, mw, badgeHex[b.Color], w, lw/2, label, lw+mw/2, message)
+}
+
+// shieldsEndpoint is the JSON schema shields.io reads from endpoint badges
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// JSON renders the badge for the shields.io endpoint badge
+func (b Badge) JSON() ([]byte, error) {
+	return json.Marshal(shieldsEndpoint{SchemaVersion: 1, Label: b.Label, Message: b.Message, Color: b.Color})
+}
+
+// writeBadge writes the badge of an inventory to an SVG file
+func writeBadge(inv *Inventory, fileName string) error {
+	if err := os.WriteFile(fileName, []byte(badgeFor(inv).SVG()), 0o644); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
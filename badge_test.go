@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// --- Tests for badgeFor ---
+
+func TestBadgeFor(t *testing.T) {
+	profiled := func(refs, pinned int) *InventoryRecord {
+		return &InventoryRecord{Profile: &WorkflowProfile{ActionRefs: refs, PinnedRefs: pinned}}
+	}
+
+	tests := []struct {
+		name     string
+		records  []*InventoryRecord
+		expected Badge
+	}{
+		{name: "no workflows", expected: Badge{"sharfer", "passing", badgeGreen}},
+		{name: "all pinned", records: []*InventoryRecord{profiled(3, 3)}, expected: Badge{"sharfer", "passing", badgeGreen}},
+		{name: "mostly pinned", records: []*InventoryRecord{profiled(10, 9)}, expected: Badge{"sharfer", "90% pinned", badgeGreen}},
+		{name: "some pinned", records: []*InventoryRecord{profiled(4, 3)}, expected: Badge{"sharfer", "75% pinned", badgeYellow}},
+		{name: "few pinned", records: []*InventoryRecord{profiled(4, 1), profiled(0, 0)}, expected: Badge{"sharfer", "25% pinned", badgeRed}},
+		{name: "matches without profiles", records: []*InventoryRecord{{Locations: []Match{{Value: "a/b@v1"}}}}, expected: Badge{"sharfer", "failing", badgeRed}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := badgeFor(&Inventory{Records: tc.records}); got != tc.expected {
+				t.Errorf("badgeFor = %+v; want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for Badge rendering ---
+
+func TestBadge_Render(t *testing.T) {
+	b := Badge{Label: "sharfer", Message: "75% <pinned>", Color: badgeYellow}
+
+	svg := b.SVG()
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, "75% &lt;pinned&gt;") || !strings.Contains(svg, badgeHex[badgeYellow]) {
+		t.Errorf("unexpected SVG: %s", svg)
+	}
+
+	body, err := b.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var endpoint map[string]any
+	if err := json.Unmarshal(body, &endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if endpoint["schemaVersion"] != float64(1) || endpoint["message"] != "75% <pinned>" || endpoint["color"] != "yellow" {
+		t.Errorf("unexpected endpoint JSON: %s", body)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// bitbucketPipelinesFile is the pipeline file of Bitbucket Pipelines at the root of a repository
+const bitbucketPipelinesFile = "bitbucket-pipelines.yml"
+
+// ruleUnpinnedPipe flags Bitbucket pipes not pinned to a digest
+const ruleUnpinnedPipe = "unpinned-pipe"
+
+// BitbucketPipelinesChecker flags the pipes and images of Bitbucket Pipelines not pinned to
+// a digest. Pipes are container images run with the variables handed to them, often
+// deployment credentials, so a moved tag runs someone else's code with them.
+type BitbucketPipelinesChecker struct{}
+
+func (c BitbucketPipelinesChecker) File() string {
+	return bitbucketPipelinesFile
+}
+
+// Check looks for pipes and images anywhere in the file: the top level, definitions of
+// steps and services, and the steps of every pipeline, including parallel steps and stages.
+// Steps shared through anchors are reported once.
+func (c BitbucketPipelinesChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	seen := map[*yaml.Node]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				switch p.Key.Value {
+				case "variables":
+					// Values handed to pipes, not pipeline settings.
+				case "image":
+					if img := pipelineImage(p.Value); img != nil && !seen[img] {
+						seen[img] = true
+						findings = append(findings, unpinnedImageFinding(wf, img)...)
+					}
+				case "pipe":
+					if pipe := resolveAlias(p.Value); pipe.Kind == yaml.ScalarNode && !seen[pipe] {
+						seen[pipe] = true
+						findings = append(findings, unpinnedPipeFinding(wf, pipe)...)
+					}
+				default:
+					walk(p.Value)
+				}
+			}
+		}
+	}
+	for _, root := range wf.roots() {
+		walk(root)
+	}
+	return findings
+}
+
+// unpinnedPipeFinding flags a pipe referenced by a tag, such as atlassian/aws-s3-deploy:1.1.0,
+// rather than by the digest of its image
+func unpinnedPipeFinding(wf *WorkflowFile, pipe *yaml.Node) []Finding {
+	if strings.Contains(pipe.Value, "$") {
+		return nil
+	}
+	ref, err := parseImageRef(pipe.Value)
+	if err != nil || ref.Digest != "" {
+		return nil
+	}
+	return []Finding{wf.finding(ruleUnpinnedPipe, SeverityMedium, pipe, fmt.Sprintf(
+		"pipe %s is referenced by a mutable tag. Pin the digest of its image. Ex: docker://<image>@sha256:<digest>", pipe.Value))}
+}
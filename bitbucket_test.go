@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for BitbucketPipelinesChecker.Check ---
+
+func TestBitbucketPipelinesChecker_Check(t *testing.T) {
+	content := `image: atlassian/default-image:4
+definitions:
+  services:
+    docker:
+      image: docker:27-dind
+  steps:
+    - step: &deploy
+        name: Deploy
+        script:
+          - pipe: atlassian/aws-s3-deploy:1.1.0
+            variables:
+              image: not-an-image
+          - pipe: docker://bitbucketpipelines/aws-s3-deploy@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+          - pipe: $CUSTOM_PIPE
+pipelines:
+  default:
+    - parallel:
+        - step:
+            image:
+              name: node@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+            script: [npm test]
+        - step:
+            image: python:3.12
+            script: [pytest]
+  branches:
+    main:
+      - step: *deploy
+    release:
+      - stage:
+          steps:
+            - step: *deploy
+            - step:
+                script:
+                  - pipe: atlassian/slack-notify:2
+`
+	findings := BitbucketPipelinesChecker{}.Check(newWorkflowFile(bitbucketPipelinesFile, []byte(content)))
+
+	expected := map[int]string{
+		1:  ruleUnpinnedImage,
+		5:  ruleUnpinnedImage,
+		10: ruleUnpinnedPipe, // reported once for the pipelines sharing the step
+		23: ruleUnpinnedImage,
+		34: ruleUnpinnedPipe,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
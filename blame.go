@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// lineAuthor is the last commit that changed the line of an issue. Its author is the
+// probable owner of the issue, even in repositories without a CODEOWNERS file.
+type lineAuthor struct {
+	Commit string    `json:"commit"`
+	Author string    `json:"author"`
+	Date   time.Time `json:"date"`
+}
+
+// blamedFile is the blame of a committed file along with its lines in the worktree
+type blamedFile struct {
+	blame   *git.BlameResult
+	current []string
+}
+
+// blamer attributes lines of files to the commits that last changed them, blaming each
+// file once
+type blamer struct {
+	files map[string]*blamedFile
+}
+
+// newBlamer creates a blamer with an empty cache
+func newBlamer() *blamer {
+	return &blamer{files: map[string]*blamedFile{}}
+}
+
+// file blames a file at the HEAD of the repository holding it, or returns nil when it is
+// not committed
+func (b *blamer) file(path string) *blamedFile {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	if f, ok := b.files[abs]; ok {
+		return f
+	}
+	b.files[abs] = nil
+
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(abs), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		logger.Debug("not a git repository. lines are not attributed", "file", abs, "err", err)
+		return nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		logger.Debug("repository has no HEAD. lines are not attributed", "file", abs, "err", err)
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		logger.Debug("could not read HEAD commit", "err", err)
+		return nil
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		logger.Debug("repository has no worktree. lines are not attributed", "file", abs, "err", err)
+		return nil
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	blame, err := git.Blame(commit, filepath.ToSlash(rel))
+	if err != nil {
+		logger.Debug("could not blame file", "file", rel, "err", err)
+		return nil
+	}
+	current, err := os.ReadFile(abs)
+	if err != nil {
+		return nil
+	}
+	b.files[abs] = &blamedFile{blame: blame, current: strings.Split(string(current), "\n")}
+	return b.files[abs]
+}
+
+// line returns the last commit that changed a line of a file, counted from 1. Lines that
+// differ from HEAD are left unattributed.
+func (b *blamer) line(path string, n int) *git.Line {
+	f := b.file(path)
+	if f == nil || n < 1 || n > len(f.blame.Lines) || n > len(f.current) {
+		return nil
+	}
+	line := f.blame.Lines[n-1]
+	if f.current[n-1] != line.Text {
+		return nil
+	}
+	return line
+}
+
+// author returns the author of the last commit that changed a line, if any
+func (b *blamer) author(path string, n int) *lineAuthor {
+	line := b.line(path, n)
+	if line == nil {
+		return nil
+	}
+	author := line.AuthorName
+	if line.Author != "" {
+		author += " <" + line.Author + ">"
+	}
+	return &lineAuthor{Commit: line.Hash.String(), Author: author, Date: line.Date}
+}
+
+// blameInventory attributes every mutable reference and finding of the inventory to the
+// author of the last commit that changed its line. Files outside git repositories, such as
+// those of remote scans, are left unattributed.
+func blameInventory(inv *Inventory) {
+	b := newBlamer()
+	for _, ir := range inv.Records {
+		for i := range ir.Locations {
+			ir.Locations[i].Blame = b.author(ir.FilePath, ir.Locations[i].Line)
+		}
+		for i := range ir.Findings {
+			ir.Findings[i].Blame = b.author(ir.FilePath, ir.Findings[i].Line)
+		}
+	}
+}
+
+// blamed reports whether any mutable reference or finding of the inventory is attributed
+func (inv *Inventory) blamed() bool {
+	for _, ir := range inv.Records {
+		for _, m := range ir.Locations {
+			if m.Blame != nil {
+				return true
+			}
+		}
+		for _, f := range ir.Findings {
+			if f.Blame != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probableOwner renders the author of an issue for report columns, or "unknown"
+func probableOwner(a *lineAuthor) string {
+	if a == nil {
+		return "unknown"
+	}
+	return a.Author
+}
+
+// introducedIn renders the commit that brought an issue in, with its date, for report
+// columns, or "unknown"
+func introducedIn(a *lineAuthor) string {
+	if a == nil {
+		return "unknown"
+	}
+	return shortSHA(a.Commit) + " on " + a.Date.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for blameInventory ---
+
+func TestBlameInventory(t *testing.T) {
+	root, path := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	when := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	commit, err := w.Commit("add workflow", &git.CommitOptions{Author: &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when}})
+	CheckIfError(err)
+
+	// A line changed since the commit has no author yet.
+	CheckIfError(os.WriteFile(path, []byte(strings.Replace(unfixedWorkflow, "ubuntu-latest", "ubuntu-24.04", 1)), 0o644))
+
+	inv := &Inventory{Records: []*InventoryRecord{
+		{
+			Repository: "app",
+			FilePath:   path,
+			Locations:  []Match{{Value: "actions/checkout@v4", Line: 6, Column: 15}},
+			Findings: []Finding{
+				{Rule: ruleUndeclaredPermissions, Line: 3},
+				{Rule: ruleMissingTimeout, Line: 4},
+				{Rule: ruleMissingRequiredWorkflow},
+			},
+		},
+		{Repository: "app", FilePath: filepath.Join(t.TempDir(), "untracked.yml"), Locations: []Match{{Value: "actions/setup-go@v5", Line: 1}}},
+	}}
+	blameInventory(inv)
+
+	expected := &lineAuthor{Commit: commit.String(), Author: "Jane Doe <jane@example.com>", Date: when}
+	ir := inv.Records[0]
+	for _, a := range []*lineAuthor{ir.Locations[0].Blame, ir.Findings[0].Blame} {
+		if a == nil || a.Commit != expected.Commit || a.Author != expected.Author || !a.Date.Equal(when) {
+			t.Errorf("expected %+v, got %+v", expected, a)
+		}
+	}
+	if ir.Findings[1].Blame != nil || ir.Findings[2].Blame != nil {
+		t.Errorf("expected changed lines and repository-level findings to be unattributed, got %+v", ir.Findings)
+	}
+	if inv.Records[1].Locations[0].Blame != nil {
+		t.Errorf("expected files outside git to be unattributed, got %+v", inv.Records[1].Locations[0])
+	}
+
+	var b strings.Builder
+	writeMarkdownRecords(&b, inv.Records, "##")
+	if !strings.Contains(b.String(), "| `actions/checkout@v4` | app | "+path+" | 6 | Jane Doe <jane@example.com> | "+shortSHA(commit.String())+" on 2025-03-01 |") ||
+		!strings.Contains(b.String(), "| `actions/setup-go@v5` | app | "+inv.Records[1].FilePath+" | 1 | unknown | unknown |") {
+		t.Errorf("expected probable owner and introducing commit columns, got:\n%s", b.String())
+	}
+
+	log := sarifReport(inv, SeverityMapping{})
+	if p := log.Runs[0].Results[0].Properties; p == nil || p.Blame.Commit != commit.String() {
+		t.Errorf("expected the SARIF result to carry the introducing commit, got %+v", p)
+	}
+	if p := log.Runs[0].Results[len(log.Runs[0].Results)-1].Properties; p != nil {
+		t.Errorf("expected no properties on unattributed results, got %+v", p)
+	}
+}
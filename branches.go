@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// maxLinkHops bounds how many symlinks are followed within a tree before reporting a cycle
+const maxLinkHops = 40
+
+// gitBranchRepository reads the files of a repository as committed on a branch, straight
+// from the object store, so branches are scanned without checking them out
+type gitBranchRepository struct {
+	GitRepository
+	tree *object.Tree
+}
+
+// AtBranch returns the repository as committed on branch, or at any revision git can
+// resolve, such as a tag or a commit SHA
+func (g GitRepository) AtBranch(branch string) (Repository, error) {
+	dir := g.localPath
+	if g.gitdir != "" {
+		dir = g.gitdir
+	}
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(branch))
+	if err != nil {
+		return nil, fmt.Errorf("git error: resolving %s: %w", branch, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	return gitBranchRepository{GitRepository: g, tree: tree}, nil
+}
+
+// treePath turns a path below the checkout into a path in the tree
+func (b gitBranchRepository) treePath(p string) (string, error) {
+	if !isWithin(b.localPath, p) {
+		return "", fmt.Errorf("git error: %s is outside of repository %s", p, b.localPath)
+	}
+	return relativePath(b.localPath, p), nil
+}
+
+func (b gitBranchRepository) ListFiles(loc string) ([]string, error) {
+	rel, err := b.treePath(loc)
+	if err != nil {
+		return nil, err
+	}
+	tree := b.tree
+	if rel != "." {
+		if tree, err = b.tree.Tree(rel); err != nil {
+			return nil, fmt.Errorf("git error: %s: %w", rel, err)
+		}
+	}
+
+	var files []string
+	for _, entry := range tree.Entries {
+		files = append(files, entry.Name)
+	}
+	return files, nil
+}
+
+// ReadFile reads a file from the tree. Symlinks are followed within the tree, and those
+// escaping it, dangling or looping return a *SymlinkError as they do in the worktree.
+func (b gitBranchRepository) ReadFile(filePath string) ([]byte, error) {
+	rel, err := b.treePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for hops := 0; ; hops++ {
+		entry, err := b.tree.FindEntry(rel)
+		if err != nil {
+			if hops > 0 {
+				return nil, &SymlinkError{Path: filePath, Target: rel, Reason: "link target does not exist"}
+			}
+			return nil, fmt.Errorf("git error: %s: %w", rel, err)
+		}
+		if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+			return nil, fmt.Errorf("git error: %s is not a file", rel)
+		}
+		file, err := b.tree.TreeEntryFile(entry)
+		if err != nil {
+			return nil, fmt.Errorf("git error: %w", err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("git error: %w", err)
+		}
+		if entry.Mode != filemode.Symlink {
+			return []byte(content), nil
+		}
+
+		if hops == maxLinkHops {
+			return nil, &SymlinkError{Path: filePath, Target: content, Reason: "link cycle detected"}
+		}
+		next := path.Join(path.Dir(rel), content)
+		if path.IsAbs(content) || next == ".." || strings.HasPrefix(next, "../") {
+			return nil, &SymlinkError{Path: filePath, Target: content, Reason: "link points outside of repository root"}
+		}
+		rel = next
+	}
+}
+
+// isBare reports whether a repository has no worktree, so that even HEAD has to be read
+// from the object store
+func isBare(repo Repository) bool {
+	b, ok := repo.(interface{ Bare() bool })
+	return ok && b.Bare()
+}
+
+// scanAllBranches scans the workflows of every branch of a repository from the object
+// store. HEAD is read from the worktree, as without --all-branches, unless the repository
+// is bare.
+func (s *Scanner) scanAllBranches(repo Repository, branches []string, regex *regexp.Regexp, searchPath string) []*InventoryRecord {
+	var records []*InventoryRecord
+	for _, branch := range branches {
+		at := repo
+		if reader, ok := repo.(BranchReader); ok && (branch != "HEAD" || isBare(repo)) {
+			var err error
+			if at, err = reader.AtBranch(branch); err != nil {
+				logger.Debug("could not read branch. skipping to next branch", "repo", repo.Name(), "branch", branch, "err", err)
+				continue
+			}
+		}
+		records = append(records, s.ScanBranch(branch, at, regex, searchPath)...)
+	}
+	return mergeBranchRecords(records)
+}
+
+// mergeBranchRecords folds records of the same file with the same matches and findings
+// on several branches into the first of them, which lists every branch in Branches.
+func mergeBranchRecords(records []*InventoryRecord) []*InventoryRecord {
+	var merged []*InventoryRecord
+	byKey := map[string]*InventoryRecord{}
+	for _, ir := range records {
+		key := branchRecordKey(ir)
+		if first, ok := byKey[key]; ok {
+			first.Branches = append(first.Branches, ir.Branch)
+			continue
+		}
+		ir.Branches = []string{ir.Branch}
+		byKey[key] = ir
+		merged = append(merged, ir)
+	}
+	return merged
+}
+
+// branchRecordKey identifies a record by everything but the branch it was found on and
+// who last changed the file there
+func branchRecordKey(ir *InventoryRecord) string {
+	c := *ir
+	c.Branch, c.Branches, c.LastModified = "", nil, nil
+	key, err := json.Marshal(c)
+	if err != nil {
+		// Distinct records are never merged.
+		return fmt.Sprintf("%p", ir)
+	}
+	return string(key)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitWorkflows writes workflow files, or symlinks for contents starting with "->", and
+// commits them on the checked out branch
+func commitWorkflows(t *testing.T, repo *git.Repository, root string, files map[string]string) {
+	t.Helper()
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	CheckIfError(os.MkdirAll(workflowDir(root), 0755))
+	for name, content := range files {
+		p := filepath.Join(workflowDir(root), name)
+		os.Remove(p)
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			CheckIfError(os.Symlink(target, p))
+		} else {
+			CheckIfError(os.WriteFile(p, []byte(content), 0644))
+		}
+		_, err = w.Add(filepath.Join(".github", "workflows", name))
+		CheckIfError(err)
+	}
+	_, err = w.Commit("update workflows", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+}
+
+// branchedRepo creates a repository whose feature branch changes ci.yml, then checks out
+// master again
+func branchedRepo(t *testing.T) (root string, repo *git.Repository) {
+	t.Helper()
+	root = t.TempDir()
+	dir := filepath.Join(root, "repo")
+	repo, err := git.PlainInit(dir, false)
+	CheckIfError(err)
+	commitWorkflows(t, repo, dir, map[string]string{
+		"ci.yml":      "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n",
+		"release.yml": "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/setup-go@v5\n",
+	})
+
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	CheckIfError(w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}))
+	commitWorkflows(t, repo, dir, map[string]string{
+		"ci.yml":   "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/cache@v4\n",
+		"out.yml":  "->../../../outside.yml",
+		"gone.yml": "->missing.yml",
+	})
+	CheckIfError(w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("master")}))
+	return root, repo
+}
+
+// --- Tests for GitRepository.AtBranch ---
+
+func TestGitRepository_AtBranch(t *testing.T) {
+	root, _ := branchedRepo(t)
+	dir := filepath.Join(root, "repo")
+	at, err := GitRepository{name: "repo", localPath: dir}.AtBranch("feature")
+	CheckIfError(err)
+
+	files, err := at.ListFiles(workflowDir(dir))
+	CheckIfError(err)
+	slices.Sort(files)
+	if !slices.Equal(files, []string{"ci.yml", "gone.yml", "out.yml", "release.yml"}) {
+		t.Errorf("expected the workflows committed on the branch, got %v", files)
+	}
+
+	content, err := at.ReadFile(filepath.Join(workflowDir(dir), "ci.yml"))
+	CheckIfError(err)
+	if !strings.Contains(string(content), "actions/cache@v4") {
+		t.Errorf("expected the content of the branch, got %q", content)
+	}
+
+	tests := []struct {
+		file   string
+		reason string
+	}{
+		{"out.yml", "link points outside of repository root"},
+		{"gone.yml", "link target does not exist"},
+	}
+	for _, tc := range tests {
+		_, err := at.ReadFile(filepath.Join(workflowDir(dir), tc.file))
+		var symErr *SymlinkError
+		if !errors.As(err, &symErr) || symErr.Reason != tc.reason {
+			t.Errorf("%s: expected a symlink error %q, got %v", tc.file, tc.reason, err)
+		}
+	}
+
+	if _, err := at.ListFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a directory missing on the branch")
+	}
+	if _, err := (GitRepository{name: "repo", localPath: dir}).AtBranch("nope"); err == nil {
+		t.Error("expected an error for an unknown branch")
+	}
+}
+
+// --- Tests for Scanner.ScanRepos with AllBranches ---
+
+func TestScanner_ScanReposAllBranches(t *testing.T) {
+	root, _ := branchedRepo(t)
+	sc := Scanner{VCS: GitHubVCS{}, FileScanner: GitHubWorkFlowScanner{}, AllBranches: true}
+	inv, err := sc.ScanRepos(root, mutableRefRegex, false)
+	CheckIfError(err)
+
+	byFile := map[string][]*InventoryRecord{}
+	for _, ir := range inv.Records {
+		byFile[filepath.Base(ir.FilePath)] = append(byFile[filepath.Base(ir.FilePath)], ir)
+	}
+
+	// release.yml is the same everywhere, ci.yml differs on the feature branch.
+	if rs := byFile["release.yml"]; len(rs) != 1 || !sameBranches(rs[0].Branches, "HEAD", "feature", "master") {
+		t.Errorf("expected release.yml once for every branch, got %+v", rs)
+	}
+	if rs := byFile["ci.yml"]; len(rs) != 2 {
+		t.Errorf("expected ci.yml once per distinct content, got %+v", rs)
+	} else {
+		for _, ir := range rs {
+			feature := slices.Contains(ir.Branches, "feature")
+			if feature && (len(ir.Matches) != 2 || len(ir.Branches) != 1) || !feature && (len(ir.Matches) != 1 || !sameBranches(ir.Branches, "HEAD", "master")) {
+				t.Errorf("unexpected ci.yml record %+v", ir)
+			}
+		}
+	}
+	if rs := byFile["out.yml"]; len(rs) != 1 || len(rs[0].Findings) != 1 || rs[0].Findings[0].Rule != ruleSuspiciousSymlink {
+		t.Errorf("expected the symlink escaping the tree to be flagged, got %+v", rs)
+	}
+
+	// Nothing was checked out.
+	content, err := os.ReadFile(filepath.Join(workflowDir(filepath.Join(root, "repo")), "ci.yml"))
+	CheckIfError(err)
+	if strings.Contains(string(content), "actions/cache@v4") {
+		t.Errorf("expected the worktree to stay on master, got %q", content)
+	}
+}
+
+func sameBranches(got []string, want ...string) bool {
+	got = slices.Clone(got)
+	slices.Sort(got)
+	return slices.Equal(got, want)
+}
+
+// --- Tests for mergeBranchRecords ---
+
+func TestMergeBranchRecords(t *testing.T) {
+	records := []*InventoryRecord{
+		{Repository: "r", Branch: "main", FilePath: "ci.yml", Matches: []string{"a@v1"}, LastModified: &fileModification{Actor: "alice"}},
+		{Repository: "r", Branch: "dev", FilePath: "ci.yml", Matches: []string{"a@v1"}, LastModified: &fileModification{Actor: "bob"}},
+		{Repository: "r", Branch: "old", FilePath: "ci.yml", Matches: []string{"a@v0"}},
+		{Repository: "other", Branch: "main", FilePath: "ci.yml", Matches: []string{"a@v1"}},
+	}
+	merged := mergeBranchRecords(records)
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(merged))
+	}
+	if !slices.Equal(merged[0].Branches, []string{"main", "dev"}) || merged[0].Branch != "main" {
+		t.Errorf("expected the first record to list both branches, got %+v", merged[0])
+	}
+	if !slices.Equal(merged[1].Branches, []string{"old"}) || !slices.Equal(merged[2].Branches, []string{"main"}) {
+		t.Errorf("expected distinct records to keep their own branch, got %+v and %+v", merged[1], merged[2])
+	}
+}
+
+// --- Tests for Scanner.ScanRepos on bare repositories ---
+
+func TestScanner_ScanReposBare(t *testing.T) {
+	src, _ := branchedRepo(t)
+	root := t.TempDir()
+	mirror := filepath.Join(root, "repo.git")
+	_, err := git.PlainClone(mirror, true, &git.CloneOptions{URL: filepath.Join(src, "repo"), Mirror: true})
+	CheckIfError(err)
+
+	if !IsBareRepo(mirror) || IsBareRepo(filepath.Join(src, "repo")) || IsBareRepo(root) {
+		t.Fatal("expected only the mirror to be a bare repository")
+	}
+
+	for _, vcs := range []VCS{GitHubVCS{}, WorkspaceVCS{}} {
+		sc := Scanner{VCS: vcs, FileScanner: GitHubWorkFlowScanner{}}
+		inv, err := sc.ScanRepos(root, mutableRefRegex, true)
+		CheckIfError(err)
+		var files []string
+		for _, ir := range inv.Records {
+			files = append(files, filepath.Base(ir.FilePath))
+			if ir.Repository != "repo.git" || ir.Branch != "HEAD" {
+				t.Errorf("%T: unexpected record %+v", vcs, ir)
+			}
+		}
+		slices.Sort(files)
+		if !slices.Equal(files, []string{"ci.yml", "release.yml"}) {
+			t.Errorf("%T: expected the workflows of HEAD, got %v", vcs, files)
+		}
+	}
+
+	sc := Scanner{VCS: GitHubVCS{}, FileScanner: GitHubWorkFlowScanner{}, AllBranches: true}
+	inv, err := sc.ScanRepos(root, mutableRefRegex, false)
+	CheckIfError(err)
+	for _, ir := range inv.Records {
+		if filepath.Base(ir.FilePath) == "release.yml" && !sameBranches(ir.Branches, "HEAD", "feature", "master") {
+			t.Errorf("expected release.yml to be read from every branch of the mirror, got %+v", ir)
+		}
+	}
+
+	t.Chdir(mirror)
+	audit, err := (&Scanner{}).AuditRepository(mutableRefRegex)
+	CheckIfError(err)
+	if len(audit.Records) != 2 {
+		t.Errorf("expected audit to read HEAD of the bare repository, got %+v", audit.Records)
+	}
+}
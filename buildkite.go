@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// buildkiteDir holds the pipeline files of Buildkite
+	buildkiteDir = ".buildkite"
+	// buildkitePipelineFile is the pipeline Buildkite uploads by default
+	buildkitePipelineFile = ".buildkite/pipeline.yml"
+)
+
+// ruleUnpinnedPlugin flags Buildkite plugins referenced by a ref that can change
+const ruleUnpinnedPlugin = "unpinned-plugin"
+
+// buildkitePlugin is a plugin reference, as written in plugins:
+type buildkitePlugin struct {
+	Node *yaml.Node
+	// Source is the plugin without its ref, such as docker or org/name
+	Source string
+	Ref    string
+}
+
+// githubRepo returns the GitHub repository of a plugin. Short names belong to the
+// buildkite-plugins organization, and names without a host to GitHub, with the
+// -buildkite-plugin suffix added. Plugins hosted elsewhere report false.
+func (p buildkitePlugin) githubRepo() (string, bool) {
+	src := strings.TrimSuffix(p.Source, ".git")
+	for _, prefix := range []string{"https://github.com/", "http://github.com/", "ssh://git@github.com/", "git@github.com:", "github.com/"} {
+		if rest, ok := strings.CutPrefix(src, prefix); ok {
+			return rest, strings.Count(rest, "/") == 1
+		}
+	}
+	if strings.Contains(src, ":") || strings.Contains(src, ".") {
+		return "", false
+	}
+
+	owner, name, ok := strings.Cut(src, "/")
+	if !ok {
+		owner, name = "buildkite-plugins", src
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	if !strings.HasSuffix(name, "-buildkite-plugin") {
+		name += "-buildkite-plugin"
+	}
+	return owner + "/" + name, true
+}
+
+// BuildkiteChecker flags the plugins of Buildkite pipelines not pinned to a commit SHA.
+// Plugins are git repositories whose hooks run on the agent with the environment of the
+// step, so a moved branch or tag runs someone else's code with its secrets.
+type BuildkiteChecker struct{}
+
+func (c BuildkiteChecker) File() string {
+	return buildkitePipelineFile
+}
+
+func (c BuildkiteChecker) Directory() string {
+	return buildkiteDir
+}
+
+func (c BuildkiteChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, p := range buildkitePlugins(wf) {
+		if fullSHA.MatchString(p.Ref) {
+			continue
+		}
+		switch {
+		case p.Ref == "":
+			findings = append(findings, wf.finding(ruleUnpinnedPlugin, SeverityHigh, p.Node, fmt.Sprintf(
+				"plugin %s has no version and follows its default branch. Pin it to a commit SHA, with scharf fix --buildkite-plugins", p.Source)))
+		case releaseTag.MatchString(p.Ref):
+			findings = append(findings, wf.finding(ruleUnpinnedPlugin, SeverityLow, p.Node, fmt.Sprintf(
+				"plugin %s follows the tag %s, which can be moved. Pin it to a commit SHA, with scharf fix --buildkite-plugins", p.Source, p.Ref)))
+		default:
+			findings = append(findings, wf.finding(ruleUnpinnedPlugin, SeverityMedium, p.Node, fmt.Sprintf(
+				"plugin %s follows the branch %s. Pin it to a commit SHA, with scharf fix --buildkite-plugins", p.Source, p.Ref)))
+		}
+	}
+	return findings
+}
+
+// buildkitePlugins returns the plugins of every step, groups included. A plugins: list holds
+// plugin names or single-key mappings of a name to its configuration, and older pipelines
+// write plugins: as a mapping. Plugins set through environment variables cannot be told.
+func buildkitePlugins(wf *WorkflowFile) []buildkitePlugin {
+	var plugins []buildkitePlugin
+	add := func(n *yaml.Node) {
+		if n == nil || n.Kind != yaml.ScalarNode || n.Value == "" || strings.Contains(n.Value, "$") {
+			return
+		}
+		src, ref, _ := strings.Cut(n.Value, "#")
+		plugins = append(plugins, buildkitePlugin{Node: n, Source: src, Ref: ref})
+	}
+
+	seen := map[*yaml.Node]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				switch p.Key.Value {
+				case "env":
+					// Variables of the step, not plugins.
+				case "plugins":
+					list := resolveAlias(p.Value)
+					if list.Kind == yaml.MappingNode {
+						for _, plugin := range mappingPairs(list) {
+							add(plugin.Key)
+						}
+						continue
+					}
+					for _, item := range list.Content {
+						item = resolveAlias(item)
+						if item.Kind == yaml.MappingNode && len(item.Content) > 0 {
+							add(item.Content[0])
+						} else {
+							add(item)
+						}
+					}
+				default:
+					walk(p.Value)
+				}
+			}
+		}
+	}
+	for _, root := range wf.roots() {
+		walk(root)
+	}
+	return plugins
+}
+
+// BuildkitePluginFixer pins the plugins of the Buildkite pipeline hosted on GitHub to the
+// commit their ref points to. Plugins without a version are pinned to the commit of main.
+type BuildkitePluginFixer struct {
+	Resolver Resolver
+}
+
+func (f BuildkitePluginFixer) File() string {
+	return buildkitePipelineFile
+}
+
+func (f BuildkitePluginFixer) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	var edits []TextEdit
+	for _, p := range buildkitePlugins(wf) {
+		if fullSHA.MatchString(p.Ref) {
+			continue
+		}
+		repo, ok := p.githubRepo()
+		if !ok {
+			logger.Debug("plugin is not hosted on GitHub, leaving it unpinned", "plugin", p.Source)
+			continue
+		}
+		action := repo
+		if p.Ref != "" {
+			action += "@" + p.Ref
+		}
+		sha, err := f.Resolver.resolve(action)
+		if err != nil {
+			logger.Warn("could not resolve plugin to a commit", "plugin", p.Node.Value, "err", err)
+			continue
+		}
+
+		start, ok := wf.lines.locate(p.Node.Value, wf.lines.offset(p.Node.Line, p.Node.Column))
+		if !ok {
+			logger.Debug("could not locate plugin to pin", "file", wf.Path, "line", p.Node.Line, "plugin", p.Node.Value)
+			continue
+		}
+		edits = append(edits, TextEdit{Start: start, End: start + len(p.Node.Value), NewText: p.Source + "#" + sha})
+	}
+	return edits, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const buildkitePipeline = `env:
+  plugins: not-a-plugin
+steps:
+  - label: test
+    command: make test
+    plugins:
+      - docker#v5.9.0:
+          image: golang:1.22
+      - my-org/secrets#main
+      - ecr
+  - group: deploy
+    steps:
+      - command: ./deploy.sh
+        plugins:
+          - https://github.com/my-org/deploy-buildkite-plugin.git#0123456789abcdef0123456789abcdef01234567: ~
+          - https://git.example.com/tools.git#v1
+          - $PLUGIN
+  - command: legacy.sh
+    plugins:
+      docker-compose#v4.0.0:
+        run: app
+`
+
+// --- Tests for BuildkiteChecker.Check ---
+
+func TestBuildkiteChecker_Check(t *testing.T) {
+	findings := BuildkiteChecker{}.Check(newWorkflowFile(buildkitePipelineFile, []byte(buildkitePipeline)))
+
+	expected := map[int]string{
+		7:  SeverityLow,    // tag
+		9:  SeverityMedium, // branch
+		10: SeverityHigh,   // no version
+		16: SeverityLow,    // tag of a plugin hosted elsewhere
+		20: SeverityLow,    // tag in a mapping
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if f.Rule != ruleUnpinnedPlugin || expected[f.Line] != f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+// --- Tests for buildkitePlugin.githubRepo ---
+
+func TestBuildkitePlugin_GitHubRepo(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+		ok       bool
+	}{
+		{"docker", "buildkite-plugins/docker-buildkite-plugin", true},
+		{"my-org/secrets", "my-org/secrets-buildkite-plugin", true},
+		{"my-org/secrets-buildkite-plugin", "my-org/secrets-buildkite-plugin", true},
+		{"https://github.com/my-org/deploy-buildkite-plugin.git", "my-org/deploy-buildkite-plugin", true},
+		{"git@github.com:my-org/deploy.git", "my-org/deploy", true},
+		{"https://git.example.com/tools.git", "", false},
+		{"file:///plugins/local", "", false},
+	}
+	for _, tc := range tests {
+		got, ok := buildkitePlugin{Source: tc.source}.githubRepo()
+		if got != tc.expected || ok != tc.ok {
+			t.Errorf("githubRepo(%q) = %q, %v; want %q, %v", tc.source, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+// --- Tests for BuildkitePluginFixer ---
+
+func TestBuildkitePluginFixer(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, filepath.FromSlash(buildkitePipelineFile))
+	CheckIfError(os.MkdirAll(filepath.Dir(path), 0o755))
+	CheckIfError(os.WriteFile(path, []byte(buildkitePipeline), 0o644))
+
+	fixer := BuildkitePluginFixer{Resolver: fakeResolver{
+		"buildkite-plugins/docker-buildkite-plugin@v5.9.0":         lockedSHA,
+		"my-org/secrets-buildkite-plugin@main":                     movedSHA,
+		"buildkite-plugins/ecr-buildkite-plugin":                   lockedSHA,
+		"buildkite-plugins/docker-compose-buildkite-plugin@v4.0.0": movedSHA,
+	}}
+
+	var out bytes.Buffer
+	results, err := FixWorkflows(root, []Fixer{fixer}, false, &out)
+	CheckIfError(err)
+	if len(results) != 1 || results[0].Path != path {
+		t.Fatalf("expected the Buildkite pipeline to be fixed, got %+v", results)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, pinned := range []string{
+		"docker#" + lockedSHA + ":", "my-org/secrets#" + movedSHA, "ecr#" + lockedSHA,
+		"docker-compose#" + movedSHA + ":", "https://git.example.com/tools.git#v1",
+	} {
+		if !strings.Contains(string(content), pinned) {
+			t.Errorf("expected %s in the fixed pipeline:\n%s", pinned, content)
+		}
+	}
+}
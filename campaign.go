@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// repoState is what the API tells about a repository before fixes are pushed to it
+type repoState struct {
+	Archived      bool
+	DefaultBranch string
+	// Protected is set when the default branch has branch protection
+	Protected bool
+	// FixBranch is whether the fix branch exists, and Diverged whether it holds commits
+	// the default branch moved away from, which can conflict
+	FixBranch bool
+	Diverged  bool
+}
+
+// repoStateFunc looks up the state of a repository and of a fix branch in it
+type repoStateFunc func(owner, name, fixBranch string) (repoState, error)
+
+// lookupRepoState reads the state of a repository through the API
+func lookupRepoState(owner, name, fixBranch string) (repoState, error) {
+	base := fmt.Sprintf("%s/%s/%s", apiURL, owner, name)
+	var repo struct {
+		Archived      bool   `json:"archived"`
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := getJSON(base, &repo); err != nil {
+		return repoState{}, err
+	}
+	state := repoState{Archived: repo.Archived, DefaultBranch: repo.DefaultBranch}
+
+	var branch struct {
+		Protected bool `json:"protected"`
+	}
+	if _, err := getJSON(base+"/branches/"+url.PathEscape(repo.DefaultBranch), &branch); err != nil {
+		return repoState{}, err
+	}
+	state.Protected = branch.Protected
+
+	var compare struct {
+		Status string `json:"status"`
+	}
+	found, err := getJSON(base+"/compare/"+url.PathEscape(repo.DefaultBranch)+"..."+url.PathEscape(fixBranch), &compare)
+	if err != nil {
+		return repoState{}, err
+	}
+	state.FixBranch = found
+	state.Diverged = found && compare.Status == "diverged"
+	return state, nil
+}
+
+// getJSON decodes the response of a GET request into v. It returns false when the resource
+// does not exist.
+func getJSON(endpoint string, v any) (bool, error) {
+	resp, err := githubGet(endpoint)
+	if err != nil {
+		return false, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("http: %s returned %s", endpoint, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("json: %w", err)
+	}
+	return true, nil
+}
+
+// campaignTarget is a repository of the workspace a fix campaign changes
+type campaignTarget struct {
+	Name string
+	Path string
+	// Owner and Repo identify the repository on GitHub
+	Owner   string
+	Repo    string
+	Results []FixResult
+	// Blockers are the reasons no pull request can be opened unattended, and Manual those
+	// its pull request cannot be merged without someone stepping in
+	Blockers []string
+	Manual   []string
+	// Rerun is set when the fix branch of an earlier run exists, and Diverged when the
+	// default branch moved away from it. Either way the branch is recreated from the default
+	// branch and its pull request updated.
+	Rerun    bool
+	Diverged bool
+}
+
+// CampaignOptions configures a fix campaign over the repositories of a workspace
+type CampaignOptions struct {
+	Fixers []Fixer
+	// Branch receives the fixes in each repository. It defaults to defaultFixBranch.
+	Branch string
+	// Only, when set, restricts the campaign to these repositories of the workspace
+	Only map[string]bool
+	// Workers is how many repositories are fixed at once. It defaults to defaultCampaignWorkers.
+	Workers int
+	// Merge squash merges pull requests once checks pass, within MergeTimeout, when the fixes
+	// only pin actions of MergeOwners
+	Merge        bool
+	MergeOwners  []string
+	MergeTimeout time.Duration
+}
+
+// defaultCampaignWorkers is how many repositories a campaign fixes at once by default
+const defaultCampaignWorkers = 4
+
+// stateLookupCalls is how many API calls lookupRepoState makes
+const stateLookupCalls = 3
+
+// campaignPlan is the blast radius of a fix campaign, computed without changing anything
+type campaignPlan struct {
+	// Repositories is how many repositories of the workspace were looked at
+	Repositories int
+	Targets      []*campaignTarget
+	Files        int
+	// APICalls counts the calls the campaign makes on GitHub, planning included
+	APICalls int
+}
+
+// Ready returns the targets the campaign can open a pull request for
+func (p *campaignPlan) Ready() []*campaignTarget {
+	var ready []*campaignTarget
+	for _, t := range p.Targets {
+		if len(t.Blockers) == 0 {
+			ready = append(ready, t)
+		}
+	}
+	return ready
+}
+
+// planCampaign runs the fixers of a campaign over every repository of the workspace at root
+// in dry-run mode, and finds the repositories fixes cannot be pushed to unattended
+func planCampaign(root string, opts CampaignOptions, lookup repoStateFunc) (*campaignPlan, error) {
+	if opts.Branch == "" {
+		opts.Branch = defaultFixBranch
+	}
+	repos, err := GitHubVCS{}.ListRepositories(root)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &campaignPlan{}
+	for _, r := range repos {
+		path := r.Location()
+		if !IsGitRepo(path) || (opts.Only != nil && !opts.Only[r.Name()]) {
+			continue
+		}
+		plan.Repositories++
+		if _, err := os.Stat(workflowDir(path)); err != nil {
+			continue
+		}
+
+		results, err := FixWorkflows(path, opts.Fixers, true, io.Discard)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Name(), err)
+		}
+		if len(results) == 0 {
+			continue
+		}
+		t := &campaignTarget{Name: r.Name(), Path: path, Results: results}
+		plan.Targets = append(plan.Targets, t)
+		plan.Files += len(results)
+
+		var looked bool
+		t.Blockers, looked = targetBlockers(t, opts.Branch, lookup)
+		if looked {
+			plan.APICalls += stateLookupCalls
+		}
+		if len(t.Blockers) == 0 {
+			// Looking for the pull request of an earlier run, then opening or updating it
+			plan.APICalls += 2
+		}
+	}
+	return plan, nil
+}
+
+// targetBlockers returns why fixes of a repository cannot be pushed and merged unattended,
+// and whether the state of the repository was looked up
+func targetBlockers(t *campaignTarget, fixBranch string, lookup repoStateFunc) ([]string, bool) {
+	remote, err := GetRemoteURL(t.Path, "origin")
+	if err != nil {
+		return []string{"no origin remote"}, false
+	}
+	if t.Owner, t.Repo, err = parseGitHubRemote(remote); err != nil {
+		return []string{"origin is not a GitHub repository"}, false
+	}
+
+	var blockers []string
+	repo, err := git.PlainOpen(t.Path)
+	if err != nil {
+		return []string{fmt.Sprintf("git error: %s", err)}, false
+	}
+	if wt, err := repo.Worktree(); err == nil {
+		if status, err := wt.Status(); err == nil && !status.IsClean() {
+			blockers = append(blockers, "uncommitted changes in the clone")
+		}
+	}
+
+	state, err := lookup(t.Owner, t.Repo, fixBranch)
+	if err != nil {
+		return append(blockers, fmt.Sprintf("could not look up the repository: %s", err)), true
+	}
+	if state.Archived {
+		blockers = append(blockers, "archived")
+	}
+	if head, err := repo.Head(); err == nil && state.DefaultBranch != "" && head.Name().Short() != state.DefaultBranch {
+		blockers = append(blockers, fmt.Sprintf("clone is on %s, not the default branch %s", head.Name().Short(), state.DefaultBranch))
+	}
+	if state.Protected {
+		t.Manual = append(t.Manual, fmt.Sprintf("%s is protected, merging may need a review", state.DefaultBranch))
+	}
+	t.Rerun, t.Diverged = state.FixBranch, state.Diverged
+	return blockers, true
+}
+
+// writeCampaignPlan renders a plan for the console
+func writeCampaignPlan(w io.Writer, plan *campaignPlan) {
+	ready := plan.Ready()
+	reruns := 0
+	for _, t := range ready {
+		if t.Rerun {
+			reruns++
+		}
+	}
+	fmt.Fprintf(w, "Repositories scanned: %d\n", plan.Repositories)
+	fmt.Fprintf(w, "Repositories with fixes: %d\n", len(plan.Targets))
+	fmt.Fprintf(w, "Pull requests to open: %d\n", len(ready)-reruns)
+	fmt.Fprintf(w, "Pull requests of earlier runs to update: %d\n", reruns)
+	fmt.Fprintf(w, "Workflow files touched: %d\n", plan.Files)
+	fmt.Fprintf(w, "GitHub API calls: %d, and %d git pushes\n", plan.APICalls, len(ready))
+
+	var lines []string
+	for _, t := range plan.Targets {
+		for _, b := range t.Blockers {
+			lines = append(lines, fmt.Sprintf("  %s: %s (skipped)", t.Name, b))
+		}
+		for _, m := range t.Manual {
+			lines = append(lines, fmt.Sprintf("  %s: %s", t.Name, m))
+		}
+	}
+	if len(lines) > 0 {
+		fmt.Fprintf(w, "\nNeeding manual intervention:\n%s\n", strings.Join(lines, "\n"))
+	}
+
+	var conflicts []string
+	for _, t := range ready {
+		if t.Diverged {
+			conflicts = append(conflicts, "  "+t.Name)
+		}
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(w, "\nFix branches that diverged from the default branch, to be recreated:\n%s\n", strings.Join(conflicts, "\n"))
+	}
+}
+
+// runCampaign writes the fixes of the targets ready in a plan and opens a pull request for
+// each, a few repositories at a time. Each step is recorded in the status, and a failure is
+// recorded with its reason without stopping the campaign.
+func runCampaign(plan *campaignPlan, opts CampaignOptions, status *campaignStatus) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultCampaignWorkers
+	}
+
+	targets := make(chan *campaignTarget)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range targets {
+				if err := fixTarget(t, opts, status); err != nil {
+					status.set(t.Name, stateFailed, err.Error(), "")
+				}
+			}
+		}()
+	}
+
+	for _, t := range plan.Targets {
+		if len(t.Blockers) > 0 {
+			status.set(t.Name, stateSkipped, strings.Join(t.Blockers, ", "), "")
+			continue
+		}
+		status.set(t.Name, stateCloned, "", "")
+		targets <- t
+	}
+	close(targets)
+	wg.Wait()
+}
+
+// fixTarget takes a repository of a campaign from its clone to an open, and possibly
+// merged, pull request
+func fixTarget(t *campaignTarget, opts CampaignOptions, status *campaignStatus) error {
+	results, err := FixWorkflows(t.Path, opts.Fixers, false, io.Discard)
+	if err != nil {
+		return err
+	}
+	status.set(t.Name, stateFixed, "", "")
+
+	pr, err := openFixPR(t.Path, results, FixPROptions{
+		Owner:    t.Owner,
+		Name:     t.Repo,
+		Branch:   opts.Branch,
+		Title:    "Secure GitHub workflows",
+		OnPushed: func() { status.set(t.Name, statePushed, "", "") },
+	})
+	if err != nil {
+		return err
+	}
+	status.set(t.Name, statePROpened, "", pr.HTMLURL)
+
+	if !opts.Merge || !autoMergeEligible(results, opts.MergeOwners) {
+		return nil
+	}
+	if err := mergeWhenGreen(t.Owner, t.Repo, pr, opts.MergeTimeout); err != nil {
+		return err
+	}
+	status.set(t.Name, stateMerged, "", pr.HTMLURL)
+	return nil
+}
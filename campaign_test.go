@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// campaignRepo commits a workflow to a new repository of the workspace whose origin is
+// github.com/org/<name>
+func campaignRepo(t *testing.T, workspace, name, workflow string) string {
+	t.Helper()
+	root := filepath.Join(workspace, name)
+	repo, err := git.PlainInit(root, false)
+	CheckIfError(err)
+	if workflow != "" {
+		CheckIfError(os.MkdirAll(workflowDir(root), 0o755))
+		CheckIfError(os.WriteFile(filepath.Join(workflowDir(root), "ci.yml"), []byte(workflow), 0o644))
+	} else {
+		CheckIfError(os.WriteFile(filepath.Join(root, "README.md"), []byte("# "+name+"\n"), 0o644))
+	}
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".")
+	CheckIfError(err)
+	_, err = w.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/org/" + name + ".git"}})
+	CheckIfError(err)
+	return root
+}
+
+// --- Tests for planCampaign ---
+
+func TestPlanCampaign(t *testing.T) {
+	workspace := t.TempDir()
+	for _, name := range []string{"ready", "archived", "protected", "diverged", "dirty"} {
+		campaignRepo(t, workspace, name, unfixedWorkflow)
+	}
+	campaignRepo(t, workspace, "docs", "")
+	dirty := filepath.Join(workspace, "dirty", "notes.txt")
+	CheckIfError(os.WriteFile(dirty, []byte("wip\n"), 0o644))
+
+	states := map[string]repoState{
+		"archived":  {Archived: true, DefaultBranch: "master"},
+		"protected": {Protected: true, DefaultBranch: "master"},
+		"diverged":  {FixBranch: true, Diverged: true, DefaultBranch: "master"},
+	}
+	lookup := func(owner, name, fixBranch string) (repoState, error) {
+		if owner != "org" || fixBranch != defaultFixBranch {
+			t.Errorf("unexpected lookup of %s/%s at %s", owner, name, fixBranch)
+		}
+		if s, ok := states[name]; ok {
+			return s, nil
+		}
+		return repoState{DefaultBranch: "master"}, nil
+	}
+
+	plan, err := planCampaign(workspace, CampaignOptions{Fixers: []Fixer{PermissionsRule{}}}, lookup)
+	CheckIfError(err)
+
+	if plan.Repositories != 6 || len(plan.Targets) != 5 || plan.Files != 5 {
+		t.Errorf("unexpected plan: %d repositories, %d targets, %d files", plan.Repositories, len(plan.Targets), plan.Files)
+	}
+	var ready []string
+	for _, r := range plan.Ready() {
+		ready = append(ready, r.Name)
+	}
+	if strings.Join(ready, ",") != "diverged,protected,ready" {
+		t.Errorf("unexpected ready repositories %v", ready)
+	}
+	// Three lookups per target and two calls per pull request
+	if plan.APICalls != 5*stateLookupCalls+3*2 {
+		t.Errorf("unexpected API calls %d", plan.APICalls)
+	}
+
+	// Planning leaves the workflows untouched.
+	content, err := os.ReadFile(filepath.Join(workflowDir(filepath.Join(workspace, "ready")), "ci.yml"))
+	CheckIfError(err)
+	if string(content) != unfixedWorkflow {
+		t.Error("expected planning not to write fixes")
+	}
+
+	var out bytes.Buffer
+	writeCampaignPlan(&out, plan)
+	for _, expected := range []string{
+		"Pull requests to open: 2",
+		"Pull requests of earlier runs to update: 1",
+		"archived: archived (skipped)",
+		"dirty: uncommitted changes in the clone (skipped)",
+		"protected: master is protected, merging may need a review",
+		"to be recreated:\n  diverged",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the plan:\n%s", expected, out.String())
+		}
+	}
+}
+
+// --- Tests for lookupRepoState ---
+
+func TestLookupRepoState(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var status int
+		var body string
+		switch req.URL.Path {
+		case "/repos/org/app":
+			status, body = http.StatusOK, `{"archived": false, "default_branch": "main"}`
+		case "/repos/org/app/branches/main":
+			status, body = http.StatusOK, `{"protected": true}`
+		case "/repos/org/app/compare/main...sharfer/fix":
+			status, body = http.StatusOK, `{"status": "diverged"}`
+		default:
+			status, body = http.StatusNotFound, `{}`
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(transport, func() {
+		state, err := lookupRepoState("org", "app", defaultFixBranch)
+		CheckIfError(err)
+		if state.DefaultBranch != "main" || !state.Protected || !state.FixBranch || !state.Diverged {
+			t.Errorf("unexpected state %+v", state)
+		}
+
+		state, err = lookupRepoState("org", "app", "other")
+		CheckIfError(err)
+		if state.FixBranch || state.Diverged {
+			t.Errorf("expected no fix branch, got %+v", state)
+		}
+	})
+}
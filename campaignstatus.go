@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultStatusFile persists the status of each repository of a fix campaign
+const defaultStatusFile = "sharfer-campaign.json"
+
+// States a repository goes through during a fix campaign
+const (
+	stateCloned   = "cloned"
+	stateFixed    = "fixed"
+	statePushed   = "pushed"
+	statePROpened = "pr opened"
+	stateMerged   = "merged"
+	stateFailed   = "failed"
+	stateSkipped  = "skipped"
+)
+
+// repoStatus is where a repository stands in a fix campaign
+type repoStatus struct {
+	Repository string `json:"repository"`
+	State      string `json:"state"`
+	// Reason tells why a repository failed or was skipped
+	Reason      string    `json:"reason,omitempty"`
+	PullRequest string    `json:"pull_request,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// campaignStatus tracks the repositories of a fix campaign. Every change is printed and
+// saved, so an interrupted campaign leaves an accurate record behind.
+type campaignStatus struct {
+	mu    sync.Mutex
+	path  string
+	out   io.Writer
+	repos map[string]*repoStatus
+}
+
+// loadCampaignStatus reads the status saved at path by an earlier campaign. A missing file
+// starts an empty one.
+func loadCampaignStatus(path string, out io.Writer) (*campaignStatus, error) {
+	s := &campaignStatus{path: path, out: out, repos: map[string]*repoStatus{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+	var repos []*repoStatus
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("json: %s: %w", path, err)
+	}
+	for _, r := range repos {
+		s.repos[r.Repository] = r
+	}
+	return s, nil
+}
+
+// set moves a repository to a state, keeping the pull request of an earlier state
+func (s *campaignStatus) set(repository, state, reason, pullRequest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.repos[repository]
+	if !ok {
+		r = &repoStatus{Repository: repository}
+		s.repos[repository] = r
+	}
+	r.State, r.Reason, r.UpdatedAt = state, reason, time.Now()
+	if pullRequest != "" {
+		r.PullRequest = pullRequest
+	}
+
+	line := fmt.Sprintf("%s: %s", repository, state)
+	if reason != "" {
+		line += ": " + reason
+	} else if pullRequest != "" {
+		line += " " + pullRequest
+	}
+	fmt.Fprintln(s.out, line)
+
+	if err := s.save(); err != nil {
+		logger.Warn("could not save the campaign status", "path", s.path, "err", err)
+	}
+}
+
+// save writes the status to its file. The caller holds the lock.
+func (s *campaignStatus) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
+
+// sorted returns the repositories by name. The caller holds the lock.
+func (s *campaignStatus) sorted() []*repoStatus {
+	repos := make([]*repoStatus, 0, len(s.repos))
+	for _, r := range s.repos {
+		repos = append(repos, r)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Repository < repos[j].Repository })
+	return repos
+}
+
+// Repositories returns a copy of the status of every repository, by name
+func (s *campaignStatus) Repositories() []repoStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var repos []repoStatus
+	for _, r := range s.sorted() {
+		repos = append(repos, *r)
+	}
+	return repos
+}
+
+// Failed returns the repositories an earlier campaign failed on
+func (s *campaignStatus) Failed() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failed := map[string]bool{}
+	for name, r := range s.repos {
+		if r.State == stateFailed {
+			failed[name] = true
+		}
+	}
+	return failed
+}
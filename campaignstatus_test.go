@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// --- Tests for campaignStatus ---
+
+func TestCampaignStatus_SavesEveryChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultStatusFile)
+	var out bytes.Buffer
+	status, err := loadCampaignStatus(path, &out)
+	CheckIfError(err)
+
+	status.set("app", statePROpened, "", "https://github.com/org/app/pull/1")
+	status.set("app", stateMerged, "", "")
+	status.set("web", stateFailed, "git error: pushing sharfer/fix", "")
+
+	reloaded, err := loadCampaignStatus(path, &out)
+	CheckIfError(err)
+	repos := reloaded.Repositories()
+	if len(repos) != 2 || repos[0].Repository != "app" || repos[0].State != stateMerged || repos[0].PullRequest != "https://github.com/org/app/pull/1" {
+		t.Errorf("unexpected saved status %+v", repos)
+	}
+	if failed := reloaded.Failed(); len(failed) != 1 || !failed["web"] {
+		t.Errorf("expected web to be failed, got %v", failed)
+	}
+	if !strings.Contains(out.String(), "web: failed: git error: pushing sharfer/fix") {
+		t.Errorf("expected progress to be printed, got:\n%s", out.String())
+	}
+}
+
+// --- Tests for runCampaign ---
+
+func TestRunCampaign_RecordsFailuresAndSkips(t *testing.T) {
+	workspace := t.TempDir()
+	root := campaignRepo(t, workspace, "app", unfixedWorkflow)
+	campaignRepo(t, workspace, "archived", unfixedWorkflow)
+
+	// Pushing to a missing remote fails after the fixes are written.
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	CheckIfError(repo.DeleteRemote("origin"))
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{filepath.Join(workspace, "missing")}})
+	CheckIfError(err)
+
+	plan := &campaignPlan{Targets: []*campaignTarget{
+		{Name: "app", Path: root, Owner: "org", Repo: "app"},
+		{Name: "archived", Path: filepath.Join(workspace, "archived"), Blockers: []string{"archived"}},
+	}}
+	var out bytes.Buffer
+	status, err := loadCampaignStatus(filepath.Join(workspace, defaultStatusFile), &out)
+	CheckIfError(err)
+
+	runCampaign(plan, CampaignOptions{Fixers: []Fixer{PermissionsRule{}}, Workers: 2}, status)
+
+	repos := status.Repositories()
+	if len(repos) != 2 {
+		t.Fatalf("unexpected status %+v", repos)
+	}
+	if repos[0].State != stateFailed || !strings.Contains(repos[0].Reason, "pushing") {
+		t.Errorf("expected app to fail pushing, got %+v", repos[0])
+	}
+	if repos[1].State != stateSkipped || repos[1].Reason != "archived" {
+		t.Errorf("expected archived to be skipped, got %+v", repos[1])
+	}
+	for _, expected := range []string{"app: cloned", "app: fixed", "app: failed"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the progress:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestPlanCampaign_OnlyRetriesGivenRepositories(t *testing.T) {
+	workspace := t.TempDir()
+	campaignRepo(t, workspace, "app", unfixedWorkflow)
+	campaignRepo(t, workspace, "web", unfixedWorkflow)
+	lookup := func(owner, name, fixBranch string) (repoState, error) {
+		return repoState{DefaultBranch: "master"}, nil
+	}
+
+	plan, err := planCampaign(workspace, CampaignOptions{Fixers: []Fixer{PermissionsRule{}}, Only: map[string]bool{"web": true}}, lookup)
+	CheckIfError(err)
+	if len(plan.Targets) != 1 || plan.Targets[0].Name != "web" {
+		t.Errorf("expected only web to be planned, got %+v", plan.Targets)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// circleCIFile is the pipeline file of CircleCI, relative to the root of a repository
+const circleCIFile = ".circleci/config.yml"
+
+// circleCIGraphQLURL is the API of the CircleCI orb registry
+const circleCIGraphQLURL = "https://circleci.com/graphql-unstable"
+
+// ruleUnpinnedOrb flags orbs referenced by a version that can change
+const ruleUnpinnedOrb = "unpinned-orb"
+
+// CircleCIChecker flags orbs not pinned to an exact version and Docker executors not pinned
+// to a digest. Published orb versions cannot be changed, but volatile, ranges such as @5 or
+// @5.1 and dev versions pick up whatever the owner of the orb publishes next.
+type CircleCIChecker struct{}
+
+func (c CircleCIChecker) File() string {
+	return circleCIFile
+}
+
+func (c CircleCIChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	seen := map[*yaml.Node]bool{}
+	for _, root := range wf.roots() {
+		for _, orb := range orbRefs(root) {
+			name, version, _ := strings.Cut(orb.Value, "@")
+			if isExactOrbVersion(version) {
+				continue
+			}
+			severity, reason := SeverityMedium, "the version range "+version
+			switch {
+			case version == "" || version == "volatile":
+				severity, reason = SeverityHigh, "volatile, the latest version"
+			case strings.HasPrefix(version, "dev:"):
+				severity, reason = SeverityHigh, "the development version "+version
+			}
+			findings = append(findings, wf.finding(ruleUnpinnedOrb, severity, orb, fmt.Sprintf(
+				"orb %s follows %s. Pin it to an exact version, with scharf fix --orbs", name, reason)))
+		}
+
+		// Executors are defined under executors: and by jobs directly.
+		for _, section := range []string{"executors", "jobs"} {
+			for _, p := range mappingPairs(mappingValue(root, section)) {
+				docker := resolveAlias(mappingValue(resolveAlias(p.Value), "docker"))
+				if docker == nil || docker.Kind != yaml.SequenceNode {
+					continue
+				}
+				for _, container := range docker.Content {
+					if img := pipelineImage(mappingValue(resolveAlias(container), "image")); img != nil && !seen[img] {
+						seen[img] = true
+						findings = append(findings, unpinnedImageFinding(wf, img)...)
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// orbRefs returns the orb references of the orbs: section. Inline orbs are definitions, not
+// references, and orbs set through pipeline parameters cannot be told.
+func orbRefs(root *yaml.Node) []*yaml.Node {
+	var refs []*yaml.Node
+	for _, p := range mappingPairs(mappingValue(root, "orbs")) {
+		if n := resolveAlias(p.Value); n != nil && n.Kind == yaml.ScalarNode && !strings.Contains(n.Value, "<<") {
+			refs = append(refs, n)
+		}
+	}
+	return refs
+}
+
+// parseOrbVersion reads the parts of a full or partial semantic version, such as 5, 5.1
+// or 5.1.0
+func parseOrbVersion(v string) ([]int, bool) {
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, len(parts) >= 1 && len(parts) <= 3
+}
+
+// isExactOrbVersion reports whether an orb version is a full semantic version
+func isExactOrbVersion(v string) bool {
+	parts, ok := parseOrbVersion(v)
+	return ok && len(parts) == 3
+}
+
+// orbVersions lists the published versions of an orb in the CircleCI registry
+func orbVersions(name string) ([]string, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":     `query($name: String!) { orb(name: $name) { versions(count: 200) { version } } }`,
+		"variables": map[string]string{"name": name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	resp, err := post(orbRegistryHTTP, circleCIGraphQLURL, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: orb registry returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	var result struct {
+		Data struct {
+			Orb *struct {
+				Versions []struct {
+					Version string `json:"version"`
+				} `json:"versions"`
+			} `json:"orb"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	if result.Data.Orb == nil {
+		return nil, fmt.Errorf("orb %s is not in the registry", name)
+	}
+
+	versions := make([]string, 0, len(result.Data.Orb.Versions))
+	for _, v := range result.Data.Orb.Versions {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// latestOrbVersion returns the highest exact version of versions within a range, such as 5
+// or 5.1. Volatile and an empty range take the highest version of all.
+func latestOrbVersion(versions []string, constraint string) (string, bool) {
+	var want []int
+	if constraint != "" && constraint != "volatile" {
+		var ok bool
+		if want, ok = parseOrbVersion(constraint); !ok {
+			return "", false
+		}
+	}
+
+	var best []int
+	var latest string
+	for _, v := range versions {
+		parts, ok := parseOrbVersion(v)
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		matches := true
+		for i, w := range want {
+			matches = matches && parts[i] == w
+		}
+		if matches && (best == nil || versionLess(best, parts)) {
+			best, latest = parts, v
+		}
+	}
+	return latest, latest != ""
+}
+
+// versionLess reports whether the version a precedes b
+func versionLess(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// OrbFixer pins orbs to the latest exact version of the range they follow. Dev versions
+// have no exact counterpart and are left to people.
+type OrbFixer struct {
+	// versions lists the published versions of an orb
+	versions func(name string) ([]string, error)
+}
+
+// NewOrbFixer creates a fixer resolving orbs through the CircleCI registry
+func NewOrbFixer() OrbFixer {
+	return OrbFixer{versions: orbVersions}
+}
+
+func (f OrbFixer) File() string {
+	return circleCIFile
+}
+
+func (f OrbFixer) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	var edits []TextEdit
+	for _, root := range wf.roots() {
+		for _, orb := range orbRefs(root) {
+			name, version, _ := strings.Cut(orb.Value, "@")
+			if isExactOrbVersion(version) || strings.HasPrefix(version, "dev:") {
+				continue
+			}
+			versions, err := f.versions(name)
+			if err != nil {
+				return nil, err
+			}
+			exact, ok := latestOrbVersion(versions, version)
+			if !ok {
+				logger.Warn("no published version of orb matches", "orb", name, "version", version)
+				continue
+			}
+
+			start, ok := wf.lines.locate(orb.Value, wf.lines.offset(orb.Line, orb.Column))
+			if !ok {
+				logger.Debug("could not locate orb to pin", "file", wf.Path, "line", orb.Line, "orb", orb.Value)
+				continue
+			}
+			edits = append(edits, TextEdit{Start: start, End: start + len(orb.Value), NewText: name + "@" + exact})
+		}
+	}
+	return edits, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const circleCIConfig = `version: 2.1
+orbs:
+  node: circleci/node@5.1.0
+  aws: circleci/aws-cli@volatile
+  slack: circleci/slack@4
+  docker: circleci/docker@2.4
+  mine: myorg/tools@dev:alpha
+  param: << pipeline.parameters.orb >>
+  inline:
+    commands:
+      hello:
+        steps: [run: echo hello]
+executors:
+  base:
+    docker:
+      - image: cimg/base:2024.01
+jobs:
+  build:
+    docker:
+      - image: cimg/node:18.0@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+      - image: postgres:16
+  test:
+    executor: base
+`
+
+// --- Tests for CircleCIChecker.Check ---
+
+func TestCircleCIChecker_Check(t *testing.T) {
+	findings := CircleCIChecker{}.Check(newWorkflowFile(circleCIFile, []byte(circleCIConfig)))
+
+	expected := map[int]string{
+		4:  ruleUnpinnedOrb + " " + SeverityHigh,
+		5:  ruleUnpinnedOrb + " " + SeverityMedium,
+		6:  ruleUnpinnedOrb + " " + SeverityMedium,
+		7:  ruleUnpinnedOrb + " " + SeverityHigh,
+		16: ruleUnpinnedImage + " " + SeverityMedium,
+		21: ruleUnpinnedImage + " " + SeverityMedium,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule+" "+f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+// --- Tests for latestOrbVersion ---
+
+func TestLatestOrbVersion(t *testing.T) {
+	versions := []string{"4.12.0", "4.9.1", "5.0.0", "5.1.0", "5.1.2", "2.4.3", "dev:alpha"}
+
+	tests := []struct {
+		constraint string
+		expected   string
+		found      bool
+	}{
+		{"volatile", "5.1.2", true},
+		{"", "5.1.2", true},
+		{"4", "4.12.0", true},
+		{"5.1", "5.1.2", true},
+		{"2.4", "2.4.3", true},
+		{"6", "", false},
+		{"dev:alpha", "", false},
+	}
+
+	for _, tc := range tests {
+		got, found := latestOrbVersion(versions, tc.constraint)
+		if got != tc.expected || found != tc.found {
+			t.Errorf("latestOrbVersion(%q) = %q, %v; want %q, %v", tc.constraint, got, found, tc.expected, tc.found)
+		}
+	}
+}
+
+// --- Tests for orbVersions ---
+
+func TestOrbVersions(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if req.URL.String() != circleCIGraphQLURL || !strings.Contains(string(body), `"name":"circleci/node"`) {
+			t.Errorf("unexpected request %s %s", req.URL, body)
+		}
+		resp := `{"data": {"orb": {"versions": [{"version": "5.1.0"}, {"version": "5.0.0"}]}}}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(resp)), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(transport, func() {
+		versions, err := orbVersions("circleci/node")
+		CheckIfError(err)
+		if strings.Join(versions, ",") != "5.1.0,5.0.0" {
+			t.Errorf("unexpected versions %v", versions)
+		}
+	})
+}
+
+// --- Tests for OrbFixer ---
+
+func TestOrbFixer(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, filepath.FromSlash(circleCIFile))
+	CheckIfError(os.MkdirAll(filepath.Dir(path), 0o755))
+	CheckIfError(os.WriteFile(path, []byte(circleCIConfig), 0o644))
+
+	published := map[string][]string{
+		"circleci/aws-cli": {"4.1.3", "4.0.0"},
+		"circleci/slack":   {"4.13.3", "5.0.0"},
+		"circleci/docker":  {"2.4.0", "2.5.0"},
+	}
+	fixer := OrbFixer{versions: func(name string) ([]string, error) { return published[name], nil }}
+
+	// The repository has no GitHub workflows, which pipeline fixers do not need.
+	var out bytes.Buffer
+	results, err := FixWorkflows(root, []Fixer{fixer}, false, &out)
+	CheckIfError(err)
+	if len(results) != 1 || results[0].Path != path {
+		t.Fatalf("expected the CircleCI configuration to be fixed, got %+v", results)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, pinned := range []string{"circleci/node@5.1.0", "circleci/aws-cli@4.1.3", "circleci/slack@4.13.3", "circleci/docker@2.4.0", "myorg/tools@dev:alpha"} {
+		if !strings.Contains(string(content), pinned) {
+			t.Errorf("expected %s in the fixed configuration:\n%s", pinned, content)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// HTTPClient sends the requests of a service scharf talks to over HTTP. *http.Client
+// satisfies it, and so do the fakes of the sharfertest package, which answer in memory.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// GitTransport clones the repositories scanned over git, and pushes the branches of fixes
+type GitTransport interface {
+	// Clone checks out the last commit of ref, a branch or a tag, or of the default branch
+	// when ref is empty, into dir. When sparse is set, only the files at the root and below
+	// those directories are needed.
+	Clone(dir, cloneURL, ref string, sparse []string) error
+	// Push pushes refspecs, such as +refs/heads/fix:refs/heads/fix, of the repository
+	// checked out at dir to one of its remotes
+	Push(dir, remote string, refSpecs []string) error
+}
+
+// Clients of the external services: the GitHub API and the files of repositories, OCI
+// registries and git hosts. Tests replace them with fakes to run offline.
+var (
+	// githubHTTP sends requests to GitHub, under the retries of githubClient
+	githubHTTP HTTPClient = http.DefaultClient
+	// registryHTTP sends requests to the distribution API of OCI registries
+	registryHTTP HTTPClient = http.DefaultClient
+	// orbRegistryHTTP sends queries to the orb registry of CircleCI
+	orbRegistryHTTP HTTPClient = http.DefaultClient
+	// metadataHTTP asks the metadata servers of cloud workloads for tokens
+	metadataHTTP HTTPClient = http.DefaultClient
+	// webhookHTTP posts alerts and reports to webhooks, such as Slack incoming webhooks
+	webhookHTTP HTTPClient = http.DefaultClient
+	// gitTransport clones repositories from their git hosts
+	gitTransport GitTransport = remoteTransport{}
+)
+
+// remoteTransport clones from git hosts. Sparse clones need the git binary; without it, or
+// when the server refuses them, the whole last commit is cloned.
+type remoteTransport struct{}
+
+func (remoteTransport) Clone(dir, cloneURL, ref string, sparse []string) error {
+	if len(sparse) > 0 {
+		if gitPath, err := exec.LookPath("git"); err == nil {
+			err = sparseClone(gitPath, dir, cloneURL, ref, sparse)
+			if err == nil {
+				return nil
+			}
+			logger.Debug("partial clone failed. falling back to a shallow clone", "url", cloneURL, "err", err)
+			if err := resetDir(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return shallowClone(dir, cloneURL, ref)
+}
+
+func (remoteTransport) Push(dir, remote string, refSpecs []string) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return fmt.Errorf("git error: %w", err)
+	}
+	specs := make([]config.RefSpec, len(refSpecs))
+	for i, spec := range refSpecs {
+		specs[i] = config.RefSpec(spec)
+	}
+	return repo.Push(&git.PushOptions{RemoteName: remote, RefSpecs: specs, Auth: pushAuth(repo, remote)})
+}
+
+// post sends body to url through client, the way http.Client.Post does
+func post(client HTTPClient, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return client.Do(req)
+}
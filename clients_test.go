@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cybrota/scharf/sharfertest"
+)
+
+// The fakes of sharfertest stand in for the clients of the external services.
+var (
+	_ HTTPClient   = (*sharfertest.GitHub)(nil)
+	_ HTTPClient   = (*sharfertest.Registry)(nil)
+	_ GitTransport = (*sharfertest.Remotes)(nil)
+)
+
+// withClients replaces the clients of the external services until the end of the test.
+// Nil clients are left as they are.
+func withClients(t *testing.T, github, registry HTTPClient, transport GitTransport) {
+	savedGitHub, savedRegistry, savedTransport := githubHTTP, registryHTTP, gitTransport
+	t.Cleanup(func() { githubHTTP, registryHTTP, gitTransport = savedGitHub, savedRegistry, savedTransport })
+	if github != nil {
+		githubHTTP = github
+	}
+	if registry != nil {
+		registryHTTP = registry
+	}
+	if transport != nil {
+		gitTransport = transport
+	}
+}
+
+// --- Tests for offline scans through fake clients ---
+
+func TestScanOrg_FakeClients(t *testing.T) {
+	withGitHubEndpoints(t)
+	gh := sharfertest.NewGitHub(t)
+	gh.File("acme/api", "HEAD", "README.md", "# api\n")
+	gh.File("acme/web", "HEAD", "README.md", "# web\n")
+
+	api := sharfertest.NewRepo(t)
+	api.CommitWorkflows(map[string]string{"ci.yml": sharfertest.MutableWorkflow})
+	web := sharfertest.NewRepo(t)
+	web.CommitWorkflows(map[string]string{"ci.yml": sharfertest.PinnedWorkflow})
+	remotes := sharfertest.NewRemotes()
+	remotes.Add("https://github.com/acme/api.git", api)
+	remotes.Add("https://github.com/acme/web.git", web)
+	withClients(t, gh, nil, remotes)
+
+	inv, err := (&Scanner{}).ScanOrg("acme", mutableRefRegex, OrgScanOptions{Clone: true})
+	CheckIfError(err)
+	if len(inv.Records) != 1 || inv.Records[0].Repository != "acme/api" || !reflect.DeepEqual(inv.Records[0].Matches, []string{"actions/checkout@v4", "actions/setup-go@v5"}) {
+		t.Fatalf("expected the mutable references of acme/api only, got %+v", inv.Records)
+	}
+	if got := remotes.Clones(); len(got) != 2 {
+		t.Errorf("expected both repositories to be cloned, got %v", got)
+	}
+}
+
+func TestImageDigestRule_FakeRegistry(t *testing.T) {
+	reg := sharfertest.NewRegistry()
+	reg.Tag("docker.io/library/node", "20", digestA)
+	withClients(t, nil, reg, nil)
+
+	content := "jobs:\n  build:\n    container:\n      image: node@" + digestC + "\n    steps:\n      - uses: docker://node@" + digestA + "\n"
+	findings := NewImageDigestRule(newRegistryClient(nil), defaultMaxImageTags).Check(newWorkflowFile("wf.yml", []byte(content)))
+	if len(findings) != 1 || findings[0].Rule != ruleStaleImageDigest || findings[0].Line != 4 {
+		t.Errorf("expected the digest no tag points to to be flagged, got %+v", findings)
+	}
+}
+
+func TestOpenFixPR_FakeRemotes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("pushing to a local remote needs the git binary")
+	}
+	t.Setenv("GITHUB_TOKEN", "token")
+	upstream := sharfertest.NewRepo(t)
+	upstream.CommitWorkflows(map[string]string{"ci.yml": unfixedWorkflow})
+	remotes := sharfertest.NewRemotes()
+	remotes.Add("https://github.com/acme/app.git", upstream)
+	withClients(t, nil, nil, remotes)
+
+	root := filepath.Join(t.TempDir(), "app")
+	CheckIfError(gitTransport.Clone(root, "https://github.com/acme/app.git", "", nil))
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, io.Discard)
+	CheckIfError(err)
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusCreated, `{"number": 7}`
+		if req.Method == http.MethodGet {
+			status, body = http.StatusOK, "[]"
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+	withHTTPClientTransport(customTransport, func() {
+		pr, err := openFixPR(root, results, FixPROptions{Owner: "acme", Name: "app", Title: "Secure GitHub workflows"})
+		if err != nil || pr.Number != 7 {
+			t.Fatalf("openFixPR = %+v, %v", pr, err)
+		}
+	})
+
+	if got := remotes.Pushes(); len(got) != 1 || got[0] != "https://github.com/acme/app.git +refs/heads/"+defaultFixBranch+":refs/heads/"+defaultFixBranch {
+		t.Errorf("expected the fix branch to be pushed through the transport, got %v", got)
+	}
+}
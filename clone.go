@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// cloneTarget returns the git URL, name and ref of a repository URL. GitHub URLs, such as
+// https://github.com/owner/repo/tree/branch, are cloned from the GitHub host; other git
+// URLs are cloned as they are, at their default branch.
+func cloneTarget(raw string) (string, string, string) {
+	if owner, name, ref, err := parseGitHubURL(raw); err == nil {
+		return fmt.Sprintf("%s/%s/%s.git", serverURL, owner, name), owner + "/" + name, ref
+	}
+	name := raw
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+	return raw, strings.TrimSuffix(strings.Trim(name, "/"), ".git"), ""
+}
+
+// cloneAuth authenticates clones from the GitHub host over HTTPS with the GitHub token.
+// Tokens are never sent to other hosts.
+func cloneAuth(cloneURL string) transport.AuthMethod {
+	token := githubToken()
+	if token == "" || !strings.HasPrefix(cloneURL, serverURL+"/") || !strings.HasPrefix(cloneURL, "https://") {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: "x-access-token", Password: token}
+}
+
+// ScanClone clones a repository into a temporary directory, scans the workflows of the ref
+// cloned and removes the clone. Unlike ScanRemoteRepository, files are read over git, so
+// repositories of any git host can be scanned without spending API calls. File paths are
+// relative to the repository root. The FileScanner defaults to GitHubWorkFlowScanner when
+// unset.
+func (s *Scanner) ScanClone(rawURL string, regex *regexp.Regexp) (*Inventory, error) {
+	if s.FileScanner == nil {
+		s.FileScanner = GitHubWorkFlowScanner{}
+	}
+	cloneURL, name, ref := cloneTarget(rawURL)
+	records, err := s.scanClone(cloneURL, name, ref, regex)
+	if err != nil {
+		return nil, err
+	}
+	return &Inventory{Records: records}, nil
+}
+
+// scanClone clones a repository at ref into a temporary directory, scans it and removes
+// the clone
+func (s *Scanner) scanClone(cloneURL, name, ref string, regex *regexp.Regexp) ([]*InventoryRecord, error) {
+	dir, err := os.MkdirTemp("", "scharf-clone-")
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sparse, ok := s.sparseDirs()
+	if !ok {
+		sparse = nil
+	}
+	if err := gitTransport.Clone(dir, cloneURL, ref, sparse); err != nil {
+		return nil, err
+	}
+
+	branch := ref
+	if branch == "" {
+		branch = "HEAD"
+	}
+	records := s.ScanBranch(branch, GitRepository{name: name, localPath: dir}, regex, workflowDir(dir))
+	for _, ir := range records {
+		ir.FilePath = relativePath(dir, ir.FilePath)
+		// Paths are relative to the root of the clone, which is removed.
+		ir.RepoRoot = "."
+	}
+	return records, nil
+}
+
+// sparseDirs returns the directories below the repository root the scan reads, besides the
+// files at the root, or false when it may read files anywhere: templates of pipelines and
+// project templates can sit in any directory.
+func (s *Scanner) sparseDirs() ([]string, bool) {
+	if s.Templates {
+		return nil, false
+	}
+	dirs := []string{".github"}
+	add := func(p string) {
+		if top, _, nested := strings.Cut(p, "/"); nested && !slices.Contains(dirs, top) {
+			dirs = append(dirs, top)
+		}
+	}
+	for _, p := range s.Pipelines {
+		if _, ok := p.(PipelineTemplates); ok {
+			return nil, false
+		}
+		add(p.File())
+		if d, ok := p.(PipelineDirectory); ok {
+			add(d.Directory() + "/")
+		}
+	}
+	if s.CheckCodeowners || s.Components.Codeowners {
+		for _, loc := range codeownersLocations {
+			add(loc)
+		}
+	}
+	return dirs, true
+}
+
+// sparseClone clones the last commit of ref, a branch or a tag, or of the default branch
+// when ref is empty, with the git binary. Only the files at the root and below dirs are
+// checked out, and servers supporting partial clones send the blobs of those files only.
+// The GitHub token authenticates through the environment, so it does not show in the
+// arguments of the process.
+func sparseClone(gitPath, dir, cloneURL, ref string, dirs []string) error {
+	args := []string{"clone", "--quiet", "--depth", "1", "--single-branch", "--filter=blob:none", "--sparse"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if auth, ok := cloneAuth(cloneURL).(*githttp.BasicAuth); ok {
+		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		env = append(env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+
+	run := func(args ...string) error {
+		cmd := exec.Command(gitPath, args...)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git error: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	if err := run(append(args, "--", cloneURL, dir)...); err != nil {
+		return err
+	}
+	return run(append([]string{"-C", dir, "sparse-checkout", "add", "--"}, dirs...)...)
+}
+
+// resetDir empties a directory a failed clone left files in
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	return nil
+}
+
+// shallowClone clones the last commit of ref, a branch or a tag, or of the default branch
+// when ref is empty
+func shallowClone(dir, cloneURL, ref string) error {
+	opts := &git.CloneOptions{URL: cloneURL, Depth: 1, SingleBranch: true, Auth: cloneAuth(cloneURL)}
+	if ref == "" {
+		if _, err := git.PlainClone(dir, false, opts); err != nil {
+			return fmt.Errorf("git error: %w", err)
+		}
+		return nil
+	}
+
+	var err error
+	for _, refName := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)} {
+		opts.ReferenceName = refName
+		if _, err = git.PlainClone(dir, false, opts); err == nil {
+			return nil
+		}
+		// A failed attempt may leave a partial clone behind.
+		if err := resetDir(dir); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("git error: %s has no branch or tag %s: %w", cloneURL, ref, err)
+}
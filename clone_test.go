@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for cloneTarget ---
+
+func TestCloneTarget(t *testing.T) {
+	tests := []struct {
+		raw      string
+		cloneURL string
+		name     string
+		ref      string
+	}{
+		{"https://github.com/owner/repo", "https://github.com/owner/repo.git", "owner/repo", ""},
+		{"https://github.com/owner/repo/tree/release/v2", "https://github.com/owner/repo.git", "owner/repo", "release/v2"},
+		{"https://gitlab.com/group/project.git", "https://gitlab.com/group/project.git", "gitlab.com/group/project", ""},
+		{"/srv/git/project.git", "/srv/git/project.git", "srv/git/project", ""},
+	}
+	for _, tc := range tests {
+		cloneURL, name, ref := cloneTarget(tc.raw)
+		if cloneURL != tc.cloneURL || name != tc.name || ref != tc.ref {
+			t.Errorf("cloneTarget(%s) = %s, %s, %s; want %s, %s, %s", tc.raw, cloneURL, name, ref, tc.cloneURL, tc.name, tc.ref)
+		}
+	}
+}
+
+// --- Tests for cloneAuth ---
+
+func TestCloneAuth(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	if cloneAuth("https://github.com/owner/repo.git") == nil {
+		t.Error("expected clones from GitHub to use the token")
+	}
+	if cloneAuth("https://gitlab.com/group/project.git") != nil {
+		t.Error("expected the token not to be sent to other hosts")
+	}
+}
+
+// --- Tests for ScanClone ---
+
+func TestScanner_ScanClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("cloning from a local path needs the git binary")
+	}
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	CheckIfError(err)
+	CheckIfError(os.MkdirAll(workflowDir(dir), 0755))
+	CheckIfError(os.WriteFile(filepath.Join(workflowDir(dir), "ci.yml"), []byte(unfixedWorkflow), 0644))
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	head, err := w.Commit("add ci", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+	_, err = repo.CreateTag("v1", head, nil)
+	CheckIfError(err)
+
+	inv, err := (&Scanner{}).ScanClone(dir, mutableRefRegex)
+	if err != nil {
+		t.Fatalf("ScanClone returned error: %v", err)
+	}
+	if len(inv.Records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(inv.Records))
+	}
+	ir := inv.Records[0]
+	if ir.FilePath != ".github/workflows/ci.yml" || ir.Branch != "HEAD" || len(ir.Locations) == 0 {
+		t.Errorf("expected the mutable references of the clone relative to its root, got %+v", ir)
+	}
+
+	if _, err := (&Scanner{}).ScanClone(filepath.Join(t.TempDir(), "missing"), mutableRefRegex); err == nil {
+		t.Error("expected an error for a repository that cannot be cloned")
+	}
+
+	// Refs are cloned as branches, then as tags.
+	CheckIfError(shallowClone(t.TempDir(), dir, "v1"))
+	if err := shallowClone(t.TempDir(), dir, "nope"); err == nil {
+		t.Error("expected an error for a ref that is neither a branch nor a tag")
+	}
+}
+
+// --- Tests for sparseClone ---
+
+func TestSparseClone(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("partial clones need the git binary")
+	}
+
+	src := t.TempDir()
+	repo, err := git.PlainInit(src, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	for _, name := range []string{".github/workflows/ci.yml", ".gitlab-ci.yml", "src/main.go"} {
+		CheckIfError(os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0755))
+		CheckIfError(os.WriteFile(filepath.Join(src, name), []byte("on: push\n"), 0644))
+		_, err = w.Add(name)
+		CheckIfError(err)
+	}
+	head, err := w.Commit("add files", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+	_, err = repo.CreateTag("v1", head, nil)
+	CheckIfError(err)
+
+	for _, ref := range []string{"", "v1"} {
+		dir := t.TempDir()
+		CheckIfError(sparseClone(gitPath, dir, "file://"+src, ref, []string{".github"}))
+		for name, want := range map[string]bool{".github/workflows/ci.yml": true, ".gitlab-ci.yml": true, "src/main.go": false} {
+			if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+				t.Errorf("ref %q: expected %s to be checked out: %v", ref, name, want)
+			}
+		}
+	}
+	if err := sparseClone(gitPath, t.TempDir(), "file://"+src, "nope", nil); err == nil {
+		t.Error("expected an error for a ref that is neither a branch nor a tag")
+	}
+}
+
+// --- Tests for Scanner.sparseDirs ---
+
+func TestScanner_SparseDirs(t *testing.T) {
+	tests := []struct {
+		name    string
+		scanner Scanner
+		dirs    []string
+		sparse  bool
+	}{
+		{"workflows", Scanner{}, []string{".github"}, true},
+		{"pipelines", Scanner{Pipelines: []PipelineChecker{GitLabCIChecker{}, CircleCIChecker{}, ArgoChecker{Dir: "argo"}}}, []string{".github", ".circleci", "argo"}, true},
+		{"codeowners", Scanner{CheckCodeowners: true}, []string{".github", "docs"}, true},
+		{"pipeline templates", Scanner{Pipelines: []PipelineChecker{AzurePipelinesChecker{}}}, nil, false},
+		{"project templates", Scanner{Templates: true}, nil, false},
+	}
+	for _, tc := range tests {
+		dirs, sparse := tc.scanner.sparseDirs()
+		if !slices.Equal(dirs, tc.dirs) || sparse != tc.sparse {
+			t.Errorf("%s: sparseDirs() = %v, %v; want %v, %v", tc.name, dirs, sparse, tc.dirs, tc.sparse)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Registries of the cloud providers, whose credentials are obtained from their CLIs. The
+// CLIs pick up IAM roles, workload identity and managed identities by themselves.
+var (
+	ecrRegistry = regexp.MustCompile(`^\d{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+	gcpRegistry = regexp.MustCompile(`^([a-z]+\.)?gcr\.io$|^[a-z0-9-]+-docker\.pkg\.dev$`)
+	acrRegistry = regexp.MustCompile(`^([a-z0-9]+)\.azurecr\.(io|cn|us)$`)
+)
+
+// gcpMetadataToken is where workload identity hands out tokens when gcloud is not installed
+const gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// runCommand runs a CLI and returns its standard output. Tests replace it.
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// cloudCredentials authenticates to ECR, GCR, Artifact Registry and ACR through the CLI of
+// their cloud, and to other registries with the logins of the docker config. Credentials
+// are fetched once per registry.
+type cloudCredentials struct {
+	mu    sync.Mutex
+	cache map[string][2]string
+}
+
+// newCloudCredentials creates the registry credentials used by default
+func newCloudCredentials() *cloudCredentials {
+	return &cloudCredentials{cache: map[string][2]string{}}
+}
+
+// credentials implements registryCredentials
+func (c *cloudCredentials) credentials(registry string) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cred, ok := c.cache[registry]; ok {
+		return cred[0], cred[1], nil
+	}
+
+	user, pass, err := lookupRegistryCredentials(registry)
+	if err != nil {
+		return "", "", err
+	}
+	c.cache[registry] = [2]string{user, pass}
+	return user, pass, nil
+}
+
+// lookupRegistryCredentials picks the credentials of a registry by its host
+func lookupRegistryCredentials(registry string) (string, string, error) {
+	if user, pass, ok := dockerConfigCredentials(registry); ok {
+		return user, pass, nil
+	}
+
+	switch {
+	case ecrRegistry.MatchString(registry):
+		region := ecrRegistry.FindStringSubmatch(registry)[2]
+		out, err := runCommand("aws", "ecr", "get-login-password", "--region", region)
+		if err != nil {
+			return "", "", fmt.Errorf("ecr: %w", err)
+		}
+		return "AWS", strings.TrimSpace(string(out)), nil
+
+	case gcpRegistry.MatchString(registry):
+		token, err := gcpAccessToken()
+		if err != nil {
+			return "", "", fmt.Errorf("gcp: %w", err)
+		}
+		return "oauth2accesstoken", token, nil
+
+	case acrRegistry.MatchString(registry):
+		name := acrRegistry.FindStringSubmatch(registry)[1]
+		out, err := runCommand("az", "acr", "login", "--name", name, "--expose-token", "--output", "tsv", "--query", "accessToken")
+		if err != nil {
+			return "", "", fmt.Errorf("acr: %w", err)
+		}
+		// ACR tokens are accepted along with this fixed user name.
+		return "00000000-0000-0000-0000-000000000000", strings.TrimSpace(string(out)), nil
+	}
+	return "", "", nil
+}
+
+// gcpAccessToken gets a token from gcloud, or from the metadata server on GCP workloads
+func gcpAccessToken() (string, error) {
+	out, cliErr := runCommand("gcloud", "auth", "print-access-token")
+	if cliErr == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataToken, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := metadataHTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcloud failed (%v) and no metadata server: %w", cliErr, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: metadata server returned %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// dockerConfigCredentials reads a login stored by `docker login` in the docker config.
+// Logins kept in credential helpers are not supported.
+func dockerConfigCredentials(registry string) (string, string, bool) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", "", false
+	}
+
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		logger.Debug("could not read docker config", "err", err)
+		return "", "", false
+	}
+
+	keys := []string{registry, "https://" + registry}
+	if registry == dockerHub {
+		keys = append(keys, "https://index.docker.io/v1/")
+	}
+	for _, k := range keys {
+		entry, ok := cfg.Auths[k]
+		if !ok || entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		if user, pass, ok := strings.Cut(string(decoded), ":"); ok {
+			return user, pass, true
+		}
+	}
+	return "", "", false
+}
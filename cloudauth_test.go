@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withCommands replaces the CLIs run for credentials with canned outputs by command line
+func withCommands(t *testing.T, outputs map[string]string) *[]string {
+	t.Helper()
+	var calls []string
+	orig := runCommand
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		line := strings.Join(append([]string{name}, args...), " ")
+		calls = append(calls, line)
+		if out, ok := outputs[line]; ok {
+			return []byte(out), nil
+		}
+		return nil, errors.New(name + ": not found")
+	}
+	t.Cleanup(func() { runCommand = orig })
+	return &calls
+}
+
+// --- Tests for lookupRegistryCredentials ---
+
+func TestLookupRegistryCredentials(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	withCommands(t, map[string]string{
+		"aws ecr get-login-password --region eu-west-1":                             "ecr-password\n",
+		"gcloud auth print-access-token":                                            "gcp-token\n",
+		"az acr login --name myacr --expose-token --output tsv --query accessToken": "acr-token\n",
+	})
+
+	tests := []struct {
+		registry, user, pass string
+	}{
+		{"123456789012.dkr.ecr.eu-west-1.amazonaws.com", "AWS", "ecr-password"},
+		{"gcr.io", "oauth2accesstoken", "gcp-token"},
+		{"eu.gcr.io", "oauth2accesstoken", "gcp-token"},
+		{"europe-west1-docker.pkg.dev", "oauth2accesstoken", "gcp-token"},
+		{"myacr.azurecr.io", "00000000-0000-0000-0000-000000000000", "acr-token"},
+		{"ghcr.io", "", ""},
+	}
+	for _, tc := range tests {
+		user, pass, err := lookupRegistryCredentials(tc.registry)
+		if err != nil || user != tc.user || pass != tc.pass {
+			t.Errorf("lookupRegistryCredentials(%s) = %q, %q, %v; want %q, %q", tc.registry, user, pass, err, tc.user, tc.pass)
+		}
+	}
+}
+
+func TestLookupRegistryCredentials_Errors(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	withCommands(t, nil)
+
+	if _, _, err := lookupRegistryCredentials("123456789012.dkr.ecr.us-east-1.amazonaws.com"); err == nil || !strings.HasPrefix(err.Error(), "ecr:") {
+		t.Errorf("expected ecr error without the aws CLI, got %v", err)
+	}
+
+	// Without gcloud, workload identity tokens come from the metadata server.
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != gcpMetadataToken || req.Header.Get("Metadata-Flavor") != "Google" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		body := `{"access_token": "metadata-token", "expires_in": 3599}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	})
+	withHTTPClientTransport(transport, func() {
+		if _, pass, err := lookupRegistryCredentials("gcr.io"); err != nil || pass != "metadata-token" {
+			t.Errorf("expected the metadata token, got %q, %v", pass, err)
+		}
+	})
+}
+
+func TestCloudCredentials_Cache(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	calls := withCommands(t, map[string]string{"gcloud auth print-access-token": "gcp-token"})
+
+	creds := newCloudCredentials()
+	for i := 0; i < 3; i++ {
+		if _, pass, err := creds.credentials("gcr.io"); err != nil || pass != "gcp-token" {
+			t.Fatalf("credentials = %q, %v", pass, err)
+		}
+	}
+	if len(*calls) != 1 {
+		t.Errorf("expected gcloud to run once, got %v", *calls)
+	}
+}
+
+// --- Tests for dockerConfigCredentials ---
+
+func TestDockerConfigCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	auth := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	config := `{"auths": {"registry.example.com": {"auth": "` + auth("user:pa:ss") + `"}, "https://index.docker.io/v1/": {"auth": "` + auth("hub:token") + `"}, "123456789012.dkr.ecr.eu-west-1.amazonaws.com": {"auth": "` + auth("AWS:stored") + `"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, pass, ok := dockerConfigCredentials("registry.example.com"); !ok || user != "user" || pass != "pa:ss" {
+		t.Errorf("got %q, %q, %v; want user, pa:ss", user, pass, ok)
+	}
+	if user, _, ok := dockerConfigCredentials(dockerHub); !ok || user != "hub" {
+		t.Errorf("expected Docker Hub login, got %q, %v", user, ok)
+	}
+	if _, _, ok := dockerConfigCredentials("ghcr.io"); ok {
+		t.Error("expected no login for ghcr.io")
+	}
+
+	// A docker login wins over the cloud CLIs.
+	withCommands(t, nil)
+	if _, pass, err := lookupRegistryCredentials("123456789012.dkr.ecr.eu-west-1.amazonaws.com"); err != nil || pass != "stored" {
+		t.Errorf("expected the docker login, got %q, %v", pass, err)
+	}
+}
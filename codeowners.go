@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ruleWorkflowCodeowners flags workflow files whose changes need no review by their owners
+const ruleWorkflowCodeowners = "workflow-codeowners"
+
+// CodeownersConfig sets who must own workflow files in CODEOWNERS
+type CodeownersConfig struct {
+	// RequiredOwners lists owners of which at least one must own every workflow file,
+	// such as a security team. Ex: @mycorp/security
+	RequiredOwners []string `yaml:"required_owners"`
+}
+
+// codeownersFinding checks that a workflow file is covered by CODEOWNERS and, if required
+// owners are set, owned by one of them. Workflow changes run code with the secrets and
+// token of the repository, so they deserve the same review as the code they build.
+// It returns nil when the file is covered.
+func codeownersFinding(owners []codeownersRule, path string, required []string) *Finding {
+	fileOwners := ownersOf(owners, path)
+	if len(fileOwners) == 0 {
+		return &Finding{
+			Rule:     ruleWorkflowCodeowners,
+			Severity: SeverityMedium,
+			Message: fmt.Sprintf("workflow %s is not covered by CODEOWNERS, so changes to it can be merged without review by its owners",
+				path),
+		}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	for _, o := range fileOwners {
+		for _, r := range required {
+			if strings.EqualFold(o, r) {
+				return nil
+			}
+		}
+	}
+	return &Finding{
+		Rule:     ruleWorkflowCodeowners,
+		Severity: SeverityMedium,
+		Message: fmt.Sprintf("workflow %s is owned by %s in CODEOWNERS, but must be owned by one of %s",
+			path, strings.Join(fileOwners, ", "), strings.Join(required, ", ")),
+	}
+}
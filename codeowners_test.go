@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for codeownersFinding ---
+
+func TestCodeownersFinding(t *testing.T) {
+	owners := parseCodeowners([]byte(`* @org/everyone
+/.github/workflows/ @org/security @org/platform
+/.github/workflows/release.yml @org/release
+`))
+
+	tests := []struct {
+		name     string
+		owners   []codeownersRule
+		path     string
+		required []string
+		expected string // substring of the message, or "" for no finding
+	}{
+		{name: "no CODEOWNERS", path: ".github/workflows/ci.yml", expected: "not covered by CODEOWNERS"},
+		{name: "covered", owners: owners, path: ".github/workflows/ci.yml"},
+		{name: "required owner among several", owners: owners, path: ".github/workflows/ci.yml", required: []string{"@ORG/security"}},
+		{name: "last match wins", owners: owners, path: ".github/workflows/release.yml", required: []string{"@org/security"}, expected: "owned by @org/release"},
+		{name: "only the catch-all", owners: parseCodeowners([]byte("*.go @org/backend\n")), path: ".github/workflows/ci.yml", expected: "not covered"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := codeownersFinding(tc.owners, tc.path, tc.required)
+			switch {
+			case tc.expected == "" && f != nil:
+				t.Errorf("expected no finding, got %+v", f)
+			case tc.expected != "" && (f == nil || !strings.Contains(f.Message, tc.expected) || f.Rule != ruleWorkflowCodeowners):
+				t.Errorf("expected finding with %q, got %+v", tc.expected, f)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanBranchChecksCodeowners(t *testing.T) {
+	repo := fakeRepository{
+		name:  "repo",
+		files: []string{"ci.yml", "deploy.yml", "README.md"},
+		fileContents: map[string][]byte{
+			"root/.github/workflows/ci.yml":     []byte("on: push\njobs:\n  a:\n    steps:\n      - run: make\n"),
+			"root/.github/workflows/deploy.yml": []byte("on: push\njobs:\n  a:\n    steps:\n      - run: make deploy\n"),
+			"root/.github/workflows/README.md":  []byte("# Workflows\n"),
+			"root/.github/CODEOWNERS":           []byte("/.github/workflows/ci.yml @org/security\n"),
+		},
+	}
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, CheckCodeowners: true}
+
+	records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d: %+v", len(records), records)
+	}
+	if !strings.HasSuffix(records[0].FilePath, "deploy.yml") || len(records[0].Findings) != 1 {
+		t.Errorf("expected a finding for deploy.yml only, got %+v", records[0])
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersLocations are the places GitHub looks for a CODEOWNERS file, in order
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Component is a part of a monorepo, made of the files under its paths
+type Component struct {
+	Name string `yaml:"name"`
+	// Paths are CODEOWNERS-style patterns, such as services/payments/ or .github/workflows/pay-*.yml
+	Paths []string `yaml:"paths"`
+}
+
+// ComponentsConfig defines how workflows are assigned to components
+type ComponentsConfig struct {
+	// Define lists components explicitly. The first matching component wins.
+	Define []Component `yaml:"define"`
+	// Codeowners derives a component per team from the CODEOWNERS file for workflows not
+	// matching any defined component
+	Codeowners bool `yaml:"codeowners"`
+}
+
+// enabled reports whether workflows should be assigned to components at all
+func (c ComponentsConfig) enabled() bool {
+	return c.Codeowners || len(c.Define) > 0
+}
+
+// codeownersRule is a line of a CODEOWNERS file
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// parseCodeowners reads the rules of a CODEOWNERS file, skipping comments and invalid lines
+func parseCodeowners(content []byte) []codeownersRule {
+	var rules []codeownersRule
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern, err := ownerPattern(fields[0])
+		if err != nil {
+			logger.Debug("skipping invalid CODEOWNERS pattern", "pattern", fields[0], "err", err)
+			continue
+		}
+		rules = append(rules, codeownersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return rules
+}
+
+// loadCodeowners reads the CODEOWNERS file of a repository checked out at root, if any
+func loadCodeowners(repo Repository, root string) []codeownersRule {
+	for _, loc := range codeownersLocations {
+		content, err := repo.ReadFile(filepath.Join(root, filepath.FromSlash(loc)))
+		if err == nil {
+			return parseCodeowners(content)
+		}
+	}
+	return nil
+}
+
+// ownerPattern compiles a CODEOWNERS pattern, which follows gitignore rules: a pattern
+// without a slash matches at any depth, a trailing slash matches everything in a directory
+// and a pattern matching a directory also matches the files within.
+func ownerPattern(p string) (*regexp.Regexp, error) {
+	dir := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	if dir {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// pathMatches reports whether a repository-relative path matches a CODEOWNERS-style pattern
+func pathMatches(pattern, path string) bool {
+	re, err := ownerPattern(pattern)
+	return err == nil && re.MatchString(path)
+}
+
+// literalPrefix returns the part of a path filter before its first wildcard
+func literalPrefix(filter string) string {
+	filter = strings.TrimPrefix(filter, "/")
+	if i := strings.IndexAny(filter, "*?[{"); i >= 0 {
+		return filter[:i]
+	}
+	return filter
+}
+
+// filterOverlaps reports whether a trigger path filter selects files of a component path
+func filterOverlaps(filter, componentPath string) bool {
+	if strings.HasPrefix(filter, "!") {
+		return false
+	}
+	f, c := literalPrefix(filter), literalPrefix(componentPath)
+	if f == "" || c == "" {
+		return false
+	}
+	return strings.HasPrefix(f, c) || strings.HasPrefix(c, f)
+}
+
+// ownersOf returns the owners of a path according to CODEOWNERS, where the last match wins
+func ownersOf(rules []codeownersRule, path string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(path) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// componentOf assigns a workflow to a component. Defined components match on the path of the
+// workflow file first, then on the path filters of its triggers, since monorepo workflows
+// usually live in the root .github/workflows and select their component through
+// `on.push.paths`. With codeowners enabled, the first owner of those paths is the component.
+func (c ComponentsConfig) componentOf(owners []codeownersRule, path string, filters []string) string {
+	for _, comp := range c.Define {
+		for _, p := range comp.Paths {
+			if pathMatches(p, path) {
+				return comp.Name
+			}
+		}
+	}
+	for _, comp := range c.Define {
+		for _, p := range comp.Paths {
+			for _, f := range filters {
+				if filterOverlaps(f, p) {
+					return comp.Name
+				}
+			}
+		}
+	}
+
+	if !c.Codeowners {
+		return ""
+	}
+	for _, f := range filters {
+		if prefix := literalPrefix(f); prefix != "" && !strings.HasPrefix(f, "!") {
+			if o := ownersOf(owners, prefix); len(o) > 0 {
+				return o[0]
+			}
+		}
+	}
+	if o := ownersOf(owners, path); len(o) > 0 {
+		return o[0]
+	}
+	return ""
+}
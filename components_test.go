@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for ownerPattern ---
+
+func TestOwnerPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"*.yml", ".github/workflows/ci.yml", true},
+		{"/services/payments/", "services/payments/.github/workflows/ci.yml", true},
+		{"/services/payments/", "other/services/payments/ci.yml", false},
+		{"services/payments", "services/payments/main.go", true},
+		{"docs", "a/docs/readme.md", true},
+		{".github/workflows/pay-*.yml", ".github/workflows/pay-deploy.yml", true},
+		{".github/workflows/pay-*.yml", ".github/workflows/sub/pay-deploy.yml", false},
+		{"/.github/**/deploy.yml", ".github/workflows/deploy.yml", true},
+		{"apps/*", "apps/web", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			if got := pathMatches(tc.pattern, tc.path); got != tc.expected {
+				t.Errorf("pathMatches(%q, %q) = %v; want %v", tc.pattern, tc.path, got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for componentOf ---
+
+func TestComponentsConfig_ComponentOf(t *testing.T) {
+	owners := parseCodeowners([]byte(`# Platform owns CI by default
+* @org/platform
+/services/search/ @org/search @org/backend
+/.github/workflows/release.yml @org/release
+`))
+	cfg := ComponentsConfig{
+		Define: []Component{
+			{Name: "payments", Paths: []string{"services/payments/", ".github/workflows/pay-*.yml"}},
+		},
+		Codeowners: true,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		filters  []string
+		expected string
+	}{
+		{name: "defined by workflow path", path: ".github/workflows/pay-deploy.yml", expected: "payments"},
+		{name: "defined by trigger paths", path: ".github/workflows/ci.yml", filters: []string{"services/payments/**"}, expected: "payments"},
+		{name: "negated filters are ignored", path: ".github/workflows/ci.yml", filters: []string{"!services/payments/**"}, expected: "@org/platform"},
+		{name: "codeowners of trigger paths", path: ".github/workflows/search.yml", filters: []string{"services/search/**"}, expected: "@org/search"},
+		{name: "codeowners of workflow file", path: ".github/workflows/release.yml", expected: "@org/release"},
+		{name: "codeowners fallback", path: ".github/workflows/lint.yml", expected: "@org/platform"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cfg.componentOf(owners, tc.path, tc.filters); got != tc.expected {
+				t.Errorf("componentOf = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+
+	cfg.Codeowners = false
+	if got := cfg.componentOf(owners, ".github/workflows/lint.yml", nil); got != "" {
+		t.Errorf("expected no component without codeowners, got %q", got)
+	}
+}
+
+// --- Tests for ScanBranch with components ---
+
+func TestScanner_ScanBranchAssignsComponents(t *testing.T) {
+	repo := fakeRepository{
+		name:  "mono",
+		files: []string{"api.yml"},
+		fileContents: map[string][]byte{
+			"root/.github/workflows/api.yml": []byte("on:\n  push:\n    paths: [services/api/**]\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n"),
+			"root/.github/CODEOWNERS":        []byte("/services/api/ @org/api\n"),
+		},
+	}
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Components: ComponentsConfig{Codeowners: true}}
+
+	records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].Component != "@org/api" {
+		t.Errorf("Component = %q; want @org/api", records[0].Component)
+	}
+}
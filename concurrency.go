@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ruleDeployConcurrency flags deployment jobs that can run alongside another deployment
+	ruleDeployConcurrency = "deploy-without-concurrency"
+	// ruleCancelInProgress flags workflows the policy requires to cancel superseded runs
+	ruleCancelInProgress = "missing-cancel-in-progress"
+)
+
+// ConcurrencyConfig sets which workflows must cancel superseded runs
+type ConcurrencyConfig struct {
+	// CancelInProgress lists globs of workflow file names, such as ci.yml or test-*.yml,
+	// that must set cancel-in-progress in their concurrency group
+	CancelInProgress []string `yaml:"cancel_in_progress"`
+}
+
+// ConcurrencyRule flags deployment jobs outside of any concurrency group, as two runs
+// deploying at once can interleave and leave an environment half rolled out, and, per the
+// policy, CI workflows that keep running for commits already superseded.
+type ConcurrencyRule struct {
+	Policy ConcurrencyConfig
+}
+
+func (r ConcurrencyRule) ID() string {
+	return ruleDeployConcurrency
+}
+
+func (r ConcurrencyRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, root := range wf.roots() {
+		wfGroup := mappingValue(root, "concurrency")
+		if wfGroup == nil {
+			for _, p := range mappingPairs(mappingValue(root, "jobs")) {
+				job := resolveAlias(p.Value)
+				env := jobEnvironment(job)
+				if env == nil || mappingValue(job, "concurrency") != nil {
+					continue
+				}
+				findings = append(findings, wf.finding(ruleDeployConcurrency, SeverityLow, env, fmt.Sprintf(
+					"job %s deploys to environment %s outside of any concurrency group, so deployments can overlap. Ex: concurrency: deploy-%s",
+					p.Key.Value, env.Value, env.Value)))
+			}
+		}
+
+		if !matchesAny(r.Policy.CancelInProgress, path.Base(wf.Path)) || cancelsInProgress(wfGroup) {
+			continue
+		}
+		at := wfGroup
+		if at == nil && len(root.Content) > 0 {
+			at = root.Content[0]
+		}
+		findings = append(findings, wf.finding(ruleCancelInProgress, SeverityLow, at,
+			"workflow does not cancel superseded runs, which the policy requires. Ex: concurrency: {group: ${{ github.workflow }}-${{ github.ref }}, cancel-in-progress: true}"))
+	}
+	return findings
+}
+
+// cancelsInProgress reports whether a concurrency node cancels runs in progress. An
+// expression is trusted to do so when it matters, such as on pull requests only.
+func cancelsInProgress(group *yaml.Node) bool {
+	group = resolveAlias(group)
+	if group == nil || group.Kind != yaml.MappingNode {
+		return false
+	}
+	cancel := mappingValue(group, "cancel-in-progress")
+	return cancel != nil && (cancel.Value == "true" || strings.Contains(cancel.Value, "${{"))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for ConcurrencyRule.Check ---
+
+func TestConcurrencyRule_Check(t *testing.T) {
+	rule := ConcurrencyRule{Policy: ConcurrencyConfig{CancelInProgress: []string{"ci.yml", "test-*.yml"}}}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected map[int]string
+	}{
+		{
+			name: "deployment without group",
+			path: ".github/workflows/deploy.yml",
+			content: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  prod:
+    environment: production
+  staging:
+    environment:
+      name: staging
+    concurrency: staging
+`,
+			expected: map[int]string{6: ruleDeployConcurrency},
+		},
+		{
+			name: "deployment in a workflow group",
+			path: ".github/workflows/deploy.yml",
+			content: `on: push
+concurrency: deploy
+jobs:
+  prod:
+    environment: production
+`,
+		},
+		{
+			name: "CI without concurrency",
+			path: ".github/workflows/ci.yml",
+			content: `on: pull_request
+jobs:
+  test:
+    runs-on: ubuntu-latest
+`,
+			expected: map[int]string{1: ruleCancelInProgress},
+		},
+		{
+			name: "CI not cancelling",
+			path: ".github/workflows/test-unit.yml",
+			content: `on: pull_request
+concurrency:
+  group: ${{ github.ref }}
+jobs: {}
+`,
+			expected: map[int]string{3: ruleCancelInProgress},
+		},
+		{
+			name: "CI cancelling on pull requests",
+			path: ".github/workflows/ci.yml",
+			content: `on: pull_request
+concurrency:
+  group: ${{ github.ref }}
+  cancel-in-progress: ${{ github.event_name == 'pull_request' }}
+jobs: {}
+`,
+		},
+		{
+			name: "workflow outside of the policy",
+			path: ".github/workflows/nightly.yml",
+			content: `on: schedule
+jobs: {}
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rule.Check(newWorkflowFile(tc.path, []byte(tc.content)))
+			if len(findings) != len(tc.expected) {
+				t.Fatalf("expected %d findings, got %+v", len(tc.expected), findings)
+			}
+			for _, f := range findings {
+				if tc.expected[f.Line] != f.Rule {
+					t.Errorf("unexpected finding %+v", f)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is the project configuration looked up in the directory scharf runs from
+const defaultConfigFile = ".sharfer.yaml"
+
+// Config holds the project settings of a .sharfer.yaml file
+type Config struct {
+	// Components split a monorepo into parts reported and scored separately
+	Components ComponentsConfig `yaml:"components"`
+	Fix        FixConfig        `yaml:"fix"`
+	// Namespaces are the orgs of the enterprise, checked with --check-namespaces
+	Namespaces NamespacesConfig `yaml:"namespaces"`
+	// Codeowners sets who must own workflows, checked with --check-codeowners
+	Codeowners CodeownersConfig `yaml:"codeowners"`
+	// Runners is the policy of runner labels, checked with --check-runners
+	Runners RunnersConfig `yaml:"runners"`
+	// Concurrency sets the workflows --check-concurrency requires to cancel superseded runs
+	Concurrency ConcurrencyConfig `yaml:"concurrency"`
+	// Timeouts sets the thresholds of --check-timeouts
+	Timeouts TimeoutsConfig `yaml:"timeouts"`
+	// Packages sets the ecosystems and packages --check-packages checks
+	Packages PackagesConfig `yaml:"packages"`
+	// Artifacts sets the retention allowed by --check-artifacts
+	Artifacts  ArtifactsConfig  `yaml:"artifacts"`
+	Actionlint ActionlintConfig `yaml:"actionlint"`
+	// Suppressions silence findings accepted for a reason, listed by `suppressions list`
+	Suppressions []Suppression `yaml:"suppressions"`
+	Report       ReportConfig  `yaml:"report"`
+	// Criticality maps globs of repository names to a tier: prod, internal or sandbox. It
+	// weighs the risk score of their issues.
+	Criticality map[string]string `yaml:"criticality"`
+	// FailOn maps tiers to the lowest severity failing --raise-error in their repositories
+	FailOn map[string]string `yaml:"fail_on"`
+	// Workspace sets where `scharf workspace` discovers local clones
+	Workspace WorkspaceConfig `yaml:"workspace"`
+	// RequiredWorkflows are the workflows every repository must have, checked with
+	// --check-required-workflows
+	RequiredWorkflows []RequiredWorkflow `yaml:"required_workflows"`
+	// Golden sets the golden workflow templates --check-golden compares workflows with
+	Golden GoldenConfig `yaml:"golden"`
+	// Severities maps severities to the levels of the sinks findings are exported to
+	Severities SeverityMapping `yaml:"severities"`
+	// Paths limits the files scanned to globs of their path relative to the repository
+	Paths PathsConfig `yaml:"paths"`
+	// Rules enables rules by the name of their --check- flag, such as permissions for
+	// --check-permissions, unless the flag is given
+	Rules []string `yaml:"rules"`
+	// SeverityOverrides sets the severity of the findings of rules, by rule ID
+	SeverityOverrides map[string]string `yaml:"severity_overrides"`
+	// Policy trusts publishers whose actions may be used by tag and bans actions outright
+	Policy ActionPolicy `yaml:"policy"`
+	// Out is the format find, audit and scan export findings in unless --out is given
+	Out string `yaml:"out"`
+}
+
+// FixConfig holds settings of the fix command
+type FixConfig struct {
+	// AutoMergeOwners lists the owners of actions whose repinning may be merged unattended
+	AutoMergeOwners []string `yaml:"auto_merge_owners"`
+	// Mirrors maps actions to the internal mirrors they must be taken from, see MirrorFixer
+	Mirrors map[string]string `yaml:"mirrors"`
+}
+
+// loadConfig reads a configuration file over the user configuration. Settings of the file
+// win over those of the user, and maps are merged key by key. Missing files yield an empty
+// configuration, so projects without one keep the defaults.
+func loadConfig(path string) (*Config, error) {
+	var cfg Config
+	if user, ok := userConfigFile(); ok {
+		if err := decodeConfig(user, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := decodeConfig(path, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// decodeConfig decodes a configuration file into cfg, leaving it alone if the file is missing
+func decodeConfig(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	// Misspelled settings would otherwise be silently ignored.
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("yaml: %s: %w", path, err)
+	}
+	return nil
+}
+
+// userConfigFile returns the configuration of the user, scharf/config.yaml in
+// XDG_CONFIG_HOME or else the configuration directory of the platform
+func userConfigFile() (string, bool) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", false
+		}
+	}
+	return filepath.Join(dir, "scharf", "config.yaml"), true
+}
+
+// applyFlags sets the flags of a command the configuration enables, leaving those given on
+// the command line alone. Only exporting commands take the output format.
+func (c *Config) applyFlags(cmd *cobra.Command, exports bool) error {
+	for _, rule := range c.Rules {
+		name := "check-" + strings.TrimPrefix(rule, "check-")
+		flag := cmd.Flag(name)
+		if flag == nil {
+			return fmt.Errorf("rules: unknown rule %q", rule)
+		}
+		if !flag.Changed {
+			if err := cmd.Flags().Set(name, "true"); err != nil {
+				return fmt.Errorf("rules: %s: %w", rule, err)
+			}
+		}
+	}
+	if c.Out != "" && exports && !cmd.Flag("out").Changed {
+		if !slices.Contains(exportFormats, c.Out) {
+			return fmt.Errorf("out: unknown format %q. Valid formats are %s", c.Out, strings.Join(exportFormats, ", "))
+		}
+		if err := cmd.Flags().Set("out", c.Out); err != nil {
+			return fmt.Errorf("out: %w", err)
+		}
+	}
+	return nil
+}
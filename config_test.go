@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// --- Tests for loadConfig ---
+
+func TestLoadConfig(t *testing.T) {
+	// The configuration of the user running the tests does not count.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		cfg, err := loadConfig(filepath.Join(dir, "absent.yaml"))
+		if err != nil || cfg == nil || cfg.Components.enabled() {
+			t.Errorf("expected empty config, got %+v (err %v)", cfg, err)
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		if _, err := loadConfig(write("empty.yaml", "")); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("components", func(t *testing.T) {
+		cfg, err := loadConfig(write("ok.yaml", "components:\n  codeowners: true\n  define:\n    - name: payments\n      paths: [services/payments/]\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !cfg.Components.Codeowners || len(cfg.Components.Define) != 1 || cfg.Components.Define[0].Paths[0] != "services/payments/" {
+			t.Errorf("unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("fix", func(t *testing.T) {
+		cfg, err := loadConfig(write("fix.yaml", "fix:\n  auto_merge_owners: [actions]\n  mirrors:\n    actions/checkout: mirrors/checkout\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cfg.Fix.AutoMergeOwners) != 1 || cfg.Fix.Mirrors["actions/checkout"] != "mirrors/checkout" {
+			t.Errorf("unexpected config: %+v", cfg)
+		}
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		if _, err := loadConfig(write("typo.yaml", "component:\n  codeowners: true\n")); err == nil {
+			t.Error("expected error for unknown setting, got nil")
+		}
+	})
+}
+
+// --- Tests for loadConfig with a user configuration ---
+
+func TestLoadConfigUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	CheckIfError(os.MkdirAll(filepath.Join(home, "scharf"), 0o755))
+	CheckIfError(os.WriteFile(filepath.Join(home, "scharf", "config.yaml"), []byte("out: sarif\nrules: [permissions]\nseverity_overrides:\n  deprecated-syntax: low\n  excessive-permissions: low\n"), 0o644))
+
+	project := filepath.Join(t.TempDir(), defaultConfigFile)
+	CheckIfError(os.WriteFile(project, []byte("rules: [secrets]\nseverity_overrides:\n  deprecated-syntax: high\n"), 0o644))
+
+	cfg, err := loadConfig(project)
+	CheckIfError(err)
+	if cfg.Out != "sarif" || len(cfg.Rules) != 1 || cfg.Rules[0] != "secrets" {
+		t.Errorf("expected the project settings over those of the user, got %+v", cfg)
+	}
+	if cfg.SeverityOverrides["deprecated-syntax"] != "high" || cfg.SeverityOverrides["excessive-permissions"] != "low" {
+		t.Errorf("expected the overrides to be merged, got %v", cfg.SeverityOverrides)
+	}
+}
+
+// --- Tests for Config.applyFlags ---
+
+func TestConfig_ApplyFlags(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "find"}
+		addRuleFlags(cmd)
+		cmd.PersistentFlags().String("out", "json", "")
+		CheckIfError(cmd.ParseFlags(args))
+		return cmd
+	}
+	cfg := Config{Rules: []string{"permissions", "check-secrets"}, Out: "sarif"}
+
+	cmd := newCmd()
+	CheckIfError(cfg.applyFlags(cmd, true))
+	if cmd.Flag("check-permissions").Value.String() != "true" || cmd.Flag("check-secrets").Value.String() != "true" || cmd.Flag("out").Value.String() != "sarif" {
+		t.Error("expected the configuration to enable the rules and set the format")
+	}
+
+	// Flags win over the configuration.
+	cmd = newCmd("--check-permissions=false", "--out", "csv")
+	CheckIfError(cfg.applyFlags(cmd, true))
+	if cmd.Flag("check-permissions").Value.String() != "false" || cmd.Flag("out").Value.String() != "csv" {
+		t.Error("expected the flags to win over the configuration")
+	}
+
+	cmd = newCmd()
+	CheckIfError(cfg.applyFlags(cmd, false))
+	if cmd.Flag("out").Value.String() != "json" {
+		t.Error("expected the format to apply to exporting commands only")
+	}
+
+	for _, bad := range []Config{{Rules: []string{"permission"}}, {Out: "pdf"}} {
+		if err := bad.applyFlags(newCmd(), true); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
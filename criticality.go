@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Criticality tiers of repositories, set in the criticality section of the configuration
+const (
+	criticalityProd     = "prod"
+	criticalityInternal = "internal"
+	criticalitySandbox  = "sandbox"
+)
+
+// criticalityOf returns the tier of a repository among globs of repository names. The
+// longest matching glob is the most specific one, and untagged repositories are internal.
+func criticalityOf(tiers map[string]string, repository string) string {
+	best := ""
+	for pattern := range tiers {
+		if matched, _ := path.Match(pattern, repository); matched && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return criticalityInternal
+	}
+	return tiers[best]
+}
+
+// checkCriticality validates the tiers of repositories and the severities failing each tier
+func checkCriticality(tiers, failOn map[string]string) error {
+	for pattern, tier := range tiers {
+		if _, ok := criticalityWeight[tier]; !ok {
+			return fmt.Errorf("criticality of %s: unknown tier %q. Valid tiers are prod, internal, sandbox", pattern, tier)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("criticality: %s: %w", pattern, err)
+		}
+	}
+	for tier, severity := range failOn {
+		if _, ok := criticalityWeight[tier]; !ok {
+			return fmt.Errorf("fail_on: unknown tier %q. Valid tiers are prod, internal, sandbox", tier)
+		}
+		if _, ok := severityRank[severity]; !ok {
+			return fmt.Errorf("fail_on of %s: unknown severity %q. Valid severities are low, medium, high, critical", tier, severity)
+		}
+	}
+	return nil
+}
+
+// fails reports whether the issues of an inventory should fail the run. Each record is held
+// to the lowest severity failing its tier, and mutable references count as medium. Tiers
+// without a threshold fail on any issue, and banned actions fail whatever the tier.
+func (inv *Inventory) fails(failOn map[string]string) bool {
+	for _, ir := range inv.Records {
+		if slices.ContainsFunc(ir.Findings, func(f Finding) bool { return f.Rule == ruleBannedAction }) {
+			return true
+		}
+		tier := ir.Criticality
+		if tier == "" {
+			tier = criticalityInternal
+		}
+		threshold, ok := failOn[tier]
+		if !ok {
+			if len(ir.Locations) > 0 || len(ir.Findings) > 0 {
+				return true
+			}
+			continue
+		}
+		if len(ir.Locations) > 0 && severityRank[SeverityMedium] >= severityRank[threshold] {
+			return true
+		}
+		for _, f := range ir.Findings {
+			if severityRank[f.Severity] >= severityRank[threshold] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for criticalityOf ---
+
+func TestCriticalityOf(t *testing.T) {
+	tiers := map[string]string{"org/*": criticalityInternal, "org/payments-*": criticalityProd, "org/sandbox-*": criticalitySandbox}
+	for repo, expected := range map[string]string{
+		"org/payments-api": criticalityProd,
+		"org/sandbox-demo": criticalitySandbox,
+		"org/web":          criticalityInternal,
+		"other/tool":       criticalityInternal,
+	} {
+		if got := criticalityOf(tiers, repo); got != expected {
+			t.Errorf("criticalityOf(%q) = %q; want %q", repo, got, expected)
+		}
+	}
+}
+
+// --- Tests for checkCriticality ---
+
+func TestCheckCriticality(t *testing.T) {
+	tests := []struct {
+		name   string
+		tiers  map[string]string
+		failOn map[string]string
+		valid  bool
+	}{
+		{"valid", map[string]string{"web": criticalityProd}, map[string]string{criticalityProd: SeverityMedium}, true},
+		{"unknown tier", map[string]string{"web": "critical"}, nil, false},
+		{"malformed glob", map[string]string{"[web": criticalityProd}, nil, false},
+		{"unknown threshold tier", nil, map[string]string{"staging": SeverityHigh}, false},
+		{"unknown severity", nil, map[string]string{criticalitySandbox: "severe"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkCriticality(tc.tiers, tc.failOn); (err == nil) != tc.valid {
+				t.Errorf("checkCriticality() = %v; want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+// --- Tests for Inventory.fails ---
+
+func TestInventoryFails(t *testing.T) {
+	failOn := map[string]string{criticalityProd: SeverityMedium, criticalitySandbox: SeverityCritical}
+	unpinned := []Match{{Value: "actions/checkout@v4", Line: 1}}
+	high := []Finding{{Rule: ruleDeprecated, Severity: SeverityHigh}}
+	low := []Finding{{Rule: ruleDeprecated, Severity: SeverityLow}}
+
+	tests := []struct {
+		name     string
+		record   *InventoryRecord
+		failOn   map[string]string
+		expected bool
+	}{
+		{"no thresholds fail on anything", &InventoryRecord{Findings: low}, nil, true},
+		{"clean record", &InventoryRecord{Criticality: criticalityProd}, failOn, false},
+		{"prod fails on mutable references", &InventoryRecord{Criticality: criticalityProd, Locations: unpinned}, failOn, true},
+		{"prod passes low findings", &InventoryRecord{Criticality: criticalityProd, Findings: low}, failOn, false},
+		{"sandbox passes high findings", &InventoryRecord{Criticality: criticalitySandbox, Findings: high, Locations: unpinned}, failOn, false},
+		{"sandbox fails critical findings", &InventoryRecord{Criticality: criticalitySandbox, Findings: []Finding{{Severity: SeverityCritical}}}, failOn, true},
+		{"banned actions fail whatever the threshold", &InventoryRecord{Criticality: criticalitySandbox, Findings: []Finding{{Rule: ruleBannedAction, Severity: SeverityLow}}}, failOn, true},
+		{"untagged records are internal", &InventoryRecord{Findings: low}, map[string]string{criticalityInternal: SeverityHigh}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inv := &Inventory{Records: []*InventoryRecord{tc.record}}
+			if got := inv.fails(tc.failOn); got != tc.expected {
+				t.Errorf("fails() = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanBranchTagsCriticality(t *testing.T) {
+	repo := fakeRepository{
+		name:         "org/payments-api",
+		files:        []string{"ci.yml"},
+		fileContents: map[string][]byte{"root/.github/workflows/ci.yml": []byte("on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n")},
+	}
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Criticality: map[string]string{"org/payments-*": criticalityProd}}
+
+	records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+	if len(records) != 1 || records[0].Criticality != criticalityProd {
+		t.Errorf("expected a prod record, got %+v", records)
+	}
+}
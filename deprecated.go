@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleDeprecated flags workflow commands and actions that GitHub deprecated or removed
+const ruleDeprecated = "deprecated-syntax"
+
+// deprecatedCommand is a workflow command replaced by an environment file
+type deprecatedCommand struct {
+	command  string
+	severity string
+	guidance string
+}
+
+// deprecatedCommands are the workflow commands printed to stdout that were replaced by
+// environment files. set-env and add-path are disabled and fail the step.
+var deprecatedCommands = []deprecatedCommand{
+	{"::set-output", SeverityLow, `write to $GITHUB_OUTPUT instead: echo "name=value" >> "$GITHUB_OUTPUT"`},
+	{"::save-state", SeverityLow, `write to $GITHUB_STATE instead: echo "name=value" >> "$GITHUB_STATE"`},
+	{"::set-env", SeverityMedium, `write to $GITHUB_ENV instead: echo "NAME=value" >> "$GITHUB_ENV"`},
+	{"::add-path", SeverityMedium, `write to $GITHUB_PATH instead: echo "/some/dir" >> "$GITHUB_PATH"`},
+}
+
+// oldNodeActions maps popular actions to their last major version running on Node 12 or 16,
+// which runners no longer provide. Later majors run on Node 20 or newer.
+var oldNodeActions = map[string]int{
+	"actions/cache":                         3,
+	"actions/checkout":                      3,
+	"actions/download-artifact":             3,
+	"actions/github-script":                 6,
+	"actions/labeler":                       4,
+	"actions/setup-dotnet":                  3,
+	"actions/setup-go":                      3,
+	"actions/setup-java":                    3,
+	"actions/setup-node":                    3,
+	"actions/setup-python":                  4,
+	"actions/stale":                         8,
+	"actions/upload-artifact":               3,
+	"aws-actions/configure-aws-credentials": 3,
+	"docker/build-push-action":              4,
+	"docker/login-action":                   2,
+	"docker/metadata-action":                4,
+	"docker/setup-buildx-action":            2,
+	"docker/setup-qemu-action":              2,
+	"github/codeql-action":                  2,
+}
+
+// DeprecatedRule flags deprecated workflow commands in run steps and actions still running on
+// a Node version runners dropped. Both break builds sooner or later, and usually mean
+// nobody maintains the workflow.
+type DeprecatedRule struct{}
+
+func (r DeprecatedRule) ID() string {
+	return ruleDeprecated
+}
+
+func (r DeprecatedRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil {
+			continue
+		}
+		for _, step := range steps.Content {
+			step = resolveAlias(step)
+			if run := mappingValue(step, "run"); run != nil && run.Kind == yaml.ScalarNode {
+				findings = append(findings, r.checkRun(wf, job.ID, run)...)
+			}
+		}
+	}
+
+	for _, n := range wf.usesNodes() {
+		action, ref, _ := strings.Cut(n.Value, "@")
+		version := ref
+		if pinnedRef.MatchString("@" + ref) {
+			version = strings.TrimSpace(strings.TrimPrefix(n.LineComment, "#"))
+		}
+		last, known := oldNodeActions[actionName(action)]
+		major, ok := majorVersion(version)
+		if known && ok && major <= last {
+			findings = append(findings, wf.finding(r.ID(), SeverityMedium, n, fmt.Sprintf(
+				"%s@%s runs on Node 16 or older, which runners no longer provide. Upgrade to v%d or later",
+				action, version, last+1)))
+		}
+	}
+	return findings
+}
+
+// checkRun flags every deprecated workflow command printed by a run script, on its own line
+func (r DeprecatedRule) checkRun(wf *WorkflowFile, jobID string, run *yaml.Node) []Finding {
+	var findings []Finding
+	from := wf.lines.offset(run.Line, run.Column)
+	for _, line := range strings.Split(run.Value, "\n") {
+		lineOff, found := -1, false
+		if strings.TrimSpace(line) != "" {
+			if lineOff, found = wf.lines.locate(line, from); found {
+				from = lineOff + len(line)
+			}
+		}
+		for _, c := range deprecatedCommands {
+			i := strings.Index(line, c.command)
+			if i < 0 {
+				continue
+			}
+			f := wf.finding(r.ID(), c.severity, run, fmt.Sprintf("job %s uses the deprecated %s command; %s",
+				jobID, strings.TrimPrefix(c.command, "::"), c.guidance))
+			if found {
+				f.Line, f.Column = wf.lines.position(lineOff + i)
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// majorVersion parses the major version of a tag such as v3, v3.1 or 3.1.0
+func majorVersion(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.ToLower(tag), "v")
+	major, _, _ := strings.Cut(tag, ".")
+	n, err := strconv.Atoi(major)
+	return n, err == nil
+}
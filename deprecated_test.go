@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for DeprecatedRule.Check ---
+
+func TestDeprecatedRule_Check(t *testing.T) {
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v2
+      - uses: actions/setup-node@` + lockedSHA + ` # v3.8.1
+      - uses: actions/setup-go@v5
+      - uses: actions/cache@main
+      - uses: other/action@v1
+      - run: |
+          make build
+          echo "::set-output name=version::1.0"
+          echo "::add-path::/opt/bin" && echo "::save-state name=x::y"
+      - run: echo "::set-env name=A::b"
+      - run: echo "version=1.0" >> "$GITHUB_OUTPUT"
+`
+	findings := DeprecatedRule{}.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	type pos struct {
+		line, column int
+		severity     string
+		text         string
+	}
+	expected := []pos{
+		{13, 17, SeverityLow, "set-output"},
+		{14, 17, SeverityMedium, "add-path"},
+		{14, 48, SeverityLow, "save-state"},
+		{15, 20, SeverityMedium, "set-env"},
+		{6, 15, SeverityMedium, "actions/checkout@v2"},
+		{7, 15, SeverityMedium, "actions/setup-node@v3.8.1"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	byText := map[string]Finding{}
+	for _, f := range findings {
+		for _, e := range expected {
+			if strings.Contains(f.Message, e.text) {
+				byText[e.text] = f
+			}
+		}
+	}
+	for _, e := range expected {
+		f, ok := byText[e.text]
+		if !ok {
+			t.Errorf("missing finding for %s", e.text)
+			continue
+		}
+		if f.Line != e.line || f.Column != e.column || f.Severity != e.severity || f.Rule != ruleDeprecated {
+			t.Errorf("finding for %s = %+v; want line %d column %d severity %s", e.text, f, e.line, e.column, e.severity)
+		}
+	}
+}
+
+// --- Tests for majorVersion ---
+
+func TestMajorVersion(t *testing.T) {
+	for _, tc := range []struct {
+		tag      string
+		expected int
+		ok       bool
+	}{
+		{"v3", 3, true},
+		{"V4.1.0", 4, true},
+		{"2.0", 2, true},
+		{"main", 0, false},
+		{"", 0, false},
+	} {
+		major, ok := majorVersion(tc.tag)
+		if major != tc.expected || ok != tc.ok {
+			t.Errorf("majorVersion(%q) = %d, %v; want %d, %v", tc.tag, major, ok, tc.expected, tc.ok)
+		}
+	}
+}
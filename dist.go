@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// gitTree is a tree listing of the GitHub git database API
+type gitTree struct {
+	Tree []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+		SHA  string `json:"sha"`
+	} `json:"tree"`
+	Truncated bool `json:"truncated"`
+}
+
+// errNoDist is returned for actions without a committed dist/ bundle
+var errNoDist = errors.New("no dist/ bundle")
+
+// distHash hashes the dist/ bundle committed at sha in the repository of an action. JavaScript
+// actions run this bundle rather than their sources, so it is what a pin really executes.
+// Blob SHAs already hash the content of files, so the tree listing is enough and no file
+// needs to be downloaded.
+func distHash(action, sha string) (string, error) {
+	endpoint := fmt.Sprintf("%s/%s/git/trees/%s?recursive=1", apiURL, actionName(action), sha)
+	resp, err := githubGet(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: no tree %s for %s: %s", sha, action, resp.Status)
+	}
+
+	var tree gitTree
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	if tree.Truncated {
+		return "", fmt.Errorf("http: tree of %s@%s is too large to list", action, sha)
+	}
+
+	// Actions in a subdirectory of their repository ship their own bundle.
+	dir := "dist/"
+	if _, sub, ok := strings.Cut(strings.SplitN(action, "@", 2)[0], "/"); ok {
+		if _, sub, ok = strings.Cut(sub, "/"); ok {
+			dir = path.Join(sub, "dist") + "/"
+		}
+	}
+
+	var entries []string
+	for _, e := range tree.Tree {
+		if e.Type == "blob" && strings.HasPrefix(e.Path, dir) {
+			entries = append(entries, e.Path+" "+e.SHA+"\n")
+		}
+	}
+	if len(entries) == 0 {
+		return "", errNoDist
+	}
+	sort.Strings(entries)
+
+	h := sha256.New()
+	for _, e := range entries {
+		h.Write([]byte(e))
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordDist stores the dist/ hash of every locked action that ships a bundle
+func (l *Lockfile) recordDist() {
+	for i, e := range l.Actions {
+		hash, err := distHash(e.Action, e.SHA)
+		if err != nil {
+			if !errors.Is(err, errNoDist) {
+				logger.Warn("could not hash dist/ bundle", "action", e.Action+"@"+e.Ref, "err", err)
+			}
+			continue
+		}
+		l.Actions[i].Dist = hash
+	}
+}
+
+// DistChange is a locked action whose dist/ bundle hashes differently than when it was locked.
+// The content of a commit cannot change, so this points at a SHA that is not the one
+// reviewed, or a mirror serving other content than upstream.
+type DistChange struct {
+	LockEntry
+	Current string `json:"current_dist"`
+}
+
+// detectDistChanges re-hashes the dist/ bundle of every locked action that has one recorded
+func detectDistChanges(lock *Lockfile) []DistChange {
+	var changes []DistChange
+	for _, e := range lock.Actions {
+		if e.Dist == "" {
+			continue
+		}
+		hash, err := distHash(e.Action, e.SHA)
+		if errors.Is(err, errNoDist) {
+			hash = ""
+		} else if err != nil {
+			logger.Warn("could not hash dist/ bundle", "action", e.Action+"@"+e.Ref, "err", err)
+			continue
+		}
+		if hash != e.Dist {
+			changes = append(changes, DistChange{LockEntry: e, Current: hash})
+		}
+	}
+	return changes
+}
+
+// distMessage describes changed dist/ bundles in a few lines of text
+func distMessage(changes []DistChange) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "scharf: %d locked action bundle(s) changed", len(changes))
+	for _, c := range changes {
+		current := c.Current
+		if current == "" {
+			current = "missing"
+		}
+		fmt.Fprintf(&b, "\n%s@%s (%s): dist/ %s -> %s", c.Action, c.Ref, c.SHA, c.Dist, current)
+	}
+	return b.String()
+}
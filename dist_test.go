@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// treeTransport serves tree listings of the git database API by URL, or 404
+func treeTransport(trees map[string]string) roundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		body, ok := trees[req.URL.String()]
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	}
+}
+
+const (
+	nodeTreeURL = "https://api.github.com/repos/actions/checkout/git/trees/" + lockedSHA + "?recursive=1"
+	nodeTree    = `{"tree": [
+		{"path": "action.yml", "type": "blob", "sha": "a1"},
+		{"path": "dist", "type": "tree", "sha": "t1"},
+		{"path": "dist/index.js", "type": "blob", "sha": "b1"},
+		{"path": "dist/licenses.txt", "type": "blob", "sha": "b2"},
+		{"path": "sub/dist/index.js", "type": "blob", "sha": "b3"}
+	]}`
+)
+
+// --- Tests for distHash ---
+
+func TestDistHash(t *testing.T) {
+	tampered := strings.Replace(nodeTree, `"sha": "b1"`, `"sha": "evil"`, 1)
+	reordered := `{"tree": [{"path": "dist/licenses.txt", "type": "blob", "sha": "b2"}, {"path": "dist/index.js", "type": "blob", "sha": "b1"}]}`
+
+	var hashes []string
+	for _, tree := range []string{nodeTree, reordered, tampered} {
+		withHTTPClientTransport(treeTransport(map[string]string{nodeTreeURL: tree}), func() {
+			hash, err := distHash("actions/checkout", lockedSHA)
+			CheckIfError(err)
+			hashes = append(hashes, hash)
+		})
+	}
+
+	if !strings.HasPrefix(hashes[0], "sha256:") {
+		t.Errorf("expected a sha256 hash, got %q", hashes[0])
+	}
+	if hashes[0] != hashes[1] {
+		t.Errorf("hash depends on the order of the listing: %s != %s", hashes[0], hashes[1])
+	}
+	if hashes[0] == hashes[2] {
+		t.Error("hash did not change with the content of the bundle")
+	}
+}
+
+func TestDistHash_Subdirectory(t *testing.T) {
+	subURL := "https://api.github.com/repos/actions/checkout/git/trees/" + lockedSHA + "?recursive=1"
+	withHTTPClientTransport(treeTransport(map[string]string{subURL: nodeTree}), func() {
+		whole, _ := distHash("actions/checkout", lockedSHA)
+		sub, err := distHash("actions/checkout/sub", lockedSHA)
+		CheckIfError(err)
+		if sub == whole {
+			t.Error("expected the bundle of the subdirectory action to hash differently")
+		}
+	})
+}
+
+func TestDistHash_Errors(t *testing.T) {
+	trees := map[string]string{
+		nodeTreeURL: `{"tree": [{"path": "action.yml", "type": "blob", "sha": "a1"}]}`,
+		"https://api.github.com/repos/big/repo/git/trees/" + lockedSHA + "?recursive=1": `{"tree": [], "truncated": true}`,
+	}
+	withHTTPClientTransport(treeTransport(trees), func() {
+		if _, err := distHash("actions/checkout", lockedSHA); !errors.Is(err, errNoDist) {
+			t.Errorf("expected errNoDist for an action without bundle, got %v", err)
+		}
+		if _, err := distHash("big/repo", lockedSHA); err == nil {
+			t.Error("expected error for a truncated tree, got nil")
+		}
+		if _, err := distHash("missing/repo", lockedSHA); err == nil || !strings.HasPrefix(err.Error(), "http:") {
+			t.Errorf("expected http error for a missing tree, got %v", err)
+		}
+	})
+}
+
+// --- Tests for detectDistChanges ---
+
+func TestDetectDistChanges(t *testing.T) {
+	lock := &Lockfile{Actions: []LockEntry{
+		{Action: "actions/checkout", Ref: "v4", SHA: lockedSHA},
+		{Action: "actions/setup-go", Ref: "v5", SHA: lockedSHA},
+	}}
+	setupGoURL := "https://api.github.com/repos/actions/setup-go/git/trees/" + lockedSHA + "?recursive=1"
+	trees := map[string]string{nodeTreeURL: nodeTree, setupGoURL: `{"tree": []}`}
+
+	withHTTPClientTransport(treeTransport(trees), func() {
+		lock.recordDist()
+		if lock.Actions[0].Dist == "" || lock.Actions[1].Dist != "" {
+			t.Fatalf("expected only the bundle of checkout to be recorded, got %+v", lock.Actions)
+		}
+		if changes := detectDistChanges(lock); len(changes) != 0 {
+			t.Errorf("expected no change right after recording, got %+v", changes)
+		}
+
+		trees[nodeTreeURL] = strings.Replace(nodeTree, `"sha": "b1"`, `"sha": "evil"`, 1)
+		changes := detectDistChanges(lock)
+		if len(changes) != 1 || changes[0].Action != "actions/checkout" || changes[0].Current == lock.Actions[0].Dist {
+			t.Errorf("expected the changed bundle of checkout, got %+v", changes)
+		}
+
+		trees[nodeTreeURL] = `{"tree": []}`
+		changes = detectDistChanges(lock)
+		if len(changes) != 1 || changes[0].Current != "" || !strings.Contains(distMessage(changes), "-> missing") {
+			t.Errorf("expected the removed bundle of checkout, got %+v", changes)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleUnverifiedDownload flags release assets and binaries downloaded without verifying
+// their checksum or signature
+const ruleUnverifiedDownload = "unverified-download"
+
+var (
+	// ghReleaseDownload matches gh release download commands, and ghReleasePattern the
+	// asset they select
+	ghReleaseDownload = regexp.MustCompile(`(?:^|[\s;&|(])gh\s+release\s+download\b`)
+	ghReleasePattern  = regexp.MustCompile(`(?:--pattern|-p)[\s=]+['"]?([^\s'"]+)`)
+	// fetchCommand matches curl and wget fetching a URL, and curlOutput and wgetOutput the
+	// file they write it to
+	fetchCommand = regexp.MustCompile(`(?:^|[\s;&|(])(curl|wget)\s[^;&|]*?(https?://[^\s'"]+)`)
+	curlOutput   = regexp.MustCompile(`\s(?:-o|--output)[\s=]+['"]?([^\s'"]+)`)
+	wgetOutput   = regexp.MustCompile(`\s(?:-O|--output-document)[\s=]+['"]?([^\s'"]+)`)
+	// releaseAssetURL matches the download URLs of GitHub and GitLab releases
+	releaseAssetURL = regexp.MustCompile(`^https?://(?:github\.com/[^/]+/[^/]+/releases/(?:latest/)?download/|objects\.githubusercontent\.com/|api\.github\.com/repos/[^/]+/[^/]+/releases/assets/|[^/]+/.+/-/releases/)`)
+	// binaryFile matches the names of archives, packages and executables
+	binaryFile = regexp.MustCompile(`(?i)\.(?:tar\.gz|tgz|tar\.xz|txz|tar\.bz2|tar\.zst|zip|gz|xz|deb|rpm|apk|msi|exe|dmg|pkg|appimage|jar|bin)$`)
+	// verification matches commands checking a checksum or a signature
+	verification = regexp.MustCompile(`(?:^|[\s;&|(])(?:sha(?:1|224|256|384|512)sum|shasum|b2sum|cosign\s+verify(?:-blob)?|gpg2?\s+(?:.*\s)?--verify|minisign\s+-V|gh\s+attestation\s+verify|slsa-verifier|openssl\s+dgst|Get-FileHash|certutil\s+-hashfile)\b`)
+)
+
+// DownloadRule flags run steps downloading release assets or binaries, through
+// gh release download, curl or wget, when neither that step nor a later step of the job
+// checks a checksum or a signature. Whoever can replace the asset, or intercept the
+// download, then runs code in the job.
+type DownloadRule struct{}
+
+func (r DownloadRule) ID() string {
+	return ruleUnverifiedDownload
+}
+
+func (r DownloadRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		var runs []*yaml.Node
+		for _, step := range steps.Content {
+			if run := mappingValue(resolveAlias(step), "run"); run != nil {
+				runs = append(runs, run)
+			}
+		}
+
+		for i, run := range runs {
+			lines := scriptCommands(run.Value)
+			for l, line := range lines {
+				d, ok := parseDownload(line)
+				if !ok {
+					continue
+				}
+				// Verification of the same line counts, as in curl -o tool $URL && sha256sum -c.
+				rest := strings.Join(lines[l:], "\n")
+				for _, later := range runs[i+1:] {
+					rest += "\n" + later.Value
+				}
+				if verification.MatchString(rest) {
+					continue
+				}
+				findings = append(findings, withSuggestion(wf.finding(ruleUnverifiedDownload, SeverityMedium, run, fmt.Sprintf(
+					"step of job %s downloads %s without verifying its checksum or signature", job.ID, d.source)),
+					fmt.Sprintf("%s\necho \"<sha256>  %s\" | sha256sum --check --strict", line, d.file)))
+			}
+		}
+	}
+	return findings
+}
+
+// download is a release asset or binary fetched by a command
+type download struct {
+	// source is what is downloaded, for messages
+	source string
+	// file is the name of the downloaded file
+	file string
+}
+
+// parseDownload tells whether a command downloads a release asset or a binary
+func parseDownload(command string) (download, bool) {
+	if ghReleaseDownload.MatchString(command) {
+		d := download{source: "release assets with gh release download", file: "<asset>"}
+		if m := ghReleasePattern.FindStringSubmatch(command); m != nil {
+			d.file = m[1]
+		}
+		return d, true
+	}
+	for _, m := range fetchCommand.FindAllStringSubmatch(command, -1) {
+		u, err := url.Parse(m[2])
+		if err != nil || !releaseAssetURL.MatchString(m[2]) && !binaryFile.MatchString(u.Path) {
+			continue
+		}
+		d := download{source: m[2], file: path.Base(u.Path)}
+		output := curlOutput
+		if m[1] == "wget" {
+			output = wgetOutput
+		}
+		if o := output.FindStringSubmatch(command); o != nil {
+			d.file = o[1]
+		}
+		return d, true
+	}
+	return download{}, false
+}
+
+// scriptCommands splits a shell script into lines, joining those continued with a
+// trailing backslash
+func scriptCommands(script string) []string {
+	var commands []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasSuffix(trimmed, "\\") {
+			current.WriteString(strings.TrimSpace(strings.TrimSuffix(trimmed, "\\")) + " ")
+			continue
+		}
+		current.WriteString(trimmed)
+		if current.Len() > 0 {
+			commands = append(commands, current.String())
+		}
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		commands = append(commands, current.String())
+	}
+	return commands
+}
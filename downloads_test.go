@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for DownloadRule.Check ---
+
+func TestDownloadRule_Check(t *testing.T) {
+	content := `jobs:
+  install:
+    steps:
+      - run: gh release download v1.2.0 --repo acme/tool --pattern 'tool_linux_amd64.tar.gz'
+      - run: |
+          curl -fsSL -o kubectl.tar.gz \
+            https://github.com/acme/kubectl/releases/download/v1.30.0/kubectl.tar.gz
+          tar xzf kubectl.tar.gz
+      - run: wget -q https://example.com/downloads/helm-v3.15.0-linux-amd64.tar.gz
+      - run: curl -fsSL https://api.example.com/status
+  verified:
+    steps:
+      - run: curl -fsSLO https://github.com/acme/tool/releases/download/v1/tool.zip
+      - run: sha256sum --check tool.sha256
+  signed:
+    steps:
+      - run: |
+          gh release download v2 -R acme/tool -p tool.tgz
+          cosign verify-blob --bundle tool.tgz.bundle tool.tgz
+  before:
+    steps:
+      - run: sha256sum --check tool.sha256
+      - run: curl -o tool.tgz https://github.com/acme/tool/releases/latest/download/tool.tgz
+`
+	findings := DownloadRule{}.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	expected := map[int]string{
+		4:  "tool_linux_amd64.tar.gz",
+		5:  "kubectl.tar.gz",
+		9:  "helm-v3.15.0-linux-amd64.tar.gz",
+		23: "tool.tgz",
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		file, ok := expected[f.Line]
+		if !ok || f.Rule != ruleUnverifiedDownload || f.Severity != SeverityMedium {
+			t.Errorf("unexpected finding %+v", f)
+			continue
+		}
+		if !strings.HasSuffix(f.Suggestion, `echo "<sha256>  `+file+`" | sha256sum --check --strict`) {
+			t.Errorf("line %d: expected a checksum verification of %s, got %q", f.Line, file, f.Suggestion)
+		}
+	}
+}
+
+// --- Tests for parseDownload ---
+
+func TestParseDownload(t *testing.T) {
+	tests := []struct {
+		command string
+		found   bool
+		file    string
+	}{
+		{"gh release download --pattern=app.deb", true, "app.deb"},
+		{"gh release download v1", true, "<asset>"},
+		{`curl -L "https://github.com/o/r/releases/download/${VERSION}/app_${VERSION}.tar.gz" -o app.tgz`, true, "app.tgz"},
+		{"wget -O- https://github.com/o/r/releases/download/v1/app.tgz | tar xz", true, "app.tgz"},
+		{"wget --output-document=app.jar https://repo.example.com/app-1.0.jar", true, "app.jar"},
+		{"curl -fsSLO https://example.com/app-1.0.zip", true, "app-1.0.zip"},
+		{"curl -H 'Accept: application/octet-stream' https://api.github.com/repos/o/r/releases/assets/42", true, "42"},
+		{"wget https://gitlab.com/group/project/-/releases/v1/downloads/app", true, "app"},
+		{"curl https://example.com/install.sh?version=1.zip", false, ""},
+		{"curl -X POST https://api.example.com/deploy", false, ""},
+		{"gh release list", false, ""},
+	}
+	for _, tc := range tests {
+		d, ok := parseDownload(tc.command)
+		if ok != tc.found || d.file != tc.file {
+			t.Errorf("parseDownload(%q) = %+v, %v; want file %q, %v", tc.command, d, ok, tc.file, tc.found)
+		}
+	}
+}
+
+// --- Tests for scriptCommands ---
+
+func TestScriptCommands(t *testing.T) {
+	got := scriptCommands("curl -o a \\\n  https://x/a.zip\n\n  unzip a\n")
+	if len(got) != 2 || got[0] != "curl -o a https://x/a.zip" || got[1] != "unzip a" {
+		t.Errorf("scriptCommands = %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// droneFile is the pipeline file of Drone CI at the root of a repository
+	droneFile = ".drone.yml"
+	// woodpeckerFile is the pipeline file of Woodpecker CI at the root of a repository
+	woodpeckerFile = ".woodpecker.yml"
+	// woodpeckerDir holds the pipeline files of Woodpecker CI when there are several
+	woodpeckerDir = ".woodpecker"
+)
+
+// rulePrivilegedStep flags pipeline steps running privileged containers
+const rulePrivilegedStep = "privileged-step"
+
+// DroneChecker flags the steps and services of Drone pipelines whose image, plugins
+// included, is not pinned to a digest, and those running privileged. Plugins receive the
+// secrets set in their settings, and a privileged container is root on the runner host.
+type DroneChecker struct{}
+
+func (c DroneChecker) File() string {
+	return droneFile
+}
+
+func (c DroneChecker) Check(wf *WorkflowFile) []Finding {
+	return checkContainerSteps(wf)
+}
+
+// WoodpeckerChecker is the DroneChecker of Woodpecker CI, the fork of Drone, which reads
+// either .woodpecker.yml or every file of .woodpecker/
+type WoodpeckerChecker struct{}
+
+func (c WoodpeckerChecker) File() string {
+	return woodpeckerFile
+}
+
+func (c WoodpeckerChecker) Directory() string {
+	return woodpeckerDir
+}
+
+func (c WoodpeckerChecker) Check(wf *WorkflowFile) []Finding {
+	return checkContainerSteps(wf)
+}
+
+// containerStep is a step or service of a pipeline, with its name
+type containerStep struct {
+	Name string
+	Node *yaml.Node
+}
+
+// checkContainerSteps checks the containers of the steps, services and clone steps of every
+// pipeline of a file. Older versions of Woodpecker name the steps section pipeline.
+func checkContainerSteps(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	seen := map[*yaml.Node]bool{}
+	for _, root := range wf.roots() {
+		for _, section := range []string{"steps", "pipeline", "services", "clone"} {
+			for _, step := range containerSteps(mappingValue(root, section)) {
+				if img := pipelineImage(mappingValue(step.Node, "image")); img != nil && !seen[img] {
+					seen[img] = true
+					findings = append(findings, unpinnedImageFinding(wf, img)...)
+				}
+				if p := mappingValue(step.Node, "privileged"); p != nil && p.Value == "true" && !seen[p] {
+					seen[p] = true
+					findings = append(findings, wf.finding(rulePrivilegedStep, SeverityHigh, p, fmt.Sprintf(
+						"step %s runs a privileged container, which is root on the runner host and can reach the other builds and secrets on it", step.Name)))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// containerSteps returns the steps of a section, written as a list of steps with a name or
+// as a mapping of names to steps
+func containerSteps(section *yaml.Node) []containerStep {
+	section = resolveAlias(section)
+	if section == nil {
+		return nil
+	}
+	var steps []containerStep
+	switch section.Kind {
+	case yaml.SequenceNode:
+		for i, n := range section.Content {
+			if n = resolveAlias(n); n.Kind == yaml.MappingNode {
+				name := fmt.Sprintf("#%d", i+1)
+				if v := mappingValue(n, "name"); v != nil {
+					name = v.Value
+				}
+				steps = append(steps, containerStep{Name: name, Node: n})
+			}
+		}
+	case yaml.MappingNode:
+		for _, p := range mappingPairs(section) {
+			if n := resolveAlias(p.Value); n != nil && n.Kind == yaml.MappingNode {
+				steps = append(steps, containerStep{Name: p.Key.Value, Node: n})
+			}
+		}
+	}
+	return steps
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// --- Tests for DroneChecker.Check ---
+
+func TestDroneChecker_Check(t *testing.T) {
+	content := `kind: pipeline
+type: docker
+name: default
+steps:
+  - name: build
+    image: golang:1.22
+    commands: [go build ./...]
+  - name: publish
+    image: plugins/docker
+    privileged: true
+    settings:
+      password:
+        from_secret: docker_password
+  - name: pinned
+    image: alpine@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+services:
+  - name: db
+    image: postgres:16
+---
+kind: secret
+name: docker_password
+`
+	findings := DroneChecker{}.Check(newWorkflowFile(droneFile, []byte(content)))
+
+	expected := map[int]string{
+		6:  ruleUnpinnedImage + " " + SeverityMedium,
+		9:  ruleUnpinnedImage + " " + SeverityMedium,
+		10: rulePrivilegedStep + " " + SeverityHigh,
+		18: ruleUnpinnedImage + " " + SeverityMedium,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule+" "+f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+	if !strings.Contains(findings[2].Message, "step publish") {
+		t.Errorf("expected the step to be named, got %q", findings[2].Message)
+	}
+}
+
+// --- Tests for WoodpeckerChecker.Check ---
+
+func TestWoodpeckerChecker_Check(t *testing.T) {
+	content := `clone:
+  git:
+    image: woodpeckerci/plugin-git
+steps:
+  test:
+    image: node:20
+    privileged: true
+`
+	findings := WoodpeckerChecker{}.Check(newWorkflowFile(woodpeckerFile, []byte(content)))
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "privileged-step,unpinned-image,unpinned-image" {
+		t.Errorf("unexpected findings %+v", findings)
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with a pipeline directory ---
+
+func TestScanner_ScanBranchPipelineDirectory(t *testing.T) {
+	root := filepath.Join("ws", "repo")
+	repo := fakeRepository{
+		name:  "repo",
+		files: []string{"build.yml", "README.md", "deploy.yaml"},
+		fileContents: map[string][]byte{
+			filepath.Join(root, woodpeckerDir, "build.yml"):   []byte("steps:\n  - name: build\n    image: golang:1.22\n"),
+			filepath.Join(root, woodpeckerDir, "README.md"):   []byte("steps:\n  - image: golang:1.22\n"),
+			filepath.Join(root, woodpeckerDir, "deploy.yaml"): []byte("steps:\n  - name: deploy\n    image: alpine:3\n"),
+		},
+	}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Pipelines: []PipelineChecker{WoodpeckerChecker{}}}
+	records := sc.ScanBranch("main", repo, regexp.MustCompile("@v"), workflowDir(root))
+
+	var files []string
+	for _, r := range records {
+		files = append(files, sarifURI(r))
+	}
+	sort.Strings(files)
+	if strings.Join(files, ",") != ".woodpecker/build.yml,.woodpecker/deploy.yaml" {
+		t.Errorf("unexpected records for %v", files)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleUnprotectedEnvironment flags deployment jobs targeting environments without protection
+const ruleUnprotectedEnvironment = "unprotected-environment"
+
+// ProtectionRule is a deployment protection rule of an environment
+type ProtectionRule struct {
+	Type      string            `json:"type"`
+	WaitTimer int               `json:"wait_timer"`
+	Reviewers []json.RawMessage `json:"reviewers"`
+}
+
+// Environment is a deployment environment configured in a repository
+type Environment struct {
+	Name            string           `json:"name"`
+	ProtectionRules []ProtectionRule `json:"protection_rules"`
+}
+
+// Protected reports whether deployments to the environment need a reviewer or wait timer
+func (e Environment) Protected() bool {
+	for _, r := range e.ProtectionRules {
+		switch r.Type {
+		case "required_reviewers":
+			if len(r.Reviewers) > 0 {
+				return true
+			}
+		case "wait_timer":
+			if r.WaitTimer > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ListEnvironments fetches the environments configured for a repository.
+// Reading protection rules requires a token with access to the repository.
+func ListEnvironments(owner, name string) ([]Environment, error) {
+	if githubToken() == "" {
+		return nil, errors.New("a GITHUB_TOKEN is required to read environments")
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s/environments?per_page=100", apiURL, owner, name)
+	resp, err := githubGet(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: listing environments of %s/%s returned %s", owner, name, resp.Status)
+	}
+
+	var body struct {
+		Environments []Environment `json:"environments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return body.Environments, nil
+}
+
+// environmentRuleFor builds an EnvironmentRule for the GitHub repository behind a URL or git remote
+func environmentRuleFor(remote string) (EnvironmentRule, error) {
+	owner, name, err := parseGitHubRemote(remote)
+	if err != nil {
+		return EnvironmentRule{}, err
+	}
+
+	envs, err := ListEnvironments(owner, name)
+	if err != nil {
+		return EnvironmentRule{}, err
+	}
+	return NewEnvironmentRule(envs), nil
+}
+
+// EnvironmentRule flags jobs deploying to environments with no required reviewers or wait
+// timer, including environments that are not configured at all, since GitHub creates those
+// on first use without any protection.
+type EnvironmentRule struct {
+	environments map[string]Environment
+}
+
+// NewEnvironmentRule creates the rule from the environments configured for a repository
+func NewEnvironmentRule(envs []Environment) EnvironmentRule {
+	m := map[string]Environment{}
+	for _, e := range envs {
+		// Environment names are case-insensitive on GitHub.
+		m[strings.ToLower(e.Name)] = e
+	}
+	return EnvironmentRule{environments: m}
+}
+
+func (r EnvironmentRule) ID() string {
+	return ruleUnprotectedEnvironment
+}
+
+func (r EnvironmentRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		node := jobEnvironment(job.Node)
+		if node == nil || strings.Contains(node.Value, "${{") {
+			// No deployment, or an environment only known at runtime.
+			continue
+		}
+
+		env, ok := r.environments[strings.ToLower(node.Value)]
+		switch {
+		case !ok:
+			findings = append(findings, wf.finding(r.ID(), SeverityMedium, node, fmt.Sprintf(
+				"job %s deploys to environment %s which is not configured. GitHub creates it on first use without required reviewers or wait timer",
+				job.ID, node.Value)))
+		case !env.Protected():
+			findings = append(findings, wf.finding(r.ID(), SeverityMedium, node, fmt.Sprintf(
+				"job %s deploys to environment %s which has no required reviewers or wait timer",
+				job.ID, node.Value)))
+		}
+	}
+	return findings
+}
+
+// jobEnvironment returns the scalar naming the environment of a job, which is either
+// `environment: name` or `environment: {name: name, url: ...}`
+func jobEnvironment(job *yaml.Node) *yaml.Node {
+	env := mappingValue(job, "environment")
+	if env != nil && env.Kind == yaml.MappingNode {
+		env = mappingValue(env, "name")
+	}
+	if env == nil || env.Kind != yaml.ScalarNode || env.Value == "" {
+		return nil
+	}
+	return env
+}
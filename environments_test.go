@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// --- Tests for Environment.Protected ---
+
+func TestEnvironmentProtected(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      Environment
+		expected bool
+	}{
+		{name: "no rules", env: Environment{Name: "prod"}, expected: false},
+		{name: "branch policy only", env: Environment{ProtectionRules: []ProtectionRule{{Type: "branch_policy"}}}, expected: false},
+		{name: "reviewers", env: Environment{ProtectionRules: []ProtectionRule{{Type: "required_reviewers", Reviewers: []json.RawMessage{[]byte(`{}`)}}}}, expected: true},
+		{name: "reviewers rule without reviewers", env: Environment{ProtectionRules: []ProtectionRule{{Type: "required_reviewers"}}}, expected: false},
+		{name: "wait timer", env: Environment{ProtectionRules: []ProtectionRule{{Type: "wait_timer", WaitTimer: 5}}}, expected: true},
+	}
+
+	for _, tc := range tests {
+		if got := tc.env.Protected(); got != tc.expected {
+			t.Errorf("%s: Protected() = %v; want %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+// --- Tests for EnvironmentRule ---
+
+func TestEnvironmentRule_Check(t *testing.T) {
+	content := `
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  staging:
+    environment: staging
+  production:
+    environment:
+      name: Production
+      url: https://example.com
+  preview:
+    environment: preview
+  dynamic:
+    environment: ${{ inputs.target }}
+`
+	rule := NewEnvironmentRule([]Environment{
+		{Name: "staging"},
+		{Name: "production", ProtectionRules: []ProtectionRule{{Type: "wait_timer", WaitTimer: 10}}},
+	})
+
+	findings := rule.Check(newWorkflowFile("deploy.yml", []byte(content)))
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+
+	expectedLines := map[int]string{7: "staging", 13: "preview"}
+	for _, f := range findings {
+		if f.Rule != ruleUnprotectedEnvironment || f.Severity != SeverityMedium {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+		if _, ok := expectedLines[f.Line]; !ok {
+			t.Errorf("unexpected finding on line %d: %s", f.Line, f.Message)
+		}
+	}
+}
+
+// --- Tests for ListEnvironments ---
+
+func TestListEnvironments(t *testing.T) {
+	t.Run("requires token", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "")
+		if _, err := ListEnvironments("owner", "repo"); err == nil {
+			t.Error("expected error without token, got nil")
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "secret")
+		body := `{"total_count": 1, "environments": [{"name": "prod", "protection_rules": [{"type": "wait_timer", "wait_timer": 30}]}]}`
+
+		customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if got := req.Header.Get("Authorization"); got != "Bearer secret" {
+				t.Errorf("unexpected Authorization header %q", got)
+			}
+			if req.URL.String() != "https://api.github.com/repos/owner/repo/environments?per_page=100" {
+				t.Errorf("unexpected URL %s", req.URL)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+				Header:     make(http.Header),
+			}, nil
+		})
+
+		withHTTPClientTransport(customTransport, func() {
+			envs, err := ListEnvironments("owner", "repo")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(envs) != 1 || envs[0].Name != "prod" || !envs[0].Protected() {
+				t.Errorf("unexpected environments: %+v", envs)
+			}
+		})
+	})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultEvidenceDir receives the evidence of hunts, a directory per repository
+const defaultEvidenceDir = "hunt-evidence"
+
+// maxRunLogBytes bounds the size of the log archive of a run that is downloaded
+const maxRunLogBytes = 256 << 20
+
+// maxExcerpt bounds the part of a log line kept as evidence
+const maxExcerpt = 200
+
+// indicator is a pattern of compromise looked for in run logs
+type indicator struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// defaultIndicators are looked for in every run log. Compromised actions have dumped
+// secrets as long base64 strings so they survive log masking.
+var defaultIndicators = []indicator{
+	{Name: "base64 blob", Pattern: regexp.MustCompile(`[A-Za-z0-9+/]{120,}={0,2}`)},
+}
+
+// parseIndicators turns indicators such as exfiltration domains into patterns, matched
+// literally, after the default ones
+func parseIndicators(values []string) []indicator {
+	indicators := append([]indicator{}, defaultIndicators...)
+	for _, v := range values {
+		indicators = append(indicators, indicator{Name: v, Pattern: regexp.MustCompile(regexp.QuoteMeta(v))})
+	}
+	return indicators
+}
+
+// workflowRun is a run of a workflow
+type workflowRun struct {
+	ID         int64     `json:"id"`
+	HTMLURL    string    `json:"html_url"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Log is the file of the evidence bundle holding the logs of the run, if downloaded
+	Log string `json:"log,omitempty"`
+}
+
+// logMatch is an indicator found in the log of a run
+type logMatch struct {
+	Run       int64  `json:"run"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Indicator string `json:"indicator"`
+	Excerpt   string `json:"excerpt"`
+}
+
+// repoEvidence bundles what a hunt found in a repository with the runs of the workflows
+// involved during the exposure period
+type repoEvidence struct {
+	Repository string        `json:"repository"`
+	Hits       []huntHit     `json:"hits"`
+	Runs       []workflowRun `json:"runs"`
+	Matches    []logMatch    `json:"indicator_matches"`
+	// Missing lists the logs that could not be downloaded or read, such as expired ones
+	Missing []string `json:"missing_logs,omitempty"`
+	Dir     string   `json:"-"`
+}
+
+// listRuns returns the runs of a workflow of a repository created during the window
+func listRuns(owner, name, workflow string, window *huntWindow) ([]workflowRun, error) {
+	until := "*"
+	if !window.Until.IsZero() {
+		until = window.Until.Format(time.DateOnly)
+	}
+	query := url.Values{"created": {window.Since.Format(time.DateOnly) + ".." + until}, "per_page": {"100"}}
+	endpoint := fmt.Sprintf("%s/%s/%s/actions/workflows/%s/runs", apiURL, owner, name, url.PathEscape(workflow))
+
+	var runs []workflowRun
+	for page := 1; page <= maxPages; page++ {
+		query.Set("page", fmt.Sprint(page))
+		var resp struct {
+			Runs []workflowRun `json:"workflow_runs"`
+		}
+		found, err := getJSON(endpoint+"?"+query.Encode(), &resp)
+		if err != nil || !found {
+			return runs, err
+		}
+		runs = append(runs, resp.Runs...)
+		if len(resp.Runs) < 100 {
+			break
+		}
+	}
+	return runs, nil
+}
+
+// downloadRunLogs returns the log archive of a run. The API redirects to a short-lived URL
+// of the archive, and answers 410 Gone once logs expired.
+func downloadRunLogs(owner, name string, run int64) ([]byte, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/%s/actions/runs/%d/logs", apiURL, owner, name, run))
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: logs of run %d returned %s", run, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRunLogBytes))
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	return data, nil
+}
+
+// grepRunLogs looks for indicators in every log file of a run log archive
+func grepRunLogs(run int64, archive []byte, indicators []indicator) ([]logMatch, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+
+	var matches []logMatch
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("zip: %w", err)
+		}
+		sc := bufio.NewScanner(rc)
+		sc.Buffer(make([]byte, 64*1024), 4<<20)
+		for line := 1; sc.Scan(); line++ {
+			for _, ind := range indicators {
+				if loc := ind.Pattern.FindStringIndex(sc.Text()); loc != nil {
+					matches = append(matches, logMatch{Run: run, File: f.Name, Line: line, Indicator: ind.Name, Excerpt: excerpt(sc.Text(), loc)})
+				}
+			}
+		}
+		err = sc.Err()
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("zip: %s: %w", f.Name, err)
+		}
+	}
+	return matches, nil
+}
+
+// excerpt keeps the start of a match in a log line, within maxExcerpt characters
+func excerpt(line string, loc []int) string {
+	start := max(loc[0]-40, 0)
+	end := min(start+maxExcerpt, len(line))
+	return strings.TrimSpace(line[start:end])
+}
+
+// collectEvidence downloads the logs of the runs, during the window, of the workflows a hunt
+// found references in, and greps them for indicators. The logs and an evidence.json file
+// are written to a directory per repository of dir.
+func collectEvidence(hits []huntHit, window *huntWindow, indicators []indicator, dir string) ([]*repoEvidence, error) {
+	byRepo := map[string]*repoEvidence{}
+	var repos []string
+	for _, h := range hits {
+		e, ok := byRepo[h.Repository]
+		if !ok {
+			e = &repoEvidence{Repository: h.Repository, Dir: filepath.Join(dir, strings.Trim(unsafeFileChars.ReplaceAllString(h.Repository, "-"), "-"))}
+			byRepo[h.Repository] = e
+			repos = append(repos, h.Repository)
+		}
+		e.Hits = append(e.Hits, h)
+	}
+	sort.Strings(repos)
+
+	var bundles []*repoEvidence
+	for _, repo := range repos {
+		e := byRepo[repo]
+		owner, name, _ := strings.Cut(repo, "/")
+		if err := os.MkdirAll(e.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+
+		workflows := map[string]bool{}
+		for _, h := range e.Hits {
+			workflows[path.Base(h.File)] = true
+		}
+		for _, wf := range sortedKeys(workflows) {
+			runs, err := listRuns(owner, name, wf, window)
+			if err != nil {
+				e.Missing = append(e.Missing, fmt.Sprintf("runs of %s: %s", wf, err))
+				continue
+			}
+			for _, run := range runs {
+				archive, err := downloadRunLogs(owner, name, run.ID)
+				if err != nil {
+					e.Missing = append(e.Missing, fmt.Sprintf("%s: %s", run.HTMLURL, err))
+					e.Runs = append(e.Runs, run)
+					continue
+				}
+				run.Log = fmt.Sprintf("run-%d.zip", run.ID)
+				if err := os.WriteFile(filepath.Join(e.Dir, run.Log), archive, 0o644); err != nil {
+					return nil, fmt.Errorf("file error: %w", err)
+				}
+				e.Runs = append(e.Runs, run)
+
+				matches, err := grepRunLogs(run.ID, archive, indicators)
+				if err != nil {
+					e.Missing = append(e.Missing, fmt.Sprintf("%s: %s", run.HTMLURL, err))
+					continue
+				}
+				e.Matches = append(e.Matches, matches...)
+			}
+		}
+
+		data, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("json: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(e.Dir, "evidence.json"), data, 0o644); err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+		bundles = append(bundles, e)
+	}
+	return bundles, nil
+}
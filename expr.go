@@ -0,0 +1,673 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exprUnknown stands for a value only known during a run, such as secrets, step outputs or
+// event payloads. Any expression depending on it is unknown as well.
+type exprUnknown struct{}
+
+// exprContext holds the contexts of expressions that are known before a run, by name.
+// Values are nil, bool, float64, string, []any or map[string]any, as decoded from JSON.
+type exprContext map[string]any
+
+// staticGitHub returns the part of the github context that does not depend on the run:
+// the endpoints of github.com, or of the GitHub Enterprise Server githubServerFromEnv found
+func staticGitHub() map[string]any {
+	return map[string]any{
+		"server_url":  serverURL,
+		"api_url":     strings.TrimSuffix(apiURL, "/repos"),
+		"graphql_url": graphqlURL,
+	}
+}
+
+// staticContext gathers the contexts known before a run of a job: the static part of
+// github, the defaults of the inputs and the env values written as literals, or computed
+// from those. root is the workflow and job may be nil for workflow-level values.
+func staticContext(root, job *yaml.Node) exprContext {
+	github := staticGitHub()
+	if name := resolveAlias(mappingValue(root, "name")); name != nil && name.Kind == yaml.ScalarNode {
+		github["workflow"] = name.Value
+	}
+
+	inputs := map[string]any{}
+	for _, trigger := range []string{"workflow_dispatch", "workflow_call"} {
+		event := resolveAlias(mappingValue(resolveAlias(mappingValue(root, "on")), trigger))
+		for _, pair := range mappingPairs(resolveAlias(mappingValue(event, "inputs"))) {
+			input := resolveAlias(pair.Value)
+			def := resolveAlias(mappingValue(input, "default"))
+			if def == nil || def.Kind != yaml.ScalarNode || strings.Contains(def.Value, "${{") {
+				continue
+			}
+			inputs[pair.Key.Value] = inputDefault(def.Value, mappingValue(input, "type"))
+		}
+	}
+
+	ctx := exprContext{"github": github, "inputs": inputs, "env": map[string]any{}}
+	ctx.addEnv(mappingValue(root, "env"))
+	ctx.addEnv(mappingValue(job, "env"))
+	return ctx
+}
+
+// inputDefault converts the default of an input to the type it is declared with
+func inputDefault(value string, typ *yaml.Node) any {
+	if typ = resolveAlias(typ); typ == nil {
+		return value
+	}
+	switch typ.Value {
+	case "boolean":
+		return strings.EqualFold(value, "true")
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// addEnv evaluates the values of an `env:` mapping and adds those fully known to the env
+// context. Values that stay unknown are left out, so references to them stay unknown.
+func (c exprContext) addEnv(env *yaml.Node) {
+	known := c["env"].(map[string]any)
+	for _, pair := range mappingPairs(resolveAlias(env)) {
+		v := resolveAlias(pair.Value)
+		if v == nil || v.Kind != yaml.ScalarNode {
+			continue
+		}
+		if value, ok := interpolate(v.Value, c); ok {
+			known[pair.Key.Value] = value
+		} else {
+			delete(known, pair.Key.Value)
+		}
+	}
+}
+
+// with returns a copy of the context with one more context set
+func (c exprContext) with(name string, value any) exprContext {
+	next := exprContext{name: value}
+	for k, v := range c {
+		if k != name {
+			next[k] = v
+		}
+	}
+	return next
+}
+
+// interpolate evaluates the ${{ }} expressions of s. Expressions that cannot be evaluated are
+// kept as written and the result reports false, so callers know the value is only partial.
+func interpolate(s string, ctx exprContext) (string, bool) {
+	var b strings.Builder
+	complete := true
+	for {
+		start := strings.Index(s, "${{")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), complete
+		}
+		end := expressionEnd(s, start+3)
+		if end < 0 {
+			b.WriteString(s)
+			return b.String(), false
+		}
+		b.WriteString(s[:start])
+
+		if v, ok := evalExpression(s[start+3:end], ctx); ok {
+			b.WriteString(exprString(v))
+		} else {
+			b.WriteString(s[start : end+2])
+			complete = false
+		}
+		s = s[end+2:]
+	}
+}
+
+// expressionEnd returns the index of the `}}` closing an expression, skipping string literals
+func expressionEnd(s string, from int) int {
+	quoted := false
+	for i := from; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(s[i:], "}}"):
+			return i
+		}
+	}
+	return -1
+}
+
+// evalExpression evaluates the body of a ${{ }} expression over static contexts. It reports
+// false when the expression is invalid or depends on values unknown before the run.
+func evalExpression(expr string, ctx exprContext) (any, bool) {
+	tokens, err := lexExpression(expr)
+	if err != nil {
+		logger.Debug("could not parse expression", "expr", expr, "err", err)
+		return nil, false
+	}
+	p := &exprParser{tokens: tokens, ctx: ctx}
+	v := p.or()
+	if p.err == nil && p.peek().text != "" {
+		p.err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if p.err != nil {
+		logger.Debug("could not parse expression", "expr", expr, "err", p.err)
+		return nil, false
+	}
+	if _, isUnknown := v.(exprUnknown); isUnknown {
+		return nil, false
+	}
+	return v, true
+}
+
+// exprToken is a token of an expression. Kind is 'i' for identifiers, 'n' for numbers,
+// 's' for strings and 'o' for operators and punctuation.
+type exprToken struct {
+	kind byte
+	text string
+}
+
+// exprOperators are the operators and punctuation of expressions, longest first
+var exprOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ".", ",", "*"}
+
+// lexExpression splits an expression into tokens
+func lexExpression(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s); j++ {
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						b.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, exprToken{'s', b.String()})
+			i = j + 1
+
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789abcdefABCDEFxX.+-", s[j]) >= 0 {
+				if (s[j] == '+' || s[j] == '-') && s[j-1] != 'e' && s[j-1] != 'E' {
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, exprToken{'n', s[i:j]})
+			i = j
+
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '-' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, exprToken{'i', s[i:j]})
+			i = j
+
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, exprToken{'o', op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser evaluates expressions by recursive descent, following the operator precedence
+// of GitHub: property access, then !, comparisons, equality, && and ||
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	ctx    exprContext
+	err    error
+}
+
+func (p *exprParser) peek() exprToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return exprToken{}
+}
+
+// accept consumes the next token if it is the given operator
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == 'o' && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) {
+	if !p.accept(op) && p.err == nil {
+		p.err = fmt.Errorf("expected %q, got %q", op, p.peek().text)
+	}
+}
+
+func (p *exprParser) or() any {
+	left := p.and()
+	for p.accept("||") {
+		right := p.and()
+		switch {
+		case isUnknown(left):
+		case exprTruthy(left):
+		default:
+			left = right
+		}
+	}
+	return left
+}
+
+func (p *exprParser) and() any {
+	left := p.equality()
+	for p.accept("&&") {
+		right := p.equality()
+		switch {
+		case isUnknown(left):
+		case !exprTruthy(left):
+		default:
+			left = right
+		}
+	}
+	return left
+}
+
+func (p *exprParser) equality() any {
+	left := p.comparison()
+	for {
+		var negate bool
+		switch {
+		case p.accept("=="):
+		case p.accept("!="):
+			negate = true
+		default:
+			return left
+		}
+		right := p.comparison()
+		if isUnknown(left) || isUnknown(right) {
+			left = exprUnknown{}
+			continue
+		}
+		left = exprEqual(left, right) != negate
+	}
+}
+
+func (p *exprParser) comparison() any {
+	left := p.unary()
+	for {
+		op := p.peek().text
+		if p.peek().kind != 'o' || (op != "<" && op != "<=" && op != ">" && op != ">=") {
+			return left
+		}
+		p.pos++
+		right := p.unary()
+		if isUnknown(left) || isUnknown(right) {
+			left = exprUnknown{}
+			continue
+		}
+		cmp, ok := exprCompare(left, right)
+		switch op {
+		case "<":
+			left = ok && cmp < 0
+		case "<=":
+			left = ok && cmp <= 0
+		case ">":
+			left = ok && cmp > 0
+		case ">=":
+			left = ok && cmp >= 0
+		}
+	}
+}
+
+func (p *exprParser) unary() any {
+	if p.accept("!") {
+		v := p.unary()
+		if isUnknown(v) {
+			return v
+		}
+		return !exprTruthy(v)
+	}
+	return p.postfix(p.primary())
+}
+
+func (p *exprParser) primary() any {
+	t := p.peek()
+	p.pos++
+	switch t.kind {
+	case 's':
+		return t.text
+	case 'n':
+		return exprNumber(t.text)
+	case 'i':
+		switch t.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		if p.accept("(") {
+			var args []any
+			for !p.accept(")") {
+				if len(args) > 0 {
+					p.expect(",")
+				}
+				args = append(args, p.or())
+				if p.err != nil {
+					return exprUnknown{}
+				}
+			}
+			return exprCall(t.text, args)
+		}
+		if v, ok := lookupFold(map[string]any(p.ctx), t.text); ok {
+			return v
+		}
+		return exprUnknown{}
+	case 'o':
+		if t.text == "(" {
+			v := p.or()
+			p.expect(")")
+			return v
+		}
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("unexpected %q", t.text)
+	}
+	return exprUnknown{}
+}
+
+// postfix applies the property accesses and indexes following a value
+func (p *exprParser) postfix(v any) any {
+	for {
+		switch {
+		case p.accept("."):
+			t := p.peek()
+			p.pos++
+			if t.kind == 'o' && t.text == "*" {
+				// Filters build arrays from runtime objects, which are never static here.
+				v = exprUnknown{}
+			} else if t.kind != 'i' {
+				p.err = fmt.Errorf("expected a property name, got %q", t.text)
+				return exprUnknown{}
+			} else {
+				v = exprIndex(v, t.text)
+			}
+		case p.accept("["):
+			index := p.or()
+			p.expect("]")
+			if isUnknown(index) {
+				v = exprUnknown{}
+			} else {
+				v = exprIndex(v, index)
+			}
+		default:
+			return v
+		}
+	}
+}
+
+func isUnknown(v any) bool {
+	_, ok := v.(exprUnknown)
+	return ok
+}
+
+// lookupFold reads a key of a map, ignoring case as GitHub does for context names
+func lookupFold(m map[string]any, key string) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// exprIndex reads a property of an object or an element of an array. Missing properties
+// are unknown, since most contexts are only partially known before the run.
+func exprIndex(v, index any) any {
+	switch container := v.(type) {
+	case map[string]any:
+		if value, ok := lookupFold(container, exprString(index)); ok {
+			return value
+		}
+	case []any:
+		if f, ok := index.(float64); ok && f >= 0 && int(f) < len(container) && f == math.Trunc(f) {
+			return container[int(f)]
+		}
+		return nil
+	}
+	return exprUnknown{}
+}
+
+// exprCall evaluates a function call. Status functions and hashFiles depend on the run.
+func exprCall(name string, args []any) any {
+	for _, a := range args {
+		if isUnknown(a) {
+			return a
+		}
+	}
+	arg := func(i int) any {
+		if i < len(args) {
+			return args[i]
+		}
+		return nil
+	}
+
+	switch strings.ToLower(name) {
+	case "contains":
+		if list, ok := arg(0).([]any); ok {
+			for _, item := range list {
+				if exprEqual(item, arg(1)) {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(strings.ToLower(exprString(arg(0))), strings.ToLower(exprString(arg(1))))
+	case "startswith":
+		return strings.HasPrefix(strings.ToLower(exprString(arg(0))), strings.ToLower(exprString(arg(1))))
+	case "endswith":
+		return strings.HasSuffix(strings.ToLower(exprString(arg(0))), strings.ToLower(exprString(arg(1))))
+	case "format":
+		return exprFormat(exprString(arg(0)), args[min(1, len(args)):])
+	case "join":
+		sep := ","
+		if len(args) > 1 {
+			sep = exprString(args[1])
+		}
+		list, ok := arg(0).([]any)
+		if !ok {
+			return exprString(arg(0))
+		}
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = exprString(item)
+		}
+		return strings.Join(parts, sep)
+	case "tojson":
+		out, err := json.MarshalIndent(arg(0), "", "  ")
+		if err != nil {
+			return exprUnknown{}
+		}
+		return string(out)
+	case "fromjson":
+		var v any
+		if err := json.Unmarshal([]byte(exprString(arg(0))), &v); err != nil {
+			return exprUnknown{}
+		}
+		return v
+	}
+	return exprUnknown{}
+}
+
+// exprFormat replaces the {N} placeholders of a format string, with {{ and }} as escapes
+func exprFormat(format string, args []any) any {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '{' && i+1 < len(format) && format[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(format) && format[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return exprUnknown{}
+			}
+			n, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || n < 0 || n >= len(args) {
+				return exprUnknown{}
+			}
+			b.WriteString(exprString(args[n]))
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// exprNumber parses a number literal, NaN if invalid
+func exprNumber(s string) float64 {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		if n, err := strconv.ParseInt(s[2:], 16, 64); err == nil {
+			return float64(n)
+		}
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// exprString converts a value to a string the way GitHub interpolates it
+func exprString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case []any:
+		return "Array"
+	default:
+		return "Object"
+	}
+}
+
+// exprToNumber coerces a value to a number for comparisons between types
+func exprToNumber(v any) float64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0
+		}
+		return exprNumber(strings.TrimSpace(v))
+	}
+	return math.NaN()
+}
+
+// exprTruthy reports whether a value is true in a condition
+func exprTruthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+// exprEqual compares values loosely: strings ignore case and values of different types are
+// compared as numbers
+func exprEqual(a, b any) bool {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.EqualFold(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return a == b
+		}
+	case nil:
+		if b == nil {
+			return true
+		}
+	case map[string]any, []any:
+		// Objects and arrays are only equal to themselves, which copies never are.
+		return false
+	}
+	return exprToNumber(a) == exprToNumber(b)
+}
+
+// exprCompare orders two values, reporting false when they cannot be ordered
+func exprCompare(a, b any) (int, bool) {
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return strings.Compare(strings.ToLower(as), strings.ToLower(bs)), true
+		}
+	}
+	x, y := exprToNumber(a), exprToNumber(b)
+	switch {
+	case math.IsNaN(x) || math.IsNaN(y):
+		return 0, false
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func exprFixture() exprContext {
+	return exprContext{
+		"github": map[string]any{"server_url": "https://github.com", "repository": "org/repo"},
+		"inputs": map[string]any{"version": "v2", "debug": false, "retries": 3.0},
+		"env":    map[string]any{"REGISTRY": "ghcr.io", "TAGS": `["a","b"]`},
+		"matrix": map[string]any{"os": "ubuntu"},
+	}
+}
+
+// --- Tests for evalExpression ---
+
+func TestEvalExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected any
+		ok       bool
+	}{
+		{"string literal", `'it''s'`, "it's", true},
+		{"number literal", "0x10", 16.0, true},
+		{"context property", "inputs.version", "v2", true},
+		{"index with string", "github['server_url']", "https://github.com", true},
+		{"context names ignore case", "ENV.registry", "ghcr.io", true},
+		{"format", "format('{0}/{1}@{2}', env.REGISTRY, github.repository, inputs.version)", "ghcr.io/org/repo@v2", true},
+		{"escaped braces", "format('{{0}} {0}', 'x')", "{0} x", true},
+		{"fromJSON and index", "fromJSON(env.TAGS)[1]", "b", true},
+		{"join", "join(fromJSON(env.TAGS), '-')", "a-b", true},
+		{"contains ignores case", "contains('Hello', 'hell')", true, true},
+		{"equality ignores case", "matrix.os == 'UBUNTU'", true, true},
+		{"equality coerces types", "inputs.retries == '3'", true, true},
+		{"and or", "inputs.debug && 'yes' || 'no'", "no", true},
+		{"not and comparison", "!(inputs.retries < 2)", true, true},
+		{"or short-circuits unknown", "'set' || secrets.TOKEN", "set", true},
+		{"secrets are unknown", "secrets.TOKEN", nil, false},
+		{"missing env is unknown", "env.OTHER", nil, false},
+		{"unknown operand", "steps.build.outputs.tag == 'v1'", nil, false},
+		{"status function", "success()", nil, false},
+		{"filter", "github.event.commits.*.id", nil, false},
+		{"syntax error", "inputs.version ==", nil, false},
+		{"unterminated string", "'abc", nil, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := evalExpression(tc.expr, exprFixture())
+			if ok != tc.ok || !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("evalExpression(%q) = %v, %v; want %v, %v", tc.expr, got, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+// --- Tests for interpolate ---
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		complete bool
+	}{
+		{"no expression", "node:20", "node:20", true},
+		{"concatenation", "${{ env.REGISTRY }}/tool:${{ inputs.version }}", "ghcr.io/tool:v2", true},
+		{"braces in a string", "${{ format('{0}}}', 'a') }}", "a}", true},
+		{"unknown kept as written", "${{ env.REGISTRY }}/${{ vars.IMAGE }}", "ghcr.io/${{ vars.IMAGE }}", false},
+		{"unclosed", "${{ env.REGISTRY", "${{ env.REGISTRY", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, complete := interpolate(tc.input, exprFixture())
+			if got != tc.expected || complete != tc.complete {
+				t.Errorf("interpolate(%q) = %q, %v; want %q, %v", tc.input, got, complete, tc.expected, tc.complete)
+			}
+		})
+	}
+}
+
+// --- Tests for staticContext ---
+
+func TestStaticContext(t *testing.T) {
+	content := `name: release
+on:
+  workflow_dispatch:
+    inputs:
+      channel:
+        default: stable
+      dry-run:
+        type: boolean
+        default: true
+env:
+  IMAGE: ghcr.io/org/app:${{ inputs.channel }}
+  TOKEN: ${{ secrets.TOKEN }}
+jobs:
+  build:
+    env:
+      IMAGE: ${{ env.IMAGE }}-slim
+`
+	wf := newWorkflowFile("wf.yml", []byte(content))
+	job := wf.jobs()[0]
+
+	expected := exprContext{
+		"github": map[string]any{
+			"server_url":  "https://github.com",
+			"api_url":     "https://api.github.com",
+			"graphql_url": "https://api.github.com/graphql",
+			"workflow":    "release",
+		},
+		"inputs": map[string]any{"channel": "stable", "dry-run": true},
+		"env":    map[string]any{"IMAGE": "ghcr.io/org/app:stable-slim"},
+	}
+	if !reflect.DeepEqual(job.Context, expected) {
+		t.Errorf("staticContext = %v; want %v", job.Context, expected)
+	}
+}
+
+func TestStaticContext_EnterpriseServer(t *testing.T) {
+	withGitHubEndpoints(t)
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example.com")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_GRAPHQL_URL", "")
+	CheckIfError(githubServerFromEnv())
+
+	github := staticContext(nil, nil)["github"]
+	expected := map[string]any{
+		"server_url":  "https://github.example.com",
+		"api_url":     "https://github.example.com/api/v3",
+		"graphql_url": "https://github.example.com/api/graphql",
+	}
+	if !reflect.DeepEqual(github, expected) {
+		t.Errorf("github = %v; want %v", github, expected)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// PathsConfig limits the files scanned by globs of their path relative to the repository,
+// such as .github/workflows/release-*.yml. A glob matching a directory matches the files
+// below it.
+type PathsConfig struct {
+	// Include, when set, scans only the files matching one of these globs
+	Include []string `yaml:"include"`
+	// Exclude skips the files matching one of these globs, even when included
+	Exclude []string `yaml:"exclude"`
+}
+
+// includes reports whether a file at a path relative to the repository is scanned
+func (p PathsConfig) includes(rel string) bool {
+	if len(p.Include) > 0 && !matchesPath(p.Include, rel) {
+		return false
+	}
+	return !matchesPath(p.Exclude, rel)
+}
+
+// check validates the globs of the paths section of the configuration
+func (p PathsConfig) check() error {
+	for _, glob := range slices.Concat(p.Include, p.Exclude) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("paths: %s: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// matchesPath reports whether one of the globs matches a path or one of its parent
+// directories
+func matchesPath(globs []string, rel string) bool {
+	for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		for _, glob := range globs {
+			if matched, _ := path.Match(glob, p); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchingAction returns the first glob matching a reference, such as actions/checkout@v4.
+// Globs with a ref match the whole reference, others the action or its repository.
+func matchingAction(globs []string, ref string) (string, bool) {
+	action, _, _ := strings.Cut(ref, "@")
+	for _, glob := range globs {
+		candidates := []string{action, actionName(action)}
+		if strings.Contains(glob, "@") {
+			candidates = []string{ref}
+		}
+		for _, c := range candidates {
+			if matched, _ := path.Match(strings.ToLower(glob), strings.ToLower(c)); matched {
+				return glob, true
+			}
+		}
+	}
+	return "", false
+}
+
+// overrideSeverities sets the severities of the findings of rules listed in overrides
+func overrideSeverities(findings []Finding, overrides map[string]string) {
+	for i, f := range findings {
+		if severity, ok := overrides[f.Rule]; ok {
+			findings[i].Severity = severity
+		}
+	}
+}
+
+// checkSeverityOverrides validates the severity_overrides section of the configuration
+func checkSeverityOverrides(overrides map[string]string) error {
+	for rule, severity := range overrides {
+		if _, ok := severityRank[severity]; !ok {
+			return fmt.Errorf("severity_overrides of %s: unknown severity %q. Valid severities are low, medium, high, critical", rule, severity)
+		}
+	}
+	return nil
+}
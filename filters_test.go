@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for PathsConfig.includes ---
+
+func TestPathsConfig_Includes(t *testing.T) {
+	paths := PathsConfig{
+		Include: []string{".github/workflows", ".gitlab-ci.yml"},
+		Exclude: []string{".github/workflows/experimental", "*/*/*.draft.yml"},
+	}
+	tests := []struct {
+		rel      string
+		expected bool
+	}{
+		{".github/workflows/ci.yml", true},
+		{".gitlab-ci.yml", true},
+		{".github/workflows/experimental/try.yml", false},
+		{".github/workflows/next.draft.yml", false},
+		{".circleci/config.yml", false},
+	}
+	for _, tc := range tests {
+		if got := paths.includes(tc.rel); got != tc.expected {
+			t.Errorf("includes(%s) = %v; want %v", tc.rel, got, tc.expected)
+		}
+	}
+	if !(PathsConfig{}).includes(".circleci/config.yml") {
+		t.Error("expected every file to be scanned without include")
+	}
+	if err := (PathsConfig{Exclude: []string{"["}}).check(); err == nil {
+		t.Error("expected an error for a malformed glob")
+	}
+}
+
+// --- Tests for matchingAction ---
+
+func TestMatchingAction(t *testing.T) {
+	globs := []string{"actions/*", "github/codeql-action", "docker/login-action@v3"}
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"actions/checkout@v4", "actions/*"},
+		{"Actions/Setup-Go@v5", "actions/*"},
+		{"github/codeql-action/init@v3", "github/codeql-action"},
+		{"docker/login-action@v3", "docker/login-action@v3"},
+		{"docker/login-action@v2", ""},
+		{"acme/deploy@main", ""},
+	}
+	for _, tc := range tests {
+		if got, ok := matchingAction(globs, tc.ref); got != tc.expected || ok != (tc.expected != "") {
+			t.Errorf("matchingAction(%s) = %s, %v; want %s", tc.ref, got, ok, tc.expected)
+		}
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with project settings ---
+
+func TestScanner_ScanBranchAppliesProjectSettings(t *testing.T) {
+	repo := fakeRepository{
+		name:  "repo",
+		files: []string{"ci.yml", "wip.yml"},
+		fileContents: map[string][]byte{
+			"root/.github/workflows/ci.yml":  []byte("on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: acme/deploy@v1\n      - run: echo \"::set-output name=a::b\"\n"),
+			"root/.github/workflows/wip.yml": []byte("on: push\njobs:\n  a:\n    steps:\n      - uses: acme/deploy@v1\n"),
+		},
+	}
+	sc := Scanner{
+		FileScanner:       GitHubWorkFlowScanner{},
+		Rules:             []Rule{DeprecatedRule{}},
+		Paths:             PathsConfig{Exclude: []string{".github/workflows/wip.yml"}},
+		Policy:            ActionPolicy{Trusted: []string{"actions/*"}},
+		SeverityOverrides: map[string]string{ruleDeprecated: SeverityHigh},
+	}
+
+	records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+	if len(records) != 1 {
+		t.Fatalf("expected the excluded workflow to be skipped, got %+v", records)
+	}
+	if ir := records[0]; len(ir.Matches) != 1 || ir.Matches[0] != "acme/deploy@v1" {
+		t.Errorf("expected the trusted action to be left out, got %v", ir.Matches)
+	}
+	if f := records[0].Findings; len(f) != 1 || f[0].Severity != SeverityHigh {
+		t.Errorf("expected the severity of the rule to be overridden, got %+v", f)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TextEdit replaces the bytes between the Start and End offsets of a file with NewText.
+// Edits only touch the lines they are about, so formatting and comments elsewhere survive.
+type TextEdit struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	NewText string `json:"new_text"`
+}
+
+// applyEdits returns content with all edits applied. Edits must not overlap.
+func applyEdits(content []byte, edits []TextEdit) ([]byte, error) {
+	sorted := append([]TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	prev := 0
+	for _, e := range sorted {
+		if e.Start < prev || e.End < e.Start || e.End > len(content) {
+			return nil, fmt.Errorf("fix: overlapping or invalid edit at offset %d", e.Start)
+		}
+		b.Write(content[prev:e.Start])
+		b.WriteString(e.NewText)
+		prev = e.End
+	}
+	b.Write(content[prev:])
+
+	return []byte(b.String()), nil
+}
+
+// indentation returns the number of leading spaces of a line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// blockExtent returns the byte range covering a mapping key and its value, from the start
+// of the key line up to and including the last line indented deeper than the key. Trailing
+// blank and comment lines are left out, as they usually belong to what follows.
+func (wf *WorkflowFile) blockExtent(key *yaml.Node) (int, int) {
+	li := wf.lines
+	last := key.Line
+	for l := key.Line + 1; l <= li.lineCount(); l++ {
+		text := li.lineText(l)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indentation(text) <= key.Column-1 {
+			break
+		}
+		last = l
+	}
+	return li.lineStart(key.Line), li.lineEnd(last)
+}
+
+// localKey returns the key node of a mapping entry defined in the mapping itself,
+// ignoring entries brought in through merge keys
+func localKey(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// FixResult describes a workflow file changed by fixers
+type FixResult struct {
+	Path     string
+	Original []byte
+	Fixed    []byte
+}
+
+// FixWorkflows runs fixers over every workflow file of the repository at root, and pipeline
+// fixers over the pipeline file they are about. With dryRun the files are left untouched and
+// a unified diff of the changes is written to out instead.
+func FixWorkflows(root string, fixers []Fixer, dryRun bool, out io.Writer) ([]FixResult, error) {
+	repo := GitRepository{name: filepath.Base(root), localPath: root}
+
+	var workflowFixers []Fixer
+	var paths []string
+	byPath := map[string][]Fixer{}
+	for _, f := range fixers {
+		pf, ok := f.(PipelineFixer)
+		if !ok {
+			workflowFixers = append(workflowFixers, f)
+			continue
+		}
+		fPath := filepath.Join(root, filepath.FromSlash(pf.File()))
+		if _, err := os.Stat(fPath); err != nil {
+			logger.Debug("no pipeline file to fix", "file", fPath)
+			continue
+		}
+		if byPath[fPath] == nil {
+			paths = append(paths, fPath)
+		}
+		byPath[fPath] = append(byPath[fPath], f)
+	}
+
+	if len(workflowFixers) > 0 {
+		dir := workflowDir(root)
+		fileNames, err := repo.ListFiles(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+		var workflows []string
+		for _, fileName := range fileNames {
+			if isYAMLFile(fileName) {
+				workflows = append(workflows, filepath.Join(dir, fileName))
+			}
+		}
+		for _, fPath := range workflows {
+			byPath[fPath] = workflowFixers
+		}
+		paths = append(workflows, paths...)
+	}
+
+	var results []FixResult
+	for _, fPath := range paths {
+		content, err := repo.ReadFile(fPath)
+		if err != nil {
+			logger.Warn("skipping workflow file", "file", fPath, "err", err)
+			continue
+		}
+
+		fixed, err := fixContent(fPath, content, byPath[fPath])
+		if err != nil {
+			return results, err
+		}
+		if string(fixed) == string(content) {
+			continue
+		}
+
+		results = append(results, FixResult{Path: fPath, Original: content, Fixed: fixed})
+
+		if dryRun {
+			fmt.Fprint(out, unifiedDiff(relativePath(root, fPath), content, fixed))
+			continue
+		}
+
+		info, err := os.Stat(fPath)
+		if err != nil {
+			return results, fmt.Errorf("file error: %w", err)
+		}
+		if err := os.WriteFile(fPath, fixed, info.Mode().Perm()); err != nil {
+			return results, fmt.Errorf("file error: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// relativePath returns path relative to root with forward slashes, as used in diffs and
+// pull requests, or path itself if it is not under root
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// fixContent applies fixers one after another, re-parsing the file in between so each
+// fixer sees the result of the previous one. The outcome must still be valid YAML.
+func fixContent(path string, content []byte, fixers []Fixer) ([]byte, error) {
+	for _, f := range fixers {
+		wf := newWorkflowFile(path, content)
+		if len(wf.Docs) == 0 {
+			return content, nil
+		}
+
+		edits, err := f.Fix(wf)
+		if err != nil {
+			return nil, fmt.Errorf("fix %s: %w", path, err)
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		fixed, err := applyEdits(content, edits)
+		if err != nil {
+			return nil, fmt.Errorf("fix %s: %w", path, err)
+		}
+		if _, err := parseYAMLDocuments(fixed); err != nil {
+			return nil, fmt.Errorf("fix %s: result would be invalid YAML: %w", path, err)
+		}
+		content = fixed
+	}
+
+	return content, nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// --- Tests for applyEdits ---
+
+func TestApplyEdits(t *testing.T) {
+	content := []byte("abcdef")
+	tests := []struct {
+		name     string
+		edits    []TextEdit
+		expected string
+		wantErr  bool
+	}{
+		{name: "no edits", expected: "abcdef"},
+		{name: "insert", edits: []TextEdit{{Start: 3, End: 3, NewText: "X"}}, expected: "abcXdef"},
+		{name: "replace unordered", edits: []TextEdit{{Start: 4, End: 6, NewText: "Z"}, {Start: 0, End: 1, NewText: "Y"}}, expected: "YbcdZ"},
+		{name: "overlap", edits: []TextEdit{{Start: 0, End: 3}, {Start: 2, End: 4}}, wantErr: true},
+		{name: "out of range", edits: []TextEdit{{Start: 5, End: 9}}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := applyEdits(content, tc.edits)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("applyEdits = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for blockExtent ---
+
+func TestBlockExtent(t *testing.T) {
+	content := "a:\n  b: 1\n\n  # note\n  c: 2\n# trailing\nd: 3\n"
+	wf := newWorkflowFile("wf.yml", []byte(content))
+	key, _ := localKey(wf.roots()[0], "a")
+
+	start, end := wf.blockExtent(key)
+	if got := content[start:end]; got != "a:\n  b: 1\n\n  # note\n  c: 2\n" {
+		t.Errorf("blockExtent = %q", got)
+	}
+}
+
+// --- Tests for unifiedDiff ---
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		fixed    string
+		expected string
+	}{
+		{name: "unchanged", original: "a\nb\n", fixed: "a\nb\n", expected: ""},
+		{
+			name:     "insert",
+			original: "1\n2\n3\n4\n5\n",
+			fixed:    "1\n2\nnew\n3\n4\n5\n",
+			expected: "diff --git a/wf.yml b/wf.yml\n--- a/wf.yml\n+++ b/wf.yml\n@@ -1,5 +1,6 @@\n 1\n 2\n+new\n 3\n 4\n 5\n",
+		},
+		{
+			name:     "separate hunks",
+			original: "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			fixed:    "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			expected: "diff --git a/wf.yml b/wf.yml\n--- a/wf.yml\n+++ b/wf.yml\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name:     "no newline at end",
+			original: "a\nb",
+			fixed:    "a\nc",
+			expected: "diff --git a/wf.yml b/wf.yml\n--- a/wf.yml\n+++ b/wf.yml\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := unifiedDiff("wf.yml", []byte(tc.original), []byte(tc.fixed)); got != tc.expected {
+				t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for FixWorkflows ---
+
+const unfixedWorkflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+`
+
+func fixRepoFixture(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	dir := workflowDir(root)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ci.yml")
+	if err := os.WriteFile(path, []byte(unfixedWorkflow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root, path
+}
+
+func TestFixWorkflows_DryRun(t *testing.T) {
+	root, path := fixRepoFixture(t)
+
+	var out bytes.Buffer
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, true, &out)
+	if err != nil {
+		t.Fatalf("FixWorkflows returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 fixed file, got %d", len(results))
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != unfixedWorkflow {
+		t.Errorf("dry run must not write the file, got:\n%s", content)
+	}
+	if !strings.HasPrefix(out.String(), "diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml\n") {
+		t.Errorf("unexpected diff:\n%s", out.String())
+	}
+
+	// The diff must apply cleanly and yield the fixed file.
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+	cmd := exec.Command("git", "apply")
+	cmd.Dir = root
+	cmd.Stdin = &out
+	if b, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s", err, b)
+	}
+	content, _ = os.ReadFile(path)
+	if string(content) != string(results[0].Fixed) {
+		t.Errorf("applied diff =\n%s\nwant\n%s", content, results[0].Fixed)
+	}
+}
+
+func TestFixWorkflows_DryRunPins(t *testing.T) {
+	root, path := fixRepoFixture(t)
+	other := filepath.Join(filepath.Dir(path), "release.yml")
+	CheckIfError(os.WriteFile(other, []byte("on: push\njobs:\n  release:\n    uses: acme/workflows/.github/workflows/release.yml@main\n"), 0o644))
+
+	fixer := PinFixer{Resolver: fakeResolver{"actions/checkout@v4": lockedSHA, "acme/workflows@main": movedSHA}}
+	var out bytes.Buffer
+	results, err := FixWorkflows(root, []Fixer{fixer}, true, &out)
+	CheckIfError(err)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 fixed files, got %d", len(results))
+	}
+
+	for _, expected := range []string{
+		"diff --git a/.github/workflows/ci.yml b/.github/workflows/ci.yml\n",
+		"-      - uses: actions/checkout@v4\n+      - uses: actions/checkout@" + lockedSHA + " # v4\n",
+		"diff --git a/.github/workflows/release.yml b/.github/workflows/release.yml\n",
+		"-    uses: acme/workflows/.github/workflows/release.yml@main\n+    uses: acme/workflows/.github/workflows/release.yml@" + movedSHA + " # main\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in the diff:\n%s", expected, out.String())
+		}
+	}
+	if content, _ := os.ReadFile(path); string(content) != unfixedWorkflow {
+		t.Errorf("dry run must not write the file, got:\n%s", content)
+	}
+}
+
+func TestFixWorkflows_Write(t *testing.T) {
+	root, path := fixRepoFixture(t)
+
+	var out bytes.Buffer
+	if _, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, &out); err != nil {
+		t.Fatalf("FixWorkflows returned error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "permissions:\n      contents: read\n") {
+		t.Errorf("file was not fixed:\n%s", content)
+	}
+
+	// Nothing is left to fix.
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, &out)
+	if err != nil || len(results) != 0 {
+		t.Errorf("expected no further fixes, got %d (err %v)", len(results), err)
+	}
+}
+
+func TestFixWorkflows_NoWorkflowDirectory(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, filepath.FromSlash(buildkitePipelineFile))
+	CheckIfError(os.MkdirAll(filepath.Dir(path), 0o755))
+	CheckIfError(os.WriteFile(path, []byte(buildkitePipeline), 0o644))
+
+	// Without workflows, workflow fixers have nothing to do while pipelines are still fixed.
+	fixer := BuildkitePluginFixer{Resolver: fakeResolver{"my-org/secrets-buildkite-plugin@main": movedSHA}}
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}, fixer}, false, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("FixWorkflows returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != path {
+		t.Errorf("expected the Buildkite pipeline to be fixed, got %+v", results)
+	}
+}
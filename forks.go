@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// forkReadOnlyTriggers are events a pull request from a fork can start that run without
+// secrets and with a read-only token, but with the code of the fork
+var forkReadOnlyTriggers = map[string]bool{
+	"pull_request":                true,
+	"pull_request_review":         true,
+	"pull_request_review_comment": true,
+}
+
+// forkPrivilegedTriggers are events a pull request from a fork can start, directly or
+// through another workflow, that run in the base repository with its secrets
+var forkPrivilegedTriggers = map[string]bool{
+	"pull_request_target": true,
+	"workflow_run":        true,
+	"issue_comment":       true,
+}
+
+// forkGuard matches job conditions that skip pull requests from forks
+var forkGuard = regexp.MustCompile(`github\.event\.pull_request\.head\.repo\.(fork\s*==\s*false|full_name\s*==\s*github\.repository)\b|!\s*github\.event\.pull_request\.head\.repo\.fork\b`)
+
+// ForkVerdict tells whether a pull request from a fork can get at the secrets, the token or
+// the runners of a workflow, with the reasons why or why not
+type ForkVerdict struct {
+	Safe    bool     `json:"safe"`
+	Reasons []string `json:"reasons"`
+}
+
+// verdict renders the verdict in a few words
+func (v *ForkVerdict) verdict() string {
+	if v.Safe {
+		return "safe for fork PRs"
+	}
+	return "not safe for fork PRs"
+}
+
+// forkVerdict analyzes what the runs of a workflow started by pull requests from forks can
+// reach. pull_request runs get neither secrets nor a token able to write, so only self-hosted
+// runners make them unsafe. Runs of privileged triggers are unsafe as soon as a job reads a
+// secret, passes secrets to a reusable workflow, deploys to an environment or gets a token
+// able to write, unless the job skips forks.
+func forkVerdict(wf *WorkflowFile) *ForkVerdict {
+	var readOnly, privileged []string
+	for _, root := range wf.roots() {
+		for _, t := range workflowTriggers(mappingValue(root, "on")) {
+			switch {
+			case forkReadOnlyTriggers[t] && !slices.Contains(readOnly, t):
+				readOnly = append(readOnly, t)
+			case forkPrivilegedTriggers[t] && !slices.Contains(privileged, t):
+				privileged = append(privileged, t)
+			}
+		}
+	}
+	if len(readOnly) == 0 && len(privileged) == 0 {
+		return &ForkVerdict{Safe: true, Reasons: []string{"no trigger can be started by a pull request from a fork"}}
+	}
+
+	var unsafe, notes []string
+	on := strings.Join(privileged, ", ")
+	for _, root := range wf.roots() {
+		wfPerms := mappingValue(root, "permissions")
+		for _, pair := range mappingPairs(mappingValue(root, "jobs")) {
+			job := resolveAlias(pair.Value)
+			if job == nil || job.Kind != yaml.MappingNode {
+				continue
+			}
+			id := pair.Key.Value
+			if cond := mappingValue(job, "if"); cond != nil && forkGuard.MatchString(cond.Value) {
+				notes = append(notes, fmt.Sprintf("job %s skips pull requests from forks", id))
+				continue
+			}
+
+			ctx, matrix := staticContext(root, job), jobMatrix(job)
+			for _, written := range runnerLabels(mappingValue(job, "runs-on")) {
+				for _, label := range expandMatrix(written, ctx, matrix) {
+					if isSelfHosted(label) {
+						unsafe = append(unsafe, fmt.Sprintf("job %s runs the code of forks on the self-hosted runner %s", id, label))
+					}
+				}
+			}
+			if len(privileged) == 0 {
+				continue
+			}
+
+			if names := jobSecrets(root, job); len(names) > 0 {
+				unsafe = append(unsafe, fmt.Sprintf("job %s reads the secrets %s on %s", id, strings.Join(names, ", "), on))
+			}
+			if s, uses := mappingValue(job, "secrets"), mappingValue(job, "uses"); s != nil && s.Value == "inherit" && uses != nil {
+				unsafe = append(unsafe, fmt.Sprintf("job %s passes all secrets to %s on %s", id, uses.Value, on))
+			}
+			if env := jobEnvironment(job); env != nil {
+				unsafe = append(unsafe, fmt.Sprintf("job %s deploys to the environment %s on %s, whose secrets are exposed unless it requires reviewers", id, env.Value, on))
+			}
+
+			granted := mappingValue(job, "permissions")
+			if granted == nil {
+				granted = wfPerms
+			}
+			if granted == nil {
+				unsafe = append(unsafe, fmt.Sprintf("job %s gets a token with the default permissions of the repository on %s, which may write", id, on))
+				continue
+			}
+			var writes []string
+			for scope, level := range parsePermissions(granted) {
+				if level == permWrite {
+					writes = append(writes, scope)
+				}
+			}
+			if len(writes) > 0 {
+				sort.Strings(writes)
+				unsafe = append(unsafe, fmt.Sprintf("job %s gets a token able to write %s on %s", id, strings.Join(writes, ", "), on))
+			}
+		}
+	}
+
+	if len(unsafe) > 0 {
+		return &ForkVerdict{Reasons: append(unsafe, notes...)}
+	}
+	var reasons []string
+	if len(readOnly) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%s runs from forks get no secrets and a read-only token", strings.Join(readOnly, ", ")))
+	}
+	if len(privileged) > 0 {
+		reasons = append(reasons, fmt.Sprintf("no job reads secrets or gets a token able to write on %s", on))
+	}
+	return &ForkVerdict{Safe: true, Reasons: append(reasons, notes...)}
+}
+
+// jobSecrets returns the secrets a job reads, in its steps, handed to a reusable workflow
+// through secrets: or set in the env: of the workflow
+func jobSecrets(root, job *yaml.Node) []string {
+	names := map[string]bool{}
+	collect := func(n *yaml.Node) {
+		for _, m := range secretReference.FindAllStringSubmatch(n.Value, -1) {
+			if m[1] != "GITHUB_TOKEN" {
+				names[m[1]] = true
+			}
+		}
+	}
+	walkScalars(job, collect)
+	if env := mappingValue(root, "env"); env != nil {
+		walkScalars(env, collect)
+	}
+	return sortedKeys(names)
+}
+
+// forkRow is a workflow of the workspace with its fork verdict
+type forkRow struct {
+	Repository string
+	FilePath   string
+	Verdict    *ForkVerdict
+}
+
+// forkVerdicts returns the fork verdicts of the profiled workflows of an inventory, unsafe
+// workflows first
+func forkVerdicts(inv *Inventory) []forkRow {
+	var rows []forkRow
+	for _, ir := range inv.Records {
+		if ir.Profile != nil && ir.Profile.Fork != nil {
+			rows = append(rows, forkRow{Repository: ir.Repository, FilePath: ir.FilePath, Verdict: ir.Profile.Fork})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Verdict.Safe != rows[j].Verdict.Safe {
+			return !rows[i].Verdict.Safe
+		}
+		if rows[i].Repository != rows[j].Repository {
+			return rows[i].Repository < rows[j].Repository
+		}
+		return rows[i].FilePath < rows[j].FilePath
+	})
+	return rows
+}
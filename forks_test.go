@@ -0,0 +1,156 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// --- Tests for forkVerdict ---
+
+func TestForkVerdict(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected ForkVerdict
+	}{
+		{
+			name: "push only",
+			content: `on: push
+jobs:
+  build:
+    runs-on: self-hosted
+    steps:
+      - run: echo ${{ secrets.TOKEN }}
+`,
+			expected: ForkVerdict{Safe: true, Reasons: []string{"no trigger can be started by a pull request from a fork"}},
+		},
+		{
+			name: "pull_request with secrets",
+			content: `on: [pull_request, push]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ secrets.TOKEN }}
+`,
+			expected: ForkVerdict{Safe: true, Reasons: []string{"pull_request runs from forks get no secrets and a read-only token"}},
+		},
+		{
+			name: "pull_request on self-hosted runners",
+			content: `on: pull_request
+jobs:
+  test:
+    runs-on: [self-hosted, linux]
+    steps:
+      - run: make test
+`,
+			expected: ForkVerdict{Reasons: []string{
+				"job test runs the code of forks on the self-hosted runner self-hosted",
+				"job test runs the code of forks on the self-hosted runner linux",
+			}},
+		},
+		{
+			name: "pull_request_target reading secrets",
+			content: `on: pull_request_target
+permissions:
+  contents: read
+env:
+  REGISTRY: ${{ secrets.REGISTRY }}
+jobs:
+  label:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ secrets.NPM_TOKEN }} ${{ secrets.GITHUB_TOKEN }}
+`,
+			expected: ForkVerdict{Reasons: []string{"job label reads the secrets NPM_TOKEN, REGISTRY on pull_request_target"}},
+		},
+		{
+			name: "workflow_run passing secrets, deploying and writing",
+			content: `on:
+  workflow_run:
+    workflows: [ci]
+permissions:
+  contents: write
+  pull-requests: write
+jobs:
+  release:
+    uses: org/repo/.github/workflows/release.yml@main
+    secrets: inherit
+  deploy:
+    runs-on: ubuntu-latest
+    environment:
+      name: production
+    permissions: {}
+    steps:
+      - run: ./deploy.sh
+`,
+			expected: ForkVerdict{Reasons: []string{
+				"job release passes all secrets to org/repo/.github/workflows/release.yml@main on workflow_run",
+				"job release gets a token able to write contents, pull-requests on workflow_run",
+				"job deploy deploys to the environment production on workflow_run, whose secrets are exposed unless it requires reviewers",
+			}},
+		},
+		{
+			name: "issue_comment with the default token",
+			content: `on: issue_comment
+jobs:
+  triage:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo hi
+`,
+			expected: ForkVerdict{Reasons: []string{"job triage gets a token with the default permissions of the repository on issue_comment, which may write"}},
+		},
+		{
+			name: "pull_request_target job skipping forks",
+			content: `on: [pull_request_target, pull_request]
+permissions: read-all
+jobs:
+  publish:
+    if: github.event.pull_request.head.repo.full_name == github.repository
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ secrets.TOKEN }}
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+`,
+			expected: ForkVerdict{Safe: true, Reasons: []string{
+				"pull_request runs from forks get no secrets and a read-only token",
+				"no job reads secrets or gets a token able to write on pull_request_target",
+				"job publish skips pull requests from forks",
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := forkVerdict(newWorkflowFile("wf.yml", []byte(tc.content)))
+			if !reflect.DeepEqual(*v, tc.expected) {
+				t.Errorf("verdict = %+v; want %+v", *v, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for forkVerdicts ---
+
+func TestForkVerdicts(t *testing.T) {
+	safe, unsafe := &ForkVerdict{Safe: true}, &ForkVerdict{}
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "a", FilePath: "ci.yml", Profile: &WorkflowProfile{Fork: safe}},
+		{Repository: "b", FilePath: "ci.yml"},
+		{Repository: "c", FilePath: "label.yml", Profile: &WorkflowProfile{Fork: unsafe}},
+		{Repository: "a", FilePath: "triage.yml", Profile: &WorkflowProfile{Fork: unsafe}},
+	}}
+
+	var got []string
+	for _, r := range forkVerdicts(inv) {
+		got = append(got, r.Repository+"/"+r.FilePath+" "+r.Verdict.verdict())
+	}
+	expected := []string{"a/triage.yml not safe for fork PRs", "c/label.yml not safe for fork PRs", "a/ci.yml safe for fork PRs"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("forkVerdicts = %v; want %v", got, expected)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dependencyFreshness tells how far a pinned action is behind its latest release
+type dependencyFreshness struct {
+	Action string
+	// Ref is the SHA or exact version the workflows pin
+	Ref string
+	// Version is the tag of the pinned commit, empty when no recent tag points to it
+	Version    string
+	PinnedAt   time.Time
+	Latest     string
+	LatestAt   time.Time
+	Repository []string
+}
+
+// Gap returns the whole days between the pinned commit and the latest release, or -1 when
+// either date is unknown
+func (d dependencyFreshness) Gap() int {
+	if d.PinnedAt.IsZero() || d.LatestAt.IsZero() {
+		return -1
+	}
+	return max(0, int(d.LatestAt.Sub(d.PinnedAt).Hours()/24))
+}
+
+// Current reports whether the latest release is the pinned version
+func (d dependencyFreshness) Current() bool {
+	return d.Latest != "" && (d.Latest == d.Version || d.Latest == d.Ref)
+}
+
+// pinnedDependencies collects the actions of the scanned workflows pinned to a commit SHA
+// or an exact version, along with the repositories pinning each
+func pinnedDependencies(inv *Inventory) map[string]map[string]bool {
+	deps := map[string]map[string]bool{}
+	for _, ir := range inv.Records {
+		if ir.Profile == nil {
+			continue
+		}
+		for _, uses := range ir.Profile.Actions {
+			action, ref, ok := strings.Cut(uses, "@")
+			if !ok || strings.HasPrefix(uses, "docker://") || strings.HasPrefix(uses, ".") {
+				continue
+			}
+			if !pinnedRef.MatchString("@"+ref) && !exactSemver.MatchString(ref) {
+				continue
+			}
+			key := actionName(action) + "@" + ref
+			if deps[key] == nil {
+				deps[key] = map[string]bool{}
+			}
+			deps[key][ir.Repository] = true
+		}
+	}
+	return deps
+}
+
+// freshnessReport looks up the date of every pinned dependency and the latest release of
+// its action. The stalest dependencies come first.
+func freshnessReport(inv *Inventory) []dependencyFreshness {
+	type latest struct {
+		tag  string
+		date time.Time
+		tags []BranchOrTag
+	}
+	releases := map[string]*latest{}
+
+	var report []dependencyFreshness
+	for key, repos := range pinnedDependencies(inv) {
+		action, ref, _ := strings.Cut(key, "@")
+		d := dependencyFreshness{Action: action, Ref: ref, Repository: sortedKeys(repos)}
+
+		l, ok := releases[action]
+		if !ok {
+			l = &latest{}
+			releases[action] = l
+			tags, err := GetRefList(action)
+			if err != nil {
+				logger.Warn("could not list the tags of action", "action", action, "err", err)
+			}
+			l.tags = tags
+			if rel, err := fetchLatestRelease(action); err == nil {
+				l.tag, l.date = rel.TagName, rel.PublishedAt
+			} else if tag, ok := highestTag(tags); ok {
+				// Actions without releases publish versions as tags only.
+				logger.Debug("no latest release. falling back to tags", "action", action, "err", err)
+				l.tag = tag.Name
+				if l.date, err = commitDate(action, tag.Commit.Sha); err != nil {
+					logger.Warn("could not date the latest tag of action", "action", action, "tag", tag.Name, "err", err)
+				}
+			}
+		}
+		d.Latest, d.LatestAt = l.tag, l.date
+
+		d.Version = ref
+		if pinnedRef.MatchString("@" + ref) {
+			d.Version = ""
+			for _, t := range l.tags {
+				if t.Commit.Sha == ref {
+					d.Version = t.Name
+					break
+				}
+			}
+		}
+		var err error
+		if d.PinnedAt, err = commitDate(action, ref); err != nil {
+			logger.Warn("could not date the pinned commit of action", "action", key, "err", err)
+		}
+		report = append(report, d)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Gap() != report[j].Gap() {
+			return report[i].Gap() > report[j].Gap()
+		}
+		if report[i].Action != report[j].Action {
+			return report[i].Action < report[j].Action
+		}
+		return report[i].Ref < report[j].Ref
+	})
+	return report
+}
+
+// highestTag returns the tag with the highest full semantic version, such as v4.2.1
+func highestTag(tags []BranchOrTag) (BranchOrTag, bool) {
+	var best []int
+	var found BranchOrTag
+	for _, t := range tags {
+		parts, ok := parseOrbVersion(strings.TrimPrefix(t.Name, "v"))
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		if best == nil || versionLess(best, parts) {
+			best, found = parts, t
+		}
+	}
+	return found, best != nil
+}
+
+// fetchLatestRelease fetches the latest release of an action, which skips drafts and
+// prereleases
+func fetchLatestRelease(action string) (*Release, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/releases/latest", apiURL, actionName(action)))
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: no latest release for %s: %s", action, resp.Status)
+	}
+
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return &r, nil
+}
+
+// commitDate fetches when the commit a SHA or tag of an action points to was committed
+func commitDate(action, ref string) (time.Time, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/commits/%s", apiURL, actionName(action), url.PathEscape(ref)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("http: no commit %s for %s: %s", ref, action, resp.Status)
+	}
+
+	var c struct {
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return time.Time{}, fmt.Errorf("json: %w", err)
+	}
+	return c.Commit.Committer.Date, nil
+}
+
+// freshnessDate formats a date of the report, empty when unknown
+func freshnessDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+// writeFreshnessCSV renders a freshness report as CSV. Unknown dates and gaps are empty.
+func writeFreshnessCSV(w io.Writer, report []dependencyFreshness) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"action", "pinned_ref", "pinned_version", "pinned_at", "latest_version", "latest_at", "gap_days", "repositories"}}
+	for _, d := range report {
+		gap := ""
+		if d.Gap() >= 0 {
+			gap = strconv.Itoa(d.Gap())
+		}
+		rows = append(rows, []string{d.Action, d.Ref, d.Version, freshnessDate(d.PinnedAt), d.Latest, freshnessDate(d.LatestAt), gap, strings.Join(d.Repository, " ")})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
+
+var freshnessHTML = template.Must(template.New("freshness").Funcs(template.FuncMap{
+	"date": freshnessDate,
+	"ref": func(ref string) string {
+		if pinnedRef.MatchString("@" + ref) {
+			return shortSHA(ref)
+		}
+		return ref
+	},
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scharf dependency freshness</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; cursor: pointer; }
+.current { background: #c8e6c9; }
+</style>
+</head>
+<body>
+<h1>Dependency freshness</h1>
+<p>{{len .}} pinned dependencies. Click a column to sort by it.</p>
+<table id="freshness">
+<thead>
+<tr><th>Action</th><th>Pinned</th><th>Version</th><th>Pinned at</th><th>Latest</th><th>Latest at</th><th>Gap (days)</th><th>Repositories</th></tr>
+</thead>
+<tbody>
+{{- range .}}
+<tr{{if .Current}} class="current"{{end}}><td>{{.Action}}</td><td><code>{{ref .Ref}}</code></td><td>{{.Version}}</td><td>{{date .PinnedAt}}</td><td>{{.Latest}}</td><td>{{date .LatestAt}}</td><td data-sort="{{.Gap}}">{{if ge .Gap 0}}{{.Gap}}{{end}}</td><td>{{join .Repository ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#freshness th").forEach(function (th, col) {
+  var asc = false;
+  th.addEventListener("click", function () {
+    var body = document.querySelector("#freshness tbody");
+    var key = function (tr) { var td = tr.children[col]; return td.dataset.sort !== undefined ? Number(td.dataset.sort) : td.textContent; };
+    asc = !asc;
+    Array.from(body.rows).sort(function (a, b) {
+      var x = key(a), y = key(b);
+      return (x < y ? -1 : x > y ? 1 : 0) * (asc ? 1 : -1);
+    }).forEach(function (tr) { body.appendChild(tr); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// writeFreshnessHTML renders a freshness report as a standalone HTML page, sorted by a
+// click on any column
+func writeFreshnessHTML(w io.Writer, report []dependencyFreshness) error {
+	return freshnessHTML.Execute(w, report)
+}
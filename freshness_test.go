@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Tests for freshnessReport ---
+
+func TestFreshnessReport(t *testing.T) {
+	const oldSHA = "0000000000000000000000000000000000000001"
+	responses := map[string]string{
+		"/repos/actions/checkout/tags":                 `[{"name": "v4.2.0", "commit": {"sha": "` + lockedSHA + `"}}, {"name": "v3.6.0", "commit": {"sha": "` + oldSHA + `"}}]`,
+		"/repos/actions/checkout/releases/latest":      `{"tag_name": "v4.2.0", "published_at": "2025-03-11T00:00:00Z"}`,
+		"/repos/actions/checkout/commits/" + oldSHA:    `{"commit": {"committer": {"date": "2024-01-01T00:00:00Z"}}}`,
+		"/repos/actions/checkout/commits/" + lockedSHA: `{"commit": {"committer": {"date": "2025-03-10T12:00:00Z"}}}`,
+		// The tool has no releases, so its highest version tag is the latest.
+		"/repos/acme/tool/tags":           `[{"name": "v1.10.0", "commit": {"sha": "c2"}}, {"name": "v1.9.0", "commit": {"sha": "c1"}}, {"name": "nightly", "commit": {"sha": "c3"}}]`,
+		"/repos/acme/tool/commits/c2":     `{"commit": {"committer": {"date": "2025-02-01T00:00:00Z"}}}`,
+		"/repos/acme/tool/commits/v1.9.0": `{"commit": {"committer": {"date": "2025-01-01T00:00:00Z"}}}`,
+	}
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if body, ok := responses[req.URL.Path]; ok {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(bytes.NewReader(nil)), Header: make(http.Header)}, nil
+	})
+
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "api", Profile: &WorkflowProfile{Actions: []string{"actions/checkout@" + oldSHA, "acme/tool@v1.9.0", "actions/setup-go@v5", "./local"}}},
+		{Repository: "web", Profile: &WorkflowProfile{Actions: []string{"actions/checkout@" + oldSHA, "actions/checkout@" + lockedSHA}}},
+	}}
+
+	var report []dependencyFreshness
+	withHTTPClientTransport(customTransport, func() {
+		report = freshnessReport(inv)
+	})
+	if len(report) != 3 {
+		t.Fatalf("expected 3 pinned dependencies, got %+v", report)
+	}
+
+	stale, tool, current := report[0], report[1], report[2]
+	if stale.Ref != oldSHA || stale.Version != "v3.6.0" || stale.Latest != "v4.2.0" || stale.Gap() != 435 ||
+		!slices.Equal(stale.Repository, []string{"api", "web"}) || stale.Current() {
+		t.Errorf("unexpected freshness of the stalest dependency %+v", stale)
+	}
+	if tool.Action != "acme/tool" || tool.Latest != "v1.10.0" || !tool.LatestAt.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) || tool.Gap() != 31 {
+		t.Errorf("expected the highest tag to stand for the latest release, got %+v", tool)
+	}
+	if current.Version != "v4.2.0" || current.Gap() != 0 || !current.Current() {
+		t.Errorf("expected the dependency to be current, got %+v", current)
+	}
+
+	var b bytes.Buffer
+	CheckIfError(writeFreshnessCSV(&b, report))
+	if !strings.Contains(b.String(), "actions/checkout,"+oldSHA+",v3.6.0,2024-01-01,v4.2.0,2025-03-11,435,api web\n") {
+		t.Errorf("unexpected CSV report\n%s", b.String())
+	}
+
+	b.Reset()
+	CheckIfError(writeFreshnessHTML(&b, report))
+	if !strings.Contains(b.String(), `<td data-sort="435">435</td>`) || !strings.Contains(b.String(), `class="current"`) {
+		t.Errorf("unexpected HTML report\n%s", b.String())
+	}
+}
+
+// --- Tests for highestTag ---
+
+func TestHighestTag(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+		found    bool
+	}{
+		{[]string{"v2.0.0", "v10.0.0", "v9.9.9"}, "v10.0.0", true},
+		{[]string{"v4", "v4.1", "4.1.2"}, "4.1.2", true},
+		{[]string{"main", "v1"}, "", false},
+	}
+	for _, tc := range tests {
+		var tags []BranchOrTag
+		for _, name := range tc.tags {
+			tags = append(tags, BranchOrTag{Name: name})
+		}
+		if got, ok := highestTag(tags); got.Name != tc.expected || ok != tc.found {
+			t.Errorf("highestTag(%v) = %q, %v; want %q, %v", tc.tags, got.Name, ok, tc.expected, tc.found)
+		}
+	}
+}
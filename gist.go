@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// gistFile is a file entry of a gist as returned by the GitHub API
+type gistFile struct {
+	Filename string `json:"filename"`
+	RawURL   string `json:"raw_url"`
+}
+
+// gist is a single gist as returned by the GitHub API
+type gist struct {
+	ID      string              `json:"id"`
+	HTMLURL string              `json:"html_url"`
+	Files   map[string]gistFile `json:"files"`
+}
+
+// parseGistURL extracts the user of a URL like https://gist.github.com/user
+func parseGistURL(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host != "gist.github.com" {
+		return "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 1 || parts[0] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// listGists fetches the public gists of a user, every page of them
+func listGists(user string) ([]gist, error) {
+	return getPages[gist](fmt.Sprintf("%s/%s/gists", gistAPIURL, url.PathEscape(user)))
+}
+
+// ScanGists collects inventory details from the YAML files of a user's public gists
+func ScanGists(user string, regex *regexp.Regexp) (*Inventory, error) {
+	gists, err := listGists(user)
+	if err != nil {
+		return nil, fmt.Errorf("gist error: %w", err)
+	}
+
+	var inventory Inventory
+	fs := GitHubWorkFlowScanner{}
+	for _, g := range gists {
+		names := make([]string, 0, len(g.Files))
+		for name := range g.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !isYAMLFile(name) {
+				continue
+			}
+
+			content, err := fetchRaw(g.Files[name].RawURL)
+			if err != nil {
+				// Log error and skip this file.
+				logger.Debug("failed to fetch gist file. skipping", "gist", g.ID, "file", name, "err", err)
+				continue
+			}
+
+			matches, err := fs.ScanContent(content, regex)
+			if err != nil || len(matches) == 0 {
+				continue
+			}
+
+			inventory.Records = append(inventory.Records, &InventoryRecord{
+				Repository: fmt.Sprintf("gist:%s/%s", user, g.ID),
+				FilePath:   fmt.Sprintf("%s#%s", g.HTMLURL, name),
+				Matches:    matchValues(matches),
+				Locations:  matches,
+			})
+		}
+	}
+
+	return &inventory, nil
+}
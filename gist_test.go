@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// --- Tests for parseGistURL ---
+
+func TestParseGistURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantUser string
+		wantOK   bool
+	}{
+		{input: "https://gist.github.com/octocat", wantUser: "octocat", wantOK: true},
+		{input: "https://gist.github.com/octocat/", wantUser: "octocat", wantOK: true},
+		{input: "https://gist.github.com/octocat/aa5a315d61ae9438b18d", wantOK: false},
+		{input: "https://github.com/octocat", wantOK: false},
+	}
+
+	for _, tc := range tests {
+		user, ok := parseGistURL(tc.input)
+		if ok != tc.wantOK || user != tc.wantUser {
+			t.Errorf("parseGistURL(%q) = (%q, %v); want (%q, %v)", tc.input, user, ok, tc.wantUser, tc.wantOK)
+		}
+	}
+}
+
+// --- Tests for ScanGists ---
+
+func TestScanGists(t *testing.T) {
+	gists, err := json.Marshal([]gist{
+		{
+			ID:      "abc",
+			HTMLURL: "https://gist.github.com/octocat/abc",
+			Files: map[string]gistFile{
+				"ci.yml":    {Filename: "ci.yml", RawURL: "https://gist.githubusercontent.com/raw/ci.yml"},
+				"notes.txt": {Filename: "notes.txt", RawURL: "https://gist.githubusercontent.com/raw/notes.txt"},
+			},
+		},
+	})
+	CheckIfError(err)
+
+	responses := map[string]string{
+		"https://api.github.com/users/octocat/gists?per_page=100&page=1": string(gists),
+		"https://gist.githubusercontent.com/raw/ci.yml":                  "steps:\n  - uses: actions/checkout@v4\n",
+		"https://gist.githubusercontent.com/raw/notes.txt":               "uses: actions/checkout@v4",
+	}
+
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.String()]
+		if !ok {
+			t.Errorf("unexpected request to %s", req.URL)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		inv, err := ScanGists("octocat", mutableRefRegex)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(inv.Records) != 1 {
+			t.Fatalf("expected only the YAML gist file to be reported, got %d records", len(inv.Records))
+		}
+		ir := inv.Records[0]
+		if ir.Repository != "gist:octocat/abc" || ir.FilePath != "https://gist.github.com/octocat/abc#ci.yml" {
+			t.Errorf("unexpected record: %+v", ir)
+		}
+	})
+}
+
+// --- Tests for listGists ---
+
+func TestListGists_Pages(t *testing.T) {
+	first := make([]gist, 100)
+	for i := range first {
+		first[i] = gist{ID: fmt.Sprintf("g%d", i)}
+	}
+	pages := map[string][]gist{
+		"https://api.github.com/users/octocat/gists?per_page=100&page=1": first,
+		"https://api.github.com/users/octocat/gists?per_page=100&page=2": {{ID: "last"}},
+	}
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		page, ok := pages[req.URL.String()]
+		if !ok {
+			t.Errorf("unexpected request to %s", req.URL)
+		}
+		body, err := json.Marshal(page)
+		CheckIfError(err)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		gists, err := listGists("octocat")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(gists) != 101 || gists[100].ID != "last" {
+			t.Errorf("expected the gists of both pages, got %d", len(gists))
+		}
+	})
+}
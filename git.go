@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 
 	"github.com/go-git/go-git/v5"
@@ -109,3 +112,39 @@ func IsGitRepo(path string) bool {
 
 	return true
 }
+
+// IsBareRepo reports whether path is a Git repository without a worktree, such as a
+// server-side mirror
+func IsBareRepo(path string) bool {
+	// Most directories of a workspace are not repositories, so they are ruled out cheaply.
+	for _, name := range []string{"HEAD", "objects"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return false
+	}
+	_, err = repo.Worktree()
+	return errors.Is(err, git.ErrIsBareRepository)
+}
+
+// GetRemoteURL returns the first URL of a named remote of a Git Repository
+func GetRemoteURL(path, name string) (string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := repo.Remote(name)
+	if err != nil {
+		return "", err
+	}
+
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %s has no URL", name)
+	}
+	return urls[0], nil
+}
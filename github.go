@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Endpoints of GitHub. They are those of github.com unless githubServerFromEnv points them to
+// a GitHub Enterprise Server instance.
+var (
+	// serverURL is where repositories are browsed and cloned from
+	serverURL = "https://github.com"
+	// apiURL is the repositories API, and orgsAPIURL, enterprisesAPIURL and gistAPIURL the
+	// APIs of organizations, enterprises and users
+	apiURL            = "https://api.github.com/repos"
+	orgsAPIURL        = "https://api.github.com/orgs"
+	enterprisesAPIURL = "https://api.github.com/enterprises"
+	gistAPIURL        = "https://api.github.com/users"
+	// appInstallationsURL is where GitHub Apps exchange their JWT for installation tokens
+	appInstallationsURL = "https://api.github.com/app/installations"
+	// graphqlURL is the GitHub GraphQL API, needed for features missing from the REST API
+	graphqlURL = "https://api.github.com/graphql"
+	// rawContentURL serves the files of repositories
+	rawContentURL = "https://raw.githubusercontent.com"
+)
+
+// githubAPIVersion is sent as the X-GitHub-Api-Version header of API calls, when set
+var githubAPIVersion string
+
+// githubServerFromEnv points the endpoints to the GitHub Enterprise Server instance at
+// GITHUB_SERVER_URL, as set on its runners, with the REST API at GITHUB_API_URL, by default
+// <server>/api/v3, and the GraphQL API at GITHUB_GRAPHQL_URL, by default <server>/api/graphql.
+// GITHUB_API_VERSION pins the API version requested, for instances not supporting the
+// latest.
+func githubServerFromEnv() error {
+	githubAPIVersion = os.Getenv("GITHUB_API_VERSION")
+	server := strings.TrimSuffix(os.Getenv("GITHUB_SERVER_URL"), "/")
+	if server == "" || server == serverURL {
+		return nil
+	}
+	if u, err := url.Parse(server); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("GITHUB_SERVER_URL %q is not a URL like https://github.example.com", server)
+	}
+
+	api := strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/")
+	if api == "" {
+		api = server + "/api/v3"
+	}
+	graphql := os.Getenv("GITHUB_GRAPHQL_URL")
+	if graphql == "" {
+		graphql = strings.TrimSuffix(api, "/v3") + "/graphql"
+	}
+
+	serverURL = server
+	apiURL, orgsAPIURL, enterprisesAPIURL, gistAPIURL = api+"/repos", api+"/orgs", api+"/enterprises", api+"/users"
+	appInstallationsURL = api + "/app/installations"
+	graphqlURL = graphql
+	rawContentURL = server + "/raw"
+	logger.Debug("using GitHub Enterprise Server", "server", serverURL, "api", api, "graphql", graphqlURL)
+	return nil
+}
+
+// serverHost returns the host of serverURL, github.com by default
+func serverHost() string {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// setGitHubHeaders sets the media type and API version of a request against the GitHub API
+func setGitHubHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if githubAPIVersion != "" {
+		req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	}
+}
+
+// githubToken returns the token used to authenticate GitHub API calls, if any: an
+// installation token when authenticating as a GitHub App, the GITHUB_TOKEN otherwise
+func githubToken() string {
+	if githubAppAuth != nil {
+		token, err := githubAppAuth.installationToken()
+		if err != nil {
+			logger.Warn("could not authenticate as the GitHub App", "err", err)
+		}
+		return token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// githubGet performs a GET request against GitHub, authenticated when a token is available.
+// Unauthenticated requests work for public data but are heavily rate limited.
+func githubGet(endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setGitHubHeaders(req)
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if polite != nil {
+		return polite.do(req)
+	}
+	return githubClient.do(req)
+}
+
+// githubSend performs a request with a JSON body against the GitHub API. Unlike reads,
+// changes always need a token.
+func githubSend(method, endpoint string, payload any) (*http.Response, error) {
+	token := githubToken()
+	if token == "" {
+		return nil, errors.New("a GITHUB_TOKEN is required to change repositories")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	setGitHubHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if polite != nil {
+		return polite.do(req)
+	}
+	return githubClient.do(req)
+}
+
+// maxPages bounds how many pages getPages reads, at 100 items a page
+const maxPages = 100
+
+// getPages reads every page of a list endpoint of the API
+func getPages[T any](endpoint string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	var all []T
+	for page := 1; page <= maxPages; page++ {
+		var items []T
+		found, err := getJSON(fmt.Sprintf("%s%sper_page=100&page=%d", endpoint, sep, page), &items)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("http: %s was not found", endpoint)
+		}
+		all = append(all, items...)
+		if len(items) < 100 {
+			break
+		}
+	}
+	return all, nil
+}
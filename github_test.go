@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// withGitHubEndpoints restores the github.com endpoints after the test
+func withGitHubEndpoints(t *testing.T) {
+	vars := []*string{&serverURL, &apiURL, &orgsAPIURL, &enterprisesAPIURL, &gistAPIURL, &appInstallationsURL, &graphqlURL, &rawContentURL, &githubAPIVersion}
+	saved := make([]string, len(vars))
+	for i, v := range vars {
+		saved[i] = *v
+	}
+	t.Cleanup(func() {
+		for i, v := range vars {
+			*v = saved[i]
+		}
+	})
+}
+
+// --- Tests for githubServerFromEnv ---
+
+func TestGitHubServerFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantErr     bool
+		wantAPI     string
+		wantGraphQL string
+		wantRaw     string
+	}{
+		{
+			name:        "github.com",
+			env:         map[string]string{"GITHUB_SERVER_URL": "https://github.com", "GITHUB_API_URL": "https://api.github.com"},
+			wantAPI:     "https://api.github.com/repos",
+			wantGraphQL: "https://api.github.com/graphql",
+			wantRaw:     "https://raw.githubusercontent.com",
+		},
+		{
+			name:        "default endpoints of the server",
+			env:         map[string]string{"GITHUB_SERVER_URL": "https://ghes.example.com/"},
+			wantAPI:     "https://ghes.example.com/api/v3/repos",
+			wantGraphQL: "https://ghes.example.com/api/graphql",
+			wantRaw:     "https://ghes.example.com/raw",
+		},
+		{
+			name:        "endpoints set on the runners",
+			env:         map[string]string{"GITHUB_SERVER_URL": "https://ghes.example.com", "GITHUB_API_URL": "https://api.ghes.example.com", "GITHUB_GRAPHQL_URL": "https://api.ghes.example.com/graphql"},
+			wantAPI:     "https://api.ghes.example.com/repos",
+			wantGraphQL: "https://api.ghes.example.com/graphql",
+			wantRaw:     "https://ghes.example.com/raw",
+		},
+		{
+			name:    "not a URL",
+			env:     map[string]string{"GITHUB_SERVER_URL": "ghes.example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			withGitHubEndpoints(t)
+			for _, name := range []string{"GITHUB_SERVER_URL", "GITHUB_API_URL", "GITHUB_GRAPHQL_URL", "GITHUB_API_VERSION"} {
+				t.Setenv(name, tc.env[name])
+			}
+			err := githubServerFromEnv()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("githubServerFromEnv error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if apiURL != tc.wantAPI || graphqlURL != tc.wantGraphQL || rawContentURL != tc.wantRaw {
+				t.Errorf("unexpected endpoints %s, %s, %s", apiURL, graphqlURL, rawContentURL)
+			}
+		})
+	}
+}
+
+func TestGitHubServer_Requests(t *testing.T) {
+	withGitHubEndpoints(t)
+	t.Setenv("GITHUB_SERVER_URL", "https://ghes.example.com")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_GRAPHQL_URL", "")
+	t.Setenv("GITHUB_API_VERSION", "2022-11-28")
+	CheckIfError(githubServerFromEnv())
+
+	owner, name, err := parseGitHubRemote("git@ghes.example.com:acme/app.git")
+	if err != nil || owner != "acme" || name != "app" {
+		t.Errorf("parseGitHubRemote = %s, %s, %v", owner, name, err)
+	}
+	if _, _, _, err := parseGitHubURL("https://github.com/acme/app"); err == nil {
+		t.Error("expected github.com URLs to be rejected when using a GitHub Enterprise Server")
+	}
+
+	var got *http.Request
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`[]`)), Header: make(http.Header)}, nil
+	})
+	withHTTPClientTransport(rt, func() {
+		_, err := SHAResolver{}.resolve("actions/checkout@v4")
+		if err == nil {
+			t.Error("expected an unknown tag to fail")
+		}
+	})
+	if got == nil || got.URL.String() != "https://ghes.example.com/api/v3/repos/actions/checkout/tags" || got.Header.Get("X-GitHub-Api-Version") != "2022-11-28" {
+		t.Errorf("unexpected request %+v", got)
+	}
+}
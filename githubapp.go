@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before it expires an installation token is replaced, so
+// long scans never send an expired one
+const tokenRefreshMargin = 5 * time.Minute
+
+// githubAppAuth authenticates GitHub API calls and pushes as a GitHub App installation
+// instead of with the GITHUB_TOKEN, when configured
+var githubAppAuth *githubApp
+
+// githubApp mints the installation tokens of a GitHub App. Tokens last an hour and are
+// renewed as they expire.
+type githubApp struct {
+	ID             string
+	InstallationID string
+	Key            *rsa.PrivateKey
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// githubAppFromEnv reads the GitHub App to authenticate as from GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY, the PEM key itself, or
+// GITHUB_APP_PRIVATE_KEY_FILE. It returns nil when no app is configured.
+func githubAppFromEnv() (*githubApp, error) {
+	id := os.Getenv("GITHUB_APP_ID")
+	if id == "" {
+		return nil, nil
+	}
+	installation := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	if installation == "" {
+		return nil, errors.New("GITHUB_APP_INSTALLATION_ID is required along with GITHUB_APP_ID")
+	}
+
+	key := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if path := os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"); len(key) == 0 && path != "" {
+		content, err := os.ReadFile(expandHome(path))
+		if err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+		key = content
+	}
+	if len(key) == 0 {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_FILE is required along with GITHUB_APP_ID")
+	}
+	privateKey, err := parsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &githubApp{ID: id, InstallationID: installation, Key: privateKey}, nil
+}
+
+// parsePrivateKey reads an RSA key in PEM, either PKCS#1 as downloaded from GitHub or PKCS#8
+func parsePrivateKey(content []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("private key of the GitHub App is not in PEM format")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("private key of the GitHub App: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key of the GitHub App is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// jwt signs the short-lived token identifying the app itself. It is backdated a minute to
+// allow for clock drift.
+func (a *githubApp) jwt(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.Key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// installationToken returns a token of the installation, minting a new one when there is
+// none yet or the current one is about to expire
+func (a *githubApp) installationToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if a.token != "" && now.Add(tokenRefreshMargin).Before(a.expires) {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt(now)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/access_tokens", appInstallationsURL, a.InstallationID), nil)
+	if err != nil {
+		return "", err
+	}
+	setGitHubHeaders(req)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	resp, err := githubClient.do(req)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("http: installation %s of GitHub App %s returned %s", a.InstallationID, a.ID, resp.Status)
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	logger.Debug("minted installation token", "app", a.ID, "installation", a.InstallationID, "expires", token.ExpiresAt)
+	a.token, a.expires = token.Token, token.ExpiresAt
+	return a.token, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Tests for githubAppFromEnv ---
+
+func TestGitHubAppFromEnv(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	CheckIfError(err)
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	CheckIfError(err)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	CheckIfError(os.WriteFile(keyFile, pkcs8, 0o600))
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantApp bool
+		wantErr bool
+	}{
+		{name: "not configured", env: map[string]string{}},
+		{name: "PKCS#1 key", env: map[string]string{"GITHUB_APP_ID": "42", "GITHUB_APP_INSTALLATION_ID": "7", "GITHUB_APP_PRIVATE_KEY": string(pkcs1)}, wantApp: true},
+		{name: "PKCS#8 key file", env: map[string]string{"GITHUB_APP_ID": "42", "GITHUB_APP_INSTALLATION_ID": "7", "GITHUB_APP_PRIVATE_KEY_FILE": keyFile}, wantApp: true},
+		{name: "no installation", env: map[string]string{"GITHUB_APP_ID": "42", "GITHUB_APP_PRIVATE_KEY": string(pkcs1)}, wantErr: true},
+		{name: "no key", env: map[string]string{"GITHUB_APP_ID": "42", "GITHUB_APP_INSTALLATION_ID": "7"}, wantErr: true},
+		{name: "invalid key", env: map[string]string{"GITHUB_APP_ID": "42", "GITHUB_APP_INSTALLATION_ID": "7", "GITHUB_APP_PRIVATE_KEY": "not a key"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_APP_ID", "GITHUB_APP_INSTALLATION_ID", "GITHUB_APP_PRIVATE_KEY", "GITHUB_APP_PRIVATE_KEY_FILE"} {
+				t.Setenv(name, tc.env[name])
+			}
+			app, err := githubAppFromEnv()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("githubAppFromEnv error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if (app != nil) != tc.wantApp {
+				t.Fatalf("githubAppFromEnv = %+v, wantApp %v", app, tc.wantApp)
+			}
+			if app != nil && (app.ID != "42" || app.InstallationID != "7" || !app.Key.Equal(key)) {
+				t.Errorf("unexpected app %+v", app)
+			}
+		})
+	}
+}
+
+// --- Tests for githubApp.installationToken ---
+
+func TestGitHubApp_InstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	CheckIfError(err)
+	app := &githubApp{ID: "42", InstallationID: "7", Key: key}
+
+	minted := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.String() != appInstallationsURL+"/7/access_tokens" {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		}
+
+		// The JWT must be signed by the app key and issued by the app.
+		parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("unexpected authorization %q", req.Header.Get("Authorization"))
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		CheckIfError(err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		CheckIfError(err)
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		CheckIfError(json.Unmarshal(payload, &claims))
+		if claims.Iss != "42" || claims.Exp-claims.Iat > 600 {
+			t.Errorf("unexpected claims %+v", claims)
+		}
+
+		minted++
+		body := fmt.Sprintf(`{"token":"ghs_%d","expires_at":%q}`, minted, time.Now().Add(time.Hour).Format(time.RFC3339))
+		return &http.Response{StatusCode: http.StatusCreated, Status: "201 Created", Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(rt, func() {
+		for range 2 {
+			token, err := app.installationToken()
+			CheckIfError(err)
+			if token != "ghs_1" {
+				t.Errorf("expected the token to be reused, got %s", token)
+			}
+		}
+
+		// A token about to expire is replaced by a new one.
+		app.expires = time.Now().Add(time.Minute)
+		token, err := app.installationToken()
+		CheckIfError(err)
+		if token != "ghs_2" || minted != 2 {
+			t.Errorf("expected a new token, got %s after %d", token, minted)
+		}
+
+		githubAppAuth = app
+		defer func() { githubAppAuth = nil }()
+		t.Setenv("GITHUB_TOKEN", "ghp_user")
+		if token := githubToken(); token != "ghs_2" {
+			t.Errorf("expected the installation token to take precedence, got %s", token)
+		}
+	})
+}
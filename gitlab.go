@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gitlabCIFile is the pipeline file of GitLab CI at the root of a repository
+const gitlabCIFile = ".gitlab-ci.yml"
+
+const (
+	// ruleUnpinnedInclude flags pipeline configuration included from a source that can change
+	ruleUnpinnedInclude = "unpinned-include"
+	// ruleUnpinnedImage flags pipeline images not pinned to a digest
+	ruleUnpinnedImage = "unpinned-image"
+)
+
+// gitlabKeywords are the top-level keys of a GitLab CI file that are not jobs
+var gitlabKeywords = map[string]bool{"include": true, "stages": true, "variables": true, "workflow": true, "spec": true}
+
+// GitLabCIChecker flags the includes and images of GitLab CI pipelines that can change under
+// them. Included configuration runs with the variables of the project, and images run its
+// jobs, so both are as sensitive as the actions of a workflow.
+type GitLabCIChecker struct{}
+
+func (c GitLabCIChecker) File() string {
+	return gitlabCIFile
+}
+
+func (c GitLabCIChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	seen := map[*yaml.Node]bool{}
+	for _, root := range wf.roots() {
+		findings = append(findings, c.checkIncludes(wf, mappingValue(root, "include"))...)
+
+		// Images are set at the top level, under default: and by every job, hidden ones too.
+		// An image merged into several jobs from an anchor is reported once.
+		images := gitlabImages(root)
+		for _, p := range mappingPairs(root) {
+			if gitlabKeywords[p.Key.Value] {
+				continue
+			}
+			if job := resolveAlias(p.Value); job != nil && job.Kind == yaml.MappingNode {
+				images = append(images, gitlabImages(job)...)
+			}
+		}
+		for _, img := range images {
+			if !seen[img] {
+				seen[img] = true
+				findings = append(findings, unpinnedImageFinding(wf, img)...)
+			}
+		}
+	}
+	return findings
+}
+
+// checkIncludes flags remote includes without integrity hash, and project and component
+// includes not pinned to a commit SHA. Local files and GitLab templates ship with the
+// repository or the instance.
+func (c GitLabCIChecker) checkIncludes(wf *WorkflowFile, include *yaml.Node) []Finding {
+	include = resolveAlias(include)
+	if include == nil {
+		return nil
+	}
+	entries := []*yaml.Node{include}
+	if include.Kind == yaml.SequenceNode {
+		entries = include.Content
+	}
+
+	var findings []Finding
+	for _, entry := range entries {
+		entry = resolveAlias(entry)
+		if entry.Kind == yaml.ScalarNode {
+			if isRemoteInclude(entry.Value) {
+				findings = append(findings, remoteIncludeFinding(wf, entry))
+			}
+			continue
+		}
+
+		if remote := mappingValue(entry, "remote"); remote != nil {
+			if mappingValue(entry, "integrity") == nil {
+				findings = append(findings, remoteIncludeFinding(wf, remote))
+			}
+		}
+		if project := mappingValue(entry, "project"); project != nil && !strings.Contains(project.Value, "$") {
+			ref := mappingValue(entry, "ref")
+			switch {
+			case ref == nil:
+				findings = append(findings, wf.finding(ruleUnpinnedInclude, SeverityMedium, project, fmt.Sprintf(
+					"include of project %s follows its default branch. Pin it with ref: <commit-sha>", project.Value)))
+			case !fullSHA.MatchString(ref.Value) && !strings.Contains(ref.Value, "$"):
+				findings = append(findings, wf.finding(ruleUnpinnedInclude, SeverityMedium, ref, fmt.Sprintf(
+					"include of project %s uses the mutable ref %s. Pin it to a commit SHA", project.Value, ref.Value)))
+			}
+		}
+		if component := mappingValue(entry, "component"); component != nil {
+			if _, version, _ := strings.Cut(component.Value, "@"); !fullSHA.MatchString(version) {
+				findings = append(findings, wf.finding(ruleUnpinnedInclude, SeverityMedium, component, fmt.Sprintf(
+					"component %s is not pinned to a commit SHA. Versions and ~latest can be moved by the owners of the component", component.Value)))
+			}
+		}
+	}
+	return findings
+}
+
+// isRemoteInclude reports whether an include written as a string names a remote file
+func isRemoteInclude(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// remoteIncludeFinding flags a remote include, whose content anyone controlling the URL
+// can change
+func remoteIncludeFinding(wf *WorkflowFile, n *yaml.Node) Finding {
+	return wf.finding(ruleUnpinnedInclude, SeverityHigh, n, fmt.Sprintf(
+		"remote include %s can change at any time. Vendor the file, or set integrity: sha256-<hash> to pin its content", n.Value))
+}
+
+// gitlabImages returns the image and the service images of a mapping: the top level,
+// default: or a job
+func gitlabImages(n *yaml.Node) []*yaml.Node {
+	var images []*yaml.Node
+	if img := pipelineImage(mappingValue(n, "image")); img != nil {
+		images = append(images, img)
+	}
+	if services := resolveAlias(mappingValue(n, "services")); services != nil && services.Kind == yaml.SequenceNode {
+		for _, svc := range services.Content {
+			if img := pipelineImage(svc); img != nil {
+				images = append(images, img)
+			}
+		}
+	}
+	return images
+}
+
+// pipelineImage returns the scalar naming an image, written image: name or image: {name: name}
+func pipelineImage(n *yaml.Node) *yaml.Node {
+	n = resolveAlias(n)
+	if n != nil && n.Kind == yaml.MappingNode {
+		n = mappingValue(n, "name")
+	}
+	if n == nil || n.Kind != yaml.ScalarNode || n.Value == "" {
+		return nil
+	}
+	return n
+}
+
+// unpinnedImageFinding flags an image not pinned to a digest. Images set through variables
+// cannot be told.
+func unpinnedImageFinding(wf *WorkflowFile, img *yaml.Node) []Finding {
+	if strings.Contains(img.Value, "$") {
+		return nil
+	}
+	ref, err := parseImageRef(img.Value)
+	if err != nil || ref.Digest != "" {
+		return nil
+	}
+	return []Finding{wf.finding(ruleUnpinnedImage, SeverityMedium, img, fmt.Sprintf(
+		"image %s is not pinned to a digest. Ex: %s@sha256:<digest>", img.Value, img.Value))}
+}
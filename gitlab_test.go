@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// --- Tests for GitLabCIChecker.Check ---
+
+func TestGitLabCIChecker_Check(t *testing.T) {
+	content := `include:
+  - local: /ci/build.yml
+  - template: Security/SAST.gitlab-ci.yml
+  - https://example.com/ci.yml
+  - remote: https://example.com/pinned.yml
+    integrity: sha256-L3/GAoKaw0Arw6hDCKeKQlV1QPEgHYxGBHsH4zG1IY8=
+  - project: platform/ci
+    file: /deploy.yml
+  - project: platform/ci
+    ref: v1.2
+    file: /test.yml
+  - project: platform/ci
+    ref: 0123456789abcdef0123456789abcdef01234567
+    file: /lint.yml
+  - component: $CI_SERVER_FQDN/platform/components/scan@~latest
+  - component: $CI_SERVER_FQDN/platform/components/lint@0123456789abcdef0123456789abcdef01234567
+image: ruby:3.3
+default:
+  services:
+    - postgres:16
+    - name: redis@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+.defaults: &defaults
+  image:
+    name: golang:1.22
+test:
+  <<: *defaults
+  script: go test ./...
+lint:
+  <<: *defaults
+  image: $LINT_IMAGE
+variables:
+  image: not-an-image
+`
+	findings := GitLabCIChecker{}.Check(newWorkflowFile(gitlabCIFile, []byte(content)))
+
+	expected := map[int]string{
+		4:  ruleUnpinnedInclude, // remote
+		7:  ruleUnpinnedInclude, // default branch
+		10: ruleUnpinnedInclude, // tag
+		15: ruleUnpinnedInclude, // ~latest
+		17: ruleUnpinnedImage,
+		20: ruleUnpinnedImage,
+		24: ruleUnpinnedImage, // reported once for the jobs merging it
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+	if findings[0].Severity != SeverityHigh {
+		t.Errorf("expected remote includes to be high severity, got %s", findings[0].Severity)
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with pipelines ---
+
+func TestScanner_ScanBranchPipelines(t *testing.T) {
+	root := filepath.Join("ws", "repo")
+	repo := fakeRepository{
+		name: "repo",
+		// The repository has no GitHub workflows.
+		listFilesErr: os.ErrNotExist,
+		fileContents: map[string][]byte{
+			filepath.Join(root, gitlabCIFile): []byte("build:\n  image: alpine:3\n  script: make\n"),
+		},
+	}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Pipelines: []PipelineChecker{GitLabCIChecker{}}}
+	records := sc.ScanBranch("main", repo, regexp.MustCompile("@v"), workflowDir(root))
+	if len(records) != 1 || len(records[0].Findings) != 1 {
+		t.Fatalf("expected one record with one finding, got %+v", records)
+	}
+	if r := records[0]; r.FilePath != filepath.Join(root, gitlabCIFile) || r.Findings[0].Rule != ruleUnpinnedImage {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if uri := sarifURI(records[0]); uri != gitlabCIFile {
+		t.Errorf("sarifURI = %s; want %s", uri, gitlabCIFile)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// updateGolden rewrites the expected results of the golden corpus: go test -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// goldenResult is the expected outcome of scanning a single corpus file.
+type goldenResult struct {
+	Matches  []Match   `json:"matches"`
+	Findings []Finding `json:"rule_findings,omitempty"`
+}
+
+// goldenRules are the rules run on the corpus: every rule needing no configuration and no
+// network access.
+var goldenRules = []Rule{
+	PermissionsRule{},
+	DeprecatedRule{},
+	SecretRule{},
+	DownloadRule{},
+	ConcurrencyRule{},
+	ArtifactRule{},
+	NewTimeoutRule(TimeoutsConfig{}),
+}
+
+// scanGolden runs a corpus file through the same scanning steps as a workflow file in a
+// repository: the mutable references, then the rules, inline suppressions and redaction.
+func scanGolden(t *testing.T, file string, content []byte) goldenResult {
+	t.Helper()
+	matches, err := GitHubWorkFlowScanner{}.ScanContent(content, mutableRefRegex)
+	if err != nil {
+		t.Fatalf("ScanContent returned error: %v", err)
+	}
+	rel := ".github/workflows/" + filepath.Base(file)
+	s := &Scanner{Rules: goldenRules}
+	findings := s.checkRules(newWorkflowFile(rel, content))
+	matches, findings, _ = s.settle(matches, findings, content, rel)
+	return goldenResult{Matches: matches, Findings: findings}
+}
+
+// TestGolden scans every workflow in testdata/golden and compares the matches and rule
+// findings with the JSON file of the same name. Adding a detection case only needs a new workflow file
+// and running the test once with -update.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.y*ml"))
+	CheckIfError(err)
+	if len(files) == 0 {
+		t.Fatal("golden corpus is empty")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			CheckIfError(err)
+			got := scanGolden(t, file, content)
+
+			expectedPath := strings.TrimSuffix(file, filepath.Ext(file)) + ".json"
+			if *updateGolden {
+				b, err := json.MarshalIndent(got, "", "  ")
+				CheckIfError(err)
+				CheckIfError(os.WriteFile(expectedPath, append(b, '\n'), 0644))
+				return
+			}
+
+			b, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("missing expected results, run with -update to create %s: %v", expectedPath, err)
+			}
+			var expected goldenResult
+			if err := json.Unmarshal(b, &expected); err != nil {
+				t.Fatalf("invalid golden file %s: %v", expectedPath, err)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				t.Errorf("results differ from %s\ngot:\n%s", expectedPath, gotJSON)
+			}
+		})
+	}
+}
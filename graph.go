@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// callWorkflow is an edge to a reusable workflow
+	callWorkflow = "workflow"
+	// callAction is an edge to an action, composite or not
+	callAction = "action"
+)
+
+// CallSite is a reference of a workflow of the caller to the callee
+type CallSite struct {
+	// Workflow is the file of the caller, relative to the root of its repository
+	Workflow string `json:"workflow"`
+	// Uses is the reference, as written
+	Uses string `json:"uses"`
+	// Pinned is set when the reference is pinned to a commit SHA
+	Pinned bool `json:"pinned"`
+}
+
+// CallEdge is a repository calling the reusable workflows or actions of another
+type CallEdge struct {
+	Caller string     `json:"caller"`
+	Callee string     `json:"callee"`
+	Kind   string     `json:"kind"`
+	Calls  []CallSite `json:"calls"`
+}
+
+// uses returns the distinct references of the edge, in order
+func (e CallEdge) uses() []string {
+	set := map[string]bool{}
+	for _, c := range e.Calls {
+		set[c.Uses] = true
+	}
+	return sortedKeys(set)
+}
+
+// CallGraph tells which repositories of an organization call the reusable workflows and
+// actions of which others, so the blast radius of a change to a shared one can be told
+type CallGraph struct {
+	Repositories []string   `json:"repositories"`
+	Edges        []CallEdge `json:"edges"`
+	// Maintenance is the maintenance state of the called repositories, set with --health
+	Maintenance map[string]string `json:"maintenance,omitempty"`
+}
+
+// Pin states of the references of an edge or to a repository
+const (
+	pinPinned   = "pinned"
+	pinPartial  = "partial"
+	pinUnpinned = "unpinned"
+)
+
+// pinColors are the colors of the pin states in the renderings of the graph
+var pinColors = map[string]string{pinPinned: "#2e7d32", pinPartial: "#ef6c00", pinUnpinned: "#c62828"}
+
+// maintenanceColors are the fill colors of the maintenance states of repositories
+var maintenanceColors = map[string]string{
+	maintenanceActive: "#ffffff", maintenanceStale: "#fff59d", maintenanceArchived: "#e0e0e0",
+	maintenanceMissing: "#ef9a9a", maintenanceUnknown: "#ffffff",
+}
+
+// pinState tells whether all, some or none of the call sites are pinned to a commit SHA
+func pinState(calls []CallSite) string {
+	pinned := 0
+	for _, c := range calls {
+		if c.Pinned {
+			pinned++
+		}
+	}
+	switch pinned {
+	case len(calls):
+		return pinPinned
+	case 0:
+		return pinUnpinned
+	}
+	return pinPartial
+}
+
+// pinStateOf returns the pin state of the references to a repository, or "" if nothing of
+// the graph calls it
+func (g *CallGraph) pinStateOf(repo string) string {
+	var calls []CallSite
+	for _, e := range g.Edges {
+		if e.Callee == repo {
+			calls = append(calls, e.Calls...)
+		}
+	}
+	if len(calls) == 0 {
+		return ""
+	}
+	return pinState(calls)
+}
+
+// lookupMaintenance sets the maintenance state of every called repository. Repositories
+// that cannot be looked up are unknown.
+func (g *CallGraph) lookupMaintenance(lookup repoActivityFunc, now time.Time) {
+	g.Maintenance = map[string]string{}
+	for _, e := range g.Edges {
+		if _, ok := g.Maintenance[e.Callee]; ok {
+			continue
+		}
+		owner, name, _ := strings.Cut(e.Callee, "/")
+		activity, err := lookup(owner, name)
+		if err != nil {
+			logger.Warn("could not look up repository", "repo", e.Callee, "err", err)
+			g.Maintenance[e.Callee] = maintenanceUnknown
+			continue
+		}
+		g.Maintenance[e.Callee] = activity.status(now)
+	}
+}
+
+// buildCallGraph builds the call graph of the profiled workflows of a workspace. Callers are
+// identified by their origin remote, and only callees owned by the owners of the workspace
+// are kept: actions of third parties are not part of the organization.
+func buildCallGraph(inv *Inventory, repoOf repoOfFunc) *CallGraph {
+	names := map[string]string{}
+	callerOf := func(ir *InventoryRecord, root string) string {
+		if name, ok := names[root]; ok {
+			return name
+		}
+		owner, name, err := repoOf(root)
+		if err != nil {
+			logger.Debug("could not identify repository, using its directory name", "repo", ir.Repository, "err", err)
+			names[root] = ir.Repository
+			return ir.Repository
+		}
+		// GitHub names are case-insensitive, and references do not always match their case.
+		names[root] = strings.ToLower(owner + "/" + name)
+		return names[root]
+	}
+
+	type edgeKey struct{ caller, callee, kind string }
+	edges := map[edgeKey]*CallEdge{}
+	repos, owners := map[string]bool{}, map[string]bool{}
+	var keys []edgeKey
+	for _, ir := range inv.Records {
+		if ir.Profile == nil {
+			continue
+		}
+		root := ir.repoRoot()
+		caller := callerOf(ir, root)
+		file, err := filepath.Rel(root, ir.FilePath)
+		if err != nil {
+			file = ir.FilePath
+		}
+		repos[caller] = true
+		if owner, _, ok := strings.Cut(caller, "/"); ok {
+			owners[owner] = true
+		}
+
+		for _, uses := range ir.Profile.Actions {
+			if strings.HasPrefix(uses, "docker://") {
+				continue
+			}
+			parts := strings.SplitN(splitRawAction(uses)[0], "/", 3)
+			if len(parts) < 2 {
+				continue
+			}
+			callee, kind := strings.ToLower(parts[0]+"/"+parts[1]), callAction
+			if len(parts) == 3 && strings.HasPrefix(parts[2], ".github/workflows/") {
+				kind = callWorkflow
+			}
+			if callee == caller {
+				continue
+			}
+
+			k := edgeKey{caller, callee, kind}
+			e := edges[k]
+			if e == nil {
+				e = &CallEdge{Caller: caller, Callee: callee, Kind: kind}
+				edges[k] = e
+				keys = append(keys, k)
+			}
+			if site := (CallSite{Workflow: filepath.ToSlash(file), Uses: uses, Pinned: pinnedRef.MatchString(uses)}); !slices.Contains(e.Calls, site) {
+				e.Calls = append(e.Calls, site)
+			}
+		}
+	}
+
+	g := &CallGraph{}
+	for _, k := range keys {
+		owner, _, _ := strings.Cut(k.callee, "/")
+		if !owners[owner] {
+			continue
+		}
+		e := edges[k]
+		sort.Slice(e.Calls, func(i, j int) bool {
+			if e.Calls[i].Workflow != e.Calls[j].Workflow {
+				return e.Calls[i].Workflow < e.Calls[j].Workflow
+			}
+			return e.Calls[i].Uses < e.Calls[j].Uses
+		})
+		g.Edges = append(g.Edges, *e)
+		repos[k.callee] = true
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Callee != b.Callee {
+			return a.Callee < b.Callee
+		}
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		return a.Kind < b.Kind
+	})
+	g.Repositories = sortedKeys(repos)
+	return g
+}
+
+// writeJSON writes the call graph as indented JSON
+func (g *CallGraph) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeDOT writes the call graph in the DOT language of Graphviz. Edges point from callers to
+// callees, dashed for actions, labeled with the references and colored by pin state.
+// Called repositories are outlined by the pin state of their references and filled by
+// their maintenance state.
+func (g *CallGraph) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph calls {\n")
+	b.WriteString("  rankdir=LR;\n  node [shape=box, style=filled, fillcolor=\"#ffffff\"];\n")
+	for _, repo := range g.Repositories {
+		var attrs []string
+		if state := g.pinStateOf(repo); state != "" {
+			attrs = append(attrs, fmt.Sprintf("color=%q", pinColors[state]))
+		}
+		if m := g.Maintenance[repo]; m != "" {
+			attrs = append(attrs, fmt.Sprintf("fillcolor=%q, tooltip=%q", maintenanceColors[m], m))
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "  %q;\n", repo)
+		} else {
+			fmt.Fprintf(&b, "  %q [%s];\n", repo, strings.Join(attrs, ", "))
+		}
+	}
+	for _, e := range g.Edges {
+		style := "solid"
+		if e.Kind == callAction {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s, color=%q];\n", e.Caller, e.Callee, strings.Join(e.uses(), "\n"), style, pinColors[pinState(e.Calls)])
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
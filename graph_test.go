@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// graphInventory profiles workflows of a workspace, keyed by repository directory and file
+func graphInventory(workflows map[string]string) *Inventory {
+	inv := &Inventory{}
+	for file, content := range workflows {
+		repo, name, _ := strings.Cut(file, "/")
+		inv.Records = append(inv.Records, &InventoryRecord{
+			Repository: repo,
+			FilePath:   filepath.Join("ws", repo, ".github", "workflows", name),
+			Profile:    profileWorkflow(newWorkflowFile(name, []byte(content))),
+		})
+	}
+	return inv
+}
+
+// --- Tests for buildCallGraph ---
+
+func TestBuildCallGraph(t *testing.T) {
+	inv := graphInventory(map[string]string{
+		"api/ci.yml": `on: push
+jobs:
+  build:
+    uses: Org/shared/.github/workflows/build.yml@main
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: org/actions/setup-go@v1
+      - uses: ./.github/actions/local
+      - uses: docker://alpine:3
+`,
+		"api/release.yml": `on: push
+jobs:
+  release:
+    uses: org/shared/.github/workflows/build.yml@v2
+`,
+		"web/ci.yml": `on: push
+jobs:
+  build:
+    uses: org/shared/.github/workflows/build.yml@main
+`,
+		"shared/build.yml": `on: workflow_call
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/shared/.github/actions/setup@main
+`,
+		"unknown/ci.yml": `on: push
+jobs:
+  build:
+    uses: org/shared/.github/workflows/build.yml@main
+`,
+	})
+	repoOf := func(root string) (string, string, error) {
+		if filepath.Base(root) == "unknown" {
+			return "", "", errors.New("no origin")
+		}
+		return "org", filepath.Base(root), nil
+	}
+
+	g := buildCallGraph(inv, repoOf)
+
+	expectedRepos := []string{"org/actions", "org/api", "org/shared", "org/web", "unknown"}
+	if !reflect.DeepEqual(g.Repositories, expectedRepos) {
+		t.Errorf("repositories = %v; want %v", g.Repositories, expectedRepos)
+	}
+	expectedEdges := []CallEdge{
+		{Caller: "org/api", Callee: "org/actions", Kind: callAction, Calls: []CallSite{{".github/workflows/ci.yml", "org/actions/setup-go@v1", false}}},
+		{Caller: "org/api", Callee: "org/shared", Kind: callWorkflow, Calls: []CallSite{
+			{".github/workflows/ci.yml", "Org/shared/.github/workflows/build.yml@main", false},
+			{".github/workflows/release.yml", "org/shared/.github/workflows/build.yml@v2", false},
+		}},
+		{Caller: "org/web", Callee: "org/shared", Kind: callWorkflow, Calls: []CallSite{{".github/workflows/ci.yml", "org/shared/.github/workflows/build.yml@main", false}}},
+		{Caller: "unknown", Callee: "org/shared", Kind: callWorkflow, Calls: []CallSite{{".github/workflows/ci.yml", "org/shared/.github/workflows/build.yml@main", false}}},
+	}
+	if !reflect.DeepEqual(g.Edges, expectedEdges) {
+		t.Errorf("edges = %+v; want %+v", g.Edges, expectedEdges)
+	}
+}
+
+// --- Tests for CallGraph.writeDOT ---
+
+func TestCallGraph_WriteDOT(t *testing.T) {
+	g := &CallGraph{
+		Repositories: []string{"org/api", "org/shared"},
+		Edges: []CallEdge{
+			{Caller: "org/api", Callee: "org/shared", Kind: callWorkflow, Calls: []CallSite{
+				{".github/workflows/ci.yml", "org/shared/.github/workflows/build.yml@v2", false},
+				{".github/workflows/release.yml", "org/shared/.github/workflows/build.yml@main", false},
+				{".github/workflows/nightly.yml", "org/shared/.github/workflows/build.yml@main", false},
+			}},
+			{Caller: "org/api", Callee: "org/shared", Kind: callAction, Calls: []CallSite{{".github/workflows/ci.yml", "org/shared/setup@" + lockedSHA, true}}},
+		},
+		Maintenance: map[string]string{"org/shared": maintenanceArchived},
+	}
+	var buf bytes.Buffer
+	CheckIfError(g.writeDOT(&buf))
+
+	expected := `digraph calls {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor="#ffffff"];
+  "org/api";
+  "org/shared" [color="#ef6c00", fillcolor="#e0e0e0", tooltip="archived"];
+  "org/api" -> "org/shared" [label="org/shared/.github/workflows/build.yml@main\norg/shared/.github/workflows/build.yml@v2", style=solid, color="#c62828"];
+  "org/api" -> "org/shared" [label="org/shared/setup@` + lockedSHA + `", style=dashed, color="#2e7d32"];
+}
+`
+	if buf.String() != expected {
+		t.Errorf("DOT = %s; want %s", buf.String(), expected)
+	}
+}
+
+// --- Tests for CallGraph.lookupMaintenance ---
+
+func TestCallGraph_LookupMaintenance(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	g := &CallGraph{Edges: []CallEdge{
+		{Caller: "org/api", Callee: "org/shared"},
+		{Caller: "org/web", Callee: "org/shared"},
+		{Caller: "org/web", Callee: "org/old"},
+		{Caller: "org/web", Callee: "org/broken"},
+	}}
+	lookups := 0
+	g.lookupMaintenance(func(owner, name string) (repoActivity, error) {
+		lookups++
+		switch name {
+		case "shared":
+			return repoActivity{Exists: true, PushedAt: now.AddDate(0, -1, 0)}, nil
+		case "old":
+			return repoActivity{Exists: true, Archived: true}, nil
+		}
+		return repoActivity{}, errors.New("boom")
+	}, now)
+
+	expected := map[string]string{"org/shared": maintenanceActive, "org/old": maintenanceArchived, "org/broken": maintenanceUnknown}
+	if !reflect.DeepEqual(g.Maintenance, expected) || lookups != 3 {
+		t.Errorf("maintenance = %v after %d lookups; want %v after 3", g.Maintenance, lookups, expected)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Layout of the HTML rendering of the call graph, in pixels
+const (
+	graphNodeWidth  = 240
+	graphNodeHeight = 30
+	graphColumnGap  = 120
+	graphRowGap     = 16
+	graphMargin     = 20
+)
+
+// writeMermaid writes the call graph as a Mermaid flowchart, for Markdown documents that
+// render Mermaid such as those of GitHub. Node and edge colors follow writeDOT.
+func (g *CallGraph) writeMermaid(w io.Writer) error {
+	ids := map[string]string{}
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, repo := range g.Repositories {
+		ids[repo] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[repo], mermaidText(repo))
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Kind == callAction {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", ids[e.Caller], arrow, mermaidText(strings.Join(e.uses(), "<br>")), ids[e.Callee])
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(&b, "  linkStyle %d stroke:%s\n", i, pinColors[pinState(e.Calls)])
+	}
+	for _, repo := range g.Repositories {
+		var styles []string
+		if state := g.pinStateOf(repo); state != "" {
+			styles = append(styles, "stroke:"+pinColors[state])
+		}
+		if m := g.Maintenance[repo]; m != "" {
+			styles = append(styles, "fill:"+maintenanceColors[m])
+		}
+		if len(styles) > 0 {
+			fmt.Fprintf(&b, "  style %s %s\n", ids[repo], strings.Join(styles, ","))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidText escapes the quotes of a Mermaid label
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// graphNode is a repository placed on the HTML rendering of the call graph
+type graphNode struct {
+	Name       string
+	X, Y       int
+	Fill       string
+	Stroke     string
+	Title      string
+	TextX      int
+	TextY      int
+	Dependents int
+}
+
+// graphLink is an edge drawn between two placed repositories
+type graphLink struct {
+	Edge  CallEdge
+	Path  string
+	Color string
+	Dash  bool
+	Title string
+}
+
+// layoutCallGraph places repositories in columns, callers left of what they call. The column
+// of a repository is the length of the longest chain of calls starting from it, so
+// repositories only called end up in the rightmost column.
+func layoutCallGraph(g *CallGraph) ([]graphNode, []graphLink, int, int) {
+	rank := map[string]int{}
+	for range g.Repositories {
+		changed := false
+		for _, e := range g.Edges {
+			if r := rank[e.Callee] + 1; r > rank[e.Caller] && r < len(g.Repositories) {
+				rank[e.Caller], changed = r, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	maxRank := 0
+	for _, r := range rank {
+		maxRank = max(maxRank, r)
+	}
+
+	dependents := map[string]map[string]bool{}
+	for _, e := range g.Edges {
+		if dependents[e.Callee] == nil {
+			dependents[e.Callee] = map[string]bool{}
+		}
+		dependents[e.Callee][e.Caller] = true
+	}
+
+	columns := make([][]string, maxRank+1)
+	for _, repo := range g.Repositories {
+		col := maxRank - rank[repo]
+		columns[col] = append(columns[col], repo)
+	}
+	pos := map[string]graphNode{}
+	var nodes []graphNode
+	rows := 0
+	for col, repos := range columns {
+		sort.Strings(repos)
+		rows = max(rows, len(repos))
+		for row, repo := range repos {
+			n := graphNode{
+				Name:       repo,
+				X:          graphMargin + col*(graphNodeWidth+graphColumnGap),
+				Y:          graphMargin + row*(graphNodeHeight+graphRowGap),
+				Fill:       maintenanceColors[maintenanceUnknown],
+				Stroke:     "#616161",
+				Dependents: len(dependents[repo]),
+			}
+			n.TextX, n.TextY = n.X+8, n.Y+graphNodeHeight/2+4
+			details := []string{repo}
+			if state := g.pinStateOf(repo); state != "" {
+				n.Stroke = pinColors[state]
+				details = append(details, fmt.Sprintf("called by %d repositories, references %s", n.Dependents, state))
+			}
+			if m := g.Maintenance[repo]; m != "" {
+				n.Fill = maintenanceColors[m]
+				details = append(details, "maintenance: "+m)
+			}
+			n.Title = strings.Join(details, "\n")
+			pos[repo] = n
+			nodes = append(nodes, n)
+		}
+	}
+
+	var links []graphLink
+	for _, e := range g.Edges {
+		from, to := pos[e.Caller], pos[e.Callee]
+		x1, y1 := from.X+graphNodeWidth, from.Y+graphNodeHeight/2
+		x2, y2 := to.X, to.Y+graphNodeHeight/2
+		if to.X <= from.X {
+			// Calls within a column or backwards, in cycles, loop around the right side.
+			x2 = to.X + graphNodeWidth
+		}
+		mid := (x1 + x2) / 2
+		if x2 <= x1 {
+			mid = x1 + graphColumnGap/2
+		}
+		links = append(links, graphLink{
+			Edge:  e,
+			Path:  fmt.Sprintf("M%d,%d C%d,%d %d,%d %d,%d", x1, y1, mid, y1, mid, y2, x2, y2),
+			Color: pinColors[pinState(e.Calls)],
+			Dash:  e.Kind == callAction,
+			Title: e.Caller + " → " + e.Callee + "\n" + strings.Join(e.uses(), "\n"),
+		})
+	}
+
+	width := 2*graphMargin + len(columns)*graphNodeWidth + (len(columns)-1)*graphColumnGap + graphColumnGap/2
+	height := 2*graphMargin + rows*(graphNodeHeight+graphRowGap)
+	return nodes, links, width, height
+}
+
+// graphPage holds the data of the HTML rendering of the call graph
+type graphPage struct {
+	Nodes         []graphNode
+	Links         []graphLink
+	Width, Height int
+	NodeWidth     int
+	NodeHeight    int
+	PinColors     map[string]string
+	Maintenance   map[string]string
+	HasHealth     bool
+}
+
+// writeHTML writes the call graph as a self-contained HTML page. Clicking a repository
+// highlights what it calls and what calls it, and hovering shows the references.
+func (g *CallGraph) writeHTML(w io.Writer) error {
+	nodes, links, width, height := layoutCallGraph(g)
+	return htmlGraph.Execute(w, graphPage{
+		Nodes: nodes, Links: links, Width: width, Height: height,
+		NodeWidth: graphNodeWidth, NodeHeight: graphNodeHeight,
+		PinColors: pinColors, Maintenance: maintenanceColors, HasHealth: len(g.Maintenance) > 0,
+	})
+}
+
+var htmlGraph = template.Must(template.New("graph").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scharf call graph</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.legend span { display: inline-block; margin-right: 1.5em; }
+.swatch { display: inline-block; width: 1em; height: 1em; border: 2px solid #616161; vertical-align: middle; margin-right: 4px; }
+svg text { font-size: 12px; pointer-events: none; }
+svg .node { cursor: pointer; }
+svg .link { fill: none; stroke-width: 2; }
+svg.focused .link, svg.focused .node { opacity: 0.15; }
+svg.focused .link.on, svg.focused .node.on { opacity: 1; }
+</style>
+</head>
+<body>
+<h1>Scharf call graph</h1>
+<p class="legend">
+{{- range $state, $color := .PinColors}}<span><span class="swatch" style="border-color: {{$color}}"></span>references {{$state}}</span>{{end}}
+<span>dashed: action, solid: reusable workflow</span>
+</p>
+{{- if .HasHealth}}
+<p class="legend">
+{{- range $state, $color := .Maintenance}}<span><span class="swatch" style="background: {{$color}}"></span>{{$state}}</span>{{end}}
+</p>
+{{- end}}
+<p>Click a repository to highlight what it calls and what calls it. Click the background to reset.</p>
+<svg id="graph" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{- range .Links}}
+<path class="link" d="{{.Path}}" stroke="{{.Color}}"{{if .Dash}} stroke-dasharray="6 4"{{end}} data-from="{{.Edge.Caller}}" data-to="{{.Edge.Callee}}"><title>{{.Title}}</title></path>
+{{- end}}
+{{- range .Nodes}}
+<g class="node" data-name="{{.Name}}"><rect x="{{.X}}" y="{{.Y}}" width="{{$.NodeWidth}}" height="{{$.NodeHeight}}" rx="4" fill="{{.Fill}}" stroke="{{.Stroke}}" stroke-width="2"><title>{{.Title}}</title></rect><text x="{{.TextX}}" y="{{.TextY}}">{{.Name}}</text></g>
+{{- end}}
+</svg>
+<h2>Calls</h2>
+<table>
+<tr><th>Caller</th><th>Callee</th><th>Kind</th><th>Workflow</th><th>Reference</th><th>Pinned</th></tr>
+{{- range .Links}}{{$e := .Edge}}
+{{- range $e.Calls}}
+<tr><td>{{$e.Caller}}</td><td>{{$e.Callee}}</td><td>{{$e.Kind}}</td><td>{{.Workflow}}</td><td>{{.Uses}}</td><td>{{if .Pinned}}yes{{else}}no{{end}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+<script>
+const svg = document.getElementById("graph");
+svg.addEventListener("click", (event) => {
+  const node = event.target.closest(".node");
+  svg.querySelectorAll(".on").forEach((el) => el.classList.remove("on"));
+  if (!node) {
+    svg.classList.remove("focused");
+    return;
+  }
+  const name = node.dataset.name;
+  svg.classList.add("focused");
+  node.classList.add("on");
+  svg.querySelectorAll(".link").forEach((link) => {
+    if (link.dataset.from === name || link.dataset.to === name) {
+      link.classList.add("on");
+      const other = link.dataset.from === name ? link.dataset.to : link.dataset.from;
+      svg.querySelectorAll(".node").forEach((n) => { if (n.dataset.name === other) n.classList.add("on"); });
+    }
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// viewGraph is a graph of three repositories, the platform one wrapping the shared action
+func viewGraph() *CallGraph {
+	return &CallGraph{
+		Repositories: []string{"org/api", "org/platform", "org/shared"},
+		Edges: []CallEdge{
+			{Caller: "org/api", Callee: "org/platform", Kind: callWorkflow, Calls: []CallSite{
+				{".github/workflows/ci.yml", "org/platform/.github/workflows/build.yml@main", false},
+			}},
+			{Caller: "org/platform", Callee: "org/shared", Kind: callAction, Calls: []CallSite{
+				{".github/workflows/build.yml", "org/shared/setup@" + lockedSHA, true},
+				{".github/workflows/lint.yml", `org/shared/setup@"v1"`, false},
+			}},
+		},
+		Maintenance: map[string]string{"org/shared": maintenanceStale},
+	}
+}
+
+// --- Tests for CallGraph.writeMermaid ---
+
+func TestCallGraph_WriteMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	CheckIfError(viewGraph().writeMermaid(&buf))
+
+	expected := `flowchart LR
+  n0["org/api"]
+  n1["org/platform"]
+  n2["org/shared"]
+  n0 -->|"org/platform/.github/workflows/build.yml@main"| n1
+  n1 -.->|"org/shared/setup@#quot;v1#quot;<br>org/shared/setup@` + lockedSHA + `"| n2
+  linkStyle 0 stroke:#c62828
+  linkStyle 1 stroke:#ef6c00
+  style n1 stroke:#c62828
+  style n2 stroke:#ef6c00,fill:#fff59d
+`
+	if buf.String() != expected {
+		t.Errorf("Mermaid = %s; want %s", buf.String(), expected)
+	}
+}
+
+// --- Tests for layoutCallGraph ---
+
+func TestLayoutCallGraph(t *testing.T) {
+	g := viewGraph()
+	// A cycle must not push repositories out of the graph.
+	g.Edges = append(g.Edges, CallEdge{Caller: "org/shared", Callee: "org/api", Kind: callWorkflow})
+
+	nodes, links, width, height := layoutCallGraph(g)
+	if len(nodes) != 3 || len(links) != 3 {
+		t.Fatalf("expected 3 nodes and 3 links, got %d and %d", len(nodes), len(links))
+	}
+	for _, n := range nodes {
+		if n.X < 0 || n.X+graphNodeWidth > width || n.Y < 0 || n.Y+graphNodeHeight > height {
+			t.Errorf("node %s at %d,%d is outside of %dx%d", n.Name, n.X, n.Y, width, height)
+		}
+	}
+
+	nodes, _, _, _ = layoutCallGraph(viewGraph())
+	x := map[string]int{}
+	for _, n := range nodes {
+		x[n.Name] = n.X
+	}
+	if !(x["org/api"] < x["org/platform"] && x["org/platform"] < x["org/shared"]) {
+		t.Errorf("expected callers left of callees, got %v", x)
+	}
+}
+
+// --- Tests for CallGraph.writeHTML ---
+
+func TestCallGraph_WriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	CheckIfError(viewGraph().writeHTML(&buf))
+
+	out := buf.String()
+	for _, want := range []string{
+		`data-name="org/shared"`,
+		`fill="#fff59d" stroke="#ef6c00"`,
+		`stroke="#ef6c00" stroke-dasharray="6 4" data-from="org/platform" data-to="org/shared"`,
+		`<td>org/shared/setup@&#34;v1&#34;</td><td>no</td>`,
+		`border-color: #2e7d32`,
+		`stale`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the HTML:\n%s", want, out)
+		}
+	}
+}
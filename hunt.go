@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHuntWorkers is how many repositories a hunt sweeps at once by default
+const defaultHuntWorkers = 8
+
+// fullSHA matches a full commit SHA
+var fullSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// huntTarget is the action version a hunt looks for. Refs holds every ref naming the same
+// commit, such as a tag and its SHA, and is empty when any version matches.
+type huntTarget struct {
+	Action string
+	Refs   map[string]bool
+}
+
+// parseHuntTarget reads an owner/action@ref argument. The ref is optional.
+func parseHuntTarget(raw string) (*huntTarget, error) {
+	splits := splitRawAction(raw)
+	if strings.Count(splits[0], "/") < 1 || strings.HasPrefix(splits[0], "/") {
+		return nil, fmt.Errorf("%q is not an action. Ex: owner/action@v1 or owner/action@<sha>", raw)
+	}
+	t := &huntTarget{Action: strings.ToLower(splits[0]), Refs: map[string]bool{}}
+	if splits[1] != "" {
+		t.Refs[splits[1]] = true
+	}
+	return t, nil
+}
+
+// repository returns the owner/name of the repository holding the action
+func (t *huntTarget) repository() string {
+	parts := strings.SplitN(t.Action, "/", 3)
+	return parts[0] + "/" + parts[1]
+}
+
+// expandRefs adds the tags pointing to the same commit as the ref of the target, and the
+// commit of a tag, so references pinned either way are found. Only the latest tags listed
+// by the API are known.
+func (t *huntTarget) expandRefs(tags []BranchOrTag) {
+	var sha string
+	for ref := range t.Refs {
+		if fullSHA.MatchString(ref) {
+			sha = ref
+		} else if found, s := searchTag(tags, ref); found {
+			sha = s
+		}
+	}
+	if sha == "" {
+		return
+	}
+	t.Refs[sha] = true
+	for _, tag := range tags {
+		if tag.Commit.Sha == sha {
+			t.Refs[tag.Name] = true
+		}
+	}
+}
+
+// matches reports whether a `uses:` value references the target. A target without a path
+// in its repository matches every action of the repository.
+func (t *huntTarget) matches(uses string) bool {
+	splits := splitRawAction(uses)
+	action := strings.ToLower(splits[0])
+	if action != t.Action && !(strings.Count(t.Action, "/") == 1 && strings.HasPrefix(action, t.Action+"/")) {
+		return false
+	}
+	return len(t.Refs) == 0 || t.Refs[splits[1]]
+}
+
+// String renders the target with the refs it stands for
+func (t *huntTarget) String() string {
+	if len(t.Refs) == 0 {
+		return t.Action + " (any version)"
+	}
+	refs := make([]string, 0, len(t.Refs))
+	for r := range t.Refs {
+		refs = append(refs, r)
+	}
+	sort.Strings(refs)
+	return fmt.Sprintf("%s@%s", t.Action, strings.Join(refs, ", "))
+}
+
+// huntHit is a reference to the hunted action
+type huntHit struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Uses       string `json:"uses"`
+	// Commit and Date tell the past commit of the branch holding the reference, set by
+	// hunts of the history. Hits at the head of the branch leave them empty.
+	Commit string    `json:"commit,omitempty"`
+	Date   time.Time `json:"date,omitzero"`
+}
+
+// usesLine is a `uses:` value of a workflow file and its line
+type usesLine struct {
+	Line int
+	Uses string
+}
+
+// blobEntry is a file of a contents API listing, with the SHA of its content
+type blobEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// hunter sweeps repositories through the API for references to an action, reading each
+// distinct workflow content once across branches and repositories
+type hunter struct {
+	target *huntTarget
+	// window, when set, extends the hunt to the history of the workflows
+	window *huntWindow
+
+	mu    sync.Mutex
+	blobs map[string][]usesLine
+}
+
+// newHunter creates a hunter for a target, searching the history of the window if any
+func newHunter(target *huntTarget, window *huntWindow) *hunter {
+	return &hunter{target: target, window: window, blobs: map[string][]usesLine{}}
+}
+
+// usesOf returns the `uses:` values of a workflow file of a branch
+func (h *hunter) usesOf(owner, name, branch string, f blobEntry) ([]usesLine, error) {
+	h.mu.Lock()
+	cached, ok := h.blobs[f.SHA]
+	h.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	content, err := fetchRaw(fmt.Sprintf("%s/%s/%s/%s/%s", rawContentURL, owner, name, branch, path.Clean(f.Path)))
+	if err != nil {
+		return nil, err
+	}
+	var lines []usesLine
+	for _, n := range newWorkflowFile(f.Path, content).usesNodes() {
+		lines = append(lines, usesLine{Line: n.Line, Uses: n.Value})
+	}
+
+	h.mu.Lock()
+	h.blobs[f.SHA] = lines
+	h.mu.Unlock()
+	return lines, nil
+}
+
+// huntWindow is the exposure period whose history a hunt searches
+type huntWindow struct {
+	Since time.Time
+	Until time.Time
+}
+
+// parseHuntWindow reads the dates opening and closing an exposure period, both included.
+// No window is returned when since is empty, and an empty until leaves it open.
+func parseHuntWindow(since, until string) (*huntWindow, error) {
+	if since == "" {
+		if until != "" {
+			return nil, fmt.Errorf("an end of the exposure period needs a start")
+		}
+		return nil, nil
+	}
+	w := &huntWindow{}
+	var err error
+	if w.Since, err = time.Parse(time.DateOnly, since); err != nil {
+		return nil, fmt.Errorf("start of the exposure period: %w", err)
+	}
+	if until != "" {
+		if w.Until, err = time.Parse(time.DateOnly, until); err != nil {
+			return nil, fmt.Errorf("end of the exposure period: %w", err)
+		}
+		w.Until = w.Until.Add(24*time.Hour - time.Second)
+		if w.Until.Before(w.Since) {
+			return nil, fmt.Errorf("the exposure period ends before it starts")
+		}
+	}
+	return w, nil
+}
+
+// huntRepository looks for the target in the workflows of every branch of a repository.
+// Branches holding no workflow directory are skipped. With a window, every commit of the
+// window that changed the workflows is searched too, along with the workflows in effect
+// when the window opened.
+func (h *hunter) huntRepository(owner, name string) ([]huntHit, error) {
+	branches, err := getPages[BranchOrTag](fmt.Sprintf("%s/%s/%s/branches", apiURL, owner, name))
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []huntHit
+	// Commits held by several branches are searched once. Matches at the head are reported
+	// for every branch, those of past commits for the first branch holding them.
+	searched := map[string][]huntHit{}
+	for _, b := range branches {
+		refs := []historyCommit{{SHA: b.Commit.Sha}}
+		if h.window != nil {
+			history, err := h.history(owner, name, b.Name)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, history...)
+		}
+		for i, c := range refs {
+			found, ok := searched[c.SHA]
+			if ok && i > 0 {
+				continue
+			}
+			if !ok {
+				if found, err = h.huntRef(owner, name, c.SHA); err != nil {
+					return nil, err
+				}
+				searched[c.SHA] = found
+			}
+			for _, hit := range found {
+				hit.Branch = b.Name
+				if i > 0 {
+					hit.Commit, hit.Date = c.SHA, c.Commit.Committer.Date
+				}
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits, nil
+}
+
+// huntRef looks for the target in the workflows at a commit
+func (h *hunter) huntRef(owner, name, ref string) ([]huntHit, error) {
+	var files []blobEntry
+	endpoint := fmt.Sprintf("%s/%s/%s/contents/%s?ref=%s", apiURL, owner, name, remoteWorkflowDir, url.QueryEscape(ref))
+	if _, err := getJSON(endpoint, &files); err != nil {
+		return nil, err
+	}
+
+	var hits []huntHit
+	for _, f := range files {
+		if f.Type != "file" || !isYAMLFile(f.Name) {
+			continue
+		}
+		lines, err := h.usesOf(owner, name, ref, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lines {
+			if h.target.matches(l.Uses) {
+				hits = append(hits, huntHit{Repository: owner + "/" + name, File: f.Path, Line: l.Line, Uses: l.Uses})
+			}
+		}
+	}
+	return hits, nil
+}
+
+// historyCommit is a commit of the history of a branch
+type historyCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// history returns the commits of a branch that changed the workflows during the window of
+// the hunter, and the last one before it, whose workflows were in effect when it opened
+func (h *hunter) history(owner, name, branch string) ([]historyCommit, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/commits", apiURL, owner, name)
+	query := url.Values{"sha": {branch}, "path": {remoteWorkflowDir}, "since": {h.window.Since.Format(time.RFC3339)}}
+	if !h.window.Until.IsZero() {
+		query.Set("until", h.window.Until.Format(time.RFC3339))
+	}
+	commits, err := getPages[historyCommit](endpoint + "?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	before := url.Values{"sha": {branch}, "path": {remoteWorkflowDir}, "until": {h.window.Since.Format(time.RFC3339)}, "per_page": {"1"}}
+	var last []historyCommit
+	if _, err := getJSON(endpoint+"?"+before.Encode(), &last); err != nil {
+		return nil, err
+	}
+	return append(commits, last...), nil
+}
+
+// orgRepository is a repository of an organization listing
+type orgRepository struct {
+	Name       string `json:"name"`
+	Archived   bool   `json:"archived"`
+	Visibility string `json:"visibility"`
+}
+
+// huntOrg sweeps every repository of an organization, a few at a time. The repositories
+// that could not be read are returned along with the hits of the others, as a hunt that
+// missed some must say so.
+func (h *hunter) huntOrg(org string, workers int) ([]huntHit, []error, error) {
+	repos, err := getPages[orgRepository](fmt.Sprintf("%s/%s/repos?type=all", orgsAPIURL, url.PathEscape(org)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if workers <= 0 {
+		workers = defaultHuntWorkers
+	}
+
+	var mu sync.Mutex
+	var hits []huntHit
+	var errs []error
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				found, err := h.huntRepository(org, name)
+				mu.Lock()
+				hits = append(hits, found...)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s/%s: %w", org, name, err))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, r := range repos {
+		names <- r.Name
+	}
+	close(names)
+	wg.Wait()
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Branch != b.Branch {
+			return a.Branch < b.Branch
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.Line < b.Line
+	})
+	return hits, errs, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	compromisedSHA = "0123456789abcdef0123456789abcdef01234567"
+	cleanSHA       = "ffffffffffffffffffffffffffffffffffffffff"
+)
+
+// --- Tests for huntTarget ---
+
+func TestHuntTargetMatches(t *testing.T) {
+	target, err := parseHuntTarget("Tj-Actions/changed-files@v45")
+	CheckIfError(err)
+	target.expandRefs([]BranchOrTag{
+		{Name: "v45", Commit: Commit{Sha: compromisedSHA}},
+		{Name: "v45.0.1", Commit: Commit{Sha: compromisedSHA}},
+		{Name: "v44", Commit: Commit{Sha: cleanSHA}},
+	})
+	anyVersion, err := parseHuntTarget("tj-actions/changed-files")
+	CheckIfError(err)
+
+	tests := []struct {
+		name     string
+		target   *huntTarget
+		uses     string
+		expected bool
+	}{
+		{"tag", target, "tj-actions/changed-files@v45", true},
+		{"other tag at the same commit", target, "tj-actions/changed-files@v45.0.1", true},
+		{"pinned SHA", target, "tj-actions/changed-files@" + compromisedSHA, true},
+		{"other version", target, "tj-actions/changed-files@v44", false},
+		{"action of a path in the repository", target, "tj-actions/changed-files/sub@v45", true},
+		{"other action", target, "actions/checkout@v45", false},
+		{"similar name", target, "tj-actions/changed-files-extra@v45", false},
+		{"any version", anyVersion, "tj-actions/changed-files@v1", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.target.matches(tc.uses); got != tc.expected {
+				t.Errorf("matches(%q) = %v; want %v", tc.uses, got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := parseHuntTarget("checkout@v4"); err == nil {
+		t.Error("expected an action without owner to be rejected")
+	}
+}
+
+// --- Tests for hunter.huntOrg ---
+
+func TestHunterHuntOrg(t *testing.T) {
+	workflow := "on: push\njobs:\n  a:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: tj-actions/changed-files@v45\n"
+	var mu sync.Mutex
+	rawReads := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, "[]"
+		switch req.URL.Host + req.URL.Path {
+		case "api.github.com/orgs/org/repos":
+			body = `[{"name": "app"}, {"name": "web"}, {"name": "broken"}]`
+		case "api.github.com/repos/org/app/branches":
+			body = `[{"name": "main", "commit": {"sha": "aaa"}}, {"name": "release", "commit": {"sha": "aaa"}}, {"name": "dev", "commit": {"sha": "bbb"}}]`
+		case "api.github.com/repos/org/web/branches":
+			body = `[{"name": "main", "commit": {"sha": "ccc"}}]`
+		case "api.github.com/repos/org/app/contents/.github/workflows":
+			body = `[{"name": "ci.yml", "path": ".github/workflows/ci.yml", "type": "file", "sha": "blob1"}, {"name": "README.md", "path": ".github/workflows/README.md", "type": "file", "sha": "blob2"}]`
+		case "api.github.com/repos/org/web/contents/.github/workflows":
+			status, body = http.StatusNotFound, `{}`
+		case "api.github.com/repos/org/broken/branches":
+			status, body = http.StatusForbidden, `{}`
+		case "raw.githubusercontent.com/org/app/aaa/.github/workflows/ci.yml",
+			"raw.githubusercontent.com/org/app/bbb/.github/workflows/ci.yml":
+			mu.Lock()
+			rawReads++
+			mu.Unlock()
+			body = workflow
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	target, err := parseHuntTarget("tj-actions/changed-files@v45")
+	CheckIfError(err)
+	withHTTPClientTransport(transport, func() {
+		hits, unread, err := newHunter(target, nil).huntOrg("org", 2)
+		CheckIfError(err)
+
+		var found []string
+		for _, h := range hits {
+			found = append(found, h.Repository+"@"+h.Branch)
+			if h.File != ".github/workflows/ci.yml" || h.Line != 6 {
+				t.Errorf("unexpected hit %+v", h)
+			}
+		}
+		if strings.Join(found, ",") != "org/app@dev,org/app@main,org/app@release" {
+			t.Errorf("unexpected hits %v", found)
+		}
+		if len(unread) != 1 || !strings.Contains(unread[0].Error(), "org/broken") {
+			t.Errorf("expected org/broken to be unread, got %v", unread)
+		}
+	})
+
+	// Every branch holds the same blob, which is read once.
+	if rawReads != 1 {
+		t.Errorf("expected one read of the workflow, got %d", rawReads)
+	}
+}
+
+func TestHunterHuntRepository_History(t *testing.T) {
+	clean := "on: push\njobs:\n  a:\n    steps:\n      - uses: tj-actions/changed-files@" + cleanSHA + "\n"
+	compromised := "on: push\njobs:\n  a:\n    steps:\n      - uses: tj-actions/changed-files@v45\n"
+	blobs := map[string]string{"head": "clean", "c2": "clean", "c1": "bad", "c0": "bad"}
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusOK, "[]"
+		q := req.URL.Query()
+		switch req.URL.Host + req.URL.Path {
+		case "api.github.com/repos/org/app/branches":
+			body = `[{"name": "main", "commit": {"sha": "head"}}]`
+		case "api.github.com/repos/org/app/commits":
+			if q.Get("sha") != "main" || q.Get("path") != remoteWorkflowDir {
+				t.Errorf("unexpected commits query %s", req.URL.RawQuery)
+			}
+			if q.Get("since") != "" {
+				if q.Get("since") != "2025-03-10T00:00:00Z" || q.Get("until") != "2025-03-15T23:59:59Z" {
+					t.Errorf("unexpected window %s", req.URL.RawQuery)
+				}
+				body = `[{"sha": "c2", "commit": {"committer": {"date": "2025-03-15T08:00:00Z"}}}, {"sha": "c1", "commit": {"committer": {"date": "2025-03-12T08:00:00Z"}}}]`
+			} else {
+				body = `[{"sha": "c0", "commit": {"committer": {"date": "2025-01-02T08:00:00Z"}}}]`
+			}
+		case "api.github.com/repos/org/app/contents/.github/workflows":
+			body = `[{"name": "ci.yml", "path": ".github/workflows/ci.yml", "type": "file", "sha": "` + blobs[q.Get("ref")] + `"}]`
+		case "raw.githubusercontent.com/org/app/head/.github/workflows/ci.yml":
+			body = clean
+		case "raw.githubusercontent.com/org/app/c1/.github/workflows/ci.yml":
+			body = compromised
+		default:
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	window, err := parseHuntWindow("2025-03-10", "2025-03-15")
+	CheckIfError(err)
+	target, err := parseHuntTarget("tj-actions/changed-files@v45")
+	CheckIfError(err)
+	withHTTPClientTransport(transport, func() {
+		hits, err := newHunter(target, window).huntRepository("org", "app")
+		CheckIfError(err)
+
+		var commits []string
+		for _, h := range hits {
+			commits = append(commits, h.Commit+" "+h.Date.Format(time.DateOnly))
+		}
+		// The compromised version was in effect when the window opened, and until c2.
+		if strings.Join(commits, ",") != "c1 2025-03-12,c0 2025-01-02" {
+			t.Errorf("unexpected hits %v", commits)
+		}
+	})
+}
+
+// --- Tests for parseHuntWindow ---
+
+func TestParseHuntWindow(t *testing.T) {
+	tests := []struct {
+		since, until string
+		valid        bool
+	}{
+		{"2025-03-10", "2025-03-15", true},
+		{"2025-03-10", "", true},
+		{"", "", true},
+		{"", "2025-03-15", false},
+		{"2025-03-15", "2025-03-10", false},
+		{"10/03/2025", "", false},
+	}
+
+	for _, tc := range tests {
+		if _, err := parseHuntWindow(tc.since, tc.until); (err == nil) != tc.valid {
+			t.Errorf("parseHuntWindow(%q, %q) = %v; want valid %v", tc.since, tc.until, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ruleStaleImageDigest flags image digests no tag points to anymore
+const ruleStaleImageDigest = "stale-image-digest"
+
+// defaultMaxImageTags caps how many tags of an image are resolved to find those of a digest
+const defaultMaxImageTags = 200
+
+// ImageDigestRule reverse-resolves the tags of digest-pinned images. A digest no tag points
+// to was untagged: the image it was pinned from was deleted or overwritten, possibly by
+// someone hiding what they pushed, and nobody can tell anymore which release it was.
+type ImageDigestRule struct {
+	client  *registryClient
+	maxTags int
+	// cache maps an image@digest to its tags, or nil when the registry could not be read
+	mu    sync.Mutex
+	cache map[string]*[]string
+}
+
+// NewImageDigestRule creates the rule, resolving at most maxTags tags per image
+func NewImageDigestRule(client *registryClient, maxTags int) *ImageDigestRule {
+	return &ImageDigestRule{client: client, maxTags: maxTags, cache: map[string]*[]string{}}
+}
+
+func (r *ImageDigestRule) ID() string {
+	return ruleStaleImageDigest
+}
+
+func (r *ImageDigestRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, img := range wf.imageNodes() {
+		for _, value := range img.Values {
+			ref, err := parseImageRef(value)
+			if err != nil || ref.Digest == "" {
+				continue
+			}
+
+			tags := r.tagsOf(ref)
+			if tags == nil || len(*tags) > 0 {
+				continue
+			}
+			findings = append(findings, wf.finding(r.ID(), SeverityMedium, img.Node, fmt.Sprintf(
+				"image %s@%s is not tagged anymore. The image it was pinned from may have been deleted or overwritten; pin the digest of a current tag",
+				ref.Registry+"/"+ref.Repository, ref.Digest)))
+		}
+	}
+	return findings
+}
+
+// tagsOf returns the tags pointing to the digest of ref, looking each digest up once
+func (r *ImageDigestRule) tagsOf(ref imageRef) *[]string {
+	key := ref.Registry + "/" + ref.Repository + "@" + ref.Digest
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	var result *[]string
+	tags, checked, err := r.client.tagsOf(ref, r.maxTags)
+	switch {
+	case err != nil:
+		logger.Warn("could not list image tags", "image", key, "err", err)
+	case len(tags) == 0 && r.maxTags > 0 && checked >= r.maxTags:
+		// Older tags were not checked, so the digest may still be tagged.
+		logger.Debug("no recent tag of image points to digest", "image", key, "checked", checked)
+	default:
+		result = &tags
+	}
+	r.mu.Lock()
+	r.cache[key] = result
+	r.mu.Unlock()
+	return result
+}
+
+// reverseLookupImage returns the tags pointing to the digest of an image reference
+func reverseLookupImage(client *registryClient, image string, maxTags int) ([]string, error) {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest == "" {
+		return nil, errors.New("image reference has no digest. Ex: alpine@sha256:...")
+	}
+	tags, _, err := client.tagsOf(ref, maxTags)
+	return tags, err
+}
+
+// isImageRef reports whether a lookup argument is a digest-pinned image rather than an action
+func isImageRef(s string) bool {
+	return strings.Contains(s, "@sha256:")
+}
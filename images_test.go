@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for ImageDigestRule.Check ---
+
+func TestImageDigestRule_Check(t *testing.T) {
+	transport, _ := fakeRegistry(t, map[string]map[string]string{
+		"library/node":  {"20": digestA},
+		"library/redis": {"7": digestB},
+		"org/tool":      {"1": digestA},
+	})
+	content := `jobs:
+  build:
+    container:
+      image: node@` + digestA + `
+    services:
+      redis:
+        image: redis@` + digestC + `
+      db: {image: "postgres:16"}
+    steps:
+      - uses: docker://registry-1.docker.io/org/tool@` + digestC + `
+      - uses: docker://org/tool@` + digestA + `
+  other:
+    container: redis@` + digestC + `
+`
+
+	withHTTPClientTransport(transport, func() {
+		rule := NewImageDigestRule(newRegistryClient(nil), defaultMaxImageTags)
+		findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+		lines := map[int]bool{}
+		for _, f := range findings {
+			if f.Rule != ruleStaleImageDigest || !strings.Contains(f.Message, digestC) {
+				t.Errorf("unexpected finding %+v", f)
+			}
+			lines[f.Line] = true
+		}
+		if len(findings) != 3 || !lines[7] || !lines[10] || !lines[13] {
+			t.Errorf("expected findings on lines 7, 10 and 13, got %+v", findings)
+		}
+	})
+}
+
+func TestImageDigestRule_Matrix(t *testing.T) {
+	transport, _ := fakeRegistry(t, map[string]map[string]string{
+		"library/node": {"20": digestA},
+	})
+	content := `jobs:
+  test:
+    strategy:
+      matrix:
+        image: ["node@` + digestA + `", "node@` + digestC + `"]
+    container: ${{ matrix.image }}
+`
+
+	withHTTPClientTransport(transport, func() {
+		rule := NewImageDigestRule(newRegistryClient(nil), defaultMaxImageTags)
+		findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+		if len(findings) != 1 || !strings.Contains(findings[0].Message, digestC) || findings[0].Line != 6 {
+			t.Errorf("expected a finding for the untagged matrix image on line 6, got %+v", findings)
+		}
+	})
+}
+
+func TestImageDigestRule_Expression(t *testing.T) {
+	transport, _ := fakeRegistry(t, map[string]map[string]string{
+		"library/node": {"20": digestA},
+	})
+	content := `on:
+  workflow_call:
+    inputs:
+      digest: {type: string, default: "` + digestC + `"}
+jobs:
+  test:
+    container: ${{ format('node@{0}', inputs.digest) }}
+`
+
+	withHTTPClientTransport(transport, func() {
+		rule := NewImageDigestRule(newRegistryClient(nil), defaultMaxImageTags)
+		findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+		if len(findings) != 1 || !strings.Contains(findings[0].Message, digestC) {
+			t.Errorf("expected a finding for the image built from the input default, got %+v", findings)
+		}
+	})
+}
+
+func TestImageDigestRule_IncompleteTags(t *testing.T) {
+	transport, _ := fakeRegistry(t, map[string]map[string]string{
+		"library/node": {"18": digestB, "20": digestB},
+	})
+
+	withHTTPClientTransport(transport, func() {
+		// Only one of two tags is checked, so an untagged digest is not conclusive.
+		rule := NewImageDigestRule(newRegistryClient(nil), 1)
+		wf := newWorkflowFile("wf.yml", []byte("jobs:\n  a:\n    container: node@"+digestA+"\n"))
+		if findings := rule.Check(wf); len(findings) != 0 {
+			t.Errorf("expected no finding when not every tag was checked, got %+v", findings)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ImpactedWorkflow is a workflow affected by a change to a shared action or workflow
+type ImpactedWorkflow struct {
+	Repository string `json:"repository"`
+	Workflow   string `json:"workflow"`
+	// Uses is the reference through which the workflow is affected
+	Uses string `json:"uses"`
+	// Depth is 1 for workflows referencing the target, and grows by one for every reusable
+	// workflow in between
+	Depth int `json:"depth"`
+}
+
+// impactTarget is an action or workflow whose consumers are looked for: every path of a
+// repository when Path is empty, and every version when Ref is empty
+type impactTarget struct {
+	Repo, Path, Ref string
+}
+
+// parseImpactTarget reads owner/repo, owner/repo/path or either with @ref
+func parseImpactTarget(s string) (impactTarget, bool) {
+	name, ref := splitRawAction(s)[0], splitRawAction(s)[1]
+	parts := strings.SplitN(strings.ToLower(name), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return impactTarget{}, false
+	}
+	t := impactTarget{Repo: parts[0] + "/" + parts[1], Ref: ref}
+	if len(parts) == 3 {
+		t.Path = strings.Trim(parts[2], "/")
+	}
+	return t, true
+}
+
+// matches reports whether a reference points at the target
+func (t impactTarget) matches(uses string) bool {
+	name, ref := splitRawAction(uses)[0], splitRawAction(uses)[1]
+	parts := strings.SplitN(strings.ToLower(name), "/", 3)
+	if len(parts) < 2 || parts[0]+"/"+parts[1] != t.Repo {
+		return false
+	}
+	if t.Path != "" && (len(parts) < 3 || strings.Trim(parts[2], "/") != t.Path) {
+		return false
+	}
+	return t.Ref == "" || ref == t.Ref
+}
+
+// impactOf lists the workflows of the call graph affected by a change or a compromise of
+// the target. Reusable workflows referencing it pass it on to their own callers, which are
+// followed until no new workflow is found. Composite actions calling the target, and local
+// references within a repository, are not followed.
+func impactOf(g *CallGraph, target impactTarget) []ImpactedWorkflow {
+	impacted := []ImpactedWorkflow{}
+	seen := map[[2]string]bool{}
+	queue := []impactTarget{target}
+	for depth := 1; len(queue) > 0; depth++ {
+		var next []impactTarget
+		for _, t := range queue {
+			for _, e := range g.Edges {
+				if e.Callee != t.Repo {
+					continue
+				}
+				for _, c := range e.Calls {
+					key := [2]string{e.Caller, c.Workflow}
+					if !t.matches(c.Uses) || seen[key] {
+						continue
+					}
+					seen[key] = true
+					impacted = append(impacted, ImpactedWorkflow{Repository: e.Caller, Workflow: c.Workflow, Uses: c.Uses, Depth: depth})
+					if path.Dir(c.Workflow) == ".github/workflows" {
+						next = append(next, impactTarget{Repo: e.Caller, Path: strings.ToLower(c.Workflow)})
+					}
+				}
+			}
+		}
+		queue = next
+	}
+
+	sort.SliceStable(impacted, func(i, j int) bool {
+		a, b := impacted[i], impacted[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Workflow < b.Workflow
+	})
+	return impacted
+}
+
+// impactedRepositories returns the distinct repositories of impacted workflows, in order
+func impactedRepositories(impacted []ImpactedWorkflow) []string {
+	set := map[string]bool{}
+	for _, w := range impacted {
+		set[w.Repository] = true
+	}
+	return sortedKeys(set)
+}
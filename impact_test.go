@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// --- Tests for parseImpactTarget ---
+
+func TestParseImpactTarget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected impactTarget
+		ok       bool
+	}{
+		{"org/shared", impactTarget{Repo: "org/shared"}, true},
+		{"Org/Shared/.github/workflows/build.yml@v2", impactTarget{Repo: "org/shared", Path: ".github/workflows/build.yml", Ref: "v2"}, true},
+		{"org/actions/setup-go/", impactTarget{Repo: "org/actions", Path: "setup-go"}, true},
+		{"shared", impactTarget{}, false},
+		{"org/", impactTarget{}, false},
+	}
+	for _, tc := range tests {
+		got, ok := parseImpactTarget(tc.input)
+		if ok != tc.ok || got != tc.expected {
+			t.Errorf("parseImpactTarget(%q) = %+v, %v; want %+v, %v", tc.input, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+// --- Tests for impactOf ---
+
+func TestImpactOf(t *testing.T) {
+	g := &CallGraph{Edges: []CallEdge{
+		// org/platform wraps the setup action in a reusable workflow used by the services.
+		{Caller: "org/platform", Callee: "org/actions", Kind: callAction, Calls: []CallSite{
+			{".github/workflows/Build.yml", "org/actions/setup@v1", false},
+			{".github/workflows/lint.yml", "org/actions/lint@v1", false},
+		}},
+		{Caller: "org/api", Callee: "org/platform", Kind: callWorkflow, Calls: []CallSite{
+			{".github/workflows/ci.yml", "org/platform/.github/workflows/build.yml@main", false},
+			{".github/workflows/lint.yml", "org/platform/.github/workflows/lint.yml@main", false},
+		}},
+		{Caller: "org/web", Callee: "org/api", Kind: callWorkflow, Calls: []CallSite{
+			{".github/workflows/e2e.yml", "org/api/.github/workflows/ci.yml@main", false},
+		}},
+		{Caller: "org/web", Callee: "org/actions", Kind: callAction, Calls: []CallSite{
+			{".github/workflows/e2e.yml", "org/actions/setup@v2", false},
+		}},
+	}}
+
+	target, _ := parseImpactTarget("org/actions/setup")
+	expected := []ImpactedWorkflow{
+		{Repository: "org/platform", Workflow: ".github/workflows/Build.yml", Uses: "org/actions/setup@v1", Depth: 1},
+		{Repository: "org/web", Workflow: ".github/workflows/e2e.yml", Uses: "org/actions/setup@v2", Depth: 1},
+		{Repository: "org/api", Workflow: ".github/workflows/ci.yml", Uses: "org/platform/.github/workflows/build.yml@main", Depth: 2},
+	}
+	if got := impactOf(g, target); !reflect.DeepEqual(got, expected) {
+		t.Errorf("impactOf = %+v; want %+v", got, expected)
+	}
+
+	// A version only reaches the workflows using it.
+	target, _ = parseImpactTarget("org/actions/setup@v1")
+	got := impactOf(g, target)
+	if repos := impactedRepositories(got); !reflect.DeepEqual(repos, []string{"org/api", "org/platform", "org/web"}) {
+		t.Errorf("impacted repositories = %v", repos)
+	}
+	if len(got) != 3 || got[2].Depth != 3 {
+		t.Errorf("expected org/web to be reached through org/api at depth 3, got %+v", got)
+	}
+}
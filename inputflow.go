@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleCompositeInjection flags untrusted input reaching a shell inside a composite action
+const ruleCompositeInjection = "composite-input-injection"
+
+// maxCompositeDepth caps how deep nested composite actions are followed
+const maxCompositeDepth = 5
+
+// untrustedInput matches expressions reading values anyone opening an issue or pull request
+// controls: titles, bodies, branch names, commit messages and the like
+var untrustedInput = regexp.MustCompile(`\bgithub\.(head_ref|event\.(` +
+	`(issue|pull_request|discussion)\.(title|body)|` +
+	`(comment|review|review_comment)\.body|` +
+	`pull_request\.head\.(ref|label|repo\.default_branch)|` +
+	`pages(\.\*|\[\d+\])\.page_name|` +
+	`(commits(\.\*|\[\d+\])|head_commit)\.(message|author\.(email|name))|` +
+	`workflow_run\.(head_branch|head_commit\.message|display_title)))\b`)
+
+// inputReference matches the inputs read in an expression of an action
+var inputReference = regexp.MustCompile(`\binputs\.([A-Za-z0-9_-]+)`)
+
+// actionFetcher returns the content of the action.yml of an action at a ref
+type actionFetcher func(action, ref string) ([]byte, error)
+
+// fetchActionFile downloads the metadata file of an action, which may be action.yml or
+// action.yaml, in the root of its repository or in a subdirectory
+func fetchActionFile(action, ref string) ([]byte, error) {
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("not an action: %s", action)
+	}
+	dir := strings.Join(parts[:2], "/") + "/" + ref
+	if len(parts) == 3 {
+		dir += "/" + parts[2]
+	}
+
+	var errs []string
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		content, err := fetchRaw(rawContentURL + "/" + dir + "/" + name)
+		if err == nil {
+			return content, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("no action metadata for %s@%s: %s", action, ref, strings.Join(errs, "; "))
+}
+
+// InputFlowRule follows the inputs handed to actions into composite actions. An input built
+// from untrusted data, such as an issue title, that a composite action interpolates into a
+// `run:` script, possibly through nested actions, lets the author of the issue run commands
+// in the workflow. Inputs should reach scripts through env: instead.
+type InputFlowRule struct {
+	fetch actionFetcher
+	// cache holds the parsed metadata of each action@ref, nil when it could not be fetched
+	mu    sync.Mutex
+	cache map[string]*yaml.Node
+}
+
+// NewInputFlowRule creates the rule, reading action metadata with fetch
+func NewInputFlowRule(fetch actionFetcher) *InputFlowRule {
+	return &InputFlowRule{fetch: fetch, cache: map[string]*yaml.Node{}}
+}
+
+func (r *InputFlowRule) ID() string {
+	return ruleCompositeInjection
+}
+
+func (r *InputFlowRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil {
+			continue
+		}
+		for _, step := range steps.Content {
+			step = resolveAlias(step)
+			uses := resolveAlias(mappingValue(step, "uses"))
+			if uses == nil || !isRemoteAction(uses.Value) {
+				continue
+			}
+
+			tainted := map[string]*yaml.Node{}
+			for _, pair := range mappingPairs(resolveAlias(mappingValue(step, "with"))) {
+				if v := resolveAlias(pair.Value); v != nil && v.Kind == yaml.ScalarNode && untrustedInput.MatchString(v.Value) {
+					tainted[pair.Key.Value] = v
+				}
+			}
+			if len(tainted) == 0 {
+				continue
+			}
+
+			for _, sink := range r.sinks(uses.Value, slices.Sorted(maps.Keys(tainted)), nil) {
+				findings = append(findings, wf.finding(r.ID(), SeverityHigh, tainted[sink.input], fmt.Sprintf(
+					"input %s of %s is built from untrusted data and reaches a shell in %s. Pass it through env: in that action, or do not hand it untrusted data",
+					sink.input, uses.Value, strings.Join(sink.chain, " -> "))))
+			}
+		}
+	}
+	return findings
+}
+
+// inputSink is an input of the analyzed step reaching a run step through a chain of actions
+type inputSink struct {
+	input string
+	chain []string
+}
+
+// sinks returns the inputs among tainted that reach a `run:` script of the composite action
+// behind uses, directly or through the actions it uses in turn. chain holds the actions
+// already followed.
+func (r *InputFlowRule) sinks(uses string, tainted []string, chain []string) []inputSink {
+	chain = append(slices.Clone(chain), uses)
+	if len(chain) > maxCompositeDepth {
+		return nil
+	}
+	runs := resolveAlias(mappingValue(r.metadata(uses), "runs"))
+	if using := resolveAlias(mappingValue(runs, "using")); using == nil || using.Value != "composite" {
+		return nil
+	}
+	steps := resolveAlias(mappingValue(runs, "steps"))
+	if steps == nil {
+		return nil
+	}
+
+	var sinks []inputSink
+	seen := map[string]bool{}
+	add := func(input string, chain []string) {
+		if !seen[input] {
+			seen[input] = true
+			sinks = append(sinks, inputSink{input: input, chain: chain})
+		}
+	}
+	for _, step := range steps.Content {
+		step = resolveAlias(step)
+		if run := resolveAlias(mappingValue(step, "run")); run != nil {
+			for _, in := range taintedInputs(run.Value, tainted) {
+				add(in, chain)
+			}
+		}
+
+		nested := resolveAlias(mappingValue(step, "uses"))
+		if nested == nil || !isRemoteAction(nested.Value) {
+			continue
+		}
+		// Map the inputs of the nested action back to the inputs they are built from.
+		origin := map[string]string{}
+		for _, pair := range mappingPairs(resolveAlias(mappingValue(step, "with"))) {
+			if v := resolveAlias(pair.Value); v != nil && v.Kind == yaml.ScalarNode {
+				if in := taintedInputs(v.Value, tainted); len(in) > 0 {
+					origin[pair.Key.Value] = in[0]
+				}
+			}
+		}
+		if len(origin) == 0 {
+			continue
+		}
+		for _, sink := range r.sinks(nested.Value, slices.Sorted(maps.Keys(origin)), chain) {
+			add(origin[sink.input], sink.chain)
+		}
+	}
+	return sinks
+}
+
+// taintedInputs returns the tainted inputs the expressions of a value read, in order
+func taintedInputs(s string, tainted []string) []string {
+	var inputs []string
+	for _, expr := range strings.Split(s, "${{")[1:] {
+		body, _, _ := strings.Cut(expr, "}}")
+		for _, m := range inputReference.FindAllStringSubmatch(body, -1) {
+			if slices.Contains(tainted, m[1]) {
+				inputs = append(inputs, m[1])
+			}
+		}
+	}
+	return inputs
+}
+
+// metadata returns the parsed action.yml of an action reference, fetching it once
+func (r *InputFlowRule) metadata(uses string) *yaml.Node {
+	r.mu.Lock()
+	meta, ok := r.cache[uses]
+	if !ok {
+		r.cache[uses] = nil
+	}
+	r.mu.Unlock()
+	if ok {
+		return meta
+	}
+
+	action, ref, _ := strings.Cut(uses, "@")
+	content, err := r.fetch(action, ref)
+	if err != nil {
+		logger.Debug("could not fetch action metadata", "action", uses, "err", err)
+		return nil
+	}
+	docs, err := parseYAMLDocuments(content)
+	if err != nil || len(docs) == 0 || len(docs[0].Content) == 0 {
+		logger.Debug("action metadata is not valid YAML", "action", uses, "err", err)
+		return nil
+	}
+	meta = resolveAlias(docs[0].Content[0])
+	r.mu.Lock()
+	r.cache[uses] = meta
+	r.mu.Unlock()
+	return meta
+}
+
+// isRemoteAction reports whether a `uses:` value is an action of another repository
+func isRemoteAction(uses string) bool {
+	return !strings.HasPrefix(uses, "./") && !strings.HasPrefix(uses, "docker://") && strings.Contains(uses, "@")
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeActions serves action metadata by action@ref
+type fakeActions map[string]string
+
+func (f fakeActions) fetch(action, ref string) ([]byte, error) {
+	content, ok := f[action+"@"+ref]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(content), nil
+}
+
+// --- Tests for InputFlowRule.Check ---
+
+func TestInputFlowRule_Check(t *testing.T) {
+	actions := fakeActions{
+		"org/greet@v1": `runs:
+  using: composite
+  steps:
+    - run: echo "Hello ${{ inputs.name }}"
+      shell: bash
+    - run: echo "$TITLE"
+      shell: bash
+      env:
+        TITLE: ${{ inputs.title }}
+`,
+		"org/wrapper@v2": `runs:
+  using: composite
+  steps:
+    - uses: org/label@v1
+      with:
+        label: prefix-${{ inputs.text }}
+`,
+		"org/label@v1": `runs:
+  using: composite
+  steps:
+    - run: gh issue edit --add-label "${{ inputs.label }}"
+      shell: bash
+`,
+		"org/node-action@v1": `runs:
+  using: node20
+  main: index.js
+`,
+	}
+	content := `on: issues
+jobs:
+  triage:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: org/greet@v1
+        with:
+          name: ${{ github.event.issue.title }}
+          title: ${{ github.event.issue.title }}
+      - uses: org/wrapper@v2
+        with:
+          text: ${{ github.event.issue.body }}
+      - uses: org/greet@v1
+        with:
+          name: ${{ github.actor }}
+      - uses: org/node-action@v1
+        with:
+          text: ${{ github.event.issue.body }}
+      - uses: org/missing@v1
+        with:
+          text: ${{ github.event.issue.body }}
+`
+
+	rule := NewInputFlowRule(actions.fetch)
+	findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Line != 8 || !strings.Contains(f.Message, "input name of org/greet@v1") {
+		t.Errorf("unexpected first finding %+v", f)
+	}
+	if f := findings[1]; f.Line != 12 || !strings.Contains(f.Message, "org/wrapper@v2 -> org/label@v1") {
+		t.Errorf("expected the chain of nested actions, got %+v", f)
+	}
+}
+
+func TestInputFlowRule_FetchesOnce(t *testing.T) {
+	fetches := 0
+	rule := NewInputFlowRule(func(action, ref string) ([]byte, error) {
+		fetches++
+		return nil, errors.New("not found")
+	})
+	content := `jobs:
+  a:
+    steps:
+      - uses: org/x@v1
+        with: {a: "${{ github.head_ref }}"}
+      - uses: org/x@v1
+        with: {b: "${{ github.head_ref }}"}
+`
+	rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches)
+	}
+}
+
+// --- Tests for untrustedInput ---
+
+func TestUntrustedInput(t *testing.T) {
+	for _, expr := range []string{
+		"${{ github.event.issue.title }}",
+		"${{ github.event.pull_request.head.ref }}",
+		"${{ github.event.commits[0].message }}",
+		"${{ github.event.head_commit.author.email }}",
+		"${{ github.event.commits.*.message }}",
+		"${{ github.head_ref }}",
+	} {
+		if !untrustedInput.MatchString(expr) {
+			t.Errorf("expected %s to be untrusted", expr)
+		}
+	}
+	for _, expr := range []string{"${{ github.actor }}", "${{ github.event.issue.number }}", "${{ github.event.pull_request.head.sha }}"} {
+		if untrustedInput.MatchString(expr) {
+			t.Errorf("expected %s to be trusted", expr)
+		}
+	}
+}
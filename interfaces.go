@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 )
 
 // Scanner ties together VCS operations with file scanning logic.
@@ -11,42 +14,315 @@ type Scanner struct {
 	// VCS system implementation (e.g., GitHub, GitLab)
 	VCS         VCS
 	FileScanner FileScanner
+	// Rules run on every workflow file in addition to the FileScanner
+	Rules []Rule
+	// Pipelines check the pipeline files of other CI systems found in repositories
+	Pipelines []PipelineChecker
+	// Profile records the triggers and privileges of every workflow file, including files
+	// without matches or findings, for the risk matrix of reports
+	Profile bool
+	// Components, when enabled, assigns every record to a component of the repository
+	Components ComponentsConfig
+	// CheckCodeowners flags workflow files not covered by CODEOWNERS, or not owned by one
+	// of the required owners of Codeowners when set
+	CheckCodeowners bool
+	Codeowners      CodeownersConfig
+	// Suppressions of the configuration, applied along with inline suppression comments
+	Suppressions []Suppression
+	// Risk, when set, scores the issues of the inventory through Rank
+	Risk *RiskModel
+	// Criticality maps globs of repository names to their tier, recorded on every record
+	Criticality map[string]string
+	// FailOn is the lowest severity failing --raise-error, per tier
+	FailOn map[string]string
+	// Severities maps severities to the levels of the sinks the inventory is exported to
+	Severities SeverityMapping
+	// QuickFixes, when set, resolves mutable references to attach the edits pinning them,
+	// along with the edits fixing findings of rules implementing QuickFixer
+	QuickFixes Resolver
+	// Templates also scans the workflows of the cookiecutter and copier project templates
+	// of repositories
+	Templates bool
+	// RequiredWorkflows, when set, flags repositories without the workflows the policy
+	// requires
+	RequiredWorkflows []RequiredWorkflow
+	// Concurrency is how many repositories are scanned at once. It defaults to
+	// defaultScanWorkers.
+	Concurrency int
+	// AllBranches reads every branch from the object store instead of the worktree and
+	// merges the records that are identical across branches
+	AllBranches bool
+	// Ref, when set, makes AuditRepository read the workflows as committed at this branch,
+	// tag or commit instead of the worktree
+	Ref string
+	// Triggers, when set, limits the scan to workflows started by one of these events
+	Triggers []string
+	// Stream, when set, receives the issues of every repository as NDJSON as soon as the
+	// repository is scanned
+	Stream *ndjsonStream
+	// RevealSecrets keeps the credentials findings quote as they are instead of redacting them
+	RevealSecrets bool
+	// RecurseSubmodules also scans the submodules of repositories, at the commits pinned
+	RecurseSubmodules bool
+	// Paths limits the files scanned by globs of their path relative to the repository
+	Paths PathsConfig
+	// SeverityOverrides sets the severity of the findings of rules, by rule ID
+	SeverityOverrides map[string]string
+	// Policy accepts the mutable references of trusted actions. Its banned actions are
+	// checked by BannedActionRule.
+	Policy ActionPolicy
 }
 
-func (s *Scanner) ScanBranch(branch string, repo Repository, regex *regexp.Regexp, dirPath string) *InventoryRecord {
+// ScanBranch scans every file in dirPath and returns a record for each file with matches
+// or findings. Files that are unsafe symlinks are reported instead of being read.
+func (s *Scanner) ScanBranch(branch string, repo Repository, regex *regexp.Regexp, dirPath string) []*InventoryRecord {
+	// Workflow directories sit two levels below the repository root.
+	root := filepath.Dir(filepath.Dir(dirPath))
+	records := s.scanPipelines(branch, repo, root)
+	if s.Templates {
+		for _, dir := range templateWorkflowDirs(repo, root) {
+			records = append(records, s.ScanBranch(branch, repo, regex, dir)...)
+		}
+	}
+	if s.RecurseSubmodules {
+		records = append(records, s.scanSubmodules(branch, repo, regex, root)...)
+	}
+
 	fileNames, err := repo.ListFiles(dirPath)
 	if err != nil {
 		// The directory might not exist on this branch; skip to next branch.
 		logger.Debug("directory might not exist on branch. skipping to next repo")
-		return nil
+		if ir := s.requiredWorkflowsRecord(branch, repo, root, dirPath, nil); ir != nil {
+			records = append(records, ir)
+		}
+		return records
+	}
+
+	var owners []codeownersRule
+	if s.Components.Codeowners || s.CheckCodeowners {
+		owners = loadCodeowners(repo, root)
 	}
 
+	// Workflows are kept to tell which of the required ones the repository has.
+	var workflows []*WorkflowFile
+
 	// Process each file found in the directory.
 	for _, fileName := range fileNames {
-		fPath := fmt.Sprintf("%s/%s", dirPath, fileName)
+		fPath := filepath.Join(dirPath, fileName)
+		if !s.Paths.includes(relativePath(root, fPath)) {
+			continue
+		}
 		content, err := repo.ReadFile(fPath)
 		if err != nil {
+			var symErr *SymlinkError
+			if errors.As(err, &symErr) {
+				logger.Warn("workflow file is an unsafe symlink", "repo", repo.Name(), "err", symErr)
+				records = append(records, &InventoryRecord{
+					Repository: repo.Name(),
+					Branch:     branch,
+					FilePath:   fPath,
+					Findings:   []Finding{symErr.Finding()},
+				})
+				continue
+			}
 			// Log error and skip this file.
 			logger.Debug("workflow directory might not exist. skipping to next repo")
 			continue
 		}
 
+		var wf *WorkflowFile
+		if len(s.Triggers) > 0 {
+			wf = newWorkflowFile(fPath, content)
+			if !triggeredBy(wf, s.Triggers) {
+				// The repository still has the workflow, whatever starts it.
+				if isYAMLFile(fileName) {
+					workflows = append(workflows, wf)
+				}
+				continue
+			}
+		}
+
 		matches, err := s.FileScanner.ScanContent(content, regex)
 		if err != nil {
 			// Log error and skip this file.
 			continue
 		}
 
-		if len(matches) > 0 {
-			return &InventoryRecord{
-				Repository: repo.Name(),
-				Branch:     branch,
-				FilePath:   fPath,
-				Matches:    matches,
+		var findings []Finding
+		var profile *WorkflowProfile
+		var component string
+		if len(s.Rules) > 0 || s.Profile || s.Components.enabled() || len(s.RequiredWorkflows) > 0 {
+			if wf == nil {
+				wf = newWorkflowFile(fPath, content)
+			}
+			if isYAMLFile(fileName) {
+				workflows = append(workflows, wf)
+			}
+			findings = s.checkRules(wf)
+			if s.Profile {
+				profile = profileWorkflow(wf)
+			}
+			if s.Components.enabled() {
+				component = s.componentOf(wf, owners, root)
+			}
+		}
+		if s.CheckCodeowners && isYAMLFile(fileName) {
+			if f := codeownersFinding(owners, relativePath(root, fPath), s.Codeowners.RequiredOwners); f != nil {
+				findings = append(findings, *f)
 			}
 		}
+		matches, findings, suppressed := s.settle(matches, findings, content, relativePath(root, fPath))
+		if s.QuickFixes != nil {
+			s.attachQuickFixes(newWorkflowFile(fPath, content), matches, findings)
+		}
+		if len(matches) > 0 || len(findings) > 0 || len(suppressed) > 0 || profile != nil {
+			var criticality string
+			if len(s.Criticality) > 0 {
+				criticality = criticalityOf(s.Criticality, repo.Name())
+			}
+			records = append(records, &InventoryRecord{
+				Repository:  repo.Name(),
+				Branch:      branch,
+				FilePath:    fPath,
+				Component:   component,
+				Criticality: criticality,
+				Matches:     matchValues(matches),
+				Locations:   matches,
+				Findings:    findings,
+				Suppressed:  suppressed,
+				Profile:     profile,
+			})
+		}
+	}
+	if ir := s.requiredWorkflowsRecord(branch, repo, root, dirPath, workflows); ir != nil {
+		records = append(records, ir)
+	}
+	return records
+}
+
+// settle applies the project settings to the mutable references and findings of a file,
+// at rel from the repository root: references of trusted actions are accepted, severities
+// overridden, suppressions applied and credentials redacted
+func (s *Scanner) settle(matches []Match, findings []Finding, content []byte, rel string) ([]Match, []Finding, []SuppressedFinding) {
+	matches, trusted := s.Policy.trust(matches)
+	overrideSeverities(findings, s.SeverityOverrides)
+	findings, suppressed := applySuppressions(findings, content, rel, s.Suppressions)
+	suppressed = append(suppressed, trusted...)
+	s.redact(findings, suppressed)
+	return matches, findings, suppressed
+}
+
+// requiredWorkflowsRecord returns a record of the workflow directory with the required
+// workflows the repository does not have, or nil if it has them all
+func (s *Scanner) requiredWorkflowsRecord(branch string, repo Repository, root, dirPath string, workflows []*WorkflowFile) *InventoryRecord {
+	if len(s.RequiredWorkflows) == 0 {
+		return nil
 	}
-	return nil
+	findings := requiredWorkflowFindings(s.RequiredWorkflows, workflows)
+	overrideSeverities(findings, s.SeverityOverrides)
+	findings, suppressed := applySuppressions(findings, nil, relativePath(root, dirPath), s.Suppressions)
+	if len(findings) == 0 && len(suppressed) == 0 {
+		return nil
+	}
+	ir := &InventoryRecord{Repository: repo.Name(), Branch: branch, FilePath: dirPath, RepoRoot: root, Findings: findings, Suppressed: suppressed}
+	if len(s.Criticality) > 0 {
+		ir.Criticality = criticalityOf(s.Criticality, repo.Name())
+	}
+	return ir
+}
+
+// scanPipelines checks the pipeline files of other CI systems in the repository checked out
+// at root, along with the templates of the repository they reference, returning a record
+// for each file with findings
+func (s *Scanner) scanPipelines(branch string, repo Repository, root string) []*InventoryRecord {
+	var records []*InventoryRecord
+	for _, p := range s.Pipelines {
+		var queue []string
+		if p.File() != "" {
+			queue = append(queue, p.File())
+		}
+		if d, ok := p.(PipelineDirectory); ok {
+			// The directory does not exist in most repositories.
+			names, _ := repo.ListFiles(filepath.Join(root, filepath.FromSlash(d.Directory())))
+			for _, name := range names {
+				if isYAMLFile(name) {
+					queue = append(queue, path.Join(d.Directory(), name))
+				}
+			}
+		}
+		visited := map[string]bool{}
+		for _, rel := range queue {
+			visited[rel] = true
+		}
+		for len(queue) > 0 {
+			rel := queue[0]
+			queue = queue[1:]
+			if !s.Paths.includes(rel) {
+				continue
+			}
+			fPath := filepath.Join(root, filepath.FromSlash(rel))
+			content, err := repo.ReadFile(fPath)
+			if err != nil {
+				// Most repositories do not use the CI system.
+				continue
+			}
+
+			wf := newWorkflowFile(fPath, content)
+			if t, ok := p.(PipelineTemplates); ok {
+				for _, tmpl := range t.Templates(wf, rel) {
+					if !visited[tmpl] {
+						visited[tmpl] = true
+						queue = append(queue, tmpl)
+					}
+				}
+			}
+
+			findings := p.Check(wf)
+			overrideSeverities(findings, s.SeverityOverrides)
+			findings, suppressed := applySuppressions(findings, content, rel, s.Suppressions)
+			s.redact(findings, suppressed)
+			if len(findings) == 0 && len(suppressed) == 0 {
+				continue
+			}
+			var criticality string
+			if len(s.Criticality) > 0 {
+				criticality = criticalityOf(s.Criticality, repo.Name())
+			}
+			records = append(records, &InventoryRecord{
+				Repository:  repo.Name(),
+				Branch:      branch,
+				FilePath:    fPath,
+				RepoRoot:    root,
+				Criticality: criticality,
+				Findings:    findings,
+				Suppressed:  suppressed,
+			})
+		}
+	}
+	return records
+}
+
+// checkRules runs all rules of the scanner on a workflow file
+func (s *Scanner) checkRules(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, r := range s.Rules {
+		findings = append(findings, r.Check(wf)...)
+	}
+	return findings
+}
+
+// componentOf returns the component of a workflow file of the repository checked out at root
+func (s *Scanner) componentOf(wf *WorkflowFile, owners []codeownersRule, root string) string {
+	rel, err := filepath.Rel(root, wf.Path)
+	if err != nil {
+		rel = wf.Path
+	}
+
+	var filters []string
+	for _, r := range wf.roots() {
+		filters = append(filters, triggerPaths(mappingValue(r, "on"))...)
+	}
+	return s.Components.componentOf(owners, filepath.ToSlash(rel), filters)
 }
 
 // ScanRepos traverses all repositories found under the root directory,
@@ -66,33 +342,52 @@ func (s *Scanner) ScanRepos(root string, regex *regexp.Regexp, ho bool) (*Invent
 		return nil, err
 	}
 
-	// Process each repository.
-	for _, repo := range repos {
+	// Process repositories a few at a time. The branches of a repository share its worktree,
+	// so they are scanned one after the other.
+	records := s.scanEach(len(repos), func(i int) string { return repos[i].Name() }, func(i int) []*InventoryRecord {
+		repo := repos[i]
 		branches, err := repo.ListBranches()
 		if err != nil {
 			// Log error and continue with next repository.
 			logger.Debug("couldn't detect branches. skipping to next repo")
-			continue
+			return nil
 		}
 
 		if ho {
 			branches = []string{"HEAD"}
 		}
 
+		searchPath := workflowDir(filepath.Join(absolutePath, repo.Name()))
+		if s.AllBranches && !ho {
+			return s.scanAllBranches(repo, branches, regex, searchPath)
+		}
+
 		// For each branch, enumerate files in the specified directory.
+		var records []*InventoryRecord
 		for _, branch := range branches {
-			searchPath := fmt.Sprintf("%s/%s/.github/workflows", absolutePath, repo.Name())
 			logger.Debug("Processing the repo:", "repo", repo.Name(), "branch", branch, "filepath", searchPath)
-			ir := s.ScanBranch(branch, repo, regex, searchPath)
-			if ir != nil {
-				inventory.Records = append(inventory.Records, ir)
+			at := repo
+			if reader, ok := repo.(BranchReader); ok && isBare(repo) {
+				// Bare repositories have no worktree, so branches are read from the object store.
+				if at, err = reader.AtBranch(branch); err != nil {
+					logger.Debug("could not read branch. skipping to next branch", "repo", repo.Name(), "branch", branch, "err", err)
+					continue
+				}
 			}
+			records = append(records, s.ScanBranch(branch, at, regex, searchPath)...)
 		}
-	}
+		return records
+	})
 
+	inventory.Records = slices.Concat(records...)
 	return &inventory, nil
 }
 
+// workflowDir returns the GitHub workflows directory of a repository checked out at root
+func workflowDir(root string) string {
+	return filepath.Join(root, ".github", "workflows")
+}
+
 // Repository abstracts a single repository and its operations.
 type Repository interface {
 	Name() string
@@ -109,6 +404,13 @@ type Repository interface {
 	SwitchBranch(branchName string) error
 }
 
+// BranchReader is implemented by repositories that can read the files of a branch, tag
+// or commit without checking it out.
+type BranchReader interface {
+	// AtBranch returns the repository as committed at the branch, tag or commit
+	AtBranch(branch string) (Repository, error)
+}
+
 // Branch abstracts a branch in a repository.
 type Branch interface {
 	Name() string
@@ -116,7 +418,62 @@ type Branch interface {
 
 // FileScanner defines functionality to scan file content using a regex.
 type FileScanner interface {
-	ScanContent(content []byte, regex *regexp.Regexp) ([]string, error)
+	ScanContent(content []byte, regex *regexp.Regexp) ([]Match, error)
+}
+
+// PipelineChecker inspects the pipeline file of a CI system other than GitHub Actions.
+// The file is parsed like a workflow, for the positions of its nodes.
+type PipelineChecker interface {
+	// File is the path of the pipeline file relative to the repository root, or "" for CI
+	// systems only reading the files of a PipelineDirectory
+	File() string
+	// Check returns the findings of the pipeline file
+	Check(wf *WorkflowFile) []Finding
+}
+
+// PipelineTemplates is implemented by PipelineCheckers of CI systems whose pipeline files
+// pull in templates, which are checked too
+type PipelineTemplates interface {
+	// Templates returns the paths, relative to the repository root, of the templates of the
+	// repository referenced by the pipeline file at rel
+	Templates(wf *WorkflowFile, rel string) []string
+}
+
+// PipelineDirectory is implemented by PipelineCheckers of CI systems that also read every
+// YAML file of a directory, besides the pipeline file
+type PipelineDirectory interface {
+	// Directory is the path of the directory relative to the repository root
+	Directory() string
+}
+
+// Rule inspects a workflow file for issues other than mutable references.
+type Rule interface {
+	// ID is the stable identifier reported with each finding of the rule
+	ID() string
+	// Check returns the findings of the rule for a workflow file
+	Check(wf *WorkflowFile) []Finding
+}
+
+// Fixer computes the text edits resolving the issues of a rule in a workflow file.
+type Fixer interface {
+	Fix(wf *WorkflowFile) ([]TextEdit, error)
+}
+
+// QuickFixer is implemented by Rules able to fix their findings one at a time, for the
+// edits attached to findings
+type QuickFixer interface {
+	// QuickFixes lists the rules of the findings it fixes
+	QuickFixes() []string
+	// QuickFix describes the fix of a finding of the workflow file and returns its edits,
+	// none when it cannot be fixed
+	QuickFix(wf *WorkflowFile, f Finding) (string, []TextEdit)
+}
+
+// PipelineFixer is a Fixer of the pipeline file of a CI system other than GitHub Actions
+type PipelineFixer interface {
+	Fixer
+	// File is the path of the pipeline file relative to the repository root
+	File() string
 }
 
 // VCS defines operations common to all version control systems.
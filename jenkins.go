@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// jenkinsfile is the pipeline file of Jenkins at the root of a repository
+const jenkinsfile = "Jenkinsfile"
+
+// ruleUnpinnedLibrary flags Jenkins shared libraries loaded at a version that can change
+const ruleUnpinnedLibrary = "unpinned-library"
+
+// releaseTag matches versions that look like release tags, such as v1.2 or 2.0.1
+var releaseTag = regexp.MustCompile(`^v?\d+(\.\d+)*([-+.][0-9A-Za-z.-]+)?$`)
+
+// groovyToken is a token of a Groovy script. Strings hold their value without quotes, and
+// Offset is where the token starts in the script.
+type groovyToken struct {
+	Kind   byte // 'i' for identifiers, 's' for strings, or the punctuation itself
+	Value  string
+	Offset int
+}
+
+// tokenizeGroovy splits a Groovy script into identifiers, strings and punctuation, skipping
+// whitespace, comments and numbers. It is a heuristic: slashy strings and the nesting of
+// ${} in GStrings are not handled, which does not matter for the calls looked for.
+func tokenizeGroovy(src []byte) []groovyToken {
+	var tokens []groovyToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'' || c == '"':
+			quote := []byte{c}
+			if bytes.HasPrefix(src[i:], []byte{c, c, c}) {
+				quote = []byte{c, c, c}
+			}
+			start := i
+			i += len(quote)
+			var b strings.Builder
+			// Only triple-quoted strings span lines.
+			for i < len(src) && !bytes.HasPrefix(src[i:], quote) && (len(quote) == 3 || src[i] != '\n') {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			i += len(quote)
+			tokens = append(tokens, groovyToken{Kind: 's', Value: b.String(), Offset: start})
+		case c == '_' || c == '$' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || (src[i]|0x20 >= 'a' && src[i]|0x20 <= 'z') || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, groovyToken{Kind: 'i', Value: string(src[start:i]), Offset: start})
+		case c >= '0' && c <= '9':
+			for i < len(src) && (src[i] == '.' || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+		default:
+			tokens = append(tokens, groovyToken{Kind: c, Value: string(c), Offset: i})
+			i++
+		}
+	}
+	return tokens
+}
+
+// JenkinsfileChecker flags shared libraries a Jenkinsfile loads without a version, or at a
+// branch or tag. Their Groovy runs inside the controller with the credentials of the
+// pipeline, so a push to the library changes every pipeline loading it.
+type JenkinsfileChecker struct{}
+
+func (c JenkinsfileChecker) File() string {
+	return jenkinsfile
+}
+
+func (c JenkinsfileChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, lib := range jenkinsLibraries(tokenizeGroovy(wf.Content)) {
+		if f := libraryFinding(wf, lib); f != nil {
+			findings = append(findings, *f)
+		}
+	}
+	return findings
+}
+
+// jenkinsLibraries returns the string tokens naming the libraries loaded by @Library, as a
+// string or a list, and by the library step, as library 'name@version' or
+// library identifier: 'name@version'
+func jenkinsLibraries(tokens []groovyToken) []groovyToken {
+	var libs []groovyToken
+	at := func(i int, kind byte, value string) bool {
+		return i < len(tokens) && tokens[i].Kind == kind && (value == "" || tokens[i].Value == value)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case at(i, '@', "") && at(i+1, 'i', "Library") && at(i+2, '(', ""):
+			j := i + 3
+			if at(j, '[', "") {
+				for j++; at(j, 's', "") || at(j, ',', ""); j++ {
+					if tokens[j].Kind == 's' {
+						libs = append(libs, tokens[j])
+					}
+				}
+			} else if at(j, 's', "") {
+				libs = append(libs, tokens[j])
+			}
+			i = j
+		case at(i, 'i', "library") && (i == 0 || !at(i-1, '.', "")):
+			j := i + 1
+			if at(j, '(', "") {
+				j++
+			}
+			switch {
+			case at(j, 's', ""):
+				libs = append(libs, tokens[j])
+			case at(j, 'i', "identifier") && at(j+1, ':', "") && at(j+2, 's', ""):
+				libs = append(libs, tokens[j+2])
+			}
+		}
+	}
+	return libs
+}
+
+// libraryFinding flags a library loaded at its default version, which the Jenkins
+// administrators set and usually is a branch, at a branch, or at a tag. Versions set
+// through variables cannot be told.
+func libraryFinding(wf *WorkflowFile, lib groovyToken) *Finding {
+	if strings.Contains(lib.Value, "$") {
+		return nil
+	}
+	name, version, _ := strings.Cut(lib.Value, "@")
+	if fullSHA.MatchString(version) {
+		return nil
+	}
+
+	f := Finding{Rule: ruleUnpinnedLibrary, Severity: SeverityMedium}
+	f.Line, f.Column = wf.lines.position(lib.Offset)
+	switch {
+	case version == "":
+		f.Message = fmt.Sprintf("shared library %s is loaded at its default version, usually a branch. Pin it to a commit SHA. Ex: '%s@<commit-sha>'", name, name)
+	case releaseTag.MatchString(version):
+		f.Severity = SeverityLow
+		f.Message = fmt.Sprintf("shared library %s is loaded at the tag %s, which can be moved. Pin it to a commit SHA", name, version)
+	default:
+		f.Message = fmt.Sprintf("shared library %s is loaded at the branch %s. Pin it to a commit SHA", name, version)
+	}
+	return &f
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+// --- Tests for JenkinsfileChecker.Check ---
+
+func TestJenkinsfileChecker_Check(t *testing.T) {
+	content := `@Library('pipeline-utils') _
+@Library(["shared@main", 'release@v1.2.0', 'pinned@0123456789abcdef0123456789abcdef01234567']) import com.example.Tool
+
+// @Library('commented@main')
+/* library 'commented-too' */
+def version = "${env.LIB_VERSION}"
+library "dynamic@${version}"
+library identifier: 'scm-lib@develop', retriever: modernSCM([$class: 'GitSCMSource', remote: 'https://git.example.com/lib.git'])
+def tools = library('tools@2.0').com.example
+
+pipeline {
+  agent any
+  stages {
+    stage('Build') {
+      steps {
+        echo 'library is loaded'
+        sh "make ${params.library}"
+      }
+    }
+  }
+}
+`
+	findings := JenkinsfileChecker{}.Check(newWorkflowFile(jenkinsfile, []byte(content)))
+
+	type pos struct{ line, column int }
+	expected := map[pos]string{
+		{1, 10}: SeverityMedium, // default version
+		{2, 11}: SeverityMedium, // branch
+		{2, 26}: SeverityLow,    // tag
+		{8, 21}: SeverityMedium, // branch through identifier:
+		{9, 21}: SeverityLow,    // tag through library()
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if f.Rule != ruleUnpinnedLibrary || expected[pos{f.Line, f.Column}] != f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+// --- Tests for tokenizeGroovy ---
+
+func TestTokenizeGroovy(t *testing.T) {
+	tokens := tokenizeGroovy([]byte(`x = '''a 'quoted' b''' + "c \" d" // e`))
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, string(tok.Kind)+":"+tok.Value)
+	}
+	expected := []string{"i:x", "=:=", "s:a 'quoted' b", "+:+", `s:c " d`}
+	if len(got) != len(expected) {
+		t.Fatalf("tokens = %q; want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("token %d = %q; want %q", i, got[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultLockFile records the commit each action tag pointed to when it was locked
+const defaultLockFile = ".sharfer.lock"
+
+// defaultDriftInterval is how often server mode re-resolves the tags of a lockfile
+const defaultDriftInterval = time.Hour
+
+// LockEntry is a tag of an action along with the commit it pointed to when locked
+type LockEntry struct {
+	Action string `json:"action"`
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+	// Dist is the hash of the dist/ bundle at SHA, recorded for JavaScript actions on request
+	Dist string `json:"dist,omitempty"`
+}
+
+// Lockfile lists the action tags used by the workflows of a repository
+type Lockfile struct {
+	Actions []LockEntry `json:"actions"`
+}
+
+// isTagRef reports whether a ref names a tag, following the convention of makeAPIEndpoint.
+// Branches are meant to move, so only tags are locked.
+func isTagRef(ref string) bool {
+	return strings.HasPrefix(strings.ToLower(ref), "v")
+}
+
+// buildLockfile records the tags referenced by the workflows of the repository at root.
+// Tags are resolved through resolver, while SHAs pinned with a version comment are recorded
+// as they are, since the comment states which tag the SHA was taken from.
+func buildLockfile(root string, resolver Resolver) (*Lockfile, error) {
+	repo := GitRepository{name: filepath.Base(root), localPath: root}
+	dir := workflowDir(root)
+
+	fileNames, err := repo.ListFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	seen := map[string]bool{}
+	lock := &Lockfile{}
+	for _, fileName := range fileNames {
+		if !isYAMLFile(fileName) {
+			continue
+		}
+		content, err := repo.ReadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			logger.Warn("skipping workflow file", "file", fileName, "err", err)
+			continue
+		}
+
+		for _, m := range usesRef.FindAllSubmatch(content, -1) {
+			action, ref, comment := string(m[1]), string(m[2]), string(m[3])
+			entry := LockEntry{Action: action, Ref: ref}
+			if pinnedRef.MatchString("@" + ref) {
+				entry.Ref, entry.SHA = comment, ref
+			}
+			if !isTagRef(entry.Ref) || seen[action+"@"+entry.Ref] {
+				continue
+			}
+			seen[action+"@"+entry.Ref] = true
+
+			if entry.SHA == "" {
+				sha, err := resolver.resolve(action + "@" + entry.Ref)
+				if err != nil {
+					logger.Warn("could not lock action", "action", action+"@"+entry.Ref, "err", err)
+					continue
+				}
+				entry.SHA = sha
+			}
+			lock.Actions = append(lock.Actions, entry)
+		}
+	}
+
+	sort.Slice(lock.Actions, func(i, j int) bool {
+		a, b := lock.Actions[i], lock.Actions[j]
+		if a.Action != b.Action {
+			return a.Action < b.Action
+		}
+		return a.Ref < b.Ref
+	})
+	return lock, nil
+}
+
+// readLockfile reads a lockfile written by write
+func readLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("json: %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// write saves the lockfile as indented JSON, so changes to it read well in reviews
+func (l *Lockfile) write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
+
+// Drift is a locked tag that now points to another commit upstream
+type Drift struct {
+	LockEntry
+	Current string `json:"current_sha"`
+}
+
+// detectDrift re-resolves every tag of the lockfile and returns those that moved. A tag
+// that moved although nothing was updated on our side may have been tampered with.
+// Tags that cannot be resolved are logged and skipped.
+func detectDrift(lock *Lockfile, resolver Resolver) []Drift {
+	var drifts []Drift
+	for _, e := range lock.Actions {
+		sha, err := resolver.resolve(e.Action + "@" + e.Ref)
+		if err != nil {
+			logger.Warn("could not re-resolve locked action", "action", e.Action+"@"+e.Ref, "err", err)
+			continue
+		}
+		if sha != e.SHA {
+			drifts = append(drifts, Drift{LockEntry: e, Current: sha})
+		}
+	}
+	return drifts
+}
+
+// driftAlert reports drifted tags
+type driftAlert func(drifts []Drift)
+
+// watchDrift checks the lockfile for drift every interval until stop is closed, alerting
+// whenever a tag has moved. The first check runs right away. A tag is alerted on once per
+// commit it moved to, so a tag left drifted does not alert again on every check.
+func watchDrift(lock *Lockfile, resolver Resolver, interval time.Duration, alert driftAlert, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// alerted maps the action@ref of every drift alerted on to the commit it moved to
+	alerted := map[string]string{}
+	for {
+		current := map[string]string{}
+		var fresh []Drift
+		for _, d := range detectDrift(lock, resolver) {
+			key := d.Action + "@" + d.Ref
+			current[key] = d.Current
+			if alerted[key] != d.Current {
+				fresh = append(fresh, d)
+			}
+		}
+		// Tags back at their locked commit are forgotten, so moving again alerts again.
+		alerted = current
+		if len(fresh) > 0 {
+			alert(fresh)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// logDrift alerts by logging every drifted tag
+func logDrift(drifts []Drift) {
+	for _, d := range drifts {
+		logger.Warn("upstream tag moved since it was locked", "action", d.Action, "ref", d.Ref, "locked", d.SHA, "current", d.Current)
+	}
+}
+
+// driftMessage describes drifted tags in a few lines of text
+func driftMessage(drifts []Drift) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "scharf: %d locked action tag(s) moved upstream", len(drifts))
+	for _, d := range drifts {
+		fmt.Fprintf(&b, "\n%s@%s: %s -> %s", d.Action, d.Ref, d.SHA, d.Current)
+	}
+	return b.String()
+}
+
+// webhookDrift returns an alert posting drifted tags to a webhook. The payload has a
+// `text` field, which Slack and most chat webhooks display, plus the drifts themselves.
+func webhookDrift(url string) driftAlert {
+	return func(drifts []Drift) {
+		logDrift(drifts)
+
+		body, err := json.Marshal(map[string]any{"text": driftMessage(drifts), "drifts": drifts})
+		if err != nil {
+			logger.Warn("could not encode drift alert", "err", err)
+			return
+		}
+		resp, err := post(webhookHTTP, url, "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			logger.Warn("could not send drift alert", "err", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.Warn("drift alert rejected by webhook", "status", resp.Status)
+		}
+	}
+}
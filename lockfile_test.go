@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver resolves actions from a map, failing for unknown ones
+type fakeResolver map[string]string
+
+func (f fakeResolver) resolve(action string) (string, error) {
+	if sha, ok := f[action]; ok {
+		return sha, nil
+	}
+	return "", errors.New("not found: " + action)
+}
+
+const (
+	lockedSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+	movedSHA  = "b4ffde65f46336ab88eb53be808477a3936bae11"
+)
+
+// --- Tests for buildLockfile ---
+
+func TestBuildLockfile(t *testing.T) {
+	root, path := fixRepoFixture(t)
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@` + lockedSHA + ` # v5.0.0
+      - uses: actions/checkout@v4
+      - uses: actions/cache@main
+      - uses: unknown/action@v1
+      - uses: ./local
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := buildLockfile(root, fakeResolver{"actions/checkout@v4": movedSHA})
+	if err != nil {
+		t.Fatalf("buildLockfile returned error: %v", err)
+	}
+
+	expected := []LockEntry{
+		{Action: "actions/checkout", Ref: "v4", SHA: movedSHA},
+		{Action: "actions/setup-go", Ref: "v5.0.0", SHA: lockedSHA},
+	}
+	if !reflect.DeepEqual(lock.Actions, expected) {
+		t.Errorf("buildLockfile = %+v; want %+v", lock.Actions, expected)
+	}
+}
+
+func TestBuildLockfile_StaleCache(t *testing.T) {
+	resolutionCache = openSHACache(filepath.Join(t.TempDir(), "shas.json"))
+	defer func() { resolutionCache = nil }()
+	// The cache still holds the commit v4 pointed to before it moved.
+	resolutionCache.put(apiURL+"/actions/checkout@v4", lockedSHA)
+
+	root, path := fixRepoFixture(t)
+	CheckIfError(os.WriteFile(path, []byte("on: push\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n"), 0o644))
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := `[{"name": "v4", "commit": {"sha": "` + movedSHA + `"}}]`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+	var lock *Lockfile
+	withHTTPClientTransport(rt, func() {
+		var err error
+		lock, err = buildLockfile(root, freshResolver())
+		CheckIfError(err)
+	})
+
+	if len(lock.Actions) != 1 || lock.Actions[0].SHA != movedSHA {
+		t.Errorf("expected the lockfile to record where v4 points now, got %+v", lock.Actions)
+	}
+}
+
+// --- Tests for readLockfile and write ---
+
+func TestLockfile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultLockFile)
+	lock := &Lockfile{Actions: []LockEntry{{Action: "actions/checkout", Ref: "v4", SHA: lockedSHA}}}
+	CheckIfError(lock.write(path))
+
+	got, err := readLockfile(path)
+	CheckIfError(err)
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("readLockfile = %+v; want %+v", got, lock)
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := readLockfile(path); err == nil || !strings.HasPrefix(err.Error(), "json:") {
+		t.Errorf("expected json error for a corrupt lockfile, got %v", err)
+	}
+}
+
+// --- Tests for detectDrift ---
+
+func TestDetectDrift(t *testing.T) {
+	lock := &Lockfile{Actions: []LockEntry{
+		{Action: "actions/checkout", Ref: "v4", SHA: lockedSHA},
+		{Action: "actions/setup-go", Ref: "v5", SHA: lockedSHA},
+		{Action: "gone/action", Ref: "v1", SHA: lockedSHA},
+	}}
+	resolver := fakeResolver{"actions/checkout@v4": movedSHA, "actions/setup-go@v5": lockedSHA}
+
+	drifts := detectDrift(lock, resolver)
+	expected := []Drift{{LockEntry: lock.Actions[0], Current: movedSHA}}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("detectDrift = %+v; want %+v", drifts, expected)
+	}
+}
+
+// --- Tests for watchDrift ---
+
+// sequenceResolver resolves every action to the next of its SHAs, then to the last one
+type sequenceResolver struct {
+	mu    sync.Mutex
+	shas  []string
+	calls int
+}
+
+func (r *sequenceResolver) resolve(action string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return r.shas[min(r.calls, len(r.shas))-1], nil
+}
+
+func TestWatchDrift(t *testing.T) {
+	const otherSHA = "0123456789abcdef0123456789abcdef01234567"
+	lock := &Lockfile{Actions: []LockEntry{{Action: "actions/checkout", Ref: "v4", SHA: lockedSHA}}}
+	// The tag moves, stays there for two more checks, then moves again.
+	resolver := &sequenceResolver{shas: []string{movedSHA, movedSHA, movedSHA, otherSHA}}
+	alerts := make(chan []Drift, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		watchDrift(lock, resolver, time.Millisecond, func(d []Drift) { alerts <- d }, stop)
+		close(done)
+	}()
+
+	for _, want := range []string{movedSHA, otherSHA} {
+		select {
+		case d := <-alerts:
+			if len(d) != 1 || d[0].Current != want {
+				t.Errorf("alert = %+v; want a drift to %s", d, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no drift alert received")
+		}
+	}
+	// Later checks see the same drift and stay quiet.
+	select {
+	case d := <-alerts:
+		t.Errorf("expected an unchanged drift not to alert again, got %+v", d)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(stop)
+	<-done
+}
+
+// --- Tests for webhookDrift ---
+
+func TestWebhookDrift(t *testing.T) {
+	var payload struct {
+		Text   string  `json:"text"`
+		Drifts []Drift `json:"drifts"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %v", body, err)
+		}
+	}))
+	defer srv.Close()
+
+	drift := Drift{LockEntry: LockEntry{Action: "actions/checkout", Ref: "v4", SHA: lockedSHA}, Current: movedSHA}
+	webhookDrift(srv.URL)([]Drift{drift})
+
+	if !strings.Contains(payload.Text, "actions/checkout@v4: "+lockedSHA+" -> "+movedSHA) {
+		t.Errorf("alert text does not describe the drift: %q", payload.Text)
+	}
+	if len(payload.Drifts) != 1 || payload.Drifts[0] != drift {
+		t.Errorf("alert drifts = %+v; want %+v", payload.Drifts, drift)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Severities of LSP diagnostics
+const (
+	lspError       = 1
+	lspWarning     = 2
+	lspInformation = 3
+)
+
+// lspSeverities map the severities of findings to those of diagnostics
+var lspSeverities = map[string]int{SeverityLow: lspInformation, SeverityMedium: lspWarning, SeverityHigh: lspError, SeverityCritical: lspError}
+
+// lspServer is a minimal Language Server Protocol server publishing the mutable references
+// and findings of the workflow files open in an editor as diagnostics, as they are typed,
+// with quick fixes pinning actions to the commit of their ref
+type lspServer struct {
+	// Scanner holds the rules and suppressions the documents are checked with
+	Scanner  Scanner
+	Resolver Resolver
+
+	out  io.Writer
+	docs map[string][]byte
+	// shas caches the commits references were resolved to
+	shas map[string]string
+}
+
+// newLSPServer creates a server writing its messages to out
+func newLSPServer(sc Scanner, resolver Resolver, out io.Writer) *lspServer {
+	return &lspServer{Scanner: sc, Resolver: resolver, out: out, docs: map[string][]byte{}, shas: map[string]string{}}
+}
+
+// lspRequest is a request or a notification of the client. Notifications have no ID.
+type lspRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type lspErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// lspPosition is a 0-based line and character offset in UTF-16 code units
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// before reports whether the position comes before another
+func (p lspPosition) before(o lspPosition) bool {
+	return p.Line < o.Line || (p.Line == o.Line && p.Character < o.Character)
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// overlaps reports whether two ranges share a position, an empty range counting as the
+// position it is at
+func (r lspRange) overlaps(o lspRange) bool {
+	return !r.End.before(o.Start) && !o.End.before(r.Start)
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCodeAction struct {
+	Title       string          `json:"title"`
+	Kind        string          `json:"kind"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+	Edit        struct {
+		Changes map[string][]lspTextEdit `json:"changes"`
+	} `json:"edit"`
+}
+
+type lspDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Range lspRange `json:"range"`
+}
+
+// readLSPMessage reads the content of a message framed by a Content-Length header
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("lsp: invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// send writes a message to the client
+func (s *lspServer) send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+// serve answers the messages read from in until the client exits or closes the stream
+func (s *lspServer) serve(in io.Reader) error {
+	r := bufio.NewReader(in)
+	for {
+		content, err := readLSPMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req lspRequest
+		if err := json.Unmarshal(content, &req); err != nil {
+			logger.Warn("skipping invalid message", "err", err)
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers a request, or acts on a notification
+func (s *lspServer) handle(req lspRequest) error {
+	var params lspDocumentParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			logger.Warn("skipping message with invalid parameters", "method", req.Method, "err", err)
+			return nil
+		}
+	}
+	uri := params.TextDocument.URI
+
+	switch req.Method {
+	case "initialize":
+		return s.send(lspResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{
+			"capabilities": map[string]any{
+				// Documents are sent whole on every change.
+				"textDocumentSync":   map[string]any{"openClose": true, "change": 1},
+				"codeActionProvider": map[string]any{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]string{"name": "scharf"},
+		}})
+	case "shutdown":
+		return s.send(lspResponse{JSONRPC: "2.0", ID: req.ID})
+	case "textDocument/didOpen":
+		s.docs[uri] = []byte(params.TextDocument.Text)
+		return s.publish(uri)
+	case "textDocument/didChange":
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[uri] = []byte(params.ContentChanges[n-1].Text)
+		}
+		return s.publish(uri)
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+		return s.send(lspNotification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: map[string]any{"uri": uri, "diagnostics": []lspDiagnostic{}}})
+	case "textDocument/codeAction":
+		return s.send(lspResponse{JSONRPC: "2.0", ID: req.ID, Result: s.codeActions(uri, params.Range)})
+	}
+
+	if req.ID != nil {
+		resp := lspErrorResponse{JSONRPC: "2.0", ID: req.ID}
+		resp.Error.Code, resp.Error.Message = -32601, "method not found: "+req.Method
+		return s.send(resp)
+	}
+	// Other notifications, such as initialized or didSave, need no answer.
+	return nil
+}
+
+// workflowPath returns the path of a document and its path relative to the repository root,
+// and false for documents other than workflow files
+func workflowPath(uri string) (string, string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || !isYAMLFile(u.Path) {
+		return "", "", false
+	}
+	i := strings.LastIndex(u.Path, "/.github/workflows/")
+	if i < 0 || path.Dir(u.Path) != u.Path[:i]+"/.github/workflows" {
+		return "", "", false
+	}
+	return u.Path, u.Path[i+1:], true
+}
+
+// publish sends the diagnostics of a document
+func (s *lspServer) publish(uri string) error {
+	diagnostics := []lspDiagnostic{}
+	for _, d := range s.diagnose(uri) {
+		diagnostics = append(diagnostics, d.lspDiagnostic)
+	}
+	return s.send(lspNotification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: map[string]any{"uri": uri, "diagnostics": diagnostics}})
+}
+
+// documentDiagnostic is a diagnostic with the mutable reference it is about, if any
+type documentDiagnostic struct {
+	lspDiagnostic
+	match *Match
+}
+
+// diagnose checks an open workflow file like ScanBranch does, reporting its mutable
+// references and the findings of the rules of the scanner, with the project settings
+// applied
+func (s *lspServer) diagnose(uri string) []documentDiagnostic {
+	fPath, rel, ok := workflowPath(uri)
+	content, open := s.docs[uri]
+	if !ok || !open || !s.Scanner.Paths.includes(rel) {
+		return nil
+	}
+	li := newLineIndex(content)
+
+	matches, err := s.Scanner.FileScanner.ScanContent(content, mutableRefRegex)
+	if err != nil {
+		logger.Debug("could not scan document", "uri", uri, "err", err)
+	}
+	matches, findings, _ := s.Scanner.settle(matches, s.Scanner.checkRules(newWorkflowFile(fPath, content)), content, rel)
+
+	var diagnostics []documentDiagnostic
+	for i, m := range matches {
+		start := li.offset(m.Line, m.Column)
+		diagnostics = append(diagnostics, documentDiagnostic{lspDiagnostic: lspDiagnostic{
+			Range:    lspRange{Start: lspPositionOf(li, start), End: lspPositionOf(li, start+len(m.Value))},
+			Severity: lspWarning,
+			Code:     ruleMutableReference,
+			Source:   "scharf",
+			Message:  fmt.Sprintf("%s is a mutable reference. Pin it to a commit SHA", m.Value),
+		}, match: &matches[i]})
+	}
+
+	for _, f := range findings {
+		// Findings about the whole file are shown on its first line.
+		line := max(f.Line, 1)
+		start := li.offset(line, max(f.Column, 1))
+		end := li.lineStart(line) + len(li.lineText(line))
+		diagnostics = append(diagnostics, documentDiagnostic{lspDiagnostic: lspDiagnostic{
+			Range:    lspRange{Start: lspPositionOf(li, start), End: lspPositionOf(li, max(start, end))},
+			Severity: lspSeverities[f.Severity],
+			Code:     f.Rule,
+			Source:   "scharf",
+			Message:  f.Message,
+		}})
+	}
+	return diagnostics
+}
+
+// codeActions returns the quick fixes pinning the mutable references of a range of a
+// document to the commit of their ref. References that cannot be resolved get none.
+func (s *lspServer) codeActions(uri string, r lspRange) []lspCodeAction {
+	content := s.docs[uri]
+	li := newLineIndex(content)
+	actions := []lspCodeAction{}
+	for _, d := range s.diagnose(uri) {
+		if d.match == nil || !d.Range.overlaps(r) {
+			continue
+		}
+		sha, ok := s.shas[d.match.Value]
+		if !ok {
+			resolved, err := s.Resolver.resolve(d.match.Value)
+			if err != nil {
+				logger.Warn("could not resolve reference to a commit", "ref", d.match.Value, "err", err)
+				continue
+			}
+			sha, s.shas[d.match.Value] = resolved, resolved
+		}
+
+		edit := pinEdit(li, *d.match, sha)
+		a := lspCodeAction{
+			Title:       fmt.Sprintf("Pin %s to %s", d.match.Value, sha),
+			Kind:        "quickfix",
+			Diagnostics: []lspDiagnostic{d.lspDiagnostic},
+		}
+		a.Edit.Changes = map[string][]lspTextEdit{uri: {{
+			Range:   lspRange{Start: lspPositionOf(li, edit.Start), End: lspPositionOf(li, edit.End)},
+			NewText: edit.NewText,
+		}}}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// lspPositionOf converts a byte offset to an LSP position
+func lspPositionOf(li *lineIndex, offset int) lspPosition {
+	line, _ := li.position(offset)
+	start := li.lineStart(line)
+	if offset < start {
+		offset = start
+	}
+	return lspPosition{Line: line - 1, Character: len(utf16.Encode([]rune(string(li.content[start:offset]))))}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// lspFrame frames a message as the client sends it
+func lspFrame(msg string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+}
+
+// readLSPMessages decodes every message written by the server
+func readLSPMessages(t *testing.T, out []byte) []map[string]any {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(out))
+	var msgs []map[string]any
+	for {
+		content, err := readLSPMessage(r)
+		if err != nil {
+			return msgs
+		}
+		var msg map[string]any
+		CheckIfError(json.Unmarshal(content, &msg))
+		msgs = append(msgs, msg)
+	}
+}
+
+// --- Tests for lspServer.serve ---
+
+func TestLSPServer_Serve(t *testing.T) {
+	uri := "file:///src/app/.github/workflows/ci.yml"
+	text := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/setup-go@v5 # Go\n"
+	textJSON, _ := json.Marshal(text)
+
+	var in strings.Builder
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"` + uri + `","languageId":"yaml","version":1,"text":` + string(textJSON) + `}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/codeAction","params":{"textDocument":{"uri":"` + uri + `"},"range":{"start":{"line":5,"character":0},"end":{"line":6,"character":0}},"context":{"diagnostics":[]}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		in.WriteString(lspFrame(msg))
+	}
+
+	var out bytes.Buffer
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}}
+	resolver := fakeResolver{"actions/checkout@v4": lockedSHA, "actions/setup-go@v5": movedSHA}
+	CheckIfError(newLSPServer(sc, resolver, &out).serve(strings.NewReader(in.String())))
+
+	msgs := readLSPMessages(t, out.Bytes())
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d: %v", len(msgs), msgs)
+	}
+
+	diagnostics := msgs[1]["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diagnostics)
+	}
+	first := diagnostics[0].(map[string]any)
+	start := first["range"].(map[string]any)["start"].(map[string]any)
+	if first["code"] != ruleMutableReference || start["line"] != 5.0 || start["character"] != 14.0 {
+		t.Errorf("unexpected diagnostic %v", first)
+	}
+
+	actions := msgs[2]["result"].([]any)
+	if len(actions) != 1 {
+		t.Fatalf("expected the quick fix of the line in range only, got %v", actions)
+	}
+	edits := actions[0].(map[string]any)["edit"].(map[string]any)["changes"].(map[string]any)[uri].([]any)
+	if got := edits[0].(map[string]any)["newText"]; got != "actions/checkout@"+lockedSHA+" # v4" {
+		t.Errorf("unexpected edit %v", got)
+	}
+
+	if msgs[3]["error"].(map[string]any)["code"] != -32601.0 {
+		t.Errorf("expected unknown methods to fail, got %v", msgs[3])
+	}
+	if result, ok := msgs[4]["result"]; !ok || result != nil {
+		t.Errorf("expected a null result to shutdown, got %v", msgs[4])
+	}
+}
+
+func TestLSPServer_DiagnoseRedactsSecrets(t *testing.T) {
+	uri := "file:///src/app/.github/workflows/ci.yml"
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	text := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    env:\n      TOKEN: " + token + "\n"
+
+	for _, reveal := range []bool{false, true} {
+		sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Rules: []Rule{SecretRule{}}, RevealSecrets: reveal}
+		s := newLSPServer(sc, fakeResolver{}, &bytes.Buffer{})
+		s.docs[uri] = []byte(text)
+
+		diagnostics := s.diagnose(uri)
+		if len(diagnostics) != 1 || diagnostics[0].Code != ruleHardcodedSecret {
+			t.Fatalf("expected the credential to be reported, got %+v", diagnostics)
+		}
+		if got := strings.Contains(diagnostics[0].Message, token); got != reveal {
+			t.Errorf("with RevealSecrets %v, diagnostic %q", reveal, diagnostics[0].Message)
+		}
+	}
+}
+
+func TestLSPServer_DiagnoseAppliesProjectSettings(t *testing.T) {
+	uri := "file:///src/app/.github/workflows/ci.yml"
+	text := "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: acme/deploy@v1\n      - run: echo \"::set-output name=a::b\"\n"
+	sc := Scanner{
+		FileScanner:       GitHubWorkFlowScanner{},
+		Rules:             []Rule{DeprecatedRule{}},
+		Policy:            ActionPolicy{Trusted: []string{"actions/*"}},
+		SeverityOverrides: map[string]string{ruleDeprecated: SeverityHigh},
+	}
+	s := newLSPServer(sc, fakeResolver{}, &bytes.Buffer{})
+	s.docs[uri] = []byte(text)
+
+	diagnostics := s.diagnose(uri)
+	if len(diagnostics) != 2 || diagnostics[0].match == nil || diagnostics[0].match.Value != "acme/deploy@v1" {
+		t.Fatalf("expected the untrusted reference and the finding, got %+v", diagnostics)
+	}
+	if diagnostics[1].Code != ruleDeprecated || diagnostics[1].Severity != lspError {
+		t.Errorf("expected the severity of the rule to be overridden, got %+v", diagnostics[1])
+	}
+
+	s.Scanner.Paths = PathsConfig{Exclude: []string{".github/workflows/ci.yml"}}
+	if diagnostics := s.diagnose(uri); len(diagnostics) != 0 {
+		t.Errorf("expected excluded files to have no diagnostics, got %+v", diagnostics)
+	}
+}
+
+// --- Tests for workflowPath ---
+
+func TestWorkflowPath(t *testing.T) {
+	tests := []struct {
+		uri      string
+		rel      string
+		expected bool
+	}{
+		{"file:///src/app/.github/workflows/ci.yml", ".github/workflows/ci.yml", true},
+		{"file:///src/app/.github/workflows/nested/ci.yml", "", false},
+		{"file:///src/app/.github/dependabot.yml", "", false},
+		{"file:///src/app/.github/workflows/README.md", "", false},
+		{"untitled:Untitled-1", "", false},
+	}
+	for _, tt := range tests {
+		_, rel, ok := workflowPath(tt.uri)
+		if ok != tt.expected || rel != tt.rel {
+			t.Errorf("workflowPath(%q) = %q, %v, expected %q, %v", tt.uri, rel, ok, tt.rel, tt.expected)
+		}
+	}
+}
+
+// --- Tests for pinEdit ---
+
+func TestPinEdit(t *testing.T) {
+	content := []byte("steps:\n  - uses: actions/checkout@v4\n  - {uses: actions/cache@v4, with: {path: x}}\n  - uses: 'actions/setup-go@v5'\n")
+	li := newLineIndex(content)
+	tests := []struct {
+		m        Match
+		expected string
+	}{
+		{Match{Value: "actions/checkout@v4", Line: 2, Column: 11}, "steps:\n  - uses: actions/checkout@" + lockedSHA + " # v4\n"},
+		{Match{Value: "actions/cache@v4", Line: 3, Column: 12}, "  - {uses: actions/cache@" + lockedSHA + ", with: {path: x}}\n"},
+		{Match{Value: "actions/setup-go@v5", Line: 4, Column: 12}, "  - uses: 'actions/setup-go@" + lockedSHA + "' # v5\n"},
+	}
+	for _, tt := range tests {
+		fixed, err := applyEdits(content, []TextEdit{pinEdit(li, tt.m, lockedSHA)})
+		CheckIfError(err)
+		if !strings.Contains(string(fixed), tt.expected) {
+			t.Errorf("unexpected fix of %s:\n%s", tt.m.Value, fixed)
+		}
+	}
+}
+
+// --- Tests for lspPositionOf ---
+
+func TestLSPPositionOf(t *testing.T) {
+	// é is one UTF-16 unit and two bytes, 😀 two units and four bytes.
+	li := newLineIndex([]byte("a: é😀x\nb\n"))
+	tests := []struct {
+		offset   int
+		expected lspPosition
+	}{
+		{0, lspPosition{0, 0}},
+		{3, lspPosition{0, 3}},
+		{9, lspPosition{0, 6}},
+		{11, lspPosition{1, 0}},
+	}
+	for _, tt := range tests {
+		if got := lspPositionOf(li, tt.offset); got != tt.expected {
+			t.Errorf("lspPositionOf(%d) = %+v, expected %+v", tt.offset, got, tt.expected)
+		}
+	}
+}
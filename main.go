@@ -4,15 +4,26 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
+// mutableRefRegex finds whether workflow has reference to vXY, main, dev or master
+var mutableRefRegex = regexp.MustCompile(`(\w*-?\w*)(\/)(\w+-?\w+)@((v\w+)|main|dev|master)`)
+
 const asciiLogo = `
 _______ _______ _     _ _______  ______ _______
 |______ |       |_____| |_____| |_____/ |______
@@ -29,6 +40,19 @@ func writeToJSON(inv *Inventory) {
 	enc.Encode(inv)
 }
 
+// writeToReport renders a report of the inventory into the given file
+func writeToReport(inv *Inventory, fileName string, render func(io.Writer, *Inventory) error) {
+	f, err := os.Create(fileName)
+	if err != nil {
+		slog.Error("could not create the report file", "file", fileName, "err", err)
+		return
+	}
+	defer f.Close()
+	if err := render(f, inv); err != nil {
+		slog.Error("could not write the report", "file", fileName, "err", err)
+	}
+}
+
 func WriteToCSV(inv *Inventory) {
 	writeRows := [][]string{
 		{
@@ -36,17 +60,33 @@ func WriteToCSV(inv *Inventory) {
 			"branch_name",
 			"actions_file",
 			"action",
+			"line",
+			"column",
 		},
 	}
+	blamed := inv.blamed()
+	if blamed {
+		writeRows[0] = append(writeRows[0], "probable_owner", "commit", "committed_at")
+	}
 
 	for _, ir := range inv.Records {
-		for _, mat := range ir.Matches {
-			writeRows = append(writeRows, []string{
+		for _, loc := range ir.Locations {
+			row := []string{
 				ir.Repository,
 				ir.Branch,
 				ir.FilePath,
-				mat,
-			})
+				loc.Value,
+				strconv.Itoa(loc.Line),
+				strconv.Itoa(loc.Column),
+			}
+			if blamed {
+				var commit, at string
+				if loc.Blame != nil {
+					commit, at = loc.Blame.Commit, loc.Blame.Date.UTC().Format(time.RFC3339)
+				}
+				row = append(row, probableOwner(loc.Blame), commit, at)
+			}
+			writeRows = append(writeRows, row)
 		}
 	}
 
@@ -56,6 +96,756 @@ func WriteToCSV(inv *Inventory) {
 	csv_writer.WriteAll(writeRows)
 }
 
+// reportInventory prints mutable references and rule findings of an inventory and
+// reports whether there was anything to print
+func reportInventory(tw *tablewriter.Table, inv *Inventory) bool {
+	hasMatches, hasFindings := inv.hasMatches(), inv.hasFindings()
+
+	if hasMatches {
+		fmt.Println("Mutable references found in your GitHub actions. Please replace them to secure your CI from supply chain attacks.")
+		printMatches(tw, inv)
+	}
+
+	if hasFindings {
+		fmt.Println("Additional issues found in your GitHub workflows:")
+		printFindings(inv)
+	}
+
+	return hasMatches || hasFindings
+}
+
+// printMatches renders mutable references of an inventory along with the SHA to pin them to.
+// A reference repeated in the same file is listed once.
+func printMatches(tw *tablewriter.Table, inv *Inventory) {
+	header := []string{"Match", "FilePath", "Replace with SHA"}
+	colors := []tablewriter.Colors{
+		{tablewriter.Bold, tablewriter.FgRedColor},
+		{tablewriter.Bold, tablewriter.FgRedColor},
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+	}
+	scored := inv.scored()
+	if scored {
+		header, colors = append(header, "Score"), append(colors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor})
+	}
+	blamed := inv.blamed()
+	if blamed {
+		header, colors = append(header, "Probable owner", "Introduced in"), append(colors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor}, tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor})
+	}
+	tw.SetHeader(header)
+	tw.SetHeaderColor(colors...)
+
+	s := SHAResolver{}
+	visited := map[string]bool{}
+
+	for _, ir := range inv.Records {
+		for _, loc := range ir.Locations {
+			mat := loc.Value
+			hashKey := mat + ir.FilePath
+			if visited[hashKey] {
+				// already reported, skip to next
+				continue
+			}
+			sha, err := s.resolve(mat)
+			if err != nil {
+				sha = "N/A"
+			}
+			row := []string{
+				mat,
+				fmt.Sprintf("%s:%d", ir.FilePath, loc.Line),
+				sha,
+			}
+			if scored {
+				row = append(row, strconv.Itoa(loc.Score))
+			}
+			if blamed {
+				row = append(row, probableOwner(loc.Blame), introducedIn(loc.Blame))
+			}
+			tw.Append(row)
+			visited[hashKey] = true
+		}
+	}
+	tw.Render()
+}
+
+// scanOrgFromFlags scans every repository of an organization kept by the --include-archived
+// and --visibility flags, and reports them as scan does a single repository
+func scanOrgFromFlags(cmd *cobra.Command, tw *tablewriter.Table, org string) {
+	for _, flag := range []string{"check-environments", "wiki", "audit-log"} {
+		if cmd.Flag(flag).Value.String() == "true" {
+			slog.Error("--" + flag + " only applies to the scan of a single repository, not to --org")
+			os.Exit(1)
+		}
+	}
+
+	opts := OrgScanOptions{Archived: cmd.Flag("include-archived").Value.String() == "true", Clone: cmd.Flag("clone").Value.String() == "true"}
+	opts.Visibilities, _ = cmd.Flags().GetStringSlice("visibility")
+	sc := scannerFromFlags(cmd, rulesFromFlags(cmd))
+	inv, err := sc.ScanOrg(org, mutableRefRegex, opts)
+	if err != nil {
+		slog.Error("problem while scanning the organization", "org", org, "err", err)
+		os.Exit(1)
+	}
+	sc.Risk.Rank(inv)
+
+	if sc.Stream == nil && !reportInventory(tw, inv) {
+		fmt.Println("No mutable references found. Good job!")
+	}
+	exportInventory(cmd, inv, &sc)
+	if cmd.Flag("raise-error").Value.String() == "true" && inv.fails(sc.FailOn) {
+		os.Exit(1)
+	}
+}
+
+// correlateFromFlags tells who last modified the workflows of an inventory when --audit-log
+// is passed
+func correlateFromFlags(cmd *cobra.Command, inv *Inventory, repoOf repoOfFunc) {
+	if cmd.Flag("audit-log").Value.String() != "true" {
+		return
+	}
+	auditLog{Enterprise: cmd.Flag("enterprise").Value.String()}.Correlate(inv, repoOf)
+}
+
+// printHuntHits renders the references a hunt found as a table on stdout. Hits in past
+// commits tell the commit and its date.
+func printHuntHits(hits []huntHit) {
+	if len(hits) == 0 {
+		fmt.Println("No references found")
+		return
+	}
+	historic := false
+	for _, h := range hits {
+		historic = historic || h.Commit != ""
+	}
+
+	repos := map[string]bool{}
+	tw := tablewriter.NewWriter(os.Stdout)
+	header := []string{"Repository", "Branch", "File", "Uses"}
+	colors := []tablewriter.Colors{
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+		{tablewriter.Bold, tablewriter.FgRedColor},
+	}
+	if historic {
+		header, colors = append(header, "Commit"), append(colors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor})
+	}
+	tw.SetHeader(header)
+	tw.SetHeaderColor(colors...)
+	for _, h := range hits {
+		repos[h.Repository] = true
+		row := []string{h.Repository, h.Branch, fmt.Sprintf("%s:%d", h.File, h.Line), h.Uses}
+		if historic {
+			commit := "head"
+			if h.Commit != "" {
+				commit = fmt.Sprintf("%s (%s)", shortSHA(h.Commit), h.Date.UTC().Format(time.DateOnly))
+			}
+			row = append(row, commit)
+		}
+		tw.Append(row)
+	}
+	tw.Render()
+	fmt.Printf("%d references in %d repositories\n", len(hits), len(repos))
+}
+
+// printTagTimeline renders when the mutable references of a repository appeared across its
+// tags as a table on stdout
+func printTagTimeline(timeline []*tagAppearance) {
+	if len(timeline) == 0 {
+		fmt.Println("No mutable references found in any tag")
+		return
+	}
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Uses", "File", "First tag", "First seen", "Last tag", "Tags", "At HEAD"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+	)
+	for _, a := range timeline {
+		tw.Append([]string{a.Uses, a.File, a.FirstTag, a.FirstSeen.UTC().Format(time.DateOnly), a.LastTag, strconv.Itoa(len(a.Tags)), strconv.FormatBool(a.AtHead)})
+	}
+	tw.Render()
+}
+
+// auditTagsFromFlags prints the timeline of the mutable references of the repository in
+// the current directory across its tags, for audit --tags
+func auditTagsFromFlags(cmd *cobra.Command) {
+	format := cmd.Flag("out").Value.String()
+	if format != "" && format != "json" {
+		slog.Error("--tags only exports json", "out", format)
+		os.Exit(1)
+	}
+	if !IsGitRepo(".") {
+		fmt.Println("Not a git repository. Skipping checks!")
+		return
+	}
+	absPath, err := os.Getwd()
+	if err != nil {
+		slog.Error("problem while reading the current directory", "err", err)
+		os.Exit(1)
+	}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}}
+	timeline, err := sc.TagTimeline(GitRepository{name: filepath.Base(absPath), localPath: absPath}, mutableRefRegex)
+	if err != nil {
+		slog.Error("problem while scanning the tags of the repository", "err", err)
+		os.Exit(1)
+	}
+	printTagTimeline(timeline)
+
+	if format == "json" {
+		f, err := os.Create("tag-timeline.json")
+		if err != nil {
+			slog.Error("could not create the report file", "file", "tag-timeline.json", "err", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		if err := writeTagTimeline(f, timeline); err != nil {
+			slog.Error("could not write the report", "file", "tag-timeline.json", "err", err)
+		}
+	}
+	if cmd.Flag("raise-error").Value.String() == "true" && slices.ContainsFunc(timeline, func(a *tagAppearance) bool { return a.AtHead }) {
+		os.Exit(1)
+	}
+}
+
+// printCampaignStatus renders the state of each repository of a campaign as a table on stdout
+func printCampaignStatus(status *campaignStatus) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Repository", "State", "Pull request", "Reason"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+	)
+	for _, r := range status.Repositories() {
+		tw.Append([]string{r.Repository, r.State, r.PullRequest, r.Reason})
+	}
+	tw.Render()
+}
+
+// printFreshness renders how far the pinned actions lag behind their latest releases as
+// a table on stdout
+func printFreshness(report []dependencyFreshness) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Action", "Pinned", "Pinned at", "Latest", "Latest at", "Gap (days)"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+	)
+	for _, d := range report {
+		pinned, gap := d.Ref, "unknown"
+		if d.Version != d.Ref {
+			pinned = shortSHA(d.Ref)
+		}
+		if d.Version != "" && d.Version != d.Ref {
+			pinned = fmt.Sprintf("%s (%s)", pinned, d.Version)
+		}
+		if d.Gap() >= 0 {
+			gap = strconv.Itoa(d.Gap())
+		}
+		tw.Append([]string{d.Action, pinned, freshnessDate(d.PinnedAt), d.Latest, freshnessDate(d.LatestAt), gap})
+	}
+	tw.Render()
+}
+
+// printRunnerInventory renders the runner labels of a workspace as a table on stdout,
+// followed by the repositories able to target sensitive runners
+func printRunnerInventory(uses []runnerUse) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Label", "Kind", "Repositories"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+	)
+	for _, u := range uses {
+		tw.Append([]string{u.Label, u.Kind, strings.Join(u.Repositories, ", ")})
+	}
+	tw.Render()
+
+	sensitive := sensitiveRepositories(uses)
+	if len(sensitive) == 0 {
+		return
+	}
+	fmt.Println("\nRepositories that can target sensitive runners:")
+	for _, r := range sensitive {
+		fmt.Printf("  %s: %s\n", r.Repository, strings.Join(r.Labels, ", "))
+	}
+}
+
+// printForkVerdicts renders the fork verdicts of a workspace as a table on stdout
+func printForkVerdicts(rows []forkRow) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Repository", "Workflow", "Verdict", "Reasons"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+	)
+	for _, r := range rows {
+		tw.Append([]string{r.Repository, r.FilePath, r.Verdict.verdict(), strings.Join(r.Verdict.Reasons, "\n")})
+	}
+	tw.Render()
+}
+
+// printImpact renders the workflows affected by a change to a shared action as a table on
+// stdout, followed by the number of repositories
+func printImpact(impacted []ImpactedWorkflow) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Repository", "Workflow", "Through", "Depth"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+	)
+	for _, w := range impacted {
+		tw.Append([]string{w.Repository, w.Workflow, w.Uses, strconv.Itoa(w.Depth)})
+	}
+	tw.Render()
+	fmt.Printf("\n%d workflows of %d repositories affected\n", len(impacted), len(impactedRepositories(impacted)))
+}
+
+// printWorkspace prints a row for each repository of the workspace
+func printWorkspace(results []WorkspaceRepo) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Repository", "Path", "Mutable refs", "Findings", "Highest severity"})
+	tw.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
+	)
+	for _, wr := range results {
+		highest := wr.Highest
+		if highest == "" {
+			highest = "-"
+		}
+		tw.Append([]string{wr.Repository, wr.Path, strconv.Itoa(wr.MutableRefs), strconv.Itoa(wr.Findings), highest})
+	}
+	tw.Render()
+
+	clean := 0
+	for _, wr := range results {
+		if wr.MutableRefs == 0 && wr.Findings == 0 {
+			clean++
+		}
+	}
+	fmt.Printf("\n%d repositories scanned, %d without issues\n", len(results), clean)
+}
+
+// printFindings renders rule findings of an inventory as a table on stdout
+func printFindings(inv *Inventory) {
+	tw := tablewriter.NewWriter(os.Stdout)
+	header := []string{"Rule", "Severity", "FilePath", "Message"}
+	colors := []tablewriter.Colors{
+		{tablewriter.Bold, tablewriter.FgRedColor},
+		{tablewriter.Bold, tablewriter.FgRedColor},
+		{tablewriter.Bold, tablewriter.FgRedColor},
+		{tablewriter.Bold, tablewriter.FgGreenColor},
+	}
+	scored := inv.scored()
+	if scored {
+		header, colors = append(header, "Score"), append(colors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor})
+	}
+	blamed := inv.blamed()
+	if blamed {
+		header, colors = append(header, "Probable owner", "Introduced in"), append(colors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor}, tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor})
+	}
+	tw.SetHeader(header)
+	tw.SetHeaderColor(colors...)
+
+	for _, ir := range inv.Records {
+		for _, f := range ir.Findings {
+			path := ir.FilePath
+			if f.Line > 0 {
+				path = fmt.Sprintf("%s:%d", path, f.Line)
+			}
+			row := []string{f.Rule, f.Severity, path, f.Message}
+			if scored {
+				row = append(row, strconv.Itoa(f.Score))
+			}
+			if blamed {
+				row = append(row, probableOwner(f.Blame), introducedIn(f.Blame))
+			}
+			tw.Append(row)
+		}
+	}
+	tw.Render()
+
+	// Suggestions are multi-line snippets, which do not fit in a table cell.
+	for _, ir := range inv.Records {
+		for _, f := range ir.Findings {
+			if f.Suggestion != "" {
+				fmt.Printf("\nSuggested for %s:%d (%s):\n%s\n", ir.FilePath, f.Line, f.Rule, f.Suggestion)
+			}
+		}
+	}
+}
+
+// addRuleFlags registers the flags enabling optional rules on a command
+func addRuleFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("check-permissions", false, "Infer the GITHUB_TOKEN permissions each job needs and report the delta versus what is granted")
+	cmd.PersistentFlags().Bool("check-deprecated", false, "Flag deprecated workflow commands, such as set-output, and actions running on Node 16 or older")
+	cmd.PersistentFlags().Bool("check-input-flow", false, "Follow untrusted data handed to composite actions and flag it when it reaches a shell, even through nested actions")
+	cmd.PersistentFlags().Bool("check-image-digests", false, "Flag digest-pinned container images no tag points to anymore")
+	cmd.PersistentFlags().Bool("actionlint", false, "Run actionlint on every workflow and include its diagnostics in the findings")
+	cmd.PersistentFlags().Bool("check-golden", false, "Flag how workflows differ from the golden template of the same name in golden.dir of the configuration, ignoring formatting and comments")
+	cmd.PersistentFlags().Bool("check-required-workflows", false, "Flag repositories without the workflows required_workflows of the configuration requires, such as CodeQL or dependency review")
+	cmd.PersistentFlags().Bool("check-codeowners", false, "Flag workflows not covered by CODEOWNERS, or not owned by one of codeowners.required_owners of the configuration")
+	cmd.PersistentFlags().Int("min-score", 0, "Only report mutable references and findings with a risk score of at least this, from 1 to 100")
+	cmd.PersistentFlags().Bool("sort-by-score", false, "Sort mutable references and findings by risk score, highest first")
+	cmd.PersistentFlags().Bool("check-namespaces", false, "Verify that actions of the orgs listed in namespaces.orgs of the configuration exist there, and flag lookalike orgs")
+	cmd.PersistentFlags().Bool("check-runners", false, "Flag jobs targeting self-hosted runner labels not listed in runners.allowed of the configuration")
+	cmd.PersistentFlags().Bool("check-timeouts", false, "Flag jobs without timeout-minutes, and steps running long-running tools without one, per timeouts of the configuration")
+	cmd.PersistentFlags().Bool("check-downloads", false, "Flag steps downloading release assets or binaries with gh release download, curl or wget without verifying a checksum or signature")
+	cmd.PersistentFlags().Bool("check-packages", false, "Flag npm, pip and go installs of build tooling at floating versions, npm install instead of npm ci, and requirement files installed without hashes, per packages of the configuration")
+	cmd.PersistentFlags().Bool("check-secrets", false, "Flag credentials written into workflows, such as GitHub tokens, AWS access keys, Slack tokens and webhooks, and private keys")
+	cmd.PersistentFlags().Bool("check-artifacts", false, "Flag uploaded artifacts likely to hold credentials, and artifacts kept longer than artifacts.max_retention_days of the configuration")
+	cmd.PersistentFlags().Bool("templates", false, "Also scan the workflows of cookiecutter and copier project templates, in directories named template or with a templated name")
+	cmd.PersistentFlags().Bool("gitlab-ci", false, "Also scan the .gitlab-ci.yml file of repositories for remote includes and images not pinned")
+	cmd.PersistentFlags().Bool("bitbucket-pipelines", false, "Also scan the bitbucket-pipelines.yml file of repositories for pipes and images not pinned to a digest")
+	cmd.PersistentFlags().Bool("circleci", false, "Also scan the .circleci/config.yml file of repositories for orbs not pinned to an exact version and Docker executors not pinned to a digest")
+	cmd.PersistentFlags().Bool("azure-pipelines", false, "Also scan the azure-pipelines.yml file of repositories, and the templates it uses, for repository resources not pinned to a commit and tasks without a version")
+	cmd.PersistentFlags().Bool("jenkinsfile", false, "Also scan the Jenkinsfile of repositories for shared libraries loaded without a version or at a branch or tag")
+	cmd.PersistentFlags().Bool("drone", false, "Also scan the .drone.yml, .woodpecker.yml and .woodpecker/ pipelines of repositories for images not pinned to a digest and privileged steps")
+	cmd.PersistentFlags().Bool("buildkite", false, "Also scan the pipelines of the .buildkite directory of repositories for plugins not pinned to a commit")
+	cmd.PersistentFlags().Bool("argo", false, "Also scan the Argo Workflows manifests of the .argo, argo and workflows directories of repositories for container images not pinned to a digest")
+	cmd.PersistentFlags().Bool("tekton", false, "Also scan the Tekton resources of the .tekton and tekton directories of repositories for bundles not pinned to a digest and cluster tasks resolved by name")
+	cmd.PersistentFlags().Bool("check-concurrency", false, "Flag deployment jobs outside of any concurrency group, and workflows listed in concurrency.cancel_in_progress of the configuration not cancelling superseded runs")
+}
+
+// rulesFromFlags returns the optional rules enabled by flags of a command
+func rulesFromFlags(cmd *cobra.Command) []Rule {
+	var rules []Rule
+	// The vendor report preset looks for every risky pattern of the workflows
+	vendor := cmd.Flag("out").Value.String() == "vendor"
+	if vendor || cmd.Flag("check-permissions").Value.String() == "true" {
+		rules = append(rules, PermissionsRule{})
+	}
+	if vendor || cmd.Flag("check-deprecated").Value.String() == "true" {
+		rules = append(rules, DeprecatedRule{})
+	}
+	if cmd.Flag("check-input-flow").Value.String() == "true" {
+		rules = append(rules, NewInputFlowRule(fetchActionFile))
+	}
+	if cmd.Flag("check-secrets").Value.String() == "true" {
+		rules = append(rules, SecretRule{})
+	}
+	if cmd.Flag("check-image-digests").Value.String() == "true" {
+		rules = append(rules, NewImageDigestRule(newRegistryClient(newCloudCredentials().credentials), defaultMaxImageTags))
+	}
+	if cmd.Flag("check-downloads").Value.String() == "true" {
+		rules = append(rules, DownloadRule{})
+	}
+	return rules
+}
+
+// fixersFromFlags returns the fixers selected by flags of a command, exiting if there is none
+func fixersFromFlags(cmd *cobra.Command, cfg *Config) []Fixer {
+	var fixers []Fixer
+	// Restoring a template replaces the whole workflow, so it comes before other fixes.
+	if cmd.Flag("golden").Value.String() == "true" {
+		if cfg.Golden.Dir == "" {
+			slog.Error("--golden needs golden.dir in the configuration file")
+			os.Exit(1)
+		}
+		fixer, err := NewGoldenFixer(expandHome(cfg.Golden.Dir))
+		if err != nil {
+			slog.Error("problem while reading the golden workflows", "dir", cfg.Golden.Dir, "err", err)
+			os.Exit(1)
+		}
+		fixers = append(fixers, fixer)
+	}
+	if cmd.Flag("permissions").Value.String() == "true" {
+		fixers = append(fixers, PermissionsRule{})
+	}
+	if cmd.Flag("mirrors").Value.String() == "true" {
+		if len(cfg.Fix.Mirrors) == 0 {
+			slog.Error("--mirrors needs fix.mirrors in the configuration file")
+			os.Exit(1)
+		}
+		fixers = append(fixers, MirrorFixer{Mirrors: cfg.Fix.Mirrors})
+	}
+	// Pins come after mirrors, so mirrored actions are pinned to commits of their mirror.
+	if cmd.Flag("pins").Value.String() == "true" {
+		fixers = append(fixers, PinFixer{Resolver: newCachingResolver(SHAResolver{})})
+	}
+	if cmd.Flag("orbs").Value.String() == "true" {
+		fixers = append(fixers, NewOrbFixer())
+	}
+	if cmd.Flag("buildkite-plugins").Value.String() == "true" {
+		fixers = append(fixers, BuildkitePluginFixer{Resolver: SHAResolver{}})
+	}
+	if len(fixers) == 0 {
+		slog.Error("nothing to fix. Please select at least one fix. Ex: --pins")
+		os.Exit(1)
+	}
+	return fixers
+}
+
+// scannerFromFlags configures a scanner from the --out and --config flags of a command
+func scannerFromFlags(cmd *cobra.Command, rules []Rule) Scanner {
+	cfg, err := loadConfig(cmd.Flag("config").Value.String())
+	if err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+
+	if cmd.Flag("check-namespaces").Value.String() == "true" {
+		if len(cfg.Namespaces.Orgs) == 0 {
+			slog.Error("--check-namespaces needs namespaces.orgs in the configuration file")
+			os.Exit(1)
+		}
+		rules = append(rules, NewNamespaceRule(cfg.Namespaces.Orgs, repoOwner))
+	}
+	if cmd.Flag("check-runners").Value.String() == "true" {
+		if len(cfg.Runners.Allowed) == 0 {
+			slog.Error("--check-runners needs runners.allowed in the configuration file")
+			os.Exit(1)
+		}
+		rules = append(rules, RunnerRule{Policy: cfg.Runners})
+	}
+	if cmd.Flag("check-concurrency").Value.String() == "true" {
+		rules = append(rules, ConcurrencyRule{Policy: cfg.Concurrency})
+	}
+	if cmd.Flag("check-timeouts").Value.String() == "true" {
+		rules = append(rules, NewTimeoutRule(cfg.Timeouts))
+	}
+	if cmd.Flag("check-artifacts").Value.String() == "true" {
+		rules = append(rules, ArtifactRule{Policy: cfg.Artifacts})
+	}
+	if cmd.Flag("check-packages").Value.String() == "true" {
+		if err := checkPackagesConfig(cfg.Packages); err != nil {
+			slog.Error("problem while reading the configuration", "err", err)
+			os.Exit(1)
+		}
+		rules = append(rules, PackageRule{Policy: cfg.Packages})
+	}
+	if cmd.Flag("check-golden").Value.String() == "true" {
+		if cfg.Golden.Dir == "" {
+			slog.Error("--check-golden needs golden.dir in the configuration file")
+			os.Exit(1)
+		}
+		rule, err := NewGoldenRule(expandHome(cfg.Golden.Dir))
+		if err != nil {
+			slog.Error("problem while reading the golden workflows", "dir", cfg.Golden.Dir, "err", err)
+			os.Exit(1)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := checkCriticality(cfg.Criticality, cfg.FailOn); err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := checkSeverityMapping(cfg.Severities); err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := checkSeverityOverrides(cfg.SeverityOverrides); err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := cfg.Paths.check(); err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := cfg.Policy.check(); err != nil {
+		slog.Error("problem while reading the configuration", "err", err)
+		os.Exit(1)
+	}
+	if len(cfg.Policy.Banned) > 0 {
+		rules = append(rules, BannedActionRule{Banned: cfg.Policy.Banned})
+	}
+	var risk *RiskModel
+	minScore, _ := cmd.Flags().GetInt("min-score")
+	sortByScore := cmd.Flag("sort-by-score").Value.String() == "true"
+	if minScore > 0 || sortByScore {
+		if risk, err = NewRiskModel(cfg.Criticality, lookupRepoActivity, time.Now()); err != nil {
+			slog.Error("problem while reading the configuration", "err", err)
+			os.Exit(1)
+		}
+		risk.MinScore, risk.SortByScore = minScore, sortByScore
+	}
+
+	var pipelines []PipelineChecker
+	if cmd.Flag("gitlab-ci").Value.String() == "true" {
+		pipelines = append(pipelines, GitLabCIChecker{})
+	}
+	if cmd.Flag("bitbucket-pipelines").Value.String() == "true" {
+		pipelines = append(pipelines, BitbucketPipelinesChecker{})
+	}
+	if cmd.Flag("circleci").Value.String() == "true" {
+		pipelines = append(pipelines, CircleCIChecker{})
+	}
+	if cmd.Flag("azure-pipelines").Value.String() == "true" {
+		pipelines = append(pipelines, AzurePipelinesChecker{})
+	}
+	if cmd.Flag("jenkinsfile").Value.String() == "true" {
+		pipelines = append(pipelines, JenkinsfileChecker{})
+	}
+	if cmd.Flag("drone").Value.String() == "true" {
+		pipelines = append(pipelines, DroneChecker{}, WoodpeckerChecker{})
+	}
+	if cmd.Flag("buildkite").Value.String() == "true" {
+		pipelines = append(pipelines, BuildkiteChecker{})
+	}
+	if cmd.Flag("argo").Value.String() == "true" {
+		for _, dir := range argoDirs {
+			pipelines = append(pipelines, ArgoChecker{Dir: dir})
+		}
+	}
+	if cmd.Flag("tekton").Value.String() == "true" {
+		for _, dir := range tektonDirs {
+			pipelines = append(pipelines, TektonChecker{Dir: dir})
+		}
+	}
+
+	var required []RequiredWorkflow
+	if cmd.Flag("check-required-workflows").Value.String() == "true" {
+		if len(cfg.RequiredWorkflows) == 0 {
+			slog.Error("--check-required-workflows needs required_workflows in the configuration file")
+			os.Exit(1)
+		}
+		for _, r := range cfg.RequiredWorkflows {
+			r, err := r.loadTemplate()
+			if err != nil {
+				slog.Error("problem while reading the template of a required workflow", "workflow", r.Name, "err", err)
+				os.Exit(1)
+			}
+			required = append(required, r)
+		}
+	}
+
+	var quickFixes Resolver
+	if cmd.Flag("quick-fixes").Value.String() == "true" {
+		quickFixes = newCachingResolver(SHAResolver{})
+	}
+
+	var stream *ndjsonStream
+	if cmd.Flag("out").Value.String() == "ndjson" {
+		// Issues are streamed as they are found, before the whole inventory is ranked or blamed.
+		for _, flag := range []string{"blame", "min-score", "sort-by-score"} {
+			if f := cmd.Flag(flag); f != nil && f.Changed {
+				slog.Error("--" + flag + " works on the whole inventory and cannot be used with --out ndjson")
+				os.Exit(1)
+			}
+		}
+		stream = newNDJSONStream(os.Stdout)
+	}
+
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	triggers, _ := cmd.Flags().GetStringSlice("trigger")
+	return Scanner{
+		FileScanner: GitHubWorkFlowScanner{},
+		Rules:       rules,
+		Pipelines:   pipelines,
+		// Trigger privileges are a signal of the risk score, and the SBOM lists the actions
+		// and images of every workflow
+		Profile:           reportFormats[cmd.Flag("out").Value.String()] || cmd.Flag("out").Value.String() == "cyclonedx" || cmd.Flag("badge").Value.String() != "" || risk != nil,
+		Components:        cfg.Components,
+		CheckCodeowners:   cmd.Flag("check-codeowners").Value.String() == "true",
+		Codeowners:        cfg.Codeowners,
+		Suppressions:      cfg.Suppressions,
+		Risk:              risk,
+		Criticality:       cfg.Criticality,
+		FailOn:            cfg.FailOn,
+		Severities:        cfg.Severities,
+		QuickFixes:        quickFixes,
+		Templates:         cmd.Flag("templates").Value.String() == "true",
+		RequiredWorkflows: required,
+		Concurrency:       concurrency,
+		Triggers:          triggers,
+		Stream:            stream,
+		RevealSecrets:     cmd.Flag("reveal-secrets").Value.String() == "true",
+		RecurseSubmodules: cmd.Flag("recurse-submodules").Value.String() == "true",
+		Paths:             cfg.Paths,
+		SeverityOverrides: cfg.SeverityOverrides,
+		Policy:            cfg.Policy,
+	}
+}
+
+// exportFormats are the formats exportInventory writes
+var exportFormats = []string{"json", "csv", "markdown", "html", "vendor", "sarif", "cyclonedx", "ndjson"}
+
+// exportInventory writes the inventory to a file in the format given to --out, with
+// severities mapped to the levels of that format, its badge to the file given to --badge
+// and, with --summary, to the job summary of GitHub Actions. In GitHub Actions, the counts
+// of issues and the report are also outputs of the step.
+func exportInventory(cmd *cobra.Command, inv *Inventory, sc *Scanner) {
+	if cmd.Flag("summary").Value.String() == "true" {
+		if err := appendStepSummary(inv); err != nil {
+			slog.Warn("could not write the job summary", "err", err)
+		}
+	}
+	if badge := cmd.Flag("badge").Value.String(); badge != "" {
+		if err := writeBadge(inv, badge); err != nil {
+			slog.Error("could not write the badge", "err", err)
+		}
+	}
+
+	inv.ScannedAt = time.Now()
+	format := cmd.Flag("out").Value.String()
+	if history, _ := cmd.Flags().GetStringSlice("history"); len(history) > 0 && format == "html" {
+		var err error
+		if inv.History, err = loadHistory(history); err != nil {
+			slog.Error("could not read the history of scans", "err", err)
+		}
+	}
+	var report string
+	switch format {
+	case "":
+	case "json":
+		report = "findings.json"
+		writeToJSON(inv)
+	case "csv":
+		report = "findings.csv"
+		WriteToCSV(inv)
+	case "markdown":
+		report = "findings.md"
+		writeToReport(inv, report, writeMarkdownReport)
+	case "html":
+		report = "findings.html"
+		writeToReport(inv, report, writeHTMLReport)
+	case "vendor":
+		report = "vendor-report.md"
+		writeToReport(inv, report, writeVendorReport)
+	case "sarif":
+		report = "findings.sarif"
+		writeToReport(inv, report, func(w io.Writer, inv *Inventory) error { return writeSARIFReport(w, inv, sc.Severities) })
+	case "cyclonedx":
+		report = "sbom.cdx.json"
+		writeToReport(inv, report, writeCycloneDX)
+	case "ndjson":
+		// Scans of many repositories streamed most issues already.
+		if sc.Stream == nil {
+			sc.Stream = newNDJSONStream(os.Stdout)
+		}
+		if err := sc.Stream.write(inv.Records); err != nil {
+			slog.Error("could not write the issues", "err", err)
+		}
+	default:
+		slog.Error("The given value to --out flag is invalid. Valid values are json, csv, markdown, html, vendor, sarif, cyclonedx, ndjson.", "value", format)
+	}
+
+	if err := appendStepOutputs(stepOutputs(inv, report, sc.FailOn)); err != nil {
+		slog.Warn("could not set the outputs of the step", "err", err)
+	}
+}
+
 func main() {
 	// list table configuration
 	tw := tablewriter.NewWriter(os.Stdout)
@@ -66,10 +856,8 @@ func main() {
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Find all GitHub actions with mutable references in a workspace. The workspace should have cloned Git repositories.`),
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			sc := Scanner{
-				VCS:         GitHubVCS{},
-				FileScanner: GitHubWorkFlowScanner{},
-			}
+			sc := scannerFromFlags(cmd, rulesFromFlags(cmd))
+			sc.VCS = GitHubVCS{}
 
 			root_path_flag := cmd.Flag("root")
 			var ho bool
@@ -79,38 +867,46 @@ func main() {
 			} else {
 				ho = false
 			}
+			sc.AllBranches = cmd.Flag("all-branches").Value.String() == "true"
+			if sc.AllBranches && ho {
+				slog.Error("--all-branches and --head-only cannot be used together")
+				os.Exit(1)
+			}
 
-			// Regex to find whether workflow has reference to vXY, main, dev or master
-			regex, _ := regexp.Compile(`(\w*-?\w*)(\/)(\w+-?\w+)@((v\w+)|main|dev|master)`)
-			inv, err := sc.ScanRepos(root_path_flag.Value.String(), regex, ho)
+			inv, err := sc.ScanRepos(root_path_flag.Value.String(), mutableRefRegex, ho)
 
 			if err != nil {
 				log.Fatal(err.Error())
 			}
 
-			out_fmt_flag := cmd.Flag("out")
-			out_fmt := out_fmt_flag.Value.String()
-
-			switch out_fmt {
-			case "json":
-				writeToJSON(inv)
-				break
-			case "csv":
-				WriteToCSV(inv)
-				break
-			default:
-				slog.Error("The given value to --out flag is invalid. Valid values are json, csv.", "value", out_fmt)
+			if cmd.Flag("blame").Value.String() == "true" {
+				blameInventory(inv)
 			}
+			correlateFromFlags(cmd, inv, originRepo)
+			sc.Risk.Rank(inv)
+			exportInventory(cmd, inv, &sc)
 		},
 	}
 
 	var cmdLookup = &cobra.Command{
 		Use:   "lookup",
-		Short: "Look up the immutable commit-SHA of a given GitHub 'action@version'. Ex: actions/checkout@v4",
+		Short: "Look up the immutable commit-SHA of a given GitHub 'action@version', or the tags of an 'image@digest'. Ex: actions/checkout@v4",
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Look up the immutable commit-SHA of a given action & version string. Ex: actions/checkout@v4`),
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if args[0] != "" {
+			if isImageRef(args[0]) {
+				tags, err := reverseLookupImage(newRegistryClient(newCloudCredentials().credentials), args[0], 0)
+				if err != nil {
+					slog.Error("problem while looking up tags of the image", "image", args[0], "err", err)
+					os.Exit(1)
+				}
+				if len(tags) == 0 {
+					fmt.Println("No tag points to this digest anymore")
+				}
+				for _, tag := range tags {
+					fmt.Println(tag)
+				}
+			} else if args[0] != "" {
 				s := SHAResolver{}
 				sha, err := s.resolve(args[0])
 				if err != nil {
@@ -124,8 +920,10 @@ func main() {
 		},
 	}
 	cmdFind.PersistentFlags().String("root", ".", "Absolute path of root directory of GitHub repositories")
-	cmdFind.PersistentFlags().String("out", "json", "Output format of findings. Available options: json, csv")
+	cmdFind.PersistentFlags().String("out", "json", "Output format of findings. Available options: json, csv, markdown, html, vendor, sarif, cyclonedx, ndjson")
+	cmdFind.PersistentFlags().StringSlice("history", nil, "Earlier findings.json files, globs allowed, to chart trends of findings from in the HTML report")
 	cmdFind.PersistentFlags().Bool("head-only", false, "Limit scan only to HEAD (Activated branch)")
+	cmdFind.PersistentFlags().Bool("all-branches", false, "Read every branch from the git objects, without checking it out, and report findings shared by branches once")
 
 	var cmdList = &cobra.Command{
 		Use:   "list",
@@ -168,64 +966,1000 @@ func main() {
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Audit the actions and raise error if any mutable references found. Good used with Ci/CD pipelines.`),
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			regex, _ := regexp.Compile(`(\w*-?\w*)(\/)(\w+-?\w+)@((v\w+)|main|dev|master)`)
-			inv, err := AuditRepository(regex)
-
-			if err != nil {
-				fmt.Println("Not a git repository. Skipping checks!")
+			if cmd.Flag("tags").Value.String() == "true" {
+				auditTagsFromFlags(cmd)
 				return
 			}
+			rules := rulesFromFlags(cmd)
+			if cmd.Flag("check-environments").Value.String() == "true" {
+				remote, err := GetRemoteURL(".", "origin")
+				if err != nil {
+					slog.Error("--check-environments needs an origin remote pointing to GitHub", "err", err)
+					os.Exit(1)
+				}
+				rule, err := environmentRuleFor(remote)
+				if err != nil {
+					slog.Error("problem while fetching environments of the repository", "err", err)
+					os.Exit(1)
+				}
+				rules = append(rules, rule)
+			}
 
-			if len(inv.Records) > 0 {
-				tw.SetHeader([]string{
-					"Match",
-					"FilePath",
-					"Replace with SHA",
-				})
-				tw.SetHeaderColor(
-					tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
-					tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor},
-					tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
-				)
+			sc := scannerFromFlags(cmd, rules)
+			sc.Ref = cmd.Flag("ref").Value.String()
+			if sc.Ref != "" && cmd.Flag("blame").Value.String() == "true" {
+				slog.Error("--blame attributes the lines of the worktree and cannot be used with --ref")
+				os.Exit(1)
+			}
+			inv, err := sc.AuditRepository(mutableRefRegex)
 
-				s := SHAResolver{}
-				visited := map[string]bool{}
-
-				for _, ir := range inv.Records {
-					for _, mat := range ir.Matches {
-						hashKey := mat + ir.FilePath
-						if visited[hashKey] {
-							// already reported, skip to next
-							continue
-						}
-						sha, err := s.resolve(mat)
-						if err != nil {
-							sha = "N/A"
-						}
-						tw.Append([]string{
-							mat,
-							ir.FilePath,
-							sha,
-						})
-						visited[hashKey] = true
-					}
-				}
-				fmt.Println("Mutable references found in your GitHub actions. Please replace them to secure your CI from supply chain attacks.")
-				tw.Render()
-				shouldRaise := cmd.Flag("raise-error")
-				if shouldRaise.Value.String() == "true" {
+			if err != nil {
+				if sc.Ref != "" && IsGitRepo(".") {
+					// A ref that cannot be read must not let a CI check pass.
+					slog.Error("problem while reading the ref", "ref", sc.Ref, "err", err)
 					os.Exit(1)
 				}
+				fmt.Println("Not a git repository. Skipping checks!")
+				return
+			}
 
-			} else {
+			if cmd.Flag("blame").Value.String() == "true" {
+				blameInventory(inv)
+			}
+			correlateFromFlags(cmd, inv, originRepo)
+			sc.Risk.Rank(inv)
+			exportInventory(cmd, inv, &sc)
+			// NDJSON on stdout is not mixed with tables.
+			if sc.Stream == nil && !reportInventory(tw, inv) {
 				fmt.Println("No mutable references found. Good job!")
 			}
+			shouldRaise := cmd.Flag("raise-error")
+			if shouldRaise.Value.String() == "true" && inv.fails(sc.FailOn) {
+				os.Exit(1)
+			}
+
+		},
+	}
+	cmdAudit.PersistentFlags().Bool("raise-error", false, "Raise error on any matches, or on those at the fail_on severity of the repository tier of the configuration. Useful for interrupting CI pipelines")
+	cmdAudit.PersistentFlags().String("out", "", "Also export findings to a file. Available options: json, csv, markdown, html, vendor, sarif, cyclonedx, ndjson")
+	cmdAudit.PersistentFlags().StringSlice("history", nil, "Earlier findings.json files, globs allowed, to chart trends of findings from in the HTML report")
+	cmdAudit.PersistentFlags().Bool("tags", false, "Scan the workflows of every tag and tell in which tag each mutable reference first appeared and whether HEAD still has it. With --raise-error, fail when HEAD does")
+	cmdAudit.PersistentFlags().String("ref", "", "Audit the workflows as committed at this branch, tag or commit SHA, read from the git objects without touching the worktree")
+	cmdAudit.PersistentFlags().Bool("check-environments", false, "Flag deployments to environments without required reviewers or wait timer. Needs GITHUB_TOKEN")
 
+	var cmdScan = &cobra.Command{
+		Use:   "scan",
+		Short: "Scan a GitHub repository by URL, or every repository of an organization, without cloning them. Ex: https://github.com/owner/repo",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Scan the workflows of a GitHub repository through the API, without git or a local clone. Useful for quick assessments of open-source projects. Ex: https://github.com/owner/repo or https://github.com/owner/repo/tree/branch. Pass https://gist.github.com/user to scan the YAML files of a user's gists. With --org, the default branch of every repository of the organization is scanned instead, and the findings are aggregated into one report. With --clone, the repository, or every repository of the organization, is cloned into a temporary directory instead, which is removed after the scan, so repositories of other git hosts can be scanned too without API calls.`),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flag("org").Value.String() != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if org := cmd.Flag("org").Value.String(); org != "" {
+				scanOrgFromFlags(cmd, tw, org)
+				return
+			}
+			rules := rulesFromFlags(cmd)
+			if cmd.Flag("check-environments").Value.String() == "true" {
+				rule, err := environmentRuleFor(args[0])
+				if err != nil {
+					slog.Error("problem while fetching environments of the repository", "err", err)
+					os.Exit(1)
+				}
+				rules = append(rules, rule)
+			}
+
+			sc := scannerFromFlags(cmd, rules)
+			var inv *Inventory
+			var err error
+			if user, ok := parseGistURL(args[0]); ok {
+				inv, err = ScanGists(user, mutableRefRegex)
+			} else if cmd.Flag("clone").Value.String() == "true" {
+				inv, err = sc.ScanClone(args[0], mutableRefRegex)
+			} else {
+				inv, err = sc.ScanRemoteRepository(args[0], mutableRefRegex)
+			}
+			if err != nil {
+				slog.Error("problem while scanning the repository. Please check the URL again.", "url", args[0], "err", err)
+				os.Exit(1)
+			}
+
+			if cmd.Flag("wiki").Value.String() == "true" {
+				owner, name, _, err := parseGitHubURL(args[0])
+				if err != nil {
+					slog.Error("--wiki needs a GitHub repository URL", "url", args[0])
+					os.Exit(1)
+				}
+				wiki, err := ScanWiki(wikiCloneURL(owner, name), fmt.Sprintf("%s/%s", owner, name), mutableRefRegex)
+				if err != nil {
+					slog.Warn("could not scan the wiki. It might not be enabled for the repository.", "err", err)
+				} else {
+					inv.Records = append(inv.Records, wiki.Records...)
+				}
+			}
+			if owner, name, _, err := parseGitHubURL(args[0]); err == nil {
+				correlateFromFlags(cmd, inv, func(string) (string, string, error) { return owner, name, nil })
+			}
+			sc.Risk.Rank(inv)
+
+			if sc.Stream == nil && !reportInventory(tw, inv) {
+				fmt.Println("No mutable references found. Good job!")
+			}
+
+			exportInventory(cmd, inv, &sc)
+
+			shouldRaise := cmd.Flag("raise-error")
+			if shouldRaise.Value.String() == "true" && inv.fails(sc.FailOn) {
+				os.Exit(1)
+			}
+		},
+	}
+	cmdScan.PersistentFlags().String("org", "", "Scan every repository of a GitHub organization instead of a single URL")
+	cmdScan.PersistentFlags().Bool("include-archived", false, "With --org, also scan archived repositories")
+	cmdScan.PersistentFlags().StringSlice("visibility", nil, "With --org, only scan repositories of these visibilities. Ex: public,internal")
+	cmdScan.PersistentFlags().Bool("check-environments", false, "Flag deployments to environments without required reviewers or wait timer. Needs GITHUB_TOKEN")
+	cmdScan.PersistentFlags().Bool("clone", false, "Clone the repository, or with --org every repository, into a temporary directory and scan the clone instead of reading it through the API, for any git host. Clones are shallow and, with the git binary, fetch the workflows and pipeline files only. Removed after the scan")
+	cmdScan.PersistentFlags().Bool("wiki", false, "Also scan YAML files and YAML code blocks of the repository's wiki")
+	cmdScan.PersistentFlags().String("out", "", "Also export findings to a file. Available options: json, csv, markdown, html, vendor, sarif, cyclonedx, ndjson")
+	cmdScan.PersistentFlags().StringSlice("history", nil, "Earlier findings.json files, globs allowed, to chart trends of findings from in the HTML report")
+	cmdScan.PersistentFlags().Bool("raise-error", false, "Raise error on any matches, or on those at the fail_on severity of the repository tier of the configuration. Useful for interrupting CI pipelines")
+
+	var cmdFix = &cobra.Command{
+		Use:   "fix",
+		Short: "Fix issues in the workflows of a given Git repository. Must run from a Git repository",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Rewrite workflow files in place to resolve issues. Use --dry-run to print a unified diff of the changes instead, which can be reviewed and applied with git apply.`),
+		Args:  cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			fixers := fixersFromFlags(cmd, cfg)
+
+			// With --dry-run, stdout only gets the diff, so it can be piped into git apply.
+			dryRun := cmd.Flag("dry-run").Value.String() == "true"
+			status := io.Writer(os.Stdout)
+			if dryRun {
+				status = os.Stderr
+			}
+
+			if !IsGitRepo(".") {
+				fmt.Fprintln(status, "Not a git repository. Skipping fixes!")
+				return
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				slog.Error("could not determine the current directory", "err", err)
+				os.Exit(1)
+			}
+
+			results, err := FixWorkflows(root, fixers, dryRun, os.Stdout)
+			if err != nil {
+				slog.Error("problem while fixing workflows", "err", err)
+				os.Exit(1)
+			}
+
+			if len(results) == 0 {
+				fmt.Fprintln(status, "Nothing to fix. Good job!")
+				return
+			}
+			if !dryRun {
+				for _, r := range results {
+					fmt.Printf("Fixed %s\n", r.Path)
+				}
+			}
+
+			createPR := cmd.Flag("pr").Value.String() == "true" || cmd.Flag("create-pr").Value.String() == "true"
+			if createPR && !dryRun {
+				remote, err := GetRemoteURL(root, "origin")
+				if err != nil {
+					slog.Error("--pr needs an origin remote pointing to GitHub", "err", err)
+					os.Exit(1)
+				}
+				owner, name, err := parseGitHubRemote(remote)
+				if err != nil {
+					slog.Error("--pr needs an origin remote pointing to GitHub", "err", err)
+					os.Exit(1)
+				}
+
+				pr, err := openFixPR(root, results, FixPROptions{
+					Owner:  owner,
+					Name:   name,
+					Branch: cmd.Flag("branch").Value.String(),
+					Title:  "Secure GitHub workflows",
+				})
+				if err != nil {
+					slog.Error("problem while opening the pull request", "err", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Opened %s\n", pr.HTMLURL)
+
+				autoMerge, merge := cmd.Flag("auto-merge").Value.String() == "true", cmd.Flag("merge").Value.String() == "true"
+				if !autoMerge && !merge {
+					return
+				}
+				owners := cfg.Fix.AutoMergeOwners
+				if cmd.Flag("auto-merge-owners").Changed {
+					owners, _ = cmd.Flags().GetStringSlice("auto-merge-owners")
+				}
+				if !autoMergeEligible(results, owners) {
+					fmt.Println("Not merging automatically: the fixes change more than pins of allowlisted owners")
+					return
+				}
+
+				status := "Auto-merge enabled for"
+				if autoMerge {
+					err = enableAutoMerge(pr)
+				} else {
+					timeout, _ := cmd.Flags().GetDuration("merge-timeout")
+					err = mergeWhenGreen(owner, name, pr, timeout)
+					status = "Merged"
+				}
+				if err != nil {
+					slog.Error("problem while merging the pull request", "err", err)
+					os.Exit(1)
+				}
+				fmt.Printf("%s %s\n", status, pr.HTMLURL)
+			}
+		},
+	}
+	cmdFix.PersistentFlags().Bool("golden", false, "Restore workflows that drifted from the golden template of the same name in golden.dir of the configuration, keeping their override blocks")
+	cmdFix.PersistentFlags().Bool("pins", false, "Pin the uses: references of workflows to the commit SHA their ref points to, keeping the ref in a comment")
+	cmdFix.PersistentFlags().Bool("permissions", false, "Insert or tighten permissions blocks to what each job needs")
+	cmdFix.PersistentFlags().Bool("mirrors", false, "Take actions from the internal mirrors given by fix.mirrors in the configuration file")
+	cmdFix.PersistentFlags().Bool("orbs", false, "Pin the orbs of .circleci/config.yml to the latest exact version of the range they follow, from the CircleCI registry")
+	cmdFix.PersistentFlags().Bool("buildkite-plugins", false, "Pin the plugins of .buildkite/pipeline.yml hosted on GitHub to the commit their ref points to")
+	cmdFix.PersistentFlags().Bool("dry-run", false, "Print a unified diff of the fixes instead of writing them")
+	cmdFix.PersistentFlags().Bool("pr", false, "Commit the fixes to a new branch, push it to origin and open a pull request. Needs GITHUB_TOKEN")
+	cmdFix.PersistentFlags().Bool("create-pr", false, "Same as --pr")
+	cmdFix.PersistentFlags().String("branch", defaultFixBranch, "Branch the fixes are pushed to with --pr")
+	cmdFix.PersistentFlags().Bool("auto-merge", false, "With --pr, enable auto-merge if the fixes only pin actions of allowlisted owners")
+	cmdFix.PersistentFlags().Bool("merge", false, "With --pr, merge once checks pass if the fixes only pin actions of allowlisted owners")
+	cmdFix.PersistentFlags().Duration("merge-timeout", 30*time.Minute, "How long --merge waits for checks to pass")
+	cmdFix.PersistentFlags().StringSlice("auto-merge-owners", nil, "Owners of actions whose pins may be merged automatically. Ex: actions,github")
+	cmdFix.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+
+	var cmdOrg = &cobra.Command{
+		Use:   "org",
+		Short: "Run campaigns over every repository cloned in a workspace, and graph how they call each other",
+	}
+	var cmdOrgFix = &cobra.Command{
+		Use:   "fix",
+		Short: "Fix the workflows of every repository of a workspace and open a pull request in each. Needs GITHUB_TOKEN",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Fix the workflows of every repository cloned in a workspace, push the fixes to a branch of each and open a pull request. Repositories that cannot take fixes unattended, such as archived ones or those with uncommitted changes, are skipped. With --plan, nothing is written or pushed: the blast radius of the campaign is printed instead.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			opts := CampaignOptions{Fixers: fixersFromFlags(cmd, cfg), Branch: cmd.Flag("branch").Value.String()}
+			opts.Workers, _ = cmd.Flags().GetInt("workers")
+			opts.Merge = cmd.Flag("merge").Value.String() == "true"
+			opts.MergeTimeout, _ = cmd.Flags().GetDuration("merge-timeout")
+			opts.MergeOwners = cfg.Fix.AutoMergeOwners
+			if cmd.Flag("auto-merge-owners").Changed {
+				opts.MergeOwners, _ = cmd.Flags().GetStringSlice("auto-merge-owners")
+			}
+
+			status, err := loadCampaignStatus(cmd.Flag("status").Value.String(), os.Stdout)
+			if err != nil {
+				slog.Error("problem while reading the campaign status", "err", err)
+				os.Exit(1)
+			}
+			if cmd.Flag("retry-failed").Value.String() == "true" {
+				opts.Only = status.Failed()
+				if len(opts.Only) == 0 {
+					fmt.Println("No failed repositories to retry")
+					return
+				}
+			}
+
+			plan, err := planCampaign(cmd.Flag("root").Value.String(), opts, lookupRepoState)
+			if err != nil {
+				slog.Error("problem while planning the campaign", "err", err)
+				os.Exit(1)
+			}
+			writeCampaignPlan(os.Stdout, plan)
+			if cmd.Flag("plan").Value.String() == "true" {
+				return
+			}
+			fmt.Println()
+			runCampaign(plan, opts, status)
+			fmt.Println()
+			printCampaignStatus(status)
+		},
+	}
+	cmdOrgFix.PersistentFlags().String("root", ".", "Workspace holding the cloned repositories")
+	cmdOrgFix.PersistentFlags().Bool("plan", false, "Only print how many repositories, pull requests, files and API calls the campaign involves, and which repositories need manual intervention")
+	cmdOrgFix.PersistentFlags().Bool("golden", false, "Restore workflows that drifted from the golden template of the same name in golden.dir of the configuration, keeping their override blocks")
+	cmdOrgFix.PersistentFlags().Bool("pins", false, "Pin the uses: references of workflows to the commit SHA their ref points to, keeping the ref in a comment")
+	cmdOrgFix.PersistentFlags().Bool("permissions", false, "Insert or tighten permissions blocks to what each job needs")
+	cmdOrgFix.PersistentFlags().Bool("mirrors", false, "Take actions from the internal mirrors given by fix.mirrors in the configuration file")
+	cmdOrgFix.PersistentFlags().Bool("orbs", false, "Pin the orbs of .circleci/config.yml to the latest exact version of the range they follow, from the CircleCI registry")
+	cmdOrgFix.PersistentFlags().Bool("buildkite-plugins", false, "Pin the plugins of .buildkite/pipeline.yml hosted on GitHub to the commit their ref points to")
+	cmdOrgFix.PersistentFlags().String("branch", defaultFixBranch, "Branch the fixes are pushed to in each repository")
+	cmdOrgFix.PersistentFlags().String("status", defaultStatusFile, "File the status of each repository is saved to as the campaign runs")
+	cmdOrgFix.PersistentFlags().Bool("retry-failed", false, "Only retry the repositories the status file records as failed")
+	cmdOrgFix.PersistentFlags().Int("workers", defaultCampaignWorkers, "How many repositories are fixed at once")
+	cmdOrgFix.PersistentFlags().Bool("merge", false, "Merge each pull request once checks pass if the fixes only pin actions of allowlisted owners")
+	cmdOrgFix.PersistentFlags().Duration("merge-timeout", 30*time.Minute, "How long --merge waits for checks to pass")
+	cmdOrgFix.PersistentFlags().StringSlice("auto-merge-owners", nil, "Owners of actions whose pins may be merged automatically. Ex: actions,github")
+	cmdOrgFix.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+	cmdOrg.AddCommand(cmdOrgFix)
+
+	var cmdOrgGraph = &cobra.Command{
+		Use:   "graph",
+		Short: "Graph which repositories of a workspace call the reusable workflows and actions of which others",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Build the graph of the reusable workflows and actions of the organization called by the workflows of every repository cloned in a workspace, and print it as DOT, JSON, Mermaid or a self-contained interactive HTML page. Edges and called repositories are colored by whether their references are pinned to a commit SHA, and with --health, repositories are filled by their maintenance state. Repositories are identified by their origin remote, and only references to repositories of the same owners are kept, so the graph tells the blast radius of a change to a shared workflow. Ex: scharf org graph --root ws | dot -Tsvg > calls.svg`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			format := cmd.Flag("out").Value.String()
+			if format != "dot" && format != "json" && format != "mermaid" && format != "html" {
+				slog.Error("unknown output format. Available options: dot, json, mermaid, html", "out", format)
+				os.Exit(1)
+			}
+			sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: GitHubVCS{}, Profile: true}
+			inv, err := sc.ScanRepos(cmd.Flag("root").Value.String(), mutableRefRegex, true)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			g := buildCallGraph(inv, originRepo)
+			if cmd.Flag("health").Value.String() == "true" {
+				g.lookupMaintenance(lookupRepoActivity, time.Now())
+			}
+			write := map[string]func(io.Writer) error{"dot": g.writeDOT, "json": g.writeJSON, "mermaid": g.writeMermaid, "html": g.writeHTML}[format]
+			if err := write(os.Stdout); err != nil {
+				slog.Error("problem while writing the graph", "err", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmdOrgGraph.PersistentFlags().String("root", ".", "Workspace holding the cloned repositories")
+	cmdOrgGraph.PersistentFlags().String("out", "dot", "Output format of the graph. Available options: dot, json, mermaid, html")
+	cmdOrgGraph.PersistentFlags().Bool("health", false, "Color called repositories by maintenance state, looked up through the API: active, stale, archived or missing")
+	cmdOrg.AddCommand(cmdOrgGraph)
+
+	var cmdWorkspace = &cobra.Command{
+		Use:   "workspace [dir...]",
+		Short: "Discover the git repositories below parent directories, such as ~/src, and scan them all with results per repository",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Discover the local clones anywhere below the given directories, or workspace.roots of the configuration, and scan the checked out branch of each with the rules enabled by flags. Branches are never switched. Prints a row per repository, those without issues included, or the records of each with --out json. Directories are searched up to --depth levels deep, skipping hidden ones, node_modules, vendor and workspace.exclude of the configuration.`),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			roots := args
+			if len(roots) == 0 {
+				roots = cfg.Workspace.Roots
+			}
+			if len(roots) == 0 {
+				roots = []string{"."}
+			}
+			for i, root := range roots {
+				roots[i] = expandHome(root)
+			}
+			depth := cfg.Workspace.MaxDepth
+			if cmd.Flag("depth").Changed {
+				depth, _ = cmd.Flags().GetInt("depth")
+			}
+
+			sc := scannerFromFlags(cmd, rulesFromFlags(cmd))
+			sc.VCS = WorkspaceVCS{MaxDepth: depth, Exclude: cfg.Workspace.Exclude}
+			results, err := scanWorkspace(sc, roots, mutableRefRegex)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			if cmd.Flag("out").Value.String() == "json" {
+				data, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					slog.Error("problem while writing the results", "err", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+				return
+			}
+			if len(results) == 0 {
+				fmt.Println("No git repositories found below", strings.Join(roots, ", "))
+				return
+			}
+			printWorkspace(results)
+		},
+	}
+	cmdWorkspace.PersistentFlags().String("out", "table", "Output format. Available options: table, json")
+	cmdWorkspace.PersistentFlags().Int("depth", defaultWorkspaceDepth, "How many directories below each root clones may sit. Overrides workspace.max_depth of the configuration")
+
+	for _, cmd := range []*cobra.Command{cmdFind, cmdAudit, cmdScan, cmdWorkspace} {
+		// Rules and the output format of the configuration apply unless given as flags.
+		cmd.PreRun = func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			if err := cfg.applyFlags(cmd, cmd != cmdWorkspace); err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+		}
+		addRuleFlags(cmd)
+		cmd.PersistentFlags().Int("concurrency", defaultScanWorkers, "How many repositories are scanned at once")
+		cmd.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+		cmd.PersistentFlags().String("badge", "", "Also write an SVG badge with the pinning score to the given file")
+		cmd.PersistentFlags().Bool("quick-fixes", false, "Attach to every mutable reference the edit pinning it to a commit SHA, and to findings the edits fixing them, in the JSON output")
+		cmd.PersistentFlags().Bool("summary", false, "Also write the results to the job summary when running in GitHub Actions")
+		cmd.PersistentFlags().Bool("recurse-submodules", false, "Also scan the workflows and CI configs of initialized git submodules, at the commits the repository pins them to")
+		cmd.PersistentFlags().Bool("reveal-secrets", false, "Show the credentials findings quote in full instead of redacted to a prefix and a hash, for triage on your machine. Reports then hold them")
+		cmd.PersistentFlags().StringSlice("trigger", nil, "Only scan workflows started by one of these events. Ex: pull_request_target,workflow_run")
+		cmd.PersistentFlags().Bool("audit-log", false, "Tell who last modified each workflow with issues, when, and from where with the audit log. Needs GITHUB_TOKEN of an organization owner")
+		cmd.PersistentFlags().String("enterprise", "", "With --audit-log, read the audit log of this GitHub Enterprise instead of the organization's")
+	}
+	for _, cmd := range []*cobra.Command{cmdFind, cmdAudit} {
+		cmd.PersistentFlags().Bool("blame", false, "Attribute each mutable reference and finding to the author of the last commit that changed its line, its probable owner")
+	}
+
+	var cmdLsp = &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a Language Server Protocol server on stdio, for diagnostics and quick fixes of workflow files in editors",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Run a minimal Language Server Protocol server over stdin and stdout. Editors such as VS Code or Neovim get the mutable references and findings of the open workflow files of .github/workflows as diagnostics while typing, and a quick fix pinning each action to the commit of its ref. The rules, paths, severity overrides, action policy and suppressions of the configuration apply, as in audit.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err == nil {
+				err = checkSeverityOverrides(cfg.SeverityOverrides)
+			}
+			if err == nil {
+				err = cfg.Paths.check()
+			}
+			if err == nil {
+				err = cfg.Policy.check()
+			}
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			// Rules of the configuration the editor cannot run, such as those calling
+			// APIs, are left to audit.
+			for _, rule := range cfg.Rules {
+				name := "check-" + strings.TrimPrefix(rule, "check-")
+				if f := cmd.Flag(name); f != nil && !f.Changed {
+					f.Value.Set("true")
+				}
+			}
+			var rules []Rule
+			if cmd.Flag("check-permissions").Value.String() == "true" {
+				rules = append(rules, PermissionsRule{})
+			}
+			if cmd.Flag("check-deprecated").Value.String() == "true" {
+				rules = append(rules, DeprecatedRule{})
+			}
+			if cmd.Flag("check-secrets").Value.String() == "true" {
+				rules = append(rules, SecretRule{})
+			}
+			if len(cfg.Policy.Banned) > 0 {
+				rules = append(rules, BannedActionRule{Banned: cfg.Policy.Banned})
+			}
+
+			sc := Scanner{
+				FileScanner:       GitHubWorkFlowScanner{},
+				Rules:             rules,
+				Suppressions:      cfg.Suppressions,
+				Paths:             cfg.Paths,
+				SeverityOverrides: cfg.SeverityOverrides,
+				Policy:            cfg.Policy,
+			}
+			if err := newLSPServer(sc, SHAResolver{}, os.Stdout).serve(os.Stdin); err != nil {
+				slog.Error("problem while serving the editor", "err", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmdLsp.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+	cmdLsp.PersistentFlags().Bool("check-permissions", false, "Also report the GITHUB_TOKEN permissions each job needs versus what is granted")
+	cmdLsp.PersistentFlags().Bool("check-deprecated", false, "Also report deprecated workflow commands and actions running on Node 16 or older")
+	cmdLsp.PersistentFlags().Bool("check-secrets", false, "Also report credentials written into workflows, redacted")
+
+	var cmdServe = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve status badges of GitHub repositories. Ex: /badge/owner/repo.svg",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Run an HTTP server answering /badge/{owner}/{repo}.svg with an SVG badge and /badge/{owner}/{repo}.json with a shields.io endpoint badge. Only repositories matching --allow are served. Repositories are scanned through the API and results are cached for 10 minutes.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			allowed, _ := cmd.Flags().GetStringSlice("allow")
+			if len(allowed) == 0 {
+				slog.Error("--allow is required, so the server only scans repositories you chose")
+				os.Exit(1)
+			}
+			for _, glob := range allowed {
+				if _, err := path.Match(glob, ""); err != nil {
+					slog.Error("problem while reading the allowed repositories", "glob", glob, "err", err)
+					os.Exit(1)
+				}
+			}
+			scan := func(owner, name string) (*Inventory, error) {
+				sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Profile: true}
+				return sc.ScanRemoteRepository(fmt.Sprintf("%s/%s/%s", serverURL, owner, name), mutableRefRegex)
+			}
+
+			if lockPath := cmd.Flag("lockfile").Value.String(); lockPath != "" {
+				lock, err := readLockfile(lockPath)
+				if err != nil {
+					slog.Error("problem while reading the lockfile", "err", err)
+					os.Exit(1)
+				}
+				alert := logDrift
+				if webhook := cmd.Flag("alert-webhook").Value.String(); webhook != "" {
+					alert = webhookDrift(webhook)
+				}
+				interval, _ := cmd.Flags().GetDuration("drift-interval")
+				slog.Info("watching locked actions for drift", "lockfile", lockPath, "interval", interval)
+				go watchDrift(lock, freshResolver(), interval, alert, nil)
+			}
+
+			addr := cmd.Flag("addr").Value.String()
+			slog.Info("serving badges", "addr", addr)
+			if err := http.ListenAndServe(addr, newBadgeServer(scan, badgeCacheTTL, allowed)); err != nil {
+				slog.Error("server stopped", "err", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmdServe.PersistentFlags().String("addr", "localhost:8080", "Address to listen on. Pass :8080 to listen on every interface")
+	cmdServe.PersistentFlags().StringSlice("allow", nil, "Repositories badges are served for, as owner/repo or globs such as owner/*. Required")
+	cmdServe.PersistentFlags().String("lockfile", "", "Lockfile whose tags are periodically re-resolved to detect upstream tags that moved")
+	cmdServe.PersistentFlags().Duration("drift-interval", defaultDriftInterval, "How often to check the lockfile for drift")
+	cmdServe.PersistentFlags().String("alert-webhook", "", "URL to post drift alerts to, such as a Slack incoming webhook")
+
+	var cmdHunt = &cobra.Command{
+		Use:   "hunt owner/action@<sha-or-tag>",
+		Short: "Find every reference to a compromised action version across all branches of an organization, without cloning. Needs GITHUB_TOKEN",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Sweep every repository of an organization through the API for workflows referencing an action, on every branch. References by tag and by SHA to the same commit both match. Leave out the version to find every use of the action.`),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			org := cmd.Flag("org").Value.String()
+			if org == "" {
+				slog.Error("--org is required")
+				os.Exit(1)
+			}
+			target, err := parseHuntTarget(args[0])
+			if err != nil {
+				slog.Error("problem while reading the action", "err", err)
+				os.Exit(1)
+			}
+			if len(target.Refs) > 0 {
+				tags, err := GetRefList(target.repository())
+				if err != nil {
+					slog.Warn("could not list the tags of the action. Only the given ref is looked for", "err", err)
+				}
+				target.expandRefs(tags)
+			}
+			window, err := parseHuntWindow(cmd.Flag("since").Value.String(), cmd.Flag("until").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the exposure period", "err", err)
+				os.Exit(1)
+			}
+			if window == nil && cmd.Flag("logs").Value.String() == "true" {
+				slog.Error("--logs needs the exposure period given by --since")
+				os.Exit(1)
+			}
+			fmt.Printf("Hunting %s in %s\n", target, org)
+
+			workers, _ := cmd.Flags().GetInt("workers")
+			hits, unread, err := newHunter(target, window).huntOrg(org, workers)
+			if err != nil {
+				slog.Error("problem while listing the repositories of the organization", "org", org, "err", err)
+				os.Exit(1)
+			}
+			printHuntHits(hits)
+			if cmd.Flag("logs").Value.String() == "true" && len(hits) > 0 {
+				indicators, _ := cmd.Flags().GetStringSlice("indicator")
+				bundles, err := collectEvidence(hits, window, parseIndicators(indicators), cmd.Flag("evidence").Value.String())
+				if err != nil {
+					slog.Error("problem while collecting evidence", "err", err)
+					os.Exit(1)
+				}
+				fmt.Println()
+				for _, e := range bundles {
+					fmt.Printf("%s: %d runs, %d indicator matches, %d logs missing. Evidence in %s\n", e.Repository, len(e.Runs), len(e.Matches), len(e.Missing), e.Dir)
+				}
+			}
+			if len(unread) > 0 {
+				fmt.Printf("\nCould not read %d repositories, which may also reference the action:\n", len(unread))
+				for _, err := range unread {
+					fmt.Printf("  %s\n", err)
+				}
+			}
+			if len(hits) > 0 && cmd.Flag("raise-error").Value.String() == "true" {
+				os.Exit(1)
+			}
+		},
+	}
+	cmdHunt.PersistentFlags().String("org", "", "Organization whose repositories are swept")
+	cmdHunt.PersistentFlags().Int("workers", defaultHuntWorkers, "How many repositories are swept at once")
+	cmdHunt.PersistentFlags().String("since", "", "Also search the commits that changed workflows from this date on, and the workflows in effect on it. Ex: 2025-03-10")
+	cmdHunt.PersistentFlags().String("until", "", "With --since, the last day of the exposure period. Defaults to today")
+	cmdHunt.PersistentFlags().Bool("logs", false, "Download the logs of the runs of matching workflows during the exposure period and grep them for indicators")
+	cmdHunt.PersistentFlags().StringSlice("indicator", nil, "With --logs, strings to look for in run logs besides base64 blobs, such as exfiltration domains. Ex: gist.githubusercontent.com")
+	cmdHunt.PersistentFlags().String("evidence", defaultEvidenceDir, "With --logs, directory the logs and indicator matches of each repository are written to")
+	cmdHunt.PersistentFlags().Bool("raise-error", false, "Exit with an error when any reference is found")
+
+	var cmdRunners = &cobra.Command{
+		Use:   "runners",
+		Short: "Inventory the runner labels workflows of a workspace target, and flag self-hosted labels the policy does not approve",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `List every runs-on label of the workflows of the cloned repositories of a workspace, with the repositories targeting it. Self-hosted labels missing from runners.allowed of the configuration are unapproved, and the repositories able to target the runners of runners.sensitive are listed.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: GitHubVCS{}, Profile: true}
+			inv, err := sc.ScanRepos(cmd.Flag("root").Value.String(), mutableRefRegex, true)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			uses := runnerInventory(inv, cfg.Runners)
+			if len(uses) == 0 {
+				fmt.Println("No runners found")
+				return
+			}
+			printRunnerInventory(uses)
+			if cmd.Flag("raise-error").Value.String() != "true" {
+				return
+			}
+			for _, u := range uses {
+				if u.Kind == runnerUnapproved {
+					os.Exit(1)
+				}
+			}
+		},
+	}
+	cmdRunners.PersistentFlags().String("root", ".", "Absolute path of root directory of GitHub repositories")
+	cmdRunners.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+	cmdRunners.PersistentFlags().Bool("raise-error", false, "Exit with an error when any workflow targets an unapproved self-hosted label")
+
+	var cmdForks = &cobra.Command{
+		Use:   "forks",
+		Short: "Tell for every workflow of a workspace whether pull requests from forks can reach its secrets",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Analyze the triggers, secrets, environments and token permissions of the workflows of the cloned repositories of a workspace, and tell for each whether it is safe for pull requests from forks, with the reasons. pull_request runs of forks get no secrets, so they are only unsafe on self-hosted runners. pull_request_target, workflow_run and issue_comment run with the secrets of the base repository, and are unsafe when a job not skipping forks reads secrets, passes them to a reusable workflow, deploys to an environment or gets a token able to write.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: GitHubVCS{}, Profile: true}
+			inv, err := sc.ScanRepos(cmd.Flag("root").Value.String(), mutableRefRegex, true)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			rows := forkVerdicts(inv)
+			if len(rows) == 0 {
+				fmt.Println("No workflows found")
+				return
+			}
+			printForkVerdicts(rows)
+			if cmd.Flag("raise-error").Value.String() == "true" && !rows[0].Verdict.Safe {
+				os.Exit(1)
+			}
+		},
+	}
+	cmdForks.PersistentFlags().String("root", ".", "Absolute path of root directory of GitHub repositories")
+	cmdForks.PersistentFlags().Bool("raise-error", false, "Exit with an error when any workflow is not safe for pull requests from forks")
+
+	var cmdFreshness = &cobra.Command{
+		Use:   "freshness",
+		Short: "Report how far every pinned action of a workspace is behind its latest release",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `List every action the workflows of the cloned repositories of a workspace pin to a commit SHA or an exact version, with the date of the pinned commit, the latest release of the action and its date, and the gap in days between them, stalest first. Actions without releases are compared with their highest version tag. Write the report as CSV or as an HTML page sortable by any column with --out, to plan update work.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: GitHubVCS{}, Profile: true}
+			inv, err := sc.ScanRepos(cmd.Flag("root").Value.String(), mutableRefRegex, true)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			report := freshnessReport(inv)
+			if len(report) == 0 {
+				fmt.Println("No pinned actions found")
+				return
+			}
+			var file string
+			var render func(io.Writer, []dependencyFreshness) error
+			switch format := cmd.Flag("out").Value.String(); format {
+			case "":
+				printFreshness(report)
+				return
+			case "csv":
+				file, render = "freshness.csv", writeFreshnessCSV
+			case "html":
+				file, render = "freshness.html", writeFreshnessHTML
+			default:
+				slog.Error("The given value to --out flag is invalid. Valid values are csv, html.", "value", format)
+				os.Exit(1)
+			}
+			f, err := os.Create(file)
+			if err == nil {
+				err = render(f, report)
+				f.Close()
+			}
+			if err != nil {
+				slog.Error("could not write the report", "file", file, "err", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Wrote the freshness of %d pinned actions to %s\n", len(report), file)
+		},
+	}
+	cmdFreshness.PersistentFlags().String("root", ".", "Absolute path of root directory of GitHub repositories")
+	cmdFreshness.PersistentFlags().String("out", "", "Write the report to a file instead of printing it. Available options: csv, html")
+
+	var cmdImpact = &cobra.Command{
+		Use:   "impact [owner/repo[/path][@ref]]",
+		Short: "List every workflow of a workspace affected by a change to a shared action or reusable workflow",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `List every workflow of the cloned repositories of a workspace that would be affected by a change or a compromise of an action or reusable workflow of the organization, from the call graph of scharf org graph. Reusable workflows calling it pass it on to their callers, which are listed too with their depth. Leave out the path to cover every action and workflow of the repository, and the ref to cover every version. Ex: scharf impact org/shared/.github/workflows/build.yml --root ws`),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			target, ok := parseImpactTarget(args[0])
+			if !ok {
+				slog.Error("expected an action or workflow as owner/repo, owner/repo/path or either with @ref", "target", args[0])
+				os.Exit(1)
+			}
+			sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: GitHubVCS{}, Profile: true}
+			inv, err := sc.ScanRepos(cmd.Flag("root").Value.String(), mutableRefRegex, true)
+			if err != nil {
+				slog.Error("problem while scanning the workspace", "err", err)
+				os.Exit(1)
+			}
+
+			impacted := impactOf(buildCallGraph(inv, originRepo), target)
+			if cmd.Flag("out").Value.String() == "json" {
+				data, err := json.MarshalIndent(impacted, "", "  ")
+				if err != nil {
+					slog.Error("problem while writing the impact", "err", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+				return
+			}
+			if len(impacted) == 0 {
+				fmt.Println("No workflow of the workspace uses", args[0])
+				return
+			}
+			printImpact(impacted)
+		},
+	}
+	cmdImpact.PersistentFlags().String("root", ".", "Workspace holding the cloned repositories")
+	cmdImpact.PersistentFlags().String("out", "table", "Output format. Available options: table, json")
+
+	var cmdLock = &cobra.Command{
+		Use:   "lock",
+		Short: "Record the commit-SHA of every action tag used by workflows. Must run from a Git repository",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Resolve every action tag referenced by the workflows of the current repository and record the commit each points to in a lockfile. With --check, re-resolve the tags of an existing lockfile instead and fail if any moved upstream, which can be a sign of tampering.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			lockPath := cmd.Flag("lockfile").Value.String()
+			if cmd.Flag("check").Value.String() == "true" {
+				lock, err := readLockfile(lockPath)
+				if err != nil {
+					slog.Error("problem while reading the lockfile", "err", err)
+					os.Exit(1)
+				}
+				drifts, changes := detectDrift(lock, freshResolver()), detectDistChanges(lock)
+				if len(drifts) > 0 {
+					fmt.Println(driftMessage(drifts))
+				}
+				if len(changes) > 0 {
+					fmt.Println(distMessage(changes))
+				}
+				if len(drifts) > 0 || len(changes) > 0 {
+					os.Exit(1)
+				}
+				fmt.Printf("All %d locked tags still point to the recorded commits\n", len(lock.Actions))
+				return
+			}
+
+			if !IsGitRepo(".") {
+				fmt.Println("Not a git repository. Skipping lock!")
+				return
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				slog.Error("could not determine the current directory", "err", err)
+				os.Exit(1)
+			}
+			lock, err := buildLockfile(root, freshResolver())
+			if err == nil && cmd.Flag("dist").Value.String() == "true" {
+				lock.recordDist()
+			}
+			if err == nil {
+				err = lock.write(lockPath)
+			}
+			if err != nil {
+				slog.Error("problem while writing the lockfile", "err", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Locked %d action tags in %s\n", len(lock.Actions), lockPath)
+		},
+	}
+	cmdLock.PersistentFlags().String("lockfile", defaultLockFile, "Path of the lockfile")
+	cmdLock.PersistentFlags().Bool("check", false, "Check the lockfile for tags that moved upstream instead of writing it")
+	cmdLock.PersistentFlags().Bool("dist", false, "Also record a hash of the dist/ bundle of JavaScript actions, which --check compares")
+
+	var cmdSuppressions = &cobra.Command{
+		Use:   "suppressions",
+		Short: "Review the suppressions silencing findings",
+	}
+	var cmdSuppressionsList = &cobra.Command{
+		Use:   "list",
+		Short: "List every inline and configuration suppression with its reason, author and age",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `List the suppressions of the configuration file and the scharf-ignore comments of the workflows of a repository. Each is attributed to the author of its line through git blame, so accepted risks can be reviewed and expired.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			entries, err := listSuppressions(cmd.Flag("root").Value.String(), cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while listing suppressions", "err", err)
+				os.Exit(1)
+			}
+			if len(entries) == 0 {
+				fmt.Println("No suppressions found")
+				return
+			}
+
+			tw.SetHeader([]string{"Rule", "Path", "Location", "Reason", "Author", "Age"})
+			now := time.Now()
+			for _, e := range entries {
+				reason := e.Reason
+				if reason == "" {
+					reason = "(no reason given)"
+				}
+				tw.Append([]string{e.Rule, e.Path, fmt.Sprintf("%s:%d", e.File, e.Line), reason, e.Author, suppressionAge(e.Date, now)})
+			}
+			tw.Render()
+		},
+	}
+	cmdSuppressionsList.PersistentFlags().String("root", ".", "Repository whose workflows are searched for suppression comments")
+	cmdSuppressionsList.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+	cmdSuppressions.AddCommand(cmdSuppressionsList)
+
+	var cmdReport = &cobra.Command{
+		Use:   "report",
+		Short: "Turn the results of a scan into reports for the people who act on them",
+	}
+	var cmdReportSplit = &cobra.Command{
+		Use:   "split",
+		Short: "Split the results of a scan into one report per owning team",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Read the findings.json written by scan --out json and write one report per owner of the workflows, as named by the CODEOWNERS file of each scanned repository. Workflows nobody owns go to an unowned report. With --notify, post a summary of each report to the Slack channel configured for its owner.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if by := cmd.Flag("by").Value.String(); by != "codeowners" {
+				slog.Error("unsupported split", "by", by, "valid", "codeowners")
+				os.Exit(1)
+			}
+			inv, err := readInventory(cmd.Flag("input").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the scan results", "err", err)
+				os.Exit(1)
+			}
+			reports, err := writeSplitReports(cmd.Flag("dir").Value.String(), cmd.Flag("format").Value.String(), splitByOwner(inv, localOwners()))
+			if err != nil {
+				slog.Error("problem while writing the reports", "err", err)
+				os.Exit(1)
+			}
+			for _, r := range reports {
+				fmt.Printf("%s: %s (%d workflows)\n", r.Team, r.File, len(r.Inv.Records))
+			}
+
+			if cmd.Flag("notify").Value.String() != "true" {
+				return
+			}
+			cfg, err := loadConfig(cmd.Flag("config").Value.String())
+			if err != nil {
+				slog.Error("problem while reading the configuration", "err", err)
+				os.Exit(1)
+			}
+			if err := notifyTeams(reports, cfg.Report.SlackWebhooks); err != nil {
+				slog.Error("problem while notifying teams", "err", err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmdReportSplit.PersistentFlags().String("by", "codeowners", "How to split the results. Only codeowners is supported")
+	cmdReportSplit.PersistentFlags().String("input", "findings.json", "Scan results written by scan --out json")
+	cmdReportSplit.PersistentFlags().String("dir", "reports", "Directory the reports are written to")
+	cmdReportSplit.PersistentFlags().String("format", "markdown", "Format of the reports. Valid values are markdown, html")
+	cmdReportSplit.PersistentFlags().Bool("notify", false, "Post a summary of each report to the Slack webhook configured for its owner under report.slack_webhooks")
+	cmdReportSplit.PersistentFlags().String("config", defaultConfigFile, "Project configuration file. Ignored if it does not exist")
+	cmdReport.AddCommand(cmdReportSplit)
+
+	var cmdSite = &cobra.Command{
+		Use:   "site",
+		Short: "Publish the results of scans as a static website",
+	}
+	var cmdSiteGenerate = &cobra.Command{
+		Use:   "generate [findings.json...]",
+		Short: "Render the results of scans into a posture dashboard deployable to GitHub Pages",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Read the findings.json files written by earlier scans with --out json, globs allowed, and write a static website into --dir: an index ranking the repositories of the latest scan by their critical, high and other issues, with the trends of every repository and rule over all the scans, and a page per repository with its issues. The directory can be published as it is to GitHub Pages or any static host.`),
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			scans, err := loadHistory(args)
+			if err != nil {
+				slog.Error("problem while reading the scan results", "err", err)
+				os.Exit(1)
+			}
+			files, err := generateSite(cmd.Flag("dir").Value.String(), scans)
+			if err != nil {
+				slog.Error("problem while writing the site", "err", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Wrote %d files from %d scans to %s\n", len(files), len(scans), cmd.Flag("dir").Value.String())
+		},
+	}
+	cmdSiteGenerate.PersistentFlags().String("dir", "site", "Directory the site is written to")
+	cmdSite.AddCommand(cmdSiteGenerate)
+
+	var rootCmd = &cobra.Command{
+		Use:  "scharf",
+		Long: asciiLogo,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if cmd.Flag("polite").Value.String() == "true" {
+				enablePoliteMode(politeInterval)
+			}
+			if err := githubServerFromEnv(); err != nil {
+				slog.Error("could not set up GitHub Enterprise Server", "err", err)
+				os.Exit(1)
+			}
+			app, err := githubAppFromEnv()
+			if err != nil {
+				slog.Error("could not set up the GitHub App authentication", "err", err)
+				os.Exit(1)
+			}
+			githubAppAuth = app
+			if cmd.Flag("no-cache").Value.String() != "true" {
+				if dir, err := cacheDir(cmd.Flag("cache-dir").Value.String()); err == nil {
+					resolutionCache = openSHACache(filepath.Join(dir, shaCacheFile))
+				}
+			}
+		},
+	}
+	rootCmd.PersistentFlags().Bool("polite", false, "Throttle requests to GitHub to one per second and revalidate cached responses, for scanning repositories you do not own")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Resolve every reference through the API instead of reusing the commits earlier runs resolved")
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory the cache of resolved references is kept in, such as one restored by actions/cache to share it across CI runs. Defaults to SCHARF_CACHE_DIR, then the cache directory of the user")
+	var cmdCache = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of the commits references resolved to",
+	}
+	var cmdCacheClear = &cobra.Command{
+		Use:   "clear",
+		Short: "Delete the cache of resolved references, so the next runs query the API again",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := cacheDir(cmd.Flag("cache-dir").Value.String())
+			if err != nil {
+				slog.Error("could not locate the cache", "err", err)
+				os.Exit(1)
+			}
+			path := filepath.Join(dir, shaCacheFile)
+			if err := clearSHACache(path); err != nil {
+				slog.Error("could not clear the cache", "file", path, "err", err)
+				os.Exit(1)
+			}
+			fmt.Println("Cleared", path)
 		},
 	}
-	cmdAudit.PersistentFlags().Bool("raise-error", false, "Raise error on any matches. Useful for interrupting CI pipelines")
+	cmdCache.AddCommand(cmdCacheClear)
 
-	var rootCmd = &cobra.Command{Use: "scharf", Long: asciiLogo}
-	rootCmd.AddCommand(cmdLookup, cmdFind, cmdList, cmdAudit)
+	rootCmd.AddCommand(cmdLookup, cmdCache, cmdFind, cmdList, cmdAudit, cmdScan, cmdFix, cmdServe, cmdLock, cmdSuppressions, cmdReport, cmdSite, cmdOrg, cmdHunt, cmdRunners, cmdForks, cmdFreshness, cmdImpact, cmdWorkspace, cmdLsp)
 	rootCmd.Execute()
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxMatrixCombinations is the most jobs GitHub generates from a matrix
+const maxMatrixCombinations = 256
+
+// matrixCombination is the value of each matrix key in one of the jobs of a matrix
+type matrixCombination map[string]string
+
+// jobMatrix returns the combinations of the `strategy.matrix` of a job, as GitHub expands
+// them: the product of the keys, minus the excluded ones, plus or extended by the included
+// ones. It returns nil when the job has no matrix or when it is computed at runtime, e.g.
+// with fromJSON. Keys computed at runtime and non-scalar values are left out, so references
+// to them stay unexpanded.
+func jobMatrix(job *yaml.Node) []matrixCombination {
+	matrix := resolveAlias(mappingValue(resolveAlias(mappingValue(job, "strategy")), "matrix"))
+	if matrix == nil || matrix.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	combos := []matrixCombination{{}}
+	original := map[string]bool{}
+	var include, exclude []matrixCombination
+	for _, pair := range mappingPairs(matrix) {
+		switch pair.Key.Value {
+		case "include":
+			include = matrixEntries(pair.Value)
+			continue
+		case "exclude":
+			exclude = matrixEntries(pair.Value)
+			continue
+		}
+
+		values := resolveAlias(pair.Value)
+		if values == nil || values.Kind != yaml.SequenceNode {
+			continue
+		}
+		var product []matrixCombination
+		for _, c := range combos {
+			for _, v := range values.Content {
+				if v = resolveAlias(v); v.Kind != yaml.ScalarNode {
+					continue
+				}
+				next := matrixCombination{pair.Key.Value: v.Value}
+				for k, cv := range c {
+					next[k] = cv
+				}
+				product = append(product, next)
+			}
+		}
+		if len(product) > maxMatrixCombinations {
+			logger.Debug("matrix has too many combinations to expand", "key", pair.Key.Value, "combinations", len(product))
+			return nil
+		}
+		combos = product
+		original[pair.Key.Value] = true
+	}
+	if len(original) == 0 {
+		combos = nil
+	}
+
+	var kept []matrixCombination
+	for _, c := range combos {
+		excluded := false
+		for _, e := range exclude {
+			excluded = excluded || c.matches(e)
+		}
+		if !excluded {
+			kept = append(kept, c)
+		}
+	}
+
+	for _, inc := range include {
+		extended := false
+		for _, c := range kept {
+			if !c.accepts(inc, original) {
+				continue
+			}
+			for k, v := range inc {
+				c[k] = v
+			}
+			extended = true
+		}
+		if !extended {
+			kept = append(kept, inc)
+		}
+	}
+	return kept
+}
+
+// matrixEntries reads the combinations listed under include or exclude
+func matrixEntries(n *yaml.Node) []matrixCombination {
+	n = resolveAlias(n)
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var entries []matrixCombination
+	for _, item := range n.Content {
+		entry := matrixCombination{}
+		for _, pair := range mappingPairs(resolveAlias(item)) {
+			if v := resolveAlias(pair.Value); v != nil && v.Kind == yaml.ScalarNode {
+				entry[pair.Key.Value] = v.Value
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// matches reports whether the combination has every value of an exclude entry
+func (c matrixCombination) matches(entry matrixCombination) bool {
+	for k, v := range entry {
+		if c[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// accepts reports whether an include entry extends the combination: it must not overwrite
+// any of the original values of the matrix
+func (c matrixCombination) accepts(entry matrixCombination, original map[string]bool) bool {
+	for k, v := range entry {
+		if original[k] && c[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// expandMatrix returns the distinct values a string takes across the combinations of a
+// matrix, in order, evaluating its expressions over the static contexts of the job.
+// Expressions that cannot be evaluated are kept as written.
+func expandMatrix(s string, ctx exprContext, combos []matrixCombination) []string {
+	if !strings.Contains(s, "${{") {
+		return []string{s}
+	}
+	if len(combos) == 0 {
+		v, _ := interpolate(s, ctx)
+		return []string{v}
+	}
+
+	var values []string
+	seen := map[string]bool{}
+	for _, c := range combos {
+		matrix := map[string]any{}
+		for k, v := range c {
+			matrix[k] = v
+		}
+		v, _ := interpolate(s, ctx.with("matrix", matrix))
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// --- Tests for jobMatrix ---
+
+func TestJobMatrix(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []matrixCombination
+	}{
+		{
+			name:     "no matrix",
+			content:  "runs-on: ubuntu-latest\n",
+			expected: nil,
+		},
+		{
+			name:     "computed at runtime",
+			content:  "strategy:\n  matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}\n",
+			expected: nil,
+		},
+		{
+			name: "product",
+			content: `strategy:
+  matrix:
+    os: [ubuntu-latest, windows-latest]
+    node: [18, 20]
+`,
+			expected: []matrixCombination{
+				{"os": "ubuntu-latest", "node": "18"},
+				{"os": "ubuntu-latest", "node": "20"},
+				{"os": "windows-latest", "node": "18"},
+				{"os": "windows-latest", "node": "20"},
+			},
+		},
+		{
+			name: "exclude and include",
+			content: `strategy:
+  matrix:
+    os: [ubuntu-latest, windows-latest]
+    node: [18, 20]
+    exclude:
+      - os: windows-latest
+        node: 18
+    include:
+      - os: windows-latest
+        shell: pwsh
+      - os: macos-latest
+        node: 20
+`,
+			expected: []matrixCombination{
+				{"os": "ubuntu-latest", "node": "18"},
+				{"os": "ubuntu-latest", "node": "20"},
+				{"os": "windows-latest", "node": "20", "shell": "pwsh"},
+				{"os": "macos-latest", "node": "20"},
+			},
+		},
+		{
+			name: "include only",
+			content: `strategy:
+  matrix:
+    include:
+      - runner: self-hosted
+`,
+			expected: []matrixCombination{{"runner": "self-hosted"}},
+		},
+		{
+			name: "computed key is left out",
+			content: `strategy:
+  matrix:
+    os: [ubuntu-latest]
+    version: ${{ fromJSON(inputs.versions) }}
+`,
+			expected: []matrixCombination{{"os": "ubuntu-latest"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := parseYAMLDocuments([]byte(tc.content))
+			CheckIfError(err)
+			got := jobMatrix(docs[0].Content[0])
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("jobMatrix = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for expandMatrix ---
+
+func TestExpandMatrix(t *testing.T) {
+	combos := []matrixCombination{
+		{"os": "ubuntu", "arch": "x64"},
+		{"os": "ubuntu", "arch": "arm64"},
+		{"os": "windows"},
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"no reference", "ubuntu-latest", []string{"ubuntu-latest"}},
+		{"single key deduplicated", "${{ matrix.os }}-latest", []string{"ubuntu-latest", "windows-latest"}},
+		{"undefined value kept", "${{matrix.os}}-${{ matrix.arch }}", []string{"ubuntu-x64", "ubuntu-arm64", "windows-${{ matrix.arch }}"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := expandMatrix(tc.input, nil, combos); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expandMatrix(%q) = %v; want %v", tc.input, got, tc.expected)
+			}
+		})
+	}
+	if got := expandMatrix("${{ matrix.os }}", nil, nil); !reflect.DeepEqual(got, []string{"${{ matrix.os }}"}) {
+		t.Errorf("expected the value as written without a matrix, got %v", got)
+	}
+}
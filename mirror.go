@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MirrorFixer rewrites references to actions so they are taken from internal mirrors.
+// Mirrors maps an action (`owner/repo`) to its mirror, and a trailing `*` maps every action
+// of an owner, e.g. `actions/*: mirrors/*`. Refs and subdirectories are kept as they are.
+type MirrorFixer struct {
+	Mirrors map[string]string
+}
+
+// mirrorOf returns the mirror of an action, or "" if it is not mirrored. An exact entry
+// wins over wildcard ones, and the longest wildcard wins among those.
+func (m MirrorFixer) mirrorOf(action string) string {
+	mirror, longest := "", -1
+	for from, to := range m.Mirrors {
+		if strings.EqualFold(from, action) {
+			return to
+		}
+		prefix, ok := strings.CutSuffix(from, "*")
+		if ok && len(prefix) > longest && len(action) > len(prefix) && strings.EqualFold(action[:len(prefix)], prefix) {
+			mirror, longest = strings.Replace(to, "*", action[len(prefix):], 1), len(prefix)
+		}
+	}
+	return mirror
+}
+
+// Fix replaces the `owner/repo` part of every mirrored `uses:` reference
+func (m MirrorFixer) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	var edits []TextEdit
+	for _, n := range wf.usesNodes() {
+		if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || strings.HasPrefix(n.Value, "./") || strings.HasPrefix(n.Value, "docker://") {
+			continue
+		}
+		ref, _, _ := strings.Cut(n.Value, "@")
+		parts := strings.SplitN(ref, "/", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		action := parts[0] + "/" + parts[1]
+		mirror := m.mirrorOf(action)
+		if mirror == "" || strings.EqualFold(mirror, action) {
+			continue
+		}
+
+		start, ok := wf.lines.locate(action, wf.lines.offset(n.Line, n.Column))
+		if !ok {
+			logger.Debug("could not locate action to mirror", "file", wf.Path, "line", n.Line, "action", action)
+			continue
+		}
+		edits = append(edits, TextEdit{Start: start, End: start + len(action), NewText: mirror})
+	}
+	return edits, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+// --- Tests for MirrorFixer.Fix ---
+
+func TestMirrorFixer_Fix(t *testing.T) {
+	fixer := MirrorFixer{Mirrors: map[string]string{
+		"actions/checkout": "mirrors/checkout",
+		"actions/*":        "mirrors/actions-*",
+		"github/*":         "mirrors/*",
+	}}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "exact entry wins over wildcard",
+			content:  "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4 # keep me\n      - uses: actions/setup-go@v5\n",
+			expected: "jobs:\n  build:\n    steps:\n      - uses: mirrors/checkout@v4 # keep me\n      - uses: mirrors/actions-setup-go@v5\n",
+		},
+		{
+			name:     "quoted with subdirectory",
+			content:  "jobs:\n  analyze:\n    steps:\n      - uses: \"github/codeql-action/init@v3\"\n",
+			expected: "jobs:\n  analyze:\n    steps:\n      - uses: \"mirrors/codeql-action/init@v3\"\n",
+		},
+		{
+			name:     "reusable workflow",
+			content:  "jobs:\n  call:\n    uses: GitHub/workflows/.github/workflows/ci.yml@main\n",
+			expected: "jobs:\n  call:\n    uses: mirrors/workflows/.github/workflows/ci.yml@main\n",
+		},
+		{
+			name:     "unmapped, local and docker references",
+			content:  "jobs:\n  build:\n    steps:\n      - uses: other/action@v1\n      - uses: ./actions/checkout\n      - uses: docker://actions/checkout:latest\n",
+			expected: "jobs:\n  build:\n    steps:\n      - uses: other/action@v1\n      - uses: ./actions/checkout\n      - uses: docker://actions/checkout:latest\n",
+		},
+		{
+			name:     "anchored step is rewritten once",
+			content:  "jobs:\n  a:\n    steps:\n      - &co\n        uses: actions/checkout@v4\n  b:\n    steps:\n      - *co\n",
+			expected: "jobs:\n  a:\n    steps:\n      - &co\n        uses: mirrors/checkout@v4\n  b:\n    steps:\n      - *co\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixed, err := fixContent("wf.yml", []byte(tc.content), []Fixer{fixer})
+			if err != nil {
+				t.Fatalf("fixContent returned error: %v", err)
+			}
+			if string(fixed) != tc.expected {
+				t.Errorf("fixed =\n%s\nwant\n%s", fixed, tc.expected)
+			}
+
+			// Fixing again must not change anything.
+			again, err := fixContent("wf.yml", fixed, []Fixer{fixer})
+			CheckIfError(err)
+			if string(again) != string(fixed) {
+				t.Errorf("fix is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
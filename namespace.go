@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ruleNamespaceOwnership flags actions of our namespaces that do not live in our orgs
+const ruleNamespaceOwnership = "namespace-ownership"
+
+// NamespacesConfig lists the orgs of the enterprise, whose actions are trusted as internal
+type NamespacesConfig struct {
+	Orgs []string `yaml:"orgs"`
+}
+
+// repoOwnerFunc returns the owner a repository resolves to, or "" if it does not exist
+type repoOwnerFunc func(owner, name string) (string, error)
+
+// repoOwner looks a repository up through the API. Renamed and transferred repositories
+// redirect to their new location, so the owner returned may differ from the one asked for.
+func repoOwner(owner, name string) (string, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/%s", apiURL, owner, name))
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: looking up %s/%s returned %s", owner, name, resp.Status)
+	}
+
+	var repo struct {
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return repo.Owner.Login, nil
+}
+
+// NamespaceRule verifies that actions referenced under the orgs of the enterprise exist
+// there, and flags owners that only look like one of those orgs. A typo or a lookalike org
+// hands our workflows to whoever registers it.
+type NamespaceRule struct {
+	orgs   map[string]string
+	lookup repoOwnerFunc
+	mu     sync.Mutex
+	cache  map[string]string
+}
+
+// NewNamespaceRule creates the rule for the approved orgs, looking repositories up with lookup
+func NewNamespaceRule(orgs []string, lookup repoOwnerFunc) *NamespaceRule {
+	m := map[string]string{}
+	for _, o := range orgs {
+		// Org names are case-insensitive on GitHub.
+		m[strings.ToLower(o)] = o
+	}
+	return &NamespaceRule{orgs: m, lookup: lookup, cache: map[string]string{}}
+}
+
+func (r *NamespaceRule) ID() string {
+	return ruleNamespaceOwnership
+}
+
+func (r *NamespaceRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, n := range wf.usesNodes() {
+		if strings.HasPrefix(n.Value, "./") || strings.HasPrefix(n.Value, "docker://") || strings.Contains(n.Value, "${{") {
+			continue
+		}
+		ref, _, _ := strings.Cut(n.Value, "@")
+		parts := strings.SplitN(ref, "/", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		owner, name := parts[0], parts[1]
+
+		if _, ok := r.orgs[strings.ToLower(owner)]; !ok {
+			if org := r.lookalike(owner); org != "" {
+				findings = append(findings, wf.finding(r.ID(), SeverityCritical, n, fmt.Sprintf(
+					"%s is taken from org %s, which looks like the approved org %s but is not one of ours", ref, owner, org)))
+			}
+			continue
+		}
+
+		resolved, ok := r.resolve(owner, name)
+		switch {
+		case !ok:
+		case resolved == "":
+			findings = append(findings, wf.finding(r.ID(), SeverityHigh, n, fmt.Sprintf(
+				"%s/%s does not exist in org %s. Check the name, as nothing guarantees who will create it", owner, name, owner)))
+		case r.orgs[strings.ToLower(resolved)] == "":
+			findings = append(findings, wf.finding(r.ID(), SeverityCritical, n, fmt.Sprintf(
+				"%s/%s resolves to org %s, outside the approved orgs. The repository was transferred", owner, name, resolved)))
+		}
+	}
+	return findings
+}
+
+// resolve returns the owner a repository resolves to, looking each repository up once.
+// It reports false when the lookup failed.
+func (r *NamespaceRule) resolve(owner, name string) (string, bool) {
+	key := strings.ToLower(owner + "/" + name)
+	r.mu.Lock()
+	resolved, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return resolved, true
+	}
+
+	resolved, err := r.lookup(owner, name)
+	if err != nil {
+		logger.Warn("could not verify owner of action", "action", owner+"/"+name, "err", err)
+		return "", false
+	}
+	r.mu.Lock()
+	r.cache[key] = resolved
+	r.mu.Unlock()
+	return resolved, true
+}
+
+// lookalike returns the approved org an owner could be mistaken for, if any: the same name
+// but for separators, a couple of typos away, or the org name with a prefix or suffix
+func (r *NamespaceRule) lookalike(owner string) string {
+	normalize := strings.NewReplacer("-", "", "_", "", ".", "")
+	o := normalize.Replace(strings.ToLower(owner))
+	for _, lower := range slices.Sorted(maps.Keys(r.orgs)) {
+		org := r.orgs[lower]
+		n := normalize.Replace(lower)
+		if o == n || (len(n) >= 5 && editDistance(o, n) <= 2) {
+			return org
+		}
+		for _, part := range strings.FieldsFunc(strings.ToLower(owner), func(c rune) bool { return c == '-' || c == '_' }) {
+			if part == lower {
+				return org
+			}
+		}
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance of two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// --- Tests for NamespaceRule.Check ---
+
+func TestNamespaceRule_Check(t *testing.T) {
+	repos := map[string]string{
+		"mycorp/deploy":             "MyCorp",
+		"mycorp/moved":              "someone-else",
+		"mycorp/internal":           "mycorp-platform",
+		"mycorp-platform/workflows": "mycorp-platform",
+	}
+	lookups := 0
+	lookup := func(owner, name string) (string, error) {
+		lookups++
+		return repos[strings.ToLower(owner+"/"+name)], nil
+	}
+	rule := NewNamespaceRule([]string{"MyCorp", "mycorp-platform"}, lookup)
+
+	content := `jobs:
+  build:
+    steps:
+      - uses: mycorp/deploy@v1
+      - uses: MyCorp/deploy/sub@v1
+      - uses: mycorp/moved@v1
+      - uses: mycorp/internal@main
+      - uses: mycorp/typo@v1
+      - uses: my-corp/deploy@v1
+      - uses: mycorpp/deploy@v1
+      - uses: mycorp-actions/deploy@v1
+      - uses: actions/checkout@v4
+      - uses: ./mycorp/local
+  call:
+    uses: mycorp-platform/workflows/.github/workflows/ci.yml@main
+`
+	findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	expected := map[int]string{
+		6:  SeverityCritical, // transferred out of the org
+		8:  SeverityHigh,     // does not exist
+		9:  SeverityCritical, // separators
+		10: SeverityCritical, // typo
+		11: SeverityCritical, // prefix
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if f.Rule != ruleNamespaceOwnership || expected[f.Line] != f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+	// mycorp/deploy is referenced twice but looked up once.
+	if lookups != 5 {
+		t.Errorf("expected 5 lookups, got %d", lookups)
+	}
+}
+
+// --- Tests for repoOwner ---
+
+func TestRepoOwner(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusNotFound, `{"message": "Not Found"}`
+		switch req.URL.String() {
+		case "https://api.github.com/repos/mycorp/deploy":
+			status, body = http.StatusOK, `{"full_name": "other/deploy", "owner": {"login": "other"}}`
+		case "https://api.github.com/repos/mycorp/error":
+			status = http.StatusInternalServerError
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if owner, err := repoOwner("mycorp", "deploy"); err != nil || owner != "other" {
+			t.Errorf("repoOwner = %q, %v; want other", owner, err)
+		}
+		if owner, err := repoOwner("mycorp", "missing"); err != nil || owner != "" {
+			t.Errorf("repoOwner of missing repository = %q, %v; want empty", owner, err)
+		}
+		if _, err := repoOwner("mycorp", "error"); err == nil {
+			t.Error("expected error for a server error, got nil")
+		}
+	})
+}
+
+// --- Tests for editDistance ---
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"mycorp", "mycorp", 0},
+		{"mycorp", "mycrop", 2},
+		{"mycorp", "mycorpp", 1},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.expected {
+			t.Errorf("editDistance(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ndjsonIssue is a mutable reference or a finding written as a line of NDJSON. Mutable
+// references are issues of the mutable-reference rule, as in SARIF.
+type ndjsonIssue struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch,omitempty"`
+	File       string `json:"file"`
+	// Match is the mutable reference, set for issues of the mutable-reference rule
+	Match string `json:"match,omitempty"`
+	// Submodule holding the file, set with --recurse-submodules
+	Submodule *SubmoduleRef `json:"submodule,omitempty"`
+	Finding
+}
+
+// ndjsonIssues flattens records into their mutable references and findings, in order.
+// Suppressed findings are left out.
+func ndjsonIssues(records []*InventoryRecord) []ndjsonIssue {
+	var issues []ndjsonIssue
+	for _, ir := range records {
+		for _, m := range ir.Locations {
+			issues = append(issues, ndjsonIssue{
+				Repository: ir.Repository, Branch: ir.Branch, File: ir.FilePath, Match: m.Value, Submodule: ir.Submodule,
+				Finding: Finding{
+					Rule: ruleMutableReference, Severity: SeverityMedium, Message: fmt.Sprintf("%s is a mutable reference. Pin it to a commit SHA", m.Value),
+					Line: m.Line, Column: m.Column, Score: m.Score, Fix: m.Fix, Blame: m.Blame,
+				},
+			})
+		}
+		for _, f := range ir.Findings {
+			issues = append(issues, ndjsonIssue{Repository: ir.Repository, Branch: ir.Branch, File: ir.FilePath, Submodule: ir.Submodule, Finding: f})
+		}
+	}
+	return issues
+}
+
+// ndjsonStream writes issues as NDJSON while repositories are scanned concurrently, and
+// remembers the records it wrote so the final export only adds the others
+type ndjsonStream struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	written map[*InventoryRecord]bool
+}
+
+func newNDJSONStream(w io.Writer) *ndjsonStream {
+	return &ndjsonStream{enc: json.NewEncoder(w), written: map[*InventoryRecord]bool{}}
+}
+
+// write writes the issues of the records not written yet, one per line
+func (s *ndjsonStream) write(records []*InventoryRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*InventoryRecord
+	for _, ir := range records {
+		if !s.written[ir] {
+			s.written[ir] = true
+			pending = append(pending, ir)
+		}
+	}
+	for _, issue := range ndjsonIssues(pending) {
+		if err := s.enc.Encode(issue); err != nil {
+			return fmt.Errorf("json: %w", err)
+		}
+	}
+	return nil
+}
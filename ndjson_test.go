@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// --- Tests for ndjsonIssues ---
+
+func TestNDJSONIssues(t *testing.T) {
+	records := []*InventoryRecord{{
+		Repository: "api",
+		Branch:     "main",
+		FilePath:   "ci.yml",
+		Locations:  []Match{{Value: "actions/checkout@v4", Line: 4, Column: 15}},
+		Findings:   []Finding{{Rule: ruleDeprecated, Severity: SeverityMedium, Message: "deprecated", Line: 7}},
+		Suppressed: []SuppressedFinding{{Finding: Finding{Rule: ruleDeprecated}}},
+	}}
+
+	issues := ndjsonIssues(records)
+	if len(issues) != 2 {
+		t.Fatalf("expected a mutable reference and a finding, got %+v", issues)
+	}
+	ref, finding := issues[0], issues[1]
+	if ref.Rule != ruleMutableReference || ref.Match != "actions/checkout@v4" || ref.Line != 4 || ref.Severity != SeverityMedium || ref.Repository != "api" {
+		t.Errorf("unexpected mutable reference %+v", ref)
+	}
+	if finding.Rule != ruleDeprecated || finding.Match != "" || finding.Line != 7 || finding.File != "ci.yml" {
+		t.Errorf("unexpected finding %+v", finding)
+	}
+}
+
+// --- Tests for Scanner.ScanRepos with Stream ---
+
+func TestScanner_ScanReposStream(t *testing.T) {
+	root := t.TempDir()
+	repos := []Repository{}
+	for _, name := range []string{"api", "web"} {
+		repos = append(repos, fakeRepository{
+			name:         name,
+			branches:     []string{"main"},
+			files:        []string{"ci.yml"},
+			fileContents: map[string][]byte{filepath.Join(root, name, ".github", "workflows", "ci.yml"): []byte("steps:\n  - uses: actions/checkout@v4\n")},
+		})
+	}
+
+	var b bytes.Buffer
+	sc := Scanner{VCS: fakeVCS{repos: repos}, FileScanner: GitHubWorkFlowScanner{}, Stream: newNDJSONStream(&b)}
+	inv, err := sc.ScanRepos(root, mutableRefRegex, true)
+	CheckIfError(err)
+
+	// The final export adds nothing streamed already.
+	CheckIfError(sc.Stream.write(inv.Records))
+
+	seen := map[string]bool{}
+	lines := bufio.NewScanner(&b)
+	for lines.Scan() {
+		var issue ndjsonIssue
+		CheckIfError(json.Unmarshal(lines.Bytes(), &issue))
+		if issue.Match != "actions/checkout@v4" || seen[issue.Repository] {
+			t.Errorf("unexpected line %s", lines.Text())
+		}
+		seen[issue.Repository] = true
+	}
+	if !seen["api"] || !seen["web"] {
+		t.Errorf("expected a line per repository, got %q", b.String())
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// stepOutput is an output of the step running scharf in GitHub Actions
+type stepOutput struct {
+	Name  string
+	Value string
+}
+
+// stepOutputs returns what downstream steps branch on: the number of mutable references and
+// findings, the issues per severity with mutable references counting as medium, the report
+// written with --out, if any, and whether the issues pass the fail_on thresholds
+func stepOutputs(inv *Inventory, report string, failOn map[string]string) []stepOutput {
+	var matches, findings int
+	counts := map[string]int{}
+	for _, ir := range inv.Records {
+		matches += len(ir.Locations)
+		counts[SeverityMedium] += len(ir.Locations)
+		findings += len(ir.Findings)
+		for _, f := range ir.Findings {
+			counts[f.Severity]++
+		}
+	}
+
+	return []stepOutput{
+		{"mutable-references", strconv.Itoa(matches)},
+		{"findings", strconv.Itoa(findings)},
+		{"critical", strconv.Itoa(counts[SeverityCritical])},
+		{"high", strconv.Itoa(counts[SeverityHigh])},
+		{"medium", strconv.Itoa(counts[SeverityMedium])},
+		{"low", strconv.Itoa(counts[SeverityLow])},
+		{"report", report},
+		{"passed", strconv.FormatBool(!inv.fails(failOn))},
+	}
+}
+
+// writeStepOutputs renders outputs in the name=value lines of GITHUB_OUTPUT
+func writeStepOutputs(w io.Writer, outputs []stepOutput) error {
+	for _, o := range outputs {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", o.Name, o.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendStepOutputs sets the outputs of the step, when running in GitHub Actions
+func appendStepOutputs(outputs []stepOutput) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	defer f.Close()
+	return writeStepOutputs(f, outputs)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// --- Tests for stepOutputs ---
+
+func TestStepOutputs(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{
+			Locations: []Match{{Value: "actions/checkout@v4"}, {Value: "actions/setup-go@v5"}},
+			Findings:  []Finding{{Severity: SeverityHigh}, {Severity: SeverityLow}},
+		},
+		{Criticality: criticalityProd, Findings: []Finding{{Severity: SeverityMedium}}},
+	}}
+
+	tests := []struct {
+		name     string
+		inv      *Inventory
+		failOn   map[string]string
+		expected string
+	}{
+		{
+			name:     "issues",
+			inv:      inv,
+			expected: "mutable-references=2\nfindings=3\ncritical=0\nhigh=1\nmedium=3\nlow=1\nreport=findings.sarif\npassed=false\n",
+		},
+		{
+			name:     "issues below the thresholds",
+			inv:      inv,
+			failOn:   map[string]string{criticalityInternal: SeverityCritical, criticalityProd: SeverityHigh},
+			expected: "mutable-references=2\nfindings=3\ncritical=0\nhigh=1\nmedium=3\nlow=1\nreport=findings.sarif\npassed=true\n",
+		},
+		{
+			name:     "no issues",
+			inv:      &Inventory{Records: []*InventoryRecord{{}}},
+			expected: "mutable-references=0\nfindings=0\ncritical=0\nhigh=0\nmedium=0\nlow=0\nreport=findings.sarif\npassed=true\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "output")
+			t.Setenv("GITHUB_OUTPUT", path)
+			CheckIfError(appendStepOutputs(stepOutputs(tc.inv, "findings.sarif", tc.failOn)))
+			content, err := os.ReadFile(path)
+			CheckIfError(err)
+			if string(content) != tc.expected {
+				t.Errorf("outputs =\n%s\nwant\n%s", content, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleUnpinnedPackage flags build tooling installed in CI at a version that can change
+// between runs
+const ruleUnpinnedPackage = "unpinned-package"
+
+// Package ecosystems checked by --check-packages
+const (
+	ecosystemNPM = "npm"
+	ecosystemPip = "pip"
+	ecosystemGo  = "go"
+)
+
+var packageEcosystems = []string{ecosystemNPM, ecosystemPip, ecosystemGo}
+
+var (
+	// exactSemver matches exact versions, such as 1.2.3, v1.2.3-rc.1 or Go pseudo-versions
+	exactSemver = regexp.MustCompile(`^v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+	// commitSHA matches abbreviated and full commit hashes
+	commitSHA = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	// commandSeparator splits a shell line into the commands it chains
+	commandSeparator = regexp.MustCompile(`&&|\|\||[;|]`)
+)
+
+// valueFlags are the options of the package managers taking a value, which is not a package
+var valueFlags = map[string]bool{
+	"--registry": true, "--prefix": true, "--cache": true, "--tag": true, "-w": true, "--workspace": true,
+	"-c": true, "--constraint": true, "-i": true, "--index-url": true, "--extra-index-url": true,
+	"-f": true, "--find-links": true, "-t": true, "--target": true, "--python": true,
+	"-e": true, "--editable": true, "--modfile": true, "-o": true,
+}
+
+// PackagesConfig sets the policy of --check-packages
+type PackagesConfig struct {
+	// Ecosystems limits the checks to some of npm, pip and go. Empty checks them all.
+	Ecosystems []string `yaml:"ecosystems"`
+	// Allowed are globs of packages installed at floating versions on purpose
+	Allowed []string `yaml:"allowed"`
+}
+
+// checkPackagesConfig reports ecosystems the rule does not know
+func checkPackagesConfig(policy PackagesConfig) error {
+	for _, e := range policy.Ecosystems {
+		if !slices.Contains(packageEcosystems, e) {
+			return fmt.Errorf("packages.ecosystems: unknown ecosystem %q. Available options: %s", e, strings.Join(packageEcosystems, ", "))
+		}
+	}
+	return nil
+}
+
+// PackageRule flags run steps installing build tooling at floating versions, as with
+// npm install -g eslint, pip install black or go install tool@latest, and installs that
+// skip lockfiles or hashes: npm install instead of npm ci, and requirement files without
+// --require-hashes. The same commit then builds with different tools from one run to the
+// next, and a compromised release reaches CI as soon as it is published.
+type PackageRule struct {
+	Policy PackagesConfig
+}
+
+func (r PackageRule) ID() string {
+	return ruleUnpinnedPackage
+}
+
+func (r PackageRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			run := mappingValue(resolveAlias(step), "run")
+			if run == nil {
+				continue
+			}
+			for _, line := range scriptCommands(run.Value) {
+				for _, command := range commandSeparator.Split(line, -1) {
+					for _, issue := range r.installIssues(strings.Fields(command)) {
+						findings = append(findings, wf.finding(ruleUnpinnedPackage, SeverityLow, run, fmt.Sprintf("step of job %s %s", job.ID, issue)))
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// checks reports whether the policy checks an ecosystem
+func (r PackageRule) checks(ecosystem string) bool {
+	return len(r.Policy.Ecosystems) == 0 || slices.Contains(r.Policy.Ecosystems, ecosystem)
+}
+
+// allowed reports whether a package may float per the policy
+func (r PackageRule) allowed(name string) bool {
+	for _, glob := range r.Policy.Allowed {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// installIssues describes what a command installs without pinning it
+func (r PackageRule) installIssues(args []string) []string {
+	// Environment assignments and sudo do not change what is installed.
+	for len(args) > 0 && (args[0] == "sudo" || strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-")) {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		return nil
+	}
+	for i := range args {
+		args[i] = strings.Trim(args[i], `'"`)
+	}
+
+	tool, sub, rest := args[0], args[1], args[2:]
+	npm, format := r.checks(ecosystemNPM), "installs %s at a floating version. Ex: %s@<version>"
+	switch {
+	case npm && tool == "npm" && slices.Contains([]string{"install", "i", "add"}, sub):
+		specs := packageArgs(rest)
+		if len(specs) == 0 && !hasFlag(rest, "-g", "--global") {
+			return []string{"runs npm install, which resolves the ranges of package.json and may update the lockfile. Ex: npm ci"}
+		}
+		return r.floating(specs, format, npmVersion)
+	case npm && tool == "yarn" && sub == "global" && len(rest) > 0 && rest[0] == "add":
+		return r.floating(packageArgs(rest[1:]), format, npmVersion)
+	case npm && tool == "pnpm" && (sub == "add" || sub == "install") && hasFlag(rest, "-g", "--global"):
+		return r.floating(packageArgs(rest), format, npmVersion)
+	case npm && (tool == "yarn" || tool == "pnpm") && sub == "dlx":
+		return r.floating(firstArg(packageArgs(rest)), "runs %s at a floating version. Ex: %s@<version>", npmVersion)
+	case npm && tool == "npx" && hasFlag(args[1:], "-y", "--yes"):
+		// Without --yes, npx runs the binaries of the project, installed from its lockfile.
+		return r.floating(firstArg(packageArgs(args[1:])), "runs %s at a floating version. Ex: %s@<version>", npmVersion)
+	case r.checks(ecosystemPip) && isPip(args):
+		return r.pipIssues(args)
+	case r.checks(ecosystemGo) && tool == "go" && (sub == "install" || sub == "get"):
+		return r.floating(packageArgs(rest), format, goVersion)
+	}
+	return nil
+}
+
+// firstArg keeps the first of args, the package dlx and npx run
+func firstArg(args []string) []string {
+	return args[:min(1, len(args))]
+}
+
+// pipIssues describes what a pip or pipx install leaves unpinned
+func (r PackageRule) pipIssues(args []string) []string {
+	i := slices.Index(args, "install")
+	if i < 0 {
+		return nil
+	}
+	args = args[i+1:]
+	var issues []string
+	hashes := hasFlag(args, "--require-hashes")
+	for j, a := range args {
+		if (a == "-r" || a == "--requirement") && j+1 < len(args) && !hashes {
+			issues = append(issues, fmt.Sprintf("installs %s without verifying hashes. Ex: pip install --require-hashes -r %s", args[j+1], args[j+1]))
+		}
+	}
+	var specs []string
+	for j, a := range args {
+		if j > 0 && (args[j-1] == "-r" || args[j-1] == "--requirement") {
+			continue
+		}
+		specs = append(specs, a)
+	}
+	return append(issues, r.floating(packageArgs(specs), "installs %s at a floating version. Ex: pip install %s==<version>", pipVersion)...)
+}
+
+// floating describes the packages of specs installed at a version that can change. parse
+// tells the name of the package of a spec, whether the spec pins a version, and whether the
+// package comes from a registry at all.
+func (r PackageRule) floating(specs []string, format string, parse func(string) (string, bool, bool)) []string {
+	var issues []string
+	for _, spec := range specs {
+		name, pinned, registry := parse(spec)
+		if !registry || pinned || r.allowed(name) {
+			continue
+		}
+		issues = append(issues, fmt.Sprintf(format, spec, name))
+	}
+	return issues
+}
+
+// npmVersion parses specs like @scope/name@1.2.3, pinned by an exact version. Git URLs,
+// tarballs and paths are not registry packages.
+func npmVersion(spec string) (string, bool, bool) {
+	if strings.Contains(spec, ":") || strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, "~") {
+		return spec, false, false
+	}
+	at := strings.LastIndex(spec, "@")
+	if at <= 0 {
+		return spec, false, true
+	}
+	return spec[:at], exactSemver.MatchString(spec[at+1:]), true
+}
+
+// pipVersion parses requirement specifiers. Only == and === without wildcards pin a single
+// version; URLs and paths are not registry packages.
+func pipVersion(spec string) (string, bool, bool) {
+	if strings.Contains(spec, "://") || strings.Contains(spec, "@") || strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasSuffix(spec, ".whl") {
+		return spec, false, false
+	}
+	name := spec
+	if end := strings.IndexAny(spec, "[<>=!~;, "); end > 0 {
+		name = spec[:end]
+	}
+	_, v, ok := strings.Cut(spec, "==")
+	return name, ok && v != "" && !strings.ContainsAny(v, "*,;<>!~"), true
+}
+
+// goVersion parses module queries like golang.org/x/tools/cmd/goimports@v0.20.0, pinned by
+// an exact or pseudo-version or a commit. Packages of the main module, and those installed
+// without a version, follow go.mod.
+func goVersion(spec string) (string, bool, bool) {
+	name, v, ok := strings.Cut(spec, "@")
+	if !ok || strings.HasPrefix(spec, ".") || !strings.Contains(strings.Split(name, "/")[0], ".") {
+		return spec, false, false
+	}
+	return name, exactSemver.MatchString(v) || commitSHA.MatchString(v), true
+}
+
+// isPip reports whether a command runs pip, through pip, pip3, python -m pip, uv pip or
+// pipx
+func isPip(args []string) bool {
+	switch {
+	case args[0] == "pip" || args[0] == "pip3" || args[0] == "pipx":
+		return true
+	case strings.HasPrefix(args[0], "python") && len(args) > 2 && args[1] == "-m" && (args[2] == "pip" || args[2] == "pipx"):
+		return true
+	}
+	return args[0] == "uv" && args[1] == "pip"
+}
+
+// packageArgs returns the arguments of a command that are not options or their values
+func packageArgs(args []string) []string {
+	var specs []string
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") || i > 0 && valueFlags[args[i-1]] || a == "" {
+			continue
+		}
+		specs = append(specs, a)
+	}
+	return specs
+}
+
+// hasFlag reports whether args hold one of flags
+func hasFlag(args []string, flags ...string) bool {
+	for _, a := range args {
+		if slices.Contains(flags, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for PackageRule.Check ---
+
+func TestPackageRule_Check(t *testing.T) {
+	content := `jobs:
+  build:
+    steps:
+      - uses: actions/setup-node@v4
+      - run: npm install
+      - run: npm ci && npm install -g eslint typescript@5.4.5 prettier@latest
+      - uses: actions/setup-python@v5
+      - run: |
+          python -m pip install --upgrade pip
+          pip install -r requirements.txt black==24.3.0 "ruff>=0.4"
+      - run: pip install --require-hashes -r requirements.lock
+      - uses: actions/setup-go@v5
+      - run: go install golang.org/x/tools/cmd/goimports@latest && go install ./cmd/app
+      - run: go install honnef.co/go/tools/cmd/staticcheck@v0.6.1
+`
+	findings := PackageRule{}.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	expected := []string{
+		"npm install, which resolves",
+		"installs eslint at",
+		"installs prettier@latest at",
+		"installs pip at",
+		"installs requirements.txt without verifying hashes",
+		"installs ruff>=0.4 at",
+		"installs golang.org/x/tools/cmd/goimports@latest at",
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for i, f := range findings {
+		if !strings.Contains(f.Message, expected[i]) || f.Rule != ruleUnpinnedPackage || f.Severity != SeverityLow {
+			t.Errorf("expected a finding about %q, got %+v", expected[i], f)
+		}
+	}
+
+	// The policy narrows the ecosystems and allows packages to float.
+	rule := PackageRule{Policy: PackagesConfig{Ecosystems: []string{ecosystemPip}, Allowed: []string{"pip"}}}
+	findings = rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+	if len(findings) != 2 {
+		t.Errorf("expected the findings of pip installs but pip itself, got %+v", findings)
+	}
+}
+
+// --- Tests for PackageRule.installIssues ---
+
+func TestPackageRule_InstallIssues(t *testing.T) {
+	tests := []struct {
+		command string
+		issues  int
+	}{
+		{"sudo npm i -g @scope/tool@^1.2.0", 1},
+		{"npm install --save-dev @scope/tool@1.2.0", 0},
+		{"npm install --registry https://npm.example.com", 1},
+		{"npm install ./local-package", 0},
+		{"npx eslint .", 0},
+		{"npx --yes create-app my-app", 1},
+		{"pnpm dlx create-app@3.0.0 my-app", 0},
+		{"yarn global add serve", 1},
+		{"CI=1 pnpm add -g serve@14.2.1", 0},
+		{"pipx install poetry==1.8.2", 0},
+		{"pip3 install 'django==5.*'", 1},
+		{"uv pip install -e . -c constraints.txt", 0},
+		{"pip install git+https://github.com/acme/tool@v1", 0},
+		{"go get github.com/acme/lib@main", 1},
+		{"go install github.com/acme/tool@v1", 1},
+		{"go install github.com/acme/tool@0123abcd", 0},
+		{"go install github.com/acme/tool", 0},
+		{"echo npm install", 0},
+	}
+	for _, tc := range tests {
+		if got := (PackageRule{}).installIssues(strings.Fields(tc.command)); len(got) != tc.issues {
+			t.Errorf("installIssues(%q) = %q; want %d issues", tc.command, got, tc.issues)
+		}
+	}
+}
+
+// --- Tests for checkPackagesConfig ---
+
+func TestCheckPackagesConfig(t *testing.T) {
+	if err := checkPackagesConfig(PackagesConfig{Ecosystems: []string{"npm", "go"}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := checkPackagesConfig(PackagesConfig{Ecosystems: []string{"cargo"}}); err == nil {
+		t.Error("expected an error for an unknown ecosystem")
+	}
+}
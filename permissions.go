@@ -0,0 +1,444 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ruleExcessivePermissions flags jobs granted more token permissions than they need
+	ruleExcessivePermissions = "excessive-permissions"
+	// ruleMissingPermissions flags jobs needing token permissions they are not granted
+	ruleMissingPermissions = "missing-permissions"
+	// ruleUndeclaredPermissions flags jobs running with the repository default token permissions
+	ruleUndeclaredPermissions = "undeclared-permissions"
+)
+
+// Permission levels of the GITHUB_TOKEN, ordered so that a higher level includes the lower ones
+const (
+	permNone = iota
+	permRead
+	permWrite
+)
+
+var permLevelNames = map[int]string{permNone: "none", permRead: "read", permWrite: "write"}
+
+// permissionScopes lists every scope of the GITHUB_TOKEN
+var permissionScopes = []string{
+	"actions", "attestations", "checks", "contents", "deployments", "discussions", "id-token",
+	"issues", "packages", "pages", "pull-requests", "repository-projects", "security-events", "statuses",
+}
+
+// Permissions maps token scopes to a permission level
+type Permissions map[string]int
+
+// merge raises the levels of p to those of other
+func (p Permissions) merge(other Permissions) {
+	for scope, level := range other {
+		if level > p[scope] {
+			p[scope] = level
+		}
+	}
+}
+
+// exceeding returns the scopes of p that are above the levels of other, sorted
+func (p Permissions) exceeding(other Permissions) []string {
+	var scopes []string
+	for scope, level := range p {
+		if level > other[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// describe renders the given scopes as "contents: write, packages: write"
+func (p Permissions) describe(scopes []string) string {
+	var parts []string
+	for _, scope := range scopes {
+		parts = append(parts, fmt.Sprintf("%s: %s", scope, permLevelNames[p[scope]]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// block renders the permissions as a `permissions:` YAML block indented by indent spaces
+func (p Permissions) block(indent int) string {
+	pad := strings.Repeat(" ", indent)
+	var scopes []string
+	for scope, level := range p {
+		if level > permNone {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return pad + "permissions: {}"
+	}
+
+	sort.Strings(scopes)
+	lines := []string{pad + "permissions:"}
+	for _, scope := range scopes {
+		lines = append(lines, fmt.Sprintf("%s  %s: %s", pad, scope, permLevelNames[p[scope]]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// actionPermissions lists what well-known actions need from the GITHUB_TOKEN. Actions not
+// listed here are assumed to need nothing unless they are handed the token explicitly.
+var actionPermissions = map[string]Permissions{
+	"actions/checkout":                       {"contents": permRead},
+	"actions/cache":                          {},
+	"actions/upload-artifact":                {},
+	"actions/download-artifact":              {},
+	"actions/setup-go":                       {},
+	"actions/setup-node":                     {},
+	"actions/setup-python":                   {},
+	"actions/setup-java":                     {},
+	"actions/setup-dotnet":                   {},
+	"actions/labeler":                        {"contents": permRead, "pull-requests": permWrite},
+	"actions/stale":                          {"issues": permWrite, "pull-requests": permWrite},
+	"actions/first-interaction":              {"issues": permWrite, "pull-requests": permWrite},
+	"actions/dependency-review-action":       {"contents": permRead},
+	"actions/configure-pages":                {"pages": permWrite},
+	"actions/upload-pages-artifact":          {},
+	"actions/deploy-pages":                   {"pages": permWrite, "id-token": permWrite},
+	"actions/attest-build-provenance":        {"contents": permRead, "id-token": permWrite, "attestations": permWrite},
+	"github/codeql-action":                   {"contents": permRead, "actions": permRead, "security-events": permWrite},
+	"ossf/scorecard-action":                  {"contents": permRead, "actions": permRead, "security-events": permWrite, "id-token": permWrite},
+	"softprops/action-gh-release":            {"contents": permWrite},
+	"ncipollo/release-action":                {"contents": permWrite},
+	"goreleaser/goreleaser-action":           {"contents": permWrite},
+	"peter-evans/create-pull-request":        {"contents": permWrite, "pull-requests": permWrite},
+	"peter-evans/create-or-update-comment":   {"issues": permWrite, "pull-requests": permWrite},
+	"marocchino/sticky-pull-request-comment": {"pull-requests": permWrite},
+	"stefanzweifel/git-auto-commit-action":   {"contents": permWrite},
+	"aws-actions/configure-aws-credentials":  {"id-token": permWrite},
+	"azure/login":                            {"id-token": permWrite},
+	"google-github-actions/auth":             {"id-token": permWrite},
+	"sigstore/cosign-installer":              {},
+	"docker/setup-buildx-action":             {},
+	"docker/setup-qemu-action":               {},
+	"docker/metadata-action":                 {},
+	"docker/build-push-action":               {},
+}
+
+// runPermissions lists commands in run blocks that use the token and what they need
+var runPermissions = []struct {
+	pattern *regexp.Regexp
+	needs   Permissions
+}{
+	{regexp.MustCompile(`\bgh\s+pr\s+(create|comment|edit|merge|review|close|reopen)\b`), Permissions{"pull-requests": permWrite}},
+	{regexp.MustCompile(`\bgh\s+pr\s+(view|list|diff|checks|status)\b`), Permissions{"pull-requests": permRead}},
+	{regexp.MustCompile(`\bgh\s+issue\s+(create|comment|edit|close|reopen)\b`), Permissions{"issues": permWrite}},
+	{regexp.MustCompile(`\bgh\s+issue\s+(view|list)\b`), Permissions{"issues": permRead}},
+	{regexp.MustCompile(`\bgh\s+release\s+(create|upload|edit|delete)\b`), Permissions{"contents": permWrite}},
+	{regexp.MustCompile(`\bgh\s+release\s+(view|list|download)\b`), Permissions{"contents": permRead}},
+	{regexp.MustCompile(`\bgh\s+run\s+(cancel|rerun|delete)\b`), Permissions{"actions": permWrite}},
+	{regexp.MustCompile(`\bgh\s+workflow\s+run\b`), Permissions{"actions": permWrite}},
+	{regexp.MustCompile(`\bgit\s+push\b`), Permissions{"contents": permWrite}},
+	{regexp.MustCompile(`\bdocker\s+push\s+ghcr\.io/`), Permissions{"packages": permWrite}},
+}
+
+// tokenReference matches expressions handing the GITHUB_TOKEN to a step
+var tokenReference = regexp.MustCompile(`\$\{\{\s*(secrets\.GITHUB_TOKEN|github\.token)\s*\}\}`)
+
+// actionName returns the owner/repo part of a `uses:` reference, lower-cased
+func actionName(uses string) string {
+	ref, _, _ := strings.Cut(uses, "@")
+	parts := strings.Split(strings.ToLower(ref), "/")
+	if len(parts) < 2 {
+		return strings.ToLower(ref)
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// parsePermissions reads a `permissions:` node into a Permissions map
+func parsePermissions(n *yaml.Node) Permissions {
+	p := Permissions{}
+	n = resolveAlias(n)
+	if n == nil {
+		return p
+	}
+
+	if n.Kind == yaml.ScalarNode {
+		level := permNone
+		switch n.Value {
+		case "read-all":
+			level = permRead
+		case "write-all":
+			level = permWrite
+		}
+		for _, scope := range permissionScopes {
+			// read-all does not include minting OIDC tokens.
+			if scope == "id-token" && level != permWrite {
+				continue
+			}
+			p[scope] = level
+		}
+		return p
+	}
+
+	for _, pair := range mappingPairs(n) {
+		switch resolveAlias(pair.Value).Value {
+		case "read":
+			p[pair.Key.Value] = permRead
+		case "write":
+			p[pair.Key.Value] = permWrite
+		default:
+			p[pair.Key.Value] = permNone
+		}
+	}
+	return p
+}
+
+// inferJobPermissions returns what a job needs from the token, along with the actions handed
+// the token whose needs are unknown
+func inferJobPermissions(job *yaml.Node) (Permissions, []string) {
+	needs := Permissions{}
+	var unknown []string
+
+	steps := mappingValue(job, "steps")
+	if steps == nil {
+		return needs, nil
+	}
+
+	for _, step := range steps.Content {
+		step = resolveAlias(step)
+		if uses := mappingValue(step, "uses"); uses != nil {
+			name := actionName(uses.Value)
+			if known, ok := actionPermissions[name]; ok {
+				needs.merge(known)
+			} else if stepUsesToken(step) {
+				unknown = append(unknown, name)
+			}
+		}
+
+		if run := mappingValue(step, "run"); run != nil {
+			for _, rp := range runPermissions {
+				if rp.pattern.MatchString(run.Value) {
+					needs.merge(rp.needs)
+				}
+			}
+		}
+	}
+
+	return needs, unknown
+}
+
+// stepUsesToken reports whether a step is handed the GITHUB_TOKEN through with: or env:
+func stepUsesToken(step *yaml.Node) bool {
+	found := false
+	for _, key := range []string{"with", "env"} {
+		walkScalars(mappingValue(step, key), func(n *yaml.Node) {
+			found = found || tokenReference.MatchString(n.Value)
+		})
+	}
+	return found
+}
+
+// PermissionsRule infers which GITHUB_TOKEN permissions each job needs, based on the actions
+// it uses and the commands in its run blocks, and reports the delta versus what is granted
+// along with a suggested minimal `permissions:` block.
+type PermissionsRule struct{}
+
+func (r PermissionsRule) ID() string {
+	return ruleExcessivePermissions
+}
+
+func (r PermissionsRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, root := range wf.roots() {
+		wfPerms := mappingValue(root, "permissions")
+
+		for _, p := range mappingPairs(mappingValue(root, "jobs")) {
+			job := resolveAlias(p.Value)
+			if job == nil || job.Kind != yaml.MappingNode || mappingValue(job, "uses") != nil {
+				// Reusable workflow calls declare their own needs.
+				continue
+			}
+			findings = append(findings, r.checkJob(wf, p.Key, job, wfPerms)...)
+		}
+	}
+	return findings
+}
+
+func (r PermissionsRule) checkJob(wf *WorkflowFile, key, job, wfPerms *yaml.Node) []Finding {
+	needs, unknown := inferJobPermissions(job)
+	suggestion := needs.block(0)
+
+	var note string
+	if len(unknown) > 0 {
+		note = fmt.Sprintf(". Could not infer the needs of %s which receive the token", strings.Join(unknown, ", "))
+	}
+
+	grantedNode := mappingValue(job, "permissions")
+	if grantedNode == nil {
+		grantedNode = wfPerms
+	}
+	if grantedNode == nil {
+		return []Finding{withSuggestion(wf.finding(ruleUndeclaredPermissions, SeverityMedium, key, fmt.Sprintf(
+			"job %s does not declare permissions and runs with the repository default token permissions%s",
+			key.Value, note)), suggestion)}
+	}
+
+	granted := parsePermissions(grantedNode)
+	var findings []Finding
+	if excess := granted.exceeding(needs); len(excess) > 0 {
+		findings = append(findings, withSuggestion(wf.finding(ruleExcessivePermissions, SeverityMedium, grantedNode, fmt.Sprintf(
+			"job %s is granted %s beyond what it needs%s", key.Value, granted.describe(excess), note)), suggestion))
+	}
+	if missing := needs.exceeding(granted); len(missing) > 0 {
+		findings = append(findings, withSuggestion(wf.finding(ruleMissingPermissions, SeverityLow, grantedNode, fmt.Sprintf(
+			"job %s likely needs %s which it is not granted", key.Value, needs.describe(missing))), suggestion))
+	}
+	return findings
+}
+
+// withSuggestion attaches a suggested replacement to a finding
+func withSuggestion(f Finding, suggestion string) Finding {
+	f.Suggestion = suggestion
+	return f
+}
+
+// Fix rewrites the `permissions:` blocks of every job to the inferred minimum. When all jobs
+// of a workflow can be handled this way, the workflow-level block is tightened to
+// `permissions: {}` too, so jobs added later start without any token permissions.
+// Jobs handing the token to actions with unknown needs are left alone.
+func (r PermissionsRule) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	nl := wf.lines.newline()
+
+	var edits []TextEdit
+	for _, root := range wf.roots() {
+		if root.Style&yaml.FlowStyle != 0 {
+			continue
+		}
+		jobsKey, jobsNode := localKey(root, "jobs")
+		if jobsKey == nil || jobsNode.Kind != yaml.MappingNode || jobsNode.Style&yaml.FlowStyle != 0 {
+			continue
+		}
+		wfKey, wfPerms := localKey(root, "permissions")
+
+		type jobFix struct {
+			key, job *yaml.Node
+			needs    Permissions
+		}
+		var fixes []jobFix
+		tighten := true
+		for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+			key, job := jobsNode.Content[i], jobsNode.Content[i+1]
+			if job.Kind != yaml.MappingNode || mappingValue(job, "uses") != nil {
+				// Aliased jobs share their definition and reusable workflow calls inherit the
+				// workflow permissions, so neither can be rewritten in place.
+				tighten = false
+				continue
+			}
+			if key.Value == mergeKey {
+				tighten = false
+				continue
+			}
+			needs, unknown := inferJobPermissions(job)
+			if len(unknown) > 0 || job.Style&yaml.FlowStyle != 0 || len(job.Content) == 0 {
+				logger.Warn("leaving permissions of job unchanged", "file", wf.Path, "job", key.Value, "unknown", unknown)
+				tighten = false
+				continue
+			}
+			fixes = append(fixes, jobFix{key: key, job: job, needs: needs})
+		}
+		if wfPerms != nil && len(parsePermissions(wfPerms).exceeding(Permissions{})) == 0 {
+			// Already as tight as it gets.
+			tighten = false
+		}
+
+		for _, f := range fixes {
+			_, permNode := localKey(f.job, "permissions")
+			var granted Permissions
+			switch {
+			case permNode != nil:
+				granted = parsePermissions(permNode)
+			case tighten:
+				// Inherits the workflow-level block once it is emptied.
+				granted = Permissions{}
+			case mappingValue(f.job, "permissions") != nil:
+				granted = parsePermissions(mappingValue(f.job, "permissions"))
+			case wfPerms != nil:
+				granted = parsePermissions(wfPerms)
+			}
+			if granted != nil && len(granted.exceeding(f.needs)) == 0 && len(f.needs.exceeding(granted)) == 0 {
+				continue
+			}
+
+			edits = append(edits, jobPermissionsEdit(wf, f.job, f.needs))
+		}
+
+		if !tighten {
+			continue
+		}
+		empty := Permissions{}.block(0)
+		if wfKey != nil {
+			start, end := wf.blockExtent(wfKey)
+			edits = append(edits, TextEdit{Start: start, End: end, NewText: blockText(strings.Repeat(" ", wfKey.Column-1)+empty, nl)})
+			continue
+		}
+		start := wf.lines.lineStart(jobsKey.Line)
+		edits = append(edits, TextEdit{Start: start, End: start, NewText: blockText(strings.Repeat(" ", jobsKey.Column-1)+empty, nl)})
+	}
+
+	return edits, nil
+}
+
+// jobPermissionsEdit rewrites the permissions: block of a job to needs, or inserts one
+// above its first key
+func jobPermissionsEdit(wf *WorkflowFile, job *yaml.Node, needs Permissions) TextEdit {
+	nl := wf.lines.newline()
+	if permKey, _ := localKey(job, "permissions"); permKey != nil {
+		start, end := wf.blockExtent(permKey)
+		return TextEdit{Start: start, End: end, NewText: blockText(needs.block(permKey.Column-1), nl)}
+	}
+	first := job.Content[0]
+	start := wf.lines.lineStart(first.Line)
+	return TextEdit{Start: start, End: start, NewText: blockText(needs.block(first.Column-1), nl)}
+}
+
+func (r PermissionsRule) QuickFixes() []string {
+	return []string{ruleExcessivePermissions, ruleMissingPermissions, ruleUndeclaredPermissions}
+}
+
+// QuickFix sets the permissions of the job of a finding to those it needs. Unlike Fix, the
+// workflow-level block is left alone, so the other jobs keep what they are granted.
+func (r PermissionsRule) QuickFix(wf *WorkflowFile, f Finding) (string, []TextEdit) {
+	same := func(g Finding) bool {
+		return g.Rule == f.Rule && g.Line == f.Line && g.Column == f.Column && g.Message == f.Message
+	}
+	for _, root := range wf.roots() {
+		_, jobsNode := localKey(root, "jobs")
+		if root.Style&yaml.FlowStyle != 0 || jobsNode == nil || jobsNode.Kind != yaml.MappingNode || jobsNode.Style&yaml.FlowStyle != 0 {
+			continue
+		}
+		wfPerms := mappingValue(root, "permissions")
+		for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+			key, job := jobsNode.Content[i], jobsNode.Content[i+1]
+			if key.Value == mergeKey || job.Kind != yaml.MappingNode || job.Style&yaml.FlowStyle != 0 || len(job.Content) == 0 || mappingValue(job, "uses") != nil {
+				continue
+			}
+			if !slices.ContainsFunc(r.checkJob(wf, key, job, wfPerms), same) {
+				continue
+			}
+			needs, unknown := inferJobPermissions(job)
+			if len(unknown) > 0 {
+				return "", nil
+			}
+			return fmt.Sprintf("Set the permissions of job %s to those it needs", key.Value), []TextEdit{jobPermissionsEdit(wf, job, needs)}
+		}
+	}
+	return "", nil
+}
+
+// blockText turns a rendered block into lines ending with the line break of the file
+func blockText(block, nl string) string {
+	return strings.ReplaceAll(block, "\n", nl) + nl
+}
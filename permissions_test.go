@@ -0,0 +1,274 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- Tests for parsePermissions ---
+
+func TestParsePermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   map[string]int
+	}{
+		{name: "mapping", content: "permissions:\n  contents: read\n  packages: write\n  issues: none\n", check: map[string]int{"contents": permRead, "packages": permWrite, "issues": permNone}},
+		{name: "read-all", content: "permissions: read-all\n", check: map[string]int{"contents": permRead, "id-token": permNone}},
+		{name: "write-all", content: "permissions: write-all\n", check: map[string]int{"contents": permWrite, "id-token": permWrite}},
+		{name: "empty", content: "permissions: {}\n", check: map[string]int{"contents": permNone}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wf := newWorkflowFile("wf.yml", []byte(tc.content))
+			p := parsePermissions(mappingValue(wf.roots()[0], "permissions"))
+			for scope, level := range tc.check {
+				if p[scope] != level {
+					t.Errorf("%s = %d; want %d", scope, p[scope], level)
+				}
+			}
+		})
+	}
+}
+
+// --- Tests for inferJobPermissions ---
+
+func TestInferJobPermissions(t *testing.T) {
+	content := `
+jobs:
+  release:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: github/codeql-action/init@v3
+      - run: |
+          gh release create v1.0.0
+          gh pr comment 1 --body done
+      - uses: some/unknown-action@v1
+        with:
+          token: ${{ secrets.GITHUB_TOKEN }}
+      - uses: other/harmless@v1
+`
+	wf := newWorkflowFile("wf.yml", []byte(content))
+	needs, unknown := inferJobPermissions(wf.jobs()[0].Node)
+
+	expected := Permissions{
+		"contents":        permWrite,
+		"actions":         permRead,
+		"security-events": permWrite,
+		"pull-requests":   permWrite,
+	}
+	if !reflect.DeepEqual(needs, expected) {
+		t.Errorf("inferJobPermissions() needs = %v; want %v", needs, expected)
+	}
+	if !reflect.DeepEqual(unknown, []string{"some/unknown-action"}) {
+		t.Errorf("inferJobPermissions() unknown = %v", unknown)
+	}
+}
+
+// --- Tests for PermissionsRule ---
+
+func TestPermissionsRule_Check(t *testing.T) {
+	content := `
+on: push
+permissions: write-all
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+  publish:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+    steps:
+      - run: git push origin HEAD
+  minimal:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+    steps:
+      - uses: actions/checkout@v4
+  call:
+    uses: owner/repo/.github/workflows/reusable.yml@main
+`
+	findings := PermissionsRule{}.Check(newWorkflowFile("ci.yml", []byte(content)))
+
+	byRule := map[string][]Finding{}
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+
+	if len(byRule[ruleExcessivePermissions]) != 1 {
+		t.Fatalf("expected one excessive-permissions finding, got %+v", findings)
+	}
+	excess := byRule[ruleExcessivePermissions][0]
+	if !strings.Contains(excess.Message, "job build") || excess.Line != 3 {
+		t.Errorf("unexpected finding: %+v", excess)
+	}
+	if excess.Suggestion != "permissions:\n  contents: read" {
+		t.Errorf("unexpected suggestion %q", excess.Suggestion)
+	}
+
+	if len(byRule[ruleMissingPermissions]) != 1 || !strings.Contains(byRule[ruleMissingPermissions][0].Message, "contents: write") {
+		t.Errorf("expected publish job to be missing contents: write, got %+v", byRule[ruleMissingPermissions])
+	}
+	if len(findings) != 2 {
+		t.Errorf("expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+}
+
+func TestPermissionsRule_Undeclared(t *testing.T) {
+	content := `
+on: push
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+`
+	findings := PermissionsRule{}.Check(newWorkflowFile("ci.yml", []byte(content)))
+	if len(findings) != 1 || findings[0].Rule != ruleUndeclaredPermissions {
+		t.Fatalf("expected one undeclared-permissions finding, got %+v", findings)
+	}
+	if findings[0].Suggestion != "permissions: {}" || findings[0].Line != 4 {
+		t.Errorf("unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestPermissionsRule_Fix(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "insert job and workflow blocks",
+			content: `on: push
+jobs:
+  build:
+    # keep this comment
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+`,
+			expected: `on: push
+permissions: {}
+jobs:
+  build:
+    # keep this comment
+    permissions:
+      contents: read
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+`,
+		},
+		{
+			name: "tighten existing blocks",
+			content: `on: push
+permissions: write-all # too much
+jobs:
+  release:
+    permissions:
+      contents: write
+      packages: write
+
+    steps:
+      - run: gh release create v1
+`,
+			expected: `on: push
+permissions: {}
+jobs:
+  release:
+    permissions:
+      contents: write
+
+    steps:
+      - run: gh release create v1
+`,
+		},
+		{
+			name: "unknown token users are left alone",
+			content: `permissions: write-all
+jobs:
+  deploy:
+    steps:
+      - uses: some/action@v1
+        with:
+          token: ${{ github.token }}
+  lint:
+    steps:
+      - run: make lint
+`,
+			expected: `permissions: write-all
+jobs:
+  deploy:
+    steps:
+      - uses: some/action@v1
+        with:
+          token: ${{ github.token }}
+  lint:
+    permissions: {}
+    steps:
+      - run: make lint
+`,
+		},
+		{
+			name: "reusable workflow calls keep the workflow block",
+			content: `permissions:
+  contents: read
+jobs:
+  call:
+    uses: org/repo/.github/workflows/ci.yml@main
+  build:
+    steps:
+      - uses: actions/checkout@v4
+`,
+			expected: `permissions:
+  contents: read
+jobs:
+  call:
+    uses: org/repo/.github/workflows/ci.yml@main
+  build:
+    steps:
+      - uses: actions/checkout@v4
+`,
+		},
+		{
+			name:     "crlf line endings",
+			content:  "jobs:\r\n  build:\r\n    steps:\r\n      - uses: actions/checkout@v4\r\n",
+			expected: "permissions: {}\r\njobs:\r\n  build:\r\n    permissions:\r\n      contents: read\r\n    steps:\r\n      - uses: actions/checkout@v4\r\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixed, err := fixContent("wf.yml", []byte(tc.content), []Fixer{PermissionsRule{}})
+			if err != nil {
+				t.Fatalf("fixContent returned error: %v", err)
+			}
+			if string(fixed) != tc.expected {
+				t.Errorf("fixed =\n%s\nwant\n%s", fixed, tc.expected)
+			}
+
+			// Fixing again must not change anything.
+			again, err := fixContent("wf.yml", fixed, []Fixer{PermissionsRule{}})
+			if err != nil {
+				t.Fatalf("fixContent returned error: %v", err)
+			}
+			if string(again) != string(fixed) {
+				t.Errorf("fix is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
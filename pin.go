@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PinFixer pins the `uses:` references of workflows to the commit their ref points to,
+// keeping the ref in a comment: `actions/checkout@<commit-sha> # v4`. Only the references
+// themselves are rewritten, so formatting and comments of the rest of the file are kept.
+type PinFixer struct {
+	Resolver Resolver
+}
+
+// Fix replaces the ref of every `uses:` reference not pinned to a commit SHA yet.
+// References that cannot be resolved are left as they are.
+func (f PinFixer) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	var edits []TextEdit
+	for _, n := range wf.usesNodes() {
+		if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || strings.HasPrefix(n.Value, "./") || strings.HasPrefix(n.Value, "docker://") {
+			continue
+		}
+		action, ref, ok := strings.Cut(n.Value, "@")
+		parts := strings.SplitN(action, "/", 3)
+		if !ok || len(parts) < 2 || fullSHA.MatchString(ref) {
+			continue
+		}
+
+		// Actions in subdirectories share the refs of their repository.
+		sha, err := f.Resolver.resolve(parts[0] + "/" + parts[1] + "@" + ref)
+		if err != nil {
+			logger.Warn("could not resolve reference to a commit", "ref", n.Value, "err", err)
+			continue
+		}
+		start, ok := wf.lines.locate(n.Value, wf.lines.offset(n.Line, n.Column))
+		if !ok {
+			logger.Debug("could not locate reference to pin", "file", wf.Path, "line", n.Line, "ref", n.Value)
+			continue
+		}
+		m := Match{Value: n.Value}
+		m.Line, m.Column = wf.lines.position(start)
+		edits = append(edits, pinEdit(wf.lines, m, sha))
+	}
+	return edits, nil
+}
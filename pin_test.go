@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+// --- Tests for PinFixer.Fix ---
+
+func TestPinFixer_Fix(t *testing.T) {
+	fixer := PinFixer{Resolver: fakeResolver{
+		"actions/checkout@v4":      lockedSHA,
+		"actions/setup-go@v5":      movedSHA,
+		"github/codeql-action@v3":  lockedSHA,
+		"acme/workflows@main":      movedSHA,
+		"actions/upload-artifact@": lockedSHA,
+	}}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "ref kept in a comment",
+			content:  "on: push\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - run: echo actions/checkout@v4\n",
+			expected: "on: push\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@" + lockedSHA + " # v4\n      - run: echo actions/checkout@v4\n",
+		},
+		{
+			name:     "existing comment kept",
+			content:  "jobs:\n  build:\n    steps:\n      - uses: actions/setup-go@v5 # Go toolchain\n",
+			expected: "jobs:\n  build:\n    steps:\n      - uses: actions/setup-go@" + movedSHA + " # Go toolchain\n",
+		},
+		{
+			name:     "quoted with subdirectory",
+			content:  "jobs:\n  analyze:\n    steps:\n      - uses: \"github/codeql-action/init@v3\"\n",
+			expected: "jobs:\n  analyze:\n    steps:\n      - uses: \"github/codeql-action/init@" + lockedSHA + "\" # v3\n",
+		},
+		{
+			name:     "reusable workflow",
+			content:  "jobs:\n  call:\n    uses: acme/workflows/.github/workflows/ci.yml@main\n",
+			expected: "jobs:\n  call:\n    uses: acme/workflows/.github/workflows/ci.yml@" + movedSHA + " # main\n",
+		},
+		{
+			name:     "pinned, unresolved, local and docker references",
+			content:  "jobs:\n  build:\n    steps:\n      - uses: actions/cache@" + lockedSHA + " # v4\n      - uses: acme/unknown@v1\n      - uses: ./actions/checkout\n      - uses: docker://alpine:3\n",
+			expected: "jobs:\n  build:\n    steps:\n      - uses: actions/cache@" + lockedSHA + " # v4\n      - uses: acme/unknown@v1\n      - uses: ./actions/checkout\n      - uses: docker://alpine:3\n",
+		},
+		{
+			name:     "anchored step is rewritten once",
+			content:  "jobs:\n  a:\n    steps:\n      - &co\n        uses: actions/checkout@v4\n  b:\n    steps:\n      - *co\n",
+			expected: "jobs:\n  a:\n    steps:\n      - &co\n        uses: actions/checkout@" + lockedSHA + " # v4\n  b:\n    steps:\n      - *co\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fixed, err := fixContent("wf.yml", []byte(tc.content), []Fixer{fixer})
+			if err != nil {
+				t.Fatalf("fixContent returned error: %v", err)
+			}
+			if string(fixed) != tc.expected {
+				t.Errorf("fixed =\n%s\nwant\n%s", fixed, tc.expected)
+			}
+
+			// Fixing again must not change anything.
+			again, err := fixContent("wf.yml", fixed, []Fixer{fixer})
+			CheckIfError(err)
+			if string(again) != string(fixed) {
+				t.Errorf("fix is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ruleBannedAction flags actions banned by the action policy, pinned or not
+const ruleBannedAction = "banned-action"
+
+// sourcePolicy is the source of findings accepted by the action policy
+const sourcePolicy = "policy"
+
+// ActionPolicy sets which actions are trusted by tag and which may not be used at all.
+// Entries are globs of actions, such as actions/*, or of references when they hold a ref,
+// such as docker/login-action@v3.
+type ActionPolicy struct {
+	// Trusted lists the publishers and actions whose mutable references are accepted
+	Trusted []string `yaml:"trusted"`
+	// Banned lists the actions failing the scan wherever they are used
+	Banned []BannedAction `yaml:"banned"`
+}
+
+// BannedAction is an action the policy bans, with why
+type BannedAction struct {
+	Uses   string `yaml:"uses"`
+	Reason string `yaml:"reason"`
+}
+
+// check validates the globs of the policy section of the configuration
+func (p ActionPolicy) check() error {
+	globs := slices.Clone(p.Trusted)
+	for _, b := range p.Banned {
+		if b.Uses == "" {
+			return fmt.Errorf("policy: banned entries need uses")
+		}
+		globs = append(globs, b.Uses)
+	}
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("policy: %s: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// trust splits mutable references into those still reported and those of trusted
+// actions, accepted with the policy entry they matched
+func (p ActionPolicy) trust(matches []Match) ([]Match, []SuppressedFinding) {
+	if len(p.Trusted) == 0 {
+		return matches, nil
+	}
+	var kept []Match
+	var trusted []SuppressedFinding
+	for _, m := range matches {
+		entry, ok := matchingAction(p.Trusted, m.Value)
+		if !ok {
+			kept = append(kept, m)
+			continue
+		}
+		trusted = append(trusted, SuppressedFinding{
+			Finding: Finding{
+				Rule:     ruleMutableReference,
+				Severity: SeverityMedium,
+				Message:  fmt.Sprintf("%s is a mutable reference. Pin it to a commit SHA", m.Value),
+				Line:     m.Line,
+				Column:   m.Column,
+			},
+			Reason: fmt.Sprintf("trusted by policy entry %s", entry),
+			Source: sourcePolicy,
+		})
+	}
+	return kept, trusted
+}
+
+// BannedActionRule flags every use of a banned action, even pinned to a commit SHA: a
+// compromised or unapproved action is not made safe by pinning it.
+type BannedActionRule struct {
+	Banned []BannedAction
+}
+
+func (r BannedActionRule) ID() string {
+	return ruleBannedAction
+}
+
+func (r BannedActionRule) Check(wf *WorkflowFile) []Finding {
+	globs := make([]string, len(r.Banned))
+	for i, b := range r.Banned {
+		globs[i] = b.Uses
+	}
+
+	var findings []Finding
+	for _, n := range wf.usesNodes() {
+		if strings.HasPrefix(n.Value, "./") || strings.HasPrefix(n.Value, "docker://") || strings.Contains(n.Value, "${{") {
+			continue
+		}
+		entry, ok := matchingAction(globs, n.Value)
+		if !ok {
+			continue
+		}
+		msg := fmt.Sprintf("%s is banned by policy entry %s", n.Value, entry)
+		for _, b := range r.Banned {
+			if b.Uses == entry && b.Reason != "" {
+				msg += ": " + b.Reason
+				break
+			}
+		}
+		findings = append(findings, wf.finding(ruleBannedAction, SeverityCritical, n, msg))
+	}
+	return findings
+}
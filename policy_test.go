@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for ActionPolicy.check ---
+
+func TestActionPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ActionPolicy
+		valid  bool
+	}{
+		{"valid", ActionPolicy{Trusted: []string{"actions/*"}, Banned: []BannedAction{{Uses: "tj-actions/changed-files"}}}, true},
+		{"malformed trusted glob", ActionPolicy{Trusted: []string{"["}}, false},
+		{"banned entry without uses", ActionPolicy{Banned: []BannedAction{{Reason: "compromised"}}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.check(); (err == nil) != tc.valid {
+				t.Errorf("check() = %v; want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+// --- Tests for ActionPolicy.trust ---
+
+func TestActionPolicy_Trust(t *testing.T) {
+	policy := ActionPolicy{Trusted: []string{"actions/*", "github/codeql-action"}}
+	matches := []Match{
+		{Value: "actions/checkout@v4", Line: 5, Column: 15},
+		{Value: "acme/deploy@main", Line: 6, Column: 15},
+		{Value: "github/codeql-action/init@v3", Line: 7, Column: 15},
+	}
+
+	kept, trusted := policy.trust(matches)
+	if len(kept) != 1 || kept[0].Value != "acme/deploy@main" {
+		t.Errorf("expected only the untrusted reference to be kept, got %v", kept)
+	}
+	if len(trusted) != 2 {
+		t.Fatalf("expected 2 trusted references, got %+v", trusted)
+	}
+	first := trusted[0]
+	if first.Rule != ruleMutableReference || first.Line != 5 || first.Source != sourcePolicy || first.Reason != "trusted by policy entry actions/*" {
+		t.Errorf("unexpected trusted reference %+v", first)
+	}
+	if trusted[1].Reason != "trusted by policy entry github/codeql-action" {
+		t.Errorf("expected the matching entry in the reason, got %q", trusted[1].Reason)
+	}
+
+	if kept, trusted := (ActionPolicy{}).trust(matches); len(kept) != 3 || trusted != nil {
+		t.Errorf("expected every reference to be kept without trusted entries, got %v %v", kept, trusted)
+	}
+}
+
+// --- Tests for BannedActionRule ---
+
+func TestBannedActionRule_InlineSuppressions(t *testing.T) {
+	content := []byte(`jobs:
+  build:
+    steps:
+      # scharf-ignore: banned-action we need it
+      - uses: tj-actions/changed-files@v45
+      - uses: tj-actions/changed-files@v44 # scharf-ignore: * anything goes
+`)
+	rule := BannedActionRule{Banned: []BannedAction{{Uses: "tj-actions/changed-files"}}}
+	findings := rule.Check(newWorkflowFile("ci.yml", content))
+
+	kept, suppressed := applySuppressions(findings, content, ".github/workflows/ci.yml", nil)
+	if len(kept) != 2 || len(suppressed) != 0 {
+		t.Errorf("expected inline comments not to lift the ban, got %+v suppressed %+v", kept, suppressed)
+	}
+	config := []Suppression{{Rule: ruleBannedAction, Path: ".github/workflows/ci.yml", Reason: "migrating off by June"}}
+	if kept, suppressed := applySuppressions(findings, content, ".github/workflows/ci.yml", config); len(kept) != 0 || len(suppressed) != 2 {
+		t.Errorf("expected the configuration to lift the ban, got %+v suppressed %+v", kept, suppressed)
+	}
+}
+
+func TestBannedActionRule(t *testing.T) {
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: tj-actions/changed-files@0e58ed8671d6b60d0890c21b07f8835ace038e67 # v44
+      - uses: actions/checkout@v4
+      - uses: docker/login-action@v2
+      - uses: docker/login-action@v3
+      - uses: ./local-action
+  deploy:
+    uses: evil-org/workflows/.github/workflows/deploy.yml@main
+`
+	rule := BannedActionRule{Banned: []BannedAction{
+		{Uses: "tj-actions/changed-files", Reason: "compromised in March 2025"},
+		{Uses: "docker/login-action@v2"},
+		{Uses: "evil-org/*"},
+	}}
+
+	findings := rule.Check(newWorkflowFile("ci.yml", []byte(content)))
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	tests := []struct {
+		line    int
+		message string
+	}{
+		{6, "is banned by policy entry tj-actions/changed-files: compromised in March 2025"},
+		{8, "docker/login-action@v2 is banned by policy entry docker/login-action@v2"},
+		{12, "is banned by policy entry evil-org/*"},
+	}
+	for i, tc := range tests {
+		f := findings[i]
+		if f.Rule != ruleBannedAction || f.Severity != SeverityCritical || f.Line != tc.line || !strings.Contains(f.Message, tc.message) {
+			t.Errorf("unexpected finding %+v; want line %d with %q", f, tc.line, tc.message)
+		}
+	}
+}
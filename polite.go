@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// politeInterval is the minimum delay between two requests to GitHub in polite mode
+const politeInterval = time.Second
+
+// politeWorkers is how many repositories are scanned at once in polite mode, whatever
+// --concurrency asks for
+const politeWorkers = 1
+
+// politeUserAgent identifies scharf to the owners of the repositories it scans
+const politeUserAgent = "scharf (+https://github.com/cybrota/scharf)"
+
+// cachedResponse is a response kept to answer conditional requests
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// politeClient sends requests and clones one at a time, no faster than its interval, and
+// revalidates what it already fetched with conditional requests. GitHub does not count requests answered
+// with 304 Not Modified against the rate limit, and they cost the server next to nothing.
+// It is meant for scanning repositories we do not own, such as vendor due-diligence scans.
+type politeClient struct {
+	interval time.Duration
+	sleep    func(time.Duration)
+	now      func() time.Time
+
+	mu    sync.Mutex
+	next  time.Time
+	cache map[string]cachedResponse
+}
+
+// polite is the client of polite mode, nil when it is off
+var polite *politeClient
+
+// enablePoliteMode throttles all GitHub requests and clones of the process
+func enablePoliteMode(interval time.Duration) {
+	polite = newPoliteClient(interval)
+	gitTransport = politeTransport{next: gitTransport, polite: polite}
+}
+
+func newPoliteClient(interval time.Duration) *politeClient {
+	return &politeClient{interval: interval, sleep: time.Sleep, now: time.Now, cache: map[string]cachedResponse{}}
+}
+
+// wait blocks until the next request may be sent. Holding the lock while waiting also keeps
+// requests from running concurrently.
+func (p *politeClient) wait() {
+	if now := p.now(); now.Before(p.next) {
+		p.sleep(p.next.Sub(now))
+	}
+	p.next = p.now().Add(p.interval)
+}
+
+// do sends a request politely. Only GET requests are revalidated.
+func (p *politeClient) do(req *http.Request) (*http.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.wait()
+
+	req.Header.Set("User-Agent", politeUserAgent)
+	if req.Method != http.MethodGet {
+		return githubClient.do(req)
+	}
+
+	key := req.URL.String()
+	cached, ok := p.cache[key]
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := githubClient.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+		return &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Header:     cached.header.Clone(),
+			Body:       io.NopCloser(bytes.NewReader(cached.body)),
+			Request:    req,
+		}, nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	p.cache[key] = cachedResponse{etag: etag, lastModified: lastModified, header: resp.Header.Clone(), body: body}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// politeTransport clones and pushes through next in turn with the requests of polite
+type politeTransport struct {
+	next   GitTransport
+	polite *politeClient
+}
+
+func (t politeTransport) Clone(dir, cloneURL, ref string, sparse []string) error {
+	t.polite.mu.Lock()
+	defer t.polite.mu.Unlock()
+	t.polite.wait()
+	return t.next.Clone(dir, cloneURL, ref, sparse)
+}
+
+func (t politeTransport) Push(dir, remote string, refSpecs []string) error {
+	t.polite.mu.Lock()
+	defer t.polite.mu.Unlock()
+	t.polite.wait()
+	return t.next.Push(dir, remote, refSpecs)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// --- Tests for politeClient ---
+
+func TestPoliteClient_ConditionalRequests(t *testing.T) {
+	requests := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		if req.Header.Get("User-Agent") != politeUserAgent {
+			t.Errorf("request without polite user agent: %q", req.Header.Get("User-Agent"))
+		}
+		h := make(http.Header)
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return &http.Response{StatusCode: http.StatusNotModified, Body: io.NopCloser(bytes.NewReader(nil)), Header: h}, nil
+		}
+		if requests > 1 {
+			t.Errorf("second request was not conditional: %v", req.Header)
+		}
+		h.Set("ETag", `"v1"`)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte("content"))), Header: h}, nil
+	})
+
+	p := newPoliteClient(0)
+	withHTTPClientTransport(transport, func() {
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo", nil)
+			resp, err := p.do(req)
+			CheckIfError(err)
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || string(body) != "content" {
+				t.Errorf("request %d: got %d %q; want 200 content", i, resp.StatusCode, body)
+			}
+		}
+	})
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestPoliteClient_Throttles(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil)), Header: make(http.Header)}, nil
+	})
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	p := newPoliteClient(time.Second)
+	p.now = func() time.Time { return clock }
+	p.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+
+	withHTTPClientTransport(transport, func() {
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo", nil)
+			_, err := p.do(req)
+			CheckIfError(err)
+			clock = clock.Add(300 * time.Millisecond)
+		}
+	})
+
+	if len(slept) != 2 || slept[0] != 700*time.Millisecond || slept[1] != 700*time.Millisecond {
+		t.Errorf("expected two waits of 700ms, got %v", slept)
+	}
+}
+
+func TestGithubGet_PoliteMode(t *testing.T) {
+	saved := gitTransport
+	t.Cleanup(func() { polite, gitTransport = nil, saved })
+	enablePoliteMode(0)
+
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("User-Agent") != politeUserAgent {
+			t.Errorf("githubGet did not go through the polite client")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil)), Header: make(http.Header)}, nil
+	})
+	withHTTPClientTransport(transport, func() {
+		resp, err := githubGet("https://api.github.com/repos/owner/repo")
+		CheckIfError(err)
+		resp.Body.Close()
+	})
+}
+
+func TestGithubSend_PoliteMode(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "token")
+	saved := gitTransport
+	t.Cleanup(func() { polite, gitTransport = nil, saved })
+	enablePoliteMode(0)
+
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("User-Agent") != politeUserAgent || req.Header.Get("If-None-Match") != "" {
+			t.Errorf("githubSend did not go through the polite client without revalidation: %v", req.Header)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil)), Header: http.Header{"Etag": {`"v1"`}}}, nil
+	})
+	withHTTPClientTransport(transport, func() {
+		for range 2 {
+			resp, err := githubSend(http.MethodPatch, "https://api.github.com/repos/owner/repo/pulls/1", map[string]string{"title": "t"})
+			CheckIfError(err)
+			resp.Body.Close()
+		}
+	})
+}
+
+// recordingTransport clones and pushes nothing, and counts what it was asked for
+type recordingTransport struct {
+	calls *int
+}
+
+func (t recordingTransport) Clone(dir, cloneURL, ref string, sparse []string) error {
+	*t.calls++
+	return nil
+}
+
+func (t recordingTransport) Push(dir, remote string, refSpecs []string) error {
+	*t.calls++
+	return nil
+}
+
+func TestPoliteTransport_Throttles(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	p := newPoliteClient(time.Second)
+	p.now = func() time.Time { return clock }
+	p.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+
+	calls := 0
+	transport := politeTransport{next: recordingTransport{calls: &calls}, polite: p}
+	CheckIfError(transport.Clone(t.TempDir(), "https://github.com/owner/a.git", "", nil))
+	CheckIfError(transport.Clone(t.TempDir(), "https://github.com/owner/b.git", "", nil))
+	CheckIfError(transport.Push(t.TempDir(), "origin", []string{"+refs/heads/fix:refs/heads/fix"}))
+
+	if calls != 3 || len(slept) != 2 || slept[0] != time.Second || slept[1] != time.Second {
+		t.Errorf("expected 3 calls, 2 waits of 1s apart, got %d calls and waits %v", calls, slept)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// utf8BOM is stripped by editors on some platforms and kept by others (notably Windows)
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// lineIndex maps byte offsets of a file to 1-based line and column numbers.
+// Line breaks follow YAML rules: "\r\n", "\n" and a lone "\r" each end a line,
+// so files checked out with CRLF endings report the same positions as LF files.
+// Columns are counted in characters, not bytes, to match the YAML parser.
+type lineIndex struct {
+	content []byte
+	starts  []int
+
+	// last resolved position, so consecutive lookups on a long line stay linear
+	lastOffset, lastLine, lastColumn int
+}
+
+func newLineIndex(content []byte) *lineIndex {
+	starts := []int{0}
+	if bytes.HasPrefix(content, utf8BOM) {
+		starts[0] = len(utf8BOM)
+	}
+
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\r':
+			if i+1 < len(content) && content[i+1] == '\n' {
+				i++
+			}
+			starts = append(starts, i+1)
+		case '\n':
+			starts = append(starts, i+1)
+		}
+	}
+
+	return &lineIndex{content: content, starts: starts}
+}
+
+// position returns the line and column of a byte offset
+func (li *lineIndex) position(offset int) (int, int) {
+	if offset > len(li.content) {
+		offset = len(li.content)
+	}
+
+	// Binary search for the last line starting at or before offset.
+	lo, hi := 0, len(li.starts)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if li.starts[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	start := li.starts[lo]
+	if offset < start {
+		offset = start
+	}
+
+	line, col := lo+1, 1
+	if li.lastLine == line && li.lastOffset <= offset {
+		start, col = li.lastOffset, li.lastColumn
+	}
+	col += utf8.RuneCount(li.content[start:offset])
+
+	li.lastOffset, li.lastLine, li.lastColumn = offset, line, col
+	return line, col
+}
+
+// offset returns the byte offset of a line and column, clamped to the file bounds
+func (li *lineIndex) offset(line, column int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(li.starts) {
+		return len(li.content)
+	}
+
+	off := li.starts[line-1]
+	for c := 1; c < column && off < len(li.content); c++ {
+		if li.content[off] == '\r' || li.content[off] == '\n' {
+			break
+		}
+		_, size := utf8.DecodeRune(li.content[off:])
+		off += size
+	}
+	return off
+}
+
+// locate finds value at or after byte offset from and returns its offset.
+// YAML nodes only know where a scalar starts, so this pins down a match inside quoted
+// or block scalars.
+func (li *lineIndex) locate(value string, from int) (int, bool) {
+	if from > len(li.content) {
+		return 0, false
+	}
+	idx := bytes.Index(li.content[from:], []byte(value))
+	if idx < 0 {
+		return 0, false
+	}
+	return from + idx, true
+}
+
+// lineCount returns the number of lines in the file
+func (li *lineIndex) lineCount() int {
+	return len(li.starts)
+}
+
+// lineStart returns the byte offset at which a 1-based line starts
+func (li *lineIndex) lineStart(line int) int {
+	if line > len(li.starts) {
+		return len(li.content)
+	}
+	return li.starts[line-1]
+}
+
+// lineEnd returns the byte offset right after the line break ending a 1-based line
+func (li *lineIndex) lineEnd(line int) int {
+	if line >= len(li.starts) {
+		return len(li.content)
+	}
+	return li.starts[line]
+}
+
+// lineText returns a 1-based line without its line break
+func (li *lineIndex) lineText(line int) string {
+	return string(bytes.TrimRight(li.content[li.lineStart(line):li.lineEnd(line)], "\r\n"))
+}
+
+// newline returns the line break used by the file, so inserted lines match the existing ones
+func (li *lineIndex) newline() string {
+	if bytes.Contains(li.content, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- Tests for lineIndex ---
+
+func TestLineIndex_Position(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		offset   int
+		wantLine int
+		wantCol  int
+	}{
+		{name: "start of file", content: "a\nb", offset: 0, wantLine: 1, wantCol: 1},
+		{name: "LF second line", content: "ab\ncd", offset: 4, wantLine: 2, wantCol: 2},
+		{name: "CRLF second line", content: "ab\r\ncd", offset: 5, wantLine: 2, wantCol: 2},
+		{name: "lone CR is a line break", content: "ab\rcd", offset: 4, wantLine: 2, wantCol: 2},
+		{name: "multi-byte characters count as one column", content: "é: x", offset: 4, wantLine: 1, wantCol: 4},
+		{name: "BOM is not counted", content: "\xef\xbb\xbfab", offset: 4, wantLine: 1, wantCol: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			line, col := newLineIndex([]byte(tc.content)).position(tc.offset)
+			if line != tc.wantLine || col != tc.wantCol {
+				t.Errorf("position(%d) = (%d, %d); want (%d, %d)", tc.offset, line, col, tc.wantLine, tc.wantCol)
+			}
+		})
+	}
+}
+
+func TestLineIndex_OffsetRoundTrip(t *testing.T) {
+	li := newLineIndex([]byte("first\r\nsecond\r\nthird"))
+	off := li.offset(2, 3)
+	if line, col := li.position(off); line != 2 || col != 3 {
+		t.Errorf("position(offset(2, 3)) = (%d, %d); want (2, 3)", line, col)
+	}
+	if got := li.offset(10, 1); got != len("first\r\nsecond\r\nthird") {
+		t.Errorf("offset beyond last line should clamp to end of file, got %d", got)
+	}
+}
+
+// --- Tests for match positions across line endings ---
+
+func TestScanContent_PositionsIgnoreLineEndings(t *testing.T) {
+	lf := `name: ci
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - name: quoted
+        uses: "actions/setup-go@v5"
+`
+	expected := []Match{
+		{Value: "actions/checkout@v4", Line: 5, Column: 15},
+		{Value: "actions/setup-go@v5", Line: 7, Column: 16},
+	}
+
+	scanner := GitHubWorkFlowScanner{}
+	for name, content := range map[string]string{
+		"LF":   lf,
+		"CRLF": strings.ReplaceAll(lf, "\n", "\r\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			matches, err := scanner.ScanContent([]byte(content), mutableRefRegex)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(matches, expected) {
+				t.Errorf("ScanContent() = %v; want %v", matches, expected)
+			}
+		})
+	}
+}
+
+func TestScanText_PositionsWithCRLF(t *testing.T) {
+	content := "not: [yaml\r\n  uses: actions/checkout@v4\r\n"
+	matches := scanText(newLineIndex([]byte(content)), mutableRefRegex)
+	expected := []Match{{Value: "actions/checkout@v4", Line: 2, Column: 9}}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("scanText() = %v; want %v", matches, expected)
+	}
+}
+
+// --- Tests for path handling ---
+
+func TestWorkflowDir(t *testing.T) {
+	root := filepath.Join("workspace", "repo")
+	expected := filepath.Join("workspace", "repo", ".github", "workflows")
+	if got := workflowDir(root); got != expected {
+		t.Errorf("workflowDir(%q) = %q; want %q", root, got, expected)
+	}
+}
+
+func TestShouldIncludeDir_CaseInsensitive(t *testing.T) {
+	for _, name := range []string{".ds_store", ".DS_STORE", ".Ruff_Cache"} {
+		if shouldIncludeDir(name) {
+			t.Errorf("shouldIncludeDir(%q) = true; expected false", name)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// defaultFixBranch is the branch fixes are pushed to for pull requests
+const defaultFixBranch = "sharfer/fix"
+
+// PullRequest is a pull request opened on GitHub
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	NodeID  string `json:"node_id"`
+	Head    struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+// FixPROptions configures the pull request opened for fixes
+type FixPROptions struct {
+	// Owner and Name identify the GitHub repository the pull request is opened on
+	Owner string
+	Name  string
+	// Base is the branch to merge into. It defaults to the checked out branch.
+	Base string
+	// Branch receives the fix commit. It defaults to defaultFixBranch.
+	Branch string
+	Title  string
+	// OnPushed, when set, is called once the branch is pushed, before the pull request is opened
+	OnPushed func()
+}
+
+// createPullRequest opens a pull request merging head into base
+func createPullRequest(owner, name, head, base, title, body string) (*PullRequest, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/pulls", apiURL, owner, name)
+	resp, err := githubSend(http.MethodPost, endpoint, map[string]string{
+		"title": title,
+		"head":  head,
+		"base":  base,
+		"body":  body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("http: opening pull request on %s/%s returned %s", owner, name, resp.Status)
+	}
+
+	var pr PullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return &pr, nil
+}
+
+// findPullRequest returns the open pull request from a branch of the repository, if any
+func findPullRequest(owner, name, head string) (*PullRequest, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/pulls?state=open&head=%s", apiURL, owner, name, url.QueryEscape(owner+":"+head))
+	var prs []PullRequest
+	found, err := getJSON(endpoint, &prs)
+	if err != nil || !found || len(prs) == 0 {
+		return nil, err
+	}
+	return &prs[0], nil
+}
+
+// updatePullRequest replaces the title and body of a pull request
+func updatePullRequest(owner, name string, number int, title, body string) (*PullRequest, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/pulls/%d", apiURL, owner, name, number)
+	resp, err := githubSend(http.MethodPatch, endpoint, map[string]string{"title": title, "body": body})
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: updating pull request #%d on %s/%s returned %s", number, owner, name, resp.Status)
+	}
+
+	var pr PullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return &pr, nil
+}
+
+// openFixPR commits fixed files of the repository at root to a fresh branch, pushes it to
+// origin and opens a pull request for it. The fixed files must already be written.
+//
+// The branch is recreated from the checked out branch on every run, and force pushed, so a
+// fix branch of an earlier run that conflicts with the base is replaced rather than merged.
+// An open pull request from the branch is updated instead of opening another one.
+func openFixPR(root string, results []FixResult, opts FixPROptions) (*PullRequest, error) {
+	if opts.Branch == "" {
+		opts.Branch = defaultFixBranch
+	}
+
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	if opts.Base == "" {
+		if !head.Name().IsBranch() {
+			return nil, errors.New("HEAD is detached. Please check out the branch to open the pull request against")
+		}
+		opts.Base = head.Name().Short()
+	}
+
+	if err := pushFixBranch(repo, head, root, results, opts); err != nil {
+		return nil, err
+	}
+	if opts.OnPushed != nil {
+		opts.OnPushed()
+	}
+
+	body := fixPRBody(results, root)
+	existing, err := findPullRequest(opts.Owner, opts.Name, opts.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return updatePullRequest(opts.Owner, opts.Name, existing.Number, opts.Title, body)
+	}
+	return createPullRequest(opts.Owner, opts.Name, opts.Branch, opts.Base, opts.Title, body)
+}
+
+// pushFixBranch commits the fixed files to the fix branch and force pushes it to origin. The
+// commit or branch checked out before is checked out again whatever happens, and keeps the
+// fixed files unless they were pushed.
+func pushFixBranch(repo *git.Repository, head *plumbing.Reference, root string, results []FixResult, opts FixPROptions) (err error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("git error: %w", err)
+	}
+	branch := plumbing.NewBranchReferenceName(opts.Branch)
+	if branch == head.Name() {
+		return fmt.Errorf("fix branch %s is checked out. Please check out the base branch", opts.Branch)
+	}
+	if err := repo.Storer.RemoveReference(branch); err != nil {
+		return fmt.Errorf("git error: removing the previous branch %s: %w", opts.Branch, err)
+	}
+	// Keep carries the fixed files over to the new branch.
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: branch, Create: true, Keep: true}); err != nil {
+		return fmt.Errorf("git error: creating branch %s: %w", opts.Branch, err)
+	}
+	defer func() {
+		restore := &git.CheckoutOptions{Branch: head.Name(), Keep: err != nil}
+		if !head.Name().IsBranch() {
+			restore = &git.CheckoutOptions{Hash: head.Hash(), Keep: err != nil}
+		}
+		if err := wt.Checkout(restore); err != nil {
+			logger.Warn("could not switch back to the base branch", "branch", opts.Base, "err", err)
+		}
+	}()
+
+	for _, r := range results {
+		if _, err := wt.Add(relativePath(root, r.Path)); err != nil {
+			return fmt.Errorf("git error: %w", err)
+		}
+	}
+	if _, err := wt.Commit(opts.Title, commitOptions(repo)); err != nil {
+		return fmt.Errorf("git error: %w", err)
+	}
+
+	if err := gitTransport.Push(root, "origin", []string{fmt.Sprintf("+%s:%s", branch, branch)}); err != nil {
+		return fmt.Errorf("git error: pushing %s: %w", opts.Branch, err)
+	}
+	return nil
+}
+
+// commitOptions signs commits as the user configured in git, or as scharf if there is none
+func commitOptions(repo *git.Repository) *git.CommitOptions {
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err == nil && cfg.User.Name != "" && cfg.User.Email != "" {
+		return &git.CommitOptions{Author: &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}}
+	}
+	return &git.CommitOptions{Author: &object.Signature{Name: "scharf", Email: "scharf@users.noreply.github.com", When: time.Now()}}
+}
+
+// pushAuth authenticates pushes to a remote over HTTPS with the GITHUB_TOKEN. Other
+// transports, such as SSH, use their own credentials.
+func pushAuth(repo *git.Repository, name string) transport.AuthMethod {
+	token := githubToken()
+	remote, err := repo.Remote(name)
+	if token == "" || err != nil || len(remote.Config().URLs) == 0 || !strings.HasPrefix(remote.Config().URLs[0], "https://") {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: "x-access-token", Password: token}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for openFixPR ---
+
+func TestOpenFixPR(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("pushing to a local remote needs the git binary")
+	}
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	CheckIfError(err)
+
+	root, path := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	_, err = w.Commit("add workflow", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	CheckIfError(err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	CheckIfError(err)
+
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, io.Discard)
+	CheckIfError(err)
+
+	var payload map[string]string
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodGet && req.URL.Path == "/repos/owner/repo/pulls" {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("[]")), Header: make(http.Header)}, nil
+		}
+		if req.Method != http.MethodPost || req.URL.Path != "/repos/owner/repo/pulls" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+		if req.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the token to be sent, got %q", req.Header.Get("Authorization"))
+		}
+		CheckIfError(json.NewDecoder(req.Body).Decode(&payload))
+		body := `{"number": 7, "html_url": "https://github.com/owner/repo/pull/7"}`
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		pr, err := openFixPR(root, results, FixPROptions{Owner: "owner", Name: "repo", Title: "Secure GitHub workflows"})
+		if err != nil {
+			t.Fatalf("openFixPR returned error: %v", err)
+		}
+		if pr.Number != 7 {
+			t.Errorf("unexpected pull request: %+v", pr)
+		}
+	})
+
+	head, err := repo.Head()
+	CheckIfError(err)
+	if payload["head"] != defaultFixBranch || payload["base"] != head.Name().Short() || !strings.Contains(payload["body"], ".github/workflows/ci.yml") {
+		t.Errorf("unexpected pull request payload: %v", payload)
+	}
+
+	// The fix commit reached the remote and the base branch is checked out again.
+	remote, err := git.PlainOpen(remoteDir)
+	CheckIfError(err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName(defaultFixBranch), true)
+	if err != nil {
+		t.Fatalf("fix branch was not pushed: %v", err)
+	}
+	commit, err := remote.CommitObject(ref.Hash())
+	CheckIfError(err)
+	if commit.Message != "Secure GitHub workflows" {
+		t.Errorf("unexpected commit message %q", commit.Message)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != unfixedWorkflow {
+		t.Errorf("expected the base branch to be checked out again, got:\n%s", content)
+	}
+}
+
+func TestOpenFixPR_RerunUpdatesExistingPR(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("pushing to a local remote needs the git binary")
+	}
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	CheckIfError(err)
+
+	root, path := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	commit := func(msg string) {
+		_, err := w.Add(".")
+		CheckIfError(err)
+		_, err = w.Commit(msg, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+		CheckIfError(err)
+	}
+	commit("add workflow")
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	CheckIfError(err)
+
+	var requests []string
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		body := `{"number": 7, "html_url": "https://github.com/owner/repo/pull/7"}`
+		status := http.StatusOK
+		switch req.Method {
+		case http.MethodGet:
+			if req.URL.Query().Get("head") != "owner:"+defaultFixBranch {
+				t.Errorf("unexpected lookup %s", req.URL)
+			}
+			body = "[" + body + "]"
+			if len(requests) == 1 {
+				body = "[]"
+			}
+		case http.MethodPost:
+			status = http.StatusCreated
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	open := func() {
+		results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, io.Discard)
+		CheckIfError(err)
+		withHTTPClientTransport(customTransport, func() {
+			if _, err := openFixPR(root, results, FixPROptions{Owner: "owner", Name: "repo", Title: "Secure GitHub workflows"}); err != nil {
+				t.Fatalf("openFixPR returned error: %v", err)
+			}
+		})
+	}
+	open()
+
+	// The base moves on while the pull request waits, so the old fix branch diverges.
+	CheckIfError(os.WriteFile(path, []byte(strings.Replace(unfixedWorkflow, "on:", "# reviewed\non:", 1)), 0o644))
+	commit("touch workflow")
+	open()
+
+	expected := []string{"GET /repos/owner/repo/pulls", "POST /repos/owner/repo/pulls", "GET /repos/owner/repo/pulls", "PATCH /repos/owner/repo/pulls/7"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected requests %v", requests)
+	}
+
+	// The fix branch was recreated on top of the new base.
+	remote, err := git.PlainOpen(remoteDir)
+	CheckIfError(err)
+	ref, err := remote.Reference(plumbing.NewBranchReferenceName(defaultFixBranch), true)
+	CheckIfError(err)
+	fix, err := remote.CommitObject(ref.Hash())
+	CheckIfError(err)
+	head, err := repo.Head()
+	CheckIfError(err)
+	if len(fix.ParentHashes) != 1 || fix.ParentHashes[0] != head.Hash() {
+		t.Errorf("expected the fix branch to be based on %s, got parents %v", head.Hash(), fix.ParentHashes)
+	}
+}
+
+func TestOpenFixPR_PushFailureRestoresBranch(t *testing.T) {
+	root, path := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	_, err = w.Commit("add workflow", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+	base, err := repo.Head()
+	CheckIfError(err)
+
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, io.Discard)
+	CheckIfError(err)
+	fixed, err := os.ReadFile(path)
+	CheckIfError(err)
+
+	// Without an origin remote the push fails after the fix commit.
+	if _, err := openFixPR(root, results, FixPROptions{Owner: "owner", Name: "repo", Title: "Secure GitHub workflows"}); err == nil {
+		t.Fatal("expected error when pushing fails, got nil")
+	}
+
+	head, err := repo.Head()
+	CheckIfError(err)
+	if head.Name() != base.Name() || head.Hash() != base.Hash() {
+		t.Errorf("expected %s to be checked out again, got %s at %s", base.Name(), head.Name(), head.Hash())
+	}
+	if content, _ := os.ReadFile(path); !bytes.Equal(content, fixed) {
+		t.Errorf("expected the fixed files to be kept, got:\n%s", content)
+	}
+}
+
+func TestOpenFixPR_DetachedHead(t *testing.T) {
+	root, _ := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	hash, err := w.Commit("add workflow", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+	CheckIfError(w.Checkout(&git.CheckoutOptions{Hash: hash}))
+
+	results, err := FixWorkflows(root, []Fixer{PermissionsRule{}}, false, io.Discard)
+	CheckIfError(err)
+
+	_, err = openFixPR(root, results, FixPROptions{Owner: "owner", Name: "repo", Title: "Secure GitHub workflows"})
+	if err == nil || !strings.Contains(err.Error(), "detached") {
+		t.Errorf("expected an error for a detached HEAD, got %v", err)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(defaultFixBranch), true); err == nil {
+		t.Error("expected no fix branch to be created")
+	}
+}
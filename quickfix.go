@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FixPosition is a position in a file: a 1-based line and column, counted in characters
+// like those of findings, and the byte offset
+type FixPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// FixEdit is a machine-applicable edit of the file of a finding: the text from Start up to,
+// and not including, End is replaced by NewText. Start and End are equal for insertions.
+type FixEdit struct {
+	Start   FixPosition `json:"start"`
+	End     FixPosition `json:"end"`
+	NewText string      `json:"new_text"`
+}
+
+// QuickFix is a fix of a finding or mutable reference, applied by applying all its edits
+type QuickFix struct {
+	Description string    `json:"description"`
+	Edits       []FixEdit `json:"edits"`
+}
+
+// newQuickFix converts the byte offsets of edits to positions of the file. It returns nil
+// without edits.
+func newQuickFix(li *lineIndex, description string, edits []TextEdit) *QuickFix {
+	if len(edits) == 0 {
+		return nil
+	}
+	fix := &QuickFix{Description: description}
+	for _, e := range edits {
+		f := FixEdit{Start: FixPosition{Offset: e.Start}, End: FixPosition{Offset: e.End}, NewText: e.NewText}
+		f.Start.Line, f.Start.Column = li.position(e.Start)
+		f.End.Line, f.End.Column = li.position(e.End)
+		fix.Edits = append(fix.Edits, f)
+	}
+	return fix
+}
+
+// pinEdit replaces the ref of a mutable reference with a commit SHA. The ref is kept in a
+// comment when nothing but the closing quote of the reference follows it on its line.
+func pinEdit(li *lineIndex, m Match, sha string) TextEdit {
+	start := li.offset(m.Line, m.Column)
+	end := start + len(m.Value)
+	parts := splitRawAction(m.Value)
+	edit := TextEdit{Start: start, End: end, NewText: parts[0] + "@" + sha}
+	rest := string(li.content[end : li.lineStart(m.Line)+len(li.lineText(m.Line))])
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') && strings.TrimSpace(rest[1:]) == "" {
+		edit.End++
+		edit.NewText += rest[:1]
+		rest = ""
+	}
+	if strings.TrimSpace(rest) == "" {
+		edit.NewText += " # " + parts[1]
+	}
+	return edit
+}
+
+// cachingResolver resolves every reference once, so a reference repeated across the
+// workflows of a workspace costs a single lookup. Failed lookups are not retried.
+type cachingResolver struct {
+	Resolver Resolver
+	mu       sync.Mutex
+	shas     map[string]string
+	errs     map[string]error
+}
+
+func newCachingResolver(r Resolver) *cachingResolver {
+	return &cachingResolver{Resolver: r, shas: map[string]string{}, errs: map[string]error{}}
+}
+
+func (c *cachingResolver) resolve(action string) (string, error) {
+	c.mu.Lock()
+	sha, resolved := c.shas[action]
+	err, failed := c.errs[action]
+	c.mu.Unlock()
+	if resolved {
+		return sha, nil
+	}
+	if failed {
+		return "", err
+	}
+
+	sha, err = c.Resolver.resolve(action)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.errs[action] = err
+		return "", err
+	}
+	c.shas[action] = sha
+	return sha, nil
+}
+
+// attachQuickFixes sets the edits pinning each mutable reference to the commit of its ref,
+// and those fixing each finding of the rules implementing QuickFixer. References that
+// cannot be resolved and findings without a fix are left without one.
+func (s *Scanner) attachQuickFixes(wf *WorkflowFile, matches []Match, findings []Finding) {
+	for i, m := range matches {
+		sha, err := s.QuickFixes.resolve(m.Value)
+		if err != nil {
+			logger.Debug("could not resolve reference to a commit", "ref", m.Value, "err", err)
+			continue
+		}
+		matches[i].Fix = newQuickFix(wf.lines, fmt.Sprintf("Pin %s to %s", m.Value, sha), []TextEdit{pinEdit(wf.lines, m, sha)})
+	}
+
+	fixers := map[string]QuickFixer{}
+	for _, r := range s.Rules {
+		if qf, ok := r.(QuickFixer); ok {
+			for _, rule := range qf.QuickFixes() {
+				fixers[rule] = qf
+			}
+		}
+	}
+	for i, f := range findings {
+		if qf, ok := fixers[f.Rule]; ok {
+			description, edits := qf.QuickFix(wf, f)
+			findings[i].Fix = newQuickFix(wf.lines, description, edits)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- Tests for Scanner.ScanBranch with quick fixes ---
+
+func TestScanner_ScanBranchQuickFixes(t *testing.T) {
+	root := filepath.Join("ws", "repo")
+	content := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/cache@v4 # keep\n      - uses: acme/unknown@v1\n      - run: git push\n"
+	repo := fakeRepository{
+		name:         "repo",
+		files:        []string{"ci.yml"},
+		fileContents: map[string][]byte{filepath.Join(workflowDir(root), "ci.yml"): []byte(content)},
+	}
+
+	sc := Scanner{
+		FileScanner: GitHubWorkFlowScanner{},
+		Rules:       []Rule{PermissionsRule{}},
+		QuickFixes:  fakeResolver{"actions/checkout@v4": lockedSHA, "actions/cache@v4": movedSHA},
+	}
+	records := sc.ScanBranch("main", repo, mutableRefRegex, workflowDir(root))
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %+v", records)
+	}
+	ir := records[0]
+
+	var edits []TextEdit
+	for _, m := range ir.Locations {
+		if m.Value == "acme/unknown@v1" {
+			if m.Fix != nil {
+				t.Errorf("expected no fix for a reference that cannot be resolved, got %+v", m.Fix)
+			}
+			continue
+		}
+		if m.Fix == nil || len(m.Fix.Edits) != 1 || !strings.HasPrefix(m.Fix.Description, "Pin "+m.Value) {
+			t.Fatalf("unexpected fix of %s: %+v", m.Value, m.Fix)
+		}
+		e := m.Fix.Edits[0]
+		if e.Start.Line != m.Line || e.Start.Column != m.Column {
+			t.Errorf("expected the edit of %s to start at %d:%d, got %+v", m.Value, m.Line, m.Column, e.Start)
+		}
+		edits = append(edits, TextEdit{Start: e.Start.Offset, End: e.End.Offset, NewText: e.NewText})
+	}
+	fixed, err := applyEdits([]byte(content), edits)
+	CheckIfError(err)
+	for _, line := range []string{"uses: actions/checkout@" + lockedSHA + " # v4\n", "uses: actions/cache@" + movedSHA + " # keep\n"} {
+		if !strings.Contains(string(fixed), line) {
+			t.Errorf("expected %q in:\n%s", line, fixed)
+		}
+	}
+
+	if len(ir.Findings) != 1 || ir.Findings[0].Fix == nil {
+		t.Fatalf("expected the undeclared permissions finding to be fixed, got %+v", ir.Findings)
+	}
+	e := ir.Findings[0].Fix.Edits[0]
+	fixed, err = applyEdits([]byte(content), []TextEdit{{Start: e.Start.Offset, End: e.End.Offset, NewText: e.NewText}})
+	CheckIfError(err)
+	if !strings.Contains(string(fixed), "  build:\n    permissions:\n      contents: write\n    runs-on:") {
+		t.Errorf("unexpected fix:\n%s", fixed)
+	}
+}
+
+// countingResolver counts the lookups of the resolver it wraps
+type countingResolver struct {
+	Resolver
+	calls int
+}
+
+func (c *countingResolver) resolve(action string) (string, error) {
+	c.calls++
+	return c.Resolver.resolve(action)
+}
+
+// --- Tests for cachingResolver.resolve ---
+
+func TestCachingResolver_Resolve(t *testing.T) {
+	inner := &countingResolver{Resolver: fakeResolver{"actions/checkout@v4": lockedSHA}}
+	r := newCachingResolver(inner)
+	for range 3 {
+		sha, err := r.resolve("actions/checkout@v4")
+		if err != nil || sha != lockedSHA {
+			t.Errorf("unexpected resolution %s, %v", sha, err)
+		}
+		if _, err := r.resolve("acme/missing@v1"); err == nil {
+			t.Errorf("expected the missing action to fail")
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected 2 lookups, got %d", inner.calls)
+	}
+}
+
+// --- Tests for PermissionsRule.QuickFix ---
+
+func TestPermissionsRule_QuickFix(t *testing.T) {
+	content := `on: push
+permissions: write-all
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+  release:
+    permissions:
+      contents: read
+    runs-on: ubuntu-latest
+    steps:
+      - run: gh release create v1
+  other:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: acme/mystery@v1
+        with:
+          token: ${{ secrets.GITHUB_TOKEN }}
+`
+	wf := newWorkflowFile(".github/workflows/ci.yml", []byte(content))
+	r := PermissionsRule{}
+
+	// Fixed content by job, empty for findings without a fix
+	fixed := map[string]string{}
+	for _, f := range r.Check(wf) {
+		job := strings.Fields(f.Message)[1]
+		description, edits := r.QuickFix(wf, f)
+		if len(edits) == 0 {
+			fixed[job] = ""
+			continue
+		}
+		out, err := applyEdits([]byte(content), edits)
+		CheckIfError(err)
+		if description != "Set the permissions of job "+job+" to those it needs" {
+			t.Errorf("unexpected description %q", description)
+		}
+		fixed[job] = string(out)
+	}
+
+	if got := fixed["lint"]; !strings.Contains(got, "  lint:\n    permissions: {}\n    runs-on:") || !strings.Contains(got, "permissions: write-all\n") {
+		t.Errorf("expected lint to get an empty block and the workflow block to stay, got:\n%s", got)
+	}
+	if got := fixed["release"]; !strings.Contains(got, "  release:\n    permissions:\n      contents: write\n    runs-on:") {
+		t.Errorf("expected release to be granted contents: write, got:\n%s", got)
+	}
+	if got, ok := fixed["other"]; !ok || got != "" {
+		t.Errorf("expected no fix for a job with unknown needs, got:\n%s", got)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retries of GitHub requests: transient errors are retried with a backoff doubling from
+// retryBackoff, and rate limits are waited out when they reset within maxRateLimitWait
+const (
+	maxRetries       = 4
+	retryBackoff     = time.Second
+	maxRateLimitWait = 15 * time.Minute
+)
+
+// retryClient sends requests to GitHub, waiting out rate limits and retrying server errors
+// and failed connections of reads, so large scans do not die midway
+type retryClient struct {
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// githubClient sends every request of the process to the GitHub API
+var githubClient = &retryClient{sleep: time.Sleep, now: time.Now}
+
+// do sends a request, retrying it when GitHub is rate limiting or failing. Bodies are
+// replayed from GetBody, which http.NewRequest sets for in-memory bodies.
+//
+// Writes are only retried when a rate limit refused them. GitHub may have applied a write
+// whose response timed out or came back as a server error, and sending it again could open
+// a second pull request or merge twice.
+func (c *retryClient) do(req *http.Request) (*http.Response, error) {
+	read := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := githubHTTP.Do(req)
+		if attempt == maxRetries {
+			return resp, err
+		}
+		if err != nil {
+			if !read || !retryable(req, err) {
+				return nil, err
+			}
+			logger.Debug("request to GitHub failed, retrying", "url", req.URL.String(), "attempt", attempt+1, "err", err)
+			c.sleep(c.backoff(attempt))
+			continue
+		}
+
+		wait, limited := c.rateLimitWait(resp)
+		switch {
+		case limited && wait > maxRateLimitWait:
+			logger.Warn("GitHub rate limit exhausted until too late to wait. Authenticate with GITHUB_TOKEN or a GitHub App for a higher limit", "url", req.URL.String(), "reset", c.now().Add(wait).Format(time.RFC3339))
+			return resp, nil
+		case limited:
+			logger.Warn("GitHub rate limit exhausted, waiting for it to reset", "wait", wait.Round(time.Second))
+		case read && resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented:
+			wait = c.backoff(attempt)
+			logger.Debug("GitHub returned a server error, retrying", "url", req.URL.String(), "status", resp.Status, "attempt", attempt+1)
+		default:
+			return resp, nil
+		}
+		resp.Body.Close()
+		c.sleep(wait)
+	}
+}
+
+// retryable reports whether a request failed on the way, timing out or with its
+// connection reset, so that sending it again may work. Unknown hosts, TLS failures and
+// cancelled requests would fail the same way again.
+func retryable(req *http.Request, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET)
+}
+
+// rateLimitWait tells whether a response was refused by a rate limit and how long to wait
+// before trying again: the Retry-After of secondary rate limits, or until the primary limit
+// resets once X-RateLimit-Remaining reaches zero
+func (c *retryClient) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Minute, true
+	}
+	// A second more absorbs the drift between our clock and GitHub's.
+	return max(time.Unix(reset, 0).Sub(c.now())+time.Second, 0), true
+}
+
+// backoff returns how long to wait before the given retry: a doubling delay, jittered so
+// that concurrent scans do not retry in lockstep
+func (c *retryClient) backoff(attempt int) time.Duration {
+	d := retryBackoff << attempt
+	return d/2 + rand.N(d/2)
+}
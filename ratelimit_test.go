@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// --- Tests for retryClient.do ---
+
+func TestRetryClient_Do(t *testing.T) {
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	reset := strconv.FormatInt(clock.Add(10*time.Minute).Unix(), 10)
+	late := strconv.FormatInt(clock.Add(2*time.Hour).Unix(), 10)
+
+	type reply struct {
+		status int
+		header map[string]string
+		err    error
+	}
+	tests := []struct {
+		name       string
+		method     string
+		replies    []reply
+		wantStatus int
+		wantErr    bool
+		wantSleeps []time.Duration
+	}{
+		{
+			name:       "success",
+			replies:    []reply{{status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "server errors retried",
+			replies:    []reply{{status: http.StatusBadGateway}, {status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{retryBackoff, 2 * retryBackoff},
+		},
+		{
+			name:       "reset connections retried",
+			replies:    []reply{{err: syscall.ECONNRESET}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{retryBackoff},
+		},
+		{
+			name:       "timeouts retried",
+			replies:    []reply{{err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{retryBackoff},
+		},
+		{
+			name:    "unknown hosts not retried",
+			replies: []reply{{err: &net.DNSError{Err: "no such host", Name: "api.github.com", IsNotFound: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "other failures not retried",
+			replies: []reply{{err: errors.New("tls: failed to verify certificate")}},
+			wantErr: true,
+		},
+		{
+			name:       "gives up after the last retry",
+			replies:    []reply{{status: http.StatusInternalServerError}, {status: http.StatusInternalServerError}, {status: http.StatusInternalServerError}, {status: http.StatusInternalServerError}, {status: http.StatusInternalServerError}},
+			wantStatus: http.StatusInternalServerError,
+			wantSleeps: []time.Duration{retryBackoff, 2 * retryBackoff, 4 * retryBackoff, 8 * retryBackoff},
+		},
+		{
+			name:    "write timeouts not retried",
+			method:  http.MethodPost,
+			replies: []reply{{err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "write resets not retried",
+			method:  http.MethodPut,
+			replies: []reply{{err: syscall.ECONNRESET}},
+			wantErr: true,
+		},
+		{
+			name:       "write server errors not retried",
+			method:     http.MethodPatch,
+			replies:    []reply{{status: http.StatusBadGateway}},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "write rate limits waited out",
+			method:     http.MethodPost,
+			replies:    []reply{{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}}, {status: http.StatusCreated}},
+			wantStatus: http.StatusCreated,
+			wantSleeps: []time.Duration{10*time.Minute + time.Second},
+		},
+		{
+			name:       "write secondary rate limits waited out",
+			method:     http.MethodPost,
+			replies:    []reply{{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "30"}}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{30 * time.Second},
+		},
+		{
+			name:       "primary rate limit waited out",
+			replies:    []reply{{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{10*time.Minute + time.Second},
+		},
+		{
+			name:       "secondary rate limit waited out",
+			replies:    []reply{{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "30"}}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{30 * time.Second},
+		},
+		{
+			name:       "rate limit resetting too late",
+			replies:    []reply{{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": late}}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "forbidden is not retried",
+			replies:    []reply{{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "42"}}},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var sleeps []time.Duration
+			c := &retryClient{now: func() time.Time { return clock }, sleep: func(d time.Duration) { sleeps = append(sleeps, d) }}
+
+			sent := 0
+			rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != "payload" {
+					t.Errorf("request %d sent body %q", sent, body)
+				}
+				r := tc.replies[sent]
+				sent++
+				if r.err != nil {
+					return nil, r.err
+				}
+				h := make(http.Header)
+				for k, v := range r.header {
+					h.Set(k, v)
+				}
+				return &http.Response{StatusCode: r.status, Status: strconv.Itoa(r.status), Body: io.NopCloser(strings.NewReader("")), Header: h}, nil
+			})
+
+			withHTTPClientTransport(rt, func() {
+				method := tc.method
+				if method == "" {
+					method = http.MethodGet
+				}
+				req, err := http.NewRequest(method, "https://api.github.com/graphql", strings.NewReader("payload"))
+				CheckIfError(err)
+				resp, err := c.do(req)
+				if tc.wantErr {
+					if err == nil {
+						t.Error("expected the error to be returned")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("do returned error: %v", err)
+				}
+				if resp.StatusCode != tc.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tc.wantStatus)
+				}
+			})
+			if sent != len(tc.replies) {
+				t.Errorf("sent %d requests, want %d", sent, len(tc.replies))
+			}
+			if len(sleeps) != len(tc.wantSleeps) {
+				t.Fatalf("slept %v, want %v", sleeps, tc.wantSleeps)
+			}
+			for i, d := range sleeps {
+				// Backoffs are jittered down to half their length.
+				if d > tc.wantSleeps[i] || d < tc.wantSleeps[i]/2 {
+					t.Errorf("sleep %d = %v, want about %v", i, d, tc.wantSleeps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryClient_DoCancelled(t *testing.T) {
+	c := &retryClient{now: time.Now, sleep: func(time.Duration) { t.Error("expected no retry of a cancelled request") }}
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, syscall.ECONNRESET
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	withHTTPClientTransport(rt, func() {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/repos/org/app", nil)
+		CheckIfError(err)
+		if _, err := c.do(req); err == nil {
+			t.Error("expected the error to be returned")
+		}
+	})
+}
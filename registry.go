@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// dockerHub is the registry of images referenced without a registry host
+const dockerHub = "docker.io"
+
+// manifestTypes are the manifest media types accepted when resolving tags. Indexes come
+// first so multi-platform images resolve to the digest people pin, not that of a platform.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// imageRef is a parsed container image reference: registry/repository:tag@digest
+type imageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageRef parses an image reference as written in workflows, with or without the
+// docker:// prefix of `uses:`. Images without a registry host come from Docker Hub.
+func parseImageRef(s string) (imageRef, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "docker://")
+	if s == "" || strings.Contains(s, "${{") {
+		return imageRef{}, fmt.Errorf("not a static image reference: %q", s)
+	}
+
+	var ref imageRef
+	s, ref.Digest, _ = strings.Cut(s, "@")
+	if i := strings.LastIndex(s, ":"); i > strings.LastIndex(s, "/") {
+		s, ref.Tag = s[:i], s[i+1:]
+	}
+
+	first, rest, found := strings.Cut(s, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, ref.Repository = first, rest
+	} else {
+		ref.Registry, ref.Repository = dockerHub, s
+		if !found {
+			ref.Repository = "library/" + s
+		}
+	}
+	if ref.Repository == "" {
+		return imageRef{}, fmt.Errorf("not an image reference: %q", s)
+	}
+	return ref, nil
+}
+
+// String renders the reference in its canonical form
+func (r imageRef) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// registryHost returns the host serving the registry API
+func registryHost(registry string) string {
+	if registry == dockerHub {
+		return "registry-1.docker.io"
+	}
+	return registry
+}
+
+// registryCredentials returns the username and password to authenticate to a registry
+// with, or empty strings for anonymous access
+type registryCredentials func(registry string) (string, string, error)
+
+// anonymousRegistry is the default credentials: public images only
+func anonymousRegistry(string) (string, string, error) {
+	return "", "", nil
+}
+
+// registryClient talks to the distribution API of OCI registries. It answers
+// authentication challenges with the credentials of the registry, and keeps the bearer
+// tokens it obtains per repository.
+type registryClient struct {
+	credentials registryCredentials
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// newRegistryClient creates a client authenticating with creds, or anonymously if nil
+func newRegistryClient(creds registryCredentials) *registryClient {
+	if creds == nil {
+		creds = anonymousRegistry
+	}
+	return &registryClient{credentials: creds, tokens: map[string]string{}}
+}
+
+// challengeParam matches a parameter of a WWW-Authenticate header
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// do sends a request to the registry of ref, authenticating when challenged
+func (c *registryClient) do(ref imageRef, method, path string, accept []string) (*http.Response, error) {
+	endpoint := "https://" + registryHost(ref.Registry) + path
+	send := func(auth string) (*http.Response, error) {
+		req, err := http.NewRequest(method, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return registryHTTP.Do(req)
+	}
+
+	key := ref.Registry + "/" + ref.Repository
+	c.mu.Lock()
+	auth := c.tokens[key]
+	c.mu.Unlock()
+
+	resp, err := send(auth)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	auth, err = c.authenticate(ref, challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.tokens[key] = auth
+	c.mu.Unlock()
+	return send(auth)
+}
+
+// authenticate answers a Basic or Bearer challenge of a registry and returns the value of
+// the Authorization header to retry with
+func (c *registryClient) authenticate(ref imageRef, challenge string) (string, error) {
+	user, pass, err := c.credentials(ref.Registry)
+	if err != nil {
+		return "", fmt.Errorf("registry credentials: %w", err)
+	}
+
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user == "" {
+			return "", fmt.Errorf("registry %s needs credentials", ref.Registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(user, pass)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("registry %s: unsupported authentication %q", ref.Registry, challenge)
+	}
+
+	p := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(params, -1) {
+		p[m[1]] = m[2]
+	}
+	if p["realm"] == "" {
+		return "", fmt.Errorf("registry %s: no realm in challenge %q", ref.Registry, challenge)
+	}
+	q := url.Values{}
+	if p["service"] != "" {
+		q.Set("service", p["service"])
+	}
+	scope := p["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+
+	req, err := http.NewRequest(http.MethodGet, p["realm"]+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	resp, err := registryHTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: token for %s returned %s", ref.Repository, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// errManifestUnknown is returned for tags or digests the registry does not know
+var errManifestUnknown = errors.New("manifest unknown")
+
+// digest resolves a tag or digest of an image to the digest of its manifest
+func (c *registryClient) digest(ref imageRef, tagOrDigest string) (string, error) {
+	resp, err := c.do(ref, http.MethodHead, "/v2/"+ref.Repository+"/manifests/"+tagOrDigest, manifestTypes)
+	if err != nil {
+		return "", fmt.Errorf("http: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", errManifestUnknown
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("http: manifest %s of %s returned %s", tagOrDigest, ref.Repository, resp.Status)
+	}
+	d := resp.Header.Get("Docker-Content-Digest")
+	if d == "" {
+		return "", fmt.Errorf("http: registry %s returned no digest for %s", ref.Registry, tagOrDigest)
+	}
+	return d, nil
+}
+
+// nextLink matches the next page in a Link header
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// tags lists the tags of an image repository, following pagination
+func (c *registryClient) tags(ref imageRef) ([]string, error) {
+	var tags []string
+	path := "/v2/" + ref.Repository + "/tags/list?n=1000"
+	for path != "" {
+		resp, err := c.do(ref, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("http: %w", err)
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("http: tags of %s returned %s", ref.Repository, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("json: %w", err)
+		}
+		tags = append(tags, page.Tags...)
+
+		path = ""
+		if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			if u, err := url.Parse(m[1]); err == nil {
+				path = u.RequestURI()
+			}
+		}
+	}
+	return tags, nil
+}
+
+// tagsOf returns the tags currently pointing to the digest of ref, checking at most
+// maxTags tags. It reports how many tags were checked, so callers know whether the
+// answer is complete.
+func (c *registryClient) tagsOf(ref imageRef, maxTags int) ([]string, int, error) {
+	all, err := c.tags(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	if maxTags > 0 && len(all) > maxTags {
+		all = all[len(all)-maxTags:]
+	}
+
+	var matching []string
+	for _, tag := range all {
+		d, err := c.digest(ref, tag)
+		if err != nil {
+			logger.Debug("could not resolve image tag", "image", ref.Repository, "tag", tag, "err", err)
+			continue
+		}
+		if d == ref.Digest {
+			matching = append(matching, tag)
+		}
+	}
+	return matching, len(all), nil
+}
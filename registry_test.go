@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	digestC = "sha256:cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
+// fakeRegistry serves the distribution API for images mapping tags to digests. It
+// challenges requests without the bearer token, like Docker Hub does.
+func fakeRegistry(t *testing.T, images map[string]map[string]string) (roundTripFunc, *int) {
+	tokenRequests := 0
+	return func(req *http.Request) (*http.Response, error) {
+		respond := func(status int, body string, header http.Header) (*http.Response, error) {
+			if header == nil {
+				header = make(http.Header)
+			}
+			return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: header}, nil
+		}
+
+		if req.URL.Host == "auth.example.com" {
+			tokenRequests++
+			if !strings.HasPrefix(req.URL.Query().Get("scope"), "repository:") {
+				t.Errorf("token requested without repository scope: %s", req.URL)
+			}
+			return respond(http.StatusOK, `{"token": "secret"}`, nil)
+		}
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			h := make(http.Header)
+			h.Set("WWW-Authenticate", `Bearer realm="https://auth.example.com/token",service="registry.example.com"`)
+			return respond(http.StatusUnauthorized, "", h)
+		}
+
+		path := strings.TrimPrefix(req.URL.Path, "/v2/")
+		for repo, tags := range images {
+			switch {
+			case path == repo+"/tags/list":
+				var names []string
+				for tag := range tags {
+					names = append(names, `"`+tag+`"`)
+				}
+				return respond(http.StatusOK, `{"tags": [`+strings.Join(names, ",")+`]}`, nil)
+			case strings.HasPrefix(path, repo+"/manifests/"):
+				if digest, ok := tags[strings.TrimPrefix(path, repo+"/manifests/")]; ok {
+					h := make(http.Header)
+					h.Set("Docker-Content-Digest", digest)
+					return respond(http.StatusOK, "", h)
+				}
+			}
+		}
+		return respond(http.StatusNotFound, "", nil)
+	}, &tokenRequests
+}
+
+// --- Tests for parseImageRef ---
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected imageRef
+	}{
+		{"alpine", imageRef{Registry: dockerHub, Repository: "library/alpine"}},
+		{"node:20-alpine", imageRef{Registry: dockerHub, Repository: "library/node", Tag: "20-alpine"}},
+		{"docker://bitnami/redis:7@" + digestA, imageRef{Registry: dockerHub, Repository: "bitnami/redis", Tag: "7", Digest: digestA}},
+		{"ghcr.io/org/app@" + digestA, imageRef{Registry: "ghcr.io", Repository: "org/app", Digest: digestA}},
+		{"localhost:5000/app:1", imageRef{Registry: "localhost:5000", Repository: "app", Tag: "1"}},
+	}
+	for _, tc := range tests {
+		got, err := parseImageRef(tc.input)
+		if err != nil || got != tc.expected {
+			t.Errorf("parseImageRef(%q) = %+v, %v; want %+v", tc.input, got, err, tc.expected)
+		}
+	}
+
+	if _, err := parseImageRef("${{ matrix.image }}"); err == nil {
+		t.Error("expected error for an expression, got nil")
+	}
+}
+
+// --- Tests for registryClient ---
+
+func TestRegistryClient_TagsOf(t *testing.T) {
+	transport, tokenRequests := fakeRegistry(t, map[string]map[string]string{
+		"library/node": {"20": digestA, "20-bookworm": digestA, "18": digestB},
+	})
+
+	withHTTPClientTransport(transport, func() {
+		client := newRegistryClient(nil)
+		ref, _ := parseImageRef("node@" + digestA)
+		tags, checked, err := client.tagsOf(ref, 0)
+		CheckIfError(err)
+		if checked != 3 {
+			t.Errorf("expected 3 tags checked, got %d", checked)
+		}
+		if strings.Join(sortedKeys(map[string]bool{tags[0]: true, tags[1]: true}), ",") != "20,20-bookworm" || len(tags) != 2 {
+			t.Errorf("tagsOf = %v; want 20 and 20-bookworm", tags)
+		}
+	})
+	if *tokenRequests != 1 {
+		t.Errorf("expected the token to be requested once and reused, got %d requests", *tokenRequests)
+	}
+}
+
+func TestRegistryClient_BasicAuth(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		user, pass, ok := req.BasicAuth()
+		h := make(http.Header)
+		if !ok || user != "user" || pass != "pass" {
+			h.Set("WWW-Authenticate", `Basic realm="registry"`)
+			return &http.Response{StatusCode: http.StatusUnauthorized, Body: io.NopCloser(bytes.NewReader(nil)), Header: h}, nil
+		}
+		h.Set("Docker-Content-Digest", digestC)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil)), Header: h}, nil
+	})
+
+	withHTTPClientTransport(transport, func() {
+		ref, _ := parseImageRef("registry.example.com/app:1")
+		creds := func(registry string) (string, string, error) {
+			if registry != "registry.example.com" {
+				t.Errorf("credentials asked for %s", registry)
+			}
+			return "user", "pass", nil
+		}
+		if d, err := newRegistryClient(creds).digest(ref, "1"); err != nil || d != digestC {
+			t.Errorf("digest = %q, %v; want %s", d, err, digestC)
+		}
+		if _, err := newRegistryClient(nil).digest(ref, "1"); err == nil {
+			t.Error("expected error for anonymous access to a registry needing credentials, got nil")
+		}
+	})
+}
+
+func TestRegistryClient_TagsPagination(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		body := `{"tags": ["3", "4"]}`
+		if req.URL.Query().Get("last") == "" {
+			h.Set("Link", `</v2/org/app/tags/list?last=2&n=2>; rel="next"`)
+			body = `{"tags": ["1", "2"]}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: h}, nil
+	})
+
+	withHTTPClientTransport(transport, func() {
+		ref, _ := parseImageRef("ghcr.io/org/app")
+		tags, err := newRegistryClient(nil).tags(ref)
+		CheckIfError(err)
+		if !reflect.DeepEqual(tags, []string{"1", "2", "3", "4"}) {
+			t.Errorf("tags = %v; want 1 to 4", tags)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// releaseExcerptLines is how many lines of release notes are quoted in fix PRs
+const releaseExcerptLines = 15
+
+// usesRef matches a `uses:` reference along with the version comment of a pinned SHA
+var usesRef = regexp.MustCompile(`uses:\s*["']?([\w.-]+/[\w./-]+)@([\w.-]+)["']?(?:\s+#\s*(\S+))?`)
+
+// refChange is an action reference changed by a fix
+type refChange struct {
+	Action string
+	From   string
+	To     string
+	// Version is the tag the new reference stands for: the ref itself or the version
+	// comment of a pinned SHA
+	Version string
+}
+
+// usesChanges lists the action references of fixed that are not in original, along with
+// the reference they replace
+func usesChanges(original, fixed []byte) []refChange {
+	before := map[string]bool{}
+	from := map[string]string{}
+	for _, m := range usesRef.FindAllSubmatch(original, -1) {
+		before[string(m[1])+"@"+string(m[2])] = true
+		from[string(m[1])] = string(m[2])
+	}
+
+	seen := map[string]bool{}
+	var changes []refChange
+	for _, m := range usesRef.FindAllSubmatch(fixed, -1) {
+		action, ref, comment := string(m[1]), string(m[2]), string(m[3])
+		key := action + "@" + ref
+		if before[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		version := ref
+		if comment != "" && pinnedRef.MatchString("@"+ref) {
+			version = comment
+		}
+		changes = append(changes, refChange{Action: action, From: from[action], To: ref, Version: version})
+	}
+	return changes
+}
+
+// Release is a GitHub release of an action
+type Release struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	// PublishedAt is when the release was published
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// fetchRelease fetches the release of an action published for a tag
+func fetchRelease(action, tag string) (*Release, error) {
+	endpoint := fmt.Sprintf("%s/%s/releases/tags/%s", apiURL, actionName(action), url.PathEscape(tag))
+	resp, err := githubGet(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: no release %s for %s: %s", tag, action, resp.Status)
+	}
+
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return &r, nil
+}
+
+// htmlTag matches HTML tags and comments of release notes
+var htmlTag = regexp.MustCompile(`<!--[\s\S]*?-->|</?[A-Za-z][^<>]*>`)
+
+// mention matches @user and @org/team mentions, but not the @ of emails or action refs
+var mention = regexp.MustCompile(`(^|[^\w@/.-])@([A-Za-z0-9])`)
+
+// defuseMarkdown makes text written upstream safe to show in our pull requests: HTML is
+// dropped, so it cannot close the blocks it is shown in, and mentions get a zero-width
+// joiner after the @, so they do not notify anyone
+func defuseMarkdown(text string) string {
+	text = htmlTag.ReplaceAllString(text, "")
+	return mention.ReplaceAllString(text, "$1@\u200d$2")
+}
+
+// releaseExcerpt quotes the first lines of release notes, defused of HTML and mentions
+func releaseExcerpt(body string) string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(defuseMarkdown(body), "\r\n", "\n")), "\n")
+	truncated := len(lines) > releaseExcerptLines
+	if truncated {
+		lines = append(lines[:releaseExcerptLines], "", "…")
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fixPRBody describes fixes for a pull request. Every action pinned to a commit is listed
+// along with the release it maps to, and every action version pinned or bumped gets an
+// excerpt of its release notes, so reviewers can assess it without leaving the PR.
+func fixPRBody(results []FixResult, root string) string {
+	var b strings.Builder
+	b.WriteString("This pull request was opened by [scharf](https://github.com/cybrota/scharf) to secure GitHub workflows.\n\n")
+	b.WriteString("Changed files:\n")
+	var changes []refChange
+	seen := map[string]bool{}
+	for _, r := range results {
+		fmt.Fprintf(&b, "- `%s`\n", relativePath(root, r.Path))
+		for _, c := range usesChanges(r.Original, r.Fixed) {
+			if key := c.Action + "@" + c.To; !seen[key] {
+				seen[key] = true
+				changes = append(changes, c)
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		return b.String()
+	}
+
+	releases := make([]*Release, len(changes))
+	for i, c := range changes {
+		rel, err := fetchRelease(c.Action, c.Version)
+		if err != nil {
+			logger.Debug("no release notes for action", "action", c.Action, "version", c.Version, "err", err)
+			continue
+		}
+		releases[i] = rel
+	}
+
+	var pinned strings.Builder
+	for i, c := range changes {
+		if !pinnedRef.MatchString("@" + c.To) {
+			continue
+		}
+		release := "no release"
+		if rel := releases[i]; rel != nil {
+			name := strings.ReplaceAll(defuseMarkdown(cmp.Or(rel.Name, rel.TagName)), "|", "\\|")
+			release = fmt.Sprintf("[%s](%s)", name, rel.HTMLURL)
+		}
+		fmt.Fprintf(&pinned, "| `%s` | `%s` | `%s` | %s |\n", c.Action, c.Version, c.To, release)
+	}
+	if pinned.Len() > 0 {
+		b.WriteString("\n### Pinned actions\n| Action | Ref | Commit | Release |\n| --- | --- | --- | --- |\n")
+		b.WriteString(pinned.String())
+	}
+
+	b.WriteString("\n### Release notes\n")
+	for i, c := range changes {
+		title := fmt.Sprintf("%s %s", c.Action, c.Version)
+		if c.From != "" && c.From != c.Version {
+			title = fmt.Sprintf("%s %s → %s", c.Action, c.From, c.Version)
+		}
+
+		rel := releases[i]
+		if rel == nil {
+			// An action pinned for the first time has nothing to compare with.
+			link := fmt.Sprintf("[Compare changes](%s/%s/compare/%s...%s)", serverURL, actionName(c.Action), c.From, c.To)
+			switch {
+			case c.From == "" && pinnedRef.MatchString("@"+c.To):
+				link = fmt.Sprintf("[View the commit](%s/%s/commit/%s)", serverURL, actionName(c.Action), c.To)
+			case c.From == "":
+				link = fmt.Sprintf("[View the tag](%s/%s/tree/%s)", serverURL, actionName(c.Action), url.PathEscape(c.To))
+			}
+			fmt.Fprintf(&b, "\n<details>\n<summary>%s</summary>\n\nNo release notes found. %s\n</details>\n", title, link)
+			continue
+		}
+		fmt.Fprintf(&b, "\n<details>\n<summary>%s</summary>\n\n%s\n\n[Full release notes](%s)\n</details>\n",
+			title, releaseExcerpt(rel.Body), rel.HTMLURL)
+	}
+	return b.String()
+}
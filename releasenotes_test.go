@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- Tests for usesChanges ---
+
+func TestUsesChanges(t *testing.T) {
+	original := []byte(`steps:
+  - uses: actions/checkout@v4
+  - uses: actions/setup-go@v4
+  - uses: docker/login-action@v2
+  - uses: ./local/action
+`)
+	fixed := []byte(`steps:
+  - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4
+  - uses: actions/setup-go@v4
+  - uses: "docker/login-action@v3"
+  - uses: ./local/action
+`)
+
+	expected := []refChange{
+		{Action: "actions/checkout", From: "v4", To: "11bd71901bbe5b1630ceea73d27597364c9af683", Version: "v4"},
+		{Action: "docker/login-action", From: "v2", To: "v3", Version: "v3"},
+	}
+	if got := usesChanges(original, fixed); !reflect.DeepEqual(got, expected) {
+		t.Errorf("usesChanges = %+v; want %+v", got, expected)
+	}
+}
+
+// --- Tests for releaseExcerpt ---
+
+func TestReleaseExcerpt(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "quoted", body: "\r\n## What's new\r\n\r\n- faster\r\n", expected: "> ## What's new\n>\n> - faster"},
+		{name: "mentions defused", body: "Thanks @octocat and @acme/maintainers!", expected: "> Thanks @\u200doctocat and @\u200dacme/maintainers!"},
+		{name: "emails and refs kept", body: "Mail dev@example.com or use actions/checkout@v4", expected: "> Mail dev@example.com or use actions/checkout@v4"},
+		{name: "HTML dropped", body: "Fixes\n</details>\n<img src=\"https://evil.example/x.png\"><!-- hidden -->\nDone", expected: "> Fixes\n>\n>\n> Done"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := releaseExcerpt(tc.body); got != tc.expected {
+				t.Errorf("releaseExcerpt = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+
+	long := strings.Repeat("line\n", releaseExcerptLines+5)
+	got := releaseExcerpt(long)
+	if strings.Count(got, "line") != releaseExcerptLines || !strings.HasSuffix(got, "> …") {
+		t.Errorf("expected a truncated excerpt, got %q", got)
+	}
+}
+
+// --- Tests for fixPRBody ---
+
+func TestFixPRBody(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/repos/actions/checkout/releases/tags/v4" {
+			body := `{"tag_name": "v4", "name": "v4 | @octocat", "body": "- Support for sparse checkout", "html_url": "https://github.com/actions/checkout/releases/tag/v4"}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(bytes.NewReader(nil)), Header: make(http.Header)}, nil
+	})
+
+	results := []FixResult{{
+		Path:     "/repo/.github/workflows/ci.yml",
+		Original: []byte("- uses: actions/checkout@v4\n- uses: docker/login-action@v2\n"),
+		Fixed: []byte("- uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4\n- uses: docker/login-action@v3\n" +
+			"- uses: actions/cache@5a3ec84eff668545956fd18022155c47e93e2684 # v4.2.3\n- uses: docker/setup-buildx-action@v3\n"),
+	}}
+
+	withHTTPClientTransport(customTransport, func() {
+		body := fixPRBody(results, "/repo")
+		if strings.Contains(body, "| `docker/login-action`") {
+			t.Errorf("expected bumped actions to be left out of the pinned ones:\n%s", body)
+		}
+		for _, want := range []string{
+			"- `.github/workflows/ci.yml`",
+			"| `actions/checkout` | `v4` | `11bd71901bbe5b1630ceea73d27597364c9af683` | [v4 \\| @\u200doctocat](https://github.com/actions/checkout/releases/tag/v4) |\n",
+			"<summary>actions/checkout v4</summary>\n\n> - Support for sparse checkout\n\n[Full release notes](https://github.com/actions/checkout/releases/tag/v4)",
+			"<summary>docker/login-action v2 → v3</summary>\n\nNo release notes found. [Compare changes](https://github.com/docker/login-action/compare/v2...v3)",
+			"<summary>actions/cache v4.2.3</summary>\n\nNo release notes found. [View the commit](https://github.com/actions/cache/commit/5a3ec84eff668545956fd18022155c47e93e2684)",
+			"<summary>docker/setup-buildx-action v3</summary>\n\nNo release notes found. [View the tag](https://github.com/docker/setup-buildx-action/tree/v3)",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("body is missing %q:\n%s", want, body)
+			}
+		}
+	})
+}
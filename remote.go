@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// remoteWorkflowDir is the workflow directory inside a remote repository
+const remoteWorkflowDir = ".github/workflows"
+
+// parseGitHubURL splits a repository URL like https://github.com/owner/repo or
+// https://github.com/owner/repo/tree/branch into owner, repository name and ref. On GitHub
+// Enterprise Server, URLs are those of its host.
+func parseGitHubURL(raw string) (string, string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", "", fmt.Errorf("url: %w", err)
+	}
+	if host := serverHost(); u.Host != host && u.Host != "www."+host {
+		return "", "", "", fmt.Errorf("url: %s is not a %s repository URL", raw, host)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("url: %s does not contain owner and repository", raw)
+	}
+
+	owner, name := parts[0], strings.TrimSuffix(parts[1], ".git")
+	var ref string
+	if len(parts) > 3 && parts[2] == "tree" {
+		ref = strings.Join(parts[3:], "/")
+	}
+
+	return owner, name, ref, nil
+}
+
+// parseGitHubRemote extracts owner and repository name of a git remote URL, accepting
+// https URLs as well as the scp-like (git@github.com:owner/repo.git) and ssh:// forms
+func parseGitHubRemote(remote string) (string, string, error) {
+	host := serverHost()
+	if rest, ok := strings.CutPrefix(remote, "git@"+host+":"); ok {
+		remote = serverURL + "/" + rest
+	} else if rest, ok := strings.CutPrefix(remote, "ssh://git@"+host+"/"); ok {
+		remote = serverURL + "/" + rest
+	}
+
+	owner, name, _, err := parseGitHubURL(remote)
+	return owner, name, err
+}
+
+// contentEntry is an item of the GitHub contents API directory listing
+type contentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// RemoteRepository implements Repository on top of the GitHub API, without a local clone.
+// File paths are relative to the repository root.
+type RemoteRepository struct {
+	owner string
+	name  string
+	ref   string
+
+	// listings caches directory listings to save API calls
+	listings map[string][]string
+}
+
+// NewRemoteRepository creates a repository reader for a GitHub URL
+func NewRemoteRepository(rawURL string) (*RemoteRepository, error) {
+	owner, name, ref, err := parseGitHubURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteRepository{owner: owner, name: name, ref: ref, listings: map[string][]string{}}, nil
+}
+
+func (r RemoteRepository) Name() string {
+	return fmt.Sprintf("%s/%s", r.owner, r.name)
+}
+
+func (r RemoteRepository) Location() string {
+	return fmt.Sprintf("%s/%s/%s", serverURL, r.owner, r.name)
+}
+
+// ListBranches only reports the ref being scanned; remote scans never enumerate branches.
+func (r RemoteRepository) ListBranches() ([]string, error) {
+	return []string{r.branch()}, nil
+}
+
+func (r RemoteRepository) ListFiles(loc string) ([]string, error) {
+	if files, ok := r.listings[loc]; ok {
+		return files, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s/contents/%s", apiURL, r.owner, r.name, filepath.ToSlash(loc))
+	if r.ref != "" {
+		endpoint = fmt.Sprintf("%s?ref=%s", endpoint, url.QueryEscape(r.ref))
+	}
+
+	resp, err := githubGet(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: listing %s returned %s", loc, resp.Status)
+	}
+
+	var entries []contentEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.Type == "file" {
+			files = append(files, e.Name)
+		}
+	}
+
+	if r.listings != nil {
+		r.listings[loc] = files
+	}
+	return files, nil
+}
+
+func (r RemoteRepository) ReadFile(filePath string) ([]byte, error) {
+	return fetchRaw(fmt.Sprintf("%s/%s/%s/%s/%s", rawContentURL, r.owner, r.name, r.branch(), path.Clean(filepath.ToSlash(filePath))))
+}
+
+// SwitchBranch is not supported as there is no worktree to check out.
+func (r RemoteRepository) SwitchBranch(branchName string) error {
+	return fmt.Errorf("remote repository %s cannot switch branches", r.Name())
+}
+
+// branch returns the ref to read files from, HEAD being the default branch
+func (r RemoteRepository) branch() string {
+	if r.ref == "" {
+		return "HEAD"
+	}
+	return r.ref
+}
+
+// fetchRaw downloads the content behind a raw file URL
+func fetchRaw(rawURL string) ([]byte, error) {
+	resp, err := githubGet(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: %s returned %s", rawURL, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	return content, nil
+}
+
+// ScanRemoteRepository collects inventory details of a GitHub repository through the API.
+// The FileScanner defaults to GitHubWorkFlowScanner when unset.
+func (s *Scanner) ScanRemoteRepository(rawURL string, regex *regexp.Regexp) (*Inventory, error) {
+	repo, err := NewRemoteRepository(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.ListFiles(remoteWorkflowDir); err != nil {
+		return nil, fmt.Errorf("remote error: %w", err)
+	}
+
+	if s.FileScanner == nil {
+		s.FileScanner = GitHubWorkFlowScanner{}
+	}
+	return &Inventory{
+		Records: s.ScanBranch(repo.branch(), repo, regex, remoteWorkflowDir),
+	}, nil
+}
+
+// OrgScanOptions selects the repositories of an organization ScanOrg scans
+type OrgScanOptions struct {
+	// Archived also scans archived repositories, which can no longer be fixed
+	Archived bool
+	// Visibilities keeps the repositories of these visibilities, such as public, private
+	// or internal, all of them when empty
+	Visibilities []string
+	// Clone reads the workflows from shallow clones of the repositories rather than through
+	// the contents API
+	Clone bool
+}
+
+// keeps reports whether the options select a repository
+func (o OrgScanOptions) keeps(r orgRepository) bool {
+	if r.Archived && !o.Archived {
+		return false
+	}
+	return len(o.Visibilities) == 0 || slices.ContainsFunc(o.Visibilities, func(v string) bool { return strings.EqualFold(v, r.Visibility) })
+}
+
+// ScanOrg collects inventory details of every repository of a GitHub organization through
+// the API, reading the workflows of their default branch, or from shallow clones with
+// opts.Clone. Repositories without workflows are skipped.
+func (s *Scanner) ScanOrg(org string, regex *regexp.Regexp, opts OrgScanOptions) (*Inventory, error) {
+	repos, err := getPages[orgRepository](fmt.Sprintf("%s/%s/repos?type=all", orgsAPIURL, url.PathEscape(org)))
+	if err != nil {
+		return nil, fmt.Errorf("remote error: %w", err)
+	}
+
+	if s.FileScanner == nil {
+		s.FileScanner = GitHubWorkFlowScanner{}
+	}
+	var kept []orgRepository
+	for _, r := range repos {
+		if !opts.keeps(r) {
+			logger.Debug("skipping repository of the organization", "repo", r.Name, "archived", r.Archived, "visibility", r.Visibility)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	records := s.scanEach(len(kept), func(i int) string { return org + "/" + kept[i].Name }, func(i int) []*InventoryRecord {
+		if opts.Clone {
+			cloneURL, name, _ := cloneTarget(fmt.Sprintf("%s/%s/%s", serverURL, org, kept[i].Name))
+			records, err := s.scanClone(cloneURL, name, "", regex)
+			if err != nil {
+				logger.Warn("could not clone repository. skipping to next repo", "repo", name, "err", err)
+			}
+			return records
+		}
+		repo := &RemoteRepository{owner: org, name: kept[i].Name, listings: map[string][]string{}}
+		return s.ScanBranch(repo.branch(), repo, regex, remoteWorkflowDir)
+	})
+	return &Inventory{Records: slices.Concat(records...)}, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/cybrota/scharf/sharfertest"
+)
+
+// --- Tests for parseGitHubURL ---
+
+func TestParseGitHubURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantOwner string
+		wantName  string
+		wantRef   string
+		wantErr   bool
+	}{
+		{name: "repository URL", input: "https://github.com/owner/repo", wantOwner: "owner", wantName: "repo"},
+		{name: "trailing slash and .git", input: "https://github.com/owner/repo.git/", wantOwner: "owner", wantName: "repo"},
+		{name: "tree URL with ref", input: "https://github.com/owner/repo/tree/feature/x", wantOwner: "owner", wantName: "repo", wantRef: "feature/x"},
+		{name: "missing repository", input: "https://github.com/owner", wantErr: true},
+		{name: "other host", input: "https://gitlab.com/owner/repo", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			owner, name, ref, err := parseGitHubURL(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q, got nil", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owner != tc.wantOwner || name != tc.wantName || ref != tc.wantRef {
+				t.Errorf("parseGitHubURL(%q) = (%q, %q, %q); want (%q, %q, %q)", tc.input, owner, name, ref, tc.wantOwner, tc.wantName, tc.wantRef)
+			}
+		})
+	}
+}
+
+// --- Tests for parseGitHubRemote ---
+
+func TestParseGitHubRemote(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/owner/repo.git",
+		"git@github.com:owner/repo.git",
+		"ssh://git@github.com/owner/repo",
+	} {
+		owner, name, err := parseGitHubRemote(remote)
+		if err != nil || owner != "owner" || name != "repo" {
+			t.Errorf("parseGitHubRemote(%q) = (%q, %q, %v); want (owner, repo, nil)", remote, owner, name, err)
+		}
+	}
+}
+
+// --- Tests for ScanRemoteRepository ---
+
+func TestScanRemoteRepository(t *testing.T) {
+	listing, err := json.Marshal([]contentEntry{
+		{Name: "ci.yml", Path: ".github/workflows/ci.yml", Type: "file"},
+		{Name: "pinned.yml", Path: ".github/workflows/pinned.yml", Type: "file"},
+		{Name: "nested", Path: ".github/workflows/nested", Type: "dir"},
+	})
+	CheckIfError(err)
+
+	responses := map[string]string{
+		"https://api.github.com/repos/owner/repo/contents/.github/workflows?ref=dev":    string(listing),
+		"https://raw.githubusercontent.com/owner/repo/dev/.github/workflows/ci.yml":     "steps:\n  - uses: actions/checkout@v4\n",
+		"https://raw.githubusercontent.com/owner/repo/dev/.github/workflows/pinned.yml": "steps:\n  - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683\n",
+	}
+
+	requests := map[string]int{}
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests[req.URL.String()]++
+		body, ok := responses[req.URL.String()]
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		inv, err := (&Scanner{}).ScanRemoteRepository("https://github.com/owner/repo/tree/dev", mutableRefRegex)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(inv.Records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(inv.Records))
+		}
+
+		ir := inv.Records[0]
+		if ir.Repository != "owner/repo" || ir.Branch != "dev" {
+			t.Errorf("unexpected record identity: %+v", ir)
+		}
+		if len(ir.Matches) != 1 || ir.Matches[0] != "actions/checkout@v4" {
+			t.Errorf("unexpected matches: %v", ir.Matches)
+		}
+	})
+
+	for u, n := range requests {
+		if n > 1 {
+			t.Errorf("%s was requested %d times, expected once", u, n)
+		}
+	}
+}
+
+func TestScanRemoteRepository_MissingWorkflows(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Status:     "404 Not Found",
+			Body:       io.NopCloser(bytes.NewReader([]byte(`{"message": "Not Found"}`))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		if _, err := (&Scanner{}).ScanRemoteRepository("https://github.com/owner/repo", mutableRefRegex); err == nil {
+			t.Error("expected error when the workflow directory cannot be listed, got nil")
+		}
+	})
+}
+
+// --- Tests for ScanOrg ---
+
+func TestScanOrg(t *testing.T) {
+	listing, err := json.Marshal([]contentEntry{{Name: "ci.yml", Path: ".github/workflows/ci.yml", Type: "file"}})
+	CheckIfError(err)
+	repos, err := json.Marshal([]orgRepository{
+		{Name: "api", Visibility: "private"},
+		{Name: "site", Visibility: "public"},
+		{Name: "legacy", Visibility: "private", Archived: true},
+		{Name: "docs", Visibility: "private"},
+	})
+	CheckIfError(err)
+
+	responses := map[string]string{
+		"https://api.github.com/orgs/acme/repos?type=all&per_page=100&page=1":         string(repos),
+		"https://api.github.com/repos/acme/api/contents/.github/workflows":            string(listing),
+		"https://api.github.com/repos/acme/site/contents/.github/workflows":           string(listing),
+		"https://api.github.com/repos/acme/legacy/contents/.github/workflows":         string(listing),
+		"https://raw.githubusercontent.com/acme/api/HEAD/.github/workflows/ci.yml":    "steps:\n  - uses: actions/checkout@v4\n",
+		"https://raw.githubusercontent.com/acme/site/HEAD/.github/workflows/ci.yml":   "steps:\n  - uses: actions/setup-node@v4\n",
+		"https://raw.githubusercontent.com/acme/legacy/HEAD/.github/workflows/ci.yml": "steps:\n  - uses: actions/cache@v3\n",
+	}
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := responses[req.URL.String()]
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusNotFound
+		}
+		return &http.Response{
+			StatusCode: status,
+			Status:     http.StatusText(status),
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	tests := []struct {
+		name     string
+		opts     OrgScanOptions
+		expected []string
+	}{
+		{name: "archived skipped", expected: []string{"acme/api", "acme/site"}},
+		{name: "archived included", opts: OrgScanOptions{Archived: true}, expected: []string{"acme/api", "acme/site", "acme/legacy"}},
+		{name: "visibility", opts: OrgScanOptions{Visibilities: []string{"Public"}}, expected: []string{"acme/site"}},
+	}
+	withHTTPClientTransport(customTransport, func() {
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				inv, err := (&Scanner{}).ScanOrg("acme", mutableRefRegex, tc.opts)
+				CheckIfError(err)
+				var got []string
+				for _, ir := range inv.Records {
+					got = append(got, ir.Repository)
+				}
+				if !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("scanned %v, expected %v", got, tc.expected)
+				}
+			})
+		}
+	})
+}
+
+// --- Tests for ScanOrg against a fake GitHub ---
+
+func TestScanOrg_FakeGitHub(t *testing.T) {
+	gh := sharfertest.NewGitHub(t)
+	gh.File("acme/api", "HEAD", ".github/workflows/ci.yml", sharfertest.MutableWorkflow)
+	gh.File("acme/web", "HEAD", ".github/workflows/ci.yml", sharfertest.PinnedWorkflow)
+	gh.File("acme/web", "HEAD", "README.md", "# web\n")
+	gh.Tag("actions/checkout", "v4", sharfertest.CheckoutSHA)
+	gh.Setenv(t)
+	withGitHubEndpoints(t)
+	CheckIfError(githubServerFromEnv())
+
+	inv, err := (&Scanner{}).ScanOrg("acme", mutableRefRegex, OrgScanOptions{})
+	CheckIfError(err)
+	if len(inv.Records) != 1 || inv.Records[0].Repository != "acme/api" || !reflect.DeepEqual(inv.Records[0].Matches, []string{"actions/checkout@v4", "actions/setup-go@v5"}) {
+		t.Fatalf("expected the mutable references of acme/api only, got %+v", inv.Records)
+	}
+
+	sha, err := SHAResolver{}.resolve("actions/checkout@v4")
+	if err != nil || sha != sharfertest.CheckoutSHA {
+		t.Errorf("expected the tag of the fake to resolve, got %s (err %v)", sha, err)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportFormats lists the --out values that render a report for humans rather than data
+var reportFormats = map[string]bool{"markdown": true, "html": true, "vendor": true}
+
+// riskRow is a workflow of the risk matrix
+type riskRow struct {
+	Repository string
+	FilePath   string
+	Profile    *WorkflowProfile
+}
+
+// riskMatrix returns the profiled workflows of an inventory, riskiest first
+func riskMatrix(inv *Inventory) []riskRow {
+	var rows []riskRow
+	for _, ir := range inv.Records {
+		if ir.Profile != nil {
+			rows = append(rows, riskRow{Repository: ir.Repository, FilePath: ir.FilePath, Profile: ir.Profile})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		ri, rj := severityRank[rows[i].Profile.Risk], severityRank[rows[j].Profile.Risk]
+		if ri != rj {
+			return ri > rj
+		}
+		if rows[i].Repository != rows[j].Repository {
+			return rows[i].Repository < rows[j].Repository
+		}
+		return rows[i].FilePath < rows[j].FilePath
+	})
+	return rows
+}
+
+// orNone renders an empty list as "none"
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// unassignedComponent gathers records not matching any component
+const unassignedComponent = "unassigned"
+
+// pinningScore returns the share of action references pinned to a SHA in the profiled
+// workflows of records, in percent. It reports false when no action is referenced.
+func pinningScore(records []*InventoryRecord) (int, bool) {
+	refs, pinned := 0, 0
+	for _, ir := range records {
+		if ir.Profile != nil {
+			refs += ir.Profile.ActionRefs
+			pinned += ir.Profile.PinnedRefs
+		}
+	}
+	if refs == 0 {
+		return 0, false
+	}
+	return pinned * 100 / refs, true
+}
+
+// scoreText renders the pinning score of records
+func scoreText(records []*InventoryRecord) string {
+	score, ok := pinningScore(records)
+	if !ok {
+		return "n/a"
+	}
+	return fmt.Sprintf("%d%%", score)
+}
+
+// componentSection holds the records of a component of a monorepo
+type componentSection struct {
+	Name     string
+	Records  []*InventoryRecord
+	Score    string
+	Matches  int
+	Findings int
+}
+
+// componentSections groups the records of an inventory by component, or returns nil when
+// no component is configured. Unassigned records come last.
+func componentSections(inv *Inventory) []componentSection {
+	byName := map[string]*componentSection{}
+	var names []string
+	assigned := false
+	for _, ir := range inv.Records {
+		name := ir.Component
+		if name == "" {
+			name = unassignedComponent
+		} else {
+			assigned = true
+		}
+		if byName[name] == nil {
+			byName[name] = &componentSection{Name: name}
+			names = append(names, name)
+		}
+		sec := byName[name]
+		sec.Records = append(sec.Records, ir)
+		sec.Matches += len(ir.Locations)
+		sec.Findings += len(ir.Findings)
+	}
+	if !assigned {
+		return nil
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		if (names[i] == unassignedComponent) != (names[j] == unassignedComponent) {
+			return names[j] == unassignedComponent
+		}
+		return names[i] < names[j]
+	})
+	var sections []componentSection
+	for _, name := range names {
+		sec := byName[name]
+		sec.Score = scoreText(sec.Records)
+		sections = append(sections, *sec)
+	}
+	return sections
+}
+
+// writeMarkdownReport renders the inventory as a Markdown report: the risk matrix of the
+// workflows followed by mutable references and rule findings, per component if configured
+func writeMarkdownReport(w io.Writer, inv *Inventory) error {
+	var b strings.Builder
+	b.WriteString("# Scharf report\n")
+	if _, ok := pinningScore(inv.Records); ok {
+		fmt.Fprintf(&b, "\nPinning score: %s of action references are pinned to a commit SHA.\n", scoreText(inv.Records))
+	}
+
+	if rows := riskMatrix(inv); len(rows) > 0 {
+		b.WriteString("\n## Workflow risk matrix\n\n")
+		b.WriteString("| Risk | Repository | Workflow | Triggers | Secrets | Permissions | Runners |\n")
+		b.WriteString("|------|------------|----------|----------|---------|-------------|---------|\n")
+		for _, r := range rows {
+			p := r.Profile
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				p.Risk, markdownCell(r.Repository), markdownCell(r.FilePath), markdownCell(orNone(p.Triggers)),
+				markdownCell(orNone(p.Secrets)), markdownCell(p.permissionsSummary()), markdownCell(p.runnerSummary()))
+		}
+	}
+
+	sections := componentSections(inv)
+	if sections == nil {
+		writeMarkdownRecords(&b, inv.Records, "##")
+	} else {
+		b.WriteString("\n## Components\n\n")
+		b.WriteString("| Component | Workflows | Pinning score | Mutable references | Findings |\n")
+		b.WriteString("|-----------|-----------|---------------|--------------------|----------|\n")
+		for _, sec := range sections {
+			fmt.Fprintf(&b, "| %s | %d | %s | %d | %d |\n", markdownCell(sec.Name), len(sec.Records), sec.Score, sec.Matches, sec.Findings)
+		}
+		for _, sec := range sections {
+			fmt.Fprintf(&b, "\n## Component: %s\n", sec.Name)
+			writeMarkdownRecords(&b, sec.Records, "###")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownRecords renders the mutable references and findings of records under
+// headings of the given level
+func writeMarkdownRecords(b *strings.Builder, records []*InventoryRecord, heading string) {
+	inv := &Inventory{Records: records}
+	// Issues attributed with --blame get columns with their probable owner and the commit
+	// that brought them in.
+	owner, ownerHeader, ownerRule := func(*lineAuthor) string { return "" }, "", ""
+	if inv.blamed() {
+		owner = func(a *lineAuthor) string {
+			return " " + markdownCell(probableOwner(a)) + " | " + introducedIn(a) + " |"
+		}
+		ownerHeader, ownerRule = " Probable owner | Introduced in |", "----------------|---------------|"
+	}
+
+	if inv.hasMatches() {
+		fmt.Fprintf(b, "\n%s Mutable references\n\n", heading)
+		b.WriteString("| Match | Repository | File | Line |" + ownerHeader + "\n")
+		b.WriteString("|-------|------------|------|------|" + ownerRule + "\n")
+		for _, ir := range records {
+			for _, loc := range ir.Locations {
+				fmt.Fprintf(b, "| `%s` | %s | %s | %d |%s\n",
+					loc.Value, markdownCell(ir.Repository), markdownCell(ir.FilePath), loc.Line, owner(loc.Blame))
+			}
+		}
+	}
+
+	if inv.hasFindings() {
+		fmt.Fprintf(b, "\n%s Findings\n\n", heading)
+		b.WriteString("| Rule | Severity | Repository | File | Line | Message |" + ownerHeader + "\n")
+		b.WriteString("|------|----------|------------|------|------|---------|" + ownerRule + "\n")
+		for _, ir := range records {
+			for _, f := range ir.Findings {
+				fmt.Fprintf(b, "| %s | %s | %s | %s | %d | %s |%s\n",
+					f.Rule, f.Severity, markdownCell(ir.Repository), markdownCell(ir.FilePath), f.Line, markdownCell(f.Message), owner(f.Blame))
+			}
+		}
+	}
+
+	if inv.hasSuppressed() {
+		fmt.Fprintf(b, "\n%s Suppressed findings\n\n", heading)
+		b.WriteString("| Rule | Severity | Repository | File | Line | Reason | Source |\n")
+		b.WriteString("|------|----------|------------|------|------|--------|--------|\n")
+		for _, ir := range records {
+			for _, f := range ir.Suppressed {
+				reason := f.Reason
+				if reason == "" {
+					reason = "none"
+				}
+				fmt.Fprintf(b, "| %s | %s | %s | %s | %d | %s | %s |\n",
+					f.Rule, f.Severity, markdownCell(ir.Repository), markdownCell(ir.FilePath), f.Line, markdownCell(reason), f.Source)
+			}
+		}
+	}
+
+	if inv.hasModifications() {
+		fmt.Fprintf(b, "\n%s Last modified\n\n", heading)
+		b.WriteString("| Repository | File | Commit | By | When | From |\n")
+		b.WriteString("|------------|------|--------|----|------|------|\n")
+		for _, ir := range records {
+			if m := ir.LastModified; m != nil {
+				fmt.Fprintf(b, "| %s | %s | `%s` | %s | %s | %s |\n",
+					markdownCell(ir.Repository), markdownCell(ir.FilePath), shortSHA(m.Commit), markdownCell(m.Actor), m.At.UTC().Format(time.RFC3339), markdownCell(orUnknown(m.From())))
+			}
+		}
+	}
+}
+
+// orUnknown returns s, or "unknown" when it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"orNone":        orNone,
+	"permissions":   (*WorkflowProfile).permissionsSummary,
+	"runners":       (*WorkflowProfile).runnerSummary,
+	"hasMatches":    func(records []*InventoryRecord) bool { return (&Inventory{Records: records}).hasMatches() },
+	"hasFindings":   func(records []*InventoryRecord) bool { return (&Inventory{Records: records}).hasFindings() },
+	"hasSuppressed": func(records []*InventoryRecord) bool { return (&Inventory{Records: records}).hasSuppressed() },
+	"hasModified":   func(records []*InventoryRecord) bool { return (&Inventory{Records: records}).hasModifications() },
+	"blamed":        func(records []*InventoryRecord) bool { return (&Inventory{Records: records}).blamed() },
+	"owner":         probableOwner,
+	"introduced":    introducedIn,
+	"shortSHA":      shortSHA,
+	"orUnknown":     orUnknown,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scharf report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.critical { background: #b71c1c; color: #fff; }
+.high { background: #e65100; color: #fff; }
+.medium { background: #fbc02d; }
+.low { background: #c8e6c9; }
+.sparkline { vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>Scharf report</h1>
+{{- if .Score}}
+<p>Pinning score: {{.Score}} of action references are pinned to a commit SHA.</p>
+{{- end}}
+{{- with .Trends}}
+<h2>Trends</h2>
+<p>Issues in {{len .Dates}} scans from {{index .Dates 0}} to now.</p>
+<table>
+<tr><th>Repository</th><th>Trend</th><th>First</th><th>Latest</th></tr>
+{{- range .Repositories}}
+<tr><td>{{.Name}}</td><td>{{.Sparkline}}</td><td>{{.First}}</td><td>{{.Latest}}</td></tr>
+{{- end}}
+</table>
+<table>
+<tr><th>Rule</th><th>Trend</th><th>First</th><th>Latest</th></tr>
+{{- range .Rules}}
+<tr><td>{{.Name}}</td><td>{{.Sparkline}}</td><td>{{.First}}</td><td>{{.Latest}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Matrix}}
+<h2>Workflow risk matrix</h2>
+<table>
+<tr><th>Risk</th><th>Repository</th><th>Workflow</th><th>Triggers</th><th>Secrets</th><th>Permissions</th><th>Runners</th></tr>
+{{- range .Matrix}}
+<tr><td class="{{.Profile.Risk}}">{{.Profile.Risk}}</td><td>{{.Repository}}</td><td>{{.FilePath}}</td><td>{{orNone .Profile.Triggers}}</td><td>{{orNone .Profile.Secrets}}</td><td>{{permissions .Profile}}</td><td>{{runners .Profile}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Sections}}
+<h2>Components</h2>
+<table>
+<tr><th>Component</th><th>Workflows</th><th>Pinning score</th><th>Mutable references</th><th>Findings</th></tr>
+{{- range .Sections}}
+<tr><td>{{.Name}}</td><td>{{len .Records}}</td><td>{{.Score}}</td><td>{{.Matches}}</td><td>{{.Findings}}</td></tr>
+{{- end}}
+</table>
+{{- range .Sections}}
+<h2>Component: {{.Name}}</h2>
+{{- template "records" .Records}}
+{{- end}}
+{{- else}}
+{{- template "records" .Inventory.Records}}
+{{- end}}
+</body>
+</html>
+{{- define "records"}}
+{{- $blamed := blamed .}}
+{{- if hasMatches .}}
+<h3>Mutable references</h3>
+<table>
+<tr><th>Match</th><th>Repository</th><th>File</th><th>Line</th>{{if $blamed}}<th>Probable owner</th><th>Introduced in</th>{{end}}</tr>
+{{- range .}}{{$ir := .}}{{range .Locations}}
+<tr><td><code>{{.Value}}</code></td><td>{{$ir.Repository}}</td><td>{{$ir.FilePath}}</td><td>{{.Line}}</td>{{if $blamed}}<td>{{owner .Blame}}</td><td>{{introduced .Blame}}</td>{{end}}</tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- if hasFindings .}}
+<h3>Findings</h3>
+<table>
+<tr><th>Rule</th><th>Severity</th><th>Repository</th><th>File</th><th>Line</th><th>Message</th>{{if $blamed}}<th>Probable owner</th><th>Introduced in</th>{{end}}</tr>
+{{- range .}}{{$ir := .}}{{range .Findings}}
+<tr><td>{{.Rule}}</td><td class="{{.Severity}}">{{.Severity}}</td><td>{{$ir.Repository}}</td><td>{{$ir.FilePath}}</td><td>{{.Line}}</td><td>{{.Message}}{{if .Suggestion}}<pre>{{.Suggestion}}</pre>{{end}}</td>{{if $blamed}}<td>{{owner .Blame}}</td><td>{{introduced .Blame}}</td>{{end}}</tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- if hasSuppressed .}}
+<h3>Suppressed findings</h3>
+<table>
+<tr><th>Rule</th><th>Severity</th><th>Repository</th><th>File</th><th>Line</th><th>Reason</th><th>Source</th></tr>
+{{- range .}}{{$ir := .}}{{range .Suppressed}}
+<tr><td>{{.Rule}}</td><td class="{{.Severity}}">{{.Severity}}</td><td>{{$ir.Repository}}</td><td>{{$ir.FilePath}}</td><td>{{.Line}}</td><td>{{or .Reason "none"}}</td><td>{{.Source}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- if hasModified .}}
+<h3>Last modified</h3>
+<table>
+<tr><th>Repository</th><th>File</th><th>Commit</th><th>By</th><th>When</th><th>From</th></tr>
+{{- range .}}{{$ir := .}}{{with .LastModified}}
+<tr><td>{{$ir.Repository}}</td><td>{{$ir.FilePath}}</td><td><code>{{shortSHA .Commit}}</code></td><td>{{.Actor}}</td><td>{{.At.UTC.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{orUnknown .From}}</td></tr>
+{{- end}}{{end}}
+</table>
+{{- end}}
+{{- end}}
+`))
+
+// writeHTMLReport renders the inventory as a standalone HTML page with the same sections
+// as the Markdown report, plus trend charts when the inventory has history
+func writeHTMLReport(w io.Writer, inv *Inventory) error {
+	var score string
+	if _, ok := pinningScore(inv.Records); ok {
+		score = scoreText(inv.Records)
+	}
+	return htmlReport.Execute(w, struct {
+		Inventory *Inventory
+		Score     string
+		Matrix    []riskRow
+		Sections  []componentSection
+		Trends    *reportTrends
+	}{inv, score, riskMatrix(inv), componentSections(inv), inventoryTrends(inv)})
+}
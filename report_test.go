@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func reportFixture() *Inventory {
+	return &Inventory{Records: []*InventoryRecord{
+		{
+			Repository: "repo",
+			FilePath:   "ci.yml",
+			Matches:    []string{"actions/checkout@v4"},
+			Locations:  []Match{{Value: "actions/checkout@v4", Line: 7, Column: 15}},
+			Profile:    &WorkflowProfile{Triggers: []string{"push"}, Risk: SeverityLow},
+		},
+		{
+			Repository: "repo",
+			FilePath:   "triage.yml",
+			Findings:   []Finding{{Rule: ruleExcessivePermissions, Severity: SeverityMedium, Message: "job a|b is granted <too much>", Line: 3}},
+			Profile:    &WorkflowProfile{Triggers: []string{"pull_request_target"}, Secrets: []string{"TOKEN"}, Risk: SeverityCritical},
+		},
+	}}
+}
+
+// --- Tests for riskMatrix ---
+
+func TestRiskMatrix(t *testing.T) {
+	inv := reportFixture()
+	inv.Records = append(inv.Records, &InventoryRecord{Repository: "repo", FilePath: "no-profile.yml"})
+
+	rows := riskMatrix(inv)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].FilePath != "triage.yml" || rows[1].FilePath != "ci.yml" {
+		t.Errorf("expected riskiest workflow first, got %s, %s", rows[0].FilePath, rows[1].FilePath)
+	}
+}
+
+// --- Tests for writeMarkdownReport ---
+
+func TestWriteMarkdownReport(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeMarkdownReport(&b, reportFixture()); err != nil {
+		t.Fatalf("writeMarkdownReport returned error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"## Workflow risk matrix",
+		"| critical | repo | triage.yml | pull_request_target | TOKEN | read-only | github-hosted |",
+		"| low | repo | ci.yml | push | none | read-only | github-hosted |",
+		"| `actions/checkout@v4` | repo | ci.yml | 7 |",
+		`| excessive-permissions | medium | repo | triage.yml | 3 | job a\|b is granted <too much> |`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "triage.yml | pull_request_target") > strings.Index(out, "ci.yml | push") {
+		t.Error("expected the critical workflow before the low one")
+	}
+}
+
+func TestWriteMarkdownReport_Suppressed(t *testing.T) {
+	inv := reportFixture()
+	inv.Records[0].Suppressed = []SuppressedFinding{{
+		Finding: Finding{Rule: ruleUndeclaredPermissions, Severity: SeverityMedium, Line: 2},
+		Reason:  "public docs",
+		Source:  suppressionInline,
+	}}
+
+	var b bytes.Buffer
+	if err := writeMarkdownReport(&b, inv); err != nil {
+		t.Fatalf("writeMarkdownReport returned error: %v", err)
+	}
+	want := "| undeclared-permissions | medium | repo | ci.yml | 2 | public docs | inline |"
+	if out := b.String(); !strings.Contains(out, "## Suppressed findings") || !strings.Contains(out, want) {
+		t.Errorf("report is missing the suppressed finding %q:\n%s", want, out)
+	}
+}
+
+// --- Tests for writeHTMLReport ---
+
+func TestWriteHTMLReport(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeHTMLReport(&b, reportFixture()); err != nil {
+		t.Fatalf("writeHTMLReport returned error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`<td class="critical">critical</td>`,
+		"<code>actions/checkout@v4</code>",
+		"job a|b is granted &lt;too much&gt;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// --- Tests for componentSections ---
+
+func TestComponentSections(t *testing.T) {
+	inv := reportFixture()
+	if componentSections(inv) != nil {
+		t.Error("expected no sections without components")
+	}
+
+	inv.Records[0].Component = "payments"
+	inv.Records[0].Profile.ActionRefs, inv.Records[0].Profile.PinnedRefs = 4, 3
+	sections := componentSections(inv)
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(sections))
+	}
+	if sections[0].Name != "payments" || sections[0].Score != "75%" || sections[0].Matches != 1 {
+		t.Errorf("unexpected payments section: %+v", sections[0])
+	}
+	if sections[1].Name != unassignedComponent || sections[1].Score != "n/a" || sections[1].Findings != 1 {
+		t.Errorf("unexpected unassigned section: %+v", sections[1])
+	}
+
+	var b bytes.Buffer
+	if err := writeMarkdownReport(&b, inv); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Pinning score: 75% of action references",
+		"| payments | 1 | 75% | 1 | 0 |",
+		"## Component: payments\n\n### Mutable references",
+		"## Component: unassigned\n\n### Findings",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ruleMissingRequiredWorkflow flags repositories without a workflow the policy requires
+const ruleMissingRequiredWorkflow = "missing-required-workflow"
+
+// RequiredWorkflow is a workflow the policy requires every repository to have, such as
+// CodeQL, dependency review or scharf itself
+type RequiredWorkflow struct {
+	// Name names the control in findings. Ex: CodeQL
+	Name string `yaml:"name"`
+	// File is a glob of the names the workflow file may have, any name when empty
+	File string `yaml:"file"`
+	// Uses lists the actions and reusable workflows, without ref, the workflow must call
+	Uses []string `yaml:"uses"`
+	// On lists the events the workflow must run on
+	On []string `yaml:"on"`
+	// Template is a reference workflow file. The actions it calls and the events it runs on
+	// are required as if listed in Uses and On.
+	Template string `yaml:"template"`
+}
+
+// loadTemplate adds the calls and events of the reference template, if any, to those
+// required
+func (r RequiredWorkflow) loadTemplate() (RequiredWorkflow, error) {
+	if r.Template == "" {
+		return r, nil
+	}
+	content, err := os.ReadFile(r.Template)
+	if err != nil {
+		return r, fmt.Errorf("file error: %w", err)
+	}
+	wf := newWorkflowFile(r.Template, content)
+	if len(wf.Docs) == 0 {
+		return r, fmt.Errorf("template %s of required workflow %s is not valid YAML", r.Template, r.Name)
+	}
+
+	r.Uses = slices.Clone(r.Uses)
+	for _, n := range wf.usesNodes() {
+		if ref := usesTarget(n.Value); ref != "" && !slices.ContainsFunc(r.Uses, func(u string) bool { return strings.EqualFold(u, ref) }) {
+			r.Uses = append(r.Uses, ref)
+		}
+	}
+	r.On = slices.Clone(r.On)
+	for _, root := range wf.roots() {
+		for _, event := range workflowTriggers(mappingValue(root, "on")) {
+			if !slices.Contains(r.On, event) {
+				r.On = append(r.On, event)
+			}
+		}
+	}
+	return r, nil
+}
+
+// usesTarget returns the action or reusable workflow a `uses:` value calls, without its
+// ref, or "" for local and Docker references
+func usesTarget(value string) string {
+	if strings.HasPrefix(value, "./") || strings.HasPrefix(value, "docker://") {
+		return ""
+	}
+	return splitRawAction(value)[0]
+}
+
+// missing returns what a workflow lacks to be the required one: the calls, then the
+// events, it is missing
+func (r RequiredWorkflow) missing(wf *WorkflowFile) []string {
+	calls := map[string]bool{}
+	for _, n := range wf.usesNodes() {
+		calls[strings.ToLower(usesTarget(n.Value))] = true
+	}
+	var events []string
+	for _, root := range wf.roots() {
+		events = append(events, workflowTriggers(mappingValue(root, "on"))...)
+	}
+
+	var missing []string
+	for _, u := range r.Uses {
+		if !calls[strings.ToLower(u)] {
+			missing = append(missing, "a call to "+u)
+		}
+	}
+	for _, on := range r.On {
+		if !slices.Contains(events, on) {
+			missing = append(missing, "the "+on+" trigger")
+		}
+	}
+	return missing
+}
+
+// requiredWorkflowFindings flags every required workflow none of the workflows of a
+// repository is. When workflows have the required file name, the finding tells what the
+// closest of them lacks.
+func requiredWorkflowFindings(required []RequiredWorkflow, workflows []*WorkflowFile) []Finding {
+	var findings []Finding
+	for _, r := range required {
+		var closest []string
+		var closestPath string
+		found := false
+		for _, wf := range workflows {
+			if r.File != "" && !matchesAny([]string{r.File}, path.Base(wf.Path)) {
+				continue
+			}
+			missing := r.missing(wf)
+			if len(missing) == 0 {
+				found = true
+				break
+			}
+			if closestPath == "" || len(missing) < len(closest) {
+				closest, closestPath = missing, path.Base(wf.Path)
+			}
+		}
+		if found {
+			continue
+		}
+
+		message := fmt.Sprintf("no workflow of the repository is the required %s workflow", r.Name)
+		if closestPath != "" && r.File != "" {
+			message = fmt.Sprintf("workflow %s is not the required %s workflow: it lacks %s", closestPath, r.Name, strings.Join(closest, ", "))
+		} else if len(r.Uses) > 0 {
+			message += fmt.Sprintf(", calling %s", strings.Join(r.Uses, ", "))
+		}
+		findings = append(findings, Finding{Rule: ruleMissingRequiredWorkflow, Severity: SeverityHigh, Message: message})
+	}
+	return findings
+}
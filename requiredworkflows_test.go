@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// --- Tests for requiredWorkflowFindings ---
+
+func TestRequiredWorkflowFindings(t *testing.T) {
+	workflows := []*WorkflowFile{
+		newWorkflowFile(".github/workflows/codeql.yml", []byte("on: [push, pull_request]\njobs:\n  analyze:\n    steps:\n      - uses: github/codeql-action/init@v3\n      - uses: GitHub/codeql-action/analyze@v3\n")),
+		newWorkflowFile(".github/workflows/dependency-review.yml", []byte("on: push\njobs:\n  review:\n    steps:\n      - uses: actions/checkout@v4\n")),
+	}
+	tests := []struct {
+		name     string
+		required RequiredWorkflow
+		expected string
+	}{
+		{
+			name:     "present",
+			required: RequiredWorkflow{Name: "CodeQL", Uses: []string{"github/codeql-action/init", "github/codeql-action/analyze"}, On: []string{"pull_request"}},
+		},
+		{
+			name:     "missing",
+			required: RequiredWorkflow{Name: "scharf", Uses: []string{"cybrota/scharf"}},
+			expected: "no workflow of the repository is the required scharf workflow, calling cybrota/scharf",
+		},
+		{
+			name:     "file not matching",
+			required: RequiredWorkflow{Name: "dependency review", File: "dependency-review.yml", Uses: []string{"actions/dependency-review-action"}, On: []string{"pull_request"}},
+			expected: "workflow dependency-review.yml is not the required dependency review workflow: it lacks a call to actions/dependency-review-action, the pull_request trigger",
+		},
+		{
+			name:     "no file with the name",
+			required: RequiredWorkflow{Name: "release", File: "release*.yml"},
+			expected: "no workflow of the repository is the required release workflow",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := requiredWorkflowFindings([]RequiredWorkflow{tc.required}, workflows)
+			if tc.expected == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no findings, got %+v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].Message != tc.expected || findings[0].Rule != ruleMissingRequiredWorkflow {
+				t.Errorf("unexpected findings %+v", findings)
+			}
+		})
+	}
+}
+
+// --- Tests for RequiredWorkflow.loadTemplate ---
+
+func TestRequiredWorkflow_LoadTemplate(t *testing.T) {
+	template := filepath.Join(t.TempDir(), "dependency-review.yml")
+	CheckIfError(os.WriteFile(template, []byte("on:\n  pull_request:\njobs:\n  review:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/dependency-review-action@v4\n      - uses: ./local\n"), 0o644))
+
+	r, err := RequiredWorkflow{Name: "dependency review", Uses: []string{"actions/checkout"}, Template: template}.loadTemplate()
+	CheckIfError(err)
+	if !reflect.DeepEqual(r.Uses, []string{"actions/checkout", "actions/dependency-review-action"}) || !reflect.DeepEqual(r.On, []string{"pull_request"}) {
+		t.Errorf("unexpected requirement %+v", r)
+	}
+
+	if _, err := (RequiredWorkflow{Name: "missing", Template: template + ".missing"}).loadTemplate(); err == nil {
+		t.Errorf("expected a missing template to fail")
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with required workflows ---
+
+func TestScanner_ScanBranchRequiredWorkflows(t *testing.T) {
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, RequiredWorkflows: []RequiredWorkflow{{Name: "scharf", Uses: []string{"cybrota/scharf"}}}}
+
+	// Repositories without workflows lack them all.
+	repo := fakeRepository{name: "repo", listFilesErr: os.ErrNotExist}
+	records := sc.ScanBranch("main", repo, mutableRefRegex, workflowDir("repo"))
+	if len(records) != 1 || records[0].FilePath != workflowDir("repo") || len(records[0].Findings) != 1 {
+		t.Fatalf("expected a record of the missing workflow, got %+v", records)
+	}
+
+	repo = fakeRepository{
+		name:         "repo",
+		files:        []string{"scharf.yml"},
+		fileContents: map[string][]byte{filepath.Join(workflowDir("repo"), "scharf.yml"): []byte("on: push\njobs:\n  audit:\n    steps:\n      - uses: cybrota/scharf@" + lockedSHA + "\n")},
+	}
+	for _, ir := range sc.ScanBranch("main", repo, mutableRefRegex, workflowDir("repo")) {
+		for _, f := range ir.Findings {
+			if strings.Contains(f.Message, "required") {
+				t.Errorf("unexpected finding %+v", f)
+			}
+		}
+	}
+}
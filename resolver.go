@@ -4,12 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 )
 
-const apiURL = "https://api.github.com/repos"
-
 // Resolver is a converter for action@version to a SHA string
 type Resolver interface {
 	// resolve checks if SHA is available for a given version of GitHub action
@@ -67,7 +64,7 @@ func makeAPIEndpoint(action string, version string) string {
 // GetRefList takes an action and returns a list of matching tags
 func GetRefList(action string) ([]BranchOrTag, error) {
 	lookupURL := fmt.Sprintf("%s/%s/tags", apiURL, action)
-	resp, err := http.Get(lookupURL)
+	resp, err := githubGet(lookupURL)
 	if err != nil {
 		return []BranchOrTag{}, fmt.Errorf("http: %w", err)
 	}
@@ -82,7 +79,17 @@ func GetRefList(action string) ([]BranchOrTag, error) {
 }
 
 // SHAResolver resolves a given action to it's safe SHA commit
-type SHAResolver struct{}
+type SHAResolver struct {
+	// Fresh skips resolutionCache on lookups, for drift detection, which must see where tags
+	// point now. What it resolves is still cached for the other commands.
+	Fresh bool
+}
+
+// freshResolver resolves references without the cache, for the lockfile and drift
+// detection, which must record and compare where tags point now
+func freshResolver() Resolver {
+	return SHAResolver{Fresh: true}
+}
 
 type Commit struct {
 	Sha string `json:"sha"`
@@ -104,9 +111,17 @@ func (s SHAResolver) resolve(action string) (string, error) {
 		version = "main"
 	}
 
+	// Keys name the API too, so lookups against GitHub Enterprise Server are kept apart.
+	key := fmt.Sprintf("%s/%s@%s", apiURL, actionBase, version)
+	if !s.Fresh {
+		if sha, ok := resolutionCache.get(key, cacheTTL(version)); ok {
+			return sha, nil
+		}
+	}
+
 	url := makeAPIEndpoint(actionBase, version)
 
-	resp, err := http.Get(url)
+	resp, err := githubGet(url)
 	if err != nil {
 		return "", fmt.Errorf("http: %w", err)
 	}
@@ -122,5 +137,6 @@ func (s SHAResolver) resolve(action string) (string, error) {
 		return "", errors.New(fmt.Sprintf("given version: %s is not found for action: %s", version, actionBase))
 	}
 
+	resolutionCache.put(key, sha)
 	return sha, nil
 }
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // --- Helper functions for testing ---
@@ -21,11 +22,12 @@ func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
 
-// withHTTPClientTransport temporarily replaces the default transport.
+// withHTTPClientTransport temporarily replaces the default transport. Retries of GitHub
+// requests do not wait in the meantime.
 func withHTTPClientTransport(rt http.RoundTripper, fn func()) {
-	orig := http.DefaultClient.Transport
-	http.DefaultClient.Transport = rt
-	defer func() { http.DefaultClient.Transport = orig }()
+	orig, sleep := http.DefaultClient.Transport, githubClient.sleep
+	http.DefaultClient.Transport, githubClient.sleep = rt, func(time.Duration) {}
+	defer func() { http.DefaultClient.Transport, githubClient.sleep = orig, sleep }()
 	fn()
 }
 
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// untrustedTriggers are events that outsiders can fire and that run in the context of the
+// base repository, with access to its secrets and a token that may be able to write
+var untrustedTriggers = map[string]bool{
+	"pull_request_target":         true,
+	"workflow_run":                true,
+	"issue_comment":               true,
+	"issues":                      true,
+	"discussion":                  true,
+	"discussion_comment":          true,
+	"pull_request_review":         true,
+	"pull_request_review_comment": true,
+}
+
+// githubHostedRunner matches labels of runners hosted by GitHub
+var githubHostedRunner = regexp.MustCompile(`^(ubuntu|windows|macos)-`)
+
+// pinnedRef matches references to a full commit SHA or an image digest
+var pinnedRef = regexp.MustCompile(`@([0-9a-f]{40}|sha256:[0-9a-f]{64})$`)
+
+// secretReference matches secrets read in expressions
+var secretReference = regexp.MustCompile(`\bsecrets\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// WorkflowProfile summarizes what can start a workflow and what it can access once started
+type WorkflowProfile struct {
+	Triggers []string `json:"triggers"`
+	// Secrets read by the workflow, or "inherit" when handed to a reusable workflow as a whole
+	Secrets []string `json:"secrets,omitempty"`
+	// WritePermissions are the token scopes with write access in any job
+	WritePermissions []string `json:"write_permissions,omitempty"`
+	// DefaultToken is set when a job runs with the repository default token permissions
+	DefaultToken bool     `json:"default_token"`
+	Runners      []string `json:"runners,omitempty"`
+	SelfHosted   bool     `json:"self_hosted"`
+	// Paths are the path filters of the triggers, which tie a workflow to parts of a monorepo
+	Paths []string `json:"paths,omitempty"`
+	// ActionRefs counts the actions and reusable workflows referenced, PinnedRefs those pinned to a SHA
+	ActionRefs int `json:"action_refs"`
+	PinnedRefs int `json:"pinned_refs"`
+	// Actions are the references counted in ActionRefs, as written
+	Actions []string `json:"actions,omitempty"`
+	// Images are the container images of jobs and services. Images of docker:// steps are
+	// in Actions.
+	Images []string `json:"images,omitempty"`
+	// Risk is the severity of the combination of triggers and privileges
+	Risk string `json:"risk"`
+	// Fork tells whether pull requests from forks can reach the secrets of the workflow
+	Fork *ForkVerdict `json:"fork"`
+}
+
+// privileged reports whether a run of the workflow has anything worth stealing
+func (p *WorkflowProfile) privileged() bool {
+	return len(p.Secrets) > 0 || len(p.WritePermissions) > 0 || p.DefaultToken || p.SelfHosted
+}
+
+// permissionsSummary describes the token permissions of the workflow in a few words
+func (p *WorkflowProfile) permissionsSummary() string {
+	var parts []string
+	if p.DefaultToken {
+		parts = append(parts, "default token")
+	}
+	if len(p.WritePermissions) > 0 {
+		parts = append(parts, "write: "+strings.Join(p.WritePermissions, ", "))
+	}
+	if len(parts) == 0 {
+		return "read-only"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// runnerSummary describes where the jobs of the workflow run
+func (p *WorkflowProfile) runnerSummary() string {
+	kind := "github-hosted"
+	if p.SelfHosted {
+		kind = "self-hosted"
+	}
+	if len(p.Runners) == 0 {
+		return kind
+	}
+	return kind + " (" + strings.Join(p.Runners, ", ") + ")"
+}
+
+// profileWorkflow builds the profile of a workflow file, or returns nil if it is not a workflow
+func profileWorkflow(wf *WorkflowFile) *WorkflowProfile {
+	roots := wf.roots()
+	if len(roots) == 0 {
+		return nil
+	}
+
+	p := &WorkflowProfile{}
+	triggers, secrets, writes, runners := map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, root := range roots {
+		on := mappingValue(root, "on")
+		for _, t := range workflowTriggers(on) {
+			if !triggers[t] {
+				triggers[t] = true
+				p.Triggers = append(p.Triggers, t)
+			}
+		}
+		p.Paths = append(p.Paths, triggerPaths(on)...)
+
+		walkScalars(root, func(n *yaml.Node) {
+			for _, m := range secretReference.FindAllStringSubmatch(n.Value, -1) {
+				if m[1] != "GITHUB_TOKEN" {
+					secrets[m[1]] = true
+				}
+			}
+		})
+
+		wfPerms := mappingValue(root, "permissions")
+		for _, pair := range mappingPairs(mappingValue(root, "jobs")) {
+			job := resolveAlias(pair.Value)
+			if job == nil || job.Kind != yaml.MappingNode {
+				continue
+			}
+			if s := mappingValue(job, "secrets"); s != nil && s.Value == "inherit" {
+				secrets["inherit"] = true
+			}
+			p.countRef(mappingValue(job, "uses"))
+			if steps := mappingValue(job, "steps"); steps != nil {
+				for _, step := range steps.Content {
+					p.countRef(mappingValue(resolveAlias(step), "uses"))
+				}
+			}
+
+			granted := mappingValue(job, "permissions")
+			if granted == nil {
+				granted = wfPerms
+			}
+			if granted == nil {
+				p.DefaultToken = true
+			} else {
+				for scope, level := range parsePermissions(granted) {
+					if level == permWrite {
+						writes[scope] = true
+					}
+				}
+			}
+
+			ctx, matrix := staticContext(root, job), jobMatrix(job)
+			for _, written := range runnerLabels(mappingValue(job, "runs-on")) {
+				for _, label := range expandMatrix(written, ctx, matrix) {
+					runners[label] = true
+					if isSelfHosted(label) {
+						p.SelfHosted = true
+					}
+				}
+			}
+		}
+	}
+
+	p.Secrets = sortedKeys(secrets)
+	p.WritePermissions = sortedKeys(writes)
+	p.Runners = sortedKeys(runners)
+	images := map[string]bool{}
+	for _, img := range wf.imageNodes() {
+		if strings.HasPrefix(img.Node.Value, "docker://") {
+			continue
+		}
+		for _, v := range img.Values {
+			if !strings.Contains(v, "${{") {
+				images[v] = true
+			}
+		}
+	}
+	p.Images = sortedKeys(images)
+	p.Risk = p.risk()
+	p.Fork = forkVerdict(wf)
+	return p
+}
+
+// countRef counts a `uses:` reference. Local actions live in the repository itself and are
+// not counted.
+func (p *WorkflowProfile) countRef(uses *yaml.Node) {
+	if uses == nil || uses.Kind != yaml.ScalarNode || strings.HasPrefix(uses.Value, "./") {
+		return
+	}
+	p.ActionRefs++
+	p.Actions = append(p.Actions, uses.Value)
+	if pinnedRef.MatchString(uses.Value) {
+		p.PinnedRefs++
+	}
+}
+
+// risk rates the workflow by crossing its triggers with its privileges. A workflow outsiders
+// can start is critical when it is privileged, and pull requests from forks on self-hosted
+// runners are high, since a fork can then run code on our infrastructure.
+func (p *WorkflowProfile) risk() string {
+	untrusted := false
+	for _, t := range p.Triggers {
+		untrusted = untrusted || untrustedTriggers[t]
+	}
+
+	switch {
+	case untrusted && p.privileged():
+		return SeverityCritical
+	case untrusted, p.SelfHosted && slices.Contains(p.Triggers, "pull_request"):
+		return SeverityHigh
+	case p.privileged():
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// workflowTriggers returns the events of an `on:` node, which is either an event name,
+// a list of them or a mapping of events to their filters
+func workflowTriggers(on *yaml.Node) []string {
+	on = resolveAlias(on)
+	if on == nil {
+		return nil
+	}
+
+	var triggers []string
+	switch on.Kind {
+	case yaml.ScalarNode:
+		triggers = append(triggers, on.Value)
+	case yaml.SequenceNode:
+		for _, n := range on.Content {
+			triggers = append(triggers, resolveAlias(n).Value)
+		}
+	case yaml.MappingNode:
+		for _, pair := range mappingPairs(on) {
+			triggers = append(triggers, pair.Key.Value)
+		}
+	}
+	return triggers
+}
+
+// triggeredBy reports whether one of events starts the workflow
+func triggeredBy(wf *WorkflowFile, events []string) bool {
+	for _, root := range wf.roots() {
+		for _, t := range workflowTriggers(mappingValue(root, "on")) {
+			if slices.Contains(events, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// triggerPaths returns the `paths:` filters of all events of an `on:` mapping
+func triggerPaths(on *yaml.Node) []string {
+	var paths []string
+	for _, pair := range mappingPairs(resolveAlias(on)) {
+		if filter := mappingValue(resolveAlias(pair.Value), "paths"); filter != nil {
+			for _, n := range filter.Content {
+				paths = append(paths, resolveAlias(n).Value)
+			}
+		}
+	}
+	return paths
+}
+
+// runnerLabels returns the labels of a `runs-on:` node, which is a label, a list of labels
+// or a mapping with a group and labels
+func runnerLabels(runsOn *yaml.Node) []string {
+	runsOn = resolveAlias(runsOn)
+	if runsOn == nil {
+		return nil
+	}
+
+	switch runsOn.Kind {
+	case yaml.ScalarNode:
+		return []string{runsOn.Value}
+	case yaml.SequenceNode:
+		var labels []string
+		for _, n := range runsOn.Content {
+			labels = append(labels, resolveAlias(n).Value)
+		}
+		return labels
+	case yaml.MappingNode:
+		labels := runnerLabels(mappingValue(runsOn, "labels"))
+		if group := mappingValue(runsOn, "group"); group != nil {
+			labels = append(labels, "group:"+group.Value)
+		}
+		return labels
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// --- Tests for profileWorkflow ---
+
+func TestProfileWorkflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected WorkflowProfile
+	}{
+		{
+			name: "pull_request_target with secrets",
+			content: `on:
+  pull_request_target:
+    types: [opened]
+  push:
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ secrets.NPM_TOKEN }} ${{ secrets.GITHUB_TOKEN }}
+`,
+			expected: WorkflowProfile{
+				Triggers: []string{"pull_request_target", "push"},
+				Secrets:  []string{"NPM_TOKEN"},
+				Runners:  []string{"ubuntu-latest"},
+				Risk:     SeverityCritical,
+			},
+		},
+		{
+			name: "pull requests on self-hosted runners",
+			content: `on: [pull_request]
+permissions: {}
+jobs:
+  test:
+    runs-on: [self-hosted, linux]
+`,
+			expected: WorkflowProfile{
+				Triggers:   []string{"pull_request"},
+				Runners:    []string{"linux", "self-hosted"},
+				SelfHosted: true,
+				Risk:       SeverityHigh,
+			},
+		},
+		{
+			name: "push with default token and inherited secrets",
+			content: `on: push
+jobs:
+  release:
+    uses: org/repo/.github/workflows/release.yml@main
+    secrets: inherit
+  publish:
+    runs-on: ubuntu-latest
+    permissions:
+      packages: write
+`,
+			expected: WorkflowProfile{
+				Triggers:         []string{"push"},
+				Secrets:          []string{"inherit"},
+				WritePermissions: []string{"packages"},
+				DefaultToken:     true,
+				Runners:          []string{"ubuntu-latest"},
+				ActionRefs:       1,
+				Actions:          []string{"org/repo/.github/workflows/release.yml@main"},
+				Risk:             SeverityMedium,
+			},
+		},
+		{
+			name: "self-hosted through the matrix",
+			content: `on: pull_request
+permissions: {}
+jobs:
+  test:
+    strategy:
+      matrix:
+        os: [ubuntu-latest, gpu-runner]
+    runs-on: ${{ matrix.os }}
+`,
+			expected: WorkflowProfile{
+				Triggers:   []string{"pull_request"},
+				Runners:    []string{"gpu-runner", "ubuntu-latest"},
+				SelfHosted: true,
+				Risk:       SeverityHigh,
+			},
+		},
+		{
+			name: "container images",
+			content: `on: push
+permissions: {}
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    container: node:20
+    services:
+      db:
+        image: postgres:${{ matrix.pg }}
+      cache: redis:7
+    strategy:
+      matrix:
+        pg: [15, 16]
+`,
+			expected: WorkflowProfile{
+				Triggers: []string{"push"},
+				Runners:  []string{"ubuntu-latest"},
+				Images:   []string{"node:20", "postgres:15", "postgres:16", "redis:7"},
+				Risk:     SeverityLow,
+			},
+		},
+		{
+			name: "read-only",
+			content: `on:
+  pull_request:
+    paths: ["services/api/**"]
+permissions:
+  contents: read
+jobs:
+  lint:
+    runs-on: ${{ matrix.os }}
+    steps:
+      - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683
+      - uses: ./.github/actions/lint
+      - uses: docker://alpine:3
+`,
+			expected: WorkflowProfile{
+				Triggers:   []string{"pull_request"},
+				Runners:    []string{"${{ matrix.os }}"},
+				Paths:      []string{"services/api/**"},
+				ActionRefs: 2,
+				PinnedRefs: 1,
+				Actions:    []string{"actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683", "docker://alpine:3"},
+				Risk:       SeverityLow,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := profileWorkflow(newWorkflowFile("wf.yml", []byte(tc.content)))
+			if p == nil {
+				t.Fatal("expected a profile, got nil")
+			}
+			// The fork verdict is covered by the tests of forkVerdict.
+			got := *p
+			got.Fork = nil
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("profile = %+v; want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestProfileWorkflow_NotAWorkflow(t *testing.T) {
+	if p := profileWorkflow(newWorkflowFile("wf.yml", []byte("- a\n- b\n"))); p != nil {
+		t.Errorf("expected nil profile, got %+v", p)
+	}
+}
+
+// --- Tests for triggeredBy ---
+
+func TestTriggeredBy(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"on: pull_request_target\njobs: {}\n", true},
+		{"on: [push, workflow_run]\njobs: {}\n", true},
+		{"on:\n  pull_request:\n    branches: [main]\njobs: {}\n", false},
+		{"jobs: {}\n", false},
+	}
+	for _, tc := range tests {
+		if got := triggeredBy(newWorkflowFile("wf.yml", []byte(tc.content)), []string{"pull_request_target", "workflow_run"}); got != tc.expected {
+			t.Errorf("triggeredBy(%q) = %v; want %v", tc.content, got, tc.expected)
+		}
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with Triggers ---
+
+func TestScanner_ScanBranchTriggers(t *testing.T) {
+	repo := fakeRepository{
+		name:  "org/api",
+		files: []string{"ci.yml", "label.yml"},
+		fileContents: map[string][]byte{
+			"root/.github/workflows/ci.yml":    []byte("on: push\njobs:\n  a:\n    steps:\n      - uses: actions/checkout@v4\n"),
+			"root/.github/workflows/label.yml": []byte("on: pull_request_target\njobs:\n  a:\n    steps:\n      - uses: actions/labeler@v5\n"),
+		},
+	}
+	// Workflows left out by the filter still count as present for required workflows.
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Triggers: []string{"pull_request_target"}, RequiredWorkflows: []RequiredWorkflow{{Name: "CI", File: "ci.yml"}}}
+
+	records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+	if len(records) != 1 || records[0].FilePath != "root/.github/workflows/label.yml" || !slices.Equal(records[0].Matches, []string{"actions/labeler@v5"}) {
+		t.Errorf("expected only the pull_request_target workflow, got %+v", records)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Weights of the signals of the risk score. The score of an issue is the base of its
+// severity multiplied by the weight of each signal, capped at 100.
+var (
+	severityBase      = map[string]float64{SeverityLow: 20, SeverityMedium: 40, SeverityHigh: 60, SeverityCritical: 80}
+	maintenanceWeight = map[string]float64{maintenanceStale: 1.2, maintenanceArchived: 1.3, maintenanceMissing: 1.5}
+	// triggerWeight rates the privileges of the workflow, from its profile
+	triggerWeight     = map[string]float64{SeverityMedium: 1.1, SeverityHigh: 1.25, SeverityCritical: 1.5}
+	criticalityWeight = map[string]float64{criticalityProd: 1.5, criticalityInternal: 1, criticalitySandbox: 0.5}
+)
+
+// Actions with fewer stars than these get fewer eyes on their changes
+const (
+	obscureStars = 100
+	nicheStars   = 1000
+)
+
+// maxRiskScore caps the risk score
+const maxRiskScore = 100
+
+// RiskModel scores mutable references and findings from their severity, the popularity and
+// upkeep of the action involved, the privileges of the workflow and the criticality of the
+// repository
+type RiskModel struct {
+	// Criticality maps globs of repository names to their tier
+	Criticality map[string]string
+	// MinScore drops issues scoring lower, when above zero
+	MinScore int
+	// SortByScore orders issues by score, highest first
+	SortByScore bool
+
+	lookup repoActivityFunc
+	now    time.Time
+	// cache holds the activity of each action repository, nil when it could not be read
+	cache map[string]*repoActivity
+}
+
+// NewRiskModel creates a model reading the activity of action repositories with lookup
+func NewRiskModel(criticality map[string]string, lookup repoActivityFunc, now time.Time) (*RiskModel, error) {
+	if err := checkCriticality(criticality, nil); err != nil {
+		return nil, err
+	}
+	return &RiskModel{Criticality: criticality, lookup: lookup, now: now, cache: map[string]*repoActivity{}}, nil
+}
+
+// activity returns the activity of the repository of an action, looking it up once
+func (m *RiskModel) activity(uses string) *repoActivity {
+	if uses == "" || !isRemoteAction(uses) {
+		return nil
+	}
+	name := actionName(uses)
+	if a, ok := m.cache[name]; ok {
+		return a
+	}
+	m.cache[name] = nil
+
+	owner, repo, ok := strings.Cut(name, "/")
+	if !ok {
+		return nil
+	}
+	a, err := m.lookup(owner, repo)
+	if err != nil {
+		logger.Warn("could not look up action repository", "action", name, "err", err)
+		return nil
+	}
+	m.cache[name] = &a
+	return &a
+}
+
+// score rates an issue of a workflow of a record. uses is the action involved, if any.
+func (m *RiskModel) score(ir *InventoryRecord, severity, uses string) int {
+	score := severityBase[severity]
+	if score == 0 {
+		score = severityBase[SeverityLow]
+	}
+	if a := m.activity(uses); a != nil {
+		score *= weightOr(maintenanceWeight, a.status(m.now))
+		switch {
+		case !a.Exists:
+		case a.Stars < obscureStars:
+			score *= 1.2
+		case a.Stars < nicheStars:
+			score *= 1.1
+		}
+	}
+	if ir.Profile != nil {
+		score *= weightOr(triggerWeight, ir.Profile.Risk)
+	}
+	score *= criticalityWeight[criticalityOf(m.Criticality, ir.Repository)]
+	return int(math.Min(math.Round(score), maxRiskScore))
+}
+
+// weightOr returns the weight of a value, or a neutral weight of 1 for other values
+func weightOr(weights map[string]float64, v string) float64 {
+	if w, ok := weights[v]; ok {
+		return w
+	}
+	return 1
+}
+
+// Rank scores the mutable references and findings of an inventory, then drops those below
+// the minimum score and sorts the rest as configured. Mutable references count as medium.
+// A nil model leaves the inventory as it is.
+func (m *RiskModel) Rank(inv *Inventory) {
+	if m == nil {
+		return
+	}
+	for _, ir := range inv.Records {
+		for i := range ir.Locations {
+			ir.Locations[i].Score = m.score(ir, SeverityMedium, ir.Locations[i].Value)
+		}
+		for i := range ir.Findings {
+			ir.Findings[i].Score = m.score(ir, ir.Findings[i].Severity, actionAt(ir, ir.Findings[i].Line))
+		}
+
+		if m.MinScore > 0 {
+			var locations []Match
+			for _, loc := range ir.Locations {
+				if loc.Score >= m.MinScore {
+					locations = append(locations, loc)
+				}
+			}
+			var findings []Finding
+			for _, f := range ir.Findings {
+				if f.Score >= m.MinScore {
+					findings = append(findings, f)
+				}
+			}
+			ir.Locations, ir.Findings = locations, findings
+			ir.Matches = matchValues(locations)
+		}
+
+		if m.SortByScore {
+			sort.SliceStable(ir.Locations, func(i, j int) bool { return ir.Locations[i].Score > ir.Locations[j].Score })
+			sort.SliceStable(ir.Findings, func(i, j int) bool { return ir.Findings[i].Score > ir.Findings[j].Score })
+		}
+	}
+	if m.SortByScore {
+		sort.SliceStable(inv.Records, func(i, j int) bool { return topScore(inv.Records[i]) > topScore(inv.Records[j]) })
+	}
+}
+
+// actionAt returns the action referenced on a line of the workflow of a record, if it is
+// one of its mutable references
+func actionAt(ir *InventoryRecord, line int) string {
+	for _, loc := range ir.Locations {
+		if line > 0 && loc.Line == line {
+			return loc.Value
+		}
+	}
+	return ""
+}
+
+// topScore returns the highest score of the issues of a record
+func topScore(ir *InventoryRecord) int {
+	top := 0
+	for _, loc := range ir.Locations {
+		top = max(top, loc.Score)
+	}
+	for _, f := range ir.Findings {
+		top = max(top, f.Score)
+	}
+	return top
+}
+
+// scored reports whether the issues of an inventory were rated by a risk model
+func (inv *Inventory) scored() bool {
+	for _, ir := range inv.Records {
+		if topScore(ir) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func riskFixture(t *testing.T, minScore int, sortByScore bool) *RiskModel {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	m, err := NewRiskModel(map[string]string{"payments*": criticalityProd, "sandbox-*": criticalitySandbox}, fakeActivity(map[string]repoActivity{
+		"actions/checkout": {Exists: true, PushedAt: now, Stars: 6000},
+		"tiny/tool":        {Exists: true, PushedAt: now.AddDate(-2, 0, 0), Stars: 12},
+	}), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.MinScore, m.SortByScore = minScore, sortByScore
+	return m
+}
+
+// --- Tests for RiskModel.score ---
+
+func TestRiskScore(t *testing.T) {
+	m := riskFixture(t, 0, false)
+	tests := []struct {
+		name     string
+		record   *InventoryRecord
+		severity string
+		uses     string
+		expected int
+	}{
+		{"severity only", &InventoryRecord{Repository: "web"}, SeverityHigh, "", 60},
+		{"popular maintained action", &InventoryRecord{Repository: "web"}, SeverityMedium, "actions/checkout@v4", 40},
+		{"stale obscure action", &InventoryRecord{Repository: "web"}, SeverityMedium, "tiny/tool@v1", 58},
+		{"privileged trigger", &InventoryRecord{Repository: "web", Profile: &WorkflowProfile{Risk: SeverityCritical}}, SeverityMedium, "", 60},
+		{"prod repository", &InventoryRecord{Repository: "payments-api"}, SeverityHigh, "", 90},
+		{"sandbox repository", &InventoryRecord{Repository: "sandbox-demo"}, SeverityHigh, "", 30},
+		{"capped", &InventoryRecord{Repository: "payments-api", Profile: &WorkflowProfile{Risk: SeverityCritical}}, SeverityCritical, "tiny/tool@v1", 100},
+		{"lookup failure is neutral", &InventoryRecord{Repository: "web"}, SeverityLow, "broken/repo@v1", 20},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := m.score(tc.record, tc.severity, tc.uses); got != tc.expected {
+				t.Errorf("score = %d; want %d", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for RiskModel.Rank ---
+
+func TestRiskRank(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{
+			Repository: "web",
+			Matches:    []string{"actions/checkout@v4"},
+			Locations:  []Match{{Value: "actions/checkout@v4", Line: 3}},
+			Findings:   []Finding{{Rule: ruleDeprecated, Severity: SeverityLow, Line: 3}},
+		},
+		{
+			Repository: "payments-api",
+			Matches:    []string{"actions/checkout@v4", "tiny/tool@v1"},
+			Locations:  []Match{{Value: "actions/checkout@v4", Line: 2}, {Value: "tiny/tool@v1", Line: 5}},
+		},
+	}}
+
+	riskFixture(t, 30, true).Rank(inv)
+
+	if inv.Records[0].Repository != "payments-api" {
+		t.Fatalf("expected the prod repository first, got %s", inv.Records[0].Repository)
+	}
+	prod, web := inv.Records[0], inv.Records[1]
+	if len(prod.Locations) != 2 || prod.Locations[0].Value != "tiny/tool@v1" || prod.Locations[0].Score != 86 {
+		t.Errorf("unexpected prod references %+v", prod.Locations)
+	}
+	if len(web.Locations) != 1 || web.Locations[0].Score != 40 || len(web.Matches) != 1 {
+		t.Errorf("unexpected web references %+v", web)
+	}
+	if len(web.Findings) != 0 {
+		t.Errorf("expected the low finding to be dropped, got %+v", web.Findings)
+	}
+	if !inv.scored() {
+		t.Error("expected the inventory to be scored")
+	}
+
+	var none *RiskModel
+	none.Rank(&Inventory{Records: []*InventoryRecord{{Repository: "web"}}})
+}
+
+// --- Tests for NewRiskModel ---
+
+func TestNewRiskModelRejectsUnknownTier(t *testing.T) {
+	lookup := func(owner, name string) (repoActivity, error) { return repoActivity{}, errors.New("unused") }
+	if _, err := NewRiskModel(map[string]string{"web": "critical"}, lookup, time.Now()); err == nil {
+		t.Error("expected an error for an unknown tier")
+	}
+	if _, err := NewRiskModel(map[string]string{"[web": criticalityProd}, lookup, time.Now()); err == nil {
+		t.Error("expected an error for a malformed glob")
+	}
+}
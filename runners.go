@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ruleRunnerLabel flags jobs targeting self-hosted runner labels the policy does not approve
+const ruleRunnerLabel = "unapproved-runner-label"
+
+// Kinds of runner labels in the runner inventory
+const (
+	runnerGitHubHosted = "github-hosted"
+	runnerSelfHosted   = "self-hosted"
+	runnerSensitive    = "sensitive"
+	runnerUnapproved   = "unapproved"
+	runnerUnresolved   = "unresolved"
+)
+
+// RunnersConfig is the policy of runner labels workflows may target. Patterns are globs of
+// labels, and groups are written group:<name>.
+type RunnersConfig struct {
+	// Allowed lists the self-hosted labels and groups jobs may target
+	Allowed []string `yaml:"allowed"`
+	// Sensitive lists the labels and groups of runners reaching sensitive networks or
+	// credentials, such as deployment runners
+	Sensitive []string `yaml:"sensitive"`
+}
+
+// matchesAny reports whether a label matches one of the glob patterns
+func matchesAny(patterns []string, label string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, label); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelfHosted reports whether a label names a self-hosted runner. Labels left as
+// expressions cannot be told.
+func isSelfHosted(label string) bool {
+	return !githubHostedRunner.MatchString(label) && !strings.Contains(label, "${{")
+}
+
+// kind classifies a runner label under the policy. Self-hosted labels are unapproved when
+// an allowlist is set and they are not on it.
+func (c RunnersConfig) kind(label string) string {
+	switch {
+	case strings.Contains(label, "${{"):
+		return runnerUnresolved
+	case !isSelfHosted(label):
+		return runnerGitHubHosted
+	case matchesAny(c.Sensitive, label):
+		return runnerSensitive
+	case len(c.Allowed) > 0 && !matchesAny(c.Allowed, label):
+		return runnerUnapproved
+	}
+	return runnerSelfHosted
+}
+
+// RunnerRule flags jobs targeting self-hosted labels missing from the allowlist of the
+// policy. A typo in a label, or a runner registered outside the approved pools, can hand
+// the job to a machine nobody vetted.
+type RunnerRule struct {
+	Policy RunnersConfig
+}
+
+func (r RunnerRule) ID() string {
+	return ruleRunnerLabel
+}
+
+// Check flags every label of every job, expanded over the job matrix, the policy does not
+// approve. Sensitive labels are approved.
+func (r RunnerRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		runsOn := mappingValue(job.Node, "runs-on")
+		seen := map[string]bool{}
+		for _, written := range runnerLabels(runsOn) {
+			for _, label := range expandMatrix(written, job.Context, job.Matrix) {
+				if seen[label] || r.Policy.kind(label) != runnerUnapproved {
+					continue
+				}
+				seen[label] = true
+				findings = append(findings, wf.finding(ruleRunnerLabel, SeverityMedium, runsOn, fmt.Sprintf(
+					"job %s runs on the self-hosted runner label %s, which runners.allowed of the configuration does not approve", job.ID, label)))
+			}
+		}
+	}
+	return findings
+}
+
+// runnerUse is a runner label of the inventory with the repositories targeting it
+type runnerUse struct {
+	Label        string
+	Kind         string
+	Repositories []string
+}
+
+// runnerInventory lists every runner label the profiled workflows of an inventory target,
+// by label
+func runnerInventory(inv *Inventory, policy RunnersConfig) []runnerUse {
+	repos := map[string]map[string]bool{}
+	for _, ir := range inv.Records {
+		if ir.Profile == nil {
+			continue
+		}
+		for _, label := range ir.Profile.Runners {
+			if repos[label] == nil {
+				repos[label] = map[string]bool{}
+			}
+			repos[label][ir.Repository] = true
+		}
+	}
+
+	uses := make([]runnerUse, 0, len(repos))
+	for label, set := range repos {
+		uses = append(uses, runnerUse{Label: label, Kind: policy.kind(label), Repositories: sortedKeys(set)})
+	}
+	sort.Slice(uses, func(i, j int) bool { return uses[i].Label < uses[j].Label })
+	return uses
+}
+
+// sensitiveRepository is a repository able to target sensitive runners
+type sensitiveRepository struct {
+	Repository string
+	Labels     []string
+}
+
+// sensitiveRepositories returns the repositories able to target sensitive runners, with the
+// sensitive labels they target
+func sensitiveRepositories(uses []runnerUse) []sensitiveRepository {
+	labels := map[string][]string{}
+	for _, u := range uses {
+		if u.Kind != runnerSensitive {
+			continue
+		}
+		for _, r := range u.Repositories {
+			labels[r] = append(labels[r], u.Label)
+		}
+	}
+
+	repos := make([]sensitiveRepository, 0, len(labels))
+	for r, l := range labels {
+		repos = append(repos, sensitiveRepository{Repository: r, Labels: l})
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Repository < repos[j].Repository })
+	return repos
+}
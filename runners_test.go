@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// --- Tests for RunnersConfig.kind ---
+
+func TestRunnersConfigKind(t *testing.T) {
+	policy := RunnersConfig{Allowed: []string{"self-hosted", "linux", "build-*", "group:deploy"}, Sensitive: []string{"group:deploy", "prod-*"}}
+
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"ubuntu-latest", runnerGitHubHosted},
+		{"windows-2022", runnerGitHubHosted},
+		{"self-hosted", runnerSelfHosted},
+		{"build-large", runnerSelfHosted},
+		{"group:deploy", runnerSensitive},
+		{"prod-eu", runnerSensitive},
+		{"bulid-large", runnerUnapproved},
+		{"group:other", runnerUnapproved},
+		{"${{ inputs.runner }}", runnerUnresolved},
+	}
+
+	for _, tc := range tests {
+		if got := policy.kind(tc.label); got != tc.expected {
+			t.Errorf("kind(%q) = %s; want %s", tc.label, got, tc.expected)
+		}
+	}
+
+	// Without an allowlist, no self-hosted label is unapproved.
+	if got := (RunnersConfig{}).kind("anything"); got != runnerSelfHosted {
+		t.Errorf("kind without a policy = %s; want %s", got, runnerSelfHosted)
+	}
+}
+
+// --- Tests for RunnerRule.Check ---
+
+func TestRunnerRule_Check(t *testing.T) {
+	content := `jobs:
+  build:
+    runs-on: [self-hosted, linux]
+  typo:
+    runs-on: [self-hosted, lnux]
+  grouped:
+    runs-on:
+      group: staging
+      labels: [self-hosted]
+  matrix:
+    strategy:
+      matrix:
+        runner: [ubuntu-latest, gpu-box, gpu-box]
+    runs-on: ${{ matrix.runner }}
+  hosted:
+    runs-on: ubuntu-latest
+`
+	rule := RunnerRule{Policy: RunnersConfig{Allowed: []string{"self-hosted", "linux"}}}
+	findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	var got []string
+	for _, f := range findings {
+		if f.Rule != ruleRunnerLabel || f.Severity != SeverityMedium {
+			t.Errorf("unexpected finding %+v", f)
+		}
+		got = append(got, fmt.Sprint(f.Line))
+	}
+	// lnux, group:staging, and gpu-box once despite the duplicate matrix value
+	if fmt.Sprint(got) != "[5 8 14]" {
+		t.Errorf("expected findings at lines 5, 8 and 14, got %v: %+v", got, findings)
+	}
+}
+
+// --- Tests for runnerInventory ---
+
+func TestRunnerInventory(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{Repository: "app", Profile: &WorkflowProfile{Runners: []string{"ubuntu-latest", "group:deploy"}}},
+		{Repository: "web", Profile: &WorkflowProfile{Runners: []string{"ubuntu-latest", "rogue"}}},
+		{Repository: "web", Profile: &WorkflowProfile{Runners: []string{"group:deploy"}}},
+		{Repository: "docs"},
+	}}
+	uses := runnerInventory(inv, RunnersConfig{Allowed: []string{"group:*"}, Sensitive: []string{"group:deploy"}})
+
+	expected := "[{group:deploy sensitive [app web]} {rogue unapproved [web]} {ubuntu-latest github-hosted [app web]}]"
+	if got := fmt.Sprint(uses); got != expected {
+		t.Errorf("runnerInventory = %s; want %s", got, expected)
+	}
+
+	sensitive := sensitiveRepositories(uses)
+	if got := fmt.Sprint(sensitive); got != "[{app [group:deploy]} {web [group:deploy]}]" {
+		t.Errorf("unexpected sensitive repositories %s", got)
+	}
+}
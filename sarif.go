@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ruleMutableReference identifies mutable references in SARIF results, which carry no rule
+// of their own in the inventory
+const ruleMutableReference = "mutable-reference"
+
+// sarifRuleDescriptions describe the rules in SARIF output. Rules not listed, such as those
+// of actionlint, are described by their ID.
+var sarifRuleDescriptions = map[string]string{
+	ruleMutableReference:        "Action or reusable workflow referenced by a mutable tag or branch instead of a commit SHA",
+	ruleDeprecated:              "Deprecated workflow syntax",
+	ruleExcessivePermissions:    "Job granted more token permissions than it needs",
+	ruleMissingPermissions:      "Job needing token permissions it is not granted",
+	ruleUndeclaredPermissions:   "Job running with the default token permissions of the repository",
+	ruleUnprotectedEnvironment:  "Deployment to an environment without required reviewers or wait timer",
+	ruleStaleImageDigest:        "Container image pinned to a digest no tag points to anymore",
+	ruleCompositeInjection:      "Input of a composite action flowing into a shell script",
+	ruleNamespaceOwnership:      "Action whose owner namespace can be taken over",
+	ruleSuspiciousSymlink:       "Workflow file that is a symlink pointing outside of the repository",
+	ruleWorkflowCodeowners:      "Workflow file not owned through CODEOWNERS",
+	ruleMissingRequiredWorkflow: "Workflow required by the policy missing from the repository",
+	ruleGoldenDrift:             "Workflow differing from the golden template it was copied from",
+	ruleRunnerLabel:             "Job targeting a self-hosted runner label the policy does not approve",
+	ruleDeployConcurrency:       "Deployment job outside of any concurrency group",
+	ruleCancelInProgress:        "Workflow not cancelling superseded runs as the policy requires",
+	ruleUnpinnedInclude:         "Pipeline configuration included from a source that can change",
+	ruleUnpinnedImage:           "Pipeline image not pinned to a digest",
+	ruleUnpinnedPipe:            "Bitbucket pipe referenced by a mutable tag",
+	ruleUnpinnedOrb:             "CircleCI orb not pinned to an exact version",
+	ruleUnpinnedResource:        "Azure Pipelines repository resource not pinned to a commit",
+	ruleUnversionedTask:         "Azure Pipelines task without a version",
+	ruleUnpinnedLibrary:         "Jenkins shared library not pinned to a commit",
+	rulePrivilegedStep:          "Pipeline step running a privileged container",
+	ruleUnpinnedPlugin:          "Buildkite plugin not pinned to a commit",
+	ruleUnpinnedBundle:          "Tekton bundle not pinned to a digest",
+	ruleClusterTask:             "Tekton cluster task resolved by name",
+	ruleMissingTimeout:          "Job or long-running step without a timeout",
+	ruleSensitiveArtifact:       "Artifact uploading files likely to hold credentials",
+	ruleArtifactRetention:       "Artifact kept longer than the policy allows",
+	ruleUnverifiedDownload:      "Release asset or binary downloaded without verifying its checksum or signature",
+	ruleUnpinnedPackage:         "Build tooling installed at a floating version",
+	ruleHardcodedSecret:         "Credential written into a workflow",
+	ruleBannedAction:            "Action banned by the action policy",
+}
+
+// sarifLevels map severities to SARIF result levels, and sarifSecuritySeverities to the
+// scores GitHub code scanning ranks security alerts by
+var (
+	sarifLevels             = map[string]string{SeverityLow: "note", SeverityMedium: "warning", SeverityHigh: "error", SeverityCritical: "error"}
+	sarifSecuritySeverities = map[string]string{SeverityLow: "3.0", SeverityMedium: "5.5", SeverityHigh: "8.0", SeverityCritical: "9.5"}
+)
+
+// SeverityMapping overrides the levels severities map to in the sinks with a taxonomy of
+// their own. Severities left out keep their default level.
+type SeverityMapping struct {
+	// SARIF maps severities to SARIF result levels: none, note, warning or error
+	SARIF map[string]string `yaml:"sarif"`
+	// SecuritySeverity maps severities to the scores, from 0.0 to 10.0, GitHub code scanning
+	// ranks security alerts by: critical from 9.0, high from 7.0, medium from 4.0
+	SecuritySeverity map[string]string `yaml:"security_severity"`
+}
+
+// sarifLevel returns the SARIF level of a severity
+func (m SeverityMapping) sarifLevel(severity string) string {
+	if level, ok := m.SARIF[severity]; ok {
+		return level
+	}
+	return sarifLevels[severity]
+}
+
+// securitySeverity returns the code scanning score of a severity
+func (m SeverityMapping) securitySeverity(severity string) string {
+	if score, ok := m.SecuritySeverity[severity]; ok {
+		return score
+	}
+	return sarifSecuritySeverities[severity]
+}
+
+// checkSeverityMapping validates the overrides of the severities section of the
+// configuration
+func checkSeverityMapping(m SeverityMapping) error {
+	for severity, level := range m.SARIF {
+		if _, ok := severityRank[severity]; !ok {
+			return fmt.Errorf("severities.sarif: unknown severity %q. Valid severities are low, medium, high, critical", severity)
+		}
+		if !slices.Contains([]string{"none", "note", "warning", "error"}, level) {
+			return fmt.Errorf("severities.sarif of %s: unknown level %q. Valid levels are none, note, warning, error", severity, level)
+		}
+	}
+	for severity, score := range m.SecuritySeverity {
+		if _, ok := severityRank[severity]; !ok {
+			return fmt.Errorf("severities.security_severity: unknown severity %q. Valid severities are low, medium, high, critical", severity)
+		}
+		if f, err := strconv.ParseFloat(score, 64); err != nil || f < 0 || f > 10 {
+			return fmt.Errorf("severities.security_severity of %s: %q is not a score from 0.0 to 10.0", severity, score)
+		}
+	}
+	return nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfig   `json:"defaultConfiguration"`
+	Properties           sarifRuleProperty `json:"properties"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperty struct {
+	Tags             []string `json:"tags"`
+	SecuritySeverity string   `json:"security-severity"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// Properties carry the commit that brought the issue in, set with --blame, and the
+	// submodule holding the file, set with --recurse-submodules
+	Properties *sarifResultProperties `json:"properties,omitempty"`
+}
+
+type sarifResultProperties struct {
+	Blame     *lineAuthor   `json:"blame,omitempty"`
+	Submodule *SubmoduleRef `json:"submodule,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifURI returns the path of the file of a record relative to the root of its repository,
+// which code scanning resolves against the checkout
+func sarifURI(ir *InventoryRecord) string {
+	return relativePath(ir.repoRoot(), ir.FilePath)
+}
+
+// sarifLocations returns the location of a line and column of the file of a record. Line 0
+// is unknown, leaving the file alone.
+func sarifLocations(ir *InventoryRecord, line, column int) []sarifLocation {
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: sarifURI(ir), URIBaseID: "%SRCROOT%"}}}
+	if line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: column}
+	}
+	return []sarifLocation{loc}
+}
+
+// sarifReport converts an inventory into a SARIF 2.1.0 log, with severities mapped to levels
+// by the given mapping. Mutable references are results of the mutable-reference rule at
+// medium severity, as elsewhere. A rule takes the highest severity of its results.
+func sarifReport(inv *Inventory, levels SeverityMapping) sarifLog {
+	results := []sarifResult{}
+	ruleSeverity := map[string]string{}
+	add := func(ir *InventoryRecord, rule, severity, message string, line, column int, blame *lineAuthor) {
+		result := sarifResult{RuleID: rule, Level: levels.sarifLevel(severity), Message: sarifMessage{Text: message}, Locations: sarifLocations(ir, line, column)}
+		if blame != nil || ir.Submodule != nil {
+			result.Properties = &sarifResultProperties{Blame: blame, Submodule: ir.Submodule}
+		}
+		results = append(results, result)
+		if severityRank[severity] > severityRank[ruleSeverity[rule]] {
+			ruleSeverity[rule] = severity
+		}
+	}
+
+	for _, ir := range inv.Records {
+		for _, m := range ir.Locations {
+			add(ir, ruleMutableReference, SeverityMedium, fmt.Sprintf("%s is a mutable reference. Pin it to a commit SHA", m.Value), m.Line, m.Column, m.Blame)
+		}
+		for _, f := range ir.Findings {
+			add(ir, f.Rule, f.Severity, f.Message, f.Line, f.Column, f.Blame)
+		}
+	}
+
+	ids := make([]string, 0, len(ruleSeverity))
+	for id := range ruleSeverity {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	index := map[string]int{}
+	rules := make([]sarifRule, 0, len(ids))
+	for i, id := range ids {
+		index[id] = i
+		description, ok := sarifRuleDescriptions[id]
+		if !ok {
+			description = strings.TrimPrefix(id, actionlintPrefix)
+		}
+		rules = append(rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{Text: description},
+			DefaultConfiguration: sarifRuleConfig{Level: levels.sarifLevel(ruleSeverity[id])},
+			Properties:           sarifRuleProperty{Tags: []string{"security"}, SecuritySeverity: levels.securitySeverity(ruleSeverity[id])},
+		})
+	}
+
+	for i := range results {
+		results[i].RuleIndex = index[results[i].RuleID]
+	}
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "scharf", InformationURI: "https://github.com/cybrota/scharf", Rules: rules}}, Results: results}
+	return sarifLog{Schema: "https://json.schemastore.org/sarif-2.1.0.json", Version: "2.1.0", Runs: []sarifRun{run}}
+}
+
+// writeSARIFReport renders the inventory as SARIF, for upload to GitHub code scanning
+func writeSARIFReport(w io.Writer, inv *Inventory, levels SeverityMapping) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sarifReport(inv, levels)); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// --- Tests for sarifReport ---
+
+func TestSARIFReport(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{
+			Repository: "app",
+			FilePath:   "/workspace/app/.github/workflows/ci.yml",
+			Locations:  []Match{{Value: "actions/checkout@v4", Line: 7, Column: 15}},
+			Findings: []Finding{
+				{Rule: ruleExcessivePermissions, Severity: SeverityHigh, Message: "job build is granted contents: write", Line: 3, Column: 5},
+				{Rule: ruleExcessivePermissions, Severity: SeverityLow, Message: "job lint is granted checks: write", Line: 12, Column: 5},
+				{Rule: actionlintPrefix + "expression", Severity: SeverityMedium, Message: "undefined property"},
+			},
+		},
+		{Repository: "owner/repo", FilePath: ".github/workflows/release.yml", Locations: []Match{{Value: "owner/action@main", Line: 2, Column: 9}}},
+	}}
+
+	log := sarifReport(inv, SeverityMapping{})
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	run := log.Runs[0]
+
+	rules := map[string]sarifRule{}
+	for _, r := range run.Tool.Driver.Rules {
+		rules[r.ID] = r
+	}
+	if len(rules) != 3 {
+		t.Errorf("expected 3 rules, got %+v", run.Tool.Driver.Rules)
+	}
+	// A rule takes the highest severity among its results.
+	if r := rules[ruleExcessivePermissions]; r.DefaultConfiguration.Level != "error" || r.Properties.SecuritySeverity != "8.0" {
+		t.Errorf("unexpected rule %+v", r)
+	}
+	if r := rules[actionlintPrefix+"expression"]; r.ShortDescription.Text != "expression" {
+		t.Errorf("unexpected actionlint rule %+v", r)
+	}
+
+	if len(run.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(run.Results))
+	}
+	for _, r := range run.Results {
+		if run.Tool.Driver.Rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("result of %s points to rule %d", r.RuleID, r.RuleIndex)
+		}
+	}
+	first := run.Results[0]
+	loc := first.Locations[0].PhysicalLocation
+	if first.RuleID != ruleMutableReference || first.Level != "warning" || loc.ArtifactLocation.URI != ".github/workflows/ci.yml" || loc.Region.StartLine != 7 || loc.Region.StartColumn != 15 {
+		t.Errorf("unexpected mutable reference result %+v at %+v", first, loc)
+	}
+	if low := run.Results[2]; low.Level != "note" {
+		t.Errorf("expected low findings to be notes, got %+v", low)
+	}
+	if unknown := run.Results[3].Locations[0].PhysicalLocation; unknown.Region != nil {
+		t.Errorf("expected no region without a line, got %+v", unknown.Region)
+	}
+	if remote := run.Results[4].Locations[0].PhysicalLocation; remote.ArtifactLocation.URI != ".github/workflows/release.yml" {
+		t.Errorf("unexpected URI of a remote file %q", remote.ArtifactLocation.URI)
+	}
+}
+
+func TestWriteSARIFReport_Empty(t *testing.T) {
+	var b bytes.Buffer
+	CheckIfError(writeSARIFReport(&b, &Inventory{}, SeverityMapping{}))
+
+	var decoded map[string]any
+	CheckIfError(json.Unmarshal(b.Bytes(), &decoded))
+	results := decoded["runs"].([]any)[0].(map[string]any)["results"]
+	if results == nil || len(results.([]any)) != 0 {
+		t.Errorf("expected an empty results array, got:\n%s", b.String())
+	}
+}
+
+func TestSARIFReport_SeverityMapping(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{{
+		FilePath:  ".github/workflows/ci.yml",
+		Locations: []Match{{Value: "actions/checkout@v4", Line: 7, Column: 15}},
+		Findings:  []Finding{{Rule: ruleExcessivePermissions, Severity: SeverityHigh, Message: "job build is granted contents: write", Line: 3}},
+	}}}
+	levels := SeverityMapping{
+		SARIF:            map[string]string{SeverityMedium: "error"},
+		SecuritySeverity: map[string]string{SeverityHigh: "9.1"},
+	}
+
+	run := sarifReport(inv, levels).Runs[0]
+	if mutable := run.Results[0]; mutable.Level != "error" {
+		t.Errorf("expected medium severity to map to error, got %+v", mutable)
+	}
+	if high := run.Results[1]; high.Level != "error" {
+		t.Errorf("expected the default level of unmapped severities, got %+v", high)
+	}
+	for _, r := range run.Tool.Driver.Rules {
+		expected := map[string]string{ruleMutableReference: "5.5", ruleExcessivePermissions: "9.1"}[r.ID]
+		if r.Properties.SecuritySeverity != expected {
+			t.Errorf("rule %s: security severity %s, expected %s", r.ID, r.Properties.SecuritySeverity, expected)
+		}
+	}
+}
+
+// --- Tests for checkSeverityMapping ---
+
+func TestCheckSeverityMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping SeverityMapping
+		wantErr bool
+	}{
+		{name: "empty", mapping: SeverityMapping{}},
+		{name: "valid", mapping: SeverityMapping{SARIF: map[string]string{SeverityLow: "none"}, SecuritySeverity: map[string]string{SeverityCritical: "10"}}},
+		{name: "unknown severity", mapping: SeverityMapping{SARIF: map[string]string{"blocker": "error"}}, wantErr: true},
+		{name: "unknown level", mapping: SeverityMapping{SARIF: map[string]string{SeverityHigh: "fatal"}}, wantErr: true},
+		{name: "score out of range", mapping: SeverityMapping{SecuritySeverity: map[string]string{SeverityHigh: "11"}}, wantErr: true},
+		{name: "score not a number", mapping: SeverityMapping{SecuritySeverity: map[string]string{SeverityHigh: "high"}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkSeverityMapping(tc.mapping); (err != nil) != tc.wantErr {
+				t.Errorf("checkSeverityMapping error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
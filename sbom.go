@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// cycloneDXBOM is the subset of a CycloneDX 1.5 document the SBOM of CI dependencies needs
+type cycloneDXBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    cycloneDXMetadata    `json:"metadata"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXTool `json:"components"`
+	} `json:"tools"`
+}
+
+type cycloneDXTool struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type               string               `json:"type"`
+	BOMRef             string               `json:"bom-ref"`
+	Group              string               `json:"group,omitempty"`
+	Name               string               `json:"name"`
+	Version            string               `json:"version,omitempty"`
+	PURL               string               `json:"purl,omitempty"`
+	Licenses           []cycloneDXLicense   `json:"licenses,omitempty"`
+	ExternalReferences []cycloneDXReference `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty  `json:"properties,omitempty"`
+}
+
+// cycloneDXLicense names a license by SPDX identifier, or by name when it has none
+type cycloneDXLicense struct {
+	License struct {
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	} `json:"license"`
+}
+
+type cycloneDXReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// repoLicense is the license GitHub detected for a repository: its SPDX identifier, or
+// NOASSERTION when GitHub could not tell, and its name
+type repoLicense struct {
+	SPDX string
+	Name string
+}
+
+// licenseFunc looks up the license of a repository. It returns nil for repositories
+// without a license.
+type licenseFunc func(owner, name string) (*repoLicense, error)
+
+// lookupLicense reads the license of a repository through the API
+func lookupLicense(owner, name string) (*repoLicense, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/%s/license", apiURL, owner, name))
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http: looking up the license of %s/%s returned %s", owner, name, resp.Status)
+	}
+
+	var body struct {
+		License struct {
+			SPDX string `json:"spdx_id"`
+			Name string `json:"name"`
+		} `json:"license"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return &repoLicense{SPDX: body.License.SPDX, Name: body.License.Name}, nil
+}
+
+// sbomComponents lists each action, reusable workflow and container image version the
+// profiled workflows of an inventory reference, once, with where it comes from. Actions get
+// the license of their repository and, when resolve can tell, the commit their ref points to.
+// Lookup failures leave those out rather than failing the SBOM.
+func sbomComponents(inv *Inventory, license licenseFunc, resolve Resolver) []cycloneDXComponent {
+	refs, workflows := map[string]bool{}, map[string]map[string]bool{}
+	use := func(ref, file string) {
+		if !refs[ref] {
+			refs[ref], workflows[ref] = true, map[string]bool{}
+		}
+		workflows[ref][file] = true
+	}
+	for _, ir := range inv.Records {
+		if ir.Profile == nil {
+			continue
+		}
+		for _, uses := range ir.Profile.Actions {
+			if !strings.Contains(uses, "${{") {
+				use(uses, ir.FilePath)
+			}
+		}
+		for _, image := range ir.Profile.Images {
+			use("docker://"+image, ir.FilePath)
+		}
+	}
+
+	// References written differently, such as alpine and docker.io/library/alpine, are
+	// the same component.
+	licenses := map[string]*repoLicense{}
+	byRef, files := map[string]*cycloneDXComponent{}, map[string]map[string]bool{}
+	for _, ref := range sortedKeys(refs) {
+		var c *cycloneDXComponent
+		if strings.HasPrefix(ref, "docker://") {
+			c = imageComponent(ref)
+		} else {
+			c = actionComponent(ref, licenses, license, resolve)
+		}
+		if c == nil {
+			continue
+		}
+		if byRef[c.BOMRef] == nil {
+			byRef[c.BOMRef], files[c.BOMRef] = c, map[string]bool{}
+		}
+		for file := range workflows[ref] {
+			files[c.BOMRef][file] = true
+		}
+	}
+
+	components := []cycloneDXComponent{}
+	for _, key := range slices.Sorted(maps.Keys(byRef)) {
+		c := byRef[key]
+		for _, file := range sortedKeys(files[key]) {
+			c.Properties = append(c.Properties, cycloneDXProperty{Name: "scharf:workflow", Value: file})
+		}
+		components = append(components, *c)
+	}
+	return components
+}
+
+// actionComponent describes an action or reusable workflow reference such as
+// owner/repo/path@ref. licenses caches the licenses looked up per repository.
+func actionComponent(uses string, licenses map[string]*repoLicense, license licenseFunc, resolve Resolver) *cycloneDXComponent {
+	action, ref, ok := strings.Cut(uses, "@")
+	parts := strings.SplitN(action, "/", 3)
+	if !ok || len(parts) < 2 {
+		return nil
+	}
+	owner, name := parts[0], parts[1]
+	purl := fmt.Sprintf("pkg:github/%s/%s@%s", strings.ToLower(owner), strings.ToLower(name), url.PathEscape(ref))
+	if len(parts) == 3 {
+		purl += "#" + parts[2]
+	}
+
+	c := &cycloneDXComponent{
+		Type:               "application",
+		BOMRef:             uses,
+		Group:              owner,
+		Name:               strings.TrimPrefix(action, owner+"/"),
+		Version:            ref,
+		PURL:               purl,
+		ExternalReferences: []cycloneDXReference{{Type: "vcs", URL: fmt.Sprintf("%s/%s/%s", serverURL, owner, name)}},
+	}
+
+	key := strings.ToLower(owner + "/" + name)
+	if _, ok := licenses[key]; !ok {
+		l, err := license(owner, name)
+		if err != nil {
+			logger.Warn("could not look up the license of action", "action", key, "err", err)
+		}
+		licenses[key] = l
+	}
+	if l := licenses[key]; l != nil {
+		var choice cycloneDXLicense
+		if l.SPDX != "" && l.SPDX != "NOASSERTION" {
+			choice.License.ID = l.SPDX
+		} else {
+			choice.License.Name = l.Name
+		}
+		c.Licenses = []cycloneDXLicense{choice}
+	}
+
+	pinned := pinnedRef.MatchString(uses)
+	commit := ref
+	if !pinned {
+		sha, err := resolve.resolve(owner + "/" + name + "@" + ref)
+		if err != nil {
+			logger.Debug("could not resolve reference to a commit", "ref", uses, "err", err)
+			commit = ""
+		} else {
+			commit = sha
+		}
+	}
+	c.Properties = append(c.Properties, cycloneDXProperty{Name: "scharf:pinned", Value: fmt.Sprint(pinned)})
+	if commit != "" {
+		c.Properties = append(c.Properties, cycloneDXProperty{Name: "scharf:commit", Value: commit})
+	}
+	return c
+}
+
+// imageComponent describes a container image reference. Registries do not tell licenses,
+// so images only get their provenance.
+func imageComponent(image string) *cycloneDXComponent {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return nil
+	}
+	version := ref.Digest
+	if version == "" {
+		version = ref.Tag
+	}
+	purl := "pkg:docker/" + ref.Repository
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	if ref.Registry != dockerHub {
+		purl += "?repository_url=" + url.QueryEscape(ref.Registry)
+	}
+
+	c := &cycloneDXComponent{
+		Type:               "container",
+		BOMRef:             ref.String(),
+		Group:              ref.Registry,
+		Name:               ref.Repository,
+		Version:            version,
+		PURL:               purl,
+		ExternalReferences: []cycloneDXReference{{Type: "distribution", URL: ref.Registry + "/" + ref.Repository}},
+		Properties:         []cycloneDXProperty{{Name: "scharf:pinned", Value: fmt.Sprint(ref.Digest != "")}},
+	}
+	if ref.Tag != "" && ref.Digest != "" {
+		c.Properties = append(c.Properties, cycloneDXProperty{Name: "scharf:tag", Value: ref.Tag})
+	}
+	return c
+}
+
+// writeCycloneDX renders the CI dependencies of the inventory as a CycloneDX SBOM
+func writeCycloneDX(w io.Writer, inv *Inventory) error {
+	return renderCycloneDX(w, inv, lookupLicense, newCachingResolver(SHAResolver{}), time.Now())
+}
+
+// renderCycloneDX writes the CycloneDX SBOM of the actions and images of the inventory
+func renderCycloneDX(w io.Writer, inv *Inventory, license licenseFunc, resolve Resolver, now time.Time) error {
+	bom := cycloneDXBOM{BOMFormat: "CycloneDX", SpecVersion: "1.5", Version: 1, Components: sbomComponents(inv, license, resolve)}
+	bom.Metadata.Timestamp = now.UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cycloneDXTool{{Type: "application", Name: "scharf"}}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(bom); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
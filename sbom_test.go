@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeLicenses(licenses map[string]*repoLicense) licenseFunc {
+	return func(owner, name string) (*repoLicense, error) {
+		if owner == "broken" {
+			return nil, errors.New("boom")
+		}
+		return licenses[owner+"/"+name], nil
+	}
+}
+
+// --- Tests for sbomComponents ---
+
+func TestSBOMComponents(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{
+		{FilePath: "ci.yml", Profile: &WorkflowProfile{
+			Actions: []string{"actions/checkout@" + lockedSHA, "actions/checkout@v4", "docker://alpine:3", "broken/tool@main", "${{ matrix.action }}"},
+			Images:  []string{"docker.io/library/alpine:3", "ghcr.io/acme/db:16@sha256:abc"},
+		}},
+		{FilePath: "release.yml", Profile: &WorkflowProfile{Actions: []string{"actions/checkout@v4", "acme/workflows/.github/workflows/release.yml@v2"}}},
+		{FilePath: "no-profile.yml"},
+	}}
+	licenses := fakeLicenses(map[string]*repoLicense{
+		"actions/checkout": {SPDX: "MIT", Name: "MIT License"},
+		"acme/workflows":   {SPDX: "NOASSERTION", Name: "Other"},
+	})
+	resolver := fakeResolver{"actions/checkout@v4": movedSHA, "acme/workflows@v2": lockedSHA}
+
+	components := sbomComponents(inv, licenses, resolver)
+	byRef := map[string]cycloneDXComponent{}
+	for _, c := range components {
+		byRef[c.BOMRef] = c
+	}
+	if len(components) != 6 || len(byRef) != 6 {
+		t.Fatalf("expected 6 distinct components, got %+v", components)
+	}
+
+	property := func(c cycloneDXComponent, name string) string {
+		var values []string
+		for _, p := range c.Properties {
+			if p.Name == name {
+				values = append(values, p.Value)
+			}
+		}
+		return strings.Join(values, ",")
+	}
+	license := func(c cycloneDXComponent) string {
+		if len(c.Licenses) == 0 {
+			return ""
+		}
+		return c.Licenses[0].License.ID + c.Licenses[0].License.Name
+	}
+
+	tests := []struct {
+		ref       string
+		purl      string
+		license   string
+		pinned    string
+		commit    string
+		workflows string
+	}{
+		{"actions/checkout@v4", "pkg:github/actions/checkout@v4", "MIT", "false", movedSHA, "ci.yml,release.yml"},
+		{"actions/checkout@" + lockedSHA, "pkg:github/actions/checkout@" + lockedSHA, "MIT", "true", lockedSHA, "ci.yml"},
+		{"acme/workflows/.github/workflows/release.yml@v2", "pkg:github/acme/workflows@v2#.github/workflows/release.yml", "Other", "false", lockedSHA, "release.yml"},
+		{"broken/tool@main", "pkg:github/broken/tool@main", "", "false", "", "ci.yml"},
+		{"docker.io/library/alpine:3", "pkg:docker/library/alpine@3", "", "false", "", "ci.yml"},
+		{"ghcr.io/acme/db:16@sha256:abc", "pkg:docker/acme/db@sha256:abc?repository_url=ghcr.io", "", "true", "", "ci.yml"},
+	}
+	for _, tc := range tests {
+		c, ok := byRef[tc.ref]
+		if !ok {
+			t.Errorf("missing component %s", tc.ref)
+			continue
+		}
+		if c.PURL != tc.purl || license(c) != tc.license || property(c, "scharf:pinned") != tc.pinned || property(c, "scharf:commit") != tc.commit || property(c, "scharf:workflow") != tc.workflows {
+			t.Errorf("%s: got purl %s, license %q, pinned %s, commit %q, workflows %s", tc.ref, c.PURL, license(c), property(c, "scharf:pinned"), property(c, "scharf:commit"), property(c, "scharf:workflow"))
+		}
+	}
+	if refs := byRef["actions/checkout@v4"].ExternalReferences; len(refs) != 1 || refs[0].URL != "https://github.com/actions/checkout" {
+		t.Errorf("expected the source repository as provenance, got %+v", refs)
+	}
+}
+
+// --- Tests for renderCycloneDX ---
+
+func TestRenderCycloneDX(t *testing.T) {
+	var b bytes.Buffer
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	CheckIfError(renderCycloneDX(&b, &Inventory{}, fakeLicenses(nil), fakeResolver{}, now))
+
+	var bom map[string]any
+	CheckIfError(json.Unmarshal(b.Bytes(), &bom))
+	if bom["bomFormat"] != "CycloneDX" || bom["specVersion"] != "1.5" {
+		t.Errorf("expected a CycloneDX 1.5 document, got %s", b.String())
+	}
+	if components, ok := bom["components"].([]any); !ok || len(components) != 0 {
+		t.Errorf("expected an empty list of components, got %v", bom["components"])
+	}
+	if !strings.Contains(b.String(), `"timestamp": "2026-06-01T00:00:00Z"`) {
+		t.Errorf("expected the timestamp of the SBOM, got %s", b.String())
+	}
+}
+
+// --- Tests for lookupLicense ---
+
+func TestLookupLicense(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected *repoLicense
+		wantErr  bool
+	}{
+		{name: "detected", status: http.StatusOK, body: `{"license": {"spdx_id": "Apache-2.0", "name": "Apache License 2.0"}}`, expected: &repoLicense{SPDX: "Apache-2.0", Name: "Apache License 2.0"}},
+		{name: "no license", status: http.StatusNotFound, body: `{"message": "Not Found"}`},
+		{name: "server error", status: http.StatusBadGateway, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Path != "/repos/actions/checkout/license" {
+					t.Errorf("unexpected request to %s", req.URL)
+				}
+				return &http.Response{StatusCode: tc.status, Status: http.StatusText(tc.status), Body: io.NopCloser(strings.NewReader(tc.body)), Header: make(http.Header)}, nil
+			})
+			withHTTPClientTransport(rt, func() {
+				l, err := lookupLicense("actions", "checkout")
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("lookupLicense error = %v, wantErr %v", err, tc.wantErr)
+				}
+				if (l == nil) != (tc.expected == nil) || (l != nil && *l != *tc.expected) {
+					t.Errorf("lookupLicense = %+v, want %+v", l, tc.expected)
+				}
+			})
+		})
+	}
+}
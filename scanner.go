@@ -3,18 +3,24 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // shouldIncludeDir returns false if the file should be ignored.
 func shouldIncludeDir(fileName string) bool {
-	// List files you want to exclude.
+	// List files you want to exclude. Keys are lower-case as names are compared
+	// case-insensitively to behave the same on Windows and macOS file systems.
 	ignoredFiles := map[string]bool{
-		".DS_Store":    true,
+		".ds_store":    true,
 		".ruff_cache":  true,
 		".ropeproject": true,
 	}
-	return !ignoredFiles[fileName]
+	return !ignoredFiles[strings.ToLower(fileName)]
 }
 
 // GitHub VCS
@@ -28,14 +34,32 @@ func (g GitHubVCS) ListRepositories(root string) ([]Repository, error) {
 		return nil, fmt.Errorf("os: %w", err)
 	}
 
+	// Symlinked entries are only followed when they stay inside the workspace, and a
+	// repository reachable through several links is scanned once.
+	visited := map[string]bool{}
 	var rs []Repository
 	for _, repo := range repos {
-		if shouldIncludeDir(repo.Name()) {
-			rs = append(rs, &GitRepository{
-				name:      repo.Name(),
-				localPath: fmt.Sprintf("%s/%s", root, repo.Name()),
-			})
+		if !shouldIncludeDir(repo.Name()) {
+			continue
+		}
+
+		localPath := filepath.Join(root, repo.Name())
+		resolved, err := resolveWithinRoot(root, localPath)
+		if err != nil {
+			logger.Warn("skipping workspace entry", "entry", localPath, "err", err)
+			continue
 		}
+		if visited[resolved] {
+			logger.Debug("repository already visited through another path. skipping", "entry", localPath)
+			continue
+		}
+		visited[resolved] = true
+
+		rs = append(rs, &GitRepository{
+			name:      repo.Name(),
+			localPath: localPath,
+			bare:      IsBareRepo(localPath),
+		})
 	}
 
 	return rs, nil
@@ -45,6 +69,10 @@ func (g GitHubVCS) ListRepositories(root string) ([]Repository, error) {
 type GitRepository struct {
 	name      string
 	localPath string
+	// bare repositories have no worktree, so their files are read from the object store
+	bare bool
+	// gitdir is where the objects are when not below localPath, as for submodules
+	gitdir string
 }
 
 func (g GitRepository) Name() string {
@@ -55,6 +83,11 @@ func (g GitRepository) Location() string {
 	return g.localPath
 }
 
+// Bare reports whether the repository has no worktree
+func (g GitRepository) Bare() bool {
+	return g.bare
+}
+
 func (g GitRepository) ListBranches() ([]string, error) {
 	return ListGitBranches(g.localPath)
 }
@@ -73,8 +106,15 @@ func (g GitRepository) ListFiles(loc string) ([]string, error) {
 	return files, nil
 }
 
+// ReadFile reads a file of the repository. Symlinks are followed only while they
+// resolve inside the repository; otherwise a *SymlinkError is returned.
 func (g GitRepository) ReadFile(filePath string) ([]byte, error) {
-	content, err := os.ReadFile(filePath)
+	resolved, err := resolveWithinRoot(g.localPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(resolved)
 	if err != nil {
 		return nil, fmt.Errorf("os: %w", err)
 	}
@@ -89,27 +129,182 @@ func (g GitRepository) SwitchBranch(branchName string) error {
 // GitHubWorkFlowScanner implements Scanner interface
 type GitHubWorkFlowScanner struct{}
 
-// ScanContent finds matches in given content
-func (gws GitHubWorkFlowScanner) ScanContent(content []byte, regex *regexp.Regexp) ([]string, error) {
-	found := regex.FindAll([]byte(content), -1)
+// ScanContent finds matches in given content.
+// YAML content is scanned document by document with anchors, aliases and merge keys
+// resolved; content that is not valid YAML falls back to a plain text scan.
+func (gws GitHubWorkFlowScanner) ScanContent(content []byte, regex *regexp.Regexp) ([]Match, error) {
+	li := newLineIndex(content)
+	docs, err := parseYAMLDocuments(content)
+	if err != nil {
+		logger.Debug("content is not valid YAML. falling back to text scan", "err", err)
+		return scanText(li, regex), nil
+	}
 
-	var matches []string
-	for _, match := range found {
-		matches = append(matches, string(match))
+	var matches []Match
+	for _, doc := range docs {
+		walkScalars(doc, func(n *yaml.Node) {
+			from := li.offset(n.Line, n.Column)
+			for _, value := range regex.FindAllString(n.Value, -1) {
+				m := Match{Value: value, Line: n.Line, Column: n.Column}
+				if off, ok := li.locate(value, from); ok {
+					m.Line, m.Column = li.position(off)
+					// Continue after this match for repeated values in one scalar.
+					from = off + len(value)
+				}
+				matches = append(matches, m)
+			}
+		})
 	}
 
 	return matches, nil
 }
 
+// scanText finds matches in raw content without interpreting its structure
+func scanText(li *lineIndex, regex *regexp.Regexp) []Match {
+	var matches []Match
+	for _, loc := range regex.FindAllIndex(li.content, -1) {
+		line, col := li.position(loc[0])
+		matches = append(matches, Match{
+			Value:  string(li.content[loc[0]:loc[1]]),
+			Line:   line,
+			Column: col,
+		})
+	}
+
+	return matches
+}
+
+// Match is a single regex match and where it starts in the file
+type Match struct {
+	Value  string `json:"match"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Score is the risk score of the reference, set when ranking by risk
+	Score int `json:"risk_score,omitempty"`
+	// Fix pins the reference to a commit SHA, set with quick fixes
+	Fix *QuickFix `json:"fix,omitempty"`
+	// Blame is the last commit that changed the line, set with --blame
+	Blame *lineAuthor `json:"blame,omitempty"`
+}
+
+// matchValues returns the matched strings of the given matches
+func matchValues(matches []Match) []string {
+	var values []string
+	for _, m := range matches {
+		values = append(values, m.Value)
+	}
+	return values
+}
+
 // InventoryRecord holds details for a regex match in a file.
 type InventoryRecord struct {
-	Repository string   `json:"repository_name"` // Repository name or path
-	Branch     string   `json:"branch_name"`     // Branch name
-	FilePath   string   `json:"actions_file"`    // File path where the match was found
-	Matches    []string `json:"matches"`         // Regex match results from the file content
+	Repository string `json:"repository_name"`     // Repository name or path
+	Branch     string `json:"branch_name"`         // Branch name
+	FilePath   string `json:"actions_file"`        // File path where the match was found
+	Component  string `json:"component,omitempty"` // Component of the repository owning the file
+	// Branches holding the same matches and findings in the file, set with --all-branches
+	Branches []string `json:"branches,omitempty"`
+	// Criticality is the tier of the repository, set when the configuration tags repositories
+	Criticality string    `json:"criticality,omitempty"`
+	Matches     []string  `json:"matches"`                 // Regex match results from the file content
+	Locations   []Match   `json:"locations"`               // Line and column of each entry in Matches
+	Findings    []Finding `json:"rule_findings,omitempty"` // Issues raised by rules other than the regex
+	// Findings silenced by a suppression, with its reason
+	Suppressed []SuppressedFinding `json:"suppressed_findings,omitempty"`
+	// Triggers and privileges of the workflow, set when the scanner profiles workflows
+	Profile *WorkflowProfile `json:"profile,omitempty"`
+	// LastModified tells who last changed the file, set with --audit-log
+	LastModified *fileModification `json:"last_modified,omitempty"`
+	// RepoRoot is where the repository is checked out, set for records not at the fixed
+	// depth of workflows below it: pipeline files of other CI systems, the workflow
+	// directory and the files of submodules
+	RepoRoot string `json:"-"`
+	// Submodule holding the file, set with --recurse-submodules
+	Submodule *SubmoduleRef `json:"submodule,omitempty"`
 }
 
+// repoRoot returns where the repository of the record is checked out: RepoRoot when set,
+// otherwise two levels above the directory of the file, as for workflow files
+func (ir *InventoryRecord) repoRoot() string {
+	if ir.RepoRoot != "" {
+		return ir.RepoRoot
+	}
+	return filepath.Dir(filepath.Dir(filepath.Dir(ir.FilePath)))
+}
+
+// Severity levels of findings
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Finding is an issue detected in a file by a rule, as opposed to a plain regex match
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	// Suggestion is a replacement snippet that would resolve the finding, if any
+	Suggestion string `json:"suggestion,omitempty"`
+	// Score is the risk score of the finding, set when ranking by risk
+	Score int `json:"risk_score,omitempty"`
+	// Fix resolves the finding, set with quick fixes
+	Fix *QuickFix `json:"fix,omitempty"`
+	// Blame is the last commit that changed the line, set with --blame
+	Blame *lineAuthor `json:"blame,omitempty"`
+}
+
+// severityRank orders severities from least to most severe
+var severityRank = map[string]int{SeverityLow: 1, SeverityMedium: 2, SeverityHigh: 3, SeverityCritical: 4}
+
 // Inventory aggregates multiple inventory records.
 type Inventory struct {
 	Records []*InventoryRecord `json:"findings"`
+	// ScannedAt is when the inventory was exported, so later reports can chart trends
+	ScannedAt time.Time `json:"scanned_at,omitzero"`
+	// History holds earlier inventories of the same scope, oldest first, set with --history
+	History []*Inventory `json:"-"`
+}
+
+// hasMatches reports whether any record holds a mutable reference match
+func (inv *Inventory) hasMatches() bool {
+	for _, ir := range inv.Records {
+		if len(ir.Locations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSuppressed reports whether any record holds a suppressed finding
+func (inv *Inventory) hasSuppressed() bool {
+	for _, ir := range inv.Records {
+		if len(ir.Suppressed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasModifications reports whether any record tells who last modified its file
+func (inv *Inventory) hasModifications() bool {
+	for _, ir := range inv.Records {
+		if ir.LastModified != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFindings reports whether any record holds a rule finding
+func (inv *Inventory) hasFindings() bool {
+	for _, ir := range inv.Records {
+		if len(ir.Findings) > 0 {
+			return true
+		}
+	}
+	return false
 }
@@ -260,3 +260,23 @@ func TestScanner_ScanReposDefaultBranch(t *testing.T) {
 		}
 	}
 }
+
+// --- Tests for InventoryRecord.repoRoot ---
+
+func TestInventoryRecord_RepoRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   InventoryRecord
+		expected string
+	}{
+		{"workflow", InventoryRecord{FilePath: "/src/app/.github/workflows/ci.yml"}, "/src/app"},
+		{"pipeline file", InventoryRecord{FilePath: "/src/app/.gitlab-ci.yml", RepoRoot: "/src/app"}, "/src/app"},
+		{"workflow directory", InventoryRecord{FilePath: "/src/app/.github/workflows", RepoRoot: "/src/app"}, "/src/app"},
+		{"submodule", InventoryRecord{FilePath: "/src/app/vendor/lib/.github/workflows/ci.yml", RepoRoot: "/src/app"}, "/src/app"},
+	}
+	for _, tc := range tests {
+		if got := tc.record.repoRoot(); got != filepath.FromSlash(tc.expected) {
+			t.Errorf("%s: repoRoot() = %s; want %s", tc.name, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// defaultScanWorkers is how many repositories a scan reads at once by default
+const defaultScanWorkers = 8
+
+// scanEach calls scan for each of n repositories, a few at a time or one at a time in polite
+// mode, and returns the records of each repository in their order. A scan failing is logged
+// and leaves out the records of its repository only, as one broken repository must not cost
+// the scan of hundreds.
+func (s *Scanner) scanEach(n int, name func(i int) string, scan func(i int) []*InventoryRecord) [][]*InventoryRecord {
+	workers := s.Concurrency
+	if workers <= 0 {
+		workers = defaultScanWorkers
+	}
+	if polite != nil {
+		workers = min(workers, politeWorkers)
+	}
+
+	results := make([][]*InventoryRecord, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				records, err := scanIsolated(func() []*InventoryRecord { return scan(i) })
+				if err != nil {
+					logger.Warn("could not scan repository. skipping to next repo", "repo", name(i), "err", err)
+					continue
+				}
+				results[i] = records
+				if s.Stream != nil {
+					if err := s.Stream.write(records); err != nil {
+						logger.Warn("could not stream the issues of repository", "repo", name(i), "err", err)
+					}
+				}
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// scanIsolated runs the scan of a repository, turning a panic into an error
+func scanIsolated(scan func() []*InventoryRecord) (records []*InventoryRecord, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scan panicked: %v", r)
+		}
+	}()
+	return scan(), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// --- Tests for scanEach ---
+
+func TestScanner_ScanEach(t *testing.T) {
+	names := []string{"api", "broken", "web", "worker", "docs"}
+
+	var mu sync.Mutex
+	running, busiest := 0, 0
+	sc := &Scanner{Concurrency: 2}
+	results := sc.scanEach(len(names), func(i int) string { return names[i] }, func(i int) []*InventoryRecord {
+		mu.Lock()
+		running++
+		busiest = max(busiest, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		if names[i] == "broken" {
+			panic("corrupt repository")
+		}
+		// Later repositories finish first, which must not reorder the records.
+		time.Sleep(time.Duration(len(names)-i) * time.Millisecond)
+		return []*InventoryRecord{{Repository: names[i]}}
+	})
+
+	if busiest > sc.Concurrency {
+		t.Errorf("scanned %d repositories at once, want at most %d", busiest, sc.Concurrency)
+	}
+	var got []string
+	for _, records := range results {
+		for _, ir := range records {
+			got = append(got, ir.Repository)
+		}
+	}
+	if fmt.Sprint(got) != "[api web worker docs]" {
+		t.Errorf("records = %v, want the repositories in order without the broken one", got)
+	}
+}
+
+func TestScanner_ScanEach_PoliteMode(t *testing.T) {
+	t.Cleanup(func() { polite = nil })
+	polite = newPoliteClient(0)
+
+	var mu sync.Mutex
+	running, busiest := 0, 0
+	sc := &Scanner{Concurrency: 4}
+	sc.scanEach(4, func(i int) string { return fmt.Sprint(i) }, func(i int) []*InventoryRecord {
+		mu.Lock()
+		running++
+		busiest = max(busiest, running)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	if busiest != politeWorkers {
+		t.Errorf("scanned %d repositories at once in polite mode, want %d", busiest, politeWorkers)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleHardcodedSecret flags credentials written into workflow files
+const ruleHardcodedSecret = "hardcoded-secret"
+
+// secretPatterns match credentials of well-known formats. They only match values that are
+// credentials on their own, so placeholders and ${{ secrets.NAME }} references never do.
+var secretPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Slack webhook", regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/_-]+`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"npm token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// SecretRule flags credentials written into workflows, such as a GitHub token in env: or a
+// curl header. Anyone able to read the repository, or the logs of its runs, can use them.
+// Their values are redacted from every output unless the scanner reveals secrets.
+type SecretRule struct{}
+
+func (r SecretRule) ID() string {
+	return ruleHardcodedSecret
+}
+
+func (r SecretRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, root := range wf.roots() {
+		walkScalars(root, func(n *yaml.Node) {
+			for _, p := range secretPatterns {
+				for _, value := range p.re.FindAllString(n.Value, -1) {
+					findings = append(findings, wf.finding(r.ID(), SeverityCritical, n, fmt.Sprintf(
+						"%s %s is written into the workflow. Revoke it, store it as an encrypted secret and read it with ${{ secrets.<NAME> }}",
+						p.name, value)))
+				}
+			}
+		})
+	}
+	return findings
+}
+
+// redactSecret keeps the first characters of a secret, enough to tell its kind, and a hash
+// to tell the same secret apart in several findings
+func redactSecret(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("%s…[redacted sha256:%s]", value[:min(4, len(value))], hex.EncodeToString(sum[:])[:12])
+}
+
+// redactSecrets replaces every credential in text by its redacted form
+func redactSecrets(text string) string {
+	for _, p := range secretPatterns {
+		text = p.re.ReplaceAllStringFunc(text, redactSecret)
+	}
+	return text
+}
+
+// redact redacts the credentials quoted by the messages and suggestions of findings,
+// suppressed or not and whatever rule reported them, unless the scanner reveals secrets
+func (s *Scanner) redact(findings []Finding, suppressed []SuppressedFinding) {
+	if s.RevealSecrets {
+		return
+	}
+	for i := range findings {
+		findings[i].Message = redactSecrets(findings[i].Message)
+		findings[i].Suggestion = redactSecrets(findings[i].Suggestion)
+	}
+	for i := range suppressed {
+		suppressed[i].Message = redactSecrets(suppressed[i].Message)
+		suppressed[i].Suggestion = redactSecrets(suppressed[i].Suggestion)
+	}
+}
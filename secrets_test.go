@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Built from parts so the fixtures are not mistaken for leaked credentials.
+var (
+	testGitHubToken = "ghp_" + strings.Repeat("a1B2", 9)
+	testAWSKey      = "AKIA" + "IOSFODNN7EXAMPLE"
+)
+
+// --- Tests for SecretRule.Check ---
+
+func TestSecretRule_Check(t *testing.T) {
+	content := `on: push
+env:
+  AWS_ACCESS_KEY_ID: ` + testAWSKey + `
+  TOKEN: ${{ secrets.TOKEN }}
+jobs:
+  a:
+    steps:
+      - run: |
+          curl -H "Authorization: token ` + testGitHubToken + `" https://api.github.com/user
+`
+	findings := SecretRule{}.Check(newWorkflowFile("wf.yml", []byte(content)))
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Rule != ruleHardcodedSecret || f.Severity != SeverityCritical || f.Line != 3 || !strings.Contains(f.Message, "AWS access key "+testAWSKey) {
+		t.Errorf("unexpected finding %+v", f)
+	}
+	if f := findings[1]; f.Line != 8 || !strings.Contains(f.Message, "GitHub token "+testGitHubToken) {
+		t.Errorf("unexpected finding %+v", f)
+	}
+}
+
+// --- Tests for redactSecrets ---
+
+func TestRedactSecrets(t *testing.T) {
+	text := "token " + testGitHubToken + " and key " + testAWSKey
+	redacted := redactSecrets(text)
+	if strings.Contains(redacted, testGitHubToken) || strings.Contains(redacted, testAWSKey) {
+		t.Fatalf("expected the secrets to be redacted, got %q", redacted)
+	}
+	if !strings.HasPrefix(redacted, "token ghp_…[redacted sha256:") || !strings.Contains(redacted, " and key AKIA…[redacted sha256:") {
+		t.Errorf("expected a prefix and a hash of each secret, got %q", redacted)
+	}
+	if redactSecrets(text) != redacted {
+		t.Error("expected the same secret to redact the same way")
+	}
+	if got := redactSecrets("uses: actions/checkout@v4"); got != "uses: actions/checkout@v4" {
+		t.Errorf("expected text without secrets to be kept, got %q", got)
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with redaction ---
+
+func TestScanner_ScanBranchRedactsSecrets(t *testing.T) {
+	repo := fakeRepository{
+		name:  "org/api",
+		files: []string{"ci.yml"},
+		fileContents: map[string][]byte{
+			"root/.github/workflows/ci.yml": []byte("on: push\njobs:\n  a:\n    steps:\n      - run: |\n          curl -H \"Authorization: token " + testGitHubToken + "\" -o tool.tgz https://github.com/acme/tool/releases/download/v1/tool.tgz\n"),
+		},
+	}
+
+	// Every rule quoting the secret is redacted, not only the rule finding it.
+	for _, reveal := range []bool{false, true} {
+		sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Rules: []Rule{SecretRule{}, DownloadRule{}}, RevealSecrets: reveal}
+		records := sc.ScanBranch("main", repo, mutableRefRegex, "root/.github/workflows")
+		if len(records) != 1 || len(records[0].Findings) != 2 {
+			t.Fatalf("expected a secret and a download finding, got %+v", records)
+		}
+		for _, f := range records[0].Findings {
+			if leaked := strings.Contains(f.Message+f.Suggestion, testGitHubToken); leaked != reveal {
+				t.Errorf("reveal %v: unexpected finding %+v", reveal, f)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// badgeCacheTTL is how long a scan result is reused for badges of the same repository
+const badgeCacheTTL = 10 * time.Minute
+
+// repoScanFunc scans a GitHub repository given its owner and name
+type repoScanFunc func(owner, name string) (*Inventory, error)
+
+// cachedBadge is a badge along with the time it was computed
+type cachedBadge struct {
+	badge Badge
+	at    time.Time
+}
+
+// badgeScan is a scan in progress, which concurrent requests for the same badge wait for
+type badgeScan struct {
+	done  chan struct{}
+	badge Badge
+	err   error
+}
+
+// badgeServer serves badges of GitHub repositories, rescanning each at most once per TTL
+type badgeServer struct {
+	scan    repoScanFunc
+	ttl     time.Duration
+	now     func() time.Time
+	allowed []string
+
+	mu       sync.Mutex
+	cache    map[string]cachedBadge
+	inflight map[string]*badgeScan
+}
+
+// newBadgeServer creates the HTTP handler of server mode. It serves
+// /badge/{owner}/{repo}.svg as an image and /badge/{owner}/{repo}.json for shields.io endpoint badges.
+// Only repositories matching the allowed globs, such as owner/* or owner/repo, are scanned.
+func newBadgeServer(scan repoScanFunc, ttl time.Duration, allowed []string) http.Handler {
+	s := &badgeServer{scan: scan, ttl: ttl, now: time.Now, allowed: allowed, cache: map[string]cachedBadge{}, inflight: map[string]*badgeScan{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /badge/{owner}/{file}", s.serveBadge)
+	return mux
+}
+
+// githubName matches valid owner and repository names
+var githubName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func (s *badgeServer) serveBadge(w http.ResponseWriter, r *http.Request) {
+	owner, file := r.PathValue("owner"), r.PathValue("file")
+	name, ext := file, ""
+	if i := strings.LastIndex(file, "."); i > 0 {
+		name, ext = file[:i], file[i+1:]
+	}
+	if !githubName.MatchString(owner) || !githubName.MatchString(name) || (ext != "svg" && ext != "json") {
+		http.NotFound(w, r)
+		return
+	}
+	if _, ok := matchingAction(s.allowed, owner+"/"+name); !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	badge, err := s.badge(owner, name)
+	if err != nil {
+		logger.Warn("could not scan repository for badge", "repo", owner+"/"+name, "err", err)
+		http.Error(w, "could not scan repository", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "max-age=300")
+	if ext == "json" {
+		body, err := badge.JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write([]byte(badge.SVG()))
+}
+
+// badge returns the badge of a repository, scanning it unless a recent result is cached.
+// Requests arriving while the repository is scanned share that scan.
+func (s *badgeServer) badge(owner, name string) (Badge, error) {
+	key := strings.ToLower(owner + "/" + name)
+
+	s.mu.Lock()
+	if c, ok := s.cache[key]; ok && s.now().Sub(c.at) < s.ttl {
+		s.mu.Unlock()
+		return c.badge, nil
+	}
+	if scan, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		<-scan.done
+		return scan.badge, scan.err
+	}
+	scan := &badgeScan{done: make(chan struct{})}
+	s.inflight[key] = scan
+	s.mu.Unlock()
+
+	inv, err := s.scan(owner, name)
+	if err == nil {
+		scan.badge = badgeFor(inv)
+	}
+	scan.err = err
+
+	s.mu.Lock()
+	if err == nil {
+		s.cache[key] = cachedBadge{badge: scan.badge, at: s.now()}
+	}
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(scan.done)
+	return scan.badge, scan.err
+}
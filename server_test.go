@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// --- Tests for badgeServer ---
+
+func TestBadgeServer(t *testing.T) {
+	scans := 0
+	scan := func(owner, name string) (*Inventory, error) {
+		scans++
+		if name == "broken" {
+			return nil, errors.New("boom")
+		}
+		return &Inventory{Records: []*InventoryRecord{{Profile: &WorkflowProfile{ActionRefs: 2, PinnedRefs: 1}}}}, nil
+	}
+	srv := newBadgeServer(scan, time.Hour, []string{"owner/*"})
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{path: "/badge/owner/repo.svg", status: http.StatusOK, contentType: "image/svg+xml", body: "50% pinned"},
+		{path: "/badge/owner/repo.json", status: http.StatusOK, contentType: "application/json", body: `"message":"50% pinned"`},
+		{path: "/badge/owner/repo.png", status: http.StatusNotFound},
+		{path: "/badge/owner/broken.svg", status: http.StatusBadGateway},
+		{path: "/badge/other/repo.svg", status: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d; want %d", rec.Code, tc.status)
+			}
+			if tc.contentType != "" && rec.Header().Get("Content-Type") != tc.contentType {
+				t.Errorf("Content-Type = %q; want %q", rec.Header().Get("Content-Type"), tc.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tc.body) {
+				t.Errorf("body %q does not contain %q", rec.Body.String(), tc.body)
+			}
+		})
+	}
+
+	// The svg and json badges of owner/repo share one cached scan.
+	if scans != 2 {
+		t.Errorf("expected 2 scans, got %d", scans)
+	}
+}
+
+func TestBadgeServer_CoalescesScans(t *testing.T) {
+	release := make(chan struct{})
+	var scans atomic.Int32
+	scan := func(owner, name string) (*Inventory, error) {
+		scans.Add(1)
+		<-release
+		return &Inventory{Records: []*InventoryRecord{{Profile: &WorkflowProfile{ActionRefs: 1, PinnedRefs: 1}}}}, nil
+	}
+	srv := newBadgeServer(scan, time.Hour, []string{"owner/repo"})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/badge/owner/repo.svg", nil))
+			codes[i] = rec.Code
+		}()
+	}
+	// Let every request reach the scan in progress before it ends.
+	for scans.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if scans.Load() != 1 {
+		t.Errorf("expected concurrent requests to share 1 scan, got %d", scans.Load())
+	}
+	for _, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("status = %d; want %d", code, http.StatusOK)
+		}
+	}
+}
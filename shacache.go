@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long resolved references are trusted. Tags seldom move, while branches move with every
+// push.
+const (
+	tagCacheTTL    = 7 * 24 * time.Hour
+	branchCacheTTL = time.Hour
+)
+
+// shaCacheEntry is the commit a reference resolved to, and when
+type shaCacheEntry struct {
+	SHA        string    `json:"sha"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// shaCache keeps the commits references resolved to in a file, so repeated scans of the same
+// actions do not query the API again. Every new lookup is written through, so runs ending
+// early keep what they resolved.
+type shaCache struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]shaCacheEntry
+}
+
+// resolutionCache caches the lookups of SHAResolver, nil with --no-cache
+var resolutionCache *shaCache
+
+// shaCacheFile is the name of the cache file in the cache directory
+const shaCacheFile = "shas.json"
+
+// cacheDir returns the directory the cache of resolved references is kept in: the one given,
+// then SCHARF_CACHE_DIR, such as a directory restored by actions/cache to share it across CI
+// runs, and the cache directory of the user otherwise
+func cacheDir(dir string) (string, error) {
+	if dir == "" {
+		dir = os.Getenv("SCHARF_CACHE_DIR")
+	}
+	if dir != "" {
+		return expandHome(dir), nil
+	}
+	userDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("os: %w", err)
+	}
+	return filepath.Join(userDir, "scharf"), nil
+}
+
+// openSHACache reads the cache file at path. A missing or unreadable file starts an empty
+// cache.
+func openSHACache(path string) *shaCache {
+	c := &shaCache{path: path, now: time.Now, entries: map[string]shaCacheEntry{}}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Debug("could not read the cache of resolved references", "file", path, "err", err)
+		}
+		return c
+	}
+	if err := json.Unmarshal(content, &c.entries); err != nil {
+		logger.Debug("ignoring corrupt cache of resolved references", "file", path, "err", err)
+		c.entries = map[string]shaCacheEntry{}
+	}
+	return c
+}
+
+// cacheTTL returns how long the resolution of a ref is trusted, telling tags from branches
+// the way makeAPIEndpoint does
+func cacheTTL(version string) time.Duration {
+	if strings.HasPrefix(strings.ToLower(version), "v") {
+		return tagCacheTTL
+	}
+	return branchCacheTTL
+}
+
+// get returns the commit a reference resolved to, unless it was resolved longer than ttl ago
+func (c *shaCache) get(key string, ttl time.Duration) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().Sub(e.ResolvedAt) > ttl {
+		return "", false
+	}
+	return e.SHA, true
+}
+
+// put records the commit a reference resolved to and writes the cache file. Expired entries
+// are dropped on the way.
+func (c *shaCache) put(key, sha string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if now.Sub(e.ResolvedAt) > tagCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = shaCacheEntry{SHA: sha, ResolvedAt: now}
+	if err := c.write(); err != nil {
+		logger.Debug("could not write the cache of resolved references", "file", c.path, "err", err)
+	}
+}
+
+// write replaces the cache file at once, so concurrent runs never read half of it
+func (c *shaCache) write() error {
+	content, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("os: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".shas-*.json")
+	if err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("file error: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
+
+// clearSHACache deletes the cache file at path, if any
+func clearSHACache(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("file error: %w", err)
+	}
+	return nil
+}
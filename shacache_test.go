@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// --- Tests for shaCache ---
+
+func TestSHACache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "shas.json")
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := openSHACache(path)
+	c.now = func() time.Time { return clock }
+	c.put("actions/checkout@v4", lockedSHA)
+	c.put("actions/checkout@main", movedSHA)
+
+	// A later run reads what was written.
+	reopened := openSHACache(path)
+	reopened.now = func() time.Time { return clock.Add(2 * time.Hour) }
+	tests := []struct {
+		key    string
+		ttl    time.Duration
+		sha    string
+		cached bool
+	}{
+		{"actions/checkout@v4", cacheTTL("v4"), lockedSHA, true},
+		{"actions/checkout@main", cacheTTL("main"), "", false},
+		{"actions/setup-go@v5", cacheTTL("v5"), "", false},
+	}
+	for _, tc := range tests {
+		if sha, ok := reopened.get(tc.key, tc.ttl); sha != tc.sha || ok != tc.cached {
+			t.Errorf("get(%s) = %q, %v; want %q, %v", tc.key, sha, ok, tc.sha, tc.cached)
+		}
+	}
+
+	CheckIfError(clearSHACache(path))
+	if _, ok := openSHACache(path).get("actions/checkout@v4", tagCacheTTL); ok {
+		t.Error("expected an empty cache once cleared")
+	}
+	CheckIfError(clearSHACache(path))
+
+	CheckIfError(os.WriteFile(path, []byte("{not json"), 0o644))
+	if c := openSHACache(path); len(c.entries) != 0 {
+		t.Errorf("expected a corrupt cache to start empty, got %+v", c.entries)
+	}
+}
+
+func TestSHAResolver_Cached(t *testing.T) {
+	resolutionCache = openSHACache(filepath.Join(t.TempDir(), "shas.json"))
+	defer func() { resolutionCache = nil }()
+
+	lookups := 0
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		lookups++
+		body := `[{"name": "v4", "commit": {"sha": "` + lockedSHA + `"}}]`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body))), Header: make(http.Header)}, nil
+	})
+	withHTTPClientTransport(rt, func() {
+		for range 2 {
+			sha, err := SHAResolver{}.resolve("actions/checkout@v4")
+			if err != nil || sha != lockedSHA {
+				t.Errorf("resolve = %s, %v", sha, err)
+			}
+		}
+	})
+	if lookups != 1 {
+		t.Errorf("expected the second resolution to be cached, got %d lookups", lookups)
+	}
+
+	withHTTPClientTransport(rt, func() {
+		_, err := SHAResolver{Fresh: true}.resolve("actions/checkout@v4")
+		CheckIfError(err)
+	})
+	if lookups != 2 {
+		t.Errorf("expected a fresh resolution to query the API, got %d lookups", lookups)
+	}
+}
+
+// --- Tests for cacheDir ---
+
+func TestCacheDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	CheckIfError(err)
+	userDir, err := os.UserCacheDir()
+	CheckIfError(err)
+
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		expected string
+	}{
+		{name: "flag", flag: "/ci/cache", env: "/env/cache", expected: "/ci/cache"},
+		{name: "environment", env: "~/scharf-cache", expected: filepath.Join(home, "scharf-cache")},
+		{name: "user cache", expected: filepath.Join(userDir, "scharf")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SCHARF_CACHE_DIR", tc.env)
+			if dir, err := cacheDir(tc.flag); err != nil || dir != tc.expected {
+				t.Errorf("cacheDir(%q) = %s, %v; want %s", tc.flag, dir, err, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package sharfertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// GitHub is a fake of the parts of the GitHub API scharf reads: the tags, branches,
+// commits, releases and files of repositories and the repositories of organizations.
+// It is laid out like GitHub Enterprise Server, with the API under /api/v3 and files
+// under /raw. Repositories are named owner/name.
+type GitHub struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	repos    map[string]*fakeRepo
+	requests []string
+}
+
+type fakeRef struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Sha string `json:"sha"`
+	} `json:"commit"`
+}
+
+type fakeRelease struct {
+	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type fakeRepo struct {
+	tags     []fakeRef
+	branches []fakeRef
+	commits  map[string]time.Time
+	releases []fakeRelease
+	// files maps refs to the contents of the files at each path
+	files map[string]map[string]string
+}
+
+// NewGitHub starts a fake GitHub closed at the end of the test
+func NewGitHub(tb testing.TB) *GitHub {
+	g := &GitHub{repos: map[string]*fakeRepo{}}
+	g.Server = httptest.NewServer(http.HandlerFunc(g.serve))
+	tb.Cleanup(g.Close)
+	return g
+}
+
+// Setenv points scharf at the fake for the rest of the test. scharf reads the variables
+// as it starts.
+func (g *GitHub) Setenv(tb testing.TB) {
+	tb.Setenv("GITHUB_SERVER_URL", g.URL)
+	tb.Setenv("GITHUB_API_URL", g.URL+"/api/v3")
+}
+
+// Tag adds a tag pointing to a commit
+func (g *GitHub) Tag(repo, name, sha string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(repo)
+	r.tags = append(r.tags, newFakeRef(name, sha))
+}
+
+// Branch adds a branch pointing to a commit
+func (g *GitHub) Branch(repo, name, sha string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(repo)
+	r.branches = append(r.branches, newFakeRef(name, sha))
+}
+
+// Commit sets when a commit, or the commit a tag or branch points to, was committed
+func (g *GitHub) Commit(repo, ref string, committedAt time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.repo(repo).commits[ref] = committedAt
+}
+
+// Release adds a release of a tag. The release published last is the latest.
+func (g *GitHub) Release(repo, tag, notes string, publishedAt time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(repo)
+	r.releases = append(r.releases, fakeRelease{TagName: tag, Body: notes, PublishedAt: publishedAt})
+}
+
+// File adds a file, by its slash-separated path, to the repository at a ref. HEAD is the
+// ref of the default branch.
+func (g *GitHub) File(repo, ref, filePath, content string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.repo(repo)
+	if r.files[ref] == nil {
+		r.files[ref] = map[string]string{}
+	}
+	r.files[ref][path.Clean(filePath)] = content
+}
+
+// Do answers a request in memory, so the fake stands in for the HTTP client of GitHub
+// without a network. Requests to api.github.com and raw.githubusercontent.com are served as
+// those to the API and files of the fake.
+func (g *GitHub) Do(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.RawPath = ""
+	switch u.Host {
+	case "api.github.com":
+		u.Path = "/api/v3" + u.Path
+	case "raw.githubusercontent.com":
+		u.Path = "/raw" + u.Path
+	}
+	return serveInMemory(g.serve, req, &u), nil
+}
+
+// Requests returns the paths of the requests served so far, queries included
+func (g *GitHub) Requests() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.requests...)
+}
+
+func newFakeRef(name, sha string) fakeRef {
+	ref := fakeRef{Name: name}
+	ref.Commit.Sha = sha
+	return ref
+}
+
+// repo returns a repository, adding it if needed. The caller holds the lock.
+func (g *GitHub) repo(name string) *fakeRepo {
+	r, ok := g.repos[name]
+	if !ok {
+		r = &fakeRepo{commits: map[string]time.Time{}, files: map[string]map[string]string{}}
+		g.repos[name] = r
+	}
+	return r
+}
+
+func (g *GitHub) serve(w http.ResponseWriter, req *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, req.URL.RequestURI())
+
+	if rest, ok := strings.CutPrefix(req.URL.Path, "/raw/"); ok {
+		// owner/name/ref/path, with refs without slashes
+		parts := strings.SplitN(rest, "/", 4)
+		if len(parts) == 4 {
+			if r, ok := g.repos[parts[0]+"/"+parts[1]]; ok {
+				if content, ok := r.files[parts[2]][parts[3]]; ok {
+					w.Write([]byte(content))
+					return
+				}
+			}
+		}
+		notFound(w)
+		return
+	}
+
+	if org, ok := strings.CutPrefix(req.URL.Path, "/api/v3/orgs/"); ok && strings.HasSuffix(org, "/repos") {
+		org = strings.TrimSuffix(org, "/repos")
+		// Every repository fits in the first page.
+		if page := req.URL.Query().Get("page"); page != "" && page != "1" {
+			writeJSON(w, []any{})
+			return
+		}
+		repos := []map[string]any{}
+		for _, name := range g.names() {
+			if owner, repo, _ := strings.Cut(name, "/"); owner == org {
+				repos = append(repos, map[string]any{"name": repo, "archived": false, "visibility": "public"})
+			}
+		}
+		writeJSON(w, repos)
+		return
+	}
+
+	rest, ok := strings.CutPrefix(req.URL.Path, "/api/v3/repos/")
+	parts := strings.SplitN(rest, "/", 4)
+	if !ok || len(parts) < 3 {
+		notFound(w)
+		return
+	}
+	r, ok := g.repos[parts[0]+"/"+parts[1]]
+	if !ok {
+		notFound(w)
+		return
+	}
+	arg := ""
+	if len(parts) == 4 {
+		arg = parts[3]
+	}
+
+	switch parts[2] {
+	case "tags":
+		writeJSON(w, append([]fakeRef{}, r.tags...))
+	case "branches":
+		writeJSON(w, append([]fakeRef{}, r.branches...))
+	case "commits":
+		if date, ok := r.commitDate(arg); ok {
+			commit := map[string]any{"sha": arg, "commit": map[string]any{"committer": map[string]any{"date": date}}}
+			writeJSON(w, commit)
+			return
+		}
+		notFound(w)
+	case "releases":
+		if rel, ok := r.release(arg); ok {
+			writeJSON(w, rel)
+			return
+		}
+		notFound(w)
+	case "contents":
+		ref := req.URL.Query().Get("ref")
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if entries := r.listing(ref, path.Clean(arg)); len(entries) > 0 {
+			writeJSON(w, entries)
+			return
+		}
+		notFound(w)
+	default:
+		notFound(w)
+	}
+}
+
+// names returns the repositories in order. The caller holds the lock.
+func (g *GitHub) names() []string {
+	names := make([]string, 0, len(g.repos))
+	for name := range g.repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commitDate returns the date of a commit, or of the commit a tag or branch points to
+func (r *fakeRepo) commitDate(ref string) (time.Time, bool) {
+	if date, ok := r.commits[ref]; ok {
+		return date, true
+	}
+	for _, t := range slices.Concat(r.tags, r.branches) {
+		if t.Name == ref {
+			date, ok := r.commits[t.Commit.Sha]
+			return date, ok
+		}
+	}
+	return time.Time{}, false
+}
+
+// release returns the release behind latest or tags/<tag>
+func (r *fakeRepo) release(arg string) (fakeRelease, bool) {
+	if arg == "latest" {
+		var latest fakeRelease
+		for _, rel := range r.releases {
+			if !rel.PublishedAt.Before(latest.PublishedAt) {
+				latest = rel
+			}
+		}
+		return latest, latest.TagName != ""
+	}
+	tag, ok := strings.CutPrefix(arg, "tags/")
+	for _, rel := range r.releases {
+		if ok && rel.TagName == tag {
+			return rel, true
+		}
+	}
+	return fakeRelease{}, false
+}
+
+// listing returns the entries of a directory at a ref, as the contents API does
+func (r *fakeRepo) listing(ref, dir string) []map[string]string {
+	seen := map[string]bool{}
+	var entries []map[string]string
+	for p := range r.files[ref] {
+		rel, ok := strings.CutPrefix(p, dir+"/")
+		if dir == "." {
+			rel, ok = p, true
+		}
+		if !ok {
+			continue
+		}
+		name, _, isDir := strings.Cut(rel, "/")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		entry := map[string]string{"name": name, "path": path.Join(dir, name), "type": "file"}
+		if isDir {
+			entry["type"] = "dir"
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["name"] < entries[j]["name"] })
+	return entries
+}
+
+// serveInMemory serves a request at u with a handler and returns the response recorded
+func serveInMemory(handler http.HandlerFunc, req *http.Request, u *url.URL) *http.Response {
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.RequestURI = u.RequestURI()
+	rec := httptest.NewRecorder()
+	handler(rec, r)
+	resp := rec.Result()
+	resp.Request = req
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Not Found"}`))
+}
@@ -0,0 +1,105 @@
+package sharfertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry is an in-memory fake of the distribution API of OCI registries, serving the
+// digests of manifests and the tags of images. Images are named registry/repository, such
+// as ghcr.io/acme/tool, or docker.io/library/alpine for the alpine image of Docker Hub.
+type Registry struct {
+	mu       sync.Mutex
+	images   map[string]map[string]string
+	requests []string
+}
+
+// NewRegistry creates a registry without images
+func NewRegistry() *Registry {
+	return &Registry{images: map[string]map[string]string{}}
+}
+
+// Tag points a tag of an image to the digest of a manifest
+func (r *Registry) Tag(image, tag, digest string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.images[image] == nil {
+		r.images[image] = map[string]string{}
+	}
+	r.images[image][tag] = digest
+}
+
+// Requests returns the URLs of the requests served so far
+func (r *Registry) Requests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.requests...)
+}
+
+// Do answers a request in memory, so the fake stands in for the HTTP client of registries.
+// Requests to registry-1.docker.io are served as those to docker.io.
+func (r *Registry) Do(req *http.Request) (*http.Response, error) {
+	return serveInMemory(r.serve, req, req.URL), nil
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req.URL.String())
+
+	host := req.URL.Host
+	if host == "registry-1.docker.io" {
+		host = "docker.io"
+	}
+	rest, _ := strings.CutPrefix(req.URL.Path, "/v2/")
+	if repo, ok := strings.CutSuffix(rest, "/tags/list"); ok {
+		tags, ok := r.images[host+"/"+repo]
+		if !ok {
+			registryError(w, "NAME_UNKNOWN")
+			return
+		}
+		names := make([]string, 0, len(tags))
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+		writeJSON(w, map[string]any{"name": repo, "tags": names})
+		return
+	}
+
+	i := strings.LastIndex(rest, "/manifests/")
+	if i < 0 {
+		registryError(w, "UNSUPPORTED")
+		return
+	}
+	if digest, ok := r.digest(host+"/"+rest[:i], rest[i+len("/manifests/"):]); ok {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+		w.Header().Set("Docker-Content-Digest", digest)
+		return
+	}
+	registryError(w, "MANIFEST_UNKNOWN")
+}
+
+// digest resolves a tag, or a digest one of the tags points to. The caller holds the lock.
+func (r *Registry) digest(image, ref string) (string, bool) {
+	tags := r.images[image]
+	if strings.HasPrefix(ref, "sha256:") {
+		for _, d := range tags {
+			if d == ref {
+				return d, true
+			}
+		}
+		return "", false
+	}
+	d, ok := tags[ref]
+	return d, ok
+}
+
+func registryError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"code": code}}})
+}
@@ -0,0 +1,121 @@
+package sharfertest
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Remotes is a fake git transport cloning Repos registered under the URLs of remotes, such
+// as https://github.com/acme/app.git, and pushing to them, instead of reaching git hosts
+type Remotes struct {
+	mu     sync.Mutex
+	repos  map[string]*Repo
+	clones []string
+	pushes []string
+}
+
+// NewRemotes creates a transport without remotes
+func NewRemotes() *Remotes {
+	return &Remotes{repos: map[string]*Repo{}}
+}
+
+// Add serves a repository under the URL of a remote
+func (r *Remotes) Add(cloneURL string, repo *Repo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.repos[cloneURL] = repo
+}
+
+// Clones returns the URLs cloned so far
+func (r *Remotes) Clones() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.clones...)
+}
+
+// Pushes returns the pushes made so far, as the URL of the remote and a refspec
+func (r *Remotes) Pushes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.pushes...)
+}
+
+// Clone clones the last commit of ref, a branch or a tag, or of the checked out branch
+// when ref is empty, into dir. Every file is checked out, whatever sparse asks for.
+func (r *Remotes) Clone(dir, cloneURL, ref string, sparse []string) error {
+	r.mu.Lock()
+	repo, ok := r.repos[cloneURL]
+	r.clones = append(r.clones, cloneURL)
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("sharfertest: no remote at %s", cloneURL)
+	}
+
+	opts := &git.CloneOptions{URL: repo.Dir, Depth: 1, SingleBranch: true}
+	if ref == "" {
+		clone, err := git.PlainClone(dir, false, opts)
+		if err != nil {
+			return err
+		}
+		return pointOrigin(clone, cloneURL)
+	}
+	var err error
+	for _, name := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)} {
+		opts.ReferenceName = name
+		var clone *git.Repository
+		if clone, err = git.PlainClone(dir, false, opts); err == nil {
+			return pointOrigin(clone, cloneURL)
+		}
+		// A failed attempt may leave a partial clone behind.
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("sharfertest: %s has no branch or tag %s: %w", cloneURL, ref, err)
+}
+
+// pointOrigin sets the URL of the origin of a clone to the remote it was cloned from, as
+// a clone from the git host would have it, so pushes find their way back
+func pointOrigin(clone *git.Repository, cloneURL string) error {
+	cfg, err := clone.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Remotes["origin"].URLs = []string{cloneURL}
+	return clone.SetConfig(cfg)
+}
+
+// Push pushes refspecs of the repository at dir to the Repo registered under the URL of
+// its remote. Like pushes to a local remote, it needs the git binary.
+func (r *Remotes) Push(dir, remote string, refSpecs []string) error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+	rem, err := repo.Remote(remote)
+	if err != nil {
+		return err
+	}
+	pushURL := rem.Config().URLs[0]
+
+	r.mu.Lock()
+	target, ok := r.repos[pushURL]
+	for _, spec := range refSpecs {
+		r.pushes = append(r.pushes, pushURL+" "+spec)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("sharfertest: no remote at %s", pushURL)
+	}
+
+	specs := make([]config.RefSpec, len(refSpecs))
+	for i, spec := range refSpecs {
+		specs[i] = config.RefSpec(spec)
+	}
+	return repo.Push(&git.PushOptions{RemoteName: remote, RemoteURL: target.Dir, RefSpecs: specs})
+}
@@ -0,0 +1,119 @@
+// Package sharfertest provides helpers for tests of tools running or embedding scharf:
+// temporary git repositories with branches and tags, workflow fixtures, a fake GitHub
+// API server scharf reads through GITHUB_SERVER_URL and GITHUB_API_URL, and in-memory
+// fakes of GitHub, OCI registries and git hosts, so tests run offline. The fakes satisfy
+// the HTTPClient and GitTransport interfaces of scharf.
+package sharfertest
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Repo is a git repository in a temporary directory removed at the end of the test
+type Repo struct {
+	// Dir is where the repository is checked out
+	Dir string
+	Git *git.Repository
+	tb  testing.TB
+}
+
+// NewRepo initializes an empty repository in a temporary directory. Its first commit
+// creates the master branch.
+func NewRepo(tb testing.TB) *Repo {
+	tb.Helper()
+	dir := tb.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		tb.Fatalf("git init: %v", err)
+	}
+	return &Repo{Dir: dir, Git: repo, tb: tb}
+}
+
+// Commit writes files, by their slash-separated path relative to the repository, and
+// commits them on the checked out branch. It returns the SHA of the commit.
+func (r *Repo) Commit(message string, files map[string]string) string {
+	r.tb.Helper()
+	w := r.worktree()
+	// Files are added in order, so the same files always make the same tree.
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := filepath.Join(r.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			r.tb.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(files[name]), 0o644); err != nil {
+			r.tb.Fatalf("write %s: %v", name, err)
+		}
+		if _, err := w.Add(name); err != nil {
+			r.tb.Fatalf("git add %s: %v", name, err)
+		}
+	}
+	hash, err := w.Commit(message, &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "sharfertest", Email: "sharfertest@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.tb.Fatalf("git commit: %v", err)
+	}
+	return hash.String()
+}
+
+// CommitWorkflows commits workflow files, by their name in .github/workflows
+func (r *Repo) CommitWorkflows(workflows map[string]string) string {
+	r.tb.Helper()
+	files := make(map[string]string, len(workflows))
+	for name, content := range workflows {
+		files[".github/workflows/"+name] = content
+	}
+	return r.Commit("update workflows", files)
+}
+
+// Branch creates a branch at the checked out commit and checks it out
+func (r *Repo) Branch(name string) {
+	r.tb.Helper()
+	err := r.worktree().Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Create: true})
+	if err != nil {
+		r.tb.Fatalf("git checkout -b %s: %v", name, err)
+	}
+}
+
+// Checkout checks out an existing branch
+func (r *Repo) Checkout(name string) {
+	r.tb.Helper()
+	if err := r.worktree().Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name)}); err != nil {
+		r.tb.Fatalf("git checkout %s: %v", name, err)
+	}
+}
+
+// Tag creates a lightweight tag at the checked out commit
+func (r *Repo) Tag(name string) {
+	r.tb.Helper()
+	head, err := r.Git.Head()
+	if err != nil {
+		r.tb.Fatalf("git rev-parse HEAD: %v", err)
+	}
+	if _, err := r.Git.CreateTag(name, head.Hash(), nil); err != nil {
+		r.tb.Fatalf("git tag %s: %v", name, err)
+	}
+}
+
+func (r *Repo) worktree() *git.Worktree {
+	r.tb.Helper()
+	w, err := r.Git.Worktree()
+	if err != nil {
+		r.tb.Fatalf("git worktree: %v", err)
+	}
+	return w
+}
@@ -0,0 +1,247 @@
+package sharfertest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// --- Tests for Repo ---
+
+func TestRepo(t *testing.T) {
+	r := NewRepo(t)
+	first := r.CommitWorkflows(map[string]string{"ci.yml": MutableWorkflow})
+	r.Tag("v1")
+	r.Branch("feature")
+	second := r.CommitWorkflows(map[string]string{"ci.yml": PinnedWorkflow})
+	r.Checkout("master")
+
+	content, err := os.ReadFile(filepath.Join(r.Dir, ".github", "workflows", "ci.yml"))
+	if err != nil || string(content) != MutableWorkflow {
+		t.Errorf("expected the workflow of master in the worktree, got %q (err %v)", content, err)
+	}
+	for ref, want := range map[plumbing.ReferenceName]string{
+		plumbing.NewTagReferenceName("v1"):         first,
+		plumbing.NewBranchReferenceName("master"):  first,
+		plumbing.NewBranchReferenceName("feature"): second,
+	} {
+		got, err := r.Git.Reference(ref, true)
+		if err != nil || got.Hash().String() != want {
+			t.Errorf("expected %s at %s, got %v (err %v)", ref, want, got, err)
+		}
+	}
+}
+
+// --- Tests for Workflow ---
+
+func TestWorkflow(t *testing.T) {
+	want := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n"
+	if got := Workflow("actions/checkout@v4"); got != want {
+		t.Errorf("unexpected workflow\n%s", got)
+	}
+}
+
+// --- Tests for GitHub ---
+
+func TestGitHub(t *testing.T) {
+	gh := NewGitHub(t)
+	gh.Tag("actions/checkout", "v4", CheckoutSHA)
+	gh.Commit("actions/checkout", CheckoutSHA, time.Date(2024, 10, 23, 0, 0, 0, 0, time.UTC))
+	gh.Release("actions/checkout", "v4.1.0", "old", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	gh.Release("actions/checkout", "v4.2.2", "notes", time.Date(2024, 10, 23, 0, 0, 0, 0, time.UTC))
+	gh.File("acme/app", "HEAD", ".github/workflows/ci.yml", MutableWorkflow)
+	gh.File("acme/app", "HEAD", ".github/workflows/templates/base.yml", PinnedWorkflow)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/api/v3/repos/actions/checkout/tags", http.StatusOK, `"sha":"` + CheckoutSHA},
+		{"/api/v3/repos/actions/checkout/commits/v4", http.StatusOK, `"date":"2024-10-23T00:00:00Z"`},
+		{"/api/v3/repos/actions/checkout/releases/latest", http.StatusOK, `"tag_name":"v4.2.2"`},
+		{"/api/v3/repos/actions/checkout/releases/tags/v4.1.0", http.StatusOK, `"body":"old"`},
+		{"/api/v3/repos/acme/app/contents/.github/workflows", http.StatusOK, `{"name":"ci.yml","path":".github/workflows/ci.yml","type":"file"},{"name":"templates","path":".github/workflows/templates","type":"dir"}`},
+		{"/api/v3/orgs/acme/repos?type=all&per_page=100&page=1", http.StatusOK, `"name":"app"`},
+		{"/raw/acme/app/HEAD/.github/workflows/ci.yml", http.StatusOK, MutableWorkflow},
+		{"/raw/acme/app/main/.github/workflows/ci.yml", http.StatusNotFound, "Not Found"},
+		{"/api/v3/repos/acme/missing/tags", http.StatusNotFound, "Not Found"},
+	}
+	for _, tc := range tests {
+		resp, err := http.Get(gh.URL + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status || !strings.Contains(string(body), tc.body) {
+			t.Errorf("GET %s = %d %s; want %d with %s", tc.path, resp.StatusCode, body, tc.status, tc.body)
+		}
+	}
+
+	var listing []map[string]any
+	resp, err := http.Get(gh.URL + "/api/v3/orgs/acme/repos?page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil || len(listing) != 0 {
+		t.Errorf("expected the second page to be empty, got %v (err %v)", listing, err)
+	}
+	if got := gh.Requests(); len(got) != len(tests)+1 || got[0] != tests[0].path {
+		t.Errorf("unexpected requests %v", got)
+	}
+}
+
+func TestGitHub_Do(t *testing.T) {
+	gh := NewGitHub(t)
+	gh.Tag("actions/checkout", "v4", CheckoutSHA)
+	gh.File("acme/app", "main", ".github/workflows/ci.yml", MutableWorkflow)
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"https://api.github.com/repos/actions/checkout/tags", http.StatusOK, CheckoutSHA},
+		{"https://raw.githubusercontent.com/acme/app/main/.github/workflows/ci.yml", http.StatusOK, MutableWorkflow},
+		{gh.URL + "/api/v3/repos/actions/checkout/tags", http.StatusOK, CheckoutSHA},
+		{"https://api.github.com/repos/acme/missing/tags", http.StatusNotFound, "Not Found"},
+	}
+	for _, tc := range tests {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := gh.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.status || !strings.Contains(string(body), tc.body) {
+			t.Errorf("GET %s = %d %s; want %d with %s", tc.url, resp.StatusCode, body, tc.status, tc.body)
+		}
+	}
+}
+
+// --- Tests for Registry ---
+
+func TestRegistry(t *testing.T) {
+	const digest = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	reg := NewRegistry()
+	reg.Tag("docker.io/library/alpine", "3.19", digest)
+	reg.Tag("docker.io/library/alpine", "3", digest)
+	reg.Tag("ghcr.io/acme/tool", "1", digest)
+
+	tests := []struct {
+		method string
+		url    string
+		status int
+		want   string
+	}{
+		{http.MethodHead, "https://registry-1.docker.io/v2/library/alpine/manifests/3.19", http.StatusOK, digest},
+		{http.MethodHead, "https://ghcr.io/v2/acme/tool/manifests/" + digest, http.StatusOK, digest},
+		{http.MethodHead, "https://ghcr.io/v2/acme/tool/manifests/2", http.StatusNotFound, ""},
+		{http.MethodGet, "https://registry-1.docker.io/v2/library/alpine/tags/list", http.StatusOK, `"tags":["3","3.19"]`},
+		{http.MethodGet, "https://ghcr.io/v2/acme/missing/tags/list", http.StatusNotFound, "NAME_UNKNOWN"},
+	}
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := reg.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		got := string(body)
+		if tc.method == http.MethodHead {
+			got = resp.Header.Get("Docker-Content-Digest")
+		}
+		if resp.StatusCode != tc.status || !strings.Contains(got, tc.want) {
+			t.Errorf("%s %s = %d %s; want %d with %s", tc.method, tc.url, resp.StatusCode, got, tc.status, tc.want)
+		}
+	}
+	if got := reg.Requests(); len(got) != len(tests) {
+		t.Errorf("unexpected requests %v", got)
+	}
+}
+
+// --- Tests for Remotes ---
+
+func TestRemotes(t *testing.T) {
+	repo := NewRepo(t)
+	repo.CommitWorkflows(map[string]string{"ci.yml": MutableWorkflow})
+	repo.Tag("v1")
+	repo.CommitWorkflows(map[string]string{"ci.yml": PinnedWorkflow})
+
+	remotes := NewRemotes()
+	remotes.Add("https://github.com/acme/app.git", repo)
+
+	for ref, want := range map[string]string{"": PinnedWorkflow, "master": PinnedWorkflow, "v1": MutableWorkflow} {
+		dir := t.TempDir()
+		if err := remotes.Clone(dir, "https://github.com/acme/app.git", ref, []string{".github"}); err != nil {
+			t.Fatalf("Clone(%q) returned error: %v", ref, err)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, ".github", "workflows", "ci.yml"))
+		if err != nil || string(content) != want {
+			t.Errorf("expected the workflow at %q, got %q (err %v)", ref, content, err)
+		}
+	}
+	if err := remotes.Clone(t.TempDir(), "https://github.com/acme/app.git", "nope", nil); err == nil {
+		t.Error("expected an error for a ref that is neither a branch nor a tag")
+	}
+	if err := remotes.Clone(t.TempDir(), "https://github.com/acme/missing.git", "", nil); err == nil {
+		t.Error("expected an error for an unknown remote")
+	}
+	if got := remotes.Clones(); len(got) != 5 {
+		t.Errorf("unexpected clones %v", got)
+	}
+}
+
+func TestRemotes_Push(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("pushing to a local remote needs the git binary")
+	}
+	repo := NewRepo(t)
+	repo.CommitWorkflows(map[string]string{"ci.yml": MutableWorkflow})
+	remotes := NewRemotes()
+	remotes.Add("https://github.com/acme/app.git", repo)
+
+	dir := t.TempDir()
+	if err := remotes.Clone(dir, "https://github.com/acme/app.git", "", nil); err != nil {
+		t.Fatalf("Clone returned error: %v", err)
+	}
+	clone, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := clone.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clone.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("fix"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := remotes.Push(dir, "origin", []string{"+refs/heads/fix:refs/heads/fix"}); err != nil {
+		t.Fatalf("Push returned error: %v", err)
+	}
+	ref, err := repo.Git.Reference(plumbing.NewBranchReferenceName("fix"), true)
+	if err != nil || ref.Hash() != head.Hash() {
+		t.Errorf("expected the fix branch to reach the remote at %s, got %v (err %v)", head.Hash(), ref, err)
+	}
+	if got := remotes.Pushes(); len(got) != 1 || got[0] != "https://github.com/acme/app.git +refs/heads/fix:refs/heads/fix" {
+		t.Errorf("unexpected pushes %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package sharfertest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckoutSHA is the commit actions/checkout@v4 pointed to, for fixtures of pinned actions
+const CheckoutSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+
+var (
+	// MutableWorkflow uses actions by tags, which scharf reports as mutable references
+	MutableWorkflow = Workflow("actions/checkout@v4", "actions/setup-go@v5")
+	// PinnedWorkflow uses an action pinned to a commit SHA, which scharf leaves alone
+	PinnedWorkflow = Workflow("actions/checkout@" + CheckoutSHA + " # v4")
+)
+
+// Workflow returns a workflow run on push whose single job uses the given actions in turn
+func Workflow(uses ...string) string {
+	var b strings.Builder
+	b.WriteString("on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n")
+	for _, u := range uses {
+		fmt.Fprintf(&b, "      - uses: %s\n", u)
+	}
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// siteRepository is the row of a repository in the league table of the posture site
+type siteRepository struct {
+	Name     string
+	Page     string
+	Score    string
+	Matches  int
+	Findings int
+	Critical int
+	High     int
+	Trend    *trendSeries
+	// Records and History hold the issues of the repository in the latest and earlier scans
+	Records []*InventoryRecord
+	History []*Inventory
+}
+
+// siteRepositories groups the records of the latest scan by repository, with the records
+// of each in earlier scans, ordered from most to fewest critical, high and other issues
+func siteRepositories(latest *Inventory, trends *reportTrends) []*siteRepository {
+	byName := map[string]*siteRepository{}
+	var repos []*siteRepository
+	for _, ir := range latest.Records {
+		r := byName[ir.Repository]
+		if r == nil {
+			r = &siteRepository{Name: ir.Repository, Page: "repos/" + teamFileName(ir.Repository) + ".html"}
+			byName[ir.Repository] = r
+			repos = append(repos, r)
+		}
+		r.Records = append(r.Records, ir)
+		r.Matches += len(ir.Locations)
+		r.Findings += len(ir.Findings)
+		for _, f := range ir.Findings {
+			switch f.Severity {
+			case SeverityCritical:
+				r.Critical++
+			case SeverityHigh:
+				r.High++
+			}
+		}
+	}
+
+	// Every repository gets every scan, so scans it had no issues in chart as zero.
+	for _, scan := range latest.History {
+		for _, r := range repos {
+			r.History = append(r.History, &Inventory{ScannedAt: scan.ScannedAt})
+		}
+		for _, ir := range scan.Records {
+			if r := byName[ir.Repository]; r != nil {
+				earlier := r.History[len(r.History)-1]
+				earlier.Records = append(earlier.Records, ir)
+			}
+		}
+	}
+	if trends != nil {
+		for i, s := range trends.Repositories {
+			if r := byName[s.Name]; r != nil {
+				r.Trend = &trends.Repositories[i]
+			}
+		}
+	}
+	for _, r := range repos {
+		if _, ok := pinningScore(r.Records); ok {
+			r.Score = scoreText(r.Records)
+		}
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := repos[i], repos[j]
+		if a.Critical != b.Critical {
+			return a.Critical > b.Critical
+		}
+		if a.High != b.High {
+			return a.High > b.High
+		}
+		if a.Matches+a.Findings != b.Matches+b.Findings {
+			return a.Matches+a.Findings > b.Matches+b.Findings
+		}
+		return a.Name < b.Name
+	})
+	return repos
+}
+
+var siteIndex = template.Must(template.New("index").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Workflow security posture</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.critical { background: #b71c1c; color: #fff; }
+.high { background: #e65100; color: #fff; }
+.sparkline { vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>Workflow security posture</h1>
+<p>{{len .Repositories}} repositories with issues in the scan of {{.Date}}{{with .Trends}}, charted over {{len .Dates}} scans since {{index .Dates 0}}{{end}}.{{with .Score}} Pinning score: {{.}} of action references are pinned to a commit SHA.{{end}}</p>
+<h2>Repositories</h2>
+<table>
+<tr><th>#</th><th>Repository</th>{{if .Scored}}<th>Pinning score</th>{{end}}<th>Critical</th><th>High</th><th>Mutable references</th><th>Findings</th>{{if .Trends}}<th>Trend</th>{{end}}</tr>
+{{- range $i, $r := .Repositories}}
+<tr><td>{{inc $i}}</td><td><a href="{{.Page}}">{{.Name}}</a></td>{{if $.Scored}}<td>{{or .Score "n/a"}}</td>{{end}}<td{{if .Critical}} class="critical"{{end}}>{{.Critical}}</td><td{{if .High}} class="high"{{end}}>{{.High}}</td><td>{{.Matches}}</td><td>{{.Findings}}</td>{{if $.Trends}}<td>{{with .Trend}}{{.Sparkline}}{{end}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- with .Trends}}
+<h2>Rules</h2>
+<table>
+<tr><th>Rule</th><th>Trend</th><th>First</th><th>Latest</th></tr>
+{{- range .Rules}}
+<tr><td>{{.Name}}</td><td>{{.Sparkline}}</td><td>{{.First}}</td><td>{{.Latest}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// generateSite writes a static posture site for the scans, oldest first, into dir: an
+// index ranking the repositories of the latest scan with the trends of all scans, and the
+// HTML report of each repository under repos/. It returns the files written.
+func generateSite(dir string, scans []*Inventory) ([]string, error) {
+	if len(scans) == 0 {
+		return nil, fmt.Errorf("no scan results to generate the site from")
+	}
+	latest := *scans[len(scans)-1]
+	latest.History = scans[:len(scans)-1]
+	trends := inventoryTrends(&latest)
+	repos := siteRepositories(&latest, trends)
+
+	if err := os.MkdirAll(filepath.Join(dir, "repos"), 0755); err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+	var files []string
+	write := func(name string, render func(f *os.File) error) error {
+		file := filepath.Join(dir, name)
+		f, err := os.Create(file)
+		if err != nil {
+			return fmt.Errorf("file error: %w", err)
+		}
+		err = render(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("site %s: %w", file, err)
+		}
+		files = append(files, file)
+		return nil
+	}
+
+	for _, r := range repos {
+		inv := &Inventory{Records: r.Records, ScannedAt: latest.ScannedAt, History: r.History}
+		if err := write(r.Page, func(f *os.File) error { return writeHTMLReport(f, inv) }); err != nil {
+			return files, err
+		}
+	}
+
+	date := "now"
+	if !latest.ScannedAt.IsZero() {
+		date = latest.ScannedAt.Format("2006-01-02")
+	}
+	var score string
+	if _, ok := pinningScore(latest.Records); ok {
+		score = scoreText(latest.Records)
+	}
+	err := write("index.html", func(f *os.File) error {
+		return siteIndex.Execute(f, struct {
+			Date         string
+			Score        string
+			Scored       bool
+			Repositories []*siteRepository
+			Trends       *reportTrends
+		}{date, score, score != "", repos, trends})
+	})
+	if err != nil {
+		return files, err
+	}
+	// GitHub Pages serves the files as they are, without Jekyll processing.
+	if err := write(".nojekyll", func(f *os.File) error { return nil }); err != nil {
+		return files, err
+	}
+	return files, nil
+}
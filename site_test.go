@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Tests for siteRepositories ---
+
+func TestSiteRepositories(t *testing.T) {
+	january := &Inventory{ScannedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Records: []*InventoryRecord{
+		{Repository: "api", Locations: []Match{{Value: "actions/checkout@v4"}, {Value: "actions/setup-go@v5"}}},
+	}}
+	latest := &Inventory{History: []*Inventory{january}, Records: []*InventoryRecord{
+		{Repository: "api", Locations: []Match{{Value: "actions/checkout@v4"}}},
+		{Repository: "web", Findings: []Finding{{Severity: SeverityHigh}}},
+		{Repository: "billing", Findings: []Finding{{Severity: SeverityCritical}, {Severity: SeverityLow}}},
+	}}
+
+	repos := siteRepositories(latest, inventoryTrends(latest))
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "billing,web,api" {
+		t.Fatalf("expected the repositories with the most severe issues first, got %v", names)
+	}
+
+	tests := []struct {
+		repo     *siteRepository
+		page     string
+		critical int
+		high     int
+		trend    []int
+	}{
+		{repos[0], "repos/billing.html", 1, 0, []int{0, 2}},
+		{repos[1], "repos/web.html", 0, 1, []int{0, 1}},
+		{repos[2], "repos/api.html", 0, 0, []int{2, 1}},
+	}
+	for _, tc := range tests {
+		r := tc.repo
+		if r.Page != tc.page || r.Critical != tc.critical || r.High != tc.high {
+			t.Errorf("%s: got page %s, %d critical, %d high", r.Name, r.Page, r.Critical, r.High)
+		}
+		if r.Trend == nil || !slices.Equal(r.Trend.Counts, tc.trend) {
+			t.Errorf("%s: expected trend %v, got %+v", r.Name, tc.trend, r.Trend)
+		}
+		if len(r.History) != 1 {
+			t.Errorf("%s: expected one earlier scan, got %d", r.Name, len(r.History))
+		}
+	}
+	if len(repos[2].History[0].Records) != 1 || len(repos[0].History[0].Records) != 0 {
+		t.Error("expected the earlier scan of each repository to hold its records only")
+	}
+}
+
+// --- Tests for generateSite ---
+
+func TestGenerateSite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "site")
+	scans := []*Inventory{
+		{ScannedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Records: []*InventoryRecord{{Repository: "acme/api", Locations: []Match{{Value: "actions/checkout@v4"}}}}},
+		{ScannedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Records: []*InventoryRecord{{Repository: "acme/api", FilePath: "ci.yml", Findings: []Finding{{Rule: ruleExcessivePermissions, Severity: SeverityCritical}}}}},
+	}
+
+	files, err := generateSite(dir, scans)
+	CheckIfError(err)
+	if len(files) != 3 {
+		t.Fatalf("expected a repository page, the index and .nojekyll, got %v", files)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	CheckIfError(err)
+	for _, want := range []string{`<a href="repos/acme-api.html">acme/api</a>`, "scan of 2026-02-01", "charted over 2 scans since 2026-01-01", ruleExcessivePermissions, `<svg class="sparkline"`} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("expected the index to contain %q:\n%s", want, index)
+		}
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "repos", "acme-api.html"))
+	CheckIfError(err)
+	if !strings.Contains(string(page), "<h2>Trends</h2>") || !strings.Contains(string(page), "ci.yml") {
+		t.Errorf("expected the repository page to report its findings and trends:\n%s", page)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".nojekyll")); err != nil {
+		t.Errorf("expected .nojekyll for GitHub Pages: %v", err)
+	}
+
+	if _, err := generateSite(dir, nil); err == nil {
+		t.Error("expected an error without scan results")
+	}
+}
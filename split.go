@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// unownedTeam collects the records no CODEOWNERS entry covers
+const unownedTeam = "unowned"
+
+// ReportConfig holds settings of the report command
+type ReportConfig struct {
+	// SlackWebhooks maps owners, as written in CODEOWNERS, to the incoming webhook of their
+	// Slack channel. Use "unowned" for workflows nobody owns.
+	SlackWebhooks map[string]string `yaml:"slack_webhooks"`
+}
+
+// splitRenderers are the report formats a split can be written in, with their extension
+var splitRenderers = map[string]struct {
+	ext    string
+	render func(io.Writer, *Inventory) error
+}{
+	"markdown": {".md", writeMarkdownReport},
+	"html":     {".html", writeHTMLReport},
+}
+
+// readInventory reads an inventory exported with --out json
+func readInventory(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+	var inv Inventory
+	if err := json.Unmarshal(content, &inv); err != nil {
+		return nil, fmt.Errorf("json: %s: %w", path, err)
+	}
+	return &inv, nil
+}
+
+// ownersFunc returns the owners of the workflow file of a record
+type ownersFunc func(ir *InventoryRecord) []string
+
+// localOwners finds owners in the CODEOWNERS file of the local repository each workflow
+// belongs to, found above its .github/workflows directory.
+func localOwners() ownersFunc {
+	cache := map[string][]codeownersRule{}
+	return func(ir *InventoryRecord) []string {
+		root := ir.repoRoot()
+		rules, ok := cache[root]
+		if !ok {
+			rules = loadCodeowners(GitRepository{name: ir.Repository, localPath: root}, root)
+			cache[root] = rules
+		}
+		return ownersOf(rules, relativePath(root, ir.FilePath))
+	}
+}
+
+// splitByOwner divides an inventory into one per owner. A workflow with several owners is
+// in the inventory of each, since every one of them can fix it.
+func splitByOwner(inv *Inventory, owners ownersFunc) map[string]*Inventory {
+	teams := map[string]*Inventory{}
+	for _, ir := range inv.Records {
+		names := owners(ir)
+		if len(names) == 0 {
+			names = []string{unownedTeam}
+		}
+		for _, name := range names {
+			if teams[name] == nil {
+				teams[name] = &Inventory{}
+			}
+			teams[name].Records = append(teams[name].Records, ir)
+		}
+	}
+	return teams
+}
+
+// unsafeFileChars matches characters of owner names that do not belong in file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// teamFileName turns an owner such as @mycorp/platform-team into the file name
+// mycorp-platform-team
+func teamFileName(team string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(team, "-"), "-")
+}
+
+// teamReport is the report written for an owner
+type teamReport struct {
+	Team string
+	File string
+	Inv  *Inventory
+}
+
+// writeSplitReports writes a report per owner into dir, in order of owner
+func writeSplitReports(dir, format string, teams map[string]*Inventory) ([]teamReport, error) {
+	renderer, ok := splitRenderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported report format %q. Valid values are markdown, html", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reports []teamReport
+	for _, name := range names {
+		file := filepath.Join(dir, teamFileName(name)+renderer.ext)
+		f, err := os.Create(file)
+		if err != nil {
+			return reports, fmt.Errorf("file error: %w", err)
+		}
+		err = renderer.render(f, teams[name])
+		f.Close()
+		if err != nil {
+			return reports, fmt.Errorf("report %s: %w", file, err)
+		}
+		reports = append(reports, teamReport{Team: name, File: file, Inv: teams[name]})
+	}
+	return reports, nil
+}
+
+// teamMessage summarizes the report of an owner for its Slack channel
+func teamMessage(r teamReport) string {
+	matches, findings := 0, 0
+	for _, ir := range r.Inv.Records {
+		matches += len(ir.Locations)
+		findings += len(ir.Findings)
+	}
+	return fmt.Sprintf("Scharf found %d mutable references and %d other findings in %d workflows owned by %s. Report: %s",
+		matches, findings, len(r.Inv.Records), r.Team, r.File)
+}
+
+// notifyTeams posts the summary of each report to the Slack webhook of its owner. Owners
+// without a webhook are skipped.
+func notifyTeams(reports []teamReport, webhooks map[string]string) error {
+	var errs []error
+	for _, r := range reports {
+		url, ok := webhooks[r.Team]
+		if !ok {
+			logger.Debug("no slack webhook for owner", "owner", r.Team)
+			continue
+		}
+		if err := postSlack(url, teamMessage(r)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Team, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postSlack sends a message to a Slack incoming webhook
+func postSlack(url, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	resp, err := post(webhookHTTP, url, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("http: slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- Tests for splitByOwner ---
+
+func TestSplitByOwner(t *testing.T) {
+	dir := t.TempDir()
+	CheckIfError(os.MkdirAll(workflowDir(dir), 0755))
+	CheckIfError(os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte(`
+* @org/platform
+/.github/workflows/deploy.yml @org/release @org/security
+/.github/workflows/orphan.yml
+`), 0644))
+
+	record := func(name string) *InventoryRecord {
+		return &InventoryRecord{Repository: dir, FilePath: filepath.Join(workflowDir(dir), name)}
+	}
+	inv := &Inventory{Records: []*InventoryRecord{record("ci.yml"), record("deploy.yml"), record("orphan.yml")}}
+
+	teams := splitByOwner(inv, localOwners())
+	expected := map[string][]string{
+		"@org/platform": {"ci.yml"},
+		"@org/release":  {"deploy.yml"},
+		"@org/security": {"deploy.yml"},
+		unownedTeam:     {"orphan.yml"},
+	}
+	if len(teams) != len(expected) {
+		t.Fatalf("expected %d teams, got %v", len(expected), teams)
+	}
+	for team, files := range expected {
+		got := teams[team]
+		if got == nil || len(got.Records) != len(files) {
+			t.Errorf("team %s: expected %v, got %+v", team, files, got)
+			continue
+		}
+		for i, f := range files {
+			if filepath.Base(got.Records[i].FilePath) != f {
+				t.Errorf("team %s: expected %s, got %s", team, f, got.Records[i].FilePath)
+			}
+		}
+	}
+}
+
+// --- Tests for teamFileName ---
+
+func TestTeamFileName(t *testing.T) {
+	for team, expected := range map[string]string{
+		"@mycorp/platform-team": "mycorp-platform-team",
+		"jo@example.com":        "jo-example.com",
+		unownedTeam:             "unowned",
+	} {
+		if got := teamFileName(team); got != expected {
+			t.Errorf("teamFileName(%q) = %q; want %q", team, got, expected)
+		}
+	}
+}
+
+// --- Tests for writeSplitReports ---
+
+func TestWriteSplitReports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	teams := map[string]*Inventory{
+		"@org/web":  {Records: []*InventoryRecord{{Repository: "web", FilePath: "ci.yml", Matches: []string{"actions/checkout@v4"}, Locations: []Match{{Value: "actions/checkout@v4", Line: 3}}}}},
+		unownedTeam: {Records: []*InventoryRecord{{Repository: "misc", FilePath: "build.yml"}}},
+	}
+
+	reports, err := writeSplitReports(dir, "markdown", teams)
+	CheckIfError(err)
+	if len(reports) != 2 || reports[0].Team != "@org/web" || reports[1].Team != unownedTeam {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "org-web.md"))
+	CheckIfError(err)
+	if !strings.Contains(string(content), "actions/checkout@v4") || strings.Contains(string(content), "build.yml") {
+		t.Errorf("report of @org/web holds the wrong records:\n%s", content)
+	}
+
+	if _, err := writeSplitReports(dir, "pdf", teams); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+// --- Tests for notifyTeams ---
+
+func TestNotifyTeams(t *testing.T) {
+	reports := []teamReport{
+		{Team: "@org/web", File: "reports/org-web.md", Inv: &Inventory{Records: []*InventoryRecord{{Locations: []Match{{Line: 1}, {Line: 2}}}}}},
+		{Team: "@org/api", File: "reports/org-api.md", Inv: &Inventory{}},
+	}
+	webhooks := map[string]string{"@org/web": "https://hooks.slack.com/web"}
+
+	var posted []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var msg map[string]string
+		CheckIfError(json.NewDecoder(req.Body).Decode(&msg))
+		posted = append(posted, req.URL.String()+" "+msg["text"])
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	withHTTPClientTransport(transport, func() {
+		CheckIfError(notifyTeams(reports, webhooks))
+	})
+
+	if len(posted) != 1 {
+		t.Fatalf("expected one notification, got %v", posted)
+	}
+	if !strings.HasPrefix(posted[0], "https://hooks.slack.com/web Scharf found 2 mutable references") || !strings.Contains(posted[0], "reports/org-web.md") {
+		t.Errorf("unexpected notification %q", posted[0])
+	}
+
+	failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	withHTTPClientTransport(failing, func() {
+		if err := notifyTeams(reports, webhooks); err == nil || !strings.Contains(err.Error(), "@org/web") {
+			t.Errorf("expected the failing owner in the error, got %v", err)
+		}
+	})
+}
+
+// --- Tests for readInventory ---
+
+func TestReadInventory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.json")
+	CheckIfError(os.WriteFile(path, []byte(`{"findings": [{"repository_name": "web", "actions_file": "/src/web/.github/workflows/ci.yml", "matches": ["actions/checkout@v4"]}]}`), 0644))
+
+	inv, err := readInventory(path)
+	CheckIfError(err)
+	if len(inv.Records) != 1 || inv.Records[0].FilePath != "/src/web/.github/workflows/ci.yml" {
+		t.Errorf("unexpected inventory %+v", inv.Records)
+	}
+}
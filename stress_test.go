@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// --- Fixture generators for pathological workspaces ---
+
+// workflowFixture renders a small but realistic workflow using the given action reference.
+func workflowFixture(i int, ref string) string {
+	return fmt.Sprintf(`name: generated-%d
+on: [push]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: %s
+      - run: echo %d
+`, i, ref, i)
+}
+
+// nestedYAMLFixture renders a mapping nested depth levels deep with a reference at the bottom.
+func nestedYAMLFixture(depth int, ref string) string {
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		b.WriteString(strings.Repeat(" ", i))
+		b.WriteString(fmt.Sprintf("level%d:\n", i))
+	}
+	b.WriteString(strings.Repeat(" ", depth))
+	b.WriteString("uses: " + ref + "\n")
+	return b.String()
+}
+
+// flowNestedYAMLFixture renders depth nested flow sequences, e.g. [[[ref]]].
+func flowNestedYAMLFixture(depth int, ref string) string {
+	return strings.Repeat("[", depth) + ref + strings.Repeat("]", depth)
+}
+
+// aliasBombFixture renders a "billion laughs" style document where each anchor
+// references the previous one several times.
+func aliasBombFixture(levels int) string {
+	var b strings.Builder
+	b.WriteString("a0: &a0 [\"actions/checkout@v4\"]\n")
+	for i := 1; i < levels; i++ {
+		prev := fmt.Sprintf("*a%d", i-1)
+		b.WriteString(fmt.Sprintf("a%d: &a%d [%s, %s, %s, %s, %s, %s, %s, %s, %s]\n", i, i,
+			prev, prev, prev, prev, prev, prev, prev, prev, prev))
+	}
+	return b.String()
+}
+
+// generateWorkspace creates a workspace with the given number of repositories, each
+// holding workflowsPerRepo workflow files, and returns its root directory.
+func generateWorkspace(t testing.TB, repos, workflowsPerRepo int) string {
+	t.Helper()
+	root := t.TempDir()
+
+	for r := 0; r < repos; r++ {
+		repoPath := filepath.Join(root, fmt.Sprintf("repo-%d", r))
+		_, err := git.PlainInit(repoPath, false)
+		CheckIfError(err)
+
+		dir := workflowDir(repoPath)
+		CheckIfError(os.MkdirAll(dir, 0755))
+		for w := 0; w < workflowsPerRepo; w++ {
+			content := workflowFixture(w, "actions/checkout@v4")
+			CheckIfError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("wf-%d.yml", w)), []byte(content), 0644))
+		}
+	}
+
+	return root
+}
+
+// --- Robustness tests ---
+
+func TestScanContent_PathologicalInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// minimum number of matches expected, -1 when the content only has to be survived
+		minMatches int
+	}{
+		{name: "deeply nested block mappings", content: nestedYAMLFixture(200, "actions/checkout@v4"), minMatches: 1},
+		{name: "nesting beyond walker limit", content: nestedYAMLFixture(2*maxYAMLDepth, "actions/checkout@v4"), minMatches: -1},
+		{name: "deeply nested flow sequences", content: flowNestedYAMLFixture(5000, "actions/checkout@v4"), minMatches: -1},
+		{name: "alias expansion bomb", content: aliasBombFixture(10), minMatches: 1},
+		{name: "long single line", content: "uses: " + strings.Repeat("actions/checkout@v4 ", 10000), minMatches: 1},
+	}
+
+	scanner := GitHubWorkFlowScanner{}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			matches, err := scanner.ScanContent([]byte(tc.content), mutableRefRegex)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.minMatches >= 0 && len(matches) < tc.minMatches {
+				t.Errorf("expected at least %d matches, got %d", tc.minMatches, len(matches))
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("scanning took %s, expected well under 5s", elapsed)
+			}
+		})
+	}
+}
+
+// TestScanner_ScanReposLargeWorkspace scans a single repository with 10k workflows.
+func TestScanner_ScanReposLargeWorkspace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping stress test in short mode")
+	}
+
+	const workflows = 10000
+	root := generateWorkspace(t, 1, workflows)
+
+	sc := Scanner{VCS: GitHubVCS{}, FileScanner: GitHubWorkFlowScanner{}}
+	start := time.Now()
+	inv, err := sc.ScanRepos(root, mutableRefRegex, true)
+	if err != nil {
+		t.Fatalf("ScanRepos returned error: %v", err)
+	}
+
+	if len(inv.Records) != workflows {
+		t.Errorf("expected %d records, got %d", workflows, len(inv.Records))
+	}
+	if elapsed := time.Since(start); elapsed > 60*time.Second {
+		t.Errorf("scanning %d workflows took %s", workflows, elapsed)
+	}
+}
+
+// --- Fuzzing ---
+
+// FuzzScanContent makes sure arbitrary input never panics the scanner and that reported
+// positions always point inside the content.
+func FuzzScanContent(f *testing.F) {
+	seeds := []string{
+		"",
+		workflowFixture(0, "actions/checkout@v4"),
+		"a: &x {uses: actions/checkout@v4}\nb:\n  <<: *x\n",
+		"---\nuses: a/b@main\n---\nuses: c/d@dev\n",
+		"uses: actions/checkout@v4\r\n",
+		"key: |\n  uses: actions/checkout@v4\n",
+		flowNestedYAMLFixture(50, "actions/checkout@v4"),
+		aliasBombFixture(4),
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+
+	scanner := GitHubWorkFlowScanner{}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		matches, err := scanner.ScanContent(content, mutableRefRegex)
+		if err != nil {
+			t.Fatalf("ScanContent returned error: %v", err)
+		}
+
+		lines := len(newLineIndex(content).starts)
+		for _, m := range matches {
+			if m.Line < 1 || m.Line > lines || m.Column < 1 {
+				t.Fatalf("match %+v has a position outside of the content (%d lines)", m, lines)
+			}
+		}
+	})
+}
+
+// --- Benchmarks ---
+
+func BenchmarkScanContent_Workflow(b *testing.B) {
+	content := []byte(workflowFixture(0, "actions/checkout@v4"))
+	scanner := GitHubWorkFlowScanner{}
+	for i := 0; i < b.N; i++ {
+		scanner.ScanContent(content, mutableRefRegex)
+	}
+}
+
+func BenchmarkScanRepos_Workspace(b *testing.B) {
+	root := generateWorkspace(b, 10, 100)
+	sc := Scanner{VCS: GitHubVCS{}, FileScanner: GitHubWorkFlowScanner{}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := sc.ScanRepos(root, mutableRefRegex, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
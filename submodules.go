@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// SubmoduleRef tells which submodule holds a file, and the commit the repository pins it to
+type SubmoduleRef struct {
+	// Path is where the submodule sits in the repository, through its parents when nested
+	Path   string `json:"path"`
+	Commit string `json:"commit"`
+}
+
+// gitDir returns the directory holding the objects of the repository: the repository
+// itself when bare, or the directory .git is or points to
+func (g GitRepository) gitDir() string {
+	if g.gitdir != "" {
+		return g.gitdir
+	}
+	dotGit := filepath.Join(g.localPath, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return g.localPath
+	}
+	if info.IsDir() {
+		return dotGit
+	}
+	// Submodules checked out by git hold a .git file pointing to their clone.
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return dotGit
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return dotGit
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.localPath, dir)
+	}
+	return dir
+}
+
+// superproject returns the repository as committed, so its submodules are read at the
+// commits it pins, whether it was scanned from the worktree or the object store
+func superproject(repo Repository) (gitBranchRepository, bool) {
+	if g, ok := repo.(*GitRepository); ok {
+		repo = *g
+	}
+	switch r := repo.(type) {
+	case gitBranchRepository:
+		return r, true
+	case GitRepository:
+		at, err := r.AtBranch("HEAD")
+		if err != nil {
+			logger.Debug("could not read the commit of the worktree. skipping submodules", "repo", r.Name(), "err", err)
+			return gitBranchRepository{}, false
+		}
+		return at.(gitBranchRepository), true
+	}
+	return gitBranchRepository{}, false
+}
+
+// scanSubmodules scans the submodules of the repository checked out at root, as committed
+// at the commit the repository pins each to. They are read from the clones git keeps in
+// the modules directory of the repository, so only submodules initialized with git
+// submodule update are scanned. Their records are tagged with the submodule.
+func (s *Scanner) scanSubmodules(branch string, repo Repository, regex *regexp.Regexp, root string) []*InventoryRecord {
+	// Templates of the repository are scanned from the same repository.
+	if filepath.Clean(root) != filepath.Clean(repo.Location()) {
+		return nil
+	}
+	parent, ok := superproject(repo)
+	if !ok {
+		return nil
+	}
+	content, err := parent.ReadFile(filepath.Join(root, ".gitmodules"))
+	if err != nil {
+		return nil
+	}
+	modules := config.NewModules()
+	if err := modules.Unmarshal(content); err != nil {
+		logger.Warn("could not parse .gitmodules. skipping submodules", "repo", repo.Name(), "err", err)
+		return nil
+	}
+
+	// Submodules are vendored code, not repositories held to the required workflows.
+	sub := *s
+	sub.RequiredWorkflows = nil
+
+	names := make([]string, 0, len(modules.Submodules))
+	for name := range modules.Submodules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var records []*InventoryRecord
+	for _, name := range names {
+		m := modules.Submodules[name]
+		at, commit, err := openSubmodule(parent, m)
+		if err != nil {
+			logger.Warn("could not read submodule. run git submodule update --init to scan it", "repo", repo.Name(), "submodule", m.Path, "err", err)
+			continue
+		}
+		for _, ir := range sub.ScanBranch(branch, at, regex, workflowDir(at.localPath)) {
+			if ir.Submodule == nil {
+				ir.Submodule = &SubmoduleRef{Path: m.Path, Commit: commit}
+			} else {
+				ir.Submodule.Path = path.Join(m.Path, ir.Submodule.Path)
+			}
+			// Files are located relative to the repository, not the submodule.
+			ir.RepoRoot = root
+			records = append(records, ir)
+		}
+	}
+	return records
+}
+
+// openSubmodule returns a submodule as committed at the commit its parent pins it to,
+// along with that commit
+func openSubmodule(parent gitBranchRepository, m *config.Submodule) (gitBranchRepository, string, error) {
+	if err := m.Validate(); err != nil {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: %w", err)
+	}
+	modulesDir := filepath.Join(parent.gitDir(), "modules")
+	dir := filepath.Join(modulesDir, filepath.FromSlash(m.Name))
+	if !isWithin(modulesDir, dir) || dir == modulesDir {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: submodule name %s is outside of %s", m.Name, modulesDir)
+	}
+
+	entry, err := parent.tree.FindEntry(m.Path)
+	if err != nil {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: %s: %w", m.Path, err)
+	}
+	if entry.Mode != filemode.Submodule {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: %s is not a submodule", m.Path)
+	}
+
+	clone, err := git.PlainOpen(dir)
+	if err != nil {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: %w", err)
+	}
+	commit, err := clone.CommitObject(entry.Hash)
+	if err != nil {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: commit %s: %w", entry.Hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return gitBranchRepository{}, "", fmt.Errorf("git error: %w", err)
+	}
+	at := GitRepository{name: parent.Name(), localPath: filepath.Join(parent.localPath, filepath.FromSlash(m.Path)), gitdir: dir}
+	return gitBranchRepository{GitRepository: at, tree: tree}, entry.Hash.String(), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for Scanner.ScanBranch with RecurseSubmodules ---
+
+func TestScanner_ScanBranchRecursesSubmodules(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("adding submodules needs the git binary")
+	}
+
+	lib := t.TempDir()
+	repo, err := git.PlainInit(lib, false)
+	CheckIfError(err)
+	CheckIfError(os.MkdirAll(workflowDir(lib), 0755))
+	CheckIfError(os.WriteFile(filepath.Join(workflowDir(lib), "ci.yml"), []byte("steps:\n  - uses: actions/checkout@v4\n"), 0644))
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	pinned, err := w.Commit("add ci", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "protocol.file.allow=always", "submodule", "--quiet", "add", lib, "vendor/lib"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "add lib"},
+	} {
+		cmd := exec.Command(gitPath, args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	// The worktree of the submodule drifting from the pinned commit does not count.
+	CheckIfError(os.WriteFile(filepath.Join(workflowDir(filepath.Join(root, "vendor", "lib")), "ci.yml"), []byte("steps:\n  - uses: actions/checkout@v5\n"), 0644))
+
+	superproject := GitRepository{name: "app", localPath: root}
+	at, err := superproject.AtBranch("HEAD")
+	CheckIfError(err)
+	for _, repo := range []Repository{superproject, at} {
+		sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, RecurseSubmodules: true}
+		records := sc.ScanBranch("main", repo, mutableRefRegex, workflowDir(root))
+		if len(records) != 1 {
+			t.Fatalf("expected a record of the submodule, got %+v", records)
+		}
+		ir := records[0]
+		if ir.Submodule == nil || ir.Submodule.Path != "vendor/lib" || ir.Submodule.Commit != pinned.String() {
+			t.Errorf("expected the record to be tagged with the submodule, got %+v", ir.Submodule)
+		}
+		if len(ir.Matches) != 1 || ir.Matches[0] != "actions/checkout@v4" || ir.Repository != "app" {
+			t.Errorf("expected the workflow at the pinned commit, got %+v", ir)
+		}
+		if uri := sarifURI(ir); uri != "vendor/lib/.github/workflows/ci.yml" {
+			t.Errorf("expected the file relative to the repository, got %s", uri)
+		}
+	}
+
+	if records := (&Scanner{FileScanner: GitHubWorkFlowScanner{}}).ScanBranch("main", superproject, mutableRefRegex, workflowDir(root)); len(records) != 0 {
+		t.Errorf("expected submodules to be skipped by default, got %+v", records)
+	}
+}
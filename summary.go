@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// pinningDocs explains why actions should be pinned to a commit SHA
+const pinningDocs = "https://docs.github.com/en/actions/security-for-github-actions/security-guides/security-hardening-for-github-actions#using-third-party-actions"
+
+// ruleDocs links rules to documentation on how to resolve their findings
+var ruleDocs = map[string]string{
+	ruleExcessivePermissions:   "https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/controlling-permissions-for-github_token",
+	ruleMissingPermissions:     "https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/controlling-permissions-for-github_token",
+	ruleUndeclaredPermissions:  "https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/controlling-permissions-for-github_token",
+	ruleUnprotectedEnvironment: "https://docs.github.com/en/actions/managing-workflow-runs-and-deployments/managing-deployments/managing-environments-for-deployment#deployment-protection-rules",
+	ruleDeprecated:             "https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/workflow-commands-for-github-actions#environment-files",
+	ruleWorkflowCodeowners:     "https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners",
+	ruleCompositeInjection:     "https://docs.github.com/en/actions/security-for-github-actions/security-guides/security-hardening-for-github-actions#understanding-the-risk-of-script-injections",
+}
+
+// ruleLink renders a rule as a Markdown link to its documentation, if any
+func ruleLink(rule string) string {
+	if url, ok := ruleDocs[rule]; ok {
+		return fmt.Sprintf("[%s](%s)", rule, url)
+	}
+	return rule
+}
+
+// writeStepSummary renders the inventory for the job summary of GitHub Actions: totals and
+// an overview table, followed by a collapsible section per file with its details
+func writeStepSummary(w io.Writer, inv *Inventory) error {
+	var b strings.Builder
+	b.WriteString("## Scharf\n\n")
+
+	matches, findings, files := 0, 0, 0
+	for _, ir := range inv.Records {
+		if len(ir.Locations)+len(ir.Findings) > 0 {
+			files++
+		}
+		matches += len(ir.Locations)
+		findings += len(ir.Findings)
+	}
+	if files == 0 {
+		b.WriteString(":white_check_mark: No mutable references found. Good job!\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, ":warning: **%d** mutable references and **%d** other findings in **%d** files. [Why pin actions?](%s)\n\n",
+		matches, findings, files, pinningDocs)
+	b.WriteString("| File | Mutable references | Findings |\n")
+	b.WriteString("|------|--------------------|----------|\n")
+	for _, ir := range inv.Records {
+		if len(ir.Locations)+len(ir.Findings) > 0 {
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", markdownCell(ir.FilePath), len(ir.Locations), len(ir.Findings))
+		}
+	}
+
+	for _, ir := range inv.Records {
+		if len(ir.Locations)+len(ir.Findings) == 0 {
+			continue
+		}
+		// A blank line after <summary> is needed for GitHub to render the Markdown inside.
+		fmt.Fprintf(&b, "\n<details>\n<summary>%s (%d issues)</summary>\n\n", markdownCell(ir.FilePath), len(ir.Locations)+len(ir.Findings))
+		if len(ir.Locations) > 0 {
+			b.WriteString("| Line | Mutable reference |\n|------|-------------------|\n")
+			for _, loc := range ir.Locations {
+				fmt.Fprintf(&b, "| %d | `%s` |\n", loc.Line, loc.Value)
+			}
+			b.WriteString("\n")
+		}
+		if len(ir.Findings) > 0 {
+			b.WriteString("| Line | Rule | Severity | Message |\n|------|------|----------|---------|\n")
+			for _, f := range ir.Findings {
+				fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", f.Line, ruleLink(f.Rule), f.Severity, markdownCell(f.Message))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("</details>\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// appendStepSummary adds the summary of the inventory to the job summary, when running in
+// GitHub Actions
+func appendStepSummary(inv *Inventory) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return fmt.Errorf("GITHUB_STEP_SUMMARY is not set. Is this running in GitHub Actions?")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("file error: %w", err)
+	}
+	defer f.Close()
+	return writeStepSummary(f, inv)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// --- Tests for writeStepSummary ---
+
+func TestWriteStepSummary(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		var b bytes.Buffer
+		inv := &Inventory{Records: []*InventoryRecord{{FilePath: "ci.yml", Profile: &WorkflowProfile{}}}}
+		if err := writeStepSummary(&b, inv); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), "No mutable references found") {
+			t.Errorf("unexpected summary:\n%s", b.String())
+		}
+	})
+
+	t.Run("issues", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := writeStepSummary(&b, reportFixture()); err != nil {
+			t.Fatal(err)
+		}
+		out := b.String()
+		for _, want := range []string{
+			"**1** mutable references and **1** other findings in **2** files",
+			"| ci.yml | 1 | 0 |",
+			"<details>\n<summary>ci.yml (1 issues)</summary>\n\n| Line | Mutable reference |",
+			"| 7 | `actions/checkout@v4` |",
+			"| 3 | [excessive-permissions](https://docs.github.com/",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("summary is missing %q:\n%s", want, out)
+			}
+		}
+	})
+}
+
+func TestAppendStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("previous step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	if err := appendStepSummary(&Inventory{}); err != nil {
+		t.Fatalf("appendStepSummary returned error: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(content), "previous step\n## Scharf") {
+		t.Errorf("expected the summary to be appended, got:\n%s", content)
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := appendStepSummary(&Inventory{}); err == nil {
+		t.Error("expected error outside of GitHub Actions, got nil")
+	}
+}
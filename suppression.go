@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// suppressionComment matches inline suppressions: `# scharf-ignore: rule[,rule] reason`.
+// A comment at the end of a line silences findings on that line, and a comment on a line
+// of its own those on the next line.
+var suppressionComment = regexp.MustCompile(`#\s*scharf-ignore:\s*([A-Za-z0-9_/,*-]+)[ \t]*(.*)$`)
+
+// Sources of suppressions
+const (
+	suppressionConfig = "config"
+	suppressionInline = "inline"
+)
+
+// Suppression silences the findings of a rule, with the reason they are accepted
+type Suppression struct {
+	// Rule is the rule silenced, or * for every rule
+	Rule string `yaml:"rule" json:"rule"`
+	// Path is a glob of the workflow files it applies to, relative to the repository root.
+	// It applies to every file when empty.
+	Path   string `yaml:"path" json:"path,omitempty"`
+	Reason string `yaml:"reason" json:"reason"`
+}
+
+// silences reports whether the suppression applies to a rule
+func (s Suppression) silences(rule string) bool {
+	for _, r := range strings.Split(s.Rule, ",") {
+		if r == "*" || r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineSuppression is a suppression comment of a workflow file
+type inlineSuppression struct {
+	Suppression
+	// Line is where the comment is written, Target the line whose findings it silences
+	Line   int
+	Target int
+}
+
+// inlineSuppressions finds the suppression comments of a workflow file
+func inlineSuppressions(content []byte) []inlineSuppression {
+	var found []inlineSuppression
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		m := suppressionComment.FindSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		s := inlineSuppression{
+			Suppression: Suppression{Rule: string(line[m[2]:m[3]]), Reason: strings.TrimSpace(string(line[m[4]:m[5]]))},
+			Line:        i + 1,
+			Target:      i + 1,
+		}
+		if len(bytes.TrimSpace(line[:m[0]])) == 0 {
+			s.Target++
+		}
+		found = append(found, s)
+	}
+	return found
+}
+
+// SuppressedFinding is a finding silenced by a suppression, kept so reports can show what
+// was accepted and why
+type SuppressedFinding struct {
+	Finding
+	Reason string `json:"reason"`
+	// Source is config, inline or policy
+	Source string `json:"source"`
+}
+
+// applySuppressions splits the findings of a workflow file into those still reported and
+// those silenced by an inline comment or a suppression of the configuration. relPath is
+// the path of the file from the repository root.
+func applySuppressions(findings []Finding, content []byte, relPath string, config []Suppression) ([]Finding, []SuppressedFinding) {
+	if len(findings) == 0 {
+		return findings, nil
+	}
+	inline := inlineSuppressions(content)
+
+	var kept []Finding
+	var suppressed []SuppressedFinding
+	for _, f := range findings {
+		if s, ok := matchSuppression(f, inline, relPath, config); ok {
+			suppressed = append(suppressed, s)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, suppressed
+}
+
+// matchSuppression finds the suppression silencing a finding, inline comments first. Bans
+// of the action policy are lifted in the configuration only, not by the authors of
+// workflows.
+func matchSuppression(f Finding, inline []inlineSuppression, relPath string, config []Suppression) (SuppressedFinding, bool) {
+	if f.Rule == ruleBannedAction {
+		inline = nil
+	}
+	for _, s := range inline {
+		if f.Line != 0 && s.Target == f.Line && s.silences(f.Rule) {
+			return SuppressedFinding{Finding: f, Reason: s.Reason, Source: suppressionInline}, true
+		}
+	}
+	for _, s := range config {
+		if !s.silences(f.Rule) {
+			continue
+		}
+		if matched, err := path.Match(s.Path, relPath); s.Path == "" || (err == nil && matched) {
+			return SuppressedFinding{Finding: f, Reason: s.Reason, Source: suppressionConfig}, true
+		}
+	}
+	return SuppressedFinding{}, false
+}
+
+// suppressionEntry is an active suppression along with who wrote it and when
+type suppressionEntry struct {
+	Suppression
+	Source string
+	// File and Line locate the suppression in the configuration or a workflow
+	File   string
+	Line   int
+	Author string
+	// Date is when the line was last changed, zero if it is not committed
+	Date time.Time
+}
+
+// listSuppressions gathers the suppressions of the configuration file and of the workflows
+// of the repository checked out at root, attributing each to its author through git blame
+func listSuppressions(root, configPath string) ([]suppressionEntry, error) {
+	entries, err := configSuppressions(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := workflowDir(root)
+	files, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || !isYAMLFile(file.Name()) {
+			continue
+		}
+		p := filepath.Join(dir, file.Name())
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+		for _, s := range inlineSuppressions(content) {
+			entries = append(entries, suppressionEntry{Suppression: s.Suppression, Source: suppressionInline, File: p, Line: s.Line})
+		}
+	}
+
+	blameSuppressions(entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].File != entries[j].File {
+			return entries[i].File < entries[j].File
+		}
+		return entries[i].Line < entries[j].Line
+	})
+	return entries, nil
+}
+
+// configSuppressions reads the suppressions of a configuration file with their line
+func configSuppressions(configPath string) ([]suppressionEntry, error) {
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("yaml: %s: %w", configPath, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := resolveAlias(mappingValue(resolveAlias(doc.Content[0]), "suppressions"))
+	if list == nil {
+		return nil, nil
+	}
+
+	var entries []suppressionEntry
+	for _, item := range list.Content {
+		var s Suppression
+		if err := item.Decode(&s); err != nil {
+			return nil, fmt.Errorf("yaml: %s: %w", configPath, err)
+		}
+		entries = append(entries, suppressionEntry{Suppression: s, Source: suppressionConfig, File: configPath, Line: item.Line})
+	}
+	return entries, nil
+}
+
+// blameSuppressions sets the author and date of each suppression from the last commit that
+// changed its line. Lines that differ from HEAD are left unattributed.
+func blameSuppressions(entries []suppressionEntry) {
+	b := newBlamer()
+	for i := range entries {
+		e := &entries[i]
+		if a := b.author(e.File, e.Line); a != nil {
+			e.Author, e.Date = a.Author, a.Date
+		}
+	}
+}
+
+// suppressionAge renders how long ago a suppression was committed
+func suppressionAge(date, now time.Time) string {
+	if date.IsZero() {
+		return "uncommitted"
+	}
+	days := int(now.Sub(date).Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
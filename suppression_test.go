@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const suppressedWorkflow = `on: push
+jobs:
+  # scharf-ignore: undeclared-permissions read-only repository, no token scopes to restrict
+  build:
+    runs-on: ubuntu-latest
+  deploy: # scharf-ignore: deprecated-syntax,excessive-permissions
+    runs-on: ubuntu-latest
+`
+
+// --- Tests for inlineSuppressions ---
+
+func TestInlineSuppressions(t *testing.T) {
+	got := inlineSuppressions([]byte(suppressedWorkflow))
+	if len(got) != 2 {
+		t.Fatalf("expected 2 suppressions, got %+v", got)
+	}
+	if s := got[0]; s.Rule != "undeclared-permissions" || s.Line != 3 || s.Target != 4 || s.Reason != "read-only repository, no token scopes to restrict" {
+		t.Errorf("unexpected own-line suppression %+v", s)
+	}
+	if s := got[1]; s.Rule != "deprecated-syntax,excessive-permissions" || s.Line != 6 || s.Target != 6 || s.Reason != "" {
+		t.Errorf("unexpected trailing suppression %+v", s)
+	}
+}
+
+// --- Tests for applySuppressions ---
+
+func TestApplySuppressions(t *testing.T) {
+	findings := []Finding{
+		{Rule: ruleUndeclaredPermissions, Line: 4},
+		{Rule: ruleExcessivePermissions, Line: 6},
+		{Rule: ruleUndeclaredPermissions, Line: 6},
+		{Rule: ruleWorkflowCodeowners},
+		{Rule: ruleDeprecated, Line: 9},
+	}
+	config := []Suppression{
+		{Rule: ruleWorkflowCodeowners, Path: ".github/workflows/*.yml", Reason: "owned by the platform team"},
+		{Rule: ruleDeprecated, Path: ".github/workflows/other.yml"},
+	}
+
+	kept, suppressed := applySuppressions(findings, []byte(suppressedWorkflow), ".github/workflows/ci.yml", config)
+	if len(kept) != 2 || kept[0].Line != 6 || kept[1].Rule != ruleDeprecated {
+		t.Errorf("unexpected findings kept %+v", kept)
+	}
+	if len(suppressed) != 3 {
+		t.Fatalf("expected 3 suppressed findings, got %+v", suppressed)
+	}
+	if s := suppressed[0]; s.Source != suppressionInline || !strings.HasPrefix(s.Reason, "read-only") {
+		t.Errorf("unexpected inline suppression %+v", s)
+	}
+	if s := suppressed[2]; s.Source != suppressionConfig || s.Reason != "owned by the platform team" {
+		t.Errorf("unexpected config suppression %+v", s)
+	}
+}
+
+// --- Tests for listSuppressions ---
+
+func TestListSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	CheckIfError(os.MkdirAll(workflowDir(dir), 0755))
+	wfPath := filepath.Join(workflowDir(dir), "ci.yml")
+	CheckIfError(os.WriteFile(wfPath, []byte(suppressedWorkflow), 0644))
+	cfgPath := filepath.Join(dir, defaultConfigFile)
+	CheckIfError(os.WriteFile(cfgPath, []byte("suppressions:\n  - rule: workflow-codeowners\n    reason: legacy\n"), 0644))
+
+	repo, err := git.PlainInit(dir, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".")
+	CheckIfError(err)
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = w.Commit("add suppressions", &git.CommitOptions{Author: &object.Signature{Name: "Jo Doe", Email: "jo@example.com", When: when}})
+	CheckIfError(err)
+
+	// An uncommitted change is not attributed to the last author of the line.
+	CheckIfError(os.WriteFile(wfPath, []byte(strings.Replace(suppressedWorkflow, "deprecated-syntax,", "", 1)), 0644))
+
+	entries, err := listSuppressions(dir, cfgPath)
+	CheckIfError(err)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 suppressions, got %+v", entries)
+	}
+
+	own, trailing, cfg := entries[0], entries[1], entries[2]
+	if cfg.Source != suppressionConfig || cfg.Line != 2 || cfg.Reason != "legacy" || cfg.Author != "Jo Doe <jo@example.com>" || !cfg.Date.Equal(when) {
+		t.Errorf("unexpected config suppression %+v", cfg)
+	}
+	if own.Source != suppressionInline || own.Line != 3 || own.Author != "Jo Doe <jo@example.com>" {
+		t.Errorf("unexpected inline suppression %+v", own)
+	}
+	if trailing.Line != 6 || trailing.Author != "" || !trailing.Date.IsZero() {
+		t.Errorf("expected the changed line to be unattributed, got %+v", trailing)
+	}
+}
+
+// --- Tests for suppressionAge ---
+
+func TestSuppressionAge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		date     time.Time
+		expected string
+	}{
+		{time.Time{}, "uncommitted"},
+		{now.Add(-30 * time.Hour), "1 day"},
+		{now.AddDate(0, 0, -45), "45 days"},
+	} {
+		if got := suppressionAge(tc.date, now); got != tc.expected {
+			t.Errorf("suppressionAge(%v) = %q; want %q", tc.date, got, tc.expected)
+		}
+	}
+}
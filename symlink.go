@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ruleSuspiciousSymlink flags workflow files that are symlinks which cannot be followed safely
+const ruleSuspiciousSymlink = "suspicious-symlink"
+
+// SymlinkError reports a path that is a symbolic link which is unsafe to follow
+type SymlinkError struct {
+	Path   string
+	Target string
+	Reason string
+}
+
+func (e *SymlinkError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("symlink %s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("symlink %s -> %s: %s", e.Path, e.Target, e.Reason)
+}
+
+// Finding converts the error to a finding that can be attached to an inventory record
+func (e *SymlinkError) Finding() Finding {
+	return Finding{
+		Rule:     ruleSuspiciousSymlink,
+		Severity: SeverityHigh,
+		Message:  e.Error(),
+	}
+}
+
+// resolveWithinRoot follows symlinks in path and returns the resolved path, as long as
+// it stays within root. Links escaping root, dangling links and link cycles all return
+// a *SymlinkError so callers can surface them instead of silently reading elsewhere.
+func resolveWithinRoot(root, path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		if !isSymlink(path) {
+			return "", fmt.Errorf("os: %w", err)
+		}
+		target, _ := os.Readlink(path)
+		switch {
+		case os.IsNotExist(err):
+			return "", &SymlinkError{Path: path, Target: target, Reason: "link target does not exist"}
+		case isLinkCycle(err):
+			return "", &SymlinkError{Path: path, Target: target, Reason: "link cycle detected"}
+		}
+		return "", fmt.Errorf("os: %w", err)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("os: %w", err)
+	}
+
+	if !isWithin(realRoot, resolved) {
+		return "", &SymlinkError{Path: path, Target: resolved, Reason: "link points outside of repository root"}
+	}
+
+	return resolved, nil
+}
+
+// isLinkCycle reports whether following links failed on a cycle: the OS refuses with ELOOP,
+// and filepath.EvalSymlinks gives up after too many links of its own
+func isLinkCycle(err error) bool {
+	return errors.Is(err, syscall.ELOOP) || strings.Contains(err.Error(), "too many links")
+}
+
+// isSymlink reports whether path itself is a symbolic link
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// isWithin reports whether path is root or lies beneath it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// symlinkOrSkip creates a symlink or skips the test on platforms where that needs privileges.
+func symlinkOrSkip(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+// --- Tests for resolveWithinRoot ---
+
+func TestResolveWithinRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	inside := filepath.Join(root, "ci.yml")
+	CheckIfError(os.WriteFile(inside, []byte("name: ci"), 0644))
+	secret := filepath.Join(outside, "secret.yml")
+	CheckIfError(os.WriteFile(secret, []byte("name: secret"), 0644))
+
+	symlinkOrSkip(t, inside, filepath.Join(root, "inside-link.yml"))
+	symlinkOrSkip(t, secret, filepath.Join(root, "outside-link.yml"))
+	symlinkOrSkip(t, filepath.Join(root, "missing.yml"), filepath.Join(root, "dangling.yml"))
+	symlinkOrSkip(t, filepath.Join(root, "loop-b.yml"), filepath.Join(root, "loop-a.yml"))
+	symlinkOrSkip(t, filepath.Join(root, "loop-a.yml"), filepath.Join(root, "loop-b.yml"))
+	symlinkOrSkip(t, filepath.Join(inside, "child.yml"), filepath.Join(root, "not-dir.yml"))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantSymErr bool
+		wantErr    bool
+	}{
+		{name: "regular file", path: inside},
+		{name: "link inside root", path: filepath.Join(root, "inside-link.yml")},
+		{name: "link outside root", path: filepath.Join(root, "outside-link.yml"), wantSymErr: true},
+		{name: "dangling link", path: filepath.Join(root, "dangling.yml"), wantSymErr: true},
+		{name: "link cycle", path: filepath.Join(root, "loop-a.yml"), wantSymErr: true},
+		{name: "link through a file", path: filepath.Join(root, "not-dir.yml"), wantErr: true},
+		{name: "missing regular file", path: filepath.Join(root, "nope.yml"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolveWithinRoot(root, tc.path)
+			var symErr *SymlinkError
+			isSymErr := errors.As(err, &symErr)
+
+			if tc.wantSymErr != isSymErr {
+				t.Fatalf("resolveWithinRoot(%q) error = %v; want SymlinkError: %v", tc.path, err, tc.wantSymErr)
+			}
+			if !tc.wantSymErr && tc.wantErr != (err != nil) {
+				t.Fatalf("resolveWithinRoot(%q) error = %v; want error: %v", tc.path, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// --- Tests for symlink handling while scanning ---
+
+func TestScanner_ScanBranchReportsUnsafeSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	dir := workflowDir(root)
+	CheckIfError(os.MkdirAll(dir, 0755))
+	CheckIfError(os.WriteFile(filepath.Join(dir, "ci.yml"), []byte("steps:\n  - uses: actions/checkout@v4\n"), 0644))
+
+	external := filepath.Join(outside, "evil.yml")
+	CheckIfError(os.WriteFile(external, []byte("steps:\n  - uses: evil/action@main\n"), 0644))
+	symlinkOrSkip(t, external, filepath.Join(dir, "evil.yml"))
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}}
+	repo := GitRepository{name: "repo", localPath: root}
+	records := sc.ScanBranch("HEAD", repo, mutableRefRegex, dir)
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	for _, ir := range records {
+		switch filepath.Base(ir.FilePath) {
+		case "ci.yml":
+			if len(ir.Matches) != 1 || len(ir.Findings) != 0 {
+				t.Errorf("expected a single match for ci.yml, got %+v", ir)
+			}
+		case "evil.yml":
+			if len(ir.Matches) != 0 {
+				t.Errorf("content behind an unsafe symlink must not be scanned, got %v", ir.Matches)
+			}
+			if len(ir.Findings) != 1 || ir.Findings[0].Rule != ruleSuspiciousSymlink {
+				t.Errorf("expected a %s finding, got %+v", ruleSuspiciousSymlink, ir.Findings)
+			}
+		default:
+			t.Errorf("unexpected record for %s", ir.FilePath)
+		}
+	}
+}
+
+func TestGitHubVCS_ListRepositoriesSkipsUnsafeLinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	CheckIfError(os.Mkdir(filepath.Join(root, "repo1"), 0755))
+	symlinkOrSkip(t, filepath.Join(root, "repo1"), filepath.Join(root, "repo1-alias"))
+	symlinkOrSkip(t, outside, filepath.Join(root, "external"))
+
+	repos, err := GitHubVCS{}.ListRepositories(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 1 || repos[0].Name() != "repo1" {
+		var names []string
+		for _, r := range repos {
+			names = append(names, r.Name())
+		}
+		t.Errorf("expected only repo1 to be listed, got %v", names)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// repoTag is a tag of a repository with the commit it points to and when it was cut: the
+// date of an annotated tag, or of the commit of a lightweight one
+type repoTag struct {
+	Name   string
+	Commit string
+	Date   time.Time
+}
+
+// tagAppearance follows a mutable reference of a workflow across the tags of a repository
+type tagAppearance struct {
+	Uses string `json:"uses"`
+	// File is relative to the root of the repository
+	File      string    `json:"file"`
+	FirstTag  string    `json:"first_tag"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastTag   string    `json:"last_tag"`
+	// Tags lists every tag holding the reference, oldest first
+	Tags []string `json:"tags"`
+	// AtHead tells whether the reference is still committed at HEAD
+	AtHead bool `json:"at_head"`
+}
+
+// listTagsByDate returns the tags of the repository at repoPath, oldest first
+func listTagsByDate(repoPath string) ([]repoTag, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+
+	var tags []repoTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		t := repoTag{Name: ref.Name().Short()}
+		if annotated, err := repo.TagObject(ref.Hash()); err == nil {
+			commit, err := annotated.Commit()
+			if err != nil {
+				// Tags of trees or blobs hold no workflows.
+				logger.Debug("tag does not point to a commit. skipping", "tag", t.Name, "err", err)
+				return nil
+			}
+			t.Commit, t.Date = commit.Hash.String(), annotated.Tagger.When
+		} else {
+			commit, err := repo.CommitObject(ref.Hash())
+			if err != nil {
+				logger.Debug("tag does not point to a commit. skipping", "tag", t.Name, "err", err)
+				return nil
+			}
+			t.Commit, t.Date = commit.Hash.String(), commit.Committer.When
+		}
+		tags = append(tags, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("git error: %w", err)
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.Before(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// TagTimeline scans the workflows of every tag of a repository, read from the object
+// store, and tells for each mutable reference the tag it first appeared in, the tags
+// holding it and whether HEAD still does. Earliest references come first.
+func (s *Scanner) TagTimeline(repo GitRepository, regex *regexp.Regexp) ([]*tagAppearance, error) {
+	tags, err := listTagsByDate(repo.localPath)
+	if err != nil {
+		return nil, err
+	}
+	if s.FileScanner == nil {
+		s.FileScanner = GitHubWorkFlowScanner{}
+	}
+
+	// references returns the mutable references of the repository at a revision, once each
+	references := func(rev string) (map[[2]string]bool, error) {
+		at, err := repo.AtBranch(rev)
+		if err != nil {
+			return nil, err
+		}
+		found := map[[2]string]bool{}
+		for _, ir := range s.ScanBranch(rev, at, regex, workflowDir(repo.localPath)) {
+			for _, loc := range ir.Locations {
+				found[[2]string{loc.Value, relativePath(repo.localPath, ir.FilePath)}] = true
+			}
+		}
+		return found, nil
+	}
+
+	timeline := []*tagAppearance{}
+	byKey := map[[2]string]*tagAppearance{}
+	for _, tag := range tags {
+		found, err := references(tag.Commit)
+		if err != nil {
+			logger.Warn("could not read tag. skipping to next tag", "tag", tag.Name, "err", err)
+			continue
+		}
+		for key := range found {
+			a, ok := byKey[key]
+			if !ok {
+				a = &tagAppearance{Uses: key[0], File: key[1], FirstTag: tag.Name, FirstSeen: tag.Date}
+				byKey[key] = a
+				timeline = append(timeline, a)
+			}
+			a.LastTag = tag.Name
+			a.Tags = append(a.Tags, tag.Name)
+		}
+	}
+
+	head, err := references("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range timeline {
+		a.AtHead = head[[2]string{a.Uses, a.File}]
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		if !timeline[i].FirstSeen.Equal(timeline[j].FirstSeen) {
+			return timeline[i].FirstSeen.Before(timeline[j].FirstSeen)
+		}
+		if timeline[i].Uses != timeline[j].Uses {
+			return timeline[i].Uses < timeline[j].Uses
+		}
+		return timeline[i].File < timeline[j].File
+	})
+	return timeline, nil
+}
+
+// writeTagTimeline renders a tag timeline as JSON
+func writeTagTimeline(w io.Writer, timeline []*tagAppearance) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(timeline); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for Scanner.TagTimeline ---
+
+func TestScanner_TagTimeline(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	CheckIfError(os.MkdirAll(workflowDir(dir), 0755))
+
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	commit := func(content string, when time.Time) plumbing.Hash {
+		CheckIfError(os.WriteFile(filepath.Join(workflowDir(dir), "ci.yml"), []byte(content), 0644))
+		_, err := w.Add(".github/workflows/ci.yml")
+		CheckIfError(err)
+		h, err := w.Commit("update ci", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: when}})
+		CheckIfError(err)
+		return h
+	}
+
+	first := commit("jobs:\n  a:\n    steps:\n      - uses: actions/checkout@v3\n", day(1))
+	second := commit("jobs:\n  a:\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/cache@v4\n", day(2))
+	commit("jobs:\n  a:\n    steps:\n      - uses: actions/checkout@"+lockedSHA+"\n      - uses: actions/cache@v4\n", day(3))
+	// The annotated tag is dated after the lightweight one, though its commit is older.
+	_, err = repo.CreateTag("v1.1", second, nil)
+	CheckIfError(err)
+	_, err = repo.CreateTag("v1.0", first, &git.CreateTagOptions{Tagger: &object.Signature{Name: "test", Email: "test@example.com", When: day(5)}, Message: "v1.0"})
+	CheckIfError(err)
+
+	tags, err := listTagsByDate(dir)
+	CheckIfError(err)
+	if len(tags) != 2 || tags[0].Name != "v1.1" || tags[1].Name != "v1.0" || tags[1].Commit != first.String() {
+		t.Fatalf("expected tags ordered by the date they were cut, got %+v", tags)
+	}
+
+	timeline, err := (&Scanner{}).TagTimeline(GitRepository{name: "repo", localPath: dir}, mutableRefRegex)
+	CheckIfError(err)
+	if len(timeline) != 2 {
+		t.Fatalf("expected 2 references, got %+v", timeline)
+	}
+	// References first seen in the same tag are ordered by name.
+	cache, checkout := timeline[0], timeline[1]
+	if checkout.Uses != "actions/checkout@v3" || checkout.File != ".github/workflows/ci.yml" || checkout.FirstTag != "v1.1" || checkout.LastTag != "v1.0" ||
+		!slices.Equal(checkout.Tags, []string{"v1.1", "v1.0"}) || checkout.AtHead {
+		t.Errorf("unexpected timeline of actions/checkout@v3 %+v", checkout)
+	}
+	if cache.Uses != "actions/cache@v4" || !cache.FirstSeen.Equal(day(2)) || !slices.Equal(cache.Tags, []string{"v1.1"}) || !cache.AtHead {
+		t.Errorf("unexpected timeline of actions/cache@v4 %+v", cache)
+	}
+
+	var b bytes.Buffer
+	CheckIfError(writeTagTimeline(&b, timeline))
+	if !strings.Contains(b.String(), `"first_tag": "v1.1"`) || !strings.Contains(b.String(), `"at_head": true`) {
+		t.Errorf("unexpected JSON timeline %s", b.String())
+	}
+}
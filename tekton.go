@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tektonDirs hold the Tekton resources of a repository: .tekton for Pipelines as Code, and
+// tekton by convention
+var tektonDirs = []string{".tekton", "tekton"}
+
+const (
+	// ruleUnpinnedBundle flags Tekton tasks and pipelines taken from a bundle not pinned to a digest
+	ruleUnpinnedBundle = "unpinned-bundle"
+	// ruleClusterTask flags Tekton tasks resolved by name from the cluster
+	ruleClusterTask = "cluster-task"
+)
+
+// TektonChecker flags the Tekton resources of a directory referencing tasks and pipelines
+// through OCI bundles not pinned to a digest, or cluster tasks by name, along with step
+// images not pinned to a digest. Referenced tasks run with the service account and the
+// workspaces of the run, so whoever can push the bundle tag or edit the cluster task
+// changes what runs.
+type TektonChecker struct {
+	// Dir is the directory relative to the repository root
+	Dir string
+}
+
+// File is empty: Tekton has no pipeline file, only the resources of the directory
+func (c TektonChecker) File() string {
+	return ""
+}
+
+func (c TektonChecker) Directory() string {
+	return c.Dir
+}
+
+func (c TektonChecker) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	seen, images := map[*yaml.Node]bool{}, map[*yaml.Node]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+
+		switch n.Kind {
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				walk(item)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				switch p.Key.Value {
+				case "taskRef":
+					findings = append(findings, tektonRefFindings(wf, "task", resolveAlias(p.Value))...)
+				case "pipelineRef":
+					findings = append(findings, tektonRefFindings(wf, "pipeline", resolveAlias(p.Value))...)
+				case "steps", "sidecars":
+					for _, step := range containerSteps(p.Value) {
+						findings = append(findings, tektonImageFindings(wf, step.Node, images)...)
+					}
+				case "stepTemplate":
+					findings = append(findings, tektonImageFindings(wf, resolveAlias(p.Value), images)...)
+				}
+				walk(p.Value)
+			}
+		}
+	}
+	for _, root := range wf.roots() {
+		// Other resources may sit next to the Tekton ones in the directory.
+		if v := mappingValue(root, "apiVersion"); v != nil && strings.HasPrefix(v.Value, "tekton.dev/") {
+			walk(root)
+		}
+	}
+	return findings
+}
+
+// tektonImageFindings flags the image of a step, sidecar or step template
+func tektonImageFindings(wf *WorkflowFile, step *yaml.Node, seen map[*yaml.Node]bool) []Finding {
+	img := mappingValue(step, "image")
+	if img == nil || img.Kind != yaml.ScalarNode || img.Value == "" || seen[img] {
+		return nil
+	}
+	seen[img] = true
+	return unpinnedImageFinding(wf, img)
+}
+
+// tektonRefFindings checks a taskRef or pipelineRef, written with the bundle field of older
+// versions of Tekton, with the bundles resolver, or by name with kind ClusterTask or the
+// cluster resolver. Names and bundles set through parameters cannot be told.
+func tektonRefFindings(wf *WorkflowFile, kind string, ref *yaml.Node) []Finding {
+	if ref == nil || ref.Kind != yaml.MappingNode {
+		return nil
+	}
+	params := map[string]*yaml.Node{}
+	if list := resolveAlias(mappingValue(ref, "params")); list != nil && list.Kind == yaml.SequenceNode {
+		for _, param := range list.Content {
+			if name, value := mappingValue(param, "name"), mappingValue(param, "value"); name != nil && value != nil && value.Kind == yaml.ScalarNode {
+				params[name.Value] = value
+			}
+		}
+	}
+	name := mappingValue(ref, "name")
+	if name == nil {
+		name = params["name"]
+	}
+	label := kind
+	if name != nil && name.Value != "" {
+		label += " " + name.Value
+	}
+
+	var resolver, refKind string
+	if r := mappingValue(ref, "resolver"); r != nil {
+		resolver = r.Value
+	}
+	if k := mappingValue(ref, "kind"); k != nil {
+		refKind = k.Value
+	}
+	switch {
+	case mappingValue(ref, "bundle") != nil:
+		return unpinnedBundleFinding(wf, label, mappingValue(ref, "bundle"))
+	case resolver == "bundles":
+		return unpinnedBundleFinding(wf, label, params["bundle"])
+	case resolver == "cluster", resolver == "" && refKind == "ClusterTask":
+		if name == nil || strings.Contains(name.Value, "$") {
+			return nil
+		}
+		return []Finding{wf.finding(ruleClusterTask, SeverityMedium, name, fmt.Sprintf(
+			"%s is resolved by name from the cluster, and changes whenever anyone allowed to edit it does. Reference it through a bundle pinned to a digest", label))}
+	}
+	return nil
+}
+
+// unpinnedBundleFinding flags a bundle reference not pinned to a sha256 digest
+func unpinnedBundleFinding(wf *WorkflowFile, label string, bundle *yaml.Node) []Finding {
+	if bundle == nil || bundle.Kind != yaml.ScalarNode || strings.Contains(bundle.Value, "$") {
+		return nil
+	}
+	ref, err := parseImageRef(bundle.Value)
+	if err != nil || strings.HasPrefix(ref.Digest, "sha256:") {
+		return nil
+	}
+	return []Finding{wf.finding(ruleUnpinnedBundle, SeverityHigh, bundle, fmt.Sprintf(
+		"%s is taken from the bundle %s, which is not pinned to a digest. Ex: %s@sha256:<digest>", label, bundle.Value, bundle.Value))}
+}
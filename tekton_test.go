@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- Tests for TektonChecker.Check ---
+
+func TestTektonChecker_Check(t *testing.T) {
+	content := `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: gcr.io/tekton-releases/catalog/upstream/git-clone:0.9
+          - name: name
+            value: git-clone
+          - name: kind
+            value: task
+    - name: lint
+      taskRef:
+        name: golangci-lint
+        bundle: ghcr.io/acme/tasks:latest
+    - name: test
+      taskRef:
+        name: go-test
+        kind: ClusterTask
+    - name: scan
+      taskRef:
+        resolver: cluster
+        params:
+          - name: name
+            value: trivy
+          - name: kind
+            value: task
+    - name: pinned
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: ghcr.io/acme/tasks@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+          - name: name
+            value: build
+    - name: local
+      taskRef:
+        name: unit
+    - name: param
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: $(params.bundle)
+    - name: inline
+      taskSpec:
+        stepTemplate:
+          image: alpine:3
+        steps:
+          - name: run
+            image: golang:1.22
+        sidecars:
+          - name: db
+            image: postgres@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  steps: ""
+---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    bundle: ghcr.io/acme/pipelines:v1
+`
+	findings := TektonChecker{Dir: ".tekton"}.Check(newWorkflowFile(".tekton/build.yaml", []byte(content)))
+
+	expected := map[int]string{
+		12: ruleUnpinnedBundle + " " + SeverityHigh,
+		20: ruleUnpinnedBundle + " " + SeverityHigh,
+		23: ruleClusterTask + " " + SeverityMedium,
+		30: ruleClusterTask + " " + SeverityMedium,
+		53: ruleUnpinnedImage + " " + SeverityMedium,
+		56: ruleUnpinnedImage + " " + SeverityMedium,
+		74: ruleUnpinnedBundle + " " + SeverityHigh,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Line] != f.Rule+" "+f.Severity {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+	for _, f := range findings {
+		if f.Line == 12 && !strings.Contains(f.Message, "task git-clone") {
+			t.Errorf("expected the task to be named, got %q", f.Message)
+		}
+		if f.Line == 74 && !strings.HasPrefix(f.Message, "pipeline is taken") {
+			t.Errorf("expected a pipeline reference, got %q", f.Message)
+		}
+	}
+}
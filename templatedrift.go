@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleGoldenDrift flags copies of golden workflows that differ from their template
+const ruleGoldenDrift = "golden-drift"
+
+// defaultBranchPlaceholder stands for the default branch in workflow templates
+const defaultBranchPlaceholder = "$default-branch"
+
+// GoldenConfig sets where the golden workflow templates are, checked with --check-golden
+type GoldenConfig struct {
+	// Dir is a directory of a clone of the central repository holding the templates, such
+	// as the workflow-templates directory of the .github repository of an organization
+	Dir string `yaml:"dir"`
+}
+
+// Override blocks are the parts of golden workflows repositories may change. They start
+// with a `# scharf:override-begin <name>` line and end with a `# scharf:override-end` line.
+const (
+	overrideBegin = "# scharf:override-begin"
+	overrideEnd   = "# scharf:override-end"
+)
+
+// GoldenRule flags the differences between workflows and the golden template of the same
+// file name, so platform teams see who changed the standard pipelines. Differences are
+// semantic: comments, formatting, quoting and key order do not count, and neither does
+// pinning an action to the commit of the ref of the template. Override blocks of the
+// workflow replace those of the template before comparing.
+type GoldenRule struct {
+	// Templates maps file names to the content of their template
+	Templates map[string][]byte
+}
+
+// loadGoldenTemplates reads the YAML files of the templates directory, by file name
+func loadGoldenTemplates(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+	templates := map[string][]byte{}
+	for _, e := range entries {
+		if e.IsDir() || !isYAMLFile(e.Name()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("file error: %w", err)
+		}
+		if len(newWorkflowFile(e.Name(), content).roots()) == 0 {
+			return nil, fmt.Errorf("golden workflow %s is not a YAML mapping", e.Name())
+		}
+		templates[e.Name()] = content
+	}
+	return templates, nil
+}
+
+// NewGoldenRule reads the golden templates of a directory
+func NewGoldenRule(dir string) (GoldenRule, error) {
+	templates, err := loadGoldenTemplates(dir)
+	return GoldenRule{Templates: templates}, err
+}
+
+func (r GoldenRule) ID() string {
+	return ruleGoldenDrift
+}
+
+func (r GoldenRule) Check(wf *WorkflowFile) []Finding {
+	name := path.Base(filepath.ToSlash(wf.Path))
+	template, ok := r.Templates[name]
+	roots := wf.roots()
+	if !ok || len(roots) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	goldenDiff(template, wf, func(at *yaml.Node, message string) {
+		findings = append(findings, wf.finding(ruleGoldenDrift, SeverityLow, at, fmt.Sprintf("workflow drifted from the golden %s: %s", name, message)))
+	})
+	return findings
+}
+
+// goldenDiff reports the differences of a workflow from its template, with the override
+// blocks of the workflow kept. It returns the restored template.
+func goldenDiff(template []byte, wf *WorkflowFile, report func(*yaml.Node, string)) []byte {
+	restored := restoreGolden(template, wf.Content)
+	golden := newWorkflowFile(wf.Path, restored).roots()
+	if len(golden) == 0 {
+		// Overrides that break the template are differences like any other.
+		restored = template
+		golden = newWorkflowFile(wf.Path, template).roots()
+	}
+	if roots := wf.roots(); len(roots) > 0 && len(golden) > 0 {
+		diffYAML("", golden[0], roots[0], report)
+	}
+	return restored
+}
+
+// overrideBlocks returns the lines of every override block of content by name. Content
+// with unbalanced markers has none.
+func overrideBlocks(content []byte) map[string][]string {
+	blocks := map[string][]string{}
+	name, open := "", false
+	for _, line := range splitLines(string(content)) {
+		marker := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(marker, overrideBegin):
+			if open {
+				return nil
+			}
+			name, open = strings.TrimSpace(strings.TrimPrefix(marker, overrideBegin)), true
+			blocks[name] = []string{}
+		case marker == overrideEnd:
+			if !open {
+				return nil
+			}
+			open = false
+		case open:
+			blocks[name] = append(blocks[name], line)
+		}
+	}
+	if open {
+		return nil
+	}
+	return blocks
+}
+
+// restoreGolden returns the template with its override blocks replaced by those of the
+// same name in content. Blocks content does not have keep the lines of the template.
+func restoreGolden(template, content []byte) []byte {
+	blocks := overrideBlocks(content)
+	var b strings.Builder
+	skipping := false
+	for _, line := range splitLines(string(template)) {
+		marker := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(marker, overrideBegin):
+			b.WriteString(line)
+			if lines, ok := blocks[strings.TrimSpace(strings.TrimPrefix(marker, overrideBegin))]; ok {
+				b.WriteString(strings.Join(lines, ""))
+				skipping = true
+			}
+		case marker == overrideEnd:
+			b.WriteString(line)
+			skipping = false
+		case !skipping:
+			b.WriteString(line)
+		}
+	}
+	return []byte(b.String())
+}
+
+// GoldenFixer restores workflows that drifted from the golden template of the same file
+// name, keeping their override blocks. The default branch placeholder of templates becomes
+// the branch checked out in the repository.
+type GoldenFixer struct {
+	Templates map[string][]byte
+}
+
+// NewGoldenFixer reads the golden templates of a directory
+func NewGoldenFixer(dir string) (GoldenFixer, error) {
+	templates, err := loadGoldenTemplates(dir)
+	return GoldenFixer{Templates: templates}, err
+}
+
+// Fix replaces the whole workflow with its restored template, if it drifted
+func (f GoldenFixer) Fix(wf *WorkflowFile) ([]TextEdit, error) {
+	template, ok := f.Templates[path.Base(filepath.ToSlash(wf.Path))]
+	if !ok {
+		return nil, nil
+	}
+	drifted := false
+	restored := goldenDiff(template, wf, func(*yaml.Node, string) { drifted = true })
+	if !drifted {
+		return nil, nil
+	}
+
+	if bytes.Contains(restored, []byte(defaultBranchPlaceholder)) {
+		// Workflow files sit three levels below the repository root.
+		branch, err := GetCurrentBranch(filepath.Dir(filepath.Dir(filepath.Dir(wf.Path))))
+		if err != nil {
+			return nil, fmt.Errorf("default branch of %s: %w", wf.Path, err)
+		}
+		restored = bytes.ReplaceAll(restored, []byte(defaultBranchPlaceholder), []byte(strings.TrimPrefix(branch, "refs/heads/")))
+	}
+	return []TextEdit{{Start: 0, End: len(wf.Content), NewText: string(restored)}}, nil
+}
+
+// diffYAML reports every difference of the actual node from the golden one, located at the
+// actual node, or at its parent for what it lacks
+func diffYAML(at string, golden, actual *yaml.Node, report func(*yaml.Node, string)) {
+	golden, actual = resolveAlias(golden), resolveAlias(actual)
+	if golden.Kind != actual.Kind {
+		report(actual, fmt.Sprintf("%s is %s instead of %s", displayPath(at), describeNode(actual), describeNode(golden)))
+		return
+	}
+
+	switch golden.Kind {
+	case yaml.ScalarNode:
+		if !sameScalar(at, golden, actual) {
+			report(actual, fmt.Sprintf("%s is %q instead of %q", displayPath(at), actual.Value, golden.Value))
+		}
+	case yaml.MappingNode:
+		kept := map[string]bool{}
+		for _, gp := range mappingPairs(golden) {
+			kept[gp.Key.Value] = true
+			if v := mappingValue(actual, gp.Key.Value); v != nil {
+				diffYAML(joinPath(at, gp.Key.Value), gp.Value, v, report)
+			} else {
+				report(actual, fmt.Sprintf("%s is missing", displayPath(joinPath(at, gp.Key.Value))))
+			}
+		}
+		for _, cp := range mappingPairs(actual) {
+			if !kept[cp.Key.Value] {
+				report(cp.Key, fmt.Sprintf("%s was added", displayPath(joinPath(at, cp.Key.Value))))
+			}
+		}
+	case yaml.SequenceNode:
+		diffSequence(at, golden, actual, report)
+	}
+}
+
+// diffSequence matches items of sequences by the identity of steps, their id, name or
+// action, so an inserted step is reported alone. Other items are matched by position.
+func diffSequence(at string, golden, actual *yaml.Node, report func(*yaml.Node, string)) {
+	keyed := len(golden.Content) > 0
+	for _, n := range append(append([]*yaml.Node{}, golden.Content...), actual.Content...) {
+		keyed = keyed && itemKey(n) != ""
+	}
+	if !keyed {
+		for i, g := range golden.Content {
+			item := fmt.Sprintf("%s[%d]", at, i)
+			if i < len(actual.Content) {
+				diffYAML(item, g, actual.Content[i], report)
+			} else {
+				report(actual, fmt.Sprintf("%s is missing", displayPath(item)))
+			}
+		}
+		for i := len(golden.Content); i < len(actual.Content); i++ {
+			report(actual.Content[i], fmt.Sprintf("%s[%d] was added", displayPath(at), i))
+		}
+		return
+	}
+
+	copies := map[string]*yaml.Node{}
+	for _, c := range actual.Content {
+		copies[itemKey(c)] = c
+	}
+	kept := map[string]bool{}
+	for _, g := range golden.Content {
+		key := itemKey(g)
+		kept[key] = true
+		item := fmt.Sprintf("%s[%s]", at, key)
+		if c, ok := copies[key]; ok {
+			diffYAML(item, g, c, report)
+		} else {
+			report(actual, fmt.Sprintf("%s is missing", displayPath(item)))
+		}
+	}
+	for _, c := range actual.Content {
+		if key := itemKey(c); !kept[key] {
+			report(c, fmt.Sprintf("%s[%s] was added", displayPath(at), key))
+		}
+	}
+}
+
+// itemKey identifies a step by its id, its name or the action it runs, without the ref
+func itemKey(n *yaml.Node) string {
+	n = resolveAlias(n)
+	for _, key := range []string{"id", "name"} {
+		if v := mappingValue(n, key); v != nil && v.Kind == yaml.ScalarNode {
+			return v.Value
+		}
+	}
+	if v := mappingValue(n, "uses"); v != nil && v.Kind == yaml.ScalarNode {
+		return splitRawAction(v.Value)[0]
+	}
+	return ""
+}
+
+// sameScalar reports whether a scalar of a copy means the same as that of the template.
+// The default branch placeholder of templates matches any branch, and a reference pinned
+// to a commit with the ref of the template as comment matches the ref.
+func sameScalar(at string, golden, actual *yaml.Node) bool {
+	if golden.Value == actual.Value || golden.Value == defaultBranchPlaceholder {
+		return true
+	}
+	if !strings.HasSuffix(at, ".uses") {
+		return false
+	}
+	g, c := splitRawAction(golden.Value), splitRawAction(actual.Value)
+	comment := strings.TrimSpace(strings.TrimPrefix(actual.LineComment, "#"))
+	return g[0] == c[0] && fullSHA.MatchString(c[1]) && comment == g[1]
+}
+
+// joinPath appends a key to a dotted path
+func joinPath(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+// displayPath names the root of the workflow when the path is empty
+func displayPath(at string) string {
+	if at == "" {
+		return "the workflow"
+	}
+	return at
+}
+
+// describeNode names the kind of a node in messages
+func describeNode(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return strconv.Quote(n.Value)
+	}
+}
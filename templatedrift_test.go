@@ -0,0 +1,197 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const goldenCI = `name: CI
+on:
+  push:
+    branches: [$default-branch]
+  pull_request:
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Test
+        run: make test
+      - uses: actions/upload-artifact@v4
+        with:
+          retention-days: 7
+`
+
+// --- Tests for GoldenRule.Check ---
+
+func TestGoldenRule_Check(t *testing.T) {
+	dir := t.TempDir()
+	CheckIfError(os.WriteFile(filepath.Join(dir, "ci.yml"), []byte(goldenCI), 0o644))
+	CheckIfError(os.WriteFile(filepath.Join(dir, "ci.properties.json"), []byte(`{"name": "CI"}`), 0o644))
+	rule, err := NewGoldenRule(dir)
+	CheckIfError(err)
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "same meaning",
+			path: ".github/workflows/ci.yml",
+			content: `# Standard CI
+name: "CI"
+permissions: {contents: read}
+on:
+  pull_request:
+  push: {branches: [main]}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@` + lockedSHA + ` # v4
+      - {name: Test, run: make test}
+      - uses: actions/upload-artifact@v4
+        with: {retention-days: 7}
+`,
+		},
+		{
+			name: "drifted",
+			path: ".github/workflows/ci.yml",
+			content: `name: CI
+on:
+  push:
+    branches: [main]
+jobs:
+  build:
+    runs-on: self-hosted
+    steps:
+      - uses: actions/checkout@v4
+      - name: Deploy
+        run: make deploy
+      - name: Test
+        run: make test
+      - uses: actions/upload-artifact@v4
+        with:
+          retention-days: 90
+`,
+			expected: []string{
+				"on.pull_request is missing",
+				"permissions is missing",
+				`jobs.build.runs-on is "self-hosted" instead of "ubuntu-latest"`,
+				`jobs.build.steps[actions/upload-artifact].with.retention-days is "90" instead of "7"`,
+				"jobs.build.steps[Deploy] was added",
+			},
+		},
+		{
+			name:    "no golden template",
+			path:    ".github/workflows/release.yml",
+			content: "on: push\njobs: {}\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rule.Check(newWorkflowFile(tc.path, []byte(tc.content)))
+			if len(findings) != len(tc.expected) {
+				t.Fatalf("expected %d findings, got %+v", len(tc.expected), findings)
+			}
+			for i, f := range findings {
+				if f.Rule != ruleGoldenDrift || !strings.HasSuffix(f.Message, ": "+tc.expected[i]) || f.Line == 0 {
+					t.Errorf("unexpected finding %+v, expected %q", f, tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+const goldenRelease = `on:
+  push:
+    branches: [$default-branch]
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      # scharf:override-begin build
+      - run: make
+      # scharf:override-end
+      - run: make release
+`
+
+// --- Tests for restoreGolden ---
+
+func TestRestoreGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "override kept",
+			content:  "jobs:\n  # scharf:override-begin build\n      - run: npm ci\n      - run: npm run build\n  # scharf:override-end\n",
+			expected: strings.Replace(goldenRelease, "      - run: make\n", "      - run: npm ci\n      - run: npm run build\n", 1),
+		},
+		{
+			name:     "no override",
+			content:  "on: push\n",
+			expected: goldenRelease,
+		},
+		{
+			name:     "unbalanced markers",
+			content:  "# scharf:override-begin build\n      - run: npm ci\n",
+			expected: goldenRelease,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(restoreGolden([]byte(goldenRelease), []byte(tc.content))); got != tc.expected {
+				t.Errorf("restoreGolden =\n%s\nwant\n%s", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for GoldenFixer.Fix ---
+
+func TestGoldenFixer_Fix(t *testing.T) {
+	root, path := fixRepoFixture(t)
+	repo, err := git.PlainOpen(root)
+	CheckIfError(err)
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	_, err = w.Add(".github/workflows/ci.yml")
+	CheckIfError(err)
+	_, err = w.Commit("add workflow", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	CheckIfError(err)
+
+	release := filepath.Join(filepath.Dir(path), "release.yml")
+	drifted := strings.Replace(strings.Replace(goldenRelease, "$default-branch", "master", 1), "      - run: make\n", "      - run: go build ./...\n", 1)
+	drifted = strings.Replace(drifted, "      - run: make release\n", "      - run: make release\n      - run: curl https://example.com/upload\n", 1)
+	CheckIfError(os.WriteFile(release, []byte(drifted), 0o644))
+
+	fixer := GoldenFixer{Templates: map[string][]byte{"release.yml": []byte(goldenRelease), "ci.yml": []byte(unfixedWorkflow)}}
+	results, err := FixWorkflows(root, []Fixer{fixer}, false, io.Discard)
+	CheckIfError(err)
+	if len(results) != 1 || results[0].Path != release {
+		t.Fatalf("expected only the drifted workflow to be restored, got %+v", results)
+	}
+
+	expected := strings.Replace(strings.Replace(goldenRelease, "$default-branch", "master", 1), "      - run: make\n", "      - run: go build ./...\n", 1)
+	if content, _ := os.ReadFile(release); string(content) != expected {
+		t.Errorf("restored =\n%s\nwant\n%s", content, expected)
+	}
+	if findings := (GoldenRule{Templates: fixer.Templates}).Check(newWorkflowFile(release, []byte(expected))); len(findings) != 0 {
+		t.Errorf("expected no drift once restored, got %+v", findings)
+	}
+}
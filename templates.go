@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// templateMarker matches the Jinja markers of cookiecutter and copier templates: `{{ x }}`
+// expressions, `{% ... %}` statements and `{# ... #}` comments. GitHub expressions are
+// matched too, to be told apart by their `$`.
+var templateMarker = regexp.MustCompile(`\$?\{\{.*?\}\}|\{%.*?%\}|\{#.*?#\}`)
+
+// templateDirNames are top-level directories holding the files a project template renders,
+// besides those whose name is itself templated, like `{{cookiecutter.project_slug}}`
+var templateDirNames = []string{"template"}
+
+// maskTemplates replaces the template markers of content so it parses as YAML. Expressions
+// become placeholders of the same length and statements and comments become spaces, so
+// lines and columns are those of the original content.
+func maskTemplates(content []byte) []byte {
+	return templateMarker.ReplaceAllFunc(content, func(m []byte) []byte {
+		if m[0] == '$' {
+			return m
+		}
+		fill := " "
+		if m[1] == '{' {
+			fill = "x"
+		}
+		return bytes.Repeat([]byte(fill), len(m))
+	})
+}
+
+// templateWorkflowDirs returns the workflow directories of the project templates of the
+// repository checked out at root, which new projects are stamped from
+func templateWorkflowDirs(repo Repository, root string) []string {
+	names, err := repo.ListFiles(root)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, name := range names {
+		if !strings.Contains(name, "{{") && !strings.Contains(name, "{%") && !slices.Contains(templateDirNames, name) {
+			continue
+		}
+		dir := workflowDir(filepath.Join(root, name))
+		if _, err := repo.ListFiles(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
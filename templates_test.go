@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// --- Tests for maskTemplates ---
+
+func TestMaskTemplates(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"name: {{cookiecutter.project_name}}\n", "name: xxxxxxxxxxxxxxxxxxxxxxxxxxxxx\n"},
+		{"{% if cookiecutter.cache == 'y' %}\n", "                                  \n"},
+		{"run: echo {{a}}{{b}} {# note #}\n", "run: echo xxxxxxxxxx           \n"},
+		{"ref: ${{ github.sha }}\n", "ref: ${{ github.sha }}\n"},
+	}
+	for _, tt := range tests {
+		if got := string(maskTemplates([]byte(tt.content))); got != tt.expected {
+			t.Errorf("maskTemplates(%q) = %q, expected %q", tt.content, got, tt.expected)
+		}
+	}
+}
+
+// --- Tests for Scanner.ScanBranch with templates ---
+
+const cookiecutterWorkflow = `name: {{cookiecutter.project_name}} CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+{%- if cookiecutter.use_cache == "y" %}
+      - uses: actions/cache@v4
+{%- endif %}
+      - run: git push origin ${{ github.ref_name }}
+`
+
+func TestScanner_ScanBranchTemplates(t *testing.T) {
+	root := t.TempDir()
+	template := filepath.Join(root, "{{cookiecutter.project_slug}}")
+	CheckIfError(os.MkdirAll(workflowDir(template), 0o755))
+	CheckIfError(os.WriteFile(filepath.Join(workflowDir(template), "ci.yml"), []byte(cookiecutterWorkflow), 0o644))
+	repo := GitRepository{name: "template", localPath: root}
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, Rules: []Rule{PermissionsRule{}}}
+	if records := sc.ScanBranch("main", repo, mutableRefRegex, workflowDir(root)); len(records) != 0 {
+		t.Fatalf("expected templates to be skipped by default, got %+v", records)
+	}
+
+	sc.Templates = true
+	records := sc.ScanBranch("main", repo, mutableRefRegex, workflowDir(root))
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %+v", records)
+	}
+	ir := records[0]
+	if ir.FilePath != filepath.Join(workflowDir(template), "ci.yml") {
+		t.Errorf("unexpected file %s", ir.FilePath)
+	}
+	if len(ir.Locations) != 2 || ir.Locations[1].Value != "actions/cache@v4" || ir.Locations[1].Line != 9 {
+		t.Errorf("unexpected matches %+v", ir.Locations)
+	}
+	// Rules run although the template is not valid YAML as it is.
+	if len(ir.Findings) != 1 || ir.Findings[0].Rule != ruleUndeclaredPermissions {
+		t.Errorf("unexpected findings %+v", ir.Findings)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleMissingTimeout flags jobs and long-running steps that are not bounded in time
+const ruleMissingTimeout = "missing-timeout"
+
+// defaultJobTimeout is how long GitHub lets a job without timeout-minutes run, in minutes
+const defaultJobTimeout = 360
+
+// longRunningTools are commands of run steps that can hang on a network, a lock or a test
+// waiting for input
+var longRunningTools = []string{
+	"docker build", "docker buildx build", "docker compose up", "docker-compose up",
+	"terraform apply", "terraform plan", "terraform destroy",
+	"helm install", "helm upgrade", "kubectl rollout status", "kubectl wait",
+	"cypress run", "playwright test", "gradle", "gradlew", "mvn", "sleep",
+}
+
+// TimeoutsConfig sets the thresholds of --check-timeouts
+type TimeoutsConfig struct {
+	// MaxJobMinutes flags jobs whose timeout-minutes is above it. Zero only flags jobs
+	// without timeout-minutes.
+	MaxJobMinutes int `yaml:"max_job_minutes"`
+	// MaxStepMinutes is the job timeout above which steps running long-running tools must
+	// set timeout-minutes of their own. Defaults to 30.
+	MaxStepMinutes int `yaml:"max_step_minutes"`
+	// Tools are more commands flagged as long-running, after the built-in ones
+	Tools []string `yaml:"tools"`
+}
+
+// TimeoutRule flags jobs without timeout-minutes, which run for up to six hours when they
+// hang, holding a runner and billing minutes, and steps running long-running tools in jobs
+// not bounded more tightly than the step threshold.
+type TimeoutRule struct {
+	policy TimeoutsConfig
+	tools  *regexp.Regexp
+}
+
+// NewTimeoutRule creates the rule with the thresholds and tools of the policy
+func NewTimeoutRule(policy TimeoutsConfig) TimeoutRule {
+	if policy.MaxStepMinutes <= 0 {
+		policy.MaxStepMinutes = 30
+	}
+	var alternatives []string
+	for _, tool := range append(append([]string{}, longRunningTools...), policy.Tools...) {
+		alternatives = append(alternatives, strings.ReplaceAll(regexp.QuoteMeta(tool), " ", `\s+`))
+	}
+	// Tools are matched as commands, such as ./gradlew, not as parts of words such as sleepy.
+	return TimeoutRule{policy: policy, tools: regexp.MustCompile(`(?m)(?:^|[\s;&|(/])(` + strings.Join(alternatives, "|") + `)(?:$|\s)`)}
+}
+
+func (r TimeoutRule) ID() string {
+	return ruleMissingTimeout
+}
+
+func (r TimeoutRule) Check(wf *WorkflowFile) []Finding {
+	var findings []Finding
+	for _, job := range wf.jobs() {
+		if mappingValue(job.Node, "uses") != nil {
+			// Reusable workflows set the timeouts of their own jobs.
+			continue
+		}
+
+		timeout := mappingValue(job.Node, "timeout-minutes")
+		minutes, known := timeoutMinutes(timeout)
+		switch {
+		case timeout == nil:
+			findings = append(findings, wf.finding(ruleMissingTimeout, SeverityLow, job.Key, fmt.Sprintf(
+				"job %s has no timeout-minutes, so a hung run holds its runner for %d minutes", job.ID, defaultJobTimeout)))
+			minutes, known = defaultJobTimeout, true
+		case known && r.policy.MaxJobMinutes > 0 && minutes > r.policy.MaxJobMinutes:
+			findings = append(findings, wf.finding(ruleMissingTimeout, SeverityLow, timeout, fmt.Sprintf(
+				"job %s has a timeout of %d minutes, above the %d allowed by timeouts.max_job_minutes of the configuration", job.ID, minutes, r.policy.MaxJobMinutes)))
+		}
+		if !known || minutes <= r.policy.MaxStepMinutes {
+			continue
+		}
+
+		steps := resolveAlias(mappingValue(job.Node, "steps"))
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			step = resolveAlias(step)
+			run := mappingValue(step, "run")
+			if run == nil || mappingValue(step, "timeout-minutes") != nil {
+				continue
+			}
+			if m := r.tools.FindStringSubmatch(run.Value); m != nil {
+				findings = append(findings, wf.finding(ruleMissingTimeout, SeverityLow, run, fmt.Sprintf(
+					"step of job %s runs %s without timeout-minutes while the job may run for %d minutes. Ex: timeout-minutes: %d",
+					job.ID, strings.Join(strings.Fields(m[1]), " "), minutes, r.policy.MaxStepMinutes)))
+			}
+		}
+	}
+	return findings
+}
+
+// timeoutMinutes reads a timeout-minutes value. Expressions are not known before a run.
+func timeoutMinutes(n *yaml.Node) (int, bool) {
+	if n == nil {
+		return 0, false
+	}
+	minutes, err := strconv.ParseFloat(n.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(minutes), true
+}
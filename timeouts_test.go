@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+)
+
+// --- Tests for TimeoutRule.Check ---
+
+func TestTimeoutRule_Check(t *testing.T) {
+	content := `jobs:
+  unbounded:
+    steps:
+      - run: make lint
+      - run: |
+          docker  build -t app .
+      - run: ./gradlew test
+        timeout-minutes: 20
+  long:
+    timeout-minutes: 120
+    steps:
+      - run: sleepy-tool && terraform apply -auto-approve
+  short:
+    timeout-minutes: 15
+    steps:
+      - run: terraform apply
+  dynamic:
+    timeout-minutes: ${{ inputs.timeout }}
+    steps:
+      - run: sleep 600
+  call:
+    uses: org/repo/.github/workflows/deploy.yml@main
+  custom:
+    timeout-minutes: 60
+    steps:
+      - run: bazel test //...
+`
+	rule := NewTimeoutRule(TimeoutsConfig{MaxJobMinutes: 90, Tools: []string{"bazel test"}})
+	findings := rule.Check(newWorkflowFile("wf.yml", []byte(content)))
+
+	expected := map[int]bool{
+		2:  true, // no job timeout
+		5:  true, // docker build
+		10: true, // above max_job_minutes
+		12: true, // terraform apply
+		26: true, // configured tool
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for _, f := range findings {
+		if !expected[f.Line] || f.Rule != ruleMissingTimeout || f.Severity != SeverityLow {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mutableRefSeries names the trend of mutable references among the rules
+const mutableRefSeries = "mutable references"
+
+// Size of the sparklines of the HTML report, in pixels
+const (
+	sparklineWidth  = 120
+	sparklineHeight = 24
+)
+
+// loadHistory reads the inventories exported earlier with --out json from the files matching
+// the given globs, oldest first. Files that hold no scan date are dated by their last change.
+func loadHistory(patterns []string) ([]*Inventory, error) {
+	var history []*Inventory
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("history %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+
+			inv, err := readInventory(file)
+			if err != nil {
+				return nil, err
+			}
+			if inv.ScannedAt.IsZero() {
+				info, err := os.Stat(file)
+				if err != nil {
+					return nil, fmt.Errorf("file error: %w", err)
+				}
+				inv.ScannedAt = info.ModTime()
+			}
+			history = append(history, inv)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].ScannedAt.Before(history[j].ScannedAt) })
+	return history, nil
+}
+
+// trendSeries is the number of issues of a repository or rule in each scan, oldest first
+type trendSeries struct {
+	Name   string
+	Counts []int
+}
+
+// First and Latest return the counts of the oldest and the current scan
+func (s trendSeries) First() int  { return s.Counts[0] }
+func (s trendSeries) Latest() int { return s.Counts[len(s.Counts)-1] }
+
+// Sparkline renders the counts as an inline SVG line chart
+func (s trendSeries) Sparkline() template.HTML {
+	return sparkline(s.Counts)
+}
+
+// reportTrends holds the charts of the HTML report
+type reportTrends struct {
+	// Dates are the days of the scans, oldest first
+	Dates        []string
+	Repositories []trendSeries
+	Rules        []trendSeries
+}
+
+// inventoryTrends counts the issues per repository and per rule in the history of an
+// inventory and in the inventory itself. It returns nil without history.
+func inventoryTrends(inv *Inventory) *reportTrends {
+	if len(inv.History) == 0 {
+		return nil
+	}
+	scans := append(append([]*Inventory{}, inv.History...), inv)
+
+	byRepo, byRule := map[string][]int{}, map[string][]int{}
+	add := func(series map[string][]int, name string, scan, n int) {
+		if series[name] == nil {
+			series[name] = make([]int, len(scans))
+		}
+		series[name][scan] += n
+	}
+	trends := &reportTrends{}
+	for i, scan := range scans {
+		date := "now"
+		if !scan.ScannedAt.IsZero() {
+			date = scan.ScannedAt.Format("2006-01-02")
+		}
+		trends.Dates = append(trends.Dates, date)
+
+		for _, ir := range scan.Records {
+			add(byRepo, ir.Repository, i, len(ir.Locations)+len(ir.Findings))
+			if len(ir.Locations) > 0 {
+				add(byRule, mutableRefSeries, i, len(ir.Locations))
+			}
+			for _, f := range ir.Findings {
+				add(byRule, f.Rule, i, 1)
+			}
+		}
+	}
+	trends.Repositories = sortedSeries(byRepo)
+	trends.Rules = sortedSeries(byRule)
+	return trends
+}
+
+// sortedSeries orders series by their latest count, largest first, then by name
+func sortedSeries(counts map[string][]int) []trendSeries {
+	var series []trendSeries
+	for name, c := range counts {
+		series = append(series, trendSeries{Name: name, Counts: c})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Latest() != series[j].Latest() {
+			return series[i].Latest() > series[j].Latest()
+		}
+		return series[i].Name < series[j].Name
+	})
+	return series
+}
+
+// sparkline draws counts as a polyline scaled to the largest count
+func sparkline(counts []int) template.HTML {
+	peak := 1
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	step := 0.0
+	if len(counts) > 1 {
+		step = float64(sparklineWidth-2) / float64(len(counts)-1)
+	}
+
+	points := make([]string, len(counts))
+	for i, n := range counts {
+		x := 1 + step*float64(i)
+		y := 1 + float64(sparklineHeight-2)*(1-float64(n)/float64(peak))
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return template.HTML(fmt.Sprintf(
+		`<svg class="sparkline" width="%d" height="%d" viewBox="0 0 %d %d"><polyline fill="none" stroke="#1565c0" stroke-width="1.5" points="%s"/></svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight, strings.Join(points, " ")))
+}
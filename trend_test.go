@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// --- Tests for loadHistory ---
+
+func TestLoadHistory(t *testing.T) {
+	dir := t.TempDir()
+	CheckIfError(os.WriteFile(filepath.Join(dir, "march.json"), []byte(`{"findings": [], "scanned_at": "2026-03-01T00:00:00Z"}`), 0644))
+	CheckIfError(os.WriteFile(filepath.Join(dir, "january.json"), []byte(`{"findings": [], "scanned_at": "2026-01-01T00:00:00Z"}`), 0644))
+	undated := filepath.Join(dir, "undated.json")
+	CheckIfError(os.WriteFile(undated, []byte(`{"findings": []}`), 0644))
+	february := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	CheckIfError(os.Chtimes(undated, february, february))
+
+	history, err := loadHistory([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "march.json")})
+	CheckIfError(err)
+	if len(history) != 3 {
+		t.Fatalf("expected 3 scans, got %d", len(history))
+	}
+	for i, month := range []time.Month{time.January, time.February, time.March} {
+		if history[i].ScannedAt.Month() != month {
+			t.Errorf("scan %d: expected %s, got %s", i, month, history[i].ScannedAt)
+		}
+	}
+}
+
+// --- Tests for inventoryTrends ---
+
+func TestInventoryTrends(t *testing.T) {
+	if inventoryTrends(&Inventory{}) != nil {
+		t.Error("expected no trends without history")
+	}
+
+	scan := func(day int, records ...*InventoryRecord) *Inventory {
+		return &Inventory{Records: records, ScannedAt: time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)}
+	}
+	inv := scan(3, &InventoryRecord{Repository: "web", Findings: []Finding{{Rule: ruleDeprecated}}})
+	inv.History = []*Inventory{
+		scan(1,
+			&InventoryRecord{Repository: "web", Locations: []Match{{Line: 1}, {Line: 2}}},
+			&InventoryRecord{Repository: "api", Locations: []Match{{Line: 1}}, Findings: []Finding{{Rule: ruleDeprecated}}}),
+		scan(2, &InventoryRecord{Repository: "web", Locations: []Match{{Line: 1}}}),
+	}
+
+	trends := inventoryTrends(inv)
+	if strings.Join(trends.Dates, " ") != "2026-01-01 2026-01-02 2026-01-03" {
+		t.Errorf("unexpected dates %v", trends.Dates)
+	}
+	expectSeries := func(kind string, got []trendSeries, expected map[string][]int, order []string) {
+		if len(got) != len(order) {
+			t.Fatalf("%s: expected %v, got %+v", kind, order, got)
+		}
+		for i, name := range order {
+			if got[i].Name != name || !slices.Equal(got[i].Counts, expected[name]) {
+				t.Errorf("%s %d: expected %s %v, got %+v", kind, i, name, expected[name], got[i])
+			}
+		}
+	}
+	expectSeries("repository", trends.Repositories, map[string][]int{"web": {2, 1, 1}, "api": {2, 0, 0}}, []string{"web", "api"})
+	expectSeries("rule", trends.Rules, map[string][]int{ruleDeprecated: {1, 0, 1}, mutableRefSeries: {3, 1, 0}}, []string{ruleDeprecated, mutableRefSeries})
+}
+
+// --- Tests for sparkline ---
+
+func TestSparkline(t *testing.T) {
+	got := string(sparkline([]int{4, 0, 2}))
+	if !strings.Contains(got, `points="1.0,1.0 60.0,23.0 119.0,12.0"`) {
+		t.Errorf("unexpected sparkline %s", got)
+	}
+}
+
+// --- Tests for writeHTMLReport trends ---
+
+func TestHTMLReportTrends(t *testing.T) {
+	inv := &Inventory{Records: []*InventoryRecord{{Repository: "web", Locations: []Match{{Value: "actions/checkout@v4", Line: 1}}}}}
+	var b bytes.Buffer
+	CheckIfError(writeHTMLReport(&b, inv))
+	if strings.Contains(b.String(), "<h2>Trends</h2>") {
+		t.Error("expected no trends without history")
+	}
+
+	inv.History = []*Inventory{{Records: []*InventoryRecord{{Repository: "web"}}, ScannedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	b.Reset()
+	CheckIfError(writeHTMLReport(&b, inv))
+	if !strings.Contains(b.String(), "<h2>Trends</h2>") || !strings.Contains(b.String(), `<svg class="sparkline"`) {
+		t.Errorf("expected trend charts in the report:\n%s", b.String())
+	}
+}
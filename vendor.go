@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// staleAfter is how long an action repository can go without a push before it is considered
+// unmaintained
+const staleAfter = 365 * 24 * time.Hour
+
+// Maintenance states of the repository of an action
+const (
+	maintenanceActive   = "active"
+	maintenanceStale    = "stale"
+	maintenanceArchived = "archived"
+	maintenanceMissing  = "missing"
+	maintenanceUnknown  = "unknown"
+)
+
+// Verdicts of the vendor due-diligence report
+const (
+	verdictLowRisk  = "Low risk"
+	verdictReview   = "Review needed"
+	verdictHighRisk = "High risk"
+)
+
+// repoActivity is what the API tells about the upkeep of a repository. Exists is false for
+// repositories that were deleted or never existed.
+type repoActivity struct {
+	Exists   bool
+	Archived bool
+	PushedAt time.Time
+	Stars    int
+}
+
+// status returns the maintenance state of the repository at the given time
+func (a repoActivity) status(now time.Time) string {
+	switch {
+	case !a.Exists:
+		return maintenanceMissing
+	case a.Archived:
+		return maintenanceArchived
+	case now.Sub(a.PushedAt) > staleAfter:
+		return maintenanceStale
+	default:
+		return maintenanceActive
+	}
+}
+
+// repoActivityFunc looks up the activity of a repository
+type repoActivityFunc func(owner, name string) (repoActivity, error)
+
+// lookupRepoActivity reads the archived flag, last push and stars of a repository through
+// the API
+func lookupRepoActivity(owner, name string) (repoActivity, error) {
+	resp, err := githubGet(fmt.Sprintf("%s/%s/%s", apiURL, owner, name))
+	if err != nil {
+		return repoActivity{}, fmt.Errorf("http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return repoActivity{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return repoActivity{}, fmt.Errorf("http: looking up %s/%s returned %s", owner, name, resp.Status)
+	}
+
+	var repo struct {
+		Archived bool      `json:"archived"`
+		PushedAt time.Time `json:"pushed_at"`
+		Stars    int       `json:"stargazers_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		return repoActivity{}, fmt.Errorf("json: %w", err)
+	}
+	return repoActivity{Exists: true, Archived: repo.Archived, PushedAt: repo.PushedAt, Stars: repo.Stars}, nil
+}
+
+// vendorDependency is an action or reusable workflow the assessed project depends on
+type vendorDependency struct {
+	Name string
+	// Refs are the distinct references to it, Unpinned how many of them are not a SHA
+	Refs      []string
+	Unpinned  int
+	Workflows []string
+	Status    string
+	PushedAt  time.Time
+}
+
+// vendorDependencies gathers the external actions referenced by the profiled workflows of
+// an inventory, by repository. Docker images are not actions and are left out.
+func vendorDependencies(inv *Inventory) []*vendorDependency {
+	byName, names := map[string]*vendorDependency{}, map[string]bool{}
+	refs, workflows := map[string]map[string]bool{}, map[string]map[string]bool{}
+	for _, ir := range inv.Records {
+		if ir.Profile == nil {
+			continue
+		}
+		for _, uses := range ir.Profile.Actions {
+			if strings.HasPrefix(uses, "docker://") {
+				continue
+			}
+			name := actionName(uses)
+			if byName[name] == nil {
+				byName[name], names[name] = &vendorDependency{Name: name}, true
+				refs[name], workflows[name] = map[string]bool{}, map[string]bool{}
+			}
+			if !refs[name][uses] {
+				refs[name][uses] = true
+				if !pinnedRef.MatchString(uses) {
+					byName[name].Unpinned++
+				}
+			}
+			workflows[name][ir.FilePath] = true
+		}
+	}
+
+	var deps []*vendorDependency
+	for _, name := range sortedKeys(names) {
+		dep := byName[name]
+		dep.Refs = sortedKeys(refs[name])
+		dep.Workflows = sortedKeys(workflows[name])
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// assessMaintenance sets the maintenance status of each dependency. Lookup failures leave
+// the status unknown rather than failing the report.
+func assessMaintenance(deps []*vendorDependency, lookup repoActivityFunc, now time.Time) {
+	for _, dep := range deps {
+		dep.Status = maintenanceUnknown
+		owner, name, ok := strings.Cut(dep.Name, "/")
+		if !ok {
+			continue
+		}
+		activity, err := lookup(owner, name)
+		if err != nil {
+			logger.Warn("could not look up action repository", "action", dep.Name, "err", err)
+			continue
+		}
+		dep.PushedAt = activity.PushedAt
+		dep.Status = activity.status(now)
+	}
+}
+
+// vendorVerdict sums the assessment up, with the reasons behind it. Workflows outsiders can
+// abuse and dependencies nobody maintains are blockers; weaker signals call for a review.
+func vendorVerdict(inv *Inventory, deps []*vendorDependency) (string, []string) {
+	var blockers, concerns []string
+	risks := map[string]int{}
+	for _, row := range riskMatrix(inv) {
+		risks[row.Profile.Risk]++
+	}
+	findings := map[string]int{}
+	for _, ir := range inv.Records {
+		for _, f := range ir.Findings {
+			findings[f.Severity]++
+		}
+	}
+
+	if n := risks[SeverityCritical]; n > 0 {
+		blockers = append(blockers, fmt.Sprintf("%d workflow(s) let outsiders run privileged jobs", n))
+	}
+	if n := findings[SeverityCritical]; n > 0 {
+		blockers = append(blockers, fmt.Sprintf("%d critical finding(s)", n))
+	}
+	unmaintained, stale, unpinned := 0, 0, 0
+	for _, dep := range deps {
+		switch dep.Status {
+		case maintenanceArchived, maintenanceMissing:
+			unmaintained++
+		case maintenanceStale:
+			stale++
+		}
+		unpinned += dep.Unpinned
+	}
+	if unmaintained > 0 {
+		blockers = append(blockers, fmt.Sprintf("%d dependency(ies) archived or deleted", unmaintained))
+	}
+
+	if n := risks[SeverityHigh]; n > 0 {
+		concerns = append(concerns, fmt.Sprintf("%d high-risk workflow(s)", n))
+	}
+	if n := findings[SeverityHigh]; n > 0 {
+		concerns = append(concerns, fmt.Sprintf("%d high finding(s)", n))
+	}
+	if stale > 0 {
+		concerns = append(concerns, fmt.Sprintf("%d dependency(ies) without a push in a year", stale))
+	}
+	if unpinned > 0 {
+		concerns = append(concerns, fmt.Sprintf("%d action reference(s) not pinned to a SHA", unpinned))
+	}
+
+	switch {
+	case len(blockers) > 0:
+		return verdictHighRisk, append(blockers, concerns...)
+	case len(concerns) > 0:
+		return verdictReview, concerns
+	default:
+		return verdictLowRisk, nil
+	}
+}
+
+// writeVendorReport renders the inventory as a due-diligence report on an external project
+func writeVendorReport(w io.Writer, inv *Inventory) error {
+	return renderVendorReport(w, inv, lookupRepoActivity, time.Now())
+}
+
+// renderVendorReport renders a Markdown report assessing a project before adopting it: its
+// verdict, the CI dependencies with their upkeep, and the risky patterns of its workflows
+func renderVendorReport(w io.Writer, inv *Inventory, lookup repoActivityFunc, now time.Time) error {
+	deps := vendorDependencies(inv)
+	assessMaintenance(deps, lookup, now)
+	verdict, reasons := vendorVerdict(inv, deps)
+
+	var b strings.Builder
+	b.WriteString("# Vendor due-diligence report\n")
+	repos := map[string]bool{}
+	for _, ir := range inv.Records {
+		repos[ir.Repository] = true
+	}
+	fmt.Fprintf(&b, "\nProject: %s\n", markdownCell(orNone(sortedKeys(repos))))
+	fmt.Fprintf(&b, "\n## Verdict: %s\n\n", verdict)
+	if len(reasons) == 0 {
+		b.WriteString("No risky pattern was found.\n")
+	}
+	for _, r := range reasons {
+		fmt.Fprintf(&b, "- %s\n", r)
+	}
+	if _, ok := pinningScore(inv.Records); ok {
+		fmt.Fprintf(&b, "\nPinning score: %s of action references are pinned to a commit SHA.\n", scoreText(inv.Records))
+	}
+
+	if len(deps) > 0 {
+		b.WriteString("\n## CI dependencies\n\n")
+		b.WriteString("| Action | Status | Last push | References | Workflows |\n")
+		b.WriteString("|--------|--------|-----------|------------|-----------|\n")
+		for _, dep := range deps {
+			pushed := "n/a"
+			if !dep.PushedAt.IsZero() {
+				pushed = dep.PushedAt.Format(time.DateOnly)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", markdownCell(dep.Name), dep.Status, pushed,
+				markdownCell(strings.Join(dep.Refs, "\n")), markdownCell(strings.Join(dep.Workflows, "\n")))
+		}
+	}
+
+	if rows := riskMatrix(inv); len(rows) > 0 {
+		b.WriteString("\n## Workflow risk matrix\n\n")
+		b.WriteString("| Risk | Workflow | Triggers | Secrets | Permissions | Runners |\n")
+		b.WriteString("|------|----------|----------|---------|-------------|---------|\n")
+		for _, r := range rows {
+			p := r.Profile
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", p.Risk, markdownCell(r.FilePath), markdownCell(orNone(p.Triggers)),
+				markdownCell(orNone(p.Secrets)), markdownCell(p.permissionsSummary()), markdownCell(p.runnerSummary()))
+		}
+	}
+	writeMarkdownRecords(&b, inv.Records, "##")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
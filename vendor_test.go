@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var vendorNow = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func vendorFixture() *Inventory {
+	return &Inventory{Records: []*InventoryRecord{
+		{
+			Repository: "vendor/project",
+			FilePath:   ".github/workflows/ci.yml",
+			Profile: &WorkflowProfile{
+				Triggers:   []string{"push"},
+				ActionRefs: 3, PinnedRefs: 1,
+				Actions: []string{
+					"actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683",
+					"actions/checkout@v4",
+					"old/setup-tool@v1",
+				},
+				Risk: SeverityLow,
+			},
+		},
+		{
+			Repository: "vendor/project",
+			FilePath:   ".github/workflows/release.yml",
+			Profile: &WorkflowProfile{
+				Triggers:   []string{"push"},
+				ActionRefs: 2, PinnedRefs: 0,
+				Actions: []string{"actions/checkout@v4", "docker://alpine:3"},
+				Risk:    SeverityLow,
+			},
+		},
+	}}
+}
+
+func fakeActivity(repos map[string]repoActivity) repoActivityFunc {
+	return func(owner, name string) (repoActivity, error) {
+		if owner == "broken" {
+			return repoActivity{}, errors.New("boom")
+		}
+		return repos[owner+"/"+name], nil
+	}
+}
+
+// --- Tests for vendorDependencies ---
+
+func TestVendorDependencies(t *testing.T) {
+	deps := vendorDependencies(vendorFixture())
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 dependencies, got %d", len(deps))
+	}
+
+	checkout := deps[0]
+	if checkout.Name != "actions/checkout" {
+		t.Errorf("expected actions/checkout first, got %s", checkout.Name)
+	}
+	if len(checkout.Refs) != 2 || checkout.Unpinned != 1 {
+		t.Errorf("expected 2 refs with 1 unpinned, got %v (%d unpinned)", checkout.Refs, checkout.Unpinned)
+	}
+	if len(checkout.Workflows) != 2 {
+		t.Errorf("expected both workflows, got %v", checkout.Workflows)
+	}
+	if deps[1].Name != "old/setup-tool" {
+		t.Errorf("expected old/setup-tool, got %s", deps[1].Name)
+	}
+}
+
+// --- Tests for assessMaintenance ---
+
+func TestAssessMaintenance(t *testing.T) {
+	deps := []*vendorDependency{{Name: "a/active"}, {Name: "a/stale"}, {Name: "a/archived"}, {Name: "a/gone"}, {Name: "broken/repo"}}
+	assessMaintenance(deps, fakeActivity(map[string]repoActivity{
+		"a/active":   {Exists: true, PushedAt: vendorNow.AddDate(0, -1, 0)},
+		"a/stale":    {Exists: true, PushedAt: vendorNow.AddDate(-2, 0, 0)},
+		"a/archived": {Exists: true, Archived: true, PushedAt: vendorNow},
+	}), vendorNow)
+
+	expected := []string{maintenanceActive, maintenanceStale, maintenanceArchived, maintenanceMissing, maintenanceUnknown}
+	for i, dep := range deps {
+		if dep.Status != expected[i] {
+			t.Errorf("%s: expected %s, got %s", dep.Name, expected[i], dep.Status)
+		}
+	}
+}
+
+// --- Tests for vendorVerdict ---
+
+func TestVendorVerdict(t *testing.T) {
+	tests := []struct {
+		name     string
+		inv      *Inventory
+		deps     []*vendorDependency
+		expected string
+		reasons  int
+	}{
+		{
+			name:     "clean",
+			inv:      &Inventory{Records: []*InventoryRecord{{Profile: &WorkflowProfile{Risk: SeverityLow}}}},
+			deps:     []*vendorDependency{{Name: "a/b", Status: maintenanceActive}},
+			expected: verdictLowRisk,
+		},
+		{
+			name:     "stale and unpinned",
+			inv:      &Inventory{Records: []*InventoryRecord{{Profile: &WorkflowProfile{Risk: SeverityLow}}}},
+			deps:     []*vendorDependency{{Name: "a/b", Status: maintenanceStale, Unpinned: 2}},
+			expected: verdictReview,
+			reasons:  2,
+		},
+		{
+			name: "critical workflow and archived dependency",
+			inv: &Inventory{Records: []*InventoryRecord{{
+				Profile:  &WorkflowProfile{Risk: SeverityCritical},
+				Findings: []Finding{{Severity: SeverityHigh}},
+			}}},
+			deps:     []*vendorDependency{{Name: "a/b", Status: maintenanceArchived}},
+			expected: verdictHighRisk,
+			reasons:  3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			verdict, reasons := vendorVerdict(tc.inv, tc.deps)
+			if verdict != tc.expected || len(reasons) != tc.reasons {
+				t.Errorf("expected %s with %d reasons, got %s with %v", tc.expected, tc.reasons, verdict, reasons)
+			}
+		})
+	}
+}
+
+// --- Tests for renderVendorReport ---
+
+func TestRenderVendorReport(t *testing.T) {
+	var b bytes.Buffer
+	err := renderVendorReport(&b, vendorFixture(), fakeActivity(map[string]repoActivity{
+		"actions/checkout": {Exists: true, PushedAt: vendorNow.AddDate(0, 0, -3)},
+		"old/setup-tool":   {Exists: true, Archived: true, PushedAt: vendorNow.AddDate(-3, 0, 0)},
+	}), vendorNow)
+	if err != nil {
+		t.Fatalf("renderVendorReport returned error: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"Project: vendor/project",
+		"## Verdict: High risk",
+		"- 1 dependency(ies) archived or deleted",
+		"- 2 action reference(s) not pinned to a SHA",
+		"Pinning score: 20%",
+		"| actions/checkout | active | 2026-05-29 | actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683<br>actions/checkout@v4 | .github/workflows/ci.yml<br>.github/workflows/release.yml |",
+		"| old/setup-tool | archived | 2023-06-01 | old/setup-tool@v1 | .github/workflows/ci.yml |",
+		"## Workflow risk matrix",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "alpine") {
+		t.Errorf("docker images should not be listed as dependencies:\n%s", out)
+	}
+}
+
+// --- Tests for lookupRepoActivity ---
+
+func TestLookupRepoActivity(t *testing.T) {
+	customTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status, body := http.StatusNotFound, `{"message": "Not Found"}`
+		if req.URL.String() == "https://api.github.com/repos/owner/kept" {
+			status, body = http.StatusOK, `{"archived": true, "pushed_at": "2024-01-02T03:04:05Z"}`
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	withHTTPClientTransport(customTransport, func() {
+		activity, err := lookupRepoActivity("owner", "kept")
+		CheckIfError(err)
+		if !activity.Exists || !activity.Archived || activity.PushedAt.Year() != 2024 {
+			t.Errorf("unexpected activity %+v", activity)
+		}
+
+		activity, err = lookupRepoActivity("owner", "gone")
+		CheckIfError(err)
+		if activity.Exists {
+			t.Errorf("expected a missing repository, got %+v", activity)
+		}
+	})
+}
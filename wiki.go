@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+)
+
+// wikiCloneURL returns the git URL of a GitHub repository's wiki
+func wikiCloneURL(owner, name string) string {
+	return fmt.Sprintf("%s/%s/%s.wiki.git", serverURL, owner, name)
+}
+
+// ScanWiki clones a wiki into a temporary directory and scans its YAML files as well as
+// the YAML code blocks embedded in its pages, as tooling sometimes fetches pipeline
+// snippets from there.
+func ScanWiki(cloneURL, name string, regex *regexp.Regexp) (*Inventory, error) {
+	dir, err := os.MkdirTemp("", "scharf-wiki-")
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := gitTransport.Clone(dir, cloneURL, "", nil); err != nil {
+		return nil, err
+	}
+	fs := osfs.New(dir)
+
+	files, err := listFilesRecursive(fs, "/")
+	if err != nil {
+		return nil, fmt.Errorf("file error: %w", err)
+	}
+
+	var inventory Inventory
+	sc := GitHubWorkFlowScanner{}
+	for _, file := range files {
+		var docs [][]byte
+		switch {
+		case isYAMLFile(file):
+			content, err := readBillyFile(fs, file)
+			if err != nil {
+				return nil, fmt.Errorf("file error: %w", err)
+			}
+			docs = [][]byte{content}
+		case isMarkdownFile(file):
+			content, err := readBillyFile(fs, file)
+			if err != nil {
+				return nil, fmt.Errorf("file error: %w", err)
+			}
+			docs = markdownYAMLBlocks(content)
+		default:
+			continue
+		}
+
+		var matches []Match
+		for _, doc := range docs {
+			found, err := sc.ScanContent(doc, regex)
+			if err != nil {
+				continue
+			}
+			matches = append(matches, found...)
+		}
+
+		if len(matches) > 0 {
+			inventory.Records = append(inventory.Records, &InventoryRecord{
+				Repository: name,
+				Branch:     "wiki",
+				FilePath:   file,
+				Matches:    matchValues(matches),
+				Locations:  matches,
+			})
+		}
+	}
+
+	return &inventory, nil
+}
+
+// listFilesRecursive returns the paths of all regular files under dir, sorted
+func listFilesRecursive(fs billy.Filesystem, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		p := path.Join(dir, e.Name())
+		switch {
+		case e.Name() == ".git":
+			continue
+		case e.IsDir():
+			nested, err := listFilesRecursive(fs, p)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, nested...)
+		case e.Mode()&os.ModeSymlink == 0:
+			files = append(files, p)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// readBillyFile reads a whole file from a billy filesystem
+func readBillyFile(fs billy.Filesystem, name string) ([]byte, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
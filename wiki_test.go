@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// --- Tests for ScanWiki ---
+
+func TestScanWiki(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("cloning from a local path needs the git binary")
+	}
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	CheckIfError(err)
+
+	files := map[string]string{
+		"Home.md":             "# Deploying\n\nUse this job:\n\n```yaml\nsteps:\n  - uses: actions/checkout@v4\n```\n",
+		"snippets/deploy.yml": "steps:\n  - uses: docker/login-action@v3\n",
+		"Other.md":            "No pipelines here, actions/checkout@v4 in prose is ignored.\n",
+	}
+	w, err := repo.Worktree()
+	CheckIfError(err)
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		CheckIfError(os.MkdirAll(filepath.Dir(p), 0755))
+		CheckIfError(os.WriteFile(p, []byte(content), 0644))
+		_, err = w.Add(filepath.ToSlash(name))
+		CheckIfError(err)
+	}
+	_, err = w.Commit("wiki pages", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	CheckIfError(err)
+
+	inv, err := ScanWiki(dir, "owner/repo", mutableRefRegex)
+	if err != nil {
+		t.Fatalf("ScanWiki returned error: %v", err)
+	}
+
+	got := map[string]Match{}
+	for _, ir := range inv.Records {
+		if ir.Branch != "wiki" || ir.Repository != "owner/repo" {
+			t.Errorf("unexpected record identity: %+v", ir)
+		}
+		for _, m := range ir.Locations {
+			got[ir.FilePath] = m
+		}
+	}
+
+	expected := map[string]Match{
+		"/Home.md":             {Value: "actions/checkout@v4", Line: 7, Column: 11},
+		"/snippets/deploy.yml": {Value: "docker/login-action@v3", Line: 2, Column: 11},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected matches in %d files, got %v", len(expected), got)
+	}
+	for file, m := range expected {
+		if got[file] != m {
+			t.Errorf("match in %s = %+v; want %+v", file, got[file], m)
+		}
+	}
+}
+
+func TestWikiCloneURL(t *testing.T) {
+	if got := wikiCloneURL("owner", "repo"); got != "https://github.com/owner/repo.wiki.git" {
+		t.Errorf("wikiCloneURL() = %q", got)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeKey is the YAML merge key used to inline mappings from anchors (<<: *defaults)
+const mergeKey = "<<"
+
+// maxYAMLDepth guards the walkers against pathological nesting
+const maxYAMLDepth = 512
+
+// parseYAMLDocuments decodes every document of a (possibly multi-document) YAML stream.
+// Content of project templates that is only invalid because of its template markers is
+// decoded with the markers masked.
+func parseYAMLDocuments(content []byte) ([]*yaml.Node, error) {
+	docs, err := decodeYAMLDocuments(content)
+	if err != nil && templateMarker.Match(content) {
+		if masked, merr := decodeYAMLDocuments(maskTemplates(content)); merr == nil {
+			return masked, nil
+		}
+	}
+	return docs, err
+}
+
+// decodeYAMLDocuments decodes every document of a YAML stream as it is
+func decodeYAMLDocuments(content []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("yaml: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	return docs, nil
+}
+
+// resolveAlias follows alias nodes until a concrete node is reached
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for depth := 0; n != nil && n.Kind == yaml.AliasNode && depth < maxYAMLDepth; depth++ {
+		n = n.Alias
+	}
+	return n
+}
+
+// yamlPair is a single key/value entry of a mapping after merge keys are applied
+type yamlPair struct {
+	Key   *yaml.Node
+	Value *yaml.Node
+}
+
+// mappingPairs returns the entries of a mapping node with merge keys expanded.
+// Keys defined locally take precedence over merged ones, as per the YAML merge spec.
+func mappingPairs(n *yaml.Node) []yamlPair {
+	return collectPairs(resolveAlias(n), 0)
+}
+
+func collectPairs(n *yaml.Node, depth int) []yamlPair {
+	if n == nil || n.Kind != yaml.MappingNode || depth > maxYAMLDepth {
+		return nil
+	}
+
+	var local, merged []yamlPair
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Kind == yaml.ScalarNode && k.Value == mergeKey && k.ShortTag() == "!!merge" {
+			src := resolveAlias(v)
+			if src == nil {
+				continue
+			}
+			if src.Kind == yaml.SequenceNode {
+				for _, item := range src.Content {
+					merged = append(merged, collectPairs(resolveAlias(item), depth+1)...)
+				}
+			} else {
+				merged = append(merged, collectPairs(src, depth+1)...)
+			}
+			continue
+		}
+		local = append(local, yamlPair{Key: k, Value: v})
+	}
+
+	seen := map[string]bool{}
+	for _, p := range local {
+		seen[p.Key.Value] = true
+	}
+
+	pairs := local
+	for _, p := range merged {
+		if seen[p.Key.Value] {
+			continue
+		}
+		seen[p.Key.Value] = true
+		pairs = append(pairs, p)
+	}
+
+	return pairs
+}
+
+// mappingValue looks up key in a mapping node, honouring merge keys and aliases
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for _, p := range mappingPairs(n) {
+		if p.Key.Value == key {
+			return resolveAlias(p.Value)
+		}
+	}
+	return nil
+}
+
+// walkScalars calls fn once for every scalar node reachable from root.
+// Aliases and merge keys are followed, but a node shared through an anchor is only
+// visited once, so a reference defined in `&defaults` and merged into several jobs
+// yields a single finding, and alias bombs cannot blow up the walk.
+func walkScalars(root *yaml.Node, fn func(n *yaml.Node)) {
+	seen := map[*yaml.Node]bool{}
+
+	var walk func(n *yaml.Node, depth int)
+	walk = func(n *yaml.Node, depth int) {
+		n = resolveAlias(n)
+		if n == nil || depth > maxYAMLDepth || seen[n] {
+			return
+		}
+		seen[n] = true
+
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c, depth+1)
+			}
+		case yaml.MappingNode:
+			for _, p := range mappingPairs(n) {
+				walk(p.Key, depth+1)
+				walk(p.Value, depth+1)
+			}
+		case yaml.ScalarNode:
+			fn(n)
+		}
+	}
+
+	walk(root, 0)
+}
+
+// isYAMLFile reports whether a file name has a YAML extension
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// isMarkdownFile reports whether a file name has a Markdown extension
+func isMarkdownFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// markdownYAMLBlocks extracts fenced ```yaml code blocks from Markdown content. Each block
+// is returned with the lines before it blanked out, so positions of matches found in a
+// block are the positions in the Markdown file.
+func markdownYAMLBlocks(content []byte) [][]byte {
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	var blocks [][]byte
+	var block []string
+	fence, start := "", 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			for _, f := range []string{"```", "~~~"} {
+				lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, f)))
+				if strings.HasPrefix(trimmed, f) && (lang == "yaml" || lang == "yml") {
+					fence, start, block = f, i+1, nil
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, fence) {
+			padding := strings.Repeat("\n", start)
+			blocks = append(blocks, []byte(padding+strings.Join(block, "\n")+"\n"))
+			fence = ""
+			continue
+		}
+		block = append(block, line)
+	}
+
+	return blocks
+}
+
+// WorkflowFile is a workflow parsed once and handed to every rule.
+// Docs is empty when the content is not valid YAML.
+type WorkflowFile struct {
+	Path    string
+	Content []byte
+	Docs    []*yaml.Node
+
+	lines *lineIndex
+}
+
+// newWorkflowFile parses the content of a workflow file
+func newWorkflowFile(path string, content []byte) *WorkflowFile {
+	docs, err := parseYAMLDocuments(content)
+	if err != nil {
+		logger.Debug("workflow is not valid YAML. rules will be skipped", "file", path, "err", err)
+	}
+
+	return &WorkflowFile{
+		Path:    path,
+		Content: content,
+		Docs:    docs,
+		lines:   newLineIndex(content),
+	}
+}
+
+// workflowJob is a job of a workflow along with the node holding its definition
+type workflowJob struct {
+	ID   string
+	Key  *yaml.Node
+	Node *yaml.Node
+	// Matrix holds the combinations of the job matrix, nil without a static matrix
+	Matrix []matrixCombination
+	// Context holds the expression contexts known before a run of the job
+	Context exprContext
+}
+
+// roots returns the top-level mapping of every document
+func (wf *WorkflowFile) roots() []*yaml.Node {
+	var roots []*yaml.Node
+	for _, doc := range wf.Docs {
+		if len(doc.Content) > 0 {
+			if root := resolveAlias(doc.Content[0]); root.Kind == yaml.MappingNode {
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots
+}
+
+// jobs returns the jobs of every document in the file
+func (wf *WorkflowFile) jobs() []workflowJob {
+	var jobs []workflowJob
+	for _, root := range wf.roots() {
+		for _, p := range mappingPairs(mappingValue(root, "jobs")) {
+			if node := resolveAlias(p.Value); node != nil && node.Kind == yaml.MappingNode {
+				jobs = append(jobs, workflowJob{ID: p.Key.Value, Key: p.Key, Node: node, Matrix: jobMatrix(node), Context: staticContext(root, node)})
+			}
+		}
+	}
+	return jobs
+}
+
+// finding creates a finding positioned at the given node
+func (wf *WorkflowFile) finding(rule, severity string, n *yaml.Node, message string) Finding {
+	f := Finding{Rule: rule, Severity: severity, Message: message}
+	if n != nil {
+		f.Line, f.Column = n.Line, n.Column
+	}
+	return f
+}
+
+// usesNodes returns the `uses:` values of every job and step in the file, each node once
+// even when shared through anchors
+func (wf *WorkflowFile) usesNodes() []*yaml.Node {
+	var nodes []*yaml.Node
+	seen := map[*yaml.Node]bool{}
+	add := func(n *yaml.Node) {
+		n = resolveAlias(n)
+		if n != nil && n.Kind == yaml.ScalarNode && !seen[n] {
+			seen[n] = true
+			nodes = append(nodes, n)
+		}
+	}
+
+	for _, job := range wf.jobs() {
+		add(mappingValue(job.Node, "uses"))
+		if steps := resolveAlias(mappingValue(job.Node, "steps")); steps != nil {
+			for _, step := range steps.Content {
+				add(mappingValue(resolveAlias(step), "uses"))
+			}
+		}
+	}
+	return nodes
+}
+
+// imageNode is an image reference of a workflow with the images it names across the
+// combinations of the job matrix
+type imageNode struct {
+	Node   *yaml.Node
+	Values []string
+}
+
+// imageNodes returns the container images referenced by the file: job containers, service
+// containers and `uses: docker://` steps. Values of `uses:` keep their docker:// prefix.
+func (wf *WorkflowFile) imageNodes() []imageNode {
+	var nodes []imageNode
+	seen := map[*yaml.Node]bool{}
+	for _, job := range wf.jobs() {
+		add := func(n *yaml.Node) {
+			n = resolveAlias(n)
+			if n != nil && n.Kind == yaml.MappingNode {
+				n = resolveAlias(mappingValue(n, "image"))
+			}
+			if n != nil && n.Kind == yaml.ScalarNode && n.Value != "" && !seen[n] {
+				seen[n] = true
+				nodes = append(nodes, imageNode{Node: n, Values: expandMatrix(n.Value, job.Context, job.Matrix)})
+			}
+		}
+
+		add(mappingValue(job.Node, "container"))
+		for _, svc := range mappingPairs(resolveAlias(mappingValue(job.Node, "services"))) {
+			add(svc.Value)
+		}
+		if steps := resolveAlias(mappingValue(job.Node, "steps")); steps != nil {
+			for _, step := range steps.Content {
+				if uses := resolveAlias(mappingValue(resolveAlias(step), "uses")); uses != nil && strings.HasPrefix(uses.Value, "docker://") {
+					add(uses)
+				}
+			}
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// --- Tests for parseYAMLDocuments ---
+
+func TestParseYAMLDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+		wantErr  bool
+	}{
+		{name: "empty content", content: "", expected: 0},
+		{name: "single document", content: "name: ci\n", expected: 1},
+		{name: "multiple documents", content: "name: one\n---\nname: two\n---\nname: three\n", expected: 3},
+		{name: "invalid yaml", content: "key: [unterminated", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := parseYAMLDocuments([]byte(tc.content))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(docs) != tc.expected {
+				t.Errorf("parseYAMLDocuments() returned %d documents; want %d", len(docs), tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for mappingValue ---
+
+func TestMappingValue_MergeKeys(t *testing.T) {
+	content := `
+defaults: &defaults
+  runs-on: ubuntu-latest
+  timeout-minutes: 10
+job:
+  <<: *defaults
+  timeout-minutes: 30
+`
+	docs, err := parseYAMLDocuments([]byte(content))
+	CheckIfError(err)
+
+	job := mappingValue(docs[0].Content[0], "job")
+	if job == nil {
+		t.Fatal("expected job mapping, got nil")
+	}
+
+	if got := mappingValue(job, "runs-on"); got == nil || got.Value != "ubuntu-latest" {
+		t.Errorf("expected merged runs-on to be ubuntu-latest, got %v", got)
+	}
+	if got := mappingValue(job, "timeout-minutes"); got == nil || got.Value != "30" {
+		t.Errorf("expected local timeout-minutes to override merged value, got %v", got)
+	}
+}
+
+// --- Tests for GitHubWorkFlowScanner.ScanContent on YAML workflows ---
+
+func TestGitHubWorkFlowScanner_ScanContentYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "anchor merged into multiple jobs is reported once",
+			content: `
+x-defaults: &defaults
+  steps:
+    - uses: actions/checkout@v4
+jobs:
+  build:
+    <<: *defaults
+    runs-on: ubuntu-latest
+  test:
+    <<: *defaults
+    runs-on: ubuntu-latest
+`,
+			expected: []string{"actions/checkout@v4"},
+		},
+		{
+			name: "reference only reachable through an alias",
+			content: `
+jobs:
+  build:
+    steps: &steps
+      - uses: actions/setup-go@v5
+  test:
+    steps: *steps
+`,
+			expected: []string{"actions/setup-go@v5"},
+		},
+		{
+			name: "every document of a multi-document file is scanned",
+			content: `
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+---
+jobs:
+  lint:
+    steps:
+      - uses: docker/login-action@master
+`,
+			expected: []string{"actions/checkout@v4", "docker/login-action@master"},
+		},
+		{
+			name: "distinct usages of the same action are all reported",
+			content: `
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+  test:
+    steps:
+      - uses: actions/checkout@v4
+`,
+			expected: []string{"actions/checkout@v4", "actions/checkout@v4"},
+		},
+		{
+			name:     "invalid yaml falls back to text scan",
+			content:  "uses: actions/checkout@v4\n  bad: [",
+			expected: []string{"actions/checkout@v4"},
+		},
+	}
+
+	scanner := GitHubWorkFlowScanner{}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := scanner.ScanContent([]byte(tc.content), mutableRefRegex)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := matchValues(matches); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("ScanContent() = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+// --- Tests for markdownYAMLBlocks ---
+
+func TestMarkdownYAMLBlocks(t *testing.T) {
+	content := "# Title\r\n```yaml\r\nuses: a/b@v1\r\n```\r\n\r\n```go\r\nfmt.Println()\r\n```\r\n~~~yml\nuses: c/d@main\n~~~\n"
+	blocks := markdownYAMLBlocks([]byte(content))
+
+	expected := []string{
+		"\n\nuses: a/b@v1\n",
+		"\n\n\n\n\n\n\n\n\nuses: c/d@main\n",
+	}
+	if len(blocks) != len(expected) {
+		t.Fatalf("expected %d blocks, got %d", len(expected), len(blocks))
+	}
+	for i := range blocks {
+		if string(blocks[i]) != expected[i] {
+			t.Errorf("block %d = %q; want %q", i, blocks[i], expected[i])
+		}
+	}
+}
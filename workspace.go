@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultWorkspaceDepth is how deep below a root repositories are looked for, enough for
+// layouts such as ~/src/github.com/owner/repo
+const defaultWorkspaceDepth = 4
+
+// WorkspaceConfig sets where `scharf workspace` looks for local clones
+type WorkspaceConfig struct {
+	// Roots are the parent directories holding the clones, such as ~/src. Defaults to the
+	// current directory.
+	Roots []string `yaml:"roots"`
+	// MaxDepth is how many directories below a root clones may sit. Defaults to 4.
+	MaxDepth int `yaml:"max_depth"`
+	// Exclude lists globs of directories to skip, matched against their path relative to
+	// the root and against their name
+	Exclude []string `yaml:"exclude"`
+}
+
+// skippedWorkspaceDirs hold dependencies rather than clones
+var skippedWorkspaceDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// WorkspaceVCS discovers the git repositories anywhere below a root, up to MaxDepth
+// directories deep, rather than only its direct entries like GitHubVCS. Repositories are
+// named by their path relative to the root.
+type WorkspaceVCS struct {
+	MaxDepth int
+	Exclude  []string
+}
+
+func (w WorkspaceVCS) ListRepositories(root string) ([]Repository, error) {
+	depth := w.MaxDepth
+	if depth <= 0 {
+		depth = defaultWorkspaceDepth
+	}
+
+	var rs []Repository
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			logger.Warn("skipping workspace entry", "entry", p, "err", err)
+			return fs.SkipDir
+		}
+		// Symlinked directories are not followed, so no clone is scanned twice.
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && w.skip(rel, d.Name()) {
+			return fs.SkipDir
+		}
+
+		// Worktrees and submodules have a .git file rather than a directory.
+		if _, err := os.Stat(filepath.Join(p, ".git")); err == nil {
+			name := rel
+			if rel == "." {
+				name = filepath.Base(root)
+			}
+			rs = append(rs, &GitRepository{name: name, localPath: p})
+			// Repositories nested in a clone are its submodules or vendored copies.
+			return fs.SkipDir
+		}
+		if IsBareRepo(p) {
+			name := rel
+			if rel == "." {
+				name = filepath.Base(root)
+			}
+			rs = append(rs, &GitRepository{name: name, localPath: p, bare: true})
+			return fs.SkipDir
+		}
+		if rel != "." && strings.Count(rel, "/")+1 >= depth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("os: %w", err)
+	}
+	return rs, nil
+}
+
+// skip tells whether a directory below the root is left out of the discovery
+func (w WorkspaceVCS) skip(rel, name string) bool {
+	if strings.HasPrefix(name, ".") || skippedWorkspaceDirs[name] || !shouldIncludeDir(name) {
+		return true
+	}
+	for _, glob := range w.Exclude {
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceRepo is the result of the scan of one clone of a workspace
+type WorkspaceRepo struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	// MutableRefs counts the references to an action that can change
+	MutableRefs int `json:"mutable_refs"`
+	Findings    int `json:"findings"`
+	// Highest is the severity of the most severe finding, or "" without findings
+	Highest string             `json:"highest_severity,omitempty"`
+	Records []*InventoryRecord `json:"records"`
+}
+
+// scanWorkspace scans the checked out branch of every repository the VCS of the scanner
+// discovers below the roots. Branches are never switched, so work in progress in the
+// clones is left alone. Repositories without issues are reported too.
+func scanWorkspace(sc Scanner, roots []string, regex *regexp.Regexp) ([]WorkspaceRepo, error) {
+	results := []WorkspaceRepo{}
+	for _, root := range roots {
+		absolutePath, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("filepath: %w", err)
+		}
+		repos, err := sc.VCS.ListRepositories(absolutePath)
+		if err != nil {
+			return nil, err
+		}
+		records := sc.scanEach(len(repos), func(i int) string { return repos[i].Name() }, func(i int) []*InventoryRecord {
+			logger.Debug("Processing the repo:", "repo", repos[i].Name(), "path", repos[i].Location())
+			return sc.ScanBranch("HEAD", repos[i], regex, workflowDir(repos[i].Location()))
+		})
+		for i, repo := range repos {
+			inv := &Inventory{Records: records[i]}
+			sc.Risk.Rank(inv)
+			results = append(results, summarizeWorkspaceRepo(repo, inv.Records))
+		}
+	}
+	return results, nil
+}
+
+// summarizeWorkspaceRepo counts the issues of the records of a repository
+func summarizeWorkspaceRepo(repo Repository, records []*InventoryRecord) WorkspaceRepo {
+	wr := WorkspaceRepo{Repository: repo.Name(), Path: repo.Location(), Records: records}
+	if wr.Records == nil {
+		wr.Records = []*InventoryRecord{}
+	}
+	for _, ir := range records {
+		wr.MutableRefs += len(ir.Locations)
+		wr.Findings += len(ir.Findings)
+		for _, f := range ir.Findings {
+			if severityRank[f.Severity] > severityRank[wr.Highest] {
+				wr.Highest = f.Severity
+			}
+		}
+	}
+	return wr
+}
+
+// expandHome replaces a leading ~ of a path with the home directory of the user
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		logger.Warn("could not find the home directory", "err", err)
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
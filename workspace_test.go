@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeClone creates an empty git repository, holding the given files, below root
+func makeClone(t *testing.T, root, rel string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, filepath.FromSlash(rel))
+	CheckIfError(os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		CheckIfError(os.MkdirAll(filepath.Dir(p), 0o755))
+		CheckIfError(os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+// --- Tests for WorkspaceVCS.ListRepositories ---
+
+func TestWorkspaceVCS_ListRepositories(t *testing.T) {
+	root := t.TempDir()
+	makeClone(t, root, "api", nil)
+	makeClone(t, root, "api/third_party/lib", nil)
+	makeClone(t, root, "github.com/acme/web", nil)
+	makeClone(t, root, "a/b/c/d/too-deep", nil)
+	makeClone(t, root, "node_modules/pkg", nil)
+	makeClone(t, root, ".cache/tool", nil)
+	makeClone(t, root, "archive/old", nil)
+	// A worktree has a .git file pointing to the repository.
+	CheckIfError(os.MkdirAll(filepath.Join(root, "worktrees", "api-fix"), 0o755))
+	CheckIfError(os.WriteFile(filepath.Join(root, "worktrees", "api-fix", ".git"), []byte("gitdir: ../../api/.git/worktrees/api-fix\n"), 0o644))
+
+	repos, err := WorkspaceVCS{Exclude: []string{"archive"}}.ListRepositories(root)
+	CheckIfError(err)
+
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name())
+		if r.Location() != filepath.Join(root, filepath.FromSlash(r.Name())) {
+			t.Errorf("unexpected location %s of %s", r.Location(), r.Name())
+		}
+	}
+	if strings.Join(names, ",") != "api,github.com/acme/web,worktrees/api-fix" {
+		t.Errorf("unexpected repositories %v", names)
+	}
+
+	repos, err = WorkspaceVCS{MaxDepth: 5}.ListRepositories(root)
+	CheckIfError(err)
+	found := false
+	for _, r := range repos {
+		found = found || r.Name() == "a/b/c/d/too-deep"
+	}
+	if !found {
+		t.Errorf("expected a deeper search to find a/b/c/d/too-deep")
+	}
+
+	// A root that is itself a clone is named after its directory.
+	repos, err = WorkspaceVCS{}.ListRepositories(filepath.Join(root, "api"))
+	CheckIfError(err)
+	if len(repos) != 1 || repos[0].Name() != "api" {
+		t.Errorf("unexpected repositories %+v", repos)
+	}
+}
+
+// --- Tests for scanWorkspace ---
+
+func TestScanWorkspace(t *testing.T) {
+	root := t.TempDir()
+	makeClone(t, root, "acme/api", map[string]string{
+		".github/workflows/ci.yml": "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/setup-go@v5\n",
+	})
+	makeClone(t, root, "acme/docs", nil)
+
+	sc := Scanner{FileScanner: GitHubWorkFlowScanner{}, VCS: WorkspaceVCS{}}
+	results, err := scanWorkspace(sc, []string{root}, mutableRefRegex)
+	CheckIfError(err)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 repositories, got %+v", results)
+	}
+	api, docs := results[0], results[1]
+	if api.Repository != "acme/api" || api.MutableRefs != 2 || len(api.Records) != 1 {
+		t.Errorf("unexpected result for acme/api %+v", api)
+	}
+	if docs.Repository != "acme/docs" || docs.MutableRefs != 0 || docs.Records == nil {
+		t.Errorf("expected acme/docs without issues, got %+v", docs)
+	}
+}
+
+// --- Tests for summarizeWorkspaceRepo ---
+
+func TestSummarizeWorkspaceRepo(t *testing.T) {
+	records := []*InventoryRecord{
+		{Locations: []Match{{Value: "@v4"}}, Findings: []Finding{{Severity: SeverityLow}, {Severity: SeverityHigh}}},
+		{Findings: []Finding{{Severity: SeverityMedium}}},
+	}
+	wr := summarizeWorkspaceRepo(GitRepository{name: "api", localPath: "/src/api"}, records)
+	if wr.MutableRefs != 1 || wr.Findings != 3 || wr.Highest != SeverityHigh || wr.Path != "/src/api" {
+		t.Errorf("unexpected summary %+v", wr)
+	}
+}
+
+// --- Tests for expandHome ---
+
+func TestExpandHome(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	tests := []struct {
+		in, expected string
+	}{
+		{"~", "/home/dev"},
+		{"~/src", "/home/dev/src"},
+		{"/srv/src", "/srv/src"},
+		{"~dev/src", "~dev/src"},
+	}
+	for _, tt := range tests {
+		if got := expandHome(tt.in); got != tt.expected {
+			t.Errorf("expandHome(%q) = %q, expected %q", tt.in, got, tt.expected)
+		}
+	}
+}